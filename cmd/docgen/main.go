@@ -0,0 +1,115 @@
+// Command docgen generates reference documentation for Flow components from
+// the Go structs and River tags of their registered Arguments and Exports.
+//
+// By default, docgen prints the generated documentation for the named
+// components to stdout:
+//
+//	go run ./cmd/docgen local.file
+//
+// Pass -out to instead write one file per component into a directory. When
+// no components are named, documentation is generated for every registered
+// component.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/agent/pkg/flow/componentdocs"
+
+	_ "github.com/grafana/agent/component/all" // Register all components
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		format          = flag.String("format", "markdown", "Output format. One of markdown or json.")
+		outDir          = flag.String("out", "", "Directory to write one file per component into. Output is written to stdout if empty.")
+		withDescription = flag.Bool("descriptions", true, "Fill in descriptions from the doc comments of the Go source code.")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: docgen [flags] [component...]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var write func(io.Writer, componentdocs.Component) error
+	switch *format {
+	case "markdown":
+		write = componentdocs.WriteMarkdown
+	case "json":
+		write = func(w io.Writer, c componentdocs.Component) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(c)
+		}
+	default:
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+
+	var components []componentdocs.Component
+	if flag.NArg() == 0 {
+		components = componentdocs.All()
+	}
+	for _, name := range flag.Args() {
+		c, ok := componentdocs.Get(name)
+		if !ok {
+			return fmt.Errorf("component %q does not exist", name)
+		}
+		components = append(components, c)
+	}
+
+	if *withDescription {
+		var dl componentdocs.DescriptionLoader
+		for i := range components {
+			if err := dl.Load(&components[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if *outDir == "" {
+		for _, c := range components {
+			if err := write(os.Stdout, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		return err
+	}
+	for _, c := range components {
+		ext := ".md"
+		if *format == "json" {
+			ext = ".json"
+		}
+		if err := writeFile(filepath.Join(*outDir, c.Name+ext), c, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(path string, c componentdocs.Component, write func(io.Writer, componentdocs.Component) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, c); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
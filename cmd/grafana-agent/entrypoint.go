@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -18,8 +19,10 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
 	"github.com/grafana/agent/pkg/config"
+	"github.com/grafana/agent/pkg/config/features"
 	"github.com/grafana/agent/pkg/logs"
 	"github.com/grafana/agent/pkg/metrics"
+	"github.com/grafana/agent/pkg/metrics/cluster/configapi"
 	"github.com/grafana/agent/pkg/metrics/instance"
 	"github.com/grafana/agent/pkg/server"
 	"github.com/grafana/agent/pkg/supportbundle"
@@ -236,6 +239,63 @@ func (ep *Entrypoint) wire(mux *mux.Router, grpc *grpc.Server) {
 	mux.HandleFunc("/-/reload", ep.reloadHandler).Methods("GET", "POST")
 
 	mux.HandleFunc("/-/support", ep.supportHandler).Methods("GET")
+
+	mux.HandleFunc("/agent/api/v1/features", ep.listFeaturesHandler).Methods("GET")
+	mux.HandleFunc("/agent/api/v1/features/{name}", ep.toggleFeatureHandler).Methods("PUT", "POST")
+}
+
+func (ep *Entrypoint) listFeaturesHandler(rw http.ResponseWriter, _ *http.Request) {
+	ep.mut.Lock()
+	ff := ep.cfg.Features
+	ep.mut.Unlock()
+
+	if err := configapi.WriteResponse(rw, http.StatusOK, ff); err != nil {
+		level.Error(ep.log).Log("msg", "failed to write response", "err", err)
+	}
+}
+
+// toggleFeatureHandler overrides the state of a toggleable feature and reloads
+// the config so the change takes effect. The override is reverted if the
+// reload fails. Overrides are kept in memory and don't persist across
+// restarts.
+func (ep *Entrypoint) toggleFeatureHandler(rw http.ResponseWriter, r *http.Request) {
+	name := features.Feature(mux.Vars(r)["name"])
+
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		_ = configapi.WriteError(rw, http.StatusBadRequest, fmt.Errorf(`request body must be a JSON object with an "enabled" boolean`))
+		return
+	}
+
+	prevEnabled, hadOverride := config.FeatureToggles.Override(name)
+	if err := config.FeatureToggles.Set(name, *req.Enabled); err != nil {
+		_ = configapi.WriteError(rw, http.StatusBadRequest, err)
+		return
+	}
+
+	level.Info(ep.log).Log(
+		"msg", "feature toggled at runtime",
+		"feature", name,
+		"enabled", *req.Enabled,
+		"remote_addr", r.RemoteAddr,
+		"user_agent", r.UserAgent(),
+	)
+
+	if !ep.TriggerReload() {
+		if hadOverride {
+			_ = config.FeatureToggles.Set(name, prevEnabled)
+		} else {
+			config.FeatureToggles.Clear(name)
+		}
+		level.Warn(ep.log).Log("msg", "reverted feature toggle after failed reload", "feature", name)
+
+		_ = configapi.WriteError(rw, http.StatusInternalServerError, fmt.Errorf("failed to reload config; feature %q was not changed", name))
+		return
+	}
+
+	ep.listFeaturesHandler(rw, r)
 }
 
 func (ep *Entrypoint) reloadHandler(rw http.ResponseWriter, r *http.Request) {
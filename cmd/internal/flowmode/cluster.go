@@ -0,0 +1,97 @@
+package flowmode
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/cluster/gossip"
+	"github.com/rfratto/ckit/peer"
+	"google.golang.org/grpc"
+)
+
+// buildGossipNode creates an unstarted GossipNode from the clustering flags.
+// The node registers itself to srv, which must be served on the same address
+// as the HTTP server.
+func (fr *flowRun) buildGossipNode(l log.Logger, srv *grpc.Server) (*gossip.GossipNode, error) {
+	_, portStr, err := net.SplitHostPort(fr.httpListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTP listen address: %w", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTP listen port: %w", err)
+	}
+
+	cfg := gossip.DefaultGossipConfig
+	cfg.NodeName = fr.clusterNodeName
+	cfg.AdvertiseAddr = fr.clusterAdvAddr
+	cfg.DiscoverPeers = fr.clusterDiscoverPeers
+	if fr.clusterJoinAddr != "" {
+		cfg.JoinPeers = strings.Split(fr.clusterJoinAddr, ",")
+	}
+
+	if err := cfg.ApplyDefaults(port); err != nil {
+		return nil, fmt.Errorf("invalid clustering settings: %w", err)
+	}
+	return gossip.NewGossipNode(l, srv, &cfg)
+}
+
+// startGossipNode joins the cluster and marks node as a participant so it
+// is assigned work. Joining is retried until it succeeds or ctx is canceled,
+// allowing agents in a cluster to start in any order.
+func startGossipNode(ctx context.Context, l log.Logger, node *gossip.GossipNode) {
+	for {
+		err := node.Start()
+		if err == nil {
+			break
+		}
+		level.Warn(l).Log("msg", "failed to join cluster; retrying", "err", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	if err := node.ChangeState(ctx, peer.StateParticipant); err != nil {
+		level.Error(l).Log("msg", "failed to become a cluster participant", "err", err)
+		return
+	}
+	level.Info(l).Log("msg", "joined cluster", "peers", len(node.Peers()))
+}
+
+// stopGossipNode gives node an opportunity to move its work to other peers
+// before leaving the cluster.
+func stopGossipNode(l log.Logger, node *gossip.GossipNode) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if node.CurrentState() == peer.StateParticipant {
+		if err := node.ChangeState(ctx, peer.StateTerminating); err != nil {
+			level.Warn(l).Log("msg", "failed to announce leaving the cluster", "err", err)
+		}
+	}
+	if err := node.Stop(); err != nil {
+		level.Warn(l).Log("msg", "failed to leave cluster", "err", err)
+	}
+}
+
+// grpcHandler routes gRPC requests to grpcSrv and all other requests to
+// next, allowing clustering traffic to share the HTTP server.
+func grpcHandler(grpcSrv *grpc.Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcSrv.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
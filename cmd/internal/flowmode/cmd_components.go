@@ -0,0 +1,79 @@
+package flowmode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/agent/pkg/flow/componentdocs"
+)
+
+func componentsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "components <subcommand>",
+		Short: "Inspect the available components",
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(
+		describeCommand(),
+	)
+	return cmd
+}
+
+func describeCommand() *cobra.Command {
+	d := &componentDescriber{
+		format: "river",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "describe [flags] component",
+		Short: "Show the default arguments of a component",
+		Long: `The describe subcommand writes the arguments and blocks of the named
+component along with their default values to stdout.
+
+The defaults are computed from the registered Arguments type of the
+component, so they're the effective values of every argument which isn't
+set in the configuration file. By default, the output is a River block
+where arguments without a default are listed as comments. Pass
+--format=json to write the full schema of the component as JSON instead,
+which includes its exported fields.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			return d.Run(args[0], os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringVarP(&d.format, "format", "f", d.format, "Output format. One of river or json.")
+	return cmd
+}
+
+type componentDescriber struct {
+	format string
+}
+
+func (d *componentDescriber) Run(name string, w io.Writer) error {
+	c, ok := componentdocs.Get(name)
+	if !ok {
+		return fmt.Errorf("component %q does not exist", name)
+	}
+
+	switch d.format {
+	case "river":
+		return componentdocs.WriteRiver(w, c)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(c)
+	default:
+		return fmt.Errorf("unsupported format %q", d.format)
+	}
+}
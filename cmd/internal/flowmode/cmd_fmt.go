@@ -12,11 +12,13 @@ import (
 	"github.com/grafana/agent/pkg/river/diag"
 	"github.com/grafana/agent/pkg/river/parser"
 	"github.com/grafana/agent/pkg/river/printer"
+	"github.com/grafana/agent/pkg/river/token"
 )
 
 func fmtCommand() *cobra.Command {
 	f := &flowFmt{
-		write: false,
+		write:             false,
+		diagnosticsFormat: diagnosticsFormatText,
 	}
 
 	cmd := &cobra.Command{
@@ -27,12 +29,20 @@ River configuration file.
 
 If the file argument is not supplied or if the file argument is "-", then fmt will read from stdin.
 
-The -w flag can be used to write the formatted file back to disk. -w can not be provided when fmt is reading from stdin. When -w is not provided, fmt will write the result to stdout.`,
+The -w flag can be used to write the formatted file back to disk. -w can not be provided when fmt is reading from stdin. When -w is not provided, fmt will write the result to stdout.
+
+The --check flag can be used to check whether the file is already formatted instead of printing the result. fmt exits with an error if the file isn't formatted.
+
+Diagnostics are printed as text by default. Pass --diagnostics-format=json or --diagnostics-format=sarif to write machine-readable diagnostics to stdout instead.`,
 		Args:         cobra.RangeArgs(0, 1),
 		SilenceUsage: true,
 		Aliases:      []string{"format"},
 
 		RunE: func(_ *cobra.Command, args []string) error {
+			if err := checkDiagnosticsFormat(f.diagnosticsFormat); err != nil {
+				return err
+			}
+
 			var err error
 
 			if len(args) == 0 {
@@ -43,32 +53,51 @@ The -w flag can be used to write the formatted file back to disk. -w can not be
 			}
 
 			var diags diag.Diagnostics
-			if errors.As(err, &diags) {
-				for _, diag := range diags {
-					fmt.Fprintln(os.Stderr, diag)
+			if err != nil && !errors.As(err, &diags) {
+				return err
+			}
+			// The formatted file is written to stdout unless --check is provided,
+			// so machine-readable diagnostics are only written when there are
+			// some to report.
+			if f.check || len(diags) > 0 {
+				if err := writeDiagnostics(f.diagnosticsFormat, f.files, diags); err != nil {
+					return err
 				}
+			}
+			if diags.HasErrors() {
 				return fmt.Errorf("encountered errors during formatting")
 			}
-
-			return err
+			return nil
 		},
 	}
 
 	cmd.Flags().BoolVarP(&f.write, "write", "w", f.write, "write result to (source) file instead of stdout")
+	cmd.Flags().BoolVar(&f.check, "check", f.check, "check whether the file is formatted instead of printing the result")
+	cmd.Flags().StringVar(&f.diagnosticsFormat, "diagnostics-format", f.diagnosticsFormat, diagnosticsFormatUsage)
 	return cmd
 }
 
 type flowFmt struct {
-	write bool
+	write             bool
+	check             bool
+	diagnosticsFormat string
+
+	// files holds the contents of the files read by Run, used for printing
+	// diagnostics.
+	files map[string][]byte
 }
 
 func (ff *flowFmt) Run(configFile string) error {
+	if ff.write && ff.check {
+		return fmt.Errorf("cannot use -w with --check")
+	}
+
 	switch configFile {
 	case "-":
 		if ff.write {
 			return fmt.Errorf("cannot use -w with standard input")
 		}
-		return format("<stdin>", nil, os.Stdin, false)
+		return ff.format("<stdin>", nil, os.Stdin)
 
 	default:
 		fi, err := os.Stat(configFile)
@@ -84,15 +113,16 @@ func (ff *flowFmt) Run(configFile string) error {
 			return err
 		}
 		defer f.Close()
-		return format(configFile, fi, f, ff.write)
+		return ff.format(configFile, fi, f)
 	}
 }
 
-func format(filename string, fi os.FileInfo, r io.Reader, write bool) error {
+func (ff *flowFmt) format(filename string, fi os.FileInfo, r io.Reader) error {
 	bb, err := io.ReadAll(r)
 	if err != nil {
 		return err
 	}
+	ff.files = map[string][]byte{filename: bb}
 
 	f, err := parser.ParseFile(filename, bb)
 	if err != nil {
@@ -107,7 +137,18 @@ func format(filename string, fi os.FileInfo, r io.Reader, write bool) error {
 	// Add a newline at the end of the file.
 	_, _ = buf.Write([]byte{'\n'})
 
-	if !write {
+	if ff.check {
+		if line, ok := firstDifferentLine(bb, buf.Bytes()); ok {
+			return diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: token.Position{Filename: filename, Line: line, Column: 1},
+				Message:  "file is not formatted; run the fmt command with -w to format it",
+			}
+		}
+		return nil
+	}
+
+	if !ff.write {
 		_, err := io.Copy(os.Stdout, &buf)
 		return err
 	}
@@ -121,3 +162,30 @@ func format(filename string, fi os.FileInfo, r io.Reader, write bool) error {
 	_, err = io.Copy(wf, &buf)
 	return err
 }
+
+// firstDifferentLine returns the 1-indexed number of the first line which
+// differs between a and b. firstDifferentLine returns false if a and b are
+// identical.
+func firstDifferentLine(a, b []byte) (int, bool) {
+	if bytes.Equal(a, b) {
+		return 0, false
+	}
+
+	var (
+		linesA = bytes.Split(a, []byte{'\n'})
+		linesB = bytes.Split(b, []byte{'\n'})
+	)
+	for i := 0; i < len(linesA) && i < len(linesB); i++ {
+		if !bytes.Equal(linesA[i], linesB[i]) {
+			return i + 1, true
+		}
+	}
+
+	// One file is a prefix of the other; report the line where the shorter one
+	// ends.
+	n := len(linesA)
+	if len(linesB) < n {
+		n = len(linesB)
+	}
+	return n, true
+}
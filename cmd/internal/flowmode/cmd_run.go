@@ -1,16 +1,20 @@
 package flowmode
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
-	"net"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
 	"path"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/grafana/agent/web/api"
 	"github.com/grafana/agent/web/ui"
@@ -18,18 +22,31 @@ import (
 	"golang.org/x/exp/maps"
 
 	"github.com/fatih/color"
+	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
-	"github.com/grafana/agent/pkg/config/instrumentation"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/cluster/gossip"
+	"github.com/grafana/agent/pkg/crash"
 	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/limits"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/handover"
 	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/upgrade"
 	"github.com/grafana/agent/pkg/usagestats"
+	"github.com/grafana/agent/pkg/util/signature"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.uber.org/atomic"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
 
 	// Install Components
 	_ "github.com/grafana/agent/component/all"
@@ -41,6 +58,19 @@ func runCommand() *cobra.Command {
 		storagePath:      "data-agent/",
 		uiPrefix:         "/",
 		disableReporting: false,
+
+		configPollFrequency: time.Minute,
+		configWatchDebounce: time.Second,
+		configMaxComponents: limits.DefaultOptions.MaxComponents,
+
+		moduleMaxDepth:      limits.DefaultOptions.MaxModuleDepth,
+		moduleMaxComponents: limits.DefaultOptions.MaxModuleComponents,
+
+		upgradeCheckInterval: upgrade.DefaultCheckerOptions.Interval,
+		upgradeReleasesURL:   upgrade.DefaultReleasesURL,
+		upgradeAssetName:     upgrade.DefaultAssetName(),
+
+		dryRunDiscoveryTimeout: 10 * time.Second,
 	}
 
 	cmd := &cobra.Command{
@@ -53,21 +83,113 @@ run must be provided an argument pointing at the River file to use. If the
 River file wasn't specified, can't be loaded, or contains errors, run will exit
 immediately.
 
+The River file may be a local path or a URL with an http, https, s3, or gs
+scheme. If a local directory is provided, its .river files are merged in
+lexical order of their names. Remote River files are polled for changes every
+--config.poll-frequency. If a changed remote file fails to load, the last
+River file which loaded successfully is restored. When --config.watch is
+provided, a local River file is reloaded when it changes on disk, once no
+further changes were made for --config.watch-debounce. When
+--config.watch-files
+is provided, files read by the file function are also checked for changes
+every --config.poll-frequency, and the River file is reloaded when one of
+them changes. When
+--config.public-key-file is provided, the River file must have a detached
+ed25519 signature at the same location with a .sig suffix.
+
 run starts an HTTP server which can be used to debug Grafana Agent Flow or
 force it to reload (by sending a GET or POST request to /-/reload). The listen
 address can be changed through the --server.http.listen-addr flag.
 
+The /-/ready endpoint reports the agent as ready once the River file has
+loaded. When --server.http.ready-components is provided, the listed
+components must also be healthy, so that traffic isn't sent to an agent whose
+critical pipeline is broken.
+
 By default, the HTTP server exposes a debugging UI at /. The path of the
 debugging UI can be changed by providing a different value to
 --server.http.ui-path-prefix.
 
 Additionally, the HTTP server exposes the following debug endpoints:
 
-  /debug/pprof   Go performance profiling tools
+  /debug/pprof              Go performance profiling tools
+  /debug/tap/{id}           Stream a sample of records flowing through a component
+  /debug/capture/{id}       Record the data received by a component for "tools replay"
+  /debug/evaluations/{id}   List recent re-evaluations of a component and their causes
+  /-/version                Report the running version against the latest release
 
 If reloading the config file fails, Grafana Agent Flow will continue running in
 its last valid state. Components which failed may be be listed as unhealthy,
 depending on the nature of the reload error.
+
+When --cluster.enabled is provided, the agent joins a cluster of agents over
+gossip. Cluster traffic is served over the same address as the HTTP server.
+Peers to join can be provided with --cluster.join-addresses or discovered with
+--cluster.discover-peers. Components such as prometheus.scrape can then
+distribute work across agents in the cluster.
+
+When --upgrade.check is provided, the agent periodically checks for the
+latest release of Grafana Agent. When --upgrade.managed is also provided,
+sending a POST request to /-/upgrade downloads the latest release, verifies
+it against the key in --upgrade.public-key-file, replaces the running binary,
+and restarts the agent with the same arguments.
+
+Limits protect the agent from configs which load too many components, such as
+a module which loads itself. Modules can be nested up to --module.max-depth
+levels deep, a single module can define up to --module.max-components
+components, and the config file and all modules can define up to
+--config.max-components components in total. Configs which exceed a limit
+fail to load.
+
+When --config.export-debounce is provided, components which depend on a
+component whose exports change frequently, such as a busy discovery component,
+are re-evaluated at most once per debounce window. Export changes within the
+window are coalesced, and dependants are re-evaluated with the latest exports
+at the end of the window.
+
+When --config.max-evaluation-duration is provided, building or updating a
+component which takes longer than the given duration is treated as a failed
+evaluation once it completes, and loading the config fails. Components can't
+be interrupted, so slow evaluations aren't shortened, but they don't go
+unnoticed.
+
+When the agent receives an interrupt or SIGTERM, components are shut down in
+the order data flows through them: components which receive data are stopped
+first, so that the components they forward data to can flush buffered data
+before they stop themselves. When --shutdown.timeout is provided, components
+which haven't stopped after the timeout are logged and the agent exits without
+waiting for them.
+
+When --handover.enabled is provided, sending SIGUSR2 to the agent restarts it
+without closing its listening sockets: a new process is started which
+inherits the sockets of the HTTP server and of components which support
+handover, and waits for the current process to shut down before loading the
+River file. Connections made in the meantime are queued instead of refused.
+Managed upgrades restart the agent the same way.
+
+When --read-only is provided, the HTTP server rejects requests which would
+change the state of the process: /-/reload and /-/upgrade are disabled and
+component HTTP endpoints only accept GET, HEAD, and OPTIONS requests.
+Components which can execute commands on the host are refused when loading
+the config file. The config file can still be reloaded by sending SIGHUP to
+the process.
+
+When --crash-report.enabled is provided, the agent writes a crash report to
+the crash-reports directory inside --storage.path when it panics. Crash
+reports contain build information, a dump of all goroutines, and the most
+recent log lines. When --crash-report.memory-threshold-bytes is provided, a
+crash report is also written when the resident memory of the agent crosses
+the threshold, since the agent may be killed for running out of memory soon
+after. Crash reports are also sent to --crash-report.upload-url, if provided.
+
+When --dry-run is provided, the River file is loaded without sending any data
+and the agent exits with a JSON report on stdout instead of running. Only
+discovery components are run, until they all found targets or
+--dry-run.discovery-timeout passed. Sample inputs listed in
+--dry-run.inputs-file are then processed by the components they name. The
+report lists the health of components, the targets found, the result of each
+input, and the destinations data would be sent to. run exits with an error if
+a component is unhealthy or an input failed to be processed.
 `,
 		Args:         cobra.ExactArgs(1),
 		SilenceUsage: true,
@@ -83,6 +205,78 @@ depending on the nature of the reload error.
 	cmd.Flags().StringVar(&r.uiPrefix, "server.http.ui-path-prefix", r.uiPrefix, "Prefix to serve the HTTP UI at")
 	cmd.Flags().
 		BoolVar(&r.disableReporting, "disable-reporting", r.disableReporting, "Disable reporting of enabled components to Grafana.")
+	cmd.Flags().
+		StringVar(&r.readyComponents, "server.http.ready-components", r.readyComponents, "Comma-separated list of component IDs which must be healthy for /-/ready to report the agent as ready")
+	cmd.Flags().
+		StringVar(&r.vulnerabilityDBFile, "server.http.vulnerability-db-file", r.vulnerabilityDBFile, "Path to a JSON array of OSV entries to evaluate the SBOM served at /api/v0/sbom against")
+	cmd.Flags().
+		BoolVar(&r.readOnly, "read-only", r.readOnly, "Disable HTTP endpoints which mutate state, such as /-/reload, and components which can execute commands.")
+	cmd.Flags().
+		DurationVar(&r.configPollFrequency, "config.poll-frequency", r.configPollFrequency, "How often to check a remote config file or watched files for changes")
+	cmd.Flags().
+		BoolVar(&r.configWatch, "config.watch", r.configWatch, "Reload a local config file when it changes on disk")
+	cmd.Flags().
+		DurationVar(&r.configWatchDebounce, "config.watch-debounce", r.configWatchDebounce, "How long to wait for further changes to a watched config file before reloading it")
+	cmd.Flags().
+		BoolVar(&r.configWatchFiles, "config.watch-files", r.configWatchFiles, "Reload the config file when a file read by the file function changes")
+	cmd.Flags().
+		StringVar(&r.configPublicKeyFile, "config.public-key-file", r.configPublicKeyFile, "Path to the ed25519 public key used to verify the signature of the config file")
+	cmd.Flags().
+		DurationVar(&r.configExportDebounce, "config.export-debounce", r.configExportDebounce, "Minimum time between re-evaluations caused by a component's exports changing; 0 disables debouncing")
+	cmd.Flags().
+		DurationVar(&r.configMaxEvaluationDuration, "config.max-evaluation-duration", r.configMaxEvaluationDuration, "Maximum time building or updating a single component may take before its evaluation is treated as failed; evaluations aren't interrupted; 0 disables the check")
+	cmd.Flags().
+		IntVar(&r.configMaxComponents, "config.max-components", r.configMaxComponents, "Maximum number of components across the config file and all modules; 0 disables the limit")
+	cmd.Flags().
+		BoolVar(&r.handover, "handover.enabled", r.handover, "Restart by starting a new process which inherits listening sockets, on SIGUSR2 and after managed upgrades")
+	cmd.Flags().
+		DurationVar(&r.shutdownTimeout, "shutdown.timeout", r.shutdownTimeout, "Maximum time to wait for components to flush buffered data when shutting down; 0 waits indefinitely")
+	cmd.Flags().
+		IntVar(&r.moduleMaxDepth, "module.max-depth", r.moduleMaxDepth, "Maximum number of modules which can be nested inside of each other; 0 disables the limit")
+	cmd.Flags().
+		IntVar(&r.moduleMaxComponents, "module.max-components", r.moduleMaxComponents, "Maximum number of components in a single module; 0 disables the limit")
+
+	// Clustering flags
+	cmd.Flags().
+		BoolVar(&r.clusterEnabled, "cluster.enabled", r.clusterEnabled, "Start in clustered mode")
+	cmd.Flags().
+		StringVar(&r.clusterNodeName, "cluster.node-name", r.clusterNodeName, "The name to use for this node; defaults to the hostname")
+	cmd.Flags().
+		StringVar(&r.clusterAdvAddr, "cluster.advertise-address", r.clusterAdvAddr, "Address to advertise to peers; defaults to an address of the first network interface and the HTTP listen port")
+	cmd.Flags().
+		StringVar(&r.clusterJoinAddr, "cluster.join-addresses", r.clusterJoinAddr, "Comma-separated list of addresses to join the cluster at")
+	cmd.Flags().
+		StringVar(&r.clusterDiscoverPeers, "cluster.discover-peers", r.clusterDiscoverPeers, "go-discover expression used to find peers to join; mutually exclusive with --cluster.join-addresses")
+
+	// Upgrade flags
+	cmd.Flags().
+		BoolVar(&r.upgradeCheck, "upgrade.check", r.upgradeCheck, "Periodically check for the latest release of Grafana Agent")
+	cmd.Flags().
+		DurationVar(&r.upgradeCheckInterval, "upgrade.check-interval", r.upgradeCheckInterval, "How often to check for the latest release")
+	cmd.Flags().
+		StringVar(&r.upgradeReleasesURL, "upgrade.releases-url", r.upgradeReleasesURL, "URL which returns the latest release in the GitHub releases API format")
+	cmd.Flags().
+		BoolVar(&r.upgradeManaged, "upgrade.managed", r.upgradeManaged, "Allow upgrading the agent binary in place through the /-/upgrade endpoint")
+	cmd.Flags().
+		StringVar(&r.upgradePublicKeyFile, "upgrade.public-key-file", r.upgradePublicKeyFile, "Path to the ed25519 public key used to verify the signature of a release's SHA256SUMS")
+	cmd.Flags().
+		StringVar(&r.upgradeAssetName, "upgrade.asset-name", r.upgradeAssetName, "Name of the release binary to install during managed upgrades")
+
+	// Crash report flags
+	cmd.Flags().
+		BoolVar(&r.crashReportEnabled, "crash-report.enabled", r.crashReportEnabled, "Write a crash report to the storage path when the agent panics")
+	cmd.Flags().
+		Uint64Var(&r.crashReportMemoryThreshold, "crash-report.memory-threshold-bytes", r.crashReportMemoryThreshold, "Resident memory usage which triggers a crash report; 0 disables crash reports on high memory usage")
+	cmd.Flags().
+		StringVar(&r.crashReportUploadURL, "crash-report.upload-url", r.crashReportUploadURL, "URL to send crash reports to with a POST request")
+
+	// Dry run flags
+	cmd.Flags().
+		BoolVar(&r.dryRunEnabled, "dry-run", r.dryRunEnabled, "Check the config file without sending any data and exit with a report")
+	cmd.Flags().
+		StringVar(&r.dryRunInputsFile, "dry-run.inputs-file", r.dryRunInputsFile, "Path to a file of JSON lines with sample inputs for components to process during a dry run")
+	cmd.Flags().
+		DurationVar(&r.dryRunDiscoveryTimeout, "dry-run.discovery-timeout", r.dryRunDiscoveryTimeout, "Maximum time to wait for discovery components to find targets during a dry run")
 	return cmd
 }
 
@@ -91,9 +285,61 @@ type flowRun struct {
 	storagePath      string
 	uiPrefix         string
 	disableReporting bool
+	readOnly         bool
+	readyComponents  string
+
+	vulnerabilityDBFile string
+
+	configPollFrequency         time.Duration
+	configWatchFiles            bool
+	configWatch                 bool
+	configWatchDebounce         time.Duration
+	configPublicKeyFile         string
+	configMaxComponents         int
+	configExportDebounce        time.Duration
+	configMaxEvaluationDuration time.Duration
+
+	shutdownTimeout time.Duration
+	handover        bool
+
+	moduleMaxDepth      int
+	moduleMaxComponents int
+
+	clusterEnabled       bool
+	clusterNodeName      string
+	clusterAdvAddr       string
+	clusterJoinAddr      string
+	clusterDiscoverPeers string
+
+	upgradeCheck         bool
+	upgradeCheckInterval time.Duration
+	upgradeReleasesURL   string
+	upgradeManaged       bool
+	upgradePublicKeyFile string
+	upgradeAssetName     string
+
+	crashReportEnabled         bool
+	crashReportMemoryThreshold uint64
+	crashReportUploadURL       string
+
+	dryRunEnabled          bool
+	dryRunInputsFile       string
+	dryRunDiscoveryTimeout time.Duration
 }
 
-func (fr *flowRun) Run(configFile string) error {
+func (fr *flowRun) Run(configFile string) (err error) {
+	// restart is set after a managed upgrade is staged. The new binary is
+	// executed once everything else has shut down.
+	var (
+		restart  atomic.Bool
+		upgrader *upgrade.Upgrader
+	)
+	defer func() {
+		if err == nil && restart.Load() {
+			err = upgrader.Exec()
+		}
+	}()
+
 	var wg sync.WaitGroup
 	defer wg.Wait()
 
@@ -104,12 +350,41 @@ func (fr *flowRun) Run(configFile string) error {
 		return fmt.Errorf("file argument not provided")
 	}
 
-	logSink, err := logging.WriterSink(os.Stderr, logging.DefaultSinkOptions)
+	// Recent log lines are kept in memory to be included in crash reports.
+	var (
+		logWriter io.Writer = os.Stderr
+		logBuffer *crash.LogBuffer
+	)
+	if fr.crashReportEnabled {
+		logBuffer = crash.NewLogBuffer(crashReportLogLines)
+		logWriter = io.MultiWriter(os.Stderr, logBuffer)
+	}
+
+	logSink, err := logging.WriterSink(logWriter, logging.DefaultSinkOptions)
 	if err != nil {
 		return fmt.Errorf("building logger: %w", err)
 	}
 	l := logging.New(logSink)
 
+	if fr.dryRunEnabled {
+		return fr.dryRun(ctx, configFile, logSink, os.Stdout)
+	}
+
+	if fr.crashReportEnabled {
+		reporter, err := fr.buildCrashReporter(l, logBuffer)
+		if err != nil {
+			return fmt.Errorf("building crash reporter: %w", err)
+		}
+		crash.SetReporter(reporter)
+		defer crash.Recover()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reporter.Run(ctx, crashReportCheckInterval)
+		}()
+	}
+
 	t, err := tracing.New(tracing.DefaultOptions)
 	if err != nil {
 		return fmt.Errorf("building tracer: %w", err)
@@ -138,41 +413,89 @@ func (fr *flowRun) Run(configFile string) error {
 	reg := prometheus.DefaultRegisterer
 	reg.MustRegister(newResourcesCollector(l))
 
-	f := flow.New(flow.Options{
-		LogSink:        logSink,
-		Tracer:         t,
-		DataPath:       fr.storagePath,
-		Reg:            reg,
-		HTTPPathPrefix: "/api/v0/component/",
-		HTTPListenAddr: fr.httpListenAddr,
+	var (
+		grpcSrv    = grpc.NewServer()
+		gossipNode *gossip.GossipNode
+		clusterer  cluster.Node
+	)
+	if fr.clusterEnabled {
+		gossipNode, err = fr.buildGossipNode(l, grpcSrv)
+		if err != nil {
+			return fmt.Errorf("building cluster node: %w", err)
+		}
+		clusterer = gossipNode
+		defer stopGossipNode(l, gossipNode)
+	}
+
+	checker, err := upgrade.NewChecker(l, reg, upgrade.CheckerOptions{
+		ReleasesURL: fr.upgradeReleasesURL,
+		Interval:    fr.upgradeCheckInterval,
 	})
+	if err != nil {
+		return fmt.Errorf("building upgrade checker: %w", err)
+	}
+	if fr.upgradeManaged {
+		upgrader, err = fr.buildUpgrader(l)
+		if err != nil {
+			return fmt.Errorf("building upgrader: %w", err)
+		}
+	}
 
-	reload := func() error {
-		flowCfg, err := loadFlowFile(configFile)
-		defer instrumentation.InstrumentLoad(err == nil)
+	// Other agents of the cluster send requests to components through a
+	// separate route, which is only served when clustering is enabled.
+	var peerHTTPPathPrefix string
+	if clusterer != nil {
+		peerHTTPPathPrefix = peerComponentPathPrefix
+	}
 
+	f := flow.New(flow.Options{
+		LogSink:               logSink,
+		Tracer:                t,
+		DataPath:              fr.storagePath,
+		Reg:                   reg,
+		HTTPPathPrefix:        componentPathPrefix,
+		PeerHTTPPathPrefix:    peerHTTPPathPrefix,
+		HTTPListenAddr:        fr.httpListenAddr,
+		Cluster:               clusterer,
+		ExportDebounce:        fr.configExportDebounce,
+		ShutdownTimeout:       fr.shutdownTimeout,
+		MaxEvaluationDuration: fr.configMaxEvaluationDuration,
+		ReadOnly:              fr.readOnly,
+		Limits: limits.New(limits.Options{
+			MaxModuleDepth:      fr.moduleMaxDepth,
+			MaxComponents:       fr.configMaxComponents,
+			MaxModuleComponents: fr.moduleMaxComponents,
+		}),
+	})
+
+	var configKey ed25519.PublicKey
+	if fr.configPublicKeyFile != "" {
+		configKey, err = signature.ReadPublicKey(fr.configPublicKeyFile)
 		if err != nil {
-			return fmt.Errorf("reading config file %q: %w", configFile, err)
+			return fmt.Errorf("loading config public key: %w", err)
 		}
-		if err := f.LoadFile(flowCfg, nil); err != nil {
-			return fmt.Errorf("error during the initial gragent load: %w", err)
-		}
-
-		return nil
 	}
+	source, err := newConfigSource(configFile, configKey)
+	if err != nil {
+		return err
+	}
+	loader := &configLoader{log: l, source: source, flow: f, watchFiles: fr.configWatchFiles}
+
+	reload := func() error { return loader.Reload(ctx) }
 
 	// Flow controller
 	{
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			defer crash.Recover()
 			f.Run(ctx)
 		}()
 	}
 
 	// HTTP server
 	{
-		lis, err := net.Listen("tcp", fr.httpListenAddr)
+		lis, err := handover.Listen("tcp", fr.httpListenAddr)
 		if err != nil {
 			return fmt.Errorf("failed to listen on %s: %w", fr.httpListenAddr, err)
 		}
@@ -185,19 +508,21 @@ func (fr *flowRun) Run(configFile string) error {
 
 		r.Handle("/metrics", promhttp.Handler())
 		r.PathPrefix("/debug/pprof").Handler(http.DefaultServeMux)
-		r.PathPrefix("/api/v0/component/{id}/").Handler(f.ComponentHandler())
+		r.Handle("/debug/tap/{id}", f.TapHandler()).Methods(http.MethodGet)
+		r.Handle("/debug/capture/{id}", f.CaptureHandler()).Methods(http.MethodGet)
+		r.Handle("/debug/evaluations/{id}", f.EvaluationsHandler()).Methods(http.MethodGet)
+		fr.registerComponentRoutes(r, f.ComponentHandler(), clusterer)
+		r.Handle("/api/v0/inventory", build.InventoryHandler(component.AllNames())).Methods(http.MethodGet)
+		r.Handle("/api/v0/sbom", build.SBOMHandler(fr.vulnerabilityDBFile)).Methods(http.MethodGet)
 
-		r.HandleFunc("/-/ready", func(w http.ResponseWriter, _ *http.Request) {
-			if f.Ready() {
-				w.WriteHeader(http.StatusOK)
-				fmt.Fprintf(w, "Agent is Ready.\n")
-			} else {
-				w.WriteHeader(http.StatusServiceUnavailable)
-				fmt.Fprint(w, "Config failed to load.\n")
-			}
-		})
+		r.HandleFunc("/-/ready", fr.readyHandler(f))
 
 		r.HandleFunc("/-/reload", func(w http.ResponseWriter, _ *http.Request) {
+			if fr.readOnly {
+				http.Error(w, "reloading over HTTP is disabled in read-only mode", http.StatusForbidden)
+				return
+			}
+
 			level.Info(l).Log("msg", "reload requested via /-/reload endpoint")
 			defer level.Info(l).Log("msg", "config reloaded")
 
@@ -209,6 +534,29 @@ func (fr *flowRun) Run(configFile string) error {
 			fmt.Fprintln(w, "config reloaded")
 		}).Methods(http.MethodGet, http.MethodPost)
 
+		r.HandleFunc("/-/config/effective", func(w http.ResponseWriter, _ *http.Request) {
+			var buf bytes.Buffer
+			if err := f.WriteEffectiveConfig(&buf); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = buf.WriteTo(w)
+		}).Methods(http.MethodGet)
+
+		r.Handle("/-/version", checker.Handler()).Methods(http.MethodGet)
+		r.HandleFunc("/-/upgrade", fr.upgradeHandler(l, checker, upgrader, func() {
+			if fr.handover {
+				if err := startHandover(l, upgrader.Executable()); err != nil {
+					level.Error(l).Log("msg", "failed to restart with socket handover; replacing the process instead", "err", err)
+					restart.Store(true)
+				}
+			} else {
+				restart.Store(true)
+			}
+			cancel()
+		})).Methods(http.MethodPost)
+
 		// Register Routes must be the last
 		fa := api.NewFlowAPI(f, r)
 		fa.RegisterRoutes(path.Join(fr.uiPrefix, "/api/v0/web"), r)
@@ -217,7 +565,13 @@ func (fr *flowRun) Run(configFile string) error {
 		// will take precedence over anything else mapped in uiPrefix.
 		ui.RegisterRoutes(fr.uiPrefix, r)
 
-		srv := &http.Server{Handler: r}
+		var handler http.Handler = r
+		if gossipNode != nil {
+			// Cluster peers communicate over gRPC. Serve it alongside HTTP/1.1
+			// traffic on the same listener through h2c.
+			handler = h2c.NewHandler(grpcHandler(grpcSrv, r), &http2.Server{})
+		}
+		srv := &http.Server{Handler: handler}
 
 		wg.Add(1)
 		go func() {
@@ -233,6 +587,24 @@ func (fr *flowRun) Run(configFile string) error {
 		defer func() { _ = srv.Shutdown(ctx) }()
 	}
 
+	// Join the cluster once the HTTP server is running; peers can't connect to
+	// us before then.
+	if gossipNode != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			startGossipNode(ctx, l, gossipNode)
+		}()
+	}
+
+	if fr.upgradeCheck {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checker.Run(ctx)
+		}()
+	}
+
 	// Report usage of enabled components
 	if !fr.disableReporting {
 		reporter, err := usagestats.NewReporter(l)
@@ -247,20 +619,28 @@ func (fr *flowRun) Run(configFile string) error {
 		}()
 	}
 
+	// A process started by a restart with socket handover waits for the
+	// previous process to shut down so that components can listen on the same
+	// addresses.
+	if handover.Inherited() {
+		level.Info(l).Log("msg", "waiting for the previous process to exit before loading components")
+		if err := handover.WaitForParent(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Perform the initial reload. This is done after starting the HTTP server so
 	// that /metric and pprof endpoints are available while the Flow controller
 	// is loading.
 	if err := reload(); err != nil {
 		var diags diag.Diagnostics
 		if errors.As(err, &diags) {
-			bb, _ := os.ReadFile(configFile)
-
 			p := diag.NewPrinter(diag.PrinterConfig{
 				Color:              !color.NoColor,
 				ContextLinesBefore: 1,
 				ContextLinesAfter:  1,
 			})
-			_ = p.Fprint(os.Stderr, map[string][]byte{configFile: bb}, diags)
+			_ = p.Fprint(os.Stderr, loader.LastRead(), diags)
 
 			// Print newline after the diagnostics.
 			fmt.Println()
@@ -272,10 +652,36 @@ func (fr *flowRun) Run(configFile string) error {
 		return err
 	}
 
+	// Sockets for addresses which are no longer in the config aren't needed.
+	handover.CloseUnclaimed()
+
+	// Remote config files and files read by the config are polled for changes.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		loader.Poll(ctx, fr.configPollFrequency)
+	}()
+
+	if fr.configWatch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := loader.Watch(ctx, fr.configWatchDebounce); err != nil {
+				level.Error(l).Log("msg", "failed to watch config file", "err", err)
+			}
+		}()
+	}
+
 	reloadSignal := make(chan os.Signal, 1)
 	signal.Notify(reloadSignal, syscall.SIGHUP)
 	defer signal.Stop(reloadSignal)
 
+	restartSignal := make(chan os.Signal, 1)
+	if fr.handover && len(handover.RestartSignals) > 0 {
+		signal.Notify(restartSignal, handover.RestartSignals...)
+		defer signal.Stop(restartSignal)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -286,10 +692,113 @@ func (fr *flowRun) Run(configFile string) error {
 			} else {
 				level.Info(l).Log("msg", "config reloaded")
 			}
+		case <-restartSignal:
+			exe, err := os.Executable()
+			if err == nil {
+				err = startHandover(l, exe)
+			}
+			if err != nil {
+				level.Error(l).Log("msg", "failed to restart with socket handover", "err", err)
+				continue
+			}
+			// Returning shuts down the components, after which the new process
+			// starts loading its own.
+			return nil
+		}
+	}
+}
+
+// startHandover starts the binary at path as a new process which inherits
+// the listening sockets of the current process.
+func startHandover(l log.Logger, path string) error {
+	proc, err := handover.Restart(path)
+	if err != nil {
+		return err
+	}
+	level.Info(l).Log("msg", "started new process which inherits listening sockets; shutting down", "pid", proc.Pid)
+	return nil
+}
+
+// readyHandler returns a handler which reports whether the agent is ready.
+// The agent is ready once the config file has loaded and all components
+// passed to --server.http.ready-components are healthy.
+func (fr *flowRun) readyHandler(f *flow.Flow) http.HandlerFunc {
+	var readyComponents []string
+	if fr.readyComponents != "" {
+		readyComponents = strings.Split(fr.readyComponents, ",")
+	}
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if !f.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, "Config failed to load.\n")
+			return
 		}
+		if err := f.CheckHealthy(readyComponents); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "Agent is not ready: %s.\n", err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "Agent is Ready.\n")
 	}
 }
 
+// guardReadOnly wraps next so that requests with methods which may mutate
+// state are rejected when read-only mode is enabled.
+func (fr *flowRun) guardReadOnly(next http.Handler) http.Handler {
+	if !fr.readOnly {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+		default:
+			http.Error(w, fmt.Sprintf("method %s is not allowed in read-only mode", r.Method), http.StatusForbidden)
+		}
+	})
+}
+
+// Path prefixes of the routes serving the HTTP handlers of components.
+const (
+	componentPathPrefix     = "/api/v0/component/"
+	peerComponentPathPrefix = "/api/v0/peer/component/"
+)
+
+// registerComponentRoutes registers the routes which serve the HTTP handlers
+// of components. When node is non-nil, the handlers are also served to peers
+// of the cluster under peerComponentPathPrefix.
+func (fr *flowRun) registerComponentRoutes(r *mux.Router, componentHandler http.Handler, node cluster.Node) {
+	r.PathPrefix(componentPathPrefix + "{id}/").Handler(fr.guardReadOnly(componentHandler))
+	if node == nil {
+		return
+	}
+
+	peerHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The component handler routes requests by their path relative to
+		// componentPathPrefix.
+		r.URL.Path = componentPathPrefix + strings.TrimPrefix(r.URL.Path, peerComponentPathPrefix)
+		r.URL.RawPath = ""
+		componentHandler.ServeHTTP(w, r)
+	})
+	r.PathPrefix(peerComponentPathPrefix + "{id}/").Handler(peerOnly(node, peerHandler))
+}
+
+// peerOnly wraps next so that only requests from peers of node are accepted.
+// Requests from peers are used to distribute work between agents, and are
+// accepted even in read-only mode.
+func peerOnly(node cluster.Node, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !cluster.IsPeerRequest(node, r) {
+			http.Error(w, "only peers of the cluster are allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // getEnabledComponentsFunc returns a function that gets the current enabled components
 func getEnabledComponentsFunc(f *flow.Flow) func() map[string]interface{} {
 	return func() map[string]interface{} {
@@ -302,24 +811,13 @@ func getEnabledComponentsFunc(f *flow.Flow) func() map[string]interface{} {
 	}
 }
 
-func loadFlowFile(filename string) (*flow.File, error) {
-	bb, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, err
-	}
-
-	instrumentation.InstrumentConfig(bb)
-
-	return flow.ReadFile(filename, bb)
-}
-
 func interruptContext() (context.Context, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	go func() {
 		defer cancel()
 		sig := make(chan os.Signal, 1)
-		signal.Notify(sig, os.Interrupt)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 		select {
 		case <-sig:
 		case <-ctx.Done():
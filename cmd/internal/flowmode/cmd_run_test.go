@@ -0,0 +1,108 @@
+package flowmode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardReadOnly(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tt := []struct {
+		method     string
+		readOnly   bool
+		expectCode int
+	}{
+		{method: http.MethodGet, readOnly: true, expectCode: http.StatusNoContent},
+		{method: http.MethodHead, readOnly: true, expectCode: http.StatusNoContent},
+		{method: http.MethodOptions, readOnly: true, expectCode: http.StatusNoContent},
+		{method: http.MethodPost, readOnly: true, expectCode: http.StatusForbidden},
+		{method: http.MethodPut, readOnly: true, expectCode: http.StatusForbidden},
+		{method: http.MethodPatch, readOnly: true, expectCode: http.StatusForbidden},
+		{method: http.MethodDelete, readOnly: true, expectCode: http.StatusForbidden},
+		{method: http.MethodPost, readOnly: false, expectCode: http.StatusNoContent},
+		{method: http.MethodDelete, readOnly: false, expectCode: http.StatusNoContent},
+	}
+
+	for _, tc := range tt {
+		fr := &flowRun{readOnly: tc.readOnly}
+		handler := fr.guardReadOnly(next)
+
+		req := httptest.NewRequest(tc.method, "/api/v0/component/loki.source.api.default/push", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, tc.expectCode, rec.Code, "method %s, read-only %t", tc.method, tc.readOnly)
+	}
+}
+
+func TestRegisterComponentRoutes_ReadOnly(t *testing.T) {
+	var served []string
+	componentHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = append(served, mux.Vars(r)["id"]+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	fr := &flowRun{readOnly: true}
+	r := mux.NewRouter()
+	fr.registerComponentRoutes(r, componentHandler, cluster.NewLocalNode("127.0.0.1:12345"))
+
+	tt := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		expectCode int
+	}{
+		{
+			name:       "component route is read-only",
+			path:       "/api/v0/component/otelcol.processor.tail_sampling.default/traces",
+			remoteAddr: "127.0.0.1:54321",
+			expectCode: http.StatusForbidden,
+		},
+		{
+			name:       "peer route accepts peers",
+			path:       "/api/v0/peer/component/otelcol.processor.tail_sampling.default/traces",
+			remoteAddr: "127.0.0.1:54321",
+			expectCode: http.StatusNoContent,
+		},
+		{
+			name:       "peer route rejects other hosts",
+			path:       "/api/v0/peer/component/otelcol.processor.tail_sampling.default/traces",
+			remoteAddr: "10.0.0.1:54321",
+			expectCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			served = nil
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			req.RemoteAddr = tc.remoteAddr
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			require.Equal(t, tc.expectCode, rec.Code)
+
+			if tc.expectCode == http.StatusNoContent {
+				require.Equal(t, []string{"otelcol.processor.tail_sampling.default /api/v0/component/otelcol.processor.tail_sampling.default/traces"}, served)
+			} else {
+				require.Empty(t, served)
+			}
+		})
+	}
+
+	// The peer route isn't served without clustering.
+	r = mux.NewRouter()
+	fr.registerComponentRoutes(r, componentHandler, nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/peer/component/otelcol.processor.tail_sampling.default/traces", nil)
+	req.RemoteAddr = "127.0.0.1:54321"
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
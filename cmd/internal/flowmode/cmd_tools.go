@@ -0,0 +1,190 @@
+package flowmode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/river/diag"
+)
+
+func toolsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tools <subcommand>",
+		Short: "Utilities for working with River files",
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(
+		graphCommand(),
+		replayCommand(),
+		validateCommand(),
+	)
+	return cmd
+}
+
+func graphCommand() *cobra.Command {
+	g := &flowGraph{
+		format: "dot",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "graph [flags] file",
+		Short: "Export the component graph of a River file",
+		Long: `The graph subcommand writes the dependency graph of components in the
+specified River configuration file to stdout.
+
+Components are not built or run; only references between components are
+inspected. The graph is emitted in the Graphviz DOT format by default, or as
+JSON if --format=json is provided. Each edge includes the export of the
+referenced component and the argument which consumes it.
+
+graph exits with an error if the file contains a cycle or references a
+component which does not exist.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			err := g.Run(args[0], os.Stdout)
+
+			var diags diag.Diagnostics
+			if errors.As(err, &diags) {
+				for _, diag := range diags {
+					fmt.Fprintln(os.Stderr, diag)
+				}
+				return fmt.Errorf("encountered errors while building the graph")
+			}
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&g.format, "format", g.format, "output format (dot, json)")
+	return cmd
+}
+
+type flowGraph struct {
+	format string
+}
+
+func (fg *flowGraph) Run(configFile string, w io.Writer) error {
+	switch fg.format {
+	case "dot", "json":
+	default:
+		return fmt.Errorf("unsupported format %q", fg.format)
+	}
+
+	bb, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	f, err := flow.ReadFile(configFile, bb)
+	if err != nil {
+		return err
+	}
+
+	gi, err := flow.BuildGraph(f)
+	if err != nil {
+		return err
+	}
+
+	if fg.format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(gi)
+	}
+	return gi.WriteDOT(w)
+}
+
+func validateCommand() *cobra.Command {
+	v := &flowValidate{
+		strict:            true,
+		diagnosticsFormat: diagnosticsFormatText,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "validate [flags] file",
+		Short: "Validate a River file without running it",
+		Long: `The validate subcommand checks the specified River configuration file for
+errors without building or running any components.
+
+By default, validate runs in strict mode, where the attributes and blocks of
+every component and config block are checked against its schema. Unknown
+attributes and blocks are reported as errors, along with a suggestion for the
+closest known name. Pass --strict=false to only check that the file parses
+and that the references between components are valid.
+
+validate reports every error found and exits with an error if there were any.
+Errors are printed as text by default. Pass --diagnostics-format=json or
+--diagnostics-format=sarif to write machine-readable diagnostics to stdout
+instead, such as for annotating pull requests from CI.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := checkDiagnosticsFormat(v.diagnosticsFormat); err != nil {
+				return err
+			}
+
+			bb, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			err = v.Run(args[0], bb)
+
+			var diags diag.Diagnostics
+			if err != nil && !errors.As(err, &diags) {
+				return err
+			}
+			if err := writeDiagnostics(v.diagnosticsFormat, map[string][]byte{args[0]: bb}, diags); err != nil {
+				return err
+			}
+			if diags.HasErrors() {
+				return fmt.Errorf("encountered errors while validating the file")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&v.strict, "strict", v.strict, "Report unknown attributes and blocks as errors")
+	cmd.Flags().StringVar(&v.diagnosticsFormat, "diagnostics-format", v.diagnosticsFormat, diagnosticsFormatUsage)
+	return cmd
+}
+
+type flowValidate struct {
+	strict            bool
+	diagnosticsFormat string
+}
+
+// Run validates the River file called configFile with contents bb. Errors in
+// the file are returned as diag.Diagnostics.
+func (fv *flowValidate) Run(configFile string, bb []byte) error {
+	f, err := flow.ReadFile(configFile, bb)
+	if err != nil {
+		return err
+	}
+
+	// Strict validation is done even if the graph couldn't be built so that
+	// every error in the file is reported at once.
+	var diags diag.Diagnostics
+	if _, err := flow.BuildGraph(f); err != nil && !errors.As(err, &diags) {
+		return err
+	}
+	if fv.strict {
+		diags = append(diags, flow.Validate(f)...)
+	}
+
+	if diags.HasErrors() {
+		return diags
+	}
+	return nil
+}
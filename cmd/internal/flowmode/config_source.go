@@ -0,0 +1,423 @@
+package flowmode
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/config/instrumentation"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/util/signature"
+	"github.com/hairyhenderson/gomplate/v3/data"
+)
+
+// configSource retrieves the River config file for Flow from local disk, an
+// HTTP server, or an object storage bucket. A local directory may be used
+// instead of a config file, in which case its .river files are merged.
+type configSource struct {
+	path   string
+	url    *url.URL // Set for remote sources.
+	client *http.Client
+
+	// key verifies the detached signature of the config file, found at the
+	// location of the config file with a .sig suffix. Signatures aren't
+	// checked when key is nil.
+	key ed25519.PublicKey
+}
+
+func newConfigSource(path string, key ed25519.PublicKey) (*configSource, error) {
+	cs := &configSource{path: path, client: http.DefaultClient, key: key}
+
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme == "" || u.Scheme == "file" || len(u.Scheme) == 1 {
+		// Treat anything which isn't a URL as a local path. Single-letter
+		// schemes are Windows drive letters.
+		return cs, nil
+	}
+
+	switch u.Scheme {
+	case "http", "https", "s3", "gs":
+		cs.url = u
+		return cs, nil
+	default:
+		return nil, fmt.Errorf("unsupported config file scheme %q; must be one of http, https, s3, or gs", u.Scheme)
+	}
+}
+
+// Remote returns true if the config file isn't on local disk.
+func (cs *configSource) Remote() bool { return cs.url != nil }
+
+// Dir returns true if the source is a local directory.
+func (cs *configSource) Dir() bool {
+	if cs.url != nil {
+		return false
+	}
+	fi, err := os.Stat(cs.path)
+	return err == nil && fi.IsDir()
+}
+
+// configFile holds the contents of a config file read by a configSource.
+type configFile struct {
+	Name string
+	Data []byte
+}
+
+// ReadFiles retrieves the config file, or the .river files of a local
+// directory in lexical order, and verifies their signatures.
+func (cs *configSource) ReadFiles(ctx context.Context) ([]configFile, error) {
+	if !cs.Dir() {
+		bb, err := cs.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []configFile{{Name: cs.path, Data: bb}}, nil
+	}
+
+	// ReadDir returns the entries sorted by file name.
+	dirents, err := os.ReadDir(cs.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []configFile
+	for _, dirent := range dirents {
+		if dirent.IsDir() || filepath.Ext(dirent.Name()) != ".river" {
+			continue
+		}
+
+		path := filepath.Join(cs.path, dirent.Name())
+		bb, err := (&configSource{path: path, key: cs.key}).Read(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dirent.Name(), err)
+		}
+		files = append(files, configFile{Name: path, Data: bb})
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .river files found in directory")
+	}
+	return files, nil
+}
+
+// readConfigFiles parses files into a single File named name.
+func readConfigFiles(name string, files []configFile) (*flow.File, error) {
+	if len(files) == 1 {
+		return flow.ReadFile(files[0].Name, files[0].Data)
+	}
+
+	parsed := make([]*flow.File, 0, len(files))
+	for _, file := range files {
+		f, err := flow.ReadFile(file.Name, file.Data)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, f)
+	}
+	return flow.MergeFiles(name, parsed)
+}
+
+// hashConfigFiles returns a hash of the names and contents of files.
+func hashConfigFiles(files []configFile) [sha256.Size]byte {
+	h := sha256.New()
+	for _, file := range files {
+		fmt.Fprintf(h, "%s\x00%d\x00", file.Name, len(file.Data))
+		h.Write(file.Data)
+	}
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// Read retrieves the config file and verifies its signature.
+func (cs *configSource) Read(ctx context.Context) ([]byte, error) {
+	bb, err := cs.fetch(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if cs.key == nil {
+		return bb, nil
+	}
+
+	sig, err := cs.fetch(ctx, ".sig")
+	if err != nil {
+		return nil, fmt.Errorf("retrieving signature: %w", err)
+	}
+	if err := signature.Verify(cs.key, bb, sig); err != nil {
+		instrumentation.InstrumentInvalidRemoteConfig("invalid_signature")
+		return nil, fmt.Errorf("verifying config file: %w", err)
+	}
+	return bb, nil
+}
+
+// fetch retrieves the file at the location of the config file with suffix
+// appended.
+func (cs *configSource) fetch(ctx context.Context, suffix string) ([]byte, error) {
+	if cs.url == nil {
+		return os.ReadFile(cs.path + suffix)
+	}
+
+	u := *cs.url
+	u.Path += suffix
+
+	switch u.Scheme {
+	case "http", "https":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := cs.client.Do(req)
+		if err != nil {
+			instrumentation.InstrumentRemoteConfigFetchError()
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		instrumentation.InstrumentRemoteConfigFetch(resp.StatusCode)
+		if resp.StatusCode/100 != 2 {
+			return nil, fmt.Errorf("error fetching %s: status code: %d", u.Redacted(), resp.StatusCode)
+		}
+		return io.ReadAll(resp.Body)
+
+	default:
+		bb, err := data.ReadBlob(u)
+		if err != nil {
+			instrumentation.InstrumentRemoteConfigFetchError()
+			return nil, fmt.Errorf("error fetching %s: %w", u.Redacted(), err)
+		}
+		return bb, nil
+	}
+}
+
+// configLoader loads config files from a configSource into a Flow
+// controller. Remote config files and files read by the file function are
+// polled for changes, and a remote config file which fails to load is rolled
+// back to the last config file which loaded successfully.
+type configLoader struct {
+	log    log.Logger
+	source *configSource
+	flow   *flow.Flow
+
+	// watchFiles enables reloading the config file when a file read by the
+	// file function changes.
+	watchFiles bool
+
+	mut      sync.Mutex
+	lastRead []configFile
+	lastHash [sha256.Size]byte
+	lastGood *flow.File
+}
+
+// Reload retrieves the config file and loads it into the controller.
+func (cl *configLoader) Reload(ctx context.Context) error {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	files, err := cl.source.ReadFiles(ctx)
+	if err != nil {
+		instrumentation.InstrumentLoad(false)
+		return fmt.Errorf("reading config file %q: %w", cl.source.path, err)
+	}
+	return cl.apply(files)
+}
+
+// LastRead returns the contents of the config files from the last reload by
+// file name.
+func (cl *configLoader) LastRead() map[string][]byte {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	contents := make(map[string][]byte, len(cl.lastRead))
+	for _, file := range cl.lastRead {
+		contents[file.Name] = file.Data
+	}
+	return contents
+}
+
+// Poll reloads the config file every frequency until ctx is canceled. The
+// controller is only updated when the contents of the file or, if watchFiles
+// is set, the contents of a file read by the file function changed. Poll is a
+// no-op for local config files when watchFiles isn't set.
+func (cl *configLoader) Poll(ctx context.Context, frequency time.Duration) {
+	if (!cl.source.Remote() && !cl.watchFiles) || frequency <= 0 {
+		return
+	}
+
+	t := time.NewTicker(frequency)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		if cl.source.Remote() {
+			if err := cl.pollOnce(ctx); err != nil {
+				level.Error(cl.log).Log("msg", "failed to reload remote config", "err", err)
+			}
+		}
+		if cl.watchFiles {
+			if err := cl.pollFilesOnce(); err != nil {
+				level.Error(cl.log).Log("msg", "failed to reload config after files changed", "err", err)
+			}
+		}
+	}
+}
+
+// Watch reloads a local config file when it changes on disk until ctx is
+// canceled. The directory of the config file is watched rather than the file
+// itself so that files which are replaced instead of written to, such as by
+// editors or Kubernetes ConfigMap updates, are still detected. If the config
+// is a directory, the directory itself is watched. Changes are
+// debounced so that a file written in several steps is reloaded once, and the
+// controller is only updated when the contents of the file changed. Watch is
+// a no-op for remote config files, which are polled instead.
+func (cl *configLoader) Watch(ctx context.Context, debounce time.Duration) error {
+	if cl.source.Remote() {
+		return nil
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	dir := filepath.Dir(cl.source.path)
+	if cl.source.Dir() {
+		dir = cl.source.path
+	}
+	if err := w.Add(dir); err != nil {
+		return fmt.Errorf("watching config file %q: %w", cl.source.path, err)
+	}
+
+	// reload is nil while no reload is pending.
+	var reload <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			level.Debug(cl.log).Log("msg", "got fsnotify event for config directory", "name", ev.Name, "op", ev.Op.String())
+			reload = time.After(debounce)
+
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			// The error may or may not be related to the config file, so it's
+			// treated as if the file changed.
+			level.Warn(cl.log).Log("msg", "got error from fsnotify watcher; treating as config file changed", "err", err)
+			reload = time.After(debounce)
+
+		case <-reload:
+			reload = nil
+			if err := cl.pollOnce(ctx); err != nil {
+				level.Error(cl.log).Log("msg", "failed to reload config", "err", err)
+			}
+		}
+	}
+}
+
+// pollFilesOnce reloads the last config file which was read if a file read by
+// the file function changed.
+func (cl *configLoader) pollFilesOnce() error {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	changed := cl.flow.ChangedFiles()
+	if len(changed) == 0 || cl.lastRead == nil {
+		return nil
+	}
+
+	level.Info(cl.log).Log("msg", "files read by the config changed; reloading", "files", strings.Join(changed, ","))
+	if err := cl.apply(cl.lastRead); err != nil {
+		return err
+	}
+	level.Info(cl.log).Log("msg", "config reloaded")
+	return nil
+}
+
+func (cl *configLoader) pollOnce(ctx context.Context) error {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	files, err := cl.source.ReadFiles(ctx)
+	if err != nil {
+		return fmt.Errorf("reading config file %q: %w", cl.source.path, err)
+	}
+	if hashConfigFiles(files) == cl.lastHash {
+		return nil
+	}
+
+	level.Info(cl.log).Log("msg", "config file changed; reloading")
+	if err := cl.apply(files); err != nil {
+		return err
+	}
+	level.Info(cl.log).Log("msg", "config reloaded")
+	return nil
+}
+
+// apply loads files into the controller. cl.mut must be held.
+func (cl *configLoader) apply(files []configFile) error {
+	cl.lastRead = files
+	cl.lastHash = hashConfigFiles(files)
+
+	var all []byte
+	for _, file := range files {
+		all = append(all, file.Data...)
+	}
+	instrumentation.InstrumentConfig(all)
+
+	flowCfg, err := readConfigFiles(cl.source.path, files)
+	if err != nil {
+		instrumentation.InstrumentLoad(false)
+		if cl.source.Remote() {
+			instrumentation.InstrumentInvalidRemoteConfig("invalid_river")
+		}
+		return fmt.Errorf("reading config file %q: %w", cl.source.path, err)
+	}
+
+	if err := cl.flow.LoadFile(flowCfg, nil); err != nil {
+		instrumentation.InstrumentLoad(false)
+		if cl.source.Remote() {
+			instrumentation.InstrumentInvalidRemoteConfig("load_failed")
+			cl.rollback()
+		}
+		return fmt.Errorf("error during the initial gragent load: %w", err)
+	}
+
+	instrumentation.InstrumentLoad(true)
+	cl.lastGood = flowCfg
+	return nil
+}
+
+// rollback reloads the last config file which loaded successfully.
+func (cl *configLoader) rollback() {
+	if cl.lastGood == nil {
+		return
+	}
+
+	level.Warn(cl.log).Log("msg", "rolling back to the last config which loaded successfully")
+	if err := cl.flow.LoadFile(cl.lastGood, nil); err != nil {
+		level.Error(cl.log).Log("msg", "failed to roll back config", "err", err)
+	}
+}
@@ -0,0 +1,230 @@
+package flowmode
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSource_Signature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.river")
+	config := []byte(`logging {}`)
+	require.NoError(t, os.WriteFile(path, config, 0644))
+
+	t.Run("missing signature", func(t *testing.T) {
+		cs, err := newConfigSource(path, pub)
+		require.NoError(t, err)
+		_, err = cs.Read(context.Background())
+		require.ErrorContains(t, err, "retrieving signature")
+	})
+
+	t.Run("valid signature", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path+".sig", ed25519.Sign(priv, config), 0644))
+
+		cs, err := newConfigSource(path, pub)
+		require.NoError(t, err)
+		bb, err := cs.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, config, bb)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path+".sig", ed25519.Sign(priv, []byte("other")), 0644))
+
+		cs, err := newConfigSource(path, pub)
+		require.NoError(t, err)
+		_, err = cs.Read(context.Background())
+		require.EqualError(t, err, "verifying config file: invalid signature")
+	})
+}
+
+func TestConfigSource_UnsupportedScheme(t *testing.T) {
+	_, err := newConfigSource("ftp://example.com/config.river", nil)
+	require.EqualError(t, err, `unsupported config file scheme "ftp"; must be one of http, https, s3, or gs`)
+}
+
+func TestConfigLoader_Rollback(t *testing.T) {
+	var (
+		mut    sync.Mutex
+		config = `discovery.relabel "a" { targets = [] }`
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+		_, _ = w.Write([]byte(config))
+	}))
+	defer srv.Close()
+
+	setConfig := func(s string) {
+		mut.Lock()
+		defer mut.Unlock()
+		config = s
+	}
+
+	sink, err := logging.WriterSink(os.Stderr, logging.DefaultSinkOptions)
+	require.NoError(t, err)
+
+	f := flow.New(flow.Options{
+		LogSink:  sink,
+		DataPath: t.TempDir(),
+		Reg:      prometheus.NewRegistry(),
+	})
+	source, err := newConfigSource(srv.URL+"/config.river", nil)
+	require.NoError(t, err)
+	loader := &configLoader{log: util.TestLogger(t), source: source, flow: f}
+
+	ctx := context.Background()
+	require.NoError(t, loader.Reload(ctx))
+	require.Equal(t, []string{"discovery.relabel.a"}, componentIDs(f))
+
+	// Unchanged configs aren't reapplied.
+	require.NoError(t, loader.pollOnce(ctx))
+
+	setConfig(`
+		discovery.relabel "b" { targets = [] }
+		discovery.relabel "c" { targets = "not a list" }
+	`)
+	require.Error(t, loader.pollOnce(ctx))
+	require.Equal(t, []string{"discovery.relabel.a"}, componentIDs(f), "expected rollback to the last good config")
+
+	// The broken config isn't retried until it changes again.
+	require.NoError(t, loader.pollOnce(ctx))
+
+	setConfig(`discovery.relabel "b" { targets = [] }`)
+	require.NoError(t, loader.pollOnce(ctx))
+	require.Equal(t, []string{"discovery.relabel.b"}, componentIDs(f))
+}
+
+func TestConfigLoader_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, contents string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+	writeFile("10-b.river", `discovery.relabel "b" { targets = [] }`)
+	writeFile("00-a.river", `discovery.relabel "a" { targets = [] }`)
+	writeFile("README.md", `not a config file`)
+
+	sink, err := logging.WriterSink(os.Stderr, logging.DefaultSinkOptions)
+	require.NoError(t, err)
+
+	f := flow.New(flow.Options{
+		LogSink:  sink,
+		DataPath: t.TempDir(),
+		Reg:      prometheus.NewRegistry(),
+	})
+	source, err := newConfigSource(dir, nil)
+	require.NoError(t, err)
+	loader := &configLoader{log: util.TestLogger(t), source: source, flow: f}
+
+	ctx := context.Background()
+	files, err := source.ReadFiles(ctx)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, filepath.Join(dir, "00-a.river"), files[0].Name)
+	require.Equal(t, filepath.Join(dir, "10-b.river"), files[1].Name)
+
+	require.NoError(t, loader.Reload(ctx))
+	require.Equal(t, []string{"discovery.relabel.a", "discovery.relabel.b"}, componentIDs(f))
+
+	writeFile("20-c.river", `discovery.relabel "a" { targets = [] }`)
+	err = loader.Reload(ctx)
+	require.ErrorContains(t, err, "Block discovery.relabel.a already declared at "+filepath.Join(dir, "00-a.river"))
+	require.Len(t, loader.LastRead(), 3)
+}
+
+func TestConfigLoader_WatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	addressPath := filepath.Join(dir, "address")
+	require.NoError(t, os.WriteFile(addressPath, []byte("localhost:1"), 0644))
+
+	configPath := filepath.Join(dir, "config.river")
+	config := fmt.Sprintf(`discovery.relabel "a" { targets = [{"__address__" = file(%q)}] }`, addressPath)
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0644))
+
+	sink, err := logging.WriterSink(os.Stderr, logging.DefaultSinkOptions)
+	require.NoError(t, err)
+
+	f := flow.New(flow.Options{
+		LogSink:  sink,
+		DataPath: t.TempDir(),
+		Reg:      prometheus.NewRegistry(),
+	})
+	source, err := newConfigSource(configPath, nil)
+	require.NoError(t, err)
+	loader := &configLoader{log: util.TestLogger(t), source: source, flow: f, watchFiles: true}
+
+	require.NoError(t, loader.Reload(context.Background()))
+	require.Empty(t, f.ChangedFiles())
+
+	require.NoError(t, os.WriteFile(addressPath, []byte("localhost:2"), 0644))
+	require.Equal(t, []string{addressPath}, f.ChangedFiles())
+
+	require.NoError(t, loader.pollFilesOnce())
+	require.Empty(t, f.ChangedFiles(), "expected changed files to be read again")
+}
+
+func TestConfigLoader_Watch(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.river")
+	require.NoError(t, os.WriteFile(configPath, []byte(`discovery.relabel "a" { targets = [] }`), 0644))
+
+	sink, err := logging.WriterSink(os.Stderr, logging.DefaultSinkOptions)
+	require.NoError(t, err)
+
+	f := flow.New(flow.Options{
+		LogSink:  sink,
+		DataPath: t.TempDir(),
+		Reg:      prometheus.NewRegistry(),
+	})
+	source, err := newConfigSource(configPath, nil)
+	require.NoError(t, err)
+	loader := &configLoader{log: util.TestLogger(t), source: source, flow: f}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, loader.Reload(ctx))
+	require.Equal(t, []string{"discovery.relabel.a"}, componentIDs(f))
+
+	watchErr := make(chan error, 1)
+	go func() { watchErr <- loader.Watch(ctx, 10*time.Millisecond) }()
+
+	// Give the watcher time to start before changing the file.
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, os.WriteFile(configPath, []byte(`discovery.relabel "b" { targets = [] }`), 0644))
+
+	require.Eventually(t, func() bool {
+		ids := componentIDs(f)
+		return len(ids) == 1 && ids[0] == "discovery.relabel.b"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-watchErr)
+}
+
+func componentIDs(f *flow.Flow) []string {
+	var ids []string
+	for _, info := range f.ComponentInfos() {
+		ids = append(ids, info.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,39 @@
+package flowmode
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/crash"
+)
+
+const (
+	// crashReportLogLines is the number of recent log lines included in crash
+	// reports.
+	crashReportLogLines = 1000
+
+	// crashReportCheckInterval is how often memory usage is checked against
+	// --crash-report.memory-threshold-bytes.
+	crashReportCheckInterval = 5 * time.Second
+)
+
+// buildCrashReporter creates a crash Reporter from the crash report flags.
+func (fr *flowRun) buildCrashReporter(l log.Logger, logs *crash.LogBuffer) (*crash.Reporter, error) {
+	opts := crash.DefaultOptions
+	opts.Dir = filepath.Join(fr.storagePath, "crash-reports")
+	opts.MemoryThreshold = fr.crashReportMemoryThreshold
+	opts.UploadURL = fr.crashReportUploadURL
+
+	reporter, err := crash.NewReporter(l, logs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Point out reports from previous runs, which may have crashed.
+	if reports, err := reporter.Reports(); err == nil && len(reports) > 0 {
+		level.Warn(l).Log("msg", "found crash reports from previous runs", "count", len(reports), "latest", reports[len(reports)-1])
+	}
+	return reporter, nil
+}
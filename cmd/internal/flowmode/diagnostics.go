@@ -0,0 +1,63 @@
+package flowmode
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/river/diag"
+)
+
+// Supported values for the --diagnostics-format flag.
+const (
+	diagnosticsFormatText  = "text"
+	diagnosticsFormatJSON  = "json"
+	diagnosticsFormatSARIF = "sarif"
+)
+
+const diagnosticsFormatUsage = "format to report diagnostics in (text, json, sarif)"
+
+func checkDiagnosticsFormat(format string) error {
+	switch format {
+	case diagnosticsFormatText, diagnosticsFormatJSON, diagnosticsFormatSARIF:
+		return nil
+	default:
+		return fmt.Errorf("unsupported diagnostics format %q", format)
+	}
+}
+
+// writeDiagnostics reports diags in format. Text diagnostics are written to
+// stderr with the lines of files they refer to. Machine-readable diagnostics
+// are written to stdout so they can be redirected separately from other
+// errors, and are written even when diags is empty so CI systems always get
+// a valid document.
+func writeDiagnostics(format string, files map[string][]byte, diags diag.Diagnostics) error {
+	switch format {
+	case diagnosticsFormatJSON:
+		return diag.FprintJSON(os.Stdout, diags)
+	case diagnosticsFormatSARIF:
+		return diag.FprintSARIF(os.Stdout, diag.Tool{
+			Name:           "grafana-agent",
+			Version:        build.Version,
+			InformationURI: "https://grafana.com/docs/agent/latest/flow/",
+		}, diags)
+	default:
+		if len(diags) == 0 {
+			return nil
+		}
+
+		p := diag.NewPrinter(diag.PrinterConfig{
+			Color:              !color.NoColor,
+			ContextLinesBefore: 1,
+			ContextLinesAfter:  1,
+		})
+		if err := p.Fprint(os.Stderr, files, diags); err != nil {
+			return err
+		}
+
+		// Print newline after the diagnostics.
+		fmt.Fprintln(os.Stderr)
+		return nil
+	}
+}
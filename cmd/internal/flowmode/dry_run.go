@@ -0,0 +1,150 @@
+package flowmode
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/util/signature"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dryRun loads configFile into a controller which doesn't send any data,
+// and writes the report of the dry run to w as JSON. An error is returned if
+// the report lists a failure.
+func (fr *flowRun) dryRun(ctx context.Context, configFile string, logSink *logging.Sink, w io.Writer) error {
+	var inputs []flow.DryRunInput
+	if fr.dryRunInputsFile != "" {
+		var err error
+		inputs, err = readDryRunInputs(fr.dryRunInputsFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	var configKey ed25519.PublicKey
+	if fr.configPublicKeyFile != "" {
+		var err error
+		configKey, err = signature.ReadPublicKey(fr.configPublicKeyFile)
+		if err != nil {
+			return fmt.Errorf("loading config public key: %w", err)
+		}
+	}
+
+	f, cleanup, err := loadDryRunController(ctx, logSink, configFile, configKey, fr.configMaxEvaluationDuration)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	report, err := f.DryRun(ctx, flow.DryRunOptions{
+		DiscoveryTimeout: fr.dryRunDiscoveryTimeout,
+		Inputs:           inputs,
+	})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	if report.Failed() {
+		return fmt.Errorf("dry run failed")
+	}
+	return nil
+}
+
+// readDryRunInputs reads the inputs of a dry run from path, which holds one
+// JSON object per line.
+func readDryRunInputs(path string) ([]flow.DryRunInput, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening dry run inputs: %w", err)
+	}
+	defer file.Close()
+
+	var (
+		inputs []flow.DryRunInput
+		line   int
+	)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var in flow.DryRunInput
+		if err := json.Unmarshal(scanner.Bytes(), &in); err != nil {
+			return nil, fmt.Errorf("%s:%d: decoding dry run input: %w", path, line, err)
+		}
+		if in.Component == "" {
+			return nil, fmt.Errorf("%s:%d: dry run input does not name a component", path, line)
+		}
+		inputs = append(inputs, in)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading dry run inputs: %w", err)
+	}
+	return inputs, nil
+}
+
+// loadDryRunController loads configFile into a controller which isn't run,
+// for dry runs. The returned function removes the data directory of the
+// controller.
+func loadDryRunController(ctx context.Context, logSink *logging.Sink, configFile string, configKey ed25519.PublicKey, maxEvaluationDuration time.Duration) (*flow.Flow, func(), error) {
+	// Components may write to their data directory when they're built, so a
+	// temporary directory is used to leave the storage path untouched.
+	dataPath, err := os.MkdirTemp("", "agent-dry-run-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating data directory: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(dataPath) }
+
+	f := flow.New(flow.Options{
+		LogSink:               logSink,
+		DataPath:              dataPath,
+		Reg:                   prometheus.NewRegistry(),
+		HTTPPathPrefix:        "/api/v0/component/",
+		MaxEvaluationDuration: maxEvaluationDuration,
+		DryRun:                true,
+	})
+
+	source, err := newConfigSource(configFile, configKey)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	loader := &configLoader{log: logging.New(logSink), source: source, flow: f}
+	if err := loader.Reload(ctx); err != nil {
+		cleanup()
+
+		var diags diag.Diagnostics
+		if errors.As(err, &diags) {
+			p := diag.NewPrinter(diag.PrinterConfig{
+				Color:              !color.NoColor,
+				ContextLinesBefore: 1,
+				ContextLinesAfter:  1,
+			})
+			_ = p.Fprint(os.Stderr, loader.LastRead(), diags)
+			fmt.Fprintln(os.Stderr)
+			return nil, nil, fmt.Errorf("could not load the config file")
+		}
+		return nil, nil, err
+	}
+	return f, cleanup, nil
+}
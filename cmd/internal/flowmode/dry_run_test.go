@@ -0,0 +1,86 @@
+package flowmode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	dir := t.TempDir()
+
+	configFile := filepath.Join(dir, "config.river")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+		discovery.relabel "example" {
+			targets = [{"__address__" = "localhost:9090"}]
+		}
+
+		loki.process "example" {
+			forward_to = [loki.write.example.receiver]
+
+			stage.json {
+				expressions = {level = ""}
+			}
+
+			stage.labels {
+				values = {level = ""}
+			}
+		}
+
+		loki.write "example" {
+			endpoint {
+				url = "http://localhost:3100/loki/api/v1/push"
+			}
+		}
+	`), 0644))
+
+	inputsFile := filepath.Join(dir, "inputs.jsonl")
+	require.NoError(t, os.WriteFile(inputsFile, []byte(
+		`{"component": "loki.process.example", "input": {"labels": {"app": "web"}, "line": "{\"level\": \"error\"}", "timestamp": "2023-01-01T00:00:00Z"}}`+"\n\n",
+	), 0644))
+
+	logSink, err := logging.WriterSink(os.Stderr, logging.DefaultSinkOptions)
+	require.NoError(t, err)
+
+	fr := &flowRun{
+		dryRunInputsFile:       inputsFile,
+		dryRunDiscoveryTimeout: 5 * time.Second,
+	}
+	var buf bytes.Buffer
+	require.NoError(t, fr.dryRun(context.Background(), configFile, logSink, &buf))
+
+	var report flow.DryRunReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	require.Len(t, report.Components, 3)
+	require.Contains(t, report.Targets, "discovery.relabel.example")
+	require.Equal(t, map[string][]string{
+		"loki.write.example": {"http://localhost:3100/loki/api/v1/push"},
+	}, report.Destinations)
+
+	require.Len(t, report.Inputs, 1)
+	require.Empty(t, report.Inputs[0].Error)
+	require.Equal(t, `output: {app="web", level="error"} 2023-01-01T00:00:00Z "{\"level\": \"error\"}"`, report.Inputs[0].Steps[len(report.Inputs[0].Steps)-1])
+}
+
+func TestReadDryRunInputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inputs.jsonl")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"component": "loki.process.example", "input": {"line": "hello"}}`+"\n"), 0644))
+	inputs, err := readDryRunInputs(path)
+	require.NoError(t, err)
+	require.Equal(t, []flow.DryRunInput{
+		{Component: "loki.process.example", Input: json.RawMessage(`{"line": "hello"}`)},
+	}, inputs)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"input": {}}`+"\n"), 0644))
+	_, err = readDryRunInputs(path)
+	require.ErrorContains(t, err, "inputs.jsonl:1: dry run input does not name a component")
+}
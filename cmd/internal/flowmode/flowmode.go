@@ -24,8 +24,10 @@ func Run() {
 	cmd.SetVersionTemplate("{{ .Version }}\n")
 
 	cmd.AddCommand(
+		componentsCommand(),
 		fmtCommand(),
 		runCommand(),
+		toolsCommand(),
 	)
 
 	if err := cmd.Execute(); err != nil {
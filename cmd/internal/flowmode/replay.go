@@ -0,0 +1,173 @@
+package flowmode
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/logging"
+)
+
+func replayCommand() *cobra.Command {
+	r := &flowReplay{}
+
+	cmd := &cobra.Command{
+		Use:   "replay [flags] file capture",
+		Short: "Replay captured data through the components of a River file",
+		Long: `The replay subcommand processes data captured from the /debug/capture/{id}
+endpoint of a running agent with the components of the specified River
+configuration file, without sending any data.
+
+The result of each captured record is written to stdout as a JSON line. Save
+the results of replaying a capture with the current River file, then pass
+them to --compare when replaying the same capture with a changed River file
+to list the records whose output changed.
+
+replay exits with an error if a record failed to be processed, or if
+--compare is provided and the output of a record changed.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			logSink, err := logging.WriterSink(os.Stderr, logging.DefaultSinkOptions)
+			if err != nil {
+				return fmt.Errorf("building logger: %w", err)
+			}
+			return r.Run(context.Background(), logSink, args[0], args[1], os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringVar(&r.compare, "compare", r.compare, "Path to the results of a previous replay to compare outputs against")
+	return cmd
+}
+
+type flowReplay struct {
+	compare string
+}
+
+// Run replays the inputs in captureFile through the components of
+// configFile. The results are written to w, or the differences to the
+// results in fr.compare if set.
+func (fr *flowReplay) Run(ctx context.Context, logSink *logging.Sink, configFile, captureFile string, w io.Writer) error {
+	inputs, err := readDryRunInputs(captureFile)
+	if err != nil {
+		return err
+	}
+
+	var baseline []flow.DryRunInputResult
+	if fr.compare != "" {
+		baseline, err = readReplayResults(fr.compare)
+		if err != nil {
+			return err
+		}
+		if len(baseline) != len(inputs) {
+			return fmt.Errorf("%s has %d results but %s has %d records", fr.compare, len(baseline), captureFile, len(inputs))
+		}
+	}
+
+	f, cleanup, err := loadDryRunController(ctx, logSink, configFile, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	report, err := f.DryRun(ctx, flow.DryRunOptions{
+		SkipDiscovery: true,
+		Inputs:        inputs,
+	})
+	if err != nil {
+		return err
+	}
+
+	var failed, changed int
+	enc := json.NewEncoder(w)
+	for i, res := range report.Inputs {
+		if res.Error != "" {
+			failed++
+		}
+
+		if baseline == nil {
+			if err := enc.Encode(res); err != nil {
+				return err
+			}
+			continue
+		}
+
+		want, got := replayOutput(baseline[i]), replayOutput(res)
+		if reflect.DeepEqual(want, got) {
+			continue
+		}
+		changed++
+		fmt.Fprintf(w, "record %d (%s):\n", i+1, res.Component)
+		fmt.Fprintf(w, "  before:\n    %s\n", strings.Join(want, "\n    "))
+		fmt.Fprintf(w, "  after:\n    %s\n", strings.Join(got, "\n    "))
+	}
+
+	switch {
+	case failed > 0:
+		return fmt.Errorf("%d of %d records failed to be processed", failed, len(report.Inputs))
+	case changed > 0:
+		return fmt.Errorf("the output of %d of %d records changed", changed, len(report.Inputs))
+	}
+	return nil
+}
+
+// replayOutput returns the steps of res which describe the output of the
+// component. If the component didn't output anything, such as because the
+// record was dropped, the last step is returned instead.
+func replayOutput(res flow.DryRunInputResult) []string {
+	if res.Error != "" {
+		return []string{"error: " + res.Error}
+	}
+
+	var output []string
+	for _, step := range res.Steps {
+		if strings.HasPrefix(step, "output: ") {
+			output = append(output, step)
+		}
+	}
+	if len(output) == 0 && len(res.Steps) > 0 {
+		output = res.Steps[len(res.Steps)-1:]
+	}
+	return output
+}
+
+// readReplayResults reads the results of a previous replay from path.
+func readReplayResults(path string) ([]flow.DryRunInputResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening replay results: %w", err)
+	}
+	defer file.Close()
+
+	var (
+		results []flow.DryRunInputResult
+		line    int
+	)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var res flow.DryRunInputResult
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			return nil, fmt.Errorf("%s:%d: decoding replay result: %w", path, line, err)
+		}
+		results = append(results, res)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading replay results: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,70 @@
+package flowmode
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	writeConfig := func(name, stages string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(`
+			loki.process "example" {
+				forward_to = []
+				`+stages+`
+			}
+		`), 0644))
+		return path
+	}
+	before := writeConfig("before.river", `
+		stage.logfmt {
+			mapping = {level = ""}
+		}
+	`)
+	after := writeConfig("after.river", `
+		stage.logfmt {
+			mapping = {level = ""}
+		}
+		stage.drop {
+			source = "level"
+			value  = "debug"
+		}
+	`)
+
+	captureFile := filepath.Join(dir, "capture.jsonl")
+	require.NoError(t, os.WriteFile(captureFile, []byte(
+		`{"component": "loki.process.example", "input": {"line": "level=info msg=hello", "timestamp": "2023-01-01T00:00:00Z"}}`+"\n"+
+			`{"component": "loki.process.example", "input": {"line": "level=debug msg=hello", "timestamp": "2023-01-01T00:00:00Z"}}`+"\n",
+	), 0644))
+
+	logSink, err := logging.WriterSink(os.Stderr, logging.DefaultSinkOptions)
+	require.NoError(t, err)
+
+	var results bytes.Buffer
+	require.NoError(t, (&flowReplay{}).Run(context.Background(), logSink, before, captureFile, &results))
+
+	resultsFile := filepath.Join(dir, "results.jsonl")
+	require.NoError(t, os.WriteFile(resultsFile, results.Bytes(), 0644))
+
+	// Replaying the capture with the same config doesn't change any output.
+	var diff bytes.Buffer
+	require.NoError(t, (&flowReplay{compare: resultsFile}).Run(context.Background(), logSink, before, captureFile, &diff))
+	require.Empty(t, diff.String())
+
+	err = (&flowReplay{compare: resultsFile}).Run(context.Background(), logSink, after, captureFile, &diff)
+	require.EqualError(t, err, "the output of 1 of 2 records changed")
+	require.Equal(t, `record 2 (loki.process.example):
+  before:
+    output: {} 2023-01-01T00:00:00Z "level=debug msg=hello"
+  after:
+    stage.drop: dropped
+`, diff.String())
+}
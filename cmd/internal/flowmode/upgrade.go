@@ -0,0 +1,77 @@
+package flowmode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/upgrade"
+	"github.com/grafana/agent/pkg/util/signature"
+)
+
+// buildUpgrader creates an Upgrader from the managed upgrade flags.
+func (fr *flowRun) buildUpgrader(l log.Logger) (*upgrade.Upgrader, error) {
+	if fr.upgradePublicKeyFile == "" {
+		return nil, fmt.Errorf("--upgrade.public-key-file must be set when managed upgrades are enabled")
+	}
+	key, err := signature.ReadPublicKey(fr.upgradePublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return upgrade.NewUpgrader(l, upgrade.UpgraderOptions{
+		PublicKey: key,
+		AssetName: fr.upgradeAssetName,
+	})
+}
+
+// upgradeHandler stages an upgrade to the latest release and invokes restart
+// once the new binary is in place. The restart is only requested after the
+// response has been written. Requests made while an upgrade is being staged
+// are rejected with 409 Conflict.
+func (fr *flowRun) upgradeHandler(l log.Logger, c *upgrade.Checker, u *upgrade.Upgrader, restart func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case fr.readOnly:
+			http.Error(w, "upgrading over HTTP is disabled in read-only mode", http.StatusForbidden)
+			return
+		case u == nil:
+			http.Error(w, "managed upgrades are disabled; start the agent with --upgrade.managed to enable them", http.StatusForbidden)
+			return
+		}
+
+		if err := c.Check(r.Context()); err != nil {
+			http.Error(w, fmt.Sprintf("failed to check for the latest release: %s", err), http.StatusBadGateway)
+			return
+		}
+		status := c.Status()
+		if !status.UpdateAvailable {
+			fmt.Fprintf(w, "already running the latest release %s\n", status.LatestVersion)
+			return
+		}
+
+		level.Info(l).Log("msg", "managed upgrade requested", "current", status.CurrentVersion, "latest", status.LatestVersion, "remote_addr", r.RemoteAddr)
+
+		// Staging isn't tied to the request context so that a client
+		// disconnecting doesn't abort the upgrade halfway through.
+		switch err := u.Stage(context.Background(), c.Latest()); {
+		case errors.Is(err, upgrade.ErrInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			level.Error(l).Log("msg", "failed to stage upgrade", "err", err)
+			http.Error(w, fmt.Sprintf("failed to stage upgrade: %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, "upgraded to %s; restarting\n", status.LatestVersion)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		restart()
+	}
+}
@@ -0,0 +1,81 @@
+package flowmode
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/upgrade"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeHandler_InProgress(t *testing.T) {
+	oldVersion := build.Version
+	build.Version = "v0.39.1"
+	t.Cleanup(func() { build.Version = oldVersion })
+
+	// Release assets are only served once release is closed, so the first
+	// upgrade stays in progress until then.
+	var (
+		requested = make(chan struct{}, 1)
+		release   = make(chan struct{})
+	)
+	assets := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-release
+		http.NotFound(w, r)
+	}))
+	defer assets.Close()
+
+	releases := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		rel := upgrade.Release{Version: "v0.40.0"}
+		for _, name := range []string{"grafana-agent-test.zip", "SHA256SUMS", "SHA256SUMS.sig"} {
+			rel.Assets = append(rel.Assets, upgrade.Asset{Name: name, URL: assets.URL + "/" + name})
+		}
+		_ = json.NewEncoder(w).Encode(rel)
+	}))
+	defer releases.Close()
+
+	checker, err := upgrade.NewChecker(log.NewNopLogger(), prometheus.NewRegistry(), upgrade.CheckerOptions{
+		ReleasesURL: releases.URL,
+	})
+	require.NoError(t, err)
+
+	pub, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	upgrader, err := upgrade.NewUpgrader(log.NewNopLogger(), upgrade.UpgraderOptions{
+		PublicKey:  pub,
+		AssetName:  "grafana-agent-test",
+		Executable: filepath.Join(t.TempDir(), "grafana-agent"),
+	})
+	require.NoError(t, err)
+
+	fr := &flowRun{}
+	handler := fr.upgradeHandler(log.NewNopLogger(), checker, upgrader, func() {})
+
+	first := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/-/upgrade", nil))
+		first <- rec
+	}()
+	<-requested
+
+	// Requests made while the first upgrade is being staged are rejected.
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/-/upgrade", nil))
+	require.Equal(t, http.StatusConflict, rec.Code)
+	require.Contains(t, rec.Body.String(), "an upgrade is already in progress")
+
+	close(release)
+	require.Equal(t, http.StatusInternalServerError, (<-first).Code)
+}
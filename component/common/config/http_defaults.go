@@ -0,0 +1,143 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/config"
+)
+
+// HTTPDefaults holds settings which are inherited by the HTTP clients of
+// components unless the component overrides them.
+type HTTPDefaults struct {
+	// ProxyURL is used by clients which don't set proxy_url.
+	ProxyURL URL `river:"proxy_url,attr,optional"`
+
+	// TLSMinVersion is used by clients which don't set tls_config.min_version.
+	TLSMinVersion TLSVersion `river:"tls_min_version,attr,optional"`
+
+	// DialTimeout limits how long clients wait for a connection to be
+	// established.
+	DialTimeout time.Duration `river:"dial_timeout,attr,optional"`
+
+	// DialFallbackDelay is how long clients wait for a connection over IPv6
+	// before also trying IPv4 when a host has addresses of both families. A
+	// negative value disables the fallback.
+	DialFallbackDelay time.Duration `river:"dial_fallback_delay,attr,optional"`
+
+	// IPFamily restricts the address family clients connect over.
+	IPFamily IPFamily `river:"ip_family,attr,optional"`
+
+	// IdleConnTimeout is how long idle connections are kept open.
+	IdleConnTimeout time.Duration `river:"idle_conn_timeout,attr,optional"`
+
+	// UserAgent replaces the user agent sent by clients.
+	UserAgent string `river:"user_agent,attr,optional"`
+}
+
+var (
+	httpDefaultsMut sync.RWMutex
+	httpDefaults    HTTPDefaults
+)
+
+// SetHTTPDefaults replaces the HTTP client defaults of the process. Clients
+// created after the call inherit the new defaults; existing clients are
+// unchanged.
+func SetHTTPDefaults(d HTTPDefaults) {
+	httpDefaultsMut.Lock()
+	defer httpDefaultsMut.Unlock()
+	httpDefaults = d
+}
+
+// GetHTTPDefaults returns the current HTTP client defaults of the process.
+func GetHTTPDefaults() HTTPDefaults {
+	httpDefaultsMut.RLock()
+	defer httpDefaultsMut.RUnlock()
+	return httpDefaults
+}
+
+// applyHTTPDefaults sets the fields of cfg which aren't set to the current
+// HTTP client defaults.
+func applyHTTPDefaults(cfg *config.HTTPClientConfig) {
+	d := GetHTTPDefaults()
+	if cfg.ProxyURL.URL == nil && d.ProxyURL.URL != nil {
+		cfg.ProxyURL = d.ProxyURL.Convert()
+	}
+	if cfg.TLSConfig.MinVersion == 0 {
+		cfg.TLSConfig.MinVersion = config.TLSVersion(d.TLSMinVersion)
+	}
+}
+
+// HTTPClientOptions returns the options which apply the user agent and
+// timeouts of the current HTTP client defaults to clients created with
+// config.NewClientFromConfig. Options passed after them take precedence.
+func HTTPClientOptions() []config.HTTPClientOption {
+	return GetHTTPDefaults().ClientOptions()
+}
+
+// ClientOptions returns the options which apply the user agent and timeouts
+// of d to clients created with config.NewClientFromConfig.
+func (d HTTPDefaults) ClientOptions() []config.HTTPClientOption {
+	var opts []config.HTTPClientOption
+	if d.UserAgent != "" {
+		opts = append(opts, config.WithUserAgent(d.UserAgent))
+	}
+	if d.IdleConnTimeout > 0 {
+		opts = append(opts, config.WithIdleConnTimeout(d.IdleConnTimeout))
+	}
+	if d.DialTimeout > 0 || d.DialFallbackDelay != 0 || d.IPFamily.restricted() {
+		dialer := &net.Dialer{Timeout: d.DialTimeout, FallbackDelay: d.DialFallbackDelay}
+		family := d.IPFamily
+		opts = append(opts, config.WithDialContextFunc(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, family.Network(network), addr)
+		}))
+	}
+	return opts
+}
+
+// IPFamily is the address family used to establish connections.
+type IPFamily string
+
+// Supported IPFamily values.
+const (
+	IPFamilyDual IPFamily = "dual" // Connect over IPv4 or IPv6.
+	IPFamilyIPv4 IPFamily = "ipv4" // Only connect over IPv4.
+	IPFamilyIPv6 IPFamily = "ipv6" // Only connect over IPv6.
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *IPFamily) UnmarshalText(text []byte) error {
+	switch v := IPFamily(text); v {
+	case IPFamilyDual, IPFamilyIPv4, IPFamilyIPv6:
+		*f = v
+	default:
+		return fmt.Errorf("unknown IP family %q, expected one of \"dual\", \"ipv4\", \"ipv6\"", string(text))
+	}
+	return nil
+}
+
+// restricted reports whether f limits connections to one address family.
+// The zero value connects over IPv4 or IPv6 like IPFamilyDual.
+func (f IPFamily) restricted() bool {
+	return f == IPFamilyIPv4 || f == IPFamilyIPv6
+}
+
+// Network returns the network to dial instead of network so that only
+// addresses of f are used. Networks other than tcp and udp are returned
+// unchanged.
+func (f IPFamily) Network(network string) string {
+	if network != "tcp" && network != "udp" {
+		return network
+	}
+	switch f {
+	case IPFamilyIPv4:
+		return network + "4"
+	case IPFamilyIPv6:
+		return network + "6"
+	default:
+		return network
+	}
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPDefaults_Unmarshal(t *testing.T) {
+	var exampleRiverConfig = `
+	proxy_url           = "http://0.0.0.0:11111"
+	tls_min_version     = "TLS12"
+	dial_timeout        = "5s"
+	idle_conn_timeout   = "1m"
+	user_agent          = "custom-agent"
+	dial_fallback_delay = "100ms"
+	ip_family           = "ipv6"
+`
+
+	var defaults HTTPDefaults
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &defaults))
+	require.Equal(t, "http://0.0.0.0:11111", defaults.ProxyURL.String())
+	require.Equal(t, TLSVersion(tls.VersionTLS12), defaults.TLSMinVersion)
+	require.Equal(t, 5*time.Second, defaults.DialTimeout)
+	require.Equal(t, time.Minute, defaults.IdleConnTimeout)
+	require.Equal(t, "custom-agent", defaults.UserAgent)
+	require.Equal(t, 100*time.Millisecond, defaults.DialFallbackDelay)
+	require.Equal(t, IPFamilyIPv6, defaults.IPFamily)
+
+	require.NoError(t, river.Unmarshal([]byte(`ip_family = "dual"`), &defaults))
+	require.Equal(t, IPFamilyDual, defaults.IPFamily)
+	require.ErrorContains(t, river.Unmarshal([]byte(`ip_family = "ipv5"`), &defaults), `unknown IP family "ipv5"`)
+}
+
+func TestIPFamily_Network(t *testing.T) {
+	tt := []struct {
+		family  IPFamily
+		network string
+		expect  string
+	}{
+		{family: IPFamilyDual, network: "tcp", expect: "tcp"},
+		{family: IPFamilyIPv4, network: "tcp", expect: "tcp4"},
+		{family: IPFamilyIPv6, network: "tcp", expect: "tcp6"},
+		{family: IPFamilyIPv4, network: "udp", expect: "udp4"},
+		{family: IPFamilyIPv6, network: "tcp4", expect: "tcp4"},
+		{family: IPFamilyIPv6, network: "unix", expect: "unix"},
+	}
+	for _, tc := range tt {
+		require.Equal(t, tc.expect, tc.family.Network(tc.network), "family %q, network %s", tc.family, tc.network)
+	}
+}
+
+func TestHTTPDefaults_Convert(t *testing.T) {
+	t.Cleanup(func() { SetHTTPDefaults(HTTPDefaults{}) })
+
+	var defaults HTTPDefaults
+	require.NoError(t, river.Unmarshal([]byte(`
+	proxy_url       = "http://0.0.0.0:11111"
+	tls_min_version = "TLS12"
+`), &defaults))
+	SetHTTPDefaults(defaults)
+
+	t.Run("nil config inherits defaults", func(t *testing.T) {
+		var h *HTTPClientConfig
+		cfg := h.Convert()
+		require.Equal(t, "http://0.0.0.0:11111", cfg.ProxyURL.String())
+		require.Equal(t, config.TLSVersion(tls.VersionTLS12), cfg.TLSConfig.MinVersion)
+
+		// The shared default config must not be modified.
+		require.Nil(t, config.DefaultHTTPClientConfig.ProxyURL.URL)
+	})
+
+	t.Run("unset settings inherit defaults", func(t *testing.T) {
+		h := DefaultHTTPClientConfig
+		cfg := h.Convert()
+		require.Equal(t, "http://0.0.0.0:11111", cfg.ProxyURL.String())
+		require.Equal(t, config.TLSVersion(tls.VersionTLS12), cfg.TLSConfig.MinVersion)
+	})
+
+	t.Run("component settings take precedence", func(t *testing.T) {
+		var h HTTPClientConfig
+		require.NoError(t, river.Unmarshal([]byte(`
+		proxy_url = "http://0.0.0.0:22222"
+
+		tls_config {
+			min_version = "TLS13"
+		}
+`), &h))
+		cfg := h.Convert()
+		require.Equal(t, "http://0.0.0.0:22222", cfg.ProxyURL.String())
+		require.Equal(t, config.TLSVersion(tls.VersionTLS13), cfg.TLSConfig.MinVersion)
+	})
+}
+
+func TestHTTPDefaults_ClientOptions(t *testing.T) {
+	t.Cleanup(func() { SetHTTPDefaults(HTTPDefaults{}) })
+
+	userAgents := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.UserAgent()
+	}))
+	defer srv.Close()
+
+	SetHTTPDefaults(HTTPDefaults{
+		DialTimeout: 5 * time.Second,
+		UserAgent:   "default-agent",
+	})
+
+	// Clients inherit the default user agent.
+	client, err := config.NewClientFromConfig(config.DefaultHTTPClientConfig, "test", HTTPClientOptions()...)
+	require.NoError(t, err)
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, "default-agent", <-userAgents)
+
+	// Options passed after the defaults take precedence.
+	opts := append(HTTPClientOptions(), config.WithUserAgent("component-agent"))
+	client, err = config.NewClientFromConfig(config.DefaultHTTPClientConfig, "test", opts...)
+	require.NoError(t, err)
+	resp, err = client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, "component-agent", <-userAgents)
+}
+
+func TestHTTPDefaults_IPFamily(t *testing.T) {
+	t.Cleanup(func() { SetHTTPDefaults(HTTPDefaults{}) })
+
+	// httptest servers listen on an IPv4 loopback address.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	get := func(family IPFamily) error {
+		SetHTTPDefaults(HTTPDefaults{IPFamily: family, DialFallbackDelay: -1})
+
+		client, err := config.NewClientFromConfig(config.DefaultHTTPClientConfig, "test", HTTPClientOptions()...)
+		require.NoError(t, err)
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	require.NoError(t, get(IPFamilyDual))
+	require.NoError(t, get(IPFamilyIPv4))
+	require.Error(t, get(IPFamilyIPv6))
+}
+
+func TestHTTPClientConfig_ClientOptions(t *testing.T) {
+	t.Cleanup(func() { SetHTTPDefaults(HTTPDefaults{}) })
+	SetHTTPDefaults(HTTPDefaults{IPFamily: IPFamilyIPv6, DialFallbackDelay: -1})
+
+	// httptest servers listen on an IPv4 loopback address.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	get := func(cfg string) error {
+		var args HTTPClientConfig
+		require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+		client, err := config.NewClientFromConfig(*args.Convert(), "test", args.ClientOptions()...)
+		require.NoError(t, err)
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	// Clients inherit the IP family of the defaults unless they set their own.
+	require.Error(t, get(``))
+	require.NoError(t, get(`ip_family = "ipv4"`))
+	require.NoError(t, get(`ip_family = "dual"`))
+}
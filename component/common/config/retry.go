@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+)
+
+// RetryConfig is the retry and backoff schema shared by components which
+// write data to remote endpoints. Fields left unset keep the defaults of the
+// component, so a retry block only needs to list the settings it overrides.
+//
+// Not every component can honor every field; components reject values they
+// can't honor with CheckSupported.
+type RetryConfig struct {
+	// InitialInterval is the time to wait after the first failure before
+	// retrying.
+	InitialInterval time.Duration `river:"initial_interval,attr,optional"`
+
+	// MaxInterval is the upper bound of the time to wait between retries.
+	MaxInterval time.Duration `river:"max_interval,attr,optional"`
+
+	// Multiplier is the factor the time to wait is multiplied by after every
+	// retry.
+	Multiplier float64 `river:"multiplier,attr,optional"`
+
+	// MaxElapsedTime is the maximum amount of time spent retrying a request
+	// before the data is discarded.
+	MaxElapsedTime time.Duration `river:"max_elapsed_time,attr,optional"`
+
+	// RetryableStatusCodes are the HTTP status codes of responses which are
+	// retried.
+	RetryableStatusCodes []int `river:"retryable_status_codes,attr,optional"`
+}
+
+var _ river.Unmarshaler = (*RetryConfig)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (rc *RetryConfig) UnmarshalRiver(f func(v interface{}) error) error {
+	*rc = RetryConfig{}
+
+	type config RetryConfig
+	if err := f((*config)(rc)); err != nil {
+		return err
+	}
+	return rc.Validate()
+}
+
+// Validate returns an error if rc is invalid.
+func (rc *RetryConfig) Validate() error {
+	if rc.InitialInterval < 0 {
+		return fmt.Errorf("initial_interval must not be negative")
+	}
+	if rc.MaxInterval < 0 {
+		return fmt.Errorf("max_interval must not be negative")
+	}
+	if rc.InitialInterval > 0 && rc.MaxInterval > 0 && rc.MaxInterval < rc.InitialInterval {
+		return fmt.Errorf("max_interval must not be less than initial_interval")
+	}
+	if rc.Multiplier != 0 && rc.Multiplier < 1 {
+		return fmt.Errorf("multiplier must be at least 1")
+	}
+	if rc.MaxElapsedTime < 0 {
+		return fmt.Errorf("max_elapsed_time must not be negative")
+	}
+	for _, code := range rc.RetryableStatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid retryable status code %d", code)
+		}
+	}
+	return nil
+}
+
+// RetrySupport describes which fields of RetryConfig a component can honor.
+type RetrySupport struct {
+	// Multiplier is the only multiplier the component supports. Zero means
+	// any multiplier is supported.
+	Multiplier float64
+
+	// MaxElapsedTime is true if the component supports max_elapsed_time.
+	MaxElapsedTime bool
+
+	// StatusCodes reports whether the component honors listing the given
+	// status code in retryable_status_codes. A nil StatusCodes means
+	// retryable_status_codes isn't supported.
+	StatusCodes func(code int) bool
+}
+
+// CheckSupported returns an error if rc sets fields which a component with
+// the given support can't honor.
+func (rc *RetryConfig) CheckSupported(support RetrySupport) error {
+	if rc.Multiplier != 0 && support.Multiplier != 0 && rc.Multiplier != support.Multiplier {
+		return fmt.Errorf("multiplier must be %g", support.Multiplier)
+	}
+	if rc.MaxElapsedTime != 0 && !support.MaxElapsedTime {
+		return fmt.Errorf("max_elapsed_time is not supported")
+	}
+	if len(rc.RetryableStatusCodes) > 0 {
+		if support.StatusCodes == nil {
+			return fmt.Errorf("retryable_status_codes is not supported")
+		}
+		for _, code := range rc.RetryableStatusCodes {
+			if !support.StatusCodes(code) {
+				return fmt.Errorf("status code %d can't be listed in retryable_status_codes", code)
+			}
+		}
+	}
+	return nil
+}
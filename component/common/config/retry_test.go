@@ -0,0 +1,80 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	initial_interval       = "1s"
+	max_interval           = "1m"
+	multiplier             = 2
+	max_elapsed_time       = "10m"
+	retryable_status_codes = [429, 503]
+`
+
+	var retryConfig RetryConfig
+	err := river.Unmarshal([]byte(exampleRiverConfig), &retryConfig)
+	require.NoError(t, err)
+	require.Equal(t, RetryConfig{
+		InitialInterval:      time.Second,
+		MaxInterval:          time.Minute,
+		Multiplier:           2,
+		MaxElapsedTime:       10 * time.Minute,
+		RetryableStatusCodes: []int{429, 503},
+	}, retryConfig)
+}
+
+func TestRetryConfigBadConfig(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name: "max_interval less than initial_interval",
+			config: `
+				initial_interval = "1m"
+				max_interval     = "1s"
+			`,
+			err: "max_interval must not be less than initial_interval",
+		},
+		{
+			name:   "multiplier less than 1",
+			config: `multiplier = 0.5`,
+			err:    "multiplier must be at least 1",
+		},
+		{
+			name:   "invalid status code",
+			config: `retryable_status_codes = [42]`,
+			err:    "invalid retryable status code 42",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var retryConfig RetryConfig
+			err := river.Unmarshal([]byte(tc.config), &retryConfig)
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestRetryConfigCheckSupported(t *testing.T) {
+	support := RetrySupport{
+		Multiplier: 2,
+		StatusCodes: func(code int) bool {
+			return code == 429
+		},
+	}
+
+	require.NoError(t, (&RetryConfig{Multiplier: 2, RetryableStatusCodes: []int{429}}).CheckSupported(support))
+	require.EqualError(t, (&RetryConfig{Multiplier: 3}).CheckSupported(support), "multiplier must be 2")
+	require.EqualError(t, (&RetryConfig{MaxElapsedTime: time.Minute}).CheckSupported(support), "max_elapsed_time is not supported")
+	require.EqualError(t, (&RetryConfig{RetryableStatusCodes: []int{503}}).CheckSupported(support), "status code 503 can't be listed in retryable_status_codes")
+	require.EqualError(t, (&RetryConfig{RetryableStatusCodes: []int{503}}).CheckSupported(RetrySupport{}), "retryable_status_codes is not supported")
+}
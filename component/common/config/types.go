@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/grafana/agent/pkg/river"
 
@@ -26,6 +27,11 @@ type HTTPClientConfig struct {
 	TLSConfig       TLSConfig         `river:"tls_config,block,optional"`
 	FollowRedirects bool              `river:"follow_redirects,attr,optional"`
 	EnableHTTP2     bool              `river:"enable_http2,attr,optional"`
+
+	// DialFallbackDelay and IPFamily override the HTTP client defaults of the
+	// same name for this client when set.
+	DialFallbackDelay time.Duration `river:"dial_fallback_delay,attr,optional"`
+	IPFamily          IPFamily      `river:"ip_family,attr,optional"`
 }
 
 // UnmarshalRiver implements the umarshaller
@@ -104,13 +110,16 @@ func (h *HTTPClientConfig) Validate() error {
 }
 
 // Convert converts HTTPClientConfig to the native Prometheus type. If h is
-// nil, the default client config is returned.
+// nil, the default client config is returned. Settings which aren't set by h
+// are inherited from the HTTP client defaults set with SetHTTPDefaults.
 func (h *HTTPClientConfig) Convert() *config.HTTPClientConfig {
 	if h == nil {
-		return &config.DefaultHTTPClientConfig
+		cfg := config.DefaultHTTPClientConfig
+		applyHTTPDefaults(&cfg)
+		return &cfg
 	}
 
-	return &config.HTTPClientConfig{
+	cfg := &config.HTTPClientConfig{
 		BasicAuth:       h.BasicAuth.Convert(),
 		Authorization:   h.Authorization.Convert(),
 		OAuth2:          h.OAuth2.Convert(),
@@ -121,6 +130,30 @@ func (h *HTTPClientConfig) Convert() *config.HTTPClientConfig {
 		FollowRedirects: h.FollowRedirects,
 		EnableHTTP2:     h.EnableHTTP2,
 	}
+	applyHTTPDefaults(cfg)
+	return cfg
+}
+
+// ClientDefaults returns the current HTTP client defaults with the
+// dial_fallback_delay and ip_family of h taking precedence. h may be nil.
+func (h *HTTPClientConfig) ClientDefaults() HTTPDefaults {
+	d := GetHTTPDefaults()
+	if h == nil {
+		return d
+	}
+	if h.DialFallbackDelay != 0 {
+		d.DialFallbackDelay = h.DialFallbackDelay
+	}
+	if h.IPFamily != "" {
+		d.IPFamily = h.IPFamily
+	}
+	return d
+}
+
+// ClientOptions is like HTTPClientOptions, but the dial_fallback_delay and
+// ip_family of h take precedence over the HTTP client defaults.
+func (h *HTTPClientConfig) ClientOptions() []config.HTTPClientOption {
+	return h.ClientDefaults().ClientOptions()
 }
 
 // Clone creates a shallow clone of h.
@@ -2,12 +2,14 @@ package kubernetes
 
 import (
 	"fmt"
+	"net/http"
 	"reflect"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	commoncfg "github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/pkg/build"
+	"github.com/prometheus/client_golang/prometheus"
 	promconfig "github.com/prometheus/common/config"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -48,12 +50,16 @@ func (args *ClientArguments) UnmarshalRiver(f func(interface{}) error) error {
 	return args.HTTPClientConfig.Validate()
 }
 
-// BuildRESTConfig converts ClientArguments to a Kubernetes REST config.
-func (args *ClientArguments) BuildRESTConfig(l log.Logger) (*rest.Config, error) {
-	var (
-		cfg *rest.Config
-		err error
-	)
+// BuildRESTConfig converts ClientArguments to a Kubernetes REST config. 401
+// Unauthorized responses received by clients of the config are counted in a
+// metric registered to reg.
+func (args *ClientArguments) BuildRESTConfig(l log.Logger, reg prometheus.Registerer) (*rest.Config, error) {
+	unauthorized, err := registerUnauthorizedResponses(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *rest.Config
 
 	switch {
 	case args.KubeConfig != "":
@@ -71,7 +77,7 @@ func (args *ClientArguments) BuildRESTConfig(l log.Logger) (*rest.Config, error)
 		level.Info(l).Log("msg", "Using pod service account via in-cluster config")
 
 	default:
-		rt, err := promconfig.NewRoundTripperFromConfig(*args.HTTPClientConfig.Convert(), "component.common.kubernetes")
+		rt, err := promconfig.NewRoundTripperFromConfig(*args.HTTPClientConfig.Convert(), "component.common.kubernetes", args.HTTPClientConfig.ClientOptions()...)
 		if err != nil {
 			return nil, err
 		}
@@ -83,6 +89,48 @@ func (args *ClientArguments) BuildRESTConfig(l log.Logger) (*rest.Config, error)
 
 	cfg.UserAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
 	cfg.ContentType = "application/vnd.kubernetes.protobuf"
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &unauthorizedRoundTripper{next: rt, logger: l, unauthorized: unauthorized}
+	})
 
 	return cfg, nil
 }
+
+// registerUnauthorizedResponses registers the counter of 401 Unauthorized
+// responses to reg, or returns the counter registered by a previous call.
+func registerUnauthorizedResponses(reg prometheus.Registerer) (prometheus.Counter, error) {
+	c := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "agent_kubernetes_client_unauthorized_responses_total",
+		Help: "Total number of 401 Unauthorized responses received from the Kubernetes API server.",
+	})
+	if err := reg.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector.(prometheus.Counter), nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// unauthorizedRoundTripper counts and logs 401 Unauthorized responses.
+//
+// Tokens are never cached for the lifetime of the client: client-go re-reads
+// the token file of in-cluster and kubeconfig credentials periodically and
+// after the server rejects a token, and bearer_token_file is read on every
+// request. A 401 therefore forces the next request to re-authenticate with
+// whatever token is currently on disk, such as a rotated projected service
+// account token.
+type unauthorizedRoundTripper struct {
+	next         http.RoundTripper
+	logger       log.Logger
+	unauthorized prometheus.Counter
+}
+
+func (rt *unauthorizedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		rt.unauthorized.Inc()
+		level.Warn(rt.logger).Log("msg", "Kubernetes API server rejected credentials, re-authenticating on next request", "url", req.URL.Redacted())
+	}
+	return resp, err
+}
@@ -1,10 +1,18 @@
 package kubernetes
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/go-kit/log"
 	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
 )
 
 func TestUnmarshalRiver(t *testing.T) {
@@ -59,3 +67,37 @@ func TestBadConfigs(t *testing.T) {
 		})
 	}
 }
+
+func TestUnauthorizedResponses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	var args ClientArguments
+	err := river.Unmarshal([]byte(fmt.Sprintf(`api_server = %q`, srv.URL)), &args)
+	require.NoError(t, err)
+
+	reg := prometheus.NewRegistry()
+	get := func() {
+		cfg, err := args.BuildRESTConfig(log.NewNopLogger(), reg)
+		require.NoError(t, err)
+		cli, err := rest.HTTPClientFor(cfg)
+		require.NoError(t, err)
+
+		resp, err := cli.Get(srv.URL + "/api/v1/pods")
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	// Configs built again for the same registerer share the counter.
+	get()
+	get()
+
+	expect := `
+# HELP agent_kubernetes_client_unauthorized_responses_total Total number of 401 Unauthorized responses received from the Kubernetes API server.
+# TYPE agent_kubernetes_client_unauthorized_responses_total counter
+agent_kubernetes_client_unauthorized_responses_total 2
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expect)))
+}
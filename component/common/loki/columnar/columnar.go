@@ -0,0 +1,190 @@
+// Package columnar implements an experimental columnar encoding of Loki push
+// requests, used to send logs between agents.
+//
+// An encoded request starts with a header holding the labels of its streams
+// and their number of entries. The entries of all streams follow as three
+// columns: the delta-encoded timestamps, the lengths of the lines, and the
+// lines themselves. Grouping similar values together makes requests smaller
+// once compressed, and allows decoding without per-entry message framing.
+package columnar
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// ContentType is the content type of snappy-compressed requests in the
+// columnar encoding.
+const ContentType = "application/x-grafana-agent-columnar-logs"
+
+// magic starts every encoded request, followed by the version of the
+// encoding.
+const (
+	magic   = "LCOL"
+	version = 1
+)
+
+// MaxDecodedSize is the maximum size of a request once decompressed. Larger
+// requests are rejected before they're decompressed.
+const MaxDecodedSize = 100 << 20
+
+var errTruncated = errors.New("columnar: truncated request")
+
+// Encode encodes req in the columnar encoding and compresses it with snappy.
+func Encode(req *logproto.PushRequest) []byte {
+	buf := append([]byte(magic), version)
+
+	buf = binary.AppendUvarint(buf, uint64(len(req.Streams)))
+	for _, s := range req.Streams {
+		buf = binary.AppendUvarint(buf, uint64(len(s.Labels)))
+		buf = append(buf, s.Labels...)
+		buf = binary.AppendUvarint(buf, uint64(len(s.Entries)))
+	}
+
+	// Timestamps are stored as the difference to the previous timestamp,
+	// which is small for entries of the same stream.
+	var prev int64
+	for _, s := range req.Streams {
+		for _, e := range s.Entries {
+			ts := e.Timestamp.UnixNano()
+			buf = binary.AppendVarint(buf, ts-prev)
+			prev = ts
+		}
+	}
+	for _, s := range req.Streams {
+		for _, e := range s.Entries {
+			buf = binary.AppendUvarint(buf, uint64(len(e.Line)))
+		}
+	}
+	for _, s := range req.Streams {
+		for _, e := range s.Entries {
+			buf = append(buf, e.Line...)
+		}
+	}
+	return snappy.Encode(nil, buf)
+}
+
+// Decode decompresses and decodes a request encoded by Encode. Requests
+// larger than MaxDecodedSize once decompressed are rejected.
+func Decode(compressed []byte) (*logproto.PushRequest, error) {
+	size, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("columnar: %w", err)
+	}
+	if size > MaxDecodedSize {
+		return nil, fmt.Errorf("columnar: decompressed request size %d exceeds the maximum of %d bytes", size, MaxDecodedSize)
+	}
+
+	buf, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("columnar: %w", err)
+	}
+	d := decoder{buf: buf}
+
+	if len(buf) < len(magic)+1 || string(buf[:len(magic)]) != magic {
+		return nil, errors.New("columnar: not a columnar request")
+	}
+	if v := buf[len(magic)]; v != version {
+		return nil, fmt.Errorf("columnar: unsupported version %d", v)
+	}
+	d.buf = d.buf[len(magic)+1:]
+
+	numStreams := d.uvarint()
+	// Every stream takes at least two bytes, which bounds the allocation for
+	// corrupted requests.
+	if numStreams > uint64(len(d.buf)/2) {
+		return nil, errTruncated
+	}
+	req := &logproto.PushRequest{Streams: make([]logproto.Stream, numStreams)}
+
+	var entries uint64
+	for i := range req.Streams {
+		req.Streams[i].Labels = string(d.bytes(d.uvarint()))
+		n := d.uvarint()
+		// Every entry takes at least two bytes too.
+		if d.err == nil && n > uint64(len(d.buf)/2)-entries {
+			return nil, errTruncated
+		}
+		req.Streams[i].Entries = make([]logproto.Entry, n)
+		entries += n
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	var ts int64
+	for i := range req.Streams {
+		for j := range req.Streams[i].Entries {
+			ts += d.varint()
+			req.Streams[i].Entries[j].Timestamp = time.Unix(0, ts)
+		}
+	}
+	lengths := make([]uint64, 0, entries)
+	for i := uint64(0); i < entries; i++ {
+		lengths = append(lengths, d.uvarint())
+	}
+	for i := range req.Streams {
+		for j := range req.Streams[i].Entries {
+			req.Streams[i].Entries[j].Line = string(d.bytes(lengths[0]))
+			lengths = lengths[1:]
+		}
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if len(d.buf) > 0 {
+		return nil, fmt.Errorf("columnar: %d unexpected trailing bytes", len(d.buf))
+	}
+	return req, nil
+}
+
+// decoder reads values from buf. Once a value can't be read, err is set and
+// all further reads return zero values.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = errTruncated
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = errTruncated
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) bytes(n uint64) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > uint64(len(d.buf)) {
+		d.err = errTruncated
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
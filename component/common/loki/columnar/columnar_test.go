@@ -0,0 +1,94 @@
+package columnar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	now := time.Unix(0, 1680000000123456789)
+	req := &logproto.PushRequest{
+		Streams: []logproto.Stream{
+			{
+				Labels: `{app="a"}`,
+				Entries: []logproto.Entry{
+					{Timestamp: now, Line: "first line"},
+					{Timestamp: now.Add(time.Millisecond), Line: ""},
+					{Timestamp: now.Add(-time.Second), Line: "out of order line"},
+				},
+			},
+			{Labels: `{app="empty"}`, Entries: []logproto.Entry{}},
+			{
+				Labels:  `{app="b"}`,
+				Entries: []logproto.Entry{{Timestamp: time.Unix(0, 0), Line: "epoch"}},
+			},
+		},
+	}
+
+	actual, err := Decode(Encode(req))
+	require.NoError(t, err)
+	require.Len(t, actual.Streams, len(req.Streams))
+	for i, s := range req.Streams {
+		require.Equal(t, s.Labels, actual.Streams[i].Labels)
+		require.Len(t, actual.Streams[i].Entries, len(s.Entries))
+		for j, e := range s.Entries {
+			require.True(t, e.Timestamp.Equal(actual.Streams[i].Entries[j].Timestamp))
+			require.Equal(t, e.Line, actual.Streams[i].Entries[j].Line)
+		}
+	}
+}
+
+func TestEncode_Smaller(t *testing.T) {
+	req := &logproto.PushRequest{}
+	for i := 0; i < 10; i++ {
+		s := logproto.Stream{Labels: `{app="app", namespace="default"}`}
+		for j := 0; j < 1000; j++ {
+			s.Entries = append(s.Entries, logproto.Entry{
+				Timestamp: time.Unix(1680000000, int64(j)*int64(time.Millisecond)),
+				Line:      "level=info msg=\"request handled\" status=200",
+			})
+		}
+		req.Streams = append(req.Streams, s)
+	}
+
+	pb, err := proto.Marshal(req)
+	require.NoError(t, err)
+	require.Less(t, len(Encode(req)), len(snappy.Encode(nil, pb)))
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	valid := Encode(&logproto.PushRequest{
+		Streams: []logproto.Stream{{
+			Labels:  `{app="a"}`,
+			Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "line"}},
+		}},
+	})
+	raw, err := snappy.Decode(nil, valid)
+	require.NoError(t, err)
+
+	tt := []struct {
+		name   string
+		buf    []byte
+		expect string
+	}{
+		{name: "not compressed", buf: raw, expect: "columnar: snappy: corrupt input"},
+		{name: "wrong magic", buf: snappy.Encode(nil, []byte("LPRO\x01")), expect: "columnar: not a columnar request"},
+		{name: "unknown version", buf: snappy.Encode(nil, []byte("LCOL\x02")), expect: "columnar: unsupported version 2"},
+		{name: "truncated", buf: snappy.Encode(nil, raw[:len(raw)-1]), expect: "columnar: truncated request"},
+		{name: "trailing bytes", buf: snappy.Encode(nil, append(raw, 0)), expect: "columnar: 1 unexpected trailing bytes"},
+		{name: "too many streams", buf: snappy.Encode(nil, []byte("LCOL\x01\xff\xff\xff\xff\x0f")), expect: "columnar: truncated request"},
+		{name: "too large", buf: []byte{0x80, 0x80, 0x80, 0x80, 0x0f}, expect: "columnar: decompressed request size 4026531840 exceeds the maximum of 104857600 bytes"},
+		{name: "too many entries", buf: snappy.Encode(nil, []byte("LCOL\x01\x01\x00\xff\xff\xff\xff\x0f")), expect: "columnar: truncated request"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Decode(tc.buf)
+			require.EqualError(t, err, tc.expect)
+		})
+	}
+}
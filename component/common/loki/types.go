@@ -31,6 +31,11 @@ type LogsReceiver chan Entry
 type Entry struct {
 	Labels model.LabelSet
 	logproto.Entry
+
+	// StructuredMetadata holds metadata of the entry which Loki stores
+	// alongside the line without indexing it. Components which create new
+	// entries from existing ones may drop it.
+	StructuredMetadata model.LabelSet
 }
 
 // InstrumentedEntryHandler ...
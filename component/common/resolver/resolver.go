@@ -0,0 +1,236 @@
+// Package resolver implements a caching DNS resolver for outbound clients
+// which supports custom nameservers, TTL bounds, and re-resolution after
+// connection failures.
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Arguments configures how outbound clients resolve hostnames.
+type Arguments struct {
+	// Nameservers to send queries to as host:port pairs. When empty, the
+	// system resolver is used.
+	Nameservers []string `river:"nameservers,attr,optional"`
+
+	// MinTTL and MaxTTL bound how long resolved addresses are cached for. When
+	// the system resolver is used, record TTLs are unknown and results are
+	// cached for MinTTL.
+	MinTTL time.Duration `river:"min_ttl,attr,optional"`
+	MaxTTL time.Duration `river:"max_ttl,attr,optional"`
+
+	// ReresolveOnError drops cached addresses for a host after failing to
+	// connect to all of them.
+	ReresolveOnError bool `river:"reresolve_on_error,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	MinTTL:           0,
+	MaxTTL:           5 * time.Minute,
+	ReresolveOnError: true,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+	return args.Validate()
+}
+
+// Validate returns an error if args is invalid.
+func (args *Arguments) Validate() error {
+	if args.MinTTL < 0 || args.MaxTTL < 0 {
+		return fmt.Errorf("min_ttl and max_ttl must not be negative")
+	}
+	if args.MaxTTL != 0 && args.MinTTL > args.MaxTTL {
+		return fmt.Errorf("min_ttl must not be greater than max_ttl")
+	}
+	for _, ns := range args.Nameservers {
+		if _, _, err := net.SplitHostPort(ns); err != nil {
+			return fmt.Errorf("invalid nameserver %q: %w", ns, err)
+		}
+	}
+	return nil
+}
+
+// Resolver resolves and caches addresses for outbound connections. Resolver
+// is safe for concurrent use.
+type Resolver struct {
+	args   Arguments
+	dialer *net.Dialer
+	client *dns.Client
+	now    func() time.Time
+
+	mut   sync.Mutex
+	cache map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+// New creates a new Resolver from args.
+func New(args Arguments) *Resolver {
+	return &Resolver{
+		args:   args,
+		dialer: &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		client: &dns.Client{},
+		now:    time.Now,
+		cache:  make(map[string]cacheEntry),
+	}
+}
+
+// DialContext connects to addr on the named network, resolving the host of
+// addr through r. It can be used as the DialContext function of an
+// http.Transport.
+func (r *Resolver) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return r.dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := r.LookupIP(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := r.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+
+	if r.args.ReresolveOnError {
+		r.Forget(host)
+	}
+	return nil, lastErr
+}
+
+// LookupIP returns the addresses for host, using cached addresses when they
+// haven't expired.
+func (r *Resolver) LookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	r.mut.Lock()
+	entry, ok := r.cache[host]
+	r.mut.Unlock()
+	if ok && r.now().Before(entry.expires) {
+		return entry.ips, nil
+	}
+
+	ips, ttl, err := r.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	ttl = r.clampTTL(ttl)
+	if ttl > 0 {
+		r.mut.Lock()
+		r.cache[host] = cacheEntry{ips: ips, expires: r.now().Add(ttl)}
+		r.mut.Unlock()
+	}
+	return ips, nil
+}
+
+// Forget removes any cached addresses for host.
+func (r *Resolver) Forget(host string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	delete(r.cache, host)
+}
+
+func (r *Resolver) clampTTL(ttl time.Duration) time.Duration {
+	if ttl < r.args.MinTTL {
+		ttl = r.args.MinTTL
+	}
+	if r.args.MaxTTL > 0 && ttl > r.args.MaxTTL {
+		ttl = r.args.MaxTTL
+	}
+	return ttl
+}
+
+// lookup resolves host. The returned TTL is the smallest TTL of all returned
+// records, or 0 if the TTL is unknown.
+func (r *Resolver) lookup(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	if len(r.args.Nameservers) == 0 {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, 0, err
+		}
+		ips := make([]net.IP, 0, len(addrs))
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+		return ips, 0, nil
+	}
+
+	var lastErr error
+	for _, ns := range r.args.Nameservers {
+		ips, ttl, err := r.queryNameserver(ctx, ns, host)
+		if err == nil {
+			return ips, ttl, nil
+		}
+		lastErr = err
+	}
+	return nil, 0, lastErr
+}
+
+func (r *Resolver) queryNameserver(ctx context.Context, ns, host string) ([]net.IP, time.Duration, error) {
+	var (
+		ips    []net.IP
+		minTTL uint32
+		seen   bool
+	)
+
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		msg := new(dns.Msg)
+		msg.SetQuestion(dns.Fqdn(host), qtype)
+
+		resp, _, err := r.client.ExchangeContext(ctx, msg, ns)
+		if err != nil {
+			return nil, 0, fmt.Errorf("querying %s: %w", ns, err)
+		}
+		if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+			return nil, 0, fmt.Errorf("querying %s: %s", ns, dns.RcodeToString[resp.Rcode])
+		}
+
+		for _, rr := range resp.Answer {
+			var ip net.IP
+			switch rr := rr.(type) {
+			case *dns.A:
+				ip = rr.A
+			case *dns.AAAA:
+				ip = rr.AAAA
+			default:
+				continue
+			}
+
+			ips = append(ips, ip)
+			if ttl := rr.Header().Ttl; !seen || ttl < minTTL {
+				minTTL = ttl
+				seen = true
+			}
+		}
+	}
+
+	return ips, time.Duration(minTTL) * time.Second, nil
+}
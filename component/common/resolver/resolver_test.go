@@ -0,0 +1,113 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		var args Arguments
+		require.NoError(t, river.Unmarshal([]byte(``), &args))
+		require.Equal(t, DefaultArguments, args)
+	})
+
+	t.Run("min_ttl greater than max_ttl", func(t *testing.T) {
+		var args Arguments
+		err := river.Unmarshal([]byte(`
+			min_ttl = "10m"
+			max_ttl = "1m"
+		`), &args)
+		require.EqualError(t, err, "min_ttl must not be greater than max_ttl")
+	})
+
+	t.Run("nameserver without port", func(t *testing.T) {
+		var args Arguments
+		err := river.Unmarshal([]byte(`nameservers = ["10.0.0.1"]`), &args)
+		require.ErrorContains(t, err, `invalid nameserver "10.0.0.1"`)
+	})
+}
+
+func TestResolver_LookupIP(t *testing.T) {
+	var queries atomic.Int64
+	ns := startTestServer(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		queries.Inc()
+
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		if req.Question[0].Qtype == dns.TypeA {
+			resp.Answer = append(resp.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: req.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 3600},
+				A:   net.ParseIP("10.0.0.1"),
+			})
+		}
+		_ = w.WriteMsg(resp)
+	})
+
+	now := time.Now()
+	r := New(Arguments{
+		Nameservers: []string{ns},
+		MaxTTL:      time.Minute,
+	})
+	r.now = func() time.Time { return now }
+
+	ips, err := r.LookupIP(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.Equal(t, []net.IP{net.ParseIP("10.0.0.1").To4()}, ips)
+	require.Equal(t, int64(2), queries.Load(), "expected one A and one AAAA query")
+
+	// Cached results should be used until max_ttl passes, even though the
+	// record TTL is longer.
+	_, err = r.LookupIP(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), queries.Load())
+
+	now = now.Add(2 * time.Minute)
+	_, err = r.LookupIP(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.Equal(t, int64(4), queries.Load())
+
+	// Forgetting the host should force a new query.
+	r.Forget("example.com")
+	_, err = r.LookupIP(context.Background(), "example.com")
+	require.NoError(t, err)
+	require.Equal(t, int64(6), queries.Load())
+}
+
+func TestResolver_LookupIP_NotFound(t *testing.T) {
+	ns := startTestServer(t, func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetRcode(req, dns.RcodeNameError)
+		_ = w.WriteMsg(resp)
+	})
+
+	r := New(Arguments{Nameservers: []string{ns}})
+	_, err := r.LookupIP(context.Background(), "missing.example.com")
+	require.ErrorContains(t, err, "no such host")
+}
+
+func startTestServer(t *testing.T, handler dns.HandlerFunc) string {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	srv := &dns.Server{
+		PacketConn:        pc,
+		Handler:           handler,
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() { _ = srv.ActivateAndServe() }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+
+	<-started
+	return pc.LocalAddr().String()
+}
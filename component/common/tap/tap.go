@@ -0,0 +1,55 @@
+// Package tap implements a helper for components which implement
+// component.TapComponent.
+package tap
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/atomic"
+)
+
+// Tapper tracks active taps for a component. The zero value is ready for use.
+type Tapper struct {
+	active atomic.Int64
+
+	mut  sync.RWMutex
+	taps map[*func(string)]struct{}
+}
+
+// Tap registers fn to receive published records until ctx is canceled. Tap
+// blocks until ctx is canceled. Tap implements the method of the same name
+// from component.TapComponent.
+func (t *Tapper) Tap(ctx context.Context, fn func(record string)) {
+	t.mut.Lock()
+	if t.taps == nil {
+		t.taps = make(map[*func(string)]struct{})
+	}
+	t.taps[&fn] = struct{}{}
+	t.active.Inc()
+	t.mut.Unlock()
+
+	<-ctx.Done()
+
+	t.mut.Lock()
+	delete(t.taps, &fn)
+	t.active.Dec()
+	t.mut.Unlock()
+}
+
+// Active returns true if there is at least one registered tap. Components
+// should check Active before building records to publish to avoid the cost of
+// formatting records nobody is listening for.
+func (t *Tapper) Active() bool {
+	return t.active.Load() > 0
+}
+
+// Publish sends record to all registered taps.
+func (t *Tapper) Publish(record string) {
+	t.mut.RLock()
+	defer t.mut.RUnlock()
+
+	for fn := range t.taps {
+		(*fn)(record)
+	}
+}
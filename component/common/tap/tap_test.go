@@ -0,0 +1,43 @@
+package tap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTapper(t *testing.T) {
+	var tapper Tapper
+	require.False(t, tapper.Active())
+
+	// Publishing without any taps should be a no-op.
+	tapper.Publish("dropped")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan string, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tapper.Tap(ctx, func(record string) { received <- record })
+	}()
+
+	util.Eventually(t, func(t require.TestingT) {
+		require.True(t, tapper.Active())
+	})
+
+	tapper.Publish("hello")
+	require.Equal(t, "hello", <-received)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "Tap did not return after context was canceled")
+	}
+	require.False(t, tapper.Active())
+}
@@ -33,6 +33,13 @@
 // Default values for Arguments may be provided by implementing
 // river.Unmarshaler.
 //
+// Arguments may be validated by implementing river.Validator. Validate is
+// called after decoding and before the component is built or updated, so
+// invalid Arguments are reported as configuration errors instead of failing
+// when the component runs. Errors created with river.PathError are reported at
+// the position of the attribute or block they refer to, and multiple errors
+// can be reported at once with river.ValidationErrors.
+//
 // # Arguments and Exports immutability
 //
 // Arguments passed to a component should be treated as immutable, as memory
@@ -60,6 +67,7 @@ package component
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 )
 
@@ -115,6 +123,20 @@ type DebugComponent interface {
 	DebugInfo() interface{}
 }
 
+// TapComponent is an extension interface for components which can stream a
+// sample of the data flowing through them for debugging.
+type TapComponent interface {
+	Component
+
+	// Tap calls fn with a human-readable representation of each record
+	// processed by the component until ctx is canceled. Tap blocks until ctx
+	// is canceled.
+	//
+	// fn is called synchronously from the component's processing path and
+	// must not block. Multiple taps may be active at the same time.
+	Tap(ctx context.Context, fn func(record string))
+}
+
 // HTTPComponent is an extension interface for components which contain their own HTTP handlers.
 type HTTPComponent interface {
 	Component
@@ -125,3 +147,54 @@ type HTTPComponent interface {
 	// will receive a request to just `/metrics`.
 	Handler() http.Handler
 }
+
+// DryRunComponent is an extension interface for components which can process
+// sample input in isolation during a dry run, without forwarding the result
+// to other components.
+type DryRunComponent interface {
+	Component
+
+	// DryRun processes input, given as JSON in a component-specific format,
+	// and returns a human-readable description of each processing step.
+	// Steps which describe a record output by the component start with
+	// "output: ".
+	DryRun(input json.RawMessage) ([]string, error)
+}
+
+// CaptureComponent is an extension interface for components which can record
+// the data they receive, so that it can be replayed during a dry run.
+type CaptureComponent interface {
+	DryRunComponent
+
+	// Capture calls fn with each record received by the component, encoded in
+	// the input format of DryRun, until ctx is canceled. Capture blocks until
+	// ctx is canceled.
+	//
+	// fn is called synchronously from the component's processing path and
+	// must not block. Multiple captures may be active at the same time.
+	Capture(ctx context.Context, fn func(input json.RawMessage))
+}
+
+// DestinationComponent is an extension interface for components which send
+// data outside of the agent.
+type DestinationComponent interface {
+	Component
+
+	// Destinations returns the URLs the component sends data to.
+	Destinations() []string
+}
+
+// LeaderOnlyArguments is an extension interface for component Arguments which
+// can restrict a component to running on a single agent in a cluster.
+//
+// When LeaderOnly returns true, the Flow controller only builds and runs the
+// component on the agent elected as the component's leader. Other agents keep
+// the component on standby without building it, so it doesn't export any
+// values there. If leadership moves to another agent, the component is shut
+// down and rebuilt on the new leader.
+type LeaderOnlyArguments interface {
+	Arguments
+
+	// LeaderOnly returns true if the component must only run on the leader.
+	LeaderOnly() bool
+}
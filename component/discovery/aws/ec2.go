@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/pkg/flow/rivertypes"
 	promcfg "github.com/prometheus/common/config"
@@ -42,6 +43,8 @@ type EC2Arguments struct {
 	RefreshInterval time.Duration     `river:"refresh_interval,attr,optional"`
 	Port            int               `river:"port,attr,optional"`
 	Filters         []*EC2Filter      `river:"filter,block,optional"`
+
+	HTTPClientConfig config.HTTPClientConfig `river:",squash"`
 }
 
 func (args EC2Arguments) Convert() *promaws.EC2SDConfig {
@@ -54,6 +57,8 @@ func (args EC2Arguments) Convert() *promaws.EC2SDConfig {
 		RoleARN:         args.RoleARN,
 		RefreshInterval: model.Duration(args.RefreshInterval),
 		Port:            args.Port,
+
+		HTTPClientConfig: *args.HTTPClientConfig.Convert(),
 	}
 	for _, f := range args.Filters {
 		cfg.Filters = append(cfg.Filters, &promaws.EC2Filter{
@@ -67,6 +72,8 @@ func (args EC2Arguments) Convert() *promaws.EC2SDConfig {
 var DefaultEC2SDConfig = EC2Arguments{
 	Port:            80,
 	RefreshInterval: 60 * time.Second,
+
+	HTTPClientConfig: config.DefaultHTTPClientConfig,
 }
 
 // UnmarshalRiver implements river.Unmarshaler, applying defaults and
@@ -78,6 +85,12 @@ func (args *EC2Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	if err := f((*arguments)(args)); err != nil {
 		return err
 	}
+
+	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
+	if err := args.HTTPClientConfig.Validate(); err != nil {
+		return err
+	}
+
 	if args.Region == "" {
 		sess, err := session.NewSession()
 		if err != nil {
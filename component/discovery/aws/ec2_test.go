@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEC2RiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	region   = "us-east-1"
+	role_arn = "arn:aws:iam::123456789012:role/agent"
+	proxy_url = "http://proxy:3128"
+
+	filter {
+		name   = "tag:environment"
+		values = ["production"]
+	}
+`
+
+	var args EC2Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	sd := args.Convert()
+	require.Equal(t, "us-east-1", sd.Region)
+	require.Equal(t, "arn:aws:iam::123456789012:role/agent", sd.RoleARN)
+	require.Equal(t, "http://proxy:3128", sd.HTTPClientConfig.ProxyURL.String())
+	require.True(t, sd.HTTPClientConfig.FollowRedirects)
+	require.Len(t, sd.Filters, 1)
+	require.Equal(t, "tag:environment", sd.Filters[0].Name)
+}
+
+func TestEC2BadRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	region            = "us-east-1"
+	bearer_token      = "token"
+	bearer_token_file = "/path/to/file.token"
+`
+
+	var args EC2Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
+
+	exampleRiverConfig = `
+	region = "us-east-1"
+
+	filter {
+		name   = "tag:environment"
+		values = []
+	}
+`
+	err = river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.ErrorContains(t, err, "EC2 SD configuration filter values cannot be empty")
+}
+
+func TestLightsailRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	region = "us-east-1"
+	tls_config {
+		insecure_skip_verify = true
+	}
+`
+
+	var args LightsailArguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	sd := args.Convert()
+	require.Equal(t, "us-east-1", sd.Region)
+	require.True(t, sd.HTTPClientConfig.TLSConfig.InsecureSkipVerify)
+}
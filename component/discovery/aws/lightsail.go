@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/pkg/flow/rivertypes"
 	promcfg "github.com/prometheus/common/config"
@@ -35,6 +36,8 @@ type LightsailArguments struct {
 	RoleARN         string            `river:"role_arn,attr,optional"`
 	RefreshInterval time.Duration     `river:"refresh_interval,attr,optional"`
 	Port            int               `river:"port,attr,optional"`
+
+	HTTPClientConfig config.HTTPClientConfig `river:",squash"`
 }
 
 func (args LightsailArguments) Convert() *promaws.LightsailSDConfig {
@@ -47,6 +50,8 @@ func (args LightsailArguments) Convert() *promaws.LightsailSDConfig {
 		RoleARN:         args.RoleARN,
 		RefreshInterval: model.Duration(args.RefreshInterval),
 		Port:            args.Port,
+
+		HTTPClientConfig: *args.HTTPClientConfig.Convert(),
 	}
 	return cfg
 }
@@ -55,6 +60,8 @@ func (args LightsailArguments) Convert() *promaws.LightsailSDConfig {
 var DefaultLightsailSDConfig = LightsailArguments{
 	Port:            80,
 	RefreshInterval: 60 * time.Second,
+
+	HTTPClientConfig: config.DefaultHTTPClientConfig,
 }
 
 func (args *LightsailArguments) UnmarshalRiver(f func(interface{}) error) error {
@@ -64,6 +71,12 @@ func (args *LightsailArguments) UnmarshalRiver(f func(interface{}) error) error
 	if err := f((*arguments)(args)); err != nil {
 		return err
 	}
+
+	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
+	if err := args.HTTPClientConfig.Validate(); err != nil {
+		return err
+	}
+
 	if args.Region == "" {
 		sess, err := session.NewSession()
 		if err != nil {
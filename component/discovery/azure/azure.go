@@ -0,0 +1,142 @@
+// Package azure implements the discovery.azure component.
+package azure
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/river"
+	common "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	prom_discovery "github.com/prometheus/prometheus/discovery/azure"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.azure",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the discovery.azure component.
+type Arguments struct {
+	Environment     string           `river:"environment,attr,optional"`
+	Port            int              `river:"port,attr,optional"`
+	SubscriptionID  string           `river:"subscription_id,attr"`
+	OAuth           *OAuth           `river:"oauth,block,optional"`
+	ManagedIdentity *ManagedIdentity `river:"managed_identity,block,optional"`
+	RefreshInterval time.Duration    `river:"refresh_interval,attr,optional"`
+	ResourceGroup   string           `river:"resource_group,attr,optional"`
+
+	ProxyURL        config.URL       `river:"proxy_url,attr,optional"`
+	FollowRedirects bool             `river:"follow_redirects,attr,optional"`
+	EnableHTTP2     bool             `river:"enable_http2,attr,optional"`
+	TLSConfig       config.TLSConfig `river:"tls_config,block,optional"`
+}
+
+// OAuth authenticates with a client ID and secret of an Azure AD
+// application.
+type OAuth struct {
+	ClientID     string            `river:"client_id,attr"`
+	TenantID     string            `river:"tenant_id,attr"`
+	ClientSecret rivertypes.Secret `river:"client_secret,attr"`
+}
+
+// ManagedIdentity authenticates with the managed identity of the Azure
+// resource the agent runs on.
+type ManagedIdentity struct {
+	// ClientID selects a user-assigned identity. It is empty for the
+	// system-assigned identity.
+	ClientID string `river:"client_id,attr,optional"`
+}
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	Environment:     azure.PublicCloud.Name,
+	Port:            80,
+	RefreshInterval: 5 * time.Minute,
+	FollowRedirects: true,
+	EnableHTTP2:     true,
+}
+
+var _ river.Unmarshaler = (*Arguments)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler, applying defaults and
+// validating the provided config.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.SubscriptionID == "" {
+		return fmt.Errorf("subscription_id must not be empty")
+	}
+	if (args.OAuth == nil) == (args.ManagedIdentity == nil) {
+		return fmt.Errorf("exactly one of oauth or managed_identity must be specified")
+	}
+	if _, err := azure.EnvironmentFromName(args.Environment); err != nil {
+		return fmt.Errorf("invalid environment %q: %w", args.Environment, err)
+	}
+	if args.RefreshInterval <= 0 {
+		return fmt.Errorf("refresh_interval must be greater than 0")
+	}
+	return nil
+}
+
+// Convert converts Arguments to the upstream Prometheus SD type.
+func (args Arguments) Convert() *prom_discovery.SDConfig {
+	var (
+		authMethod   string
+		clientID     string
+		tenantID     string
+		clientSecret common.Secret
+	)
+	if args.ManagedIdentity != nil {
+		authMethod = "ManagedIdentity"
+		clientID = args.ManagedIdentity.ClientID
+	} else {
+		authMethod = "OAuth"
+		clientID = args.OAuth.ClientID
+		tenantID = args.OAuth.TenantID
+		clientSecret = common.Secret(args.OAuth.ClientSecret)
+	}
+
+	httpClientConfig := common.DefaultHTTPClientConfig
+	httpClientConfig.ProxyURL = args.ProxyURL.Convert()
+	httpClientConfig.FollowRedirects = args.FollowRedirects
+	httpClientConfig.EnableHTTP2 = args.EnableHTTP2
+	httpClientConfig.TLSConfig = *args.TLSConfig.Convert()
+
+	return &prom_discovery.SDConfig{
+		Environment:          args.Environment,
+		Port:                 args.Port,
+		SubscriptionID:       args.SubscriptionID,
+		TenantID:             tenantID,
+		ClientID:             clientID,
+		ClientSecret:         clientSecret,
+		RefreshInterval:      model.Duration(args.RefreshInterval),
+		AuthenticationMethod: authMethod,
+		ResourceGroup:        args.ResourceGroup,
+		HTTPClientConfig:     httpClientConfig,
+	}
+}
+
+// New returns a new instance of a discovery.azure component.
+func New(opts component.Options, args Arguments) (component.Component, error) {
+	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
+		return prom_discovery.NewDiscovery(args.(Arguments).Convert(), opts.Logger), nil
+	})
+}
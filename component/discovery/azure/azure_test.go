@@ -0,0 +1,98 @@
+package azure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	var riverConfig = `
+	subscription_id = "subscription"
+	resource_group = "group"
+	refresh_interval = "10m"
+	port = 9100
+
+	oauth {
+		client_id = "client"
+		tenant_id = "tenant"
+		client_secret = "secret"
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+	require.Equal(t, "AzurePublicCloud", args.Environment)
+	require.Equal(t, 10*time.Minute, args.RefreshInterval)
+	require.Equal(t, 9100, args.Port)
+}
+
+func TestUnmarshalRiverInvalid(t *testing.T) {
+	tt := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name: "missing auth",
+			config: `
+	subscription_id = "subscription"
+`,
+			expectedErr: "exactly one of oauth or managed_identity must be specified",
+		},
+		{
+			name: "both auth methods",
+			config: `
+	subscription_id = "subscription"
+	oauth {
+		client_id = "client"
+		tenant_id = "tenant"
+		client_secret = "secret"
+	}
+	managed_identity {}
+`,
+			expectedErr: "exactly one of oauth or managed_identity must be specified",
+		},
+		{
+			name: "unknown environment",
+			config: `
+	subscription_id = "subscription"
+	environment = "AzureMoonCloud"
+	managed_identity {}
+`,
+			expectedErr: `invalid environment "AzureMoonCloud"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	args := DefaultArguments
+	args.SubscriptionID = "subscription"
+	args.ManagedIdentity = &ManagedIdentity{ClientID: "client"}
+
+	sdConfig := args.Convert()
+	require.Equal(t, "ManagedIdentity", sdConfig.AuthenticationMethod)
+	require.Equal(t, "client", sdConfig.ClientID)
+	require.Equal(t, "subscription", sdConfig.SubscriptionID)
+	require.Empty(t, sdConfig.TenantID)
+	require.True(t, sdConfig.HTTPClientConfig.FollowRedirects)
+
+	args.ManagedIdentity = nil
+	args.OAuth = &OAuth{ClientID: "client", TenantID: "tenant", ClientSecret: "secret"}
+
+	sdConfig = args.Convert()
+	require.Equal(t, "OAuth", sdConfig.AuthenticationMethod)
+	require.Equal(t, "tenant", sdConfig.TenantID)
+	require.Equal(t, "secret", string(sdConfig.ClientSecret))
+}
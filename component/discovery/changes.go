@@ -0,0 +1,99 @@
+package discovery
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxChangedTargets is the maximum number of added and removed targets listed
+// in the debug info of a component. Only the number of changes is reported
+// beyond that, to keep the debug info readable when many targets change.
+const maxChangedTargets = 10
+
+// ChangeTracker tracks how the targets of a component change over time, so
+// users can see churn caused by flapping service discovery.
+type ChangeTracker struct {
+	mut     sync.Mutex
+	current map[uint64]Target // Hash of target labels -> target.
+	info    ChangesDebugInfo
+}
+
+// ChangesDebugInfo summarizes the latest change to the targets of a
+// component.
+type ChangesDebugInfo struct {
+	Targets      int       `river:"targets,attr"`
+	LastChange   time.Time `river:"last_change,attr,optional"`
+	Added        int       `river:"added,attr"`
+	Removed      int       `river:"removed,attr"`
+	TotalAdded   int       `river:"total_added,attr"`
+	TotalRemoved int       `river:"total_removed,attr"`
+
+	// Up to maxChangedTargets of the targets which were added or removed in
+	// the latest update.
+	AddedTargets   []string `river:"added_targets,attr,optional"`
+	RemovedTargets []string `river:"removed_targets,attr,optional"`
+}
+
+// NewChangeTracker creates a new ChangeTracker without any targets.
+func NewChangeTracker() *ChangeTracker {
+	return &ChangeTracker{current: map[uint64]Target{}}
+}
+
+// Update records targets as the new set of targets, comparing it against the
+// previous set. Updates which don't change the set of targets are ignored, so
+// the latest change remains visible.
+func (ct *ChangeTracker) Update(targets []Target) {
+	next := make(map[uint64]Target, len(targets))
+	for _, t := range targets {
+		next[t.Labels().Hash()] = t
+	}
+
+	ct.mut.Lock()
+	defer ct.mut.Unlock()
+
+	info := ChangesDebugInfo{
+		Targets:      len(next),
+		LastChange:   time.Now(),
+		TotalAdded:   ct.info.TotalAdded,
+		TotalRemoved: ct.info.TotalRemoved,
+	}
+	for hash, t := range next {
+		if _, ok := ct.current[hash]; !ok {
+			info.Added++
+			info.AddedTargets = append(info.AddedTargets, t.Labels().String())
+		}
+	}
+	for hash, t := range ct.current {
+		if _, ok := next[hash]; !ok {
+			info.Removed++
+			info.RemovedTargets = append(info.RemovedTargets, t.Labels().String())
+		}
+	}
+	if info.Added == 0 && info.Removed == 0 {
+		return
+	}
+	info.TotalAdded += info.Added
+	info.TotalRemoved += info.Removed
+	info.AddedTargets = summarizeTargets(info.AddedTargets)
+	info.RemovedTargets = summarizeTargets(info.RemovedTargets)
+
+	ct.current = next
+	ct.info = info
+}
+
+// summarizeTargets sorts targets and keeps at most maxChangedTargets of them.
+func summarizeTargets(targets []string) []string {
+	sort.Strings(targets)
+	if len(targets) > maxChangedTargets {
+		targets = targets[:maxChangedTargets]
+	}
+	return targets
+}
+
+// DebugInfo returns the summary of the latest change to the targets.
+func (ct *ChangeTracker) DebugInfo() ChangesDebugInfo {
+	ct.mut.Lock()
+	defer ct.mut.Unlock()
+	return ct.info
+}
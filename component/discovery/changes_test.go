@@ -0,0 +1,57 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeTracker(t *testing.T) {
+	ct := NewChangeTracker()
+
+	ct.Update([]Target{
+		{"__address__": "a:80"},
+		{"__address__": "b:80"},
+	})
+	info := ct.DebugInfo()
+	require.Equal(t, 2, info.Targets)
+	require.Equal(t, 2, info.Added)
+	require.Equal(t, 0, info.Removed)
+	require.Equal(t, []string{`{__address__="a:80"}`, `{__address__="b:80"}`}, info.AddedTargets)
+
+	// Updates without changes keep the latest change.
+	ct.Update([]Target{
+		{"__address__": "b:80"},
+		{"__address__": "a:80"},
+	})
+	require.Equal(t, info, ct.DebugInfo())
+
+	// A flapping target shows up as removed and added again.
+	ct.Update([]Target{
+		{"__address__": "a:80"},
+	})
+	ct.Update([]Target{
+		{"__address__": "a:80"},
+		{"__address__": "b:80", "zone": "us-east-1"},
+	})
+	info = ct.DebugInfo()
+	require.Equal(t, 2, info.Targets)
+	require.Equal(t, 1, info.Added)
+	require.Equal(t, 0, info.Removed)
+	require.Equal(t, []string{`{__address__="b:80", zone="us-east-1"}`}, info.AddedTargets)
+	require.Empty(t, info.RemovedTargets)
+	require.Equal(t, 3, info.TotalAdded)
+	require.Equal(t, 1, info.TotalRemoved)
+
+	// Only a summary of large changes is listed.
+	var many []Target
+	for _, addr := range []string{"c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n"} {
+		many = append(many, Target{"__address__": addr + ":80"})
+	}
+	ct.Update(many)
+	info = ct.DebugInfo()
+	require.Equal(t, 12, info.Added)
+	require.Equal(t, 2, info.Removed)
+	require.Len(t, info.AddedTargets, maxChangedTargets)
+	require.Len(t, info.RemovedTargets, 2)
+}
@@ -0,0 +1,101 @@
+// Package consulagent implements the discovery.consulagent component.
+package consulagent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	promtail_consulagent "github.com/grafana/loki/clients/pkg/promtail/discovery/consulagent"
+	prom_config "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.consulagent",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the discovery.consulagent component.
+type Arguments struct {
+	Server          string            `river:"server,attr,optional"`
+	Token           rivertypes.Secret `river:"token,attr,optional"`
+	Datacenter      string            `river:"datacenter,attr,optional"`
+	TagSeparator    string            `river:"tag_separator,attr,optional"`
+	Scheme          string            `river:"scheme,attr,optional"`
+	Username        string            `river:"username,attr,optional"`
+	Password        rivertypes.Secret `river:"password,attr,optional"`
+	AllowStale      bool              `river:"allow_stale,attr,optional"`
+	RefreshInterval time.Duration     `river:"refresh_interval,attr,optional"`
+	Services        []string          `river:"services,attr,optional"`
+	ServiceTags     []string          `river:"tags,attr,optional"`
+	NodeMeta        map[string]string `river:"node_meta,attr,optional"`
+	TLSConfig       config.TLSConfig  `river:"tls_config,block,optional"`
+}
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	Server:          "localhost:8500",
+	TagSeparator:    ",",
+	Scheme:          "http",
+	AllowStale:      true,
+	RefreshInterval: 30 * time.Second,
+}
+
+// UnmarshalRiver implements river.Unmarshaler, applying defaults and
+// validating the provided config.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(args.Server) == "" {
+		return fmt.Errorf("server attribute must not be empty")
+	}
+
+	if args.RefreshInterval <= 0 {
+		return fmt.Errorf("refresh_interval must be greater than 0")
+	}
+
+	return nil
+}
+
+// Convert converts Arguments to the upstream SD type.
+func (args Arguments) Convert() *promtail_consulagent.SDConfig {
+	return &promtail_consulagent.SDConfig{
+		Server:          args.Server,
+		Token:           prom_config.Secret(args.Token),
+		Datacenter:      args.Datacenter,
+		TagSeparator:    args.TagSeparator,
+		Scheme:          args.Scheme,
+		Username:        args.Username,
+		Password:        prom_config.Secret(args.Password),
+		AllowStale:      args.AllowStale,
+		RefreshInterval: model.Duration(args.RefreshInterval),
+		Services:        args.Services,
+		ServiceTags:     args.ServiceTags,
+		NodeMeta:        args.NodeMeta,
+		TLSConfig:       *args.TLSConfig.Convert(),
+	}
+}
+
+// New returns a new instance of a discovery.consulagent component.
+func New(opts component.Options, args Arguments) (component.Component, error) {
+	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
+		return promtail_consulagent.NewDiscovery(args.(Arguments).Convert(), opts.Logger)
+	})
+}
@@ -0,0 +1,43 @@
+package consulagent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	server   = "localhost:8500"
+	token    = "secret"
+	services = ["api", "web"]
+	tags     = ["prometheus"]
+	tls_config {
+		ca_file = "/etc/consul/ca.pem"
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	sd := args.Convert()
+	require.Equal(t, "localhost:8500", sd.Server)
+	require.Equal(t, "secret", string(sd.Token))
+	require.Equal(t, "http", sd.Scheme)
+	require.Equal(t, []string{"api", "web"}, sd.Services)
+	require.Equal(t, []string{"prometheus"}, sd.ServiceTags)
+	require.Equal(t, 30*time.Second, time.Duration(sd.RefreshInterval))
+	require.Equal(t, "/etc/consul/ca.pem", sd.TLSConfig.CAFile)
+}
+
+func TestBadRiverConfig(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`server = ""`), &args)
+	require.ErrorContains(t, err, "server attribute must not be empty")
+
+	err = river.Unmarshal([]byte(`refresh_interval = "0s"`), &args)
+	require.ErrorContains(t, err, "refresh_interval must be greater than 0")
+}
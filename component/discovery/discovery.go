@@ -48,13 +48,17 @@ type Component struct {
 	newDiscoverer chan struct{}
 
 	creator Creator
+	changes *ChangeTracker
 }
 
+var _ component.DebugComponent = (*Component)(nil)
+
 // New creates a discovery component given arguments and a concrete Discovery implementation function.
 func New(o component.Options, args component.Arguments, creator Creator) (*Component, error) {
 	c := &Component{
 		opts:    o,
 		creator: creator,
+		changes: NewChangeTracker(),
 		// buffered to avoid deadlock from the first immediate update
 		newDiscoverer: make(chan struct{}, 1),
 	}
@@ -106,6 +110,12 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+// DebugInfo implements component.DebugComponent. It reports how the targets
+// changed in the latest update.
+func (c *Component) DebugInfo() interface{} {
+	return c.changes.DebugInfo()
+}
+
 // maxUpdateFrequency is the minimum time to wait between updating targets.
 // Currently not settable, since prometheus uses a static threshold, but
 // we could reconsider later.
@@ -137,6 +147,7 @@ func (c *Component) runDiscovery(ctx context.Context, d Discoverer) {
 				allTargets = append(allTargets, labels)
 			}
 		}
+		c.changes.Update(allTargets)
 		c.opts.OnStateChange(Exports{Targets: allTargets})
 	}
 
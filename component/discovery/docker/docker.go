@@ -81,6 +81,12 @@ func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 		return fmt.Errorf("refresh_interval must be greater than 0")
 	}
 
+	for _, filter := range args.Filters {
+		if filter.Name == "" {
+			return fmt.Errorf("filter name must not be empty")
+		}
+	}
+
 	return args.HTTPClientConfig.Validate()
 }
 
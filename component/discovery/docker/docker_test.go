@@ -29,3 +29,18 @@ func TestBadRiverConfig(t *testing.T) {
 	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
+
+func TestBadFilter(t *testing.T) {
+	var exampleRiverConfig = `
+	host = "unix:///var/run/docker.sock"
+
+	filter {
+		name   = ""
+		values = ["running"]
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.ErrorContains(t, err, "filter name must not be empty")
+}
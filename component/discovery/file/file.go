@@ -7,7 +7,6 @@ import (
 
 	"github.com/grafana/agent/component/discovery"
 
-	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 )
 
@@ -29,7 +28,10 @@ type Arguments struct {
 	SyncPeriod  time.Duration      `river:"sync_period,attr,optional"`
 }
 
-var _ component.Component = (*Component)(nil)
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
 
 // Component implements the discovery.file component.
 type Component struct {
@@ -37,8 +39,8 @@ type Component struct {
 
 	mut      sync.RWMutex
 	args     Arguments
-	watches  []watch
 	watchDog *time.Ticker
+	changes  *discovery.ChangeTracker
 }
 
 // New creates a new discovery.file component.
@@ -47,8 +49,8 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		opts:     o,
 		mut:      sync.RWMutex{},
 		args:     args,
-		watches:  make([]watch, 0),
 		watchDog: time.NewTicker(args.SyncPeriod),
+		changes:  discovery.NewChangeTracker(),
 	}
 
 	if err := c.Update(args); err != nil {
@@ -78,13 +80,6 @@ func (c *Component) Update(args component.Arguments) error {
 		c.watchDog.Reset(c.args.SyncPeriod)
 	}
 	c.args = args.(Arguments)
-	c.watches = c.watches[:0]
-	for _, v := range c.args.PathTargets {
-		c.watches = append(c.watches, watch{
-			target: v,
-			log:    c.opts.Logger,
-		})
-	}
 
 	return nil
 }
@@ -96,6 +91,7 @@ func (c *Component) Run(ctx context.Context) error {
 		defer c.mut.Unlock()
 
 		paths := c.getWatchedFiles()
+		c.changes.Update(paths)
 		// The component node checks to see if exports have actually changed.
 		c.opts.OnStateChange(discovery.Exports{Targets: paths})
 	}
@@ -114,14 +110,11 @@ func (c *Component) Run(ctx context.Context) error {
 }
 
 func (c *Component) getWatchedFiles() []discovery.Target {
-	paths := make([]discovery.Target, 0)
-	// See if there is anything new we need to check.
-	for _, w := range c.watches {
-		newPaths, err := w.getPaths()
-		if err != nil {
-			level.Error(c.opts.Logger).Log("msg", "error getting paths", "path", w.getPath(), "excluded", w.getExcludePath(), "err", err)
-		}
-		paths = append(paths, newPaths...)
-	}
-	return paths
+	return MatchTargets(c.opts.Logger, c.args.PathTargets)
+}
+
+// DebugInfo implements component.DebugComponent. It reports how the targets
+// changed in the latest update.
+func (c *Component) DebugInfo() interface{} {
+	return c.changes.DebugInfo()
 }
@@ -11,6 +11,23 @@ import (
 	"github.com/grafana/agent/component/discovery"
 )
 
+// MatchTargets expands the doublestar glob pattern in the __path__ label of
+// each target into one target per matching file, excluding files which match
+// the pattern in the __path_exclude__ label. Other labels of a target are
+// copied to the targets it expands into. Errors are logged to l.
+func MatchTargets(l log.Logger, targets []discovery.Target) []discovery.Target {
+	paths := make([]discovery.Target, 0)
+	for _, t := range targets {
+		w := watch{target: t, log: l}
+		newPaths, err := w.getPaths()
+		if err != nil {
+			level.Error(l).Log("msg", "error getting paths", "path", w.getPath(), "excluded", w.getExcludePath(), "err", err)
+		}
+		paths = append(paths, newPaths...)
+	}
+	return paths
+}
+
 // watch handles a single discovery.target for file watching.
 type watch struct {
 	target discovery.Target
@@ -0,0 +1,116 @@
+package filesd
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+// debouncer wraps a Discoverer, holding back target groups until the
+// Discoverer stops sending updates for a period of time. This avoids
+// exporting partial sets of targets while configuration management rewrites
+// many target files at once.
+//
+// Only the latest version of each target group is kept while holding back
+// updates. Updates are sent no later than maxPeriod after the first update
+// which was held back, even if the Discoverer keeps sending updates.
+type debouncer struct {
+	disc      discovery.Discoverer
+	period    time.Duration
+	maxPeriod time.Duration
+}
+
+func newDebouncer(disc discovery.Discoverer, period, maxPeriod time.Duration) *debouncer {
+	return &debouncer{
+		disc:      disc,
+		period:    period,
+		maxPeriod: maxPeriod,
+	}
+}
+
+// Run implements discovery.Discoverer.
+func (d *debouncer) Run(ctx context.Context, up chan<- []*targetgroup.Group) {
+	ch := make(chan []*targetgroup.Group)
+	go d.disc.Run(ctx, ch)
+
+	var (
+		// Latest version of held back target groups, keyed by source.
+		pending = map[string]*targetgroup.Group{}
+		// Order in which sources were first held back, so updates are sent in
+		// a consistent order.
+		order []string
+
+		periodTimer, maxTimer *time.Timer
+		periodC, maxC         <-chan time.Time
+	)
+
+	stopTimers := func() {
+		if periodTimer != nil {
+			periodTimer.Stop()
+		}
+		if maxTimer != nil {
+			maxTimer.Stop()
+		}
+		periodTimer, maxTimer = nil, nil
+		periodC, maxC = nil, nil
+	}
+	defer stopTimers()
+
+	flush := func() bool {
+		stopTimers()
+
+		groups := make([]*targetgroup.Group, 0, len(order))
+		for _, source := range order {
+			groups = append(groups, pending[source])
+		}
+		pending = map[string]*targetgroup.Group{}
+		order = nil
+
+		select {
+		case up <- groups:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case groups := <-ch:
+			for _, group := range groups {
+				if group == nil {
+					continue
+				}
+				if _, ok := pending[group.Source]; !ok {
+					order = append(order, group.Source)
+				}
+				pending[group.Source] = group
+			}
+
+			if periodTimer != nil {
+				periodTimer.Stop()
+			}
+			periodTimer = time.NewTimer(d.period)
+			periodC = periodTimer.C
+
+			if maxTimer == nil {
+				maxTimer = time.NewTimer(d.maxPeriod)
+				maxC = maxTimer.C
+			}
+
+		case <-periodC:
+			if !flush() {
+				return
+			}
+		case <-maxC:
+			if !flush() {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package filesd implements the discovery.file_sd component.
+package filesd
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/common/model"
+	prom_discovery "github.com/prometheus/prometheus/discovery/file"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.file_sd",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// patFileSDName matches the file names supported by Prometheus file-based
+// service discovery: JSON and YAML files, with an optional glob in the last
+// path element. It is copied from the upstream package, which doesn't export
+// it.
+var patFileSDName = regexp.MustCompile(`^[^*]*(\*[^/]*)?\.(json|yml|yaml|JSON|YML|YAML)$`)
+
+// Arguments configures the discovery.file_sd component.
+type Arguments struct {
+	Files           []string      `river:"files,attr"`
+	RefreshInterval time.Duration `river:"refresh_interval,attr,optional"`
+
+	// DebouncePeriod is how long target files must be left unchanged before
+	// their targets are exported.
+	DebouncePeriod time.Duration `river:"debounce_period,attr,optional"`
+
+	// MaxDebouncePeriod bounds how long exporting targets may be delayed when
+	// target files keep changing.
+	MaxDebouncePeriod time.Duration `river:"max_debounce_period,attr,optional"`
+}
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	RefreshInterval:   5 * time.Minute,
+	DebouncePeriod:    time.Second,
+	MaxDebouncePeriod: 10 * time.Second,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if len(args.Files) == 0 {
+		return fmt.Errorf("files must contain at least one path")
+	}
+	for _, name := range args.Files {
+		if !patFileSDName.MatchString(name) {
+			return fmt.Errorf("path %q is not valid for file discovery, it must end in .json, .yml, or .yaml", name)
+		}
+	}
+	if args.RefreshInterval <= 0 {
+		return fmt.Errorf("refresh_interval must be greater than 0")
+	}
+	if args.DebouncePeriod < 0 {
+		return fmt.Errorf("debounce_period must not be negative")
+	}
+	if args.DebouncePeriod > 0 && args.MaxDebouncePeriod < args.DebouncePeriod {
+		return fmt.Errorf("max_debounce_period must not be less than debounce_period")
+	}
+	return nil
+}
+
+// Convert converts Arguments to the upstream Prometheus SD type.
+func (args Arguments) Convert() *prom_discovery.SDConfig {
+	return &prom_discovery.SDConfig{
+		Files:           args.Files,
+		RefreshInterval: model.Duration(args.RefreshInterval),
+	}
+}
+
+// New returns a new instance of a discovery.file_sd component.
+func New(opts component.Options, args Arguments) (component.Component, error) {
+	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
+		newArgs := args.(Arguments)
+		disc := prom_discovery.NewDiscovery(newArgs.Convert(), opts.Logger)
+		if newArgs.DebouncePeriod == 0 {
+			return disc, nil
+		}
+		return newDebouncer(disc, newArgs.DebouncePeriod, newArgs.MaxDebouncePeriod), nil
+	})
+}
@@ -0,0 +1,167 @@
+package filesd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/common/model"
+	prom_discovery "github.com/prometheus/prometheus/discovery/file"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	riverConfig := `
+	files = ["/etc/targets/*.json", "/etc/targets/static.yml"]
+	refresh_interval = "1m"
+`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverConfig), &args))
+	require.Equal(t, time.Minute, args.RefreshInterval)
+	require.Equal(t, DefaultArguments.DebouncePeriod, args.DebouncePeriod)
+	require.Equal(t, DefaultArguments.MaxDebouncePeriod, args.MaxDebouncePeriod)
+}
+
+func TestUnmarshalRiverInvalid(t *testing.T) {
+	tt := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name:        "no files",
+			config:      `files = []`,
+			expectedErr: "files must contain at least one path",
+		},
+		{
+			name:        "unsupported extension",
+			config:      `files = ["/etc/targets/*.txt"]`,
+			expectedErr: `path "/etc/targets/*.txt" is not valid for file discovery`,
+		},
+		{
+			name:        "glob in directory",
+			config:      `files = ["/etc/*/targets.json"]`,
+			expectedErr: `path "/etc/*/targets.json" is not valid for file discovery`,
+		},
+		{
+			name: "max debounce period too short",
+			config: `
+	files = ["/etc/targets/*.json"]
+	debounce_period = "5s"
+	max_debounce_period = "1s"
+`,
+			expectedErr: "max_debounce_period must not be less than debounce_period",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+// fakeDiscoverer sends target groups received on ch.
+type fakeDiscoverer struct {
+	ch chan []*targetgroup.Group
+}
+
+func (d *fakeDiscoverer) Run(ctx context.Context, up chan<- []*targetgroup.Group) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case groups := <-d.ch:
+			select {
+			case up <- groups:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func group(source string, addrs ...string) *targetgroup.Group {
+	g := &targetgroup.Group{Source: source}
+	for _, addr := range addrs {
+		g.Targets = append(g.Targets, model.LabelSet{model.AddressLabel: model.LabelValue(addr)})
+	}
+	return g
+}
+
+func TestDebouncer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := &fakeDiscoverer{ch: make(chan []*targetgroup.Group)}
+	d := newDebouncer(fake, 50*time.Millisecond, time.Minute)
+
+	up := make(chan []*targetgroup.Group)
+	go d.Run(ctx, up)
+
+	// Updates sent in quick succession are combined, keeping the latest version
+	// of each group.
+	fake.ch <- []*targetgroup.Group{group("a", "a:1")}
+	fake.ch <- []*targetgroup.Group{group("b", "b:1")}
+	fake.ch <- []*targetgroup.Group{group("a", "a:2")}
+
+	select {
+	case groups := <-up:
+		require.Equal(t, []*targetgroup.Group{group("a", "a:2"), group("b", "b:1")}, groups)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no update received")
+	}
+}
+
+func TestDebouncer_MaxPeriod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := &fakeDiscoverer{ch: make(chan []*targetgroup.Group)}
+	d := newDebouncer(fake, time.Hour, 50*time.Millisecond)
+
+	up := make(chan []*targetgroup.Group)
+	go d.Run(ctx, up)
+
+	fake.ch <- []*targetgroup.Group{group("a", "a:1")}
+
+	select {
+	case groups := <-up:
+		require.Equal(t, []*targetgroup.Group{group("a", "a:1")}, groups)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "update was not sent after max_debounce_period")
+	}
+}
+
+func TestDebouncer_FileDiscovery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "targets.json"), []byte(`[{"targets": ["localhost:9090"], "labels": {"env": "test"}}]`), 0644)
+	require.NoError(t, err)
+
+	args := DefaultArguments
+	args.Files = []string{filepath.Join(dir, "*.json")}
+	args.DebouncePeriod = 10 * time.Millisecond
+
+	d := newDebouncer(prom_discovery.NewDiscovery(args.Convert(), util.TestLogger(t)), args.DebouncePeriod, args.MaxDebouncePeriod)
+	up := make(chan []*targetgroup.Group)
+	go d.Run(ctx, up)
+
+	select {
+	case groups := <-up:
+		require.Len(t, groups, 1)
+		require.Equal(t, []model.LabelSet{{model.AddressLabel: "localhost:9090"}}, groups[0].Targets)
+		require.Equal(t, model.LabelValue("test"), groups[0].Labels["env"])
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no targets discovered")
+	}
+}
@@ -0,0 +1,83 @@
+// Package http implements the discovery.http component.
+package http
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/common/model"
+	prom_discovery "github.com/prometheus/prometheus/discovery/http"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.http",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the discovery.http component.
+type Arguments struct {
+	HTTPClientConfig config.HTTPClientConfig `river:",squash"`
+	RefreshInterval  time.Duration           `river:"refresh_interval,attr,optional"`
+	URL              config.URL              `river:"url,attr"`
+}
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	HTTPClientConfig: config.DefaultHTTPClientConfig,
+	RefreshInterval:  60 * time.Second,
+}
+
+// UnmarshalRiver implements river.Unmarshaler, applying defaults and
+// validating the provided config.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.URL.URL == nil {
+		return fmt.Errorf("url must not be empty")
+	}
+	if args.URL.Scheme != "http" && args.URL.Scheme != "https" {
+		return fmt.Errorf("url scheme must be http or https")
+	}
+	if args.URL.Host == "" {
+		return fmt.Errorf("url must contain a host")
+	}
+
+	if args.RefreshInterval <= 0 {
+		return fmt.Errorf("refresh_interval must be greater than 0")
+	}
+
+	// We must explicitly Validate because HTTPClientConfig is squashed and it
+	// won't run otherwise.
+	return args.HTTPClientConfig.Validate()
+}
+
+// Convert converts Arguments to the upstream Prometheus SD type.
+func (args Arguments) Convert() *prom_discovery.SDConfig {
+	return &prom_discovery.SDConfig{
+		HTTPClientConfig: *args.HTTPClientConfig.Convert(),
+		RefreshInterval:  model.Duration(args.RefreshInterval),
+		URL:              args.URL.String(),
+	}
+}
+
+// New returns a new instance of a discovery.http component.
+func New(opts component.Options, args Arguments) (component.Component, error) {
+	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
+		return prom_discovery.NewDiscovery(args.(Arguments).Convert(), opts.Logger, nil)
+	})
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/model"
+	prom_discovery "github.com/prometheus/prometheus/discovery/http"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	url              = "https://cmdb.example.com/targets"
+	refresh_interval = "5m"
+	bearer_token     = "token"
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	sd := args.Convert()
+	require.Equal(t, "https://cmdb.example.com/targets", sd.URL)
+	require.Equal(t, 5*time.Minute, time.Duration(sd.RefreshInterval))
+	require.Equal(t, "Bearer", sd.HTTPClientConfig.Authorization.Type)
+	require.Equal(t, "token", string(sd.HTTPClientConfig.Authorization.Credentials))
+}
+
+func TestBadRiverConfig(t *testing.T) {
+	tt := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name:        "bad scheme",
+			config:      `url = "ftp://cmdb.example.com/targets"`,
+			expectedErr: "url scheme must be http or https",
+		},
+		{
+			name:        "missing host",
+			config:      `url = "http:///targets"`,
+			expectedErr: "url must contain a host",
+		},
+		{
+			name: "bad refresh interval",
+			config: `
+	url              = "http://cmdb.example.com/targets"
+	refresh_interval = "0s"
+`,
+			expectedErr: "refresh_interval must be greater than 0",
+		},
+		{
+			name: "multiple auth methods",
+			config: `
+	url          = "http://cmdb.example.com/targets"
+	bearer_token = "token"
+	basic_auth {
+		username = "user"
+	}
+`,
+			expectedErr: "at most one of basic_auth, oauth2, bearer_token & bearer_token_file must be configured",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"targets": ["10.0.0.1:9100"], "labels": {"team": "a"}}]`))
+	}))
+	defer srv.Close()
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`url = "`+srv.URL+`"`), &args))
+
+	d, err := prom_discovery.NewDiscovery(args.Convert(), nil, nil)
+	require.NoError(t, err)
+
+	groups, err := d.Refresh(context.Background())
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Equal(t, []model.LabelSet{{model.AddressLabel: "10.0.0.1:9100"}}, groups[0].Targets)
+	require.Equal(t, model.LabelValue("a"), groups[0].Labels["team"])
+}
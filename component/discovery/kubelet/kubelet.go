@@ -0,0 +1,306 @@
+// Package kubelet implements the discovery.kubelet component.
+package kubelet
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/kubernetes"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/util/strutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclient "k8s.io/client-go/kubernetes"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.kubelet",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Labels of targets which carry the authentication settings for scraping the
+// kubelet. They're understood by prometheus.scrape.
+const (
+	bearerTokenFileLabel = "__bearer_token_file__"
+	tlsCAFileLabel       = "__tls_ca_file__"
+)
+
+const (
+	metaLabelPrefix      = model.MetaLabelPrefix + "kubernetes_node_"
+	nodeNameLabel        = metaLabelPrefix + "name"
+	nodeLabelPrefix      = metaLabelPrefix + "label_"
+	nodeAddressPrefix    = metaLabelPrefix + "address_"
+	metricsPathLabelName = "metrics_path"
+)
+
+// Arguments holds values which are used to configure the discovery.kubelet
+// component.
+type Arguments struct {
+	NodeName        string        `river:"node_name,attr"`
+	MetricsPaths    []string      `river:"metrics_paths,attr,optional"`
+	BearerTokenFile string        `river:"bearer_token_file,attr,optional"`
+	CAFile          string        `river:"ca_file,attr,optional"`
+	RefreshInterval time.Duration `river:"refresh_interval,attr,optional"`
+
+	// Client settings to connect to Kubernetes.
+	Client kubernetes.ClientArguments `river:"client,block,optional"`
+}
+
+// DefaultArguments holds default settings for discovery.kubelet.
+var DefaultArguments = Arguments{
+	MetricsPaths:    []string{"/metrics", "/metrics/cadvisor", "/metrics/probes"},
+	BearerTokenFile: "/var/run/secrets/kubernetes.io/serviceaccount/token",
+	CAFile:          "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+	RefreshInterval: time.Minute,
+
+	Client: kubernetes.DefaultClientArguments,
+}
+
+// UnmarshalRiver implements river.Unmarshaler and applies defaults.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.NodeName == "" {
+		return fmt.Errorf("node_name must not be an empty string")
+	}
+	if len(args.MetricsPaths) == 0 {
+		return fmt.Errorf("metrics_paths must not be empty")
+	}
+	for _, p := range args.MetricsPaths {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("metrics path %q must start with /", p)
+		}
+	}
+	if args.RefreshInterval <= 0 {
+		return fmt.Errorf("refresh_interval must be greater than 0")
+	}
+	return nil
+}
+
+// Component implements the discovery.kubelet component, which resolves the
+// kubelet endpoints of a node and exports them as targets.
+type Component struct {
+	opts component.Options
+
+	mut    sync.Mutex
+	args   Arguments
+	client kubeclient.Interface
+	reload chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+
+	changes *discovery.ChangeTracker
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+	_ component.DebugComponent  = (*Component)(nil)
+)
+
+// New creates a new discovery.kubelet component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		reload:  make(chan struct{}, 1),
+		changes: discovery.NewChangeTracker(),
+	}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	c.mut.Lock()
+	ticker := time.NewTicker(c.args.RefreshInterval)
+	c.mut.Unlock()
+	defer ticker.Stop()
+
+	for {
+		c.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-c.reload:
+			c.mut.Lock()
+			ticker.Reset(c.args.RefreshInterval)
+			c.mut.Unlock()
+		}
+	}
+}
+
+// refresh looks up the node and exports its kubelet targets. On failure, the
+// previously exported targets are kept.
+func (c *Component) refresh(ctx context.Context) {
+	c.mut.Lock()
+	args, client := c.args, c.client
+	c.mut.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, args.RefreshInterval)
+	defer cancel()
+
+	node, err := client.CoreV1().Nodes().Get(ctx, args.NodeName, metav1.GetOptions{})
+	if err == nil {
+		var targets []discovery.Target
+		targets, err = buildTargets(node, args)
+		if err == nil {
+			c.changes.Update(targets)
+			c.opts.OnStateChange(discovery.Exports{Targets: targets})
+		}
+	}
+
+	if err != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to resolve kubelet endpoints", "node", args.NodeName, "err", err)
+		c.setHealth(component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("failed to resolve kubelet endpoints: %s", err),
+			UpdateTime: time.Now(),
+		})
+		return
+	}
+	c.setHealth(component.Health{
+		Health:     component.HealthTypeHealthy,
+		Message:    "resolved kubelet endpoints",
+		UpdateTime: time.Now(),
+	})
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	newArgs := args.(Arguments)
+
+	// Create a new client if we don't have one or if our client arguments
+	// changed.
+	if c.client == nil || !reflect.DeepEqual(c.args.Client, newArgs.Client) {
+		restConfig, err := newArgs.Client.BuildRESTConfig(c.opts.Logger, c.opts.Registerer)
+		if err != nil {
+			return fmt.Errorf("building Kubernetes client config: %w", err)
+		}
+		client, err := kubeclient.NewForConfig(restConfig)
+		if err != nil {
+			return fmt.Errorf("creating Kubernetes client: %w", err)
+		}
+		c.client = client
+	}
+	c.args = newArgs
+
+	select {
+	case c.reload <- struct{}{}:
+	default:
+		// no-op: reload already queued.
+	}
+	return nil
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	defer c.healthMut.RUnlock()
+	return c.health
+}
+
+// DebugInfo implements component.DebugComponent. It reports how the targets
+// changed in the latest update.
+func (c *Component) DebugInfo() interface{} {
+	return c.changes.DebugInfo()
+}
+
+func (c *Component) setHealth(h component.Health) {
+	c.healthMut.Lock()
+	defer c.healthMut.Unlock()
+	c.health = h
+}
+
+// buildTargets returns a target for every metrics path of the kubelet running
+// on node.
+func buildTargets(node *corev1.Node, args Arguments) ([]discovery.Target, error) {
+	addr, err := nodeAddress(node)
+	if err != nil {
+		return nil, err
+	}
+	port := node.Status.DaemonEndpoints.KubeletEndpoint.Port
+	if port == 0 {
+		return nil, fmt.Errorf("node %s does not report a kubelet port", node.Name)
+	}
+
+	base := discovery.Target{
+		model.AddressLabel: net.JoinHostPort(addr, strconv.Itoa(int(port))),
+		model.SchemeLabel:  "https",
+		nodeNameLabel:      node.Name,
+		"node":             node.Name,
+	}
+	if args.BearerTokenFile != "" {
+		base[bearerTokenFileLabel] = args.BearerTokenFile
+	}
+	if args.CAFile != "" {
+		base[tlsCAFileLabel] = args.CAFile
+	}
+	for k, v := range node.Labels {
+		base[nodeLabelPrefix+strutil.SanitizeLabelName(k)] = v
+	}
+	for _, a := range node.Status.Addresses {
+		name := nodeAddressPrefix + string(a.Type)
+		if _, ok := base[name]; !ok {
+			base[name] = a.Address
+		}
+	}
+
+	targets := make([]discovery.Target, 0, len(args.MetricsPaths))
+	for _, p := range args.MetricsPaths {
+		target := make(discovery.Target, len(base)+2)
+		for k, v := range base {
+			target[k] = v
+		}
+		target[model.MetricsPathLabel] = p
+		target[metricsPathLabelName] = p
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// nodeAddress returns the address of node used to reach its kubelet, in the
+// same order of preference as the Kubernetes node role of discovery.kubernetes.
+func nodeAddress(node *corev1.Node) (string, error) {
+	for _, t := range []corev1.NodeAddressType{
+		corev1.NodeInternalIP,
+		corev1.NodeInternalDNS,
+		corev1.NodeExternalIP,
+		corev1.NodeExternalDNS,
+		corev1.NodeHostName,
+	} {
+		for _, a := range node.Status.Addresses {
+			if a.Type == t {
+				return a.Address, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("node %s has no address", node.Name)
+}
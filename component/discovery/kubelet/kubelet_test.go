@@ -0,0 +1,206 @@
+package kubelet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	riverCfg := `
+		node_name        = "node-a"
+		metrics_paths    = ["/metrics/cadvisor"]
+		refresh_interval = "30s"
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+	require.Equal(t, "node-a", args.NodeName)
+	require.Equal(t, []string{"/metrics/cadvisor"}, args.MetricsPaths)
+	require.Equal(t, 30*time.Second, args.RefreshInterval)
+	require.Equal(t, DefaultArguments.BearerTokenFile, args.BearerTokenFile)
+	require.Equal(t, DefaultArguments.CAFile, args.CAFile)
+}
+
+func TestBadRiverConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{
+			name:   "empty node name",
+			config: `node_name = ""`,
+		},
+		{
+			name: "no metrics paths",
+			config: `
+				node_name     = "node-a"
+				metrics_paths = []
+			`,
+		},
+		{
+			name: "relative metrics path",
+			config: `
+				node_name     = "node-a"
+				metrics_paths = ["metrics"]
+			`,
+		},
+		{
+			name: "zero refresh interval",
+			config: `
+				node_name        = "node-a"
+				refresh_interval = "0s"
+			`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			require.Error(t, river.Unmarshal([]byte(tc.config), &args))
+		})
+	}
+}
+
+func TestBuildTargets(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-a",
+			Labels: map[string]string{"topology.kubernetes.io/zone": "zone-a"},
+		},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "node-a.local"},
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.2"},
+			},
+			DaemonEndpoints: corev1.NodeDaemonEndpoints{
+				KubeletEndpoint: corev1.DaemonEndpoint{Port: 10250},
+			},
+		},
+	}
+
+	args := DefaultArguments
+	args.NodeName = "node-a"
+	targets, err := buildTargets(node, args)
+	require.NoError(t, err)
+	require.Len(t, targets, 3)
+
+	base := discovery.Target{
+		"__address__":                 "10.0.0.1:10250",
+		"__scheme__":                  "https",
+		"__bearer_token_file__":       "/var/run/secrets/kubernetes.io/serviceaccount/token",
+		"__tls_ca_file__":             "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+		"__meta_kubernetes_node_name": "node-a",
+		"__meta_kubernetes_node_label_topology_kubernetes_io_zone": "zone-a",
+		"__meta_kubernetes_node_address_Hostname":                  "node-a.local",
+		"__meta_kubernetes_node_address_InternalIP":                "10.0.0.1",
+		"node": "node-a",
+	}
+	for i, p := range []string{"/metrics", "/metrics/cadvisor", "/metrics/probes"} {
+		expect := discovery.Target{"__metrics_path__": p, "metrics_path": p}
+		for k, v := range base {
+			expect[k] = v
+		}
+		require.Equal(t, expect, targets[i])
+	}
+}
+
+func TestBuildTargetsWithoutAuth(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "node-a.local"},
+			},
+			DaemonEndpoints: corev1.NodeDaemonEndpoints{
+				KubeletEndpoint: corev1.DaemonEndpoint{Port: 10250},
+			},
+		},
+	}
+
+	args := DefaultArguments
+	args.NodeName = "node-a"
+	args.BearerTokenFile = ""
+	args.CAFile = ""
+	targets, err := buildTargets(node, args)
+	require.NoError(t, err)
+	require.Equal(t, "node-a.local:10250", targets[0]["__address__"])
+	require.NotContains(t, targets[0], "__bearer_token_file__")
+	require.NotContains(t, targets[0], "__tls_ca_file__")
+}
+
+func TestBuildTargetsErrors(t *testing.T) {
+	args := DefaultArguments
+	args.NodeName = "node-a"
+
+	_, err := buildTargets(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Status: corev1.NodeStatus{
+			DaemonEndpoints: corev1.NodeDaemonEndpoints{
+				KubeletEndpoint: corev1.DaemonEndpoint{Port: 10250},
+			},
+		},
+	}, args)
+	require.EqualError(t, err, "node node-a has no address")
+
+	_, err = buildTargets(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}},
+		},
+	}, args)
+	require.EqualError(t, err, "node node-a does not report a kubelet port")
+}
+
+func TestRun(t *testing.T) {
+	exports := make(chan discovery.Exports, 1)
+	c := &Component{
+		opts: component.Options{
+			Logger: util.TestFlowLogger(t),
+			OnStateChange: func(e component.Exports) {
+				exports <- e.(discovery.Exports)
+			},
+		},
+		args: Arguments{
+			NodeName:        "node-a",
+			MetricsPaths:    []string{"/metrics/cadvisor"},
+			RefreshInterval: time.Minute,
+		},
+		client: fake.NewSimpleClientset(&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+			Status: corev1.NodeStatus{
+				Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}},
+				DaemonEndpoints: corev1.NodeDaemonEndpoints{
+					KubeletEndpoint: corev1.DaemonEndpoint{Port: 10250},
+				},
+			},
+		}),
+		reload:  make(chan struct{}, 1),
+		changes: discovery.NewChangeTracker(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx) //nolint:errcheck
+
+	select {
+	case e := <-exports:
+		require.Len(t, e.Targets, 1)
+		require.Equal(t, "10.0.0.1:10250", e.Targets[0]["__address__"])
+		require.Equal(t, "/metrics/cadvisor", e.Targets[0]["__metrics_path__"])
+		require.Equal(t, 1, c.DebugInfo().(discovery.ChangesDebugInfo).Added)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for targets")
+	}
+	require.Eventually(t, func() bool {
+		return c.CurrentHealth().Health == component.HealthTypeHealthy
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -2,10 +2,16 @@
 package kubernetes
 
 import (
+	"fmt"
+
+	"github.com/go-kit/log"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/config"
+	commonk8s "github.com/grafana/agent/component/common/kubernetes"
 	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/client_golang/prometheus"
 	promk8s "github.com/prometheus/prometheus/discovery/kubernetes"
+	"k8s.io/client-go/metadata"
 )
 
 func init() {
@@ -28,6 +34,7 @@ type Arguments struct {
 	HTTPClientConfig   config.HTTPClientConfig `river:",squash"`
 	NamespaceDiscovery NamespaceDiscovery      `river:"namespaces,block,optional"`
 	Selectors          []SelectorConfig        `river:"selectors,block,optional"`
+	AttachMetadata     AttachMetadata          `river:"attach_metadata,block,optional"`
 }
 
 // DefaultConfig holds defaults for SDConfig.
@@ -44,6 +51,14 @@ func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 		return err
 	}
 
+	switch promk8s.Role(args.Role) {
+	case promk8s.RolePod, promk8s.RoleEndpoint, promk8s.RoleEndpointSlice:
+	default:
+		if args.AttachMetadata.Enabled() {
+			return fmt.Errorf("attach_metadata is only supported for the pod, endpoints, and endpointslice roles")
+		}
+	}
+
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	return args.HTTPClientConfig.Validate()
 }
@@ -96,6 +111,37 @@ func (sc *SelectorConfig) convert() *promk8s.SelectorConfig {
 func New(opts component.Options, args Arguments) (component.Component, error) {
 	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
 		newArgs := args.(Arguments)
-		return promk8s.New(opts.Logger, newArgs.Convert())
+		d, err := promk8s.New(opts.Logger, newArgs.Convert())
+		if err != nil || !newArgs.AttachMetadata.Enabled() {
+			return d, err
+		}
+		return newArgs.newMetadataDiscoverer(opts.Logger, opts.Registerer, d)
 	})
 }
+
+// newMetadataDiscoverer wraps inner to attach metadata to its targets.
+func (args *Arguments) newMetadataDiscoverer(logger log.Logger, reg prometheus.Registerer, inner discovery.Discoverer) (discovery.Discoverer, error) {
+	clientArgs := commonk8s.ClientArguments{
+		APIServer:        args.APIServer,
+		KubeConfig:       args.KubeConfig,
+		HTTPClientConfig: args.HTTPClientConfig,
+	}
+	restConfig, err := clientArgs.BuildRESTConfig(logger, reg)
+	if err != nil {
+		return nil, fmt.Errorf("creating Kubernetes client config: %w", err)
+	}
+	client, err := metadata.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("creating Kubernetes metadata client: %w", err)
+	}
+
+	namespaces := args.NamespaceDiscovery.Names
+	if args.NamespaceDiscovery.IncludeOwnNamespace {
+		ns, err := ownNamespace()
+		if err != nil {
+			return nil, err
+		}
+		namespaces = append(append([]string{}, namespaces...), ns)
+	}
+	return newMetadataDiscoverer(inner, logger, client, namespaces, args.AttachMetadata), nil
+}
@@ -33,3 +33,16 @@ func TestBadRiverConfig(t *testing.T) {
 	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
+
+func TestAttachMetadataRole(t *testing.T) {
+	var exampleRiverConfig = `
+	role = "service"
+	attach_metadata {
+		node = true
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.ErrorContains(t, err, "attach_metadata is only supported for the pod, endpoints, and endpointslice roles")
+}
@@ -0,0 +1,309 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/util/strutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	metaLabelPrefix = model.MetaLabelPrefix + "kubernetes_"
+
+	namespaceLabel        = metaLabelPrefix + "namespace"
+	podNodeNameLabel      = metaLabelPrefix + "pod_node_name"
+	endpointNodeNameLabel = metaLabelPrefix + "endpoint_node_name"
+	podControllerKind     = metaLabelPrefix + "pod_controller_kind"
+	podControllerName     = metaLabelPrefix + "pod_controller_name"
+
+	nodeLabelPrefix             = metaLabelPrefix + "node_label_"
+	nodeLabelPresentPrefix      = metaLabelPrefix + "node_labelpresent_"
+	nodeAnnotationPrefix        = metaLabelPrefix + "node_annotation_"
+	nodeAnnotationPresentPrefix = metaLabelPrefix + "node_annotationpresent_"
+	podOwnerKind                = metaLabelPrefix + "pod_owner_kind"
+	podOwnerName                = metaLabelPrefix + "pod_owner_name"
+
+	ownNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+var (
+	nodesResource       = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	replicaSetsResource = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+	jobsResource        = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+)
+
+// AttachMetadata configures which metadata is attached to discovered targets.
+type AttachMetadata struct {
+	Node  bool `river:"node,attr,optional"`
+	Owner bool `river:"owner,attr,optional"`
+}
+
+// Enabled returns true if any metadata should be attached.
+func (am AttachMetadata) Enabled() bool {
+	return am.Node || am.Owner
+}
+
+// metadataDiscoverer wraps the discoverer of a pod, endpoints, or
+// endpointslice role to attach the metadata of the nodes and owners of pods
+// to their targets. The metadata is read from informers which only cache the
+// metadata of objects, rather than the whole object.
+type metadataDiscoverer struct {
+	inner      discovery.Discoverer
+	logger     log.Logger
+	client     metadata.Interface
+	namespaces []string // Empty for all namespaces.
+	attach     AttachMetadata
+
+	nodes       keyGetter
+	replicaSets keyGetter
+	jobs        keyGetter
+}
+
+func newMetadataDiscoverer(inner discovery.Discoverer, logger log.Logger, client metadata.Interface, namespaces []string, attach AttachMetadata) *metadataDiscoverer {
+	return &metadataDiscoverer{
+		inner:      inner,
+		logger:     logger,
+		client:     client,
+		namespaces: namespaces,
+		attach:     attach,
+	}
+}
+
+// Run implements discovery.Discoverer.
+func (d *metadataDiscoverer) Run(ctx context.Context, up chan<- []*targetgroup.Group) {
+	changed := make(chan struct{}, 1)
+	onChange := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	var synced []cache.InformerSynced
+	if d.attach.Node {
+		inf := d.startInformer(ctx, metav1.NamespaceAll, nodesResource, onChange)
+		d.nodes, synced = inf.GetStore(), append(synced, inf.HasSynced)
+	}
+	if d.attach.Owner {
+		replicaSets, jobs := make(multiStore, 0, len(d.namespaces)), make(multiStore, 0, len(d.namespaces))
+		for _, ns := range d.namespacesOrAll() {
+			rsInf := d.startInformer(ctx, ns, replicaSetsResource, onChange)
+			jobInf := d.startInformer(ctx, ns, jobsResource, onChange)
+			replicaSets, jobs = append(replicaSets, rsInf.GetStore()), append(jobs, jobInf.GetStore())
+			synced = append(synced, rsInf.HasSynced, jobInf.HasSynced)
+		}
+		d.replicaSets, d.jobs = replicaSets, jobs
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		if ctx.Err() == nil {
+			level.Error(d.logger).Log("msg", "failed to sync metadata informers")
+		}
+		return
+	}
+
+	innerCh := make(chan []*targetgroup.Group)
+	go d.inner.Run(ctx, innerCh)
+
+	var (
+		groups = make(map[string]*targetgroup.Group) // Groups received from inner.
+		sent   = make(map[string]*targetgroup.Group) // Groups sent to up.
+	)
+	for {
+		var out []*targetgroup.Group
+
+		select {
+		case <-ctx.Done():
+			return
+		case tgs := <-innerCh:
+			for _, tg := range tgs {
+				if tg == nil {
+					continue
+				}
+				enriched := d.enrich(tg)
+				groups[tg.Source], sent[tg.Source] = tg, enriched
+				out = append(out, enriched)
+			}
+		case <-changed:
+			// Only resend the groups whose metadata changed.
+			for source, tg := range groups {
+				enriched := d.enrich(tg)
+				if reflect.DeepEqual(enriched, sent[source]) {
+					continue
+				}
+				sent[source] = enriched
+				out = append(out, enriched)
+			}
+		}
+
+		if len(out) == 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case up <- out:
+		}
+	}
+}
+
+func (d *metadataDiscoverer) namespacesOrAll() []string {
+	if len(d.namespaces) == 0 {
+		return []string{metav1.NamespaceAll}
+	}
+	return d.namespaces
+}
+
+// startInformer starts an informer for the metadata of a resource. onChange
+// is called when the labels, annotations, or owners of an object change.
+func (d *metadataDiscoverer) startInformer(ctx context.Context, namespace string, gvr schema.GroupVersionResource, onChange func()) cache.SharedIndexInformer {
+	inf := metadatainformer.NewFilteredMetadataInformer(d.client, gvr, namespace, 0, cache.Indexers{}, nil).Informer()
+	_, err := inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) { onChange() },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// Objects such as nodes are updated frequently, but their metadata
+			// rarely changes.
+			oldMeta, ok1 := oldObj.(*metav1.PartialObjectMetadata)
+			newMeta, ok2 := newObj.(*metav1.PartialObjectMetadata)
+			if ok1 && ok2 &&
+				reflect.DeepEqual(oldMeta.Labels, newMeta.Labels) &&
+				reflect.DeepEqual(oldMeta.Annotations, newMeta.Annotations) &&
+				reflect.DeepEqual(oldMeta.OwnerReferences, newMeta.OwnerReferences) {
+				return
+			}
+			onChange()
+		},
+	})
+	if err != nil {
+		level.Warn(d.logger).Log("msg", "failed to watch for metadata changes", "resource", gvr.Resource, "err", err)
+	}
+	go inf.Run(ctx.Done())
+	return inf
+}
+
+// enrich returns a copy of tg with metadata attached to its targets.
+func (d *metadataDiscoverer) enrich(tg *targetgroup.Group) *targetgroup.Group {
+	res := &targetgroup.Group{
+		Source:  tg.Source,
+		Labels:  tg.Labels,
+		Targets: make([]model.LabelSet, 0, len(tg.Targets)),
+	}
+	for _, target := range tg.Targets {
+		// Pod metadata is either attached to the group or to the target,
+		// depending on the role.
+		get := func(name model.LabelName) string {
+			if v, ok := target[name]; ok {
+				return string(v)
+			}
+			return string(tg.Labels[name])
+		}
+
+		extra := make(model.LabelSet)
+		if d.attach.Node {
+			nodeName := get(podNodeNameLabel)
+			if nodeName == "" {
+				nodeName = get(endpointNodeNameLabel)
+			}
+			d.addNodeMetadata(extra, nodeName)
+		}
+		if d.attach.Owner {
+			d.addOwnerMetadata(extra, get(namespaceLabel), get(podControllerKind), get(podControllerName))
+		}
+		res.Targets = append(res.Targets, target.Merge(extra))
+	}
+	return res
+}
+
+func (d *metadataDiscoverer) addNodeMetadata(ls model.LabelSet, nodeName string) {
+	node := getMetadata(d.nodes, nodeName)
+	if node == nil {
+		return
+	}
+	for k, v := range node.Labels {
+		ln := strutil.SanitizeLabelName(k)
+		ls[model.LabelName(nodeLabelPrefix+ln)] = model.LabelValue(v)
+		ls[model.LabelName(nodeLabelPresentPrefix+ln)] = "true"
+	}
+	for k, v := range node.Annotations {
+		ln := strutil.SanitizeLabelName(k)
+		ls[model.LabelName(nodeAnnotationPrefix+ln)] = model.LabelValue(v)
+		ls[model.LabelName(nodeAnnotationPresentPrefix+ln)] = "true"
+	}
+}
+
+// addOwnerMetadata attaches the top-level owner of a pod with the given
+// controller. ReplicaSets owned by Deployments and Jobs owned by CronJobs are
+// resolved to their owner; other controllers are their own owner.
+func (d *metadataDiscoverer) addOwnerMetadata(ls model.LabelSet, namespace, kind, name string) {
+	if kind == "" {
+		return
+	}
+
+	var store keyGetter
+	switch kind {
+	case "ReplicaSet":
+		store = d.replicaSets
+	case "Job":
+		store = d.jobs
+	}
+	if obj := getMetadata(store, namespace+"/"+name); obj != nil {
+		if ref := metav1.GetControllerOf(obj); ref != nil {
+			kind, name = ref.Kind, ref.Name
+		}
+	}
+
+	ls[podOwnerKind] = model.LabelValue(kind)
+	ls[podOwnerName] = model.LabelValue(name)
+}
+
+func getMetadata(store keyGetter, key string) *metav1.PartialObjectMetadata {
+	if store == nil || key == "" {
+		return nil
+	}
+	obj, exists, err := store.GetByKey(key)
+	if err != nil || !exists {
+		return nil
+	}
+	meta, _ := obj.(*metav1.PartialObjectMetadata)
+	return meta
+}
+
+// keyGetter looks up objects by their key.
+type keyGetter interface {
+	GetByKey(key string) (item interface{}, exists bool, err error)
+}
+
+// multiStore looks up objects in multiple stores, such as the stores of
+// informers for different namespaces.
+type multiStore []cache.Store
+
+func (ms multiStore) GetByKey(key string) (interface{}, bool, error) {
+	for _, s := range ms {
+		obj, exists, err := s.GetByKey(key)
+		if err != nil || exists {
+			return obj, exists, err
+		}
+	}
+	return nil, false, nil
+}
+
+// ownNamespace returns the namespace of the pod Grafana Agent is running in.
+func ownNamespace() (string, error) {
+	b, err := os.ReadFile(ownNamespaceFile)
+	if err != nil {
+		return "", fmt.Errorf("reading own namespace: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
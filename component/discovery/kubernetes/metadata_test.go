@@ -0,0 +1,110 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/metadata/fake"
+)
+
+// staticDiscoverer sends a fixed set of target groups.
+type staticDiscoverer []*targetgroup.Group
+
+func (d staticDiscoverer) Run(ctx context.Context, up chan<- []*targetgroup.Group) {
+	select {
+	case <-ctx.Done():
+	case up <- d:
+	}
+	<-ctx.Done()
+}
+
+func TestMetadataDiscoverer(t *testing.T) {
+	isController := true
+	objects := []runtime.Object{
+		&metav1.PartialObjectMetadata{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Node"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "node-a",
+				Labels:      map[string]string{"topology.kubernetes.io/zone": "zone-a"},
+				Annotations: map[string]string{"team": "infra"},
+			},
+		},
+		&metav1.PartialObjectMetadata{
+			TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "ReplicaSet"},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "app-5d9c7b",
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "apps/v1", Kind: "Deployment", Name: "app", Controller: &isController},
+				},
+			},
+		},
+	}
+
+	scheme := fake.NewTestScheme()
+	require.NoError(t, metav1.AddMetaToScheme(scheme))
+	client := fake.NewSimpleMetadataClient(scheme, objects...)
+
+	inner := staticDiscoverer{
+		{
+			Source: "pod/default/app-5d9c7b-x1",
+			Labels: model.LabelSet{
+				namespaceLabel:    "default",
+				podNodeNameLabel:  "node-a",
+				podControllerKind: "ReplicaSet",
+				podControllerName: "app-5d9c7b",
+			},
+			Targets: []model.LabelSet{{model.AddressLabel: "10.0.0.1:8080"}},
+		},
+		{
+			Source: "pod/default/db-0",
+			Labels: model.LabelSet{
+				namespaceLabel:    "default",
+				podNodeNameLabel:  "node-b",
+				podControllerKind: "StatefulSet",
+				podControllerName: "db",
+			},
+			Targets: []model.LabelSet{{model.AddressLabel: "10.0.0.2:5432"}},
+		},
+	}
+	d := newMetadataDiscoverer(inner, util.TestLogger(t), client, nil, AttachMetadata{Node: true, Owner: true})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	up := make(chan []*targetgroup.Group)
+	go d.Run(ctx, up)
+
+	var tgs []*targetgroup.Group
+	select {
+	case tgs = <-up:
+	case <-ctx.Done():
+		require.FailNow(t, "no target groups received")
+	}
+	require.Len(t, tgs, 2)
+
+	require.Equal(t, model.LabelSet{
+		model.AddressLabel: "10.0.0.1:8080",
+		"__meta_kubernetes_node_label_topology_kubernetes_io_zone":        "zone-a",
+		"__meta_kubernetes_node_labelpresent_topology_kubernetes_io_zone": "true",
+		"__meta_kubernetes_node_annotation_team":                          "infra",
+		"__meta_kubernetes_node_annotationpresent_team":                   "true",
+		"__meta_kubernetes_pod_owner_kind":                                "Deployment",
+		"__meta_kubernetes_pod_owner_name":                                "app",
+	}, tgs[0].Targets[0])
+
+	// Unknown nodes don't add any labels, and controllers which aren't owned
+	// by another controller are their own owner.
+	require.Equal(t, model.LabelSet{
+		model.AddressLabel:                 "10.0.0.2:5432",
+		"__meta_kubernetes_pod_owner_kind": "StatefulSet",
+		"__meta_kubernetes_pod_owner_name": "db",
+	}, tgs[1].Targets[0])
+}
@@ -0,0 +1,195 @@
+// Package merge implements the discovery.merge component.
+package merge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.merge",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Supported values for the precedence argument, which decides which of two
+// duplicate targets is kept.
+const (
+	// PrecedenceFirst keeps the target which appears first in the input.
+	PrecedenceFirst = "first"
+	// PrecedenceLast keeps the target which appears last in the input.
+	PrecedenceLast = "last"
+)
+
+// Arguments holds values which are used to configure the discovery.merge
+// component.
+type Arguments struct {
+	// Targets holds the lists of targets to merge.
+	Targets [][]discovery.Target `river:"targets,attr"`
+
+	// KeyLabels are labels which, together with the address, identify a
+	// target. Targets with the same key are duplicates.
+	KeyLabels []string `river:"key_labels,attr,optional"`
+
+	// Precedence decides which duplicate target is kept.
+	Precedence string `river:"precedence,attr,optional"`
+
+	// MergeLabels combines the labels of duplicate targets instead of
+	// dropping all but one of them. Labels of the target with precedence win
+	// when duplicates have different values for the same label.
+	MergeLabels bool `river:"merge_labels,attr,optional"`
+}
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	Precedence: PrecedenceFirst,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	switch args.Precedence {
+	case PrecedenceFirst, PrecedenceLast:
+	default:
+		return fmt.Errorf("invalid precedence %q, must be %q or %q", args.Precedence, PrecedenceFirst, PrecedenceLast)
+	}
+	for _, name := range args.KeyLabels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid key label %q", name)
+		}
+	}
+	return nil
+}
+
+// Component implements the discovery.merge component.
+type Component struct {
+	opts component.Options
+
+	mut     sync.Mutex
+	changes *discovery.ChangeTracker
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new discovery.merge component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		changes: discovery.NewChangeTracker(),
+	}
+
+	// Call to Update() to set the output once at the start
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	targets := Merge(args.(Arguments))
+
+	c.changes.Update(targets)
+	c.opts.OnStateChange(discovery.Exports{Targets: targets})
+	return nil
+}
+
+// DebugInfo implements component.DebugComponent. It reports how the merged
+// targets changed in the latest update.
+func (c *Component) DebugInfo() interface{} {
+	return c.changes.DebugInfo()
+}
+
+// Merge merges the target lists of args into a single list without
+// duplicates. Targets are returned in the order in which they first appear in
+// the input, even if a later duplicate takes precedence.
+func Merge(args Arguments) []discovery.Target {
+	var (
+		res   []discovery.Target
+		index = map[string]int{} // Target key -> index in res.
+	)
+
+	for _, list := range args.Targets {
+		for _, t := range list {
+			key := targetKey(t, args.KeyLabels)
+
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(res)
+				res = append(res, t)
+				continue
+			}
+
+			// Decide which of the duplicates wins, then optionally fill in the
+			// labels only the other duplicate has.
+			winner, loser := res[i], t
+			if args.Precedence == PrecedenceLast {
+				winner, loser = t, res[i]
+			}
+			if args.MergeLabels {
+				winner = mergeLabels(winner, loser)
+			}
+			res[i] = winner
+		}
+	}
+
+	return res
+}
+
+// targetKey returns the key identifying t, built from its address and the
+// values of keyLabels.
+func targetKey(t discovery.Target, keyLabels []string) string {
+	var sb strings.Builder
+	sb.WriteString(t[model.AddressLabel])
+	for _, name := range keyLabels {
+		// Separate values with a byte which isn't valid in label values to
+		// avoid collisions.
+		sb.WriteByte('\xff')
+		sb.WriteString(t[name])
+	}
+	return sb.String()
+}
+
+// mergeLabels returns a new target with the labels of both winner and loser.
+// The labels of winner take precedence.
+func mergeLabels(winner, loser discovery.Target) discovery.Target {
+	res := make(discovery.Target, len(winner)+len(loser))
+	for k, v := range loser {
+		res[k] = v
+	}
+	for k, v := range winner {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,120 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	riverConfig := `
+	targets = [
+		[{ "__address__" = "localhost:9090", "job" = "static" }],
+		[{ "__address__" = "localhost:9090", "job" = "sd" }, { "__address__" = "localhost:9100" }],
+	]
+	key_labels = ["job"]
+`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverConfig), &args))
+	require.Len(t, args.Targets, 2)
+	require.Equal(t, PrecedenceFirst, args.Precedence)
+	require.Equal(t, []string{"job"}, args.KeyLabels)
+	require.False(t, args.MergeLabels)
+}
+
+func TestUnmarshalRiverInvalid(t *testing.T) {
+	tt := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name: "invalid precedence",
+			config: `
+	targets = []
+	precedence = "middle"
+`,
+			expectedErr: `invalid precedence "middle"`,
+		},
+		{
+			name: "invalid key label",
+			config: `
+	targets = []
+	key_labels = ["not-a-label"]
+`,
+			expectedErr: `invalid key label "not-a-label"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	static := []discovery.Target{
+		{"__address__": "a:80", "env": "prod"},
+		{"__address__": "b:80"},
+	}
+	dynamic := []discovery.Target{
+		{"__address__": "a:80", "env": "dev", "zone": "eu"},
+		{"__address__": "c:80"},
+	}
+
+	tt := []struct {
+		name   string
+		args   Arguments
+		expect []discovery.Target
+	}{
+		{
+			name: "first wins",
+			args: Arguments{Precedence: PrecedenceFirst},
+			expect: []discovery.Target{
+				{"__address__": "a:80", "env": "prod"},
+				{"__address__": "b:80"},
+				{"__address__": "c:80"},
+			},
+		},
+		{
+			name: "last wins",
+			args: Arguments{Precedence: PrecedenceLast},
+			expect: []discovery.Target{
+				{"__address__": "a:80", "env": "dev", "zone": "eu"},
+				{"__address__": "b:80"},
+				{"__address__": "c:80"},
+			},
+		},
+		{
+			name: "merge labels",
+			args: Arguments{Precedence: PrecedenceFirst, MergeLabels: true},
+			expect: []discovery.Target{
+				{"__address__": "a:80", "env": "prod", "zone": "eu"},
+				{"__address__": "b:80"},
+				{"__address__": "c:80"},
+			},
+		},
+		{
+			name: "key labels",
+			args: Arguments{Precedence: PrecedenceFirst, KeyLabels: []string{"env"}},
+			expect: []discovery.Target{
+				{"__address__": "a:80", "env": "prod"},
+				{"__address__": "b:80"},
+				{"__address__": "a:80", "env": "dev", "zone": "eu"},
+				{"__address__": "c:80"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.args.Targets = [][]discovery.Target{static, dynamic}
+			require.Equal(t, tc.expect, Merge(tc.args))
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Package nomad implements the discovery.nomad component.
+package nomad
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/common/model"
+	prom_discovery "github.com/prometheus/prometheus/discovery/nomad"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.nomad",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the discovery.nomad component.
+type Arguments struct {
+	AllowStale       bool                    `river:"allow_stale,attr,optional"`
+	HTTPClientConfig config.HTTPClientConfig `river:",squash"`
+	Namespace        string                  `river:"namespace,attr,optional"`
+	RefreshInterval  time.Duration           `river:"refresh_interval,attr,optional"`
+	Region           string                  `river:"region,attr,optional"`
+	Server           string                  `river:"server,attr,optional"`
+	TagSeparator     string                  `river:"tag_separator,attr,optional"`
+}
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	AllowStale:       true,
+	HTTPClientConfig: config.DefaultHTTPClientConfig,
+	Namespace:        "default",
+	RefreshInterval:  60 * time.Second,
+	Region:           "global",
+	Server:           "http://localhost:4646",
+	TagSeparator:     ",",
+}
+
+// UnmarshalRiver implements river.Unmarshaler, applying defaults and
+// validating the provided config.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.Server == "" {
+		return fmt.Errorf("server attribute must not be empty")
+	} else if _, err := url.Parse(args.Server); err != nil {
+		return fmt.Errorf("parsing server attribute: %w", err)
+	}
+
+	if args.RefreshInterval <= 0 {
+		return fmt.Errorf("refresh_interval must be greater than 0")
+	}
+
+	return args.HTTPClientConfig.Validate()
+}
+
+// Convert converts Arguments to the upstream Prometheus SD type.
+func (args Arguments) Convert() *prom_discovery.SDConfig {
+	return &prom_discovery.SDConfig{
+		AllowStale:       args.AllowStale,
+		HTTPClientConfig: *args.HTTPClientConfig.Convert(),
+		Namespace:        args.Namespace,
+		RefreshInterval:  model.Duration(args.RefreshInterval),
+		Region:           args.Region,
+		Server:           args.Server,
+		TagSeparator:     args.TagSeparator,
+	}
+}
+
+// New returns a new instance of a discovery.nomad component.
+func New(opts component.Options, args Arguments) (component.Component, error) {
+	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
+		return prom_discovery.NewDiscovery(args.(Arguments).Convert(), opts.Logger)
+	})
+}
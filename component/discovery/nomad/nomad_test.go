@@ -0,0 +1,45 @@
+package nomad
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	server    = "http://nomad.example.com:4646"
+	namespace = "apps"
+	tls_config {
+		insecure_skip_verify = true
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	sd := args.Convert()
+	require.Equal(t, "http://nomad.example.com:4646", sd.Server)
+	require.Equal(t, "apps", sd.Namespace)
+	require.Equal(t, "global", sd.Region)
+	require.True(t, sd.AllowStale)
+	require.Equal(t, time.Minute, time.Duration(sd.RefreshInterval))
+	require.True(t, sd.HTTPClientConfig.TLSConfig.InsecureSkipVerify)
+}
+
+func TestBadRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	bearer_token      = "token"
+	bearer_token_file = "/path/to/file.token"
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
+
+	err = river.Unmarshal([]byte(`refresh_interval = "0s"`), &args)
+	require.ErrorContains(t, err, "refresh_interval must be greater than 0")
+}
@@ -44,13 +44,21 @@ type Component struct {
 
 	mut sync.RWMutex
 	rcs []*relabel.Config
+
+	changes *discovery.ChangeTracker
 }
 
-var _ component.Component = (*Component)(nil)
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
 
 // New creates a new discovery.relabel component.
 func New(o component.Options, args Arguments) (*Component, error) {
-	c := &Component{opts: o}
+	c := &Component{
+		opts:    o,
+		changes: discovery.NewChangeTracker(),
+	}
 
 	// Call to Update() to set the output once at the start
 	if err := c.Update(args); err != nil {
@@ -85,6 +93,7 @@ func (c *Component) Update(args component.Arguments) error {
 		}
 	}
 
+	c.changes.Update(targets)
 	c.opts.OnStateChange(Exports{
 		Output: targets,
 		Rules:  newArgs.RelabelConfigs,
@@ -93,6 +102,12 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+// DebugInfo implements component.DebugComponent. It reports how the output
+// targets changed in the latest update.
+func (c *Component) DebugInfo() interface{} {
+	return c.changes.DebugInfo()
+}
+
 func componentMapToPromLabels(ls discovery.Target) labels.Labels {
 	res := make([]labels.Label, 0, len(ls))
 	for k, v := range ls {
@@ -0,0 +1,170 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/process/internal/stages"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+var _ component.CaptureComponent = (*Component)(nil)
+
+// dryRunInput is a log entry processed during a dry run.
+type dryRunInput struct {
+	Labels    map[string]string `json:"labels"`
+	Line      string            `json:"line"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// Capture implements component.CaptureComponent. Entries are captured as
+// they're received, before they're processed by the pipeline.
+func (c *Component) Capture(ctx context.Context, fn func(input json.RawMessage)) {
+	c.capture.Tap(ctx, func(record string) { fn(json.RawMessage(record)) })
+}
+
+func (c *Component) publishCapture(entry loki.Entry) {
+	in := dryRunInput{
+		Labels:    make(map[string]string, len(entry.Labels)),
+		Line:      entry.Line,
+		Timestamp: entry.Timestamp,
+	}
+	for name, value := range entry.Labels {
+		in.Labels[string(name)] = string(value)
+	}
+
+	bb, err := json.Marshal(in)
+	if err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to encode captured entry", "err", err)
+		return
+	}
+	c.capture.Publish(string(bb))
+}
+
+// DryRun implements component.DryRunComponent. Every stage is built again to
+// process the entry, so that the changes made by each stage can be reported
+// and the running pipeline isn't affected.
+func (c *Component) DryRun(input json.RawMessage) ([]string, error) {
+	var in dryRunInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return nil, fmt.Errorf("decoding input: %w", err)
+	}
+	if in.Timestamp.IsZero() {
+		in.Timestamp = time.Now()
+	}
+
+	c.mut.RLock()
+	cfgs := c.stages
+	c.mut.RUnlock()
+
+	// Like in the pipeline, the extracted map starts with the labels of the
+	// entry.
+	labels := make(model.LabelSet, len(in.Labels))
+	extracted := make(map[string]interface{}, len(in.Labels))
+	for name, value := range in.Labels {
+		labels[model.LabelName(name)] = model.LabelValue(value)
+		extracted[name] = value
+	}
+	entries := []stages.Entry{{
+		Extracted: extracted,
+		Entry: loki.Entry{
+			Labels: labels,
+			Entry:  logproto.Entry{Timestamp: in.Timestamp, Line: in.Line},
+		},
+	}}
+
+	var steps []string
+	for _, cfg := range cfgs {
+		stage, err := stages.New(c.opts.Logger, &c.opts.ID, cfg, prometheus.NewRegistry())
+		if err != nil {
+			return nil, err
+		}
+
+		before := describeEntries(entries)
+		entries = runStage(stage, copyEntries(entries))
+
+		name := stageName(cfg)
+		switch {
+		case len(entries) == 0:
+			steps = append(steps, fmt.Sprintf("%s: dropped", name))
+			return steps, nil
+		case describeEntries(entries) == before:
+			steps = append(steps, fmt.Sprintf("%s: unchanged", name))
+		default:
+			for _, e := range entries {
+				steps = append(steps, fmt.Sprintf("%s: %s", name, describeEntry(e)))
+			}
+		}
+	}
+
+	for _, e := range entries {
+		steps = append(steps, fmt.Sprintf("output: %s %s %q", e.Labels, e.Timestamp.Format(time.RFC3339Nano), e.Line))
+	}
+	return steps, nil
+}
+
+// runStage sends entries through s and returns the entries s outputs.
+func runStage(s stages.Stage, entries []stages.Entry) []stages.Entry {
+	in := make(chan stages.Entry, len(entries))
+	for _, e := range entries {
+		in <- e
+	}
+	close(in)
+
+	var out []stages.Entry
+	for e := range s.Run(in) {
+		out = append(out, e)
+	}
+	return out
+}
+
+// copyEntries copies the labels and extracted values of entries, which
+// stages modify in place.
+func copyEntries(entries []stages.Entry) []stages.Entry {
+	res := make([]stages.Entry, 0, len(entries))
+	for _, e := range entries {
+		extracted := make(map[string]interface{}, len(e.Extracted))
+		for k, v := range e.Extracted {
+			extracted[k] = v
+		}
+		e.Extracted = extracted
+		e.Labels = e.Labels.Clone()
+		res = append(res, e)
+	}
+	return res
+}
+
+func describeEntries(entries []stages.Entry) string {
+	descs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		descs = append(descs, describeEntry(e))
+	}
+	return strings.Join(descs, "\n")
+}
+
+func describeEntry(e stages.Entry) string {
+	return fmt.Sprintf("%s %s %q extracted=%v", e.Labels, e.Timestamp.Format(time.RFC3339Nano), e.Line, e.Extracted)
+}
+
+// stageName returns the name of the block which configures the stage of cfg,
+// such as stage.json.
+func stageName(cfg stages.StageConfig) string {
+	v := reflect.ValueOf(cfg)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsNil() {
+			continue
+		}
+		tag := v.Type().Field(i).Tag.Get("river")
+		return "stage." + strings.SplitN(tag, ",", 2)[0]
+	}
+	return "stage"
+}
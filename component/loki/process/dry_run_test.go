@@ -0,0 +1,121 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/process/internal/stages"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	stg := `
+		stage.json {
+			expressions = { level = "" }
+		}
+		stage.label_drop {
+			values = [ "unused" ]
+		}
+		stage.labels {
+			values = { level = "" }
+		}
+		stage.drop {
+			source = "level"
+			value  = "debug"
+		}`
+
+	type cfg struct {
+		Stages []stages.StageConfig `river:"stage,enum"`
+	}
+	var stagesCfg cfg
+	require.NoError(t, river.Unmarshal([]byte(stg), &stagesCfg))
+
+	c, err := New(component.Options{
+		ID:            "loki.process.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}, Arguments{Stages: stagesCfg.Stages})
+	require.NoError(t, err)
+
+	// The pipeline built by New is only stopped once the component exits.
+	ctx, cancel := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		_ = c.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-exited
+	}()
+
+	steps, err := c.DryRun([]byte(`{"labels": {"app": "web"}, "line": "{\"level\": \"error\"}", "timestamp": "2023-04-01T00:00:00Z"}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`stage.json: {app="web"} 2023-04-01T00:00:00Z "{\"level\": \"error\"}" extracted=map[app:web level:error]`,
+		`stage.label_drop: unchanged`,
+		`stage.labels: {app="web", level="error"} 2023-04-01T00:00:00Z "{\"level\": \"error\"}" extracted=map[app:web level:error]`,
+		`stage.drop: unchanged`,
+		`output: {app="web", level="error"} 2023-04-01T00:00:00Z "{\"level\": \"error\"}"`,
+	}, steps)
+
+	steps, err = c.DryRun([]byte(`{"line": "{\"level\": \"debug\"}", "timestamp": "2023-04-01T00:00:00Z"}`))
+	require.NoError(t, err)
+	require.Equal(t, `stage.drop: dropped`, steps[len(steps)-1])
+
+	_, err = c.DryRun([]byte(`{`))
+	require.Error(t, err)
+}
+
+func TestCapture(t *testing.T) {
+	c, err := New(component.Options{
+		ID:            "loki.process.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}, Arguments{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		_ = c.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-exited
+	}()
+
+	captured := make(chan json.RawMessage, 1)
+	go c.Capture(ctx, func(input json.RawMessage) { captured <- input })
+	require.Eventually(t, c.capture.Active, 5*time.Second, 10*time.Millisecond)
+
+	ts := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	c.receiver <- loki.Entry{
+		Labels: model.LabelSet{"app": "web"},
+		Entry:  logproto.Entry{Timestamp: ts, Line: "hello"},
+	}
+
+	select {
+	case input := <-captured:
+		require.JSONEq(t, `{"labels": {"app": "web"}, "line": "hello", "timestamp": "2023-04-01T00:00:00Z"}`, string(input))
+
+		// The captured entry can be processed by a dry run.
+		steps, err := c.DryRun(input)
+		require.NoError(t, err)
+		require.Equal(t, []string{`output: {app="web"} 2023-04-01T00:00:00Z "hello"`}, steps)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "failed waiting for captured entry")
+	}
+}
@@ -0,0 +1,38 @@
+package stages
+
+// This package is ported over from grafana/loki/clients/pkg/logentry/stages.
+// We aim to port the stages in steps, to avoid introducing huge amounts of
+// new code without being able to slowly review, examine and test them.
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// ansiRegex matches ANSI escape sequences, courtesy of
+// https://github.com/acarl005/stripansi.
+var ansiRegex = regexp.MustCompile("[\u001B\u009B][[\\]()#;?]*(?:(?:(?:[a-zA-Z\\d]*(?:;[a-zA-Z\\d]*)*)?\u0007)|(?:(?:\\d{1,4}(?:;\\d{0,4})*)?[\\dA-PRZcf-ntqry=><~]))")
+
+// DecolorizeConfig configures the decolorize stage, which takes no
+// arguments.
+type DecolorizeConfig struct{}
+
+// decolorizeStage removes ANSI escape sequences, such as the ones used to
+// color terminal output, from log lines.
+type decolorizeStage struct{}
+
+func newDecolorizeStage(_ DecolorizeConfig) Stage {
+	return toStage(&decolorizeStage{})
+}
+
+// Process implements Processor.
+func (m *decolorizeStage) Process(_ model.LabelSet, _ map[string]interface{}, _ *time.Time, entry *string) {
+	*entry = ansiRegex.ReplaceAllString(*entry, "")
+}
+
+// Name implements Stage.
+func (m *decolorizeStage) Name() string {
+	return StageTypeDecolorize
+}
@@ -0,0 +1,42 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testDecolorizeRiver = `stage.decolorize {}`
+
+func TestDecolorize(t *testing.T) {
+	tests := map[string]struct {
+		line string
+		want string
+	}{
+		"uncolored line": {
+			line: "sample text",
+			want: "sample text",
+		},
+		"colored line": {
+			line: "\033[0;32mgreen\033[0m \033[0;31mred\033[0m",
+			want: "green red",
+		},
+		"bold and underlined line": {
+			line: "\033[1mbold\033[22m and \033[4munderlined\033[24m",
+			want: "bold and underlined",
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			pl, err := newPipelineFromConfig(testDecolorizeRiver, "test")
+			require.NoError(t, err)
+
+			out := processEntries(pl, newEntry(nil, nil, tc.line, time.Now()))
+			require.Len(t, out, 1)
+			require.Equal(t, tc.want, out[0].Line)
+		})
+	}
+}
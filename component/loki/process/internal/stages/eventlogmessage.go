@@ -0,0 +1,154 @@
+package stages
+
+// This package is ported over from grafana/loki/clients/pkg/logentry/stages.
+// We aim to port the stages in steps, to avoid introducing huge amounts of
+// new code without being able to slowly review, examine and test them.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+)
+
+const defaultEventLogMessageSource = "message"
+
+// EventLogMessageConfig configures the eventlogmessage stage.
+type EventLogMessageConfig struct {
+	Source            string `river:"source,attr,optional"`
+	DropInvalidLabels bool   `river:"drop_invalid_labels,attr,optional"`
+	OverwriteExisting bool   `river:"overwrite_existing,attr,optional"`
+}
+
+// DefaultEventLogMessageConfig sets the default values of the
+// eventlogmessage stage.
+var DefaultEventLogMessageConfig = EventLogMessageConfig{
+	Source: defaultEventLogMessageSource,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (c *EventLogMessageConfig) UnmarshalRiver(f func(interface{}) error) error {
+	*c = DefaultEventLogMessageConfig
+
+	type cfg EventLogMessageConfig
+	return f((*cfg)(c))
+}
+
+// validateEventLogMessageConfig checks that the source is a valid label name.
+func validateEventLogMessageConfig(c EventLogMessageConfig) error {
+	if !model.LabelName(c.Source).IsValid() {
+		return fmt.Errorf(ErrInvalidLabelName, c.Source)
+	}
+	return nil
+}
+
+// newEventLogMessageStage creates a stage which extracts the key-value pairs
+// of the message of a Windows event.
+func newEventLogMessageStage(logger log.Logger, cfg EventLogMessageConfig) (Stage, error) {
+	err := validateEventLogMessageConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &eventLogMessageStage{
+		cfg:    cfg,
+		logger: log.With(logger, "component", "stage", "type", "eventlogmessage"),
+	}, nil
+}
+
+type eventLogMessageStage struct {
+	cfg    EventLogMessageConfig
+	logger log.Logger
+}
+
+// Run implements Stage.
+func (m *eventLogMessageStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			err := m.processEntry(e.Extracted, m.cfg.Source)
+			if err != nil {
+				continue
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+// processEntry splits the message in the extracted map at key into lines of
+// key-value pairs separated by a colon, and adds them to the extracted map.
+func (m *eventLogMessageStage) processEntry(extracted map[string]interface{}, key string) error {
+	value, ok := extracted[key]
+	if !ok {
+		if Debug {
+			level.Debug(m.logger).Log("msg", "source not in the extracted values", "source", key)
+		}
+		return nil
+	}
+	s, err := getString(value)
+	if err != nil {
+		level.Warn(m.logger).Log("msg", "invalid label value parsed", "value", value)
+		return err
+	}
+	lines := strings.Split(s, "\r\n")
+	for _, line := range lines {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) < 2 {
+			level.Warn(m.logger).Log("msg", "invalid line parsed from message", "line", line)
+			continue
+		}
+		mkey := parts[0]
+		if !model.LabelName(mkey).IsValid() {
+			if m.cfg.DropInvalidLabels {
+				if Debug {
+					level.Debug(m.logger).Log("msg", "invalid label parsed from message", "key", mkey)
+				}
+				continue
+			}
+			mkey = SanitizeFullLabelName(mkey)
+		}
+		if _, ok := extracted[mkey]; ok && !m.cfg.OverwriteExisting {
+			level.Info(m.logger).Log("msg", "extracted key that already existed, appending _extracted to key",
+				"key", mkey)
+			mkey += "_extracted"
+		}
+		mval := strings.TrimSpace(parts[1])
+		if !model.LabelValue(mval).IsValid() {
+			if Debug {
+				level.Debug(m.logger).Log("msg", "invalid value parsed from message", "value", mval)
+			}
+			continue
+		}
+		extracted[mkey] = mval
+	}
+	if Debug {
+		level.Debug(m.logger).Log("msg", "extracted data debug in eventlogmessage stage",
+			"extracted data", fmt.Sprintf("%v", extracted))
+	}
+	return nil
+}
+
+// Name implements Stage.
+func (m *eventLogMessageStage) Name() string {
+	return StageTypeEventLogMessage
+}
+
+// SanitizeFullLabelName converts input into a valid Prometheus label name,
+// replacing invalid characters with underscores.
+func SanitizeFullLabelName(input string) string {
+	if len(input) == 0 {
+		return "_"
+	}
+	var validSb strings.Builder
+	for i, b := range input {
+		if !((b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || b == '_' || (b >= '0' && b <= '9' && i > 0)) {
+			validSb.WriteRune('_')
+		} else {
+			validSb.WriteRune(b)
+		}
+	}
+	return validSb.String()
+}
@@ -0,0 +1,130 @@
+package stages
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+var testEvtLogMsgRiverDefaults = `stage.eventlogmessage {}`
+
+var testEvtLogMsgRiverCustomSource = `
+stage.eventlogmessage {
+		source = "Message"
+}`
+
+var testEvtLogMsgRiverDropInvalidLabels = `
+stage.eventlogmessage {
+		drop_invalid_labels = true
+}`
+
+var testEvtLogMsgRiverOverwriteExisting = `
+stage.eventlogmessage {
+		overwrite_existing = true
+}`
+
+var (
+	testEvtLogMsgSimple        = "Key1: Value 1\r\nKey2: Value 2\r\nKey3: Value: 3"
+	testEvtLogMsgInvalidLabels = "Key 1: Value 1\r\n0Key2: Value 2\r\nKey@3: Value 3\r\n: Value 4"
+	testEvtLogMsgOverwriteTest = "test: new value"
+)
+
+func TestEventLogMessage(t *testing.T) {
+	tests := map[string]struct {
+		config          string
+		sourcekey       string
+		msgdata         string
+		extractedValues map[string]interface{}
+	}{
+		"default source": {
+			testEvtLogMsgRiverDefaults,
+			"message",
+			testEvtLogMsgSimple,
+			map[string]interface{}{
+				"Key1": "Value 1",
+				"Key2": "Value 2",
+				"Key3": "Value: 3",
+				"test": "existing value",
+			},
+		},
+		"custom source": {
+			testEvtLogMsgRiverCustomSource,
+			"Message",
+			testEvtLogMsgSimple,
+			map[string]interface{}{
+				"Key1": "Value 1",
+				"Key2": "Value 2",
+				"Key3": "Value: 3",
+				"test": "existing value",
+			},
+		},
+		"sanitize invalid labels": {
+			testEvtLogMsgRiverDefaults,
+			"message",
+			testEvtLogMsgInvalidLabels,
+			map[string]interface{}{
+				"Key_1": "Value 1",
+				"_Key2": "Value 2",
+				"Key_3": "Value 3",
+				"_":     "Value 4",
+				"test":  "existing value",
+			},
+		},
+		"drop invalid labels": {
+			testEvtLogMsgRiverDropInvalidLabels,
+			"message",
+			testEvtLogMsgInvalidLabels,
+			map[string]interface{}{
+				"test": "existing value",
+			},
+		},
+		"keep existing values": {
+			testEvtLogMsgRiverDefaults,
+			"message",
+			testEvtLogMsgOverwriteTest,
+			map[string]interface{}{
+				"test":           "existing value",
+				"test_extracted": "new value",
+			},
+		},
+		"overwrite existing values": {
+			testEvtLogMsgRiverOverwriteExisting,
+			"message",
+			testEvtLogMsgOverwriteTest,
+			map[string]interface{}{
+				"test": "new value",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc.extractedValues[tc.sourcekey] = tc.msgdata
+
+		t.Run(name, func(t *testing.T) {
+			pl, err := NewPipeline(util_log.Logger, loadConfig(tc.config), nil, prometheus.NewRegistry())
+			require.NoError(t, err)
+
+			out := processEntries(pl, newEntry(map[string]interface{}{
+				tc.sourcekey: tc.msgdata,
+				"test":       "existing value",
+			}, nil, tc.msgdata, time.Now()))
+			require.Len(t, out, 1)
+			require.Equal(t, tc.extractedValues, out[0].Extracted)
+		})
+	}
+}
+
+func TestEventLogMessage_InvalidSource(t *testing.T) {
+	_, err := newEventLogMessageStage(util_log.Logger, EventLogMessageConfig{Source: "the message"})
+	require.EqualError(t, err, fmt.Sprintf(ErrInvalidLabelName, "the message"))
+}
+
+func TestSanitizeFullLabelName(t *testing.T) {
+	require.Equal(t, "_", SanitizeFullLabelName(""))
+	require.Equal(t, "_Key_1", SanitizeFullLabelName("0Key 1"))
+	require.Equal(t, "Key1", SanitizeFullLabelName("Key1"))
+}
@@ -0,0 +1,206 @@
+package stages
+
+// This package is ported over from grafana/loki/clients/pkg/logentry/stages.
+// We aim to port the stages in steps, to avoid introducing huge amounts of
+// new code without being able to slowly review, examine and test them.
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oschwald/geoip2-golang"
+	"github.com/prometheus/common/model"
+)
+
+// Configuration errors.
+const (
+	ErrEmptyDBPathGeoIPStageConfig   = "db path cannot be empty"
+	ErrEmptySourceGeoIPStageConfig   = "source cannot be empty"
+	ErrInvalidDBTypeGeoIPStageConfig = "db type should be either city or asn"
+)
+
+// Supported database types.
+const (
+	geoIPDBTypeCity = "city"
+	geoIPDBTypeASN  = "asn"
+)
+
+// GeoIPConfig represents GeoIP stage config
+type GeoIPConfig struct {
+	DB     string `river:"db,attr"`
+	Source string `river:"source,attr"`
+	DBType string `river:"db_type,attr"`
+}
+
+func validateGeoIPConfig(c GeoIPConfig) error {
+	if c.DB == "" {
+		return errors.New(ErrEmptyDBPathGeoIPStageConfig)
+	}
+
+	if c.Source == "" {
+		return errors.New(ErrEmptySourceGeoIPStageConfig)
+	}
+
+	if c.DBType != geoIPDBTypeCity && c.DBType != geoIPDBTypeASN {
+		return errors.New(ErrInvalidDBTypeGeoIPStageConfig)
+	}
+
+	return nil
+}
+
+// geoIPReader looks up IP addresses in a MaxMind database. It's implemented
+// by *geoip2.Reader.
+type geoIPReader interface {
+	City(ip net.IP) (*geoip2.City, error)
+	ASN(ip net.IP) (*geoip2.ASN, error)
+	Close() error
+}
+
+func newGeoIPStage(logger log.Logger, cfg GeoIPConfig) (Stage, error) {
+	err := validateGeoIPConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := geoip2.Open(cfg.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return &geoIPStage{
+		db:     db,
+		logger: log.With(logger, "component", "stage", "type", "geoip"),
+		cfg:    cfg,
+	}, nil
+}
+
+// geoIPStage sets labels from the geolocation of an IP address in the
+// extracted map.
+type geoIPStage struct {
+	logger log.Logger
+	db     geoIPReader
+	cfg    GeoIPConfig
+}
+
+// Run implements Stage
+func (g *geoIPStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		defer g.close()
+		for e := range in {
+			g.process(e.Labels, e.Extracted)
+			out <- e
+		}
+	}()
+	return out
+}
+
+// Name implements Stage
+func (g *geoIPStage) Name() string {
+	return StageTypeGeoIP
+}
+
+func (g *geoIPStage) process(labels model.LabelSet, extracted map[string]interface{}) {
+	value, ok := extracted[g.cfg.Source]
+	if !ok {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "source does not exist in the set of extracted values", "source", g.cfg.Source)
+		}
+		return
+	}
+
+	s, err := getString(value)
+	if err != nil {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "failed to convert source value to string", "source", g.cfg.Source, "err", err, "type", reflect.TypeOf(value))
+		}
+		return
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "source value is not an IP address", "source", g.cfg.Source, "value", s)
+		}
+		return
+	}
+
+	var values map[string]string
+	switch g.cfg.DBType {
+	case geoIPDBTypeCity:
+		record, err := g.db.City(ip)
+		if err != nil {
+			level.Error(g.logger).Log("msg", "unable to get City record for the ip", "err", err, "ip", ip)
+			return
+		}
+		values = cityValues(record)
+	case geoIPDBTypeASN:
+		record, err := g.db.ASN(ip)
+		if err != nil {
+			level.Error(g.logger).Log("msg", "unable to get ASN record for the ip", "err", err, "ip", ip)
+			return
+		}
+		values = asnValues(record)
+	}
+
+	// The values are also added to the extracted map, so that later stages
+	// can use them without turning them into labels.
+	for name, value := range values {
+		labels[model.LabelName(name)] = model.LabelValue(value)
+		extracted[name] = value
+	}
+}
+
+func (g *geoIPStage) close() {
+	if err := g.db.Close(); err != nil {
+		level.Error(g.logger).Log("msg", "error while closing geoip db", "err", err)
+	}
+}
+
+// cityValues returns the non-empty values of record by label name.
+func cityValues(record *geoip2.City) map[string]string {
+	values := map[string]string{
+		"geoip_city_name":      record.City.Names["en"],
+		"geoip_country_name":   record.Country.Names["en"],
+		"geoip_continent_name": record.Continent.Names["en"],
+		"geoip_continent_code": record.Continent.Code,
+		"geoip_postal_code":    record.Postal.Code,
+		"geoip_timezone":       record.Location.TimeZone,
+	}
+	if record.Location.Latitude != 0 || record.Location.Longitude != 0 {
+		values["geoip_location_latitude"] = fmt.Sprint(record.Location.Latitude)
+		values["geoip_location_longitude"] = fmt.Sprint(record.Location.Longitude)
+	}
+	if len(record.Subdivisions) > 0 {
+		// Use the most specific subdivision, see
+		// https://dev.maxmind.com/release-note/most-specific-subdivision-attribute-added/
+		subdivision := record.Subdivisions[len(record.Subdivisions)-1]
+		values["geoip_subdivision_name"] = subdivision.Names["en"]
+		values["geoip_subdivision_code"] = subdivision.IsoCode
+	}
+	return withoutEmptyValues(values)
+}
+
+// asnValues returns the non-empty values of record by label name.
+func asnValues(record *geoip2.ASN) map[string]string {
+	values := map[string]string{
+		"geoip_autonomous_system_organization": record.AutonomousSystemOrganization,
+	}
+	if record.AutonomousSystemNumber != 0 {
+		values["geoip_autonomous_system_number"] = fmt.Sprint(record.AutonomousSystemNumber)
+	}
+	return withoutEmptyValues(values)
+}
+
+func withoutEmptyValues(values map[string]string) map[string]string {
+	for name, value := range values {
+		if value == "" {
+			delete(values, name)
+		}
+	}
+	return values
+}
@@ -0,0 +1,117 @@
+package stages
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/oschwald/geoip2-golang"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateGeoIPConfig(t *testing.T) {
+	tests := []struct {
+		config    GeoIPConfig
+		wantError error
+	}{
+		{
+			GeoIPConfig{DB: "test", Source: "ip", DBType: "city"},
+			nil,
+		},
+		{
+			GeoIPConfig{Source: "ip", DBType: "city"},
+			errors.New(ErrEmptyDBPathGeoIPStageConfig),
+		},
+		{
+			GeoIPConfig{DB: "test", DBType: "city"},
+			errors.New(ErrEmptySourceGeoIPStageConfig),
+		},
+		{
+			GeoIPConfig{DB: "test", Source: "ip", DBType: "country"},
+			errors.New(ErrInvalidDBTypeGeoIPStageConfig),
+		},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.wantError, validateGeoIPConfig(tt.config))
+	}
+}
+
+type fakeGeoIPReader struct {
+	city *geoip2.City
+	asn  *geoip2.ASN
+}
+
+func (r fakeGeoIPReader) City(net.IP) (*geoip2.City, error) { return r.city, nil }
+func (r fakeGeoIPReader) ASN(net.IP) (*geoip2.ASN, error)   { return r.asn, nil }
+func (r fakeGeoIPReader) Close() error                      { return nil }
+
+func TestGeoIPStage_City(t *testing.T) {
+	city := &geoip2.City{}
+	city.City.Names = map[string]string{"en": "Stockholm"}
+	city.Country.Names = map[string]string{"en": "Sweden"}
+	city.Continent.Names = map[string]string{"en": "Europe"}
+	city.Continent.Code = "EU"
+	city.Location.Latitude = 59.3247
+	city.Location.Longitude = 18.056
+	city.Location.TimeZone = "Europe/Stockholm"
+	city.Subdivisions = make([]struct {
+		GeoNameID uint              `maxminddb:"geoname_id"`
+		IsoCode   string            `maxminddb:"iso_code"`
+		Names     map[string]string `maxminddb:"names"`
+	}, 1)
+	city.Subdivisions[0].Names = map[string]string{"en": "Stockholm County"}
+	city.Subdivisions[0].IsoCode = "AB"
+
+	s := &geoIPStage{
+		logger: util_log.Logger,
+		db:     fakeGeoIPReader{city: city},
+		cfg:    GeoIPConfig{DB: "test", Source: "ip", DBType: "city"},
+	}
+
+	out := processEntries(s, newEntry(map[string]interface{}{"ip": "89.160.20.112"}, model.LabelSet{"app": "web"}, "line", time.Now()))[0]
+	require.Equal(t, model.LabelSet{
+		"app":                      "web",
+		"geoip_city_name":          "Stockholm",
+		"geoip_country_name":       "Sweden",
+		"geoip_continent_name":     "Europe",
+		"geoip_continent_code":     "EU",
+		"geoip_location_latitude":  "59.3247",
+		"geoip_location_longitude": "18.056",
+		"geoip_timezone":           "Europe/Stockholm",
+		"geoip_subdivision_name":   "Stockholm County",
+		"geoip_subdivision_code":   "AB",
+	}, out.Labels)
+	require.Equal(t, "Sweden", out.Extracted["geoip_country_name"])
+}
+
+func TestGeoIPStage_ASN(t *testing.T) {
+	s := &geoIPStage{
+		logger: util_log.Logger,
+		db: fakeGeoIPReader{asn: &geoip2.ASN{
+			AutonomousSystemNumber:       29518,
+			AutonomousSystemOrganization: "Bredband2 AB",
+		}},
+		cfg: GeoIPConfig{DB: "test", Source: "ip", DBType: "asn"},
+	}
+
+	out := processEntries(s,
+		newEntry(map[string]interface{}{"ip": "89.160.20.112"}, model.LabelSet{}, "line", time.Now()),
+		newEntry(map[string]interface{}{"ip": "not an ip"}, model.LabelSet{}, "line", time.Now()),
+		newEntry(map[string]interface{}{}, model.LabelSet{}, "line", time.Now()),
+	)
+	require.Len(t, out, 3)
+	require.Equal(t, model.LabelSet{
+		"geoip_autonomous_system_number":       "29518",
+		"geoip_autonomous_system_organization": "Bredband2 AB",
+	}, out[0].Labels)
+	require.Empty(t, out[1].Labels)
+	require.Empty(t, out[2].Labels)
+}
+
+func TestNewGeoIPStage_MissingDB(t *testing.T) {
+	_, err := newGeoIPStage(util_log.Logger, GeoIPConfig{DB: "/nonexistent.mmdb", Source: "ip", DBType: "city"})
+	require.Error(t, err)
+}
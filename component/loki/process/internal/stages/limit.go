@@ -16,6 +16,8 @@ import (
 var (
 	ErrLimitStageInvalidRateOrBurst = errors.New("limit stage failed to parse rate or burst")
 	ErrLimitStageByLabelMustDrop    = errors.New("When ratelimiting by label, drop must be true")
+	ErrLimitStageByStreamMustDrop   = errors.New("When ratelimiting by stream, drop must be true")
+	ErrLimitStageByStreamAndLabel   = errors.New("by_stream and by_label_name can't be used together")
 	ratelimitDropReason             = "ratelimit_drop_stage"
 )
 
@@ -28,6 +30,7 @@ type LimitConfig struct {
 	Burst             int     `river:"burst,attr"`
 	Drop              bool    `river:"drop,attr,optional"`
 	ByLabelName       string  `river:"by_label_name,attr,optional"`
+	ByStream          bool    `river:"by_stream,attr,optional"`
 	MaxDistinctLabels int     `river:"max_distinct_labels,attr,optional"`
 }
 
@@ -38,7 +41,7 @@ func newLimitStage(logger log.Logger, cfg LimitConfig, registerer prometheus.Reg
 	}
 
 	logger = log.With(logger, "component", "stage", "type", "limit")
-	if cfg.ByLabelName != "" && cfg.MaxDistinctLabels < MinReasonableMaxDistinctLabels {
+	if (cfg.ByLabelName != "" || cfg.ByStream) && cfg.MaxDistinctLabels < MinReasonableMaxDistinctLabels {
 		level.Warn(logger).Log(
 			"msg",
 			fmt.Sprintf("max_distinct_labels was adjusted up to the minimal reasonable value of %d", MinReasonableMaxDistinctLabels),
@@ -52,12 +55,15 @@ func newLimitStage(logger log.Logger, cfg LimitConfig, registerer prometheus.Reg
 		dropCount: getDropCountMetric(registerer),
 	}
 
-	if cfg.ByLabelName != "" {
+	newRateLimiter := func() *rate.Limiter { return rate.NewLimiter(rate.Limit(cfg.Rate), cfg.Burst) }
+	switch {
+	case cfg.ByLabelName != "":
 		r.dropCountByLabel = getDropCountByLabelMetric(registerer)
-		newRateLimiter := func() *rate.Limiter { return rate.NewLimiter(rate.Limit(cfg.Rate), cfg.Burst) }
 		gcCb := func() { r.dropCountByLabel.Reset() }
 		r.rateLimiterByLabel = NewGenMap[model.LabelValue, *rate.Limiter](cfg.MaxDistinctLabels, newRateLimiter, gcCb)
-	} else {
+	case cfg.ByStream:
+		r.rateLimiterByStream = NewGenMap[model.Fingerprint, *rate.Limiter](cfg.MaxDistinctLabels, newRateLimiter, nil)
+	default:
 		r.rateLimiter = rate.NewLimiter(rate.Limit(cfg.Rate), cfg.Burst)
 	}
 
@@ -72,6 +78,14 @@ func validateLimitConfig(cfg LimitConfig) error {
 	if cfg.ByLabelName != "" && !cfg.Drop {
 		return ErrLimitStageByLabelMustDrop
 	}
+
+	if cfg.ByStream && !cfg.Drop {
+		return ErrLimitStageByStreamMustDrop
+	}
+
+	if cfg.ByStream && cfg.ByLabelName != "" {
+		return ErrLimitStageByStreamAndLabel
+	}
 	return nil
 }
 
@@ -81,8 +95,11 @@ type limitStage struct {
 	cfg                LimitConfig
 	rateLimiter        *rate.Limiter
 	rateLimiterByLabel GenerationalMap[model.LabelValue, *rate.Limiter]
-	dropCount          *prometheus.CounterVec
-	dropCountByLabel   *prometheus.CounterVec
+	// rateLimiterByStream holds a rate limiter per stream, keyed by the
+	// fingerprint of its labels.
+	rateLimiterByStream GenerationalMap[model.Fingerprint, *rate.Limiter]
+	dropCount           *prometheus.CounterVec
+	dropCountByLabel    *prometheus.CounterVec
 }
 
 func (m *limitStage) Run(in chan Entry) chan Entry {
@@ -114,6 +131,15 @@ func (m *limitStage) shouldThrottle(labels model.LabelSet) bool {
 		return true
 	}
 
+	if m.cfg.ByStream {
+		rl := m.rateLimiterByStream.GetOrCreate(labels.Fingerprint())
+		if rl.Allow() {
+			return false
+		}
+		m.dropCount.WithLabelValues(ratelimitDropReason).Inc()
+		return true
+	}
+
 	if m.cfg.Drop {
 		if m.rateLimiter.Allow() {
 			return false
@@ -143,3 +143,65 @@ func TestLimitByLabelPipeline(t *testing.T) {
 	assert.True(t, hasTotal)
 	assert.True(t, hasByLabel)
 }
+
+var testLimitByStreamRiver = `
+stage.limit {
+		rate  = 1
+		burst = 1
+		drop  = true
+
+		by_stream = true
+}`
+
+func TestLimitByStreamPipeline(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testLimitByStreamRiver), &plName, registry)
+	require.NoError(t, err)
+
+	logs := make([]Entry, 0)
+	logCount := 5
+	for i := 0; i < logCount; i++ {
+		logs = append(logs, newEntry(nil, model.LabelSet{"app": "loki", "level": "debug"}, testMatchLogLineApp1, time.Now()))
+		logs = append(logs, newEntry(nil, model.LabelSet{"app": "loki", "level": "info"}, testMatchLogLineApp2, time.Now()))
+		logs = append(logs, newEntry(nil, model.LabelSet{}, testNonAppLogLine, time.Now()))
+	}
+	out := processEntries(pl, logs...)
+
+	// Only the first entry of each stream goes through.
+	require.Len(t, out, 3)
+	assert.Equal(t, testMatchLogLineApp1, out[0].Line)
+	assert.Equal(t, testMatchLogLineApp2, out[1].Line)
+	assert.Equal(t, testNonAppLogLine, out[2].Line)
+
+	mfs, _ := registry.Gather()
+	for _, mf := range mfs {
+		if *mf.Name == "loki_process_dropped_lines_total" {
+			require.Len(t, mf.Metric, 1)
+			assert.Equal(t, 3*(logCount-1), int(mf.Metric[0].Counter.GetValue()))
+		}
+	}
+}
+
+func TestValidateLimitConfig(t *testing.T) {
+	tests := map[string]struct {
+		cfg LimitConfig
+		err error
+	}{
+		"valid":                 {cfg: LimitConfig{Rate: 1, Burst: 1}},
+		"missing rate":          {cfg: LimitConfig{Burst: 1}, err: ErrLimitStageInvalidRateOrBurst},
+		"by label without drop": {cfg: LimitConfig{Rate: 1, Burst: 1, ByLabelName: "app"}, err: ErrLimitStageByLabelMustDrop},
+		"by stream without drop": {
+			cfg: LimitConfig{Rate: 1, Burst: 1, ByStream: true},
+			err: ErrLimitStageByStreamMustDrop,
+		},
+		"by stream and label": {
+			cfg: LimitConfig{Rate: 1, Burst: 1, Drop: true, ByStream: true, ByLabelName: "app"},
+			err: ErrLimitStageByStreamAndLabel,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.err, validateLimitConfig(tc.cfg))
+		})
+	}
+}
@@ -32,6 +32,7 @@ type MultilineConfig struct {
 	Expression  string        `river:"firstline,attr"`
 	MaxLines    uint64        `river:"max_lines,attr,optional"`
 	MaxWaitTime time.Duration `river:"max_wait_time,attr,optional"`
+	MaxBlockAge time.Duration `river:"max_block_age,attr,optional"`
 	regex       *regexp.Regexp
 }
 
@@ -56,6 +57,9 @@ func (args *MultilineConfig) UnmarshalRiver(f func(interface{}) error) error {
 	if args.MaxWaitTime <= 0 {
 		return fmt.Errorf("max_wait_time must be greater than 0")
 	}
+	if args.MaxBlockAge < 0 {
+		return fmt.Errorf("max_block_age must not be negative")
+	}
 
 	return nil
 }
@@ -147,24 +151,36 @@ func (m *multilineStage) runMultiline(in chan Entry, out chan Entry, wg *sync.Wa
 		currentLines: 0,
 	}
 
+	// blockDeadline fires once the current block is older than max_block_age,
+	// so that a block is flushed even if its stream keeps sending further
+	// lines of the block. It's nil while no block is buffered or when
+	// max_block_age isn't set.
+	var blockDeadline <-chan time.Time
+
 	for {
 		select {
 		case <-time.After(m.cfg.MaxWaitTime):
 			level.Debug(m.logger).Log("msg", fmt.Sprintf("flush multiline block due to %v timeout", m.cfg.MaxWaitTime), "block", state.buffer.String())
 			m.flush(out, state)
+			blockDeadline = nil
+		case <-blockDeadline:
+			level.Debug(m.logger).Log("msg", fmt.Sprintf("flush multiline block because it's older than %v", m.cfg.MaxBlockAge), "block", state.buffer.String())
+			m.flush(out, state)
+			blockDeadline = nil
 		case e, ok := <-in:
-			level.Debug(m.logger).Log("msg", "processing line", "line", e.Line, "stream", e.Labels.FastFingerprint())
-
 			if !ok {
-				level.Debug(m.logger).Log("msg", "flush multiline block because inbound closed", "block", state.buffer.String(), "stream", e.Labels.FastFingerprint())
+				level.Debug(m.logger).Log("msg", "flush multiline block because inbound closed", "block", state.buffer.String())
 				m.flush(out, state)
 				return
 			}
 
+			level.Debug(m.logger).Log("msg", "processing line", "line", e.Line, "stream", e.Labels.FastFingerprint())
+
 			isFirstLine := m.cfg.regex.MatchString(e.Line)
 			if isFirstLine {
 				level.Debug(m.logger).Log("msg", "flush multiline block because new start line", "block", state.buffer.String(), "stream", e.Labels.FastFingerprint())
 				m.flush(out, state)
+				blockDeadline = nil
 
 				// The start line entry is used to set timestamp and labels in the flush method.
 				// The timestamps for following lines are ignored for now.
@@ -175,11 +191,16 @@ func (m *multilineStage) runMultiline(in chan Entry, out chan Entry, wg *sync.Wa
 			if state.buffer.Len() > 0 {
 				state.buffer.WriteRune('\n')
 			}
+			if blockDeadline == nil && m.cfg.MaxBlockAge > 0 {
+				blockDeadline = time.After(m.cfg.MaxBlockAge)
+			}
 			state.buffer.WriteString(e.Line)
 			state.currentLines++
 
-			if state.currentLines == m.cfg.MaxLines {
+			if m.cfg.MaxLines > 0 && state.currentLines >= m.cfg.MaxLines {
+				level.Debug(m.logger).Log("msg", "flush multiline block because it reached max_lines", "block", state.buffer.String(), "stream", e.Labels.FastFingerprint())
 				m.flush(out, state)
+				blockDeadline = nil
 			}
 		}
 	}
@@ -188,6 +209,7 @@ func (m *multilineStage) runMultiline(in chan Entry, out chan Entry, wg *sync.Wa
 func (m *multilineStage) flush(out chan Entry, s *multilineState) {
 	if s.buffer.Len() == 0 {
 		level.Debug(m.logger).Log("msg", "nothing to flush", "buffer_len", s.buffer.Len())
+		s.currentLines = 0
 		return
 	}
 	// copy extracted data.
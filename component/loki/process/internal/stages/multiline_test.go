@@ -6,11 +6,13 @@ package stages
 
 import (
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/prometheus/common/model"
@@ -128,6 +130,134 @@ func TestMultilineStageMaxWaitTime(t *testing.T) {
 	require.Equal(t, "not a start line hitting timeout", res[1].Line)
 }
 
+func TestMultilineStageMaxWaitTimeIdle(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	mcfg := MultilineConfig{Expression: "^START", MaxWaitTime: 200 * time.Millisecond}
+	err := validateMultilineConfig(&mcfg)
+	require.NoError(t, err)
+
+	stage := &multilineStage{
+		cfg:    mcfg,
+		logger: logger,
+	}
+
+	// Lines of the block keep arriving more often than max_wait_time, so the
+	// block is only flushed once the stream closes.
+	entries := []Entry{simpleEntry("START line", "label")}
+	for i := 0; i < 10; i++ {
+		entries = append(entries, simpleEntry("not a start line", "label"))
+	}
+
+	in := make(chan Entry)
+	out := stage.Run(in)
+	go func() {
+		for _, e := range entries {
+			time.Sleep(50 * time.Millisecond)
+			in <- e
+		}
+		close(in)
+	}()
+
+	var res []Entry
+	for e := range out {
+		res = append(res, e)
+	}
+	require.Len(t, res, 1)
+	require.Equal(t, 10, strings.Count(res[0].Line, "\n"))
+}
+
+func TestMultilineStageMaxBlockAge(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	mcfg := MultilineConfig{Expression: "^START", MaxWaitTime: 3 * time.Second, MaxBlockAge: 200 * time.Millisecond}
+	err := validateMultilineConfig(&mcfg)
+	require.NoError(t, err)
+
+	stage := &multilineStage{
+		cfg:    mcfg,
+		logger: logger,
+	}
+
+	in := make(chan Entry)
+	out := stage.Run(in)
+
+	// Lines of the block keep arriving more often than max_wait_time, but the
+	// block must still be flushed once it's older than max_block_age.
+	start := time.Now()
+	go func() {
+		in <- simpleEntry("START line", "label")
+		for i := 0; i < 10; i++ {
+			time.Sleep(50 * time.Millisecond)
+			in <- simpleEntry("not a start line", "label")
+		}
+		close(in)
+	}()
+
+	select {
+	case e := <-out:
+		require.Less(t, time.Since(start), 450*time.Millisecond)
+		require.True(t, strings.HasPrefix(e.Line, "START line\nnot a start line"))
+		require.Less(t, strings.Count(e.Line, "\n"), 10)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for the block to be flushed")
+	}
+	for range out {
+	}
+}
+
+func TestMultilineStageMaxLines(t *testing.T) {
+	logger := util.TestFlowLogger(t)
+	mcfg := MultilineConfig{Expression: "^START", MaxWaitTime: 3 * time.Second, MaxLines: 2}
+	err := validateMultilineConfig(&mcfg)
+	require.NoError(t, err)
+
+	stage := &multilineStage{
+		cfg:    mcfg,
+		logger: logger,
+	}
+
+	out := processEntries(stage,
+		simpleEntry("START line", "label"),
+		simpleEntry("not a start line 1", "label"),
+		simpleEntry("not a start line 2", "label"),
+		simpleEntry("not a start line 3", "label"),
+		simpleEntry("not a start line 4", "label"),
+		simpleEntry("not a start line 5", "label"),
+	)
+
+	require.Len(t, out, 3)
+	require.Equal(t, "START line\nnot a start line 1", out[0].Line)
+	require.Equal(t, "not a start line 2\nnot a start line 3", out[1].Line)
+	require.Equal(t, "not a start line 4\nnot a start line 5", out[2].Line)
+}
+
+func TestMultilineConfig_Validation(t *testing.T) {
+	var cfg MultilineConfig
+	require.NoError(t, river.Unmarshal([]byte(`firstline = "^START"`), &cfg))
+	require.Equal(t, uint64(128), cfg.MaxLines)
+	require.Equal(t, 3*time.Second, cfg.MaxWaitTime)
+
+	require.Equal(t, time.Duration(0), cfg.MaxBlockAge)
+
+	// A max_lines of 0 doesn't limit the number of lines of a block.
+	require.NoError(t, river.Unmarshal([]byte(`
+		firstline = "^START"
+		max_lines = 0
+	`), &cfg))
+	require.Equal(t, uint64(0), cfg.MaxLines)
+
+	err := river.Unmarshal([]byte(`
+		firstline     = "^START"
+		max_block_age = "-1s"
+	`), &cfg)
+	require.EqualError(t, err, "max_block_age must not be negative")
+
+	err = river.Unmarshal([]byte(`
+		firstline     = "^START"
+		max_wait_time = "0s"
+	`), &cfg)
+	require.EqualError(t, err, "max_wait_time must be greater than 0")
+}
+
 func simpleEntry(line, label string) Entry {
 	// We're adding a small wait time here, because on Windows, timers have a
 	// smaller resolution than on Linux. This can mess with the ordering of log
@@ -0,0 +1,88 @@
+package stages
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Configuration errors.
+var (
+	ErrSamplingStageInvalidRate = errors.New("sampling stage rate must be between 0.0 and 1.0")
+)
+
+var defaultSamplingDropReason = "sampling_stage"
+
+// SamplingConfig contains the configuration for a samplingStage.
+type SamplingConfig struct {
+	DropReason   string  `river:"drop_counter_reason,attr,optional"`
+	SamplingRate float64 `river:"rate,attr"`
+}
+
+// validateSamplingConfig validates the SamplingConfig for the samplingStage.
+func validateSamplingConfig(cfg *SamplingConfig) error {
+	if cfg.SamplingRate < 0 || cfg.SamplingRate > 1 {
+		return ErrSamplingStageInvalidRate
+	}
+	if cfg.DropReason == "" {
+		cfg.DropReason = defaultSamplingDropReason
+	}
+	return nil
+}
+
+// newSamplingStage creates a samplingStage from config.
+func newSamplingStage(logger log.Logger, cfg SamplingConfig, registerer prometheus.Registerer) (Stage, error) {
+	err := validateSamplingConfig(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &samplingStage{
+		logger:    log.With(logger, "component", "stage", "type", "sampling"),
+		cfg:       cfg,
+		source:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		dropCount: getDropCountMetric(registerer),
+	}, nil
+}
+
+// samplingStage keeps a random fraction of log entries and drops the rest.
+type samplingStage struct {
+	logger    log.Logger
+	cfg       SamplingConfig
+	source    *rand.Rand // Only used by the goroutine of Run.
+	dropCount *prometheus.CounterVec
+}
+
+// Run implements Stage.
+func (m *samplingStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			if m.shouldSample() {
+				out <- e
+				continue
+			}
+			m.dropCount.WithLabelValues(m.cfg.DropReason).Inc()
+		}
+	}()
+	return out
+}
+
+func (m *samplingStage) shouldSample() bool {
+	switch m.cfg.SamplingRate {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+	return m.source.Float64() < m.cfg.SamplingRate
+}
+
+// Name implements Stage.
+func (m *samplingStage) Name() string {
+	return StageTypeSampling
+}
@@ -0,0 +1,66 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSamplingRiver = `
+stage.sampling {
+		rate                = 0.5
+		drop_counter_reason = "debug_sampling"
+}`
+
+func TestSamplingPipeline(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testSamplingRiver), &plName, registry)
+	require.NoError(t, err)
+
+	logs := make([]Entry, 0)
+	logCount := 10000
+	for i := 0; i < logCount; i++ {
+		logs = append(logs, newEntry(nil, model.LabelSet{"app": "loki"}, testMatchLogLineApp1, time.Now()))
+	}
+	out := processEntries(pl, logs...)
+	assert.InDelta(t, logCount/2, len(out), float64(logCount)/10)
+
+	mfs, _ := registry.Gather()
+	var hasDropped bool
+	for _, mf := range mfs {
+		if *mf.Name == "loki_process_dropped_lines_total" {
+			hasDropped = true
+			require.Len(t, mf.Metric, 1)
+			assert.Equal(t, "debug_sampling", mf.Metric[0].Label[0].GetValue())
+			assert.Equal(t, logCount-len(out), int(mf.Metric[0].Counter.GetValue()))
+		}
+	}
+	assert.True(t, hasDropped)
+}
+
+func TestSamplingStage_Bounds(t *testing.T) {
+	for rate, expect := range map[float64]int{0: 0, 1: 100} {
+		s, err := newSamplingStage(util_log.Logger, SamplingConfig{SamplingRate: rate}, prometheus.NewRegistry())
+		require.NoError(t, err)
+
+		logs := make([]Entry, 0)
+		for i := 0; i < 100; i++ {
+			logs = append(logs, newEntry(nil, nil, "line", time.Now()))
+		}
+		assert.Len(t, processEntries(s, logs...), expect)
+	}
+}
+
+func TestValidateSamplingConfig(t *testing.T) {
+	cfg := SamplingConfig{SamplingRate: 0.1}
+	require.NoError(t, validateSamplingConfig(&cfg))
+	require.Equal(t, defaultSamplingDropReason, cfg.DropReason)
+
+	require.Equal(t, ErrSamplingStageInvalidRate, validateSamplingConfig(&SamplingConfig{SamplingRate: 1.5}))
+	require.Equal(t, ErrSamplingStageInvalidRate, validateSamplingConfig(&SamplingConfig{SamplingRate: -0.1}))
+}
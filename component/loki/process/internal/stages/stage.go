@@ -18,27 +18,32 @@ import (
 
 // TODO(@tpaschalis) Let's use this as the list of stages we need to port over.
 const (
-	StageTypeJSON         = "json"
-	StageTypeLogfmt       = "logfmt"
-	StageTypeRegex        = "regex"
-	StageTypeReplace      = "replace"
-	StageTypeMetric       = "metrics"
-	StageTypeLabel        = "labels"
-	StageTypeLabelDrop    = "labeldrop"
-	StageTypeTimestamp    = "timestamp"
-	StageTypeOutput       = "output"
-	StageTypeDocker       = "docker"
-	StageTypeCRI          = "cri"
-	StageTypeMatch        = "match"
-	StageTypeTemplate     = "template"
-	StageTypePipeline     = "pipeline"
-	StageTypeTenant       = "tenant"
-	StageTypeDrop         = "drop"
-	StageTypeLimit        = "limit"
-	StageTypeMultiline    = "multiline"
-	StageTypePack         = "pack"
-	StageTypeLabelAllow   = "labelallow"
-	StageTypeStaticLabels = "static_labels"
+	StageTypeJSON               = "json"
+	StageTypeLogfmt             = "logfmt"
+	StageTypeRegex              = "regex"
+	StageTypeReplace            = "replace"
+	StageTypeMetric             = "metrics"
+	StageTypeLabel              = "labels"
+	StageTypeLabelDrop          = "labeldrop"
+	StageTypeTimestamp          = "timestamp"
+	StageTypeOutput             = "output"
+	StageTypeDocker             = "docker"
+	StageTypeDecolorize         = "decolorize"
+	StageTypeCRI                = "cri"
+	StageTypeMatch              = "match"
+	StageTypeTemplate           = "template"
+	StageTypePipeline           = "pipeline"
+	StageTypeTenant             = "tenant"
+	StageTypeDrop               = "drop"
+	StageTypeLimit              = "limit"
+	StageTypeSampling           = "sampling"
+	StageTypeGeoIP              = "geoip"
+	StageTypeEventLogMessage    = "eventlogmessage"
+	StageTypeMultiline          = "multiline"
+	StageTypePack               = "pack"
+	StageTypeLabelAllow         = "labelallow"
+	StageTypeStaticLabels       = "static_labels"
+	StageTypeStructuredMetadata = "structured_metadata"
 )
 
 // Processor takes an existing set of labels, timestamp and log entry and returns either a possibly mutated
@@ -183,11 +188,18 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.SamplingConfig != nil:
+		s, err = newSamplingStage(logger, *cfg.SamplingConfig, registerer)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.DropConfig != nil:
 		s, err = newDropStage(logger, *cfg.DropConfig, registerer)
 		if err != nil {
 			return nil, err
 		}
+	case cfg.DecolorizeConfig != nil:
+		s = newDecolorizeStage(*cfg.DecolorizeConfig)
 	case cfg.MultilineConfig != nil:
 		s, err = newMultilineStage(logger, *cfg.MultilineConfig)
 		if err != nil {
@@ -210,6 +222,21 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.GeoIPConfig != nil:
+		s, err = newGeoIPStage(logger, *cfg.GeoIPConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.EventLogMessageConfig != nil:
+		s, err = newEventLogMessageStage(logger, *cfg.EventLogMessageConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.StructuredMetadataConfig != nil:
+		s, err = newStructuredMetadataStage(logger, *cfg.StructuredMetadataConfig)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		panic("unreachable; should have decoded into one of the StageConfig fields")
 	}
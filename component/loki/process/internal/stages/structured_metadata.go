@@ -0,0 +1,105 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+)
+
+// ErrEmptyStructuredMetadataStageConfig is returned if the config is empty.
+var ErrEmptyStructuredMetadataStageConfig = errors.New("structured_metadata stage config cannot be empty")
+
+// StructuredMetadataConfig is a set of structured metadata to be extracted.
+type StructuredMetadataConfig struct {
+	Values map[string]*string `river:"values,attr"`
+}
+
+// validateStructuredMetadataConfig validates the structured_metadata stage
+// configuration.
+func validateStructuredMetadataConfig(c StructuredMetadataConfig) error {
+	if c.Values == nil {
+		return ErrEmptyStructuredMetadataStageConfig
+	}
+	for name, src := range c.Values {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf(ErrInvalidLabelName, name)
+		}
+		// If no source was specified, use the key name
+		if src == nil || *src == "" {
+			n := name
+			c.Values[name] = &n
+		}
+	}
+	return nil
+}
+
+// newStructuredMetadataStage creates a new stage which sets structured
+// metadata from extracted data.
+func newStructuredMetadataStage(logger log.Logger, config StructuredMetadataConfig) (Stage, error) {
+	if err := validateStructuredMetadataConfig(config); err != nil {
+		return nil, err
+	}
+	return &structuredMetadataStage{
+		cfg:    config,
+		logger: logger,
+	}, nil
+}
+
+// structuredMetadataStage sets structured metadata from extracted data. Unlike
+// labels, structured metadata isn't indexed by Loki, so it can hold values with
+// a high cardinality, such as trace IDs.
+type structuredMetadataStage struct {
+	cfg    StructuredMetadataConfig
+	logger log.Logger
+}
+
+// Run implements Stage.
+func (s *structuredMetadataStage) Run(in chan Entry) chan Entry {
+	return RunWith(in, func(e Entry) Entry {
+		// The structured metadata of the entry may be shared with other
+		// receivers of the entry, so it's copied before being modified.
+		var metadata model.LabelSet
+		for name, src := range s.cfg.Values {
+			v, ok := e.Extracted[*src]
+			if !ok {
+				continue
+			}
+			str, err := getString(v)
+			if err != nil {
+				if Debug {
+					level.Debug(s.logger).Log("msg", "failed to convert extracted structured metadata value to string", "err", err, "type", reflect.TypeOf(v))
+				}
+				continue
+			}
+			value := model.LabelValue(str)
+			if !value.IsValid() {
+				if Debug {
+					level.Debug(s.logger).Log("msg", "invalid structured metadata value parsed", "value", value)
+				}
+				continue
+			}
+
+			if metadata == nil {
+				metadata = make(model.LabelSet, len(e.StructuredMetadata)+len(s.cfg.Values))
+				for k, v := range e.StructuredMetadata {
+					metadata[k] = v
+				}
+			}
+			metadata[model.LabelName(name)] = value
+		}
+
+		if metadata != nil {
+			e.StructuredMetadata = metadata
+		}
+		return e
+	})
+}
+
+// Name implements Stage.
+func (s *structuredMetadataStage) Name() string {
+	return StageTypeStructuredMetadata
+}
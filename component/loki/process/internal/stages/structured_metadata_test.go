@@ -0,0 +1,60 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+var testStructuredMetadataRiver = `
+	stage.json {
+		expressions = { level = "", trace = "trace_id" }
+	}
+	stage.labels {
+		values = { level = "" }
+	}
+	stage.structured_metadata {
+		values = { trace_id = "trace", missing = "" }
+	}`
+
+var testStructuredMetadataLogLine = `{"level": "error", "trace_id": "0242ac120002", "msg": "failed"}`
+
+func TestStructuredMetadataPipeline(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testStructuredMetadataRiver), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	in := newEntry(nil, model.LabelSet{"app": "api"}, testStructuredMetadataLogLine, time.Now())
+	in.StructuredMetadata = model.LabelSet{"pod_uid": "1234"}
+	original := in.StructuredMetadata
+
+	out := processEntries(pl, in)[0]
+	require.Equal(t, model.LabelSet{"app": "api", "level": "error"}, out.Labels)
+	require.Equal(t, model.LabelSet{"pod_uid": "1234", "trace_id": "0242ac120002"}, out.StructuredMetadata)
+
+	// The structured metadata of the incoming entry must not be modified.
+	require.Equal(t, model.LabelSet{"pod_uid": "1234"}, original)
+}
+
+func TestStructuredMetadataStage_NoValues(t *testing.T) {
+	s, err := newStructuredMetadataStage(util_log.Logger, StructuredMetadataConfig{
+		Values: map[string]*string{"trace_id": nil},
+	})
+	require.NoError(t, err)
+
+	out := processEntries(s, newEntry(map[string]interface{}{"other": "value"}, nil, "line", time.Now()))[0]
+	require.Nil(t, out.StructuredMetadata)
+}
+
+func TestValidateStructuredMetadataConfig(t *testing.T) {
+	err := validateStructuredMetadataConfig(StructuredMetadataConfig{})
+	require.ErrorIs(t, err, ErrEmptyStructuredMetadataStageConfig)
+
+	err = validateStructuredMetadataConfig(StructuredMetadataConfig{
+		Values: map[string]*string{"0invalid": nil},
+	})
+	require.EqualError(t, err, "invalid label name: 0invalid")
+}
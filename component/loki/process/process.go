@@ -2,11 +2,14 @@ package process
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/tap"
 	"github.com/grafana/agent/component/loki/process/internal/stages"
 )
 
@@ -35,12 +38,15 @@ type Exports struct {
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component    = (*Component)(nil)
+	_ component.TapComponent = (*Component)(nil)
 )
 
 // Component implements the loki.process component.
 type Component struct {
-	opts component.Options
+	opts    component.Options
+	tap     tap.Tapper
+	capture tap.Tapper // Receives entries encoded as dry run inputs.
 
 	mut          sync.RWMutex
 	receiver     loki.LogsReceiver
@@ -127,6 +133,12 @@ func (c *Component) handleIn(ctx context.Context, wg *sync.WaitGroup) {
 		case <-ctx.Done():
 			return
 		case entry := <-c.receiver:
+			if c.tap.Active() {
+				c.publishTap("in", entry)
+			}
+			if c.capture.Active() {
+				c.publishCapture(entry)
+			}
 			c.mut.RLock()
 			select {
 			case <-ctx.Done():
@@ -146,6 +158,9 @@ func (c *Component) handleOut(ctx context.Context, wg *sync.WaitGroup) {
 		case <-ctx.Done():
 			return
 		case entry := <-c.processOut:
+			if c.tap.Active() {
+				c.publishTap("out", entry)
+			}
 			c.mut.RLock()
 			for _, f := range c.fanout {
 				select {
@@ -160,6 +175,16 @@ func (c *Component) handleOut(ctx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
+// Tap implements component.TapComponent. Entries are published both when they
+// are received and after they have been processed by the pipeline.
+func (c *Component) Tap(ctx context.Context, fn func(record string)) {
+	c.tap.Tap(ctx, fn)
+}
+
+func (c *Component) publishTap(direction string, entry loki.Entry) {
+	c.tap.Publish(fmt.Sprintf("%s: %s %s %q", direction, entry.Labels.String(), entry.Timestamp.Format(time.RFC3339Nano), entry.Line))
+}
+
 func stagesChanged(prev, next []stages.StageConfig) bool {
 	if len(prev) != len(next) {
 		return true
@@ -0,0 +1,196 @@
+// Package api implements the loki.source.api component, which receives log
+// entries over the Loki push API.
+package api
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/component/loki/source/api/internal/apitarget"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:     "loki.source.api",
+		Args:     Arguments{},
+		Listener: true,
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the loki.source.api
+// component.
+type Arguments struct {
+	Listener             ListenerConfig      `river:"listener,block"`
+	Labels               map[string]string   `river:"labels,attr,optional"`
+	UseIncomingTimestamp bool                `river:"use_incoming_timestamp,attr,optional"`
+	ForwardTo            []loki.LogsReceiver `river:"forward_to,attr"`
+	RelabelRules         flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
+}
+
+// ListenerConfig defines the listener for push requests.
+type ListenerConfig struct {
+	ListenAddress string `river:"address,attr,optional"`
+	ListenPort    int    `river:"port,attr"`
+}
+
+// DefaultListenerConfig provides the default arguments for the listener.
+var DefaultListenerConfig = ListenerConfig{
+	ListenAddress: "0.0.0.0",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (lc *ListenerConfig) UnmarshalRiver(f func(interface{}) error) error {
+	*lc = DefaultListenerConfig
+
+	type listenerConfig ListenerConfig
+	return f((*listenerConfig)(lc))
+}
+
+// Component implements the loki.source.api component.
+type Component struct {
+	opts    component.Options
+	metrics *apitarget.Metrics
+
+	mut    sync.RWMutex
+	args   Arguments
+	fanout []loki.LogsReceiver
+	target *apitarget.Target
+
+	handler loki.LogsReceiver
+}
+
+// New creates a new loki.source.api component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		metrics: apitarget.NewMetrics(o.Registerer),
+		fanout:  args.ForwardTo,
+		handler: make(loki.LogsReceiver),
+	}
+
+	// Call to Update() to start the listener and set receivers once at the
+	// start.
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer func() {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+
+		level.Info(c.opts.Logger).Log("msg", "loki.source.api component shutting down, stopping listener")
+		if c.target != nil {
+			if err := c.target.Stop(); err != nil {
+				level.Error(c.opts.Logger).Log("msg", "error while stopping Loki push API listener", "err", err)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.handler:
+			c.mut.RLock()
+			for _, receiver := range c.fanout {
+				receiver <- entry
+			}
+			c.mut.RUnlock()
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	newArgs := args.(Arguments)
+	c.fanout = newArgs.ForwardTo
+
+	if c.target != nil && reflect.DeepEqual(c.args, withoutForwardTo(newArgs)) {
+		return nil
+	}
+
+	if c.target != nil {
+		if err := c.target.Stop(); err != nil {
+			level.Error(c.opts.Logger).Log("msg", "error while stopping Loki push API listener", "err", err)
+		}
+		c.target = nil
+	}
+
+	var rcs []*relabel.Config
+	if len(newArgs.RelabelRules) > 0 {
+		rcs = flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelRules)
+	}
+
+	entryHandler := loki.NewEntryHandler(c.handler, func() {})
+	t, err := apitarget.NewTarget(c.metrics, c.opts.Logger, entryHandler, rcs, newArgs.Convert())
+	if err != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to create Loki push API listener with provided config", "err", err)
+		return err
+	}
+
+	c.target = t
+	c.args = withoutForwardTo(newArgs)
+	return nil
+}
+
+// withoutForwardTo returns args without receivers, so that changing receivers
+// doesn't restart the listener.
+func withoutForwardTo(args Arguments) Arguments {
+	args.ForwardTo = nil
+	return args
+}
+
+// Convert is used to bridge between the River and target types.
+func (args *Arguments) Convert() *apitarget.Config {
+	lbls := make(model.LabelSet, len(args.Labels))
+	for k, v := range args.Labels {
+		lbls[model.LabelName(k)] = model.LabelValue(v)
+	}
+
+	return &apitarget.Config{
+		ListenAddress:        args.Listener.ListenAddress,
+		ListenPort:           args.Listener.ListenPort,
+		Labels:               lbls,
+		UseIncomingTimestamp: args.UseIncomingTimestamp,
+	}
+}
+
+// DebugInfo returns information about the status of listener.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	if c.target == nil {
+		return readerDebugInfo{}
+	}
+	return readerDebugInfo{
+		Ready:   c.target.Ready(),
+		Address: net.JoinHostPort(c.target.ListenAddress(), strconv.Itoa(c.target.ListenPort())),
+	}
+}
+
+type readerDebugInfo struct {
+	Ready   bool   `river:"ready,attr"`
+	Address string `river:"address,attr"`
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	listener {
+		port = 3100
+	}
+	labels = {
+		source = "relay",
+	}
+	use_incoming_timestamp = true
+	forward_to             = []
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+	require.Equal(t, "0.0.0.0", args.Listener.ListenAddress)
+	require.Equal(t, 3100, args.Listener.ListenPort)
+
+	cfg := args.Convert()
+	require.Equal(t, model.LabelSet{"source": "relay"}, cfg.Labels)
+	require.True(t, cfg.UseIncomingTimestamp)
+}
+
+func TestPush(t *testing.T) {
+	opts := component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	ch := make(chan loki.Entry)
+	args := Arguments{
+		Listener: ListenerConfig{
+			ListenAddress: "127.0.0.1",
+			ListenPort:    freePort(t),
+		},
+		Labels:               map[string]string{"source": "relay"},
+		UseIncomingTimestamp: true,
+		ForwardTo:            []loki.LogsReceiver{ch},
+	}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	require.Eventually(t, c.target.Ready, 5*time.Second, 50*time.Millisecond)
+
+	body := `{"streams": [{"stream": {"app": "test"}, "values": [["1680000000000000000", "hello world"]]}]}`
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(c.target.ListenAddress(), fmt.Sprint(c.target.ListenPort())), c.target.PushEndpoint())
+	res, err := http.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	select {
+	case entry := <-ch:
+		require.Equal(t, "hello world", entry.Line)
+		require.Equal(t, time.Unix(0, 1680000000000000000), entry.Timestamp)
+		require.Equal(t, model.LabelSet{"app": "test", "source": "relay"}, entry.Labels)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "failed waiting for log line")
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
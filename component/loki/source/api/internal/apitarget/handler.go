@@ -0,0 +1,194 @@
+package apitarget
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/columnar"
+	"github.com/grafana/loki/pkg/loghttp/push"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	promql_parser "github.com/prometheus/prometheus/promql/parser"
+)
+
+// Names of the endpoints, used as the value of the endpoint label of the
+// requests metric.
+const (
+	endpointPush = "push"
+	endpointRaw  = "raw"
+)
+
+// maxColumnarRequestSize is the maximum size of the compressed body of push
+// requests in the columnar encoding. The size of the decompressed body is
+// limited by columnar.Decode.
+const maxColumnarRequestSize = 100 << 20
+
+// Handler handles requests sent to the Loki push API.
+type Handler struct {
+	logger       log.Logger
+	metrics      *Metrics
+	handler      loki.EntryHandler
+	relabel      []*relabel.Config
+	labels       model.LabelSet
+	useTimestamp bool
+}
+
+// NewHandler creates a new Handler. Entries are given the labels in lbls and
+// are relabeled with relabel before being sent to handler.
+func NewHandler(logger log.Logger, metrics *Metrics, handler loki.EntryHandler, relabel []*relabel.Config, lbls model.LabelSet, useIncomingTimestamp bool) *Handler {
+	return &Handler{
+		logger:       logger,
+		metrics:      metrics,
+		handler:      handler,
+		relabel:      relabel,
+		labels:       lbls,
+		useTimestamp: useIncomingTimestamp,
+	}
+}
+
+// HandlePush handles a push request in the protobuf or JSON format of the Loki
+// push API, or in the experimental columnar encoding sent by other agents.
+func (h *Handler) HandlePush(w http.ResponseWriter, r *http.Request) {
+	req, err := h.parsePush(r)
+	if err != nil {
+		level.Warn(h.logger).Log("msg", "failed to parse incoming push request", "err", err)
+		h.respondError(w, endpointPush, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var lastErr error
+	for _, stream := range req.Streams {
+		ls, err := promql_parser.ParseMetric(stream.Labels)
+		if err != nil {
+			h.metrics.streamErrors.Inc()
+			lastErr = err
+			continue
+		}
+		sort.Sort(ls)
+
+		lset, keep := h.process(ls)
+		if !keep {
+			continue
+		}
+
+		for _, entry := range stream.Entries {
+			ts := time.Now()
+			if h.useTimestamp {
+				ts = entry.Timestamp
+			}
+			h.send(lset.Clone(), ts, entry.Line)
+		}
+	}
+
+	if lastErr != nil {
+		level.Warn(h.logger).Log("msg", "at least one stream in the push request failed to be processed", "err", lastErr)
+		h.respondError(w, endpointPush, lastErr.Error(), http.StatusBadRequest)
+		return
+	}
+	h.respond(w, endpointPush, http.StatusNoContent)
+}
+
+// parsePush parses the push request r.
+func (h *Handler) parsePush(r *http.Request) (*logproto.PushRequest, error) {
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != columnar.ContentType {
+		// The tenant ID is only used by ParseRequest for metrics which aren't
+		// registered by the agent.
+		return push.ParseRequest(h.logger, "", r, nil)
+	}
+
+	defer r.Body.Close()
+	buf, err := io.ReadAll(io.LimitReader(r.Body, maxColumnarRequestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) > maxColumnarRequestSize {
+		return nil, fmt.Errorf("request exceeds the maximum size of %d bytes", maxColumnarRequestSize)
+	}
+	return columnar.Decode(buf)
+}
+
+// HandleRaw handles a push request holding newline-delimited log lines, such
+// as plain text or NDJSON. Lines are only given the configured labels and the
+// current time.
+func (h *Handler) HandleRaw(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	lset, keep := h.process(nil)
+
+	body := bufio.NewReader(r.Body)
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			level.Warn(h.logger).Log("msg", "failed to read incoming push request", "err", err)
+			h.respondError(w, endpointRaw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" && keep {
+			h.send(lset.Clone(), time.Now(), line)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	h.respond(w, endpointRaw, http.StatusNoContent)
+}
+
+// process adds the configured labels to ls and relabels them. Labels starting
+// with "__" are dropped after relabeling. keep is false if relabeling dropped
+// the stream.
+func (h *Handler) process(ls labels.Labels) (lset model.LabelSet, keep bool) {
+	lb := labels.NewBuilder(ls)
+	for k, v := range h.labels {
+		lb.Set(string(k), string(v))
+	}
+
+	processed, keep := relabel.Process(lb.Labels(nil), h.relabel...)
+	if !keep {
+		return nil, false
+	}
+
+	lset = make(model.LabelSet, len(processed))
+	for _, l := range processed {
+		if strings.HasPrefix(l.Name, "__") {
+			continue
+		}
+		lset[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	return lset, true
+}
+
+func (h *Handler) send(lset model.LabelSet, ts time.Time, line string) {
+	h.handler.Chan() <- loki.Entry{
+		Labels: lset,
+		Entry: logproto.Entry{
+			Timestamp: ts,
+			Line:      line,
+		},
+	}
+	h.metrics.entriesWritten.Inc()
+}
+
+func (h *Handler) respond(w http.ResponseWriter, endpoint string, statusCode int) {
+	h.metrics.requests.WithLabelValues(endpoint, strconv.Itoa(statusCode)).Inc()
+	w.WriteHeader(statusCode)
+}
+
+func (h *Handler) respondError(w http.ResponseWriter, endpoint string, msg string, statusCode int) {
+	h.metrics.requests.WithLabelValues(endpoint, strconv.Itoa(statusCode)).Inc()
+	http.Error(w, msg, statusCode)
+}
@@ -0,0 +1,138 @@
+package apitarget
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/columnar"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_Push(t *testing.T) {
+	relabelConfigs := []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{"app"},
+			Regex:        relabel.MustNewRegexp("dropped"),
+			Action:       relabel.Drop,
+		},
+		{
+			SourceLabels: model.LabelNames{"__internal"},
+			Regex:        relabel.MustNewRegexp("(.+)"),
+			TargetLabel:  "internal",
+			Replacement:  "$1",
+			Action:       relabel.Replace,
+		},
+	}
+	h := NewHandler(log.NewNopLogger(), NewMetrics(prometheus.NewRegistry()), nil, relabelConfigs, model.LabelSet{"source": "relay"}, true)
+
+	body := `{"streams": [
+		{"stream": {"app": "dropped"}, "values": [["1680000000000000000", "dropped line"]]},
+		{"stream": {"app": "kept", "__internal": "x"}, "values": [
+			["1680000000000000000", "first line"],
+			["1680000001000000000", "second line"]
+		]}
+	]}`
+	entries := sendRequest(t, h, (*Handler).HandlePush, "application/json", body, http.StatusNoContent)
+	require.Len(t, entries, 2)
+
+	wantLabels := model.LabelSet{"app": "kept", "internal": "x", "source": "relay"}
+	require.Equal(t, "first line", entries[0].Line)
+	require.Equal(t, time.Unix(0, 1680000000000000000), entries[0].Timestamp)
+	require.Equal(t, wantLabels, entries[0].Labels)
+	require.Equal(t, "second line", entries[1].Line)
+	require.Equal(t, time.Unix(0, 1680000001000000000), entries[1].Timestamp)
+	require.Equal(t, wantLabels, entries[1].Labels)
+}
+
+func TestHandler_PushInvalidLabels(t *testing.T) {
+	h := NewHandler(log.NewNopLogger(), NewMetrics(prometheus.NewRegistry()), nil, nil, nil, false)
+
+	// Valid streams are forwarded even if other streams of the request are
+	// invalid.
+	body := `{"streams": [
+		{"stream": {"app": "kept"}, "values": [["1680000000000000000", "line"]]},
+		{"stream": {"0invalid": "x"}, "values": [["1680000000000000000", "invalid line"]]}
+	]}`
+	entries := sendRequest(t, h, (*Handler).HandlePush, "application/json", body, http.StatusBadRequest)
+	require.Len(t, entries, 1)
+	require.Equal(t, "line", entries[0].Line)
+	require.WithinDuration(t, time.Now(), entries[0].Timestamp, time.Second)
+}
+
+func TestHandler_PushColumnar(t *testing.T) {
+	h := NewHandler(log.NewNopLogger(), NewMetrics(prometheus.NewRegistry()), nil, nil, model.LabelSet{"source": "relay"}, true)
+
+	body := columnar.Encode(&logproto.PushRequest{
+		Streams: []logproto.Stream{{
+			Labels: `{app="kept"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(0, 1680000000000000000), Line: "first line"},
+				{Timestamp: time.Unix(0, 1680000001000000000), Line: "second line"},
+			},
+		}},
+	})
+	entries := sendRequest(t, h, (*Handler).HandlePush, columnar.ContentType, string(body), http.StatusNoContent)
+	require.Len(t, entries, 2)
+	require.Equal(t, "first line", entries[0].Line)
+	require.Equal(t, time.Unix(0, 1680000000000000000), entries[0].Timestamp)
+	require.Equal(t, model.LabelSet{"app": "kept", "source": "relay"}, entries[0].Labels)
+	require.Equal(t, "second line", entries[1].Line)
+
+	sendRequest(t, h, (*Handler).HandlePush, columnar.ContentType, "not columnar", http.StatusBadRequest)
+}
+
+func TestHandler_Raw(t *testing.T) {
+	h := NewHandler(log.NewNopLogger(), NewMetrics(prometheus.NewRegistry()), nil, nil, model.LabelSet{"source": "raw"}, true)
+
+	entries := sendRequest(t, h, (*Handler).HandleRaw, "text/plain", "first line\r\n\nsecond line", http.StatusNoContent)
+	require.Len(t, entries, 2)
+	require.Equal(t, "first line", entries[0].Line)
+	require.Equal(t, "second line", entries[1].Line)
+	for _, e := range entries {
+		require.Equal(t, model.LabelSet{"source": "raw"}, e.Labels)
+		require.WithinDuration(t, time.Now(), e.Timestamp, time.Second)
+	}
+}
+
+// sendRequest sends body to h using handle and returns the entries h
+// forwards.
+func sendRequest(t *testing.T, h *Handler, handle func(*Handler, http.ResponseWriter, *http.Request), contentType string, body string, wantStatus int) []loki.Entry {
+	t.Helper()
+
+	ch := make(loki.LogsReceiver)
+	h.handler = loki.NewEntryHandler(ch, func() {})
+
+	var entries []loki.Entry
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range ch {
+			entries = append(entries, e)
+		}
+	}()
+
+	// The version of the JSON format is detected from the request path.
+	req := httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+
+	handle(h, rec, req)
+
+	res := rec.Result()
+	msg, _ := io.ReadAll(res.Body)
+	require.Equal(t, wantStatus, res.StatusCode, string(msg))
+
+	close(ch)
+	<-done
+	return entries
+}
@@ -0,0 +1,36 @@
+package apitarget
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds the metrics of a Loki push API target.
+type Metrics struct {
+	requests       *prometheus.CounterVec
+	entriesWritten prometheus.Counter
+	streamErrors   prometheus.Counter
+}
+
+// NewMetrics creates the metrics of a Loki push API target and registers them
+// with reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+
+	m.requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_source_api_requests_total",
+		Help: "Number of push requests received, by endpoint and response status code.",
+	}, []string{"endpoint", "status_code"})
+
+	m.entriesWritten = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_source_api_entries_total",
+		Help: "Number of log entries received from push requests.",
+	})
+
+	m.streamErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_source_api_stream_errors_total",
+		Help: "Number of streams which were dropped because their labels couldn't be parsed.",
+	})
+
+	if reg != nil {
+		reg.MustRegister(m.requests, m.entriesWritten, m.streamErrors)
+	}
+	return &m
+}
@@ -0,0 +1,145 @@
+package apitarget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/handover"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+// Config describes how a Target listens for push requests.
+type Config struct {
+	// ListenAddress and ListenPort are the address and port the HTTP server
+	// listens on.
+	ListenAddress string
+	ListenPort    int
+
+	// Labels are added to every received entry.
+	Labels model.LabelSet
+
+	// UseIncomingTimestamp keeps the timestamp of entries pushed to the push
+	// endpoint. If false, the current time is used.
+	UseIncomingTimestamp bool
+}
+
+// Target runs an HTTP server which implements the Loki push API.
+type Target struct {
+	logger  log.Logger
+	handler loki.EntryHandler
+	config  *Config
+	server  *http.Server
+	done    chan struct{}
+	metrics *Metrics
+	relabel []*relabel.Config
+}
+
+// NewTarget creates a new Target and starts its HTTP server.
+func NewTarget(metrics *Metrics, logger log.Logger, handler loki.EntryHandler, relabel []*relabel.Config, config *Config) (*Target, error) {
+	t := &Target{
+		logger:  log.With(logger, "component", "loki_push_api"),
+		handler: handler,
+		config:  config,
+		metrics: metrics,
+		relabel: relabel,
+	}
+
+	if err := t.run(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// shutdownTimeout is how long Stop waits for in-flight requests to complete.
+const shutdownTimeout = 30 * time.Second
+
+func (t *Target) run() error {
+	level.Info(t.logger).Log("msg", "starting Loki push API target")
+
+	// The listener is passed to the new process when the agent restarts with
+	// socket handover, so pushes aren't refused during the restart.
+	addr := net.JoinHostPort(t.config.ListenAddress, strconv.Itoa(t.config.ListenPort))
+	lis, err := handover.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	h := NewHandler(t.logger, t.metrics, t.handler, t.relabel, t.config.Labels, t.config.UseIncomingTimestamp)
+	router := mux.NewRouter()
+	router.Path(t.PushEndpoint()).Methods("POST").Handler(http.HandlerFunc(h.HandlePush))
+	router.Path(t.RawEndpoint()).Methods("POST").Handler(http.HandlerFunc(h.HandleRaw))
+	router.Path(t.ReadyEndpoint()).Methods("GET").Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	t.server = &http.Server{Handler: router}
+	t.done = make(chan struct{})
+	go func() {
+		defer close(t.done)
+		if err := t.server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			level.Error(t.logger).Log("msg", "Loki push API target shutdown with error", "err", err)
+		}
+	}()
+
+	return nil
+}
+
+// ListenAddress returns the address the target listens on.
+func (t *Target) ListenAddress() string {
+	return t.config.ListenAddress
+}
+
+// ListenPort returns the port the target listens on.
+func (t *Target) ListenPort() int {
+	return t.config.ListenPort
+}
+
+// PushEndpoint returns the path of the Loki push endpoint.
+func (t *Target) PushEndpoint() string {
+	return "/loki/api/v1/push"
+}
+
+// RawEndpoint returns the path of the endpoint accepting newline-delimited
+// log lines.
+func (t *Target) RawEndpoint() string {
+	return "/loki/api/v1/raw"
+}
+
+// ReadyEndpoint returns the path of the readiness endpoint.
+func (t *Target) ReadyEndpoint() string {
+	return "/ready"
+}
+
+// Ready returns true if the target accepts requests.
+func (t *Target) Ready() bool {
+	req, err := http.NewRequest(http.MethodGet, "http://"+net.JoinHostPort(t.ListenAddress(), strconv.Itoa(t.ListenPort()))+t.ReadyEndpoint(), nil)
+	if err != nil {
+		return false
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer res.Body.Close()
+	return res.StatusCode == http.StatusOK
+}
+
+// Stop stops the HTTP server of the target.
+func (t *Target) Stop() error {
+	level.Info(t.logger).Log("msg", "stopping Loki push API target")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	_ = t.server.Shutdown(ctx)
+	<-t.done
+	t.handler.Stop()
+	return nil
+}
@@ -0,0 +1,95 @@
+package awsfirehose
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	listener {
+		port = 8080
+	}
+	access_key             = "secret"
+	use_incoming_timestamp = true
+	forward_to             = []
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+	require.Equal(t, "0.0.0.0", args.Listener.ListenAddress)
+	require.Equal(t, 8080, args.Listener.ListenPort)
+
+	cfg := args.Convert()
+	require.Equal(t, "secret", cfg.AccessKey)
+	require.True(t, cfg.UseIncomingTimestamp)
+}
+
+func TestPush(t *testing.T) {
+	opts := component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	ch := make(chan loki.Entry)
+	args := Arguments{
+		Listener: ListenerConfig{
+			ListenAddress: "127.0.0.1",
+			ListenPort:    freePort(t),
+		},
+		AccessKey: "secret",
+		ForwardTo: []loki.LogsReceiver{ch},
+	}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	require.Eventually(t, c.target.Ready, 5*time.Second, 50*time.Millisecond)
+
+	// "aGVsbG8gd29ybGQ=" is the base64 encoding of "hello world".
+	body := `{"requestId": "request-1", "timestamp": 1680000000000, "records": [{"data": "aGVsbG8gd29ybGQ="}]}`
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(c.target.ListenAddress(), fmt.Sprint(c.target.ListenPort())), c.target.PushEndpoint())
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("X-Amz-Firehose-Request-Id", "request-1")
+	req.Header.Set("X-Amz-Firehose-Access-Key", "secret")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	select {
+	case entry := <-ch:
+		require.Equal(t, "hello world", entry.Line)
+		require.Equal(t, model.LabelSet{}, entry.Labels)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "failed waiting for log line")
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
@@ -0,0 +1,268 @@
+package firehosetarget
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+// Headers set by AWS Firehose on delivery requests to HTTP endpoints.
+const (
+	headerRequestID = "X-Amz-Firehose-Request-Id"
+	headerAccessKey = "X-Amz-Firehose-Access-Key"
+	headerSourceARN = "X-Amz-Firehose-Source-Arn"
+)
+
+// Types of records which can be received, used as the value of the type label
+// of the records metric.
+const (
+	recordTypeDirectPut      = "direct_put"
+	recordTypeCloudWatchLogs = "cloudwatch_logs"
+)
+
+// cloudWatchControlMessage is the message type of the messages CloudWatch
+// Logs sends to check that the destination is reachable.
+const cloudWatchControlMessage = "CONTROL_MESSAGE"
+
+// firehoseRequest is the body of a delivery request sent by AWS Firehose.
+type firehoseRequest struct {
+	RequestID string           `json:"requestId"`
+	Timestamp int64            `json:"timestamp"` // Milliseconds since the epoch.
+	Records   []firehoseRecord `json:"records"`
+}
+
+type firehoseRecord struct {
+	Data string `json:"data"` // Base64-encoded record data.
+}
+
+// firehoseResponse is the body of the response to a delivery request.
+type firehoseResponse struct {
+	RequestID    string `json:"requestId"`
+	Timestamp    int64  `json:"timestamp"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// cloudWatchLogsRecord is the gzip-compressed data of records sent by a
+// CloudWatch Logs subscription filter.
+type cloudWatchLogsRecord struct {
+	Owner               string                `json:"owner"`
+	LogGroup            string                `json:"logGroup"`
+	LogStream           string                `json:"logStream"`
+	SubscriptionFilters []string              `json:"subscriptionFilters"`
+	MessageType         string                `json:"messageType"`
+	LogEvents           []cloudWatchLogsEvent `json:"logEvents"`
+}
+
+type cloudWatchLogsEvent struct {
+	ID        string `json:"id"`
+	Timestamp int64  `json:"timestamp"` // Milliseconds since the epoch.
+	Message   string `json:"message"`
+}
+
+// Handler receives delivery requests of AWS Firehose and sends the records
+// they contain as log entries to an EntryHandler.
+//
+// Records sent by CloudWatch Logs subscription filters are decoded into an
+// entry per log event; any other record is sent as a single entry.
+type Handler struct {
+	logger               log.Logger
+	metrics              *Metrics
+	handler              loki.EntryHandler
+	relabelConfigs       []*relabel.Config
+	accessKey            string
+	useIncomingTimestamp bool
+}
+
+// NewHandler creates a new Handler. If accessKey isn't empty, requests must
+// carry the same access key to be accepted.
+func NewHandler(logger log.Logger, metrics *Metrics, handler loki.EntryHandler, relabelConfigs []*relabel.Config, accessKey string, useIncomingTimestamp bool) *Handler {
+	return &Handler{
+		logger:               logger,
+		metrics:              metrics,
+		handler:              handler,
+		relabelConfigs:       relabelConfigs,
+		accessKey:            accessKey,
+		useIncomingTimestamp: useIncomingTimestamp,
+	}
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	requestID := r.Header.Get(headerRequestID)
+
+	if h.accessKey != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(headerAccessKey)), []byte(h.accessKey)) != 1 {
+		h.respond(w, requestID, http.StatusUnauthorized, "invalid access key")
+		return
+	}
+
+	body := r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			h.respond(w, requestID, http.StatusBadRequest, fmt.Sprintf("failed to decompress request: %s", err))
+			return
+		}
+		defer gr.Close()
+		body = gr
+	}
+
+	var req firehoseRequest
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		h.respond(w, requestID, http.StatusBadRequest, fmt.Sprintf("failed to decode request: %s", err))
+		return
+	}
+	if requestID == "" {
+		requestID = req.RequestID
+	}
+
+	common := labels.NewBuilder(nil)
+	common.Set("__aws_firehose_request_id", requestID)
+	if arn := r.Header.Get(headerSourceARN); arn != "" {
+		common.Set("__aws_firehose_source_arn", arn)
+	}
+
+	for i, rec := range req.Records {
+		entries, err := h.decodeRecord(rec, common.Labels(nil), time.UnixMilli(req.Timestamp))
+		if err != nil {
+			// Malformed records are dropped rather than failing the request,
+			// since AWS Firehose would retry the whole request.
+			level.Warn(h.logger).Log("msg", "failed to decode record", "request_id", requestID, "record", i, "err", err)
+			continue
+		}
+
+		for _, e := range entries {
+			select {
+			case h.handler.Chan() <- e:
+				h.metrics.entriesWritten.Inc()
+			case <-r.Context().Done():
+				h.respond(w, requestID, http.StatusServiceUnavailable, "request canceled")
+				return
+			}
+		}
+	}
+
+	h.respond(w, requestID, http.StatusOK, "")
+}
+
+// decodeRecord decodes rec into log entries.
+func (h *Handler) decodeRecord(rec firehoseRecord, common labels.Labels, requestTime time.Time) ([]loki.Entry, error) {
+	data, err := base64.StdEncoding.DecodeString(rec.Data)
+	if err != nil {
+		h.metrics.errorsTotal.WithLabelValues("base64").Inc()
+		return nil, fmt.Errorf("decoding base64: %w", err)
+	}
+
+	// Records of CloudWatch Logs subscription filters are always
+	// gzip-compressed.
+	if !isGzip(data) {
+		h.metrics.recordsTotal.WithLabelValues(recordTypeDirectPut).Inc()
+		entry, ok := h.newEntry(common, requestTime, string(data))
+		if !ok {
+			return nil, nil
+		}
+		return []loki.Entry{entry}, nil
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		h.metrics.errorsTotal.WithLabelValues("gzip").Inc()
+		return nil, fmt.Errorf("decompressing record: %w", err)
+	}
+	defer gr.Close()
+
+	var cwRecord cloudWatchLogsRecord
+	if err := json.NewDecoder(gr).Decode(&cwRecord); err != nil {
+		h.metrics.errorsTotal.WithLabelValues("cloudwatch_logs").Inc()
+		return nil, fmt.Errorf("decoding CloudWatch Logs record: %w", err)
+	}
+
+	h.metrics.recordsTotal.WithLabelValues(recordTypeCloudWatchLogs).Inc()
+	if cwRecord.MessageType == cloudWatchControlMessage {
+		return nil, nil
+	}
+
+	lb := labels.NewBuilder(common)
+	lb.Set("__aws_owner", cwRecord.Owner)
+	lb.Set("__aws_cw_log_group", cwRecord.LogGroup)
+	lb.Set("__aws_cw_log_stream", cwRecord.LogStream)
+	lb.Set("__aws_cw_matched_filters", strings.Join(cwRecord.SubscriptionFilters, ","))
+	lb.Set("__aws_cw_msg_type", cwRecord.MessageType)
+	recordLabels := lb.Labels(nil)
+
+	entries := make([]loki.Entry, 0, len(cwRecord.LogEvents))
+	for _, event := range cwRecord.LogEvents {
+		entry, ok := h.newEntry(recordLabels, time.UnixMilli(event.Timestamp), event.Message)
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// newEntry relabels lbls and creates an entry for line. ok is false if the
+// entry is dropped by relabeling.
+func (h *Handler) newEntry(lbls labels.Labels, incomingTime time.Time, line string) (entry loki.Entry, ok bool) {
+	processed, keep := relabel.Process(lbls, h.relabelConfigs...)
+	if !keep {
+		return loki.Entry{}, false
+	}
+
+	filtered := make(model.LabelSet, len(processed))
+	for _, lbl := range processed {
+		if strings.HasPrefix(lbl.Name, "__") {
+			continue
+		}
+		filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+	}
+
+	ts := time.Now()
+	if h.useIncomingTimestamp {
+		ts = incomingTime
+	}
+
+	return loki.Entry{
+		Labels: filtered,
+		Entry: logproto.Entry{
+			Timestamp: ts,
+			Line:      line,
+		},
+	}, true
+}
+
+// respond writes a response in the format expected by AWS Firehose.
+func (h *Handler) respond(w http.ResponseWriter, requestID string, statusCode int, errorMessage string) {
+	h.metrics.requests.WithLabelValues(strconv.Itoa(statusCode)).Inc()
+	if errorMessage != "" {
+		level.Warn(h.logger).Log("msg", "failed to handle AWS Firehose request", "request_id", requestID, "err", errorMessage)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(firehoseResponse{
+		RequestID:    requestID,
+		Timestamp:    time.Now().UnixMilli(),
+		ErrorMessage: errorMessage,
+	})
+}
+
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
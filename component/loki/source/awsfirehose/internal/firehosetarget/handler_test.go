@@ -0,0 +1,166 @@
+package firehosetarget
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	cwRecord := gzipJSON(t, cloudWatchLogsRecord{
+		Owner:               "123456789012",
+		LogGroup:            "/aws/lambda/test",
+		LogStream:           "stream",
+		SubscriptionFilters: []string{"filter-a", "filter-b"},
+		MessageType:         "DATA_MESSAGE",
+		LogEvents: []cloudWatchLogsEvent{
+			{ID: "1", Timestamp: 1680000000000, Message: "first event"},
+			{ID: "2", Timestamp: 1680000001000, Message: "second event"},
+		},
+	})
+	controlRecord := gzipJSON(t, cloudWatchLogsRecord{MessageType: cloudWatchControlMessage})
+
+	relabelConfigs := []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{"__aws_cw_log_group"},
+			Regex:        relabel.MustNewRegexp("(.+)"),
+			TargetLabel:  "log_group",
+			Replacement:  "$1",
+			Action:       relabel.Replace,
+		},
+		{
+			SourceLabels: model.LabelNames{"__aws_cw_matched_filters"},
+			Regex:        relabel.MustNewRegexp("(.+)"),
+			TargetLabel:  "filters",
+			Replacement:  "$1",
+			Action:       relabel.Replace,
+		},
+		{
+			SourceLabels: model.LabelNames{"__aws_firehose_request_id"},
+			Regex:        relabel.MustNewRegexp("(.+)"),
+			TargetLabel:  "request_id",
+			Replacement:  "$1",
+			Action:       relabel.Replace,
+		},
+	}
+
+	body := firehoseRequest{
+		RequestID: "request-1",
+		Timestamp: 1680000002000,
+		Records: []firehoseRecord{
+			{Data: base64.StdEncoding.EncodeToString([]byte("direct put line"))},
+			{Data: base64.StdEncoding.EncodeToString(cwRecord)},
+			{Data: base64.StdEncoding.EncodeToString(controlRecord)},
+			{Data: "not base64!"},
+		},
+	}
+
+	entries := sendRequest(t, NewHandler(log.NewNopLogger(), NewMetrics(prometheus.NewRegistry()), nil, relabelConfigs, "", true), body, nil, http.StatusOK)
+	require.Len(t, entries, 3)
+
+	require.Equal(t, "direct put line", entries[0].Line)
+	require.Equal(t, time.UnixMilli(1680000002000), entries[0].Timestamp)
+	require.Equal(t, model.LabelSet{"request_id": "request-1"}, entries[0].Labels)
+
+	wantLabels := model.LabelSet{
+		"request_id": "request-1",
+		"log_group":  "/aws/lambda/test",
+		"filters":    "filter-a,filter-b",
+	}
+	require.Equal(t, "first event", entries[1].Line)
+	require.Equal(t, time.UnixMilli(1680000000000), entries[1].Timestamp)
+	require.Equal(t, wantLabels, entries[1].Labels)
+	require.Equal(t, "second event", entries[2].Line)
+	require.Equal(t, time.UnixMilli(1680000001000), entries[2].Timestamp)
+	require.Equal(t, wantLabels, entries[2].Labels)
+}
+
+func TestHandler_AccessKey(t *testing.T) {
+	body := firehoseRequest{
+		RequestID: "request-1",
+		Records: []firehoseRecord{
+			{Data: base64.StdEncoding.EncodeToString([]byte("line"))},
+		},
+	}
+	h := NewHandler(log.NewNopLogger(), NewMetrics(prometheus.NewRegistry()), nil, nil, "secret", false)
+
+	entries := sendRequest(t, h, body, http.Header{headerAccessKey: []string{"wrong"}}, http.StatusUnauthorized)
+	require.Empty(t, entries)
+
+	entries = sendRequest(t, h, body, http.Header{headerAccessKey: []string{"secret"}}, http.StatusOK)
+	require.Len(t, entries, 1)
+	require.WithinDuration(t, time.Now(), entries[0].Timestamp, time.Second)
+}
+
+func TestHandler_InvalidRequest(t *testing.T) {
+	h := NewHandler(log.NewNopLogger(), NewMetrics(prometheus.NewRegistry()), nil, nil, "", false)
+	h.handler = loki.NewEntryHandler(make(loki.LogsReceiver), func() {})
+
+	req := httptest.NewRequest(http.MethodPost, "/awsfirehose/api/v1/push", strings.NewReader("{"))
+	req.Header.Set(headerRequestID, "request-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var res firehoseResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	require.Equal(t, "request-1", res.RequestID)
+	require.NotEmpty(t, res.ErrorMessage)
+}
+
+// sendRequest sends body to h and returns the entries h forwards.
+func sendRequest(t *testing.T, h *Handler, body firehoseRequest, header http.Header, wantStatus int) []loki.Entry {
+	t.Helper()
+
+	ch := make(loki.LogsReceiver)
+	h.handler = loki.NewEntryHandler(ch, func() {})
+
+	var entries []loki.Entry
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range ch {
+			entries = append(entries, e)
+		}
+	}()
+
+	bb, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/awsfirehose/api/v1/push", bytes.NewReader(bb))
+	req.Header.Set(headerRequestID, body.RequestID)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, wantStatus, rec.Code)
+
+	var res firehoseResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	require.Equal(t, body.RequestID, res.RequestID)
+
+	close(ch)
+	<-done
+	return entries
+}
+
+func gzipJSON(t *testing.T, v interface{}) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	require.NoError(t, json.NewEncoder(gw).Encode(v))
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
@@ -0,0 +1,42 @@
+package firehosetarget
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds the metrics of an AWS Firehose target.
+type Metrics struct {
+	requests       *prometheus.CounterVec
+	recordsTotal   *prometheus.CounterVec
+	errorsTotal    *prometheus.CounterVec
+	entriesWritten prometheus.Counter
+}
+
+// NewMetrics creates the metrics of an AWS Firehose target and registers
+// them with reg.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+
+	m.requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_source_awsfirehose_requests_total",
+		Help: "Number of delivery requests received from AWS Firehose, by response status code.",
+	}, []string{"status_code"})
+
+	m.recordsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_source_awsfirehose_records_received_total",
+		Help: "Number of records received from AWS Firehose, by record type.",
+	}, []string{"type"})
+
+	m.errorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_source_awsfirehose_record_errors_total",
+		Help: "Number of records which failed to be decoded, by reason.",
+	}, []string{"reason"})
+
+	m.entriesWritten = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_source_awsfirehose_entries_total",
+		Help: "Number of log entries forwarded from AWS Firehose records.",
+	})
+
+	if reg != nil {
+		reg.MustRegister(m.requests, m.recordsTotal, m.errorsTotal, m.entriesWritten)
+	}
+	return &m
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +15,7 @@ import (
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/component/common/loki/positions"
 	"github.com/grafana/agent/component/discovery"
+	discovery_file "github.com/grafana/agent/component/discovery/file"
 	"github.com/prometheus/common/model"
 )
 
@@ -39,10 +41,122 @@ const (
 type Arguments struct {
 	Targets   []discovery.Target  `river:"targets,attr"`
 	ForwardTo []loki.LogsReceiver `river:"forward_to,attr"`
+
+	FileMatch  FileMatch  `river:"file_match,block,optional"`
+	Tailing    Tailing    `river:"tailing,block,optional"`
+	Clustering Clustering `river:"clustering,block,optional"`
+}
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	FileMatch: DefaultFileMatch,
+	Tailing:   DefaultTailing,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type arguments Arguments
+	return f((*arguments)(a))
+}
+
+// FileMatch holds values that configure how loki.source.file expands the
+// paths of targets into the files to tail.
+type FileMatch struct {
+	// Enabled treats the paths of targets as doublestar glob patterns which
+	// are resolved every SyncPeriod.
+	Enabled    bool          `river:"enabled,attr,optional"`
+	SyncPeriod time.Duration `river:"sync_period,attr,optional"`
+}
+
+// DefaultFileMatch holds default values for FileMatch.
+var DefaultFileMatch = FileMatch{
+	SyncPeriod: 10 * time.Second,
 }
 
+// UnmarshalRiver implements river.Unmarshaler.
+func (fm *FileMatch) UnmarshalRiver(f func(interface{}) error) error {
+	*fm = DefaultFileMatch
+
+	type fileMatch FileMatch
+	if err := f((*fileMatch)(fm)); err != nil {
+		return err
+	}
+
+	if fm.SyncPeriod <= 0 {
+		return fmt.Errorf("sync_period must be greater than 0")
+	}
+	return nil
+}
+
+// Tailing holds values that limit the files loki.source.file keeps open.
+type Tailing struct {
+	// MaxOpenFiles limits the number of files tailed at once. Zero means no
+	// limit.
+	MaxOpenFiles int `river:"max_open_files,attr,optional"`
+	// IdleTimeout closes files which didn't have new lines for this long.
+	// Zero disables closing idle files.
+	IdleTimeout time.Duration `river:"idle_timeout,attr,optional"`
+	// CheckWorkers is the number of goroutines checking closed files for new
+	// lines, each one checking a shard of the files.
+	CheckWorkers int `river:"check_workers,attr,optional"`
+	// CheckPeriod is how often closed files are checked for new lines.
+	CheckPeriod time.Duration `river:"check_period,attr,optional"`
+	// OpenRate is the maximum number of files opened per second.
+	OpenRate float64 `river:"open_rate,attr,optional"`
+}
+
+// DefaultTailing holds default values for Tailing.
+var DefaultTailing = Tailing{
+	CheckWorkers: 4,
+	CheckPeriod:  1 * time.Second,
+	OpenRate:     100,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (t *Tailing) UnmarshalRiver(f func(interface{}) error) error {
+	*t = DefaultTailing
+
+	type tailing Tailing
+	if err := f((*tailing)(t)); err != nil {
+		return err
+	}
+
+	switch {
+	case t.MaxOpenFiles < 0:
+		return fmt.Errorf("max_open_files must not be negative")
+	case t.IdleTimeout < 0:
+		return fmt.Errorf("idle_timeout must not be negative")
+	case t.CheckWorkers <= 0:
+		return fmt.Errorf("check_workers must be greater than 0")
+	case t.CheckPeriod <= 0:
+		return fmt.Errorf("check_period must be greater than 0")
+	case t.OpenRate <= 0:
+		return fmt.Errorf("open_rate must be greater than 0")
+	}
+	return nil
+}
+
+// pooled reports whether files are tailed by a tailPool, which is the case
+// once the open files are limited.
+func (t Tailing) pooled() bool {
+	return t.MaxOpenFiles > 0 || t.IdleTimeout > 0
+}
+
+// Clustering holds values that configure how loki.source.file behaves when
+// the agent runs in clustered mode.
+type Clustering struct {
+	// LeaderOnly restricts the component to the leader of the cluster.
+	LeaderOnly bool `river:"leader_only,attr"`
+}
+
+// LeaderOnly implements component.LeaderOnlyArguments.
+func (a Arguments) LeaderOnly() bool { return a.Clustering.LeaderOnly }
+
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component           = (*Component)(nil)
+	_ component.LeaderOnlyArguments = Arguments{}
 )
 
 // Component implements the loki.source.file component.
@@ -59,6 +173,11 @@ type Component struct {
 	receivers    []loki.LogsReceiver
 	posFile      positions.Positions
 	readers      map[positions.Entry]reader
+	pool         *tailPool // Tails the files if the open files are limited.
+
+	// matchedTargets holds the targets resolved from the glob patterns of the
+	// arguments when file matching is enabled. Guarded by updateMut.
+	matchedTargets []discovery.Target
 }
 
 // New creates a new loki.source.file component.
@@ -96,16 +215,17 @@ func New(o component.Options, args Arguments) (*Component, error) {
 }
 
 // Run implements component.Component.
-// TODO(@tpaschalis). Should we periodically re-check? What happens if a target
-// comes alive _after_ it's been passed to us and we never receive another
-// Update()? Or should it be a responsibility of the discovery component?
 func (c *Component) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
 	defer func() {
 		level.Info(c.opts.Logger).Log("msg", "loki.source.file component shutting down, stopping readers and positions file")
 		c.mut.RLock()
 		for _, r := range c.readers {
 			r.Stop()
 		}
+		if c.pool != nil {
+			c.pool.Stop()
+		}
 		c.posFile.Stop()
 		if c.entryHandler != nil {
 			c.entryHandler.Stop()
@@ -113,6 +233,14 @@ func (c *Component) Run(ctx context.Context) error {
 		close(c.handler)
 		c.mut.RUnlock()
 	}()
+	// Wait for file matching to stop before stopping readers above.
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.runFileMatch(ctx)
+	}()
 
 	for {
 		select {
@@ -128,11 +256,70 @@ func (c *Component) Run(ctx context.Context) error {
 	}
 }
 
+// runFileMatch resolves the glob patterns of targets every sync period while
+// file matching is enabled, and restarts readers when the matching files
+// changed.
+func (c *Component) runFileMatch(ctx context.Context) {
+	for {
+		c.mut.RLock()
+		period := c.args.FileMatch.SyncPeriod
+		c.mut.RUnlock()
+
+		if period <= 0 {
+			period = DefaultFileMatch.SyncPeriod
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(period):
+			c.resyncFileMatch()
+		}
+	}
+}
+
+func (c *Component) resyncFileMatch() {
+	c.updateMut.Lock()
+	defer c.updateMut.Unlock()
+
+	c.mut.RLock()
+	args := c.args
+	c.mut.RUnlock()
+
+	if !args.FileMatch.Enabled {
+		return
+	}
+
+	targets := discovery_file.MatchTargets(c.opts.Logger, args.Targets)
+	if reflect.DeepEqual(targets, c.matchedTargets) {
+		return
+	}
+	c.matchedTargets = targets
+	c.updateReaders(args, targets)
+}
+
 // Update implements component.Component.
 func (c *Component) Update(args component.Arguments) error {
 	c.updateMut.Lock()
 	defer c.updateMut.Unlock()
 
+	newArgs := args.(Arguments)
+
+	targets := newArgs.Targets
+	if newArgs.FileMatch.Enabled {
+		targets = discovery_file.MatchTargets(c.opts.Logger, newArgs.Targets)
+		c.matchedTargets = targets
+	}
+
+	c.updateReaders(newArgs, targets)
+	return nil
+}
+
+// updateReaders sets the arguments of the component and restarts its readers
+// to tail the files of targets. updateMut must be held when calling
+// updateReaders.
+func (c *Component) updateReaders(newArgs Arguments, targets []discovery.Target) {
+
 	// Stop all readers so we can recreate them below. This *must* be done before
 	// c.mut is held to avoid a race condition where stopping a reader is
 	// flushing its data, but the flush never succeeds because the Run goroutine
@@ -155,8 +342,6 @@ func (c *Component) Update(args component.Arguments) error {
 	//   and c.stopTailingAndRemovePosition.
 	oldPaths := c.stopReaders()
 
-	newArgs := args.(Arguments)
-
 	c.mut.Lock()
 	defer c.mut.Unlock()
 	c.args = newArgs
@@ -166,13 +351,20 @@ func (c *Component) Update(args component.Arguments) error {
 	if c.entryHandler != nil {
 		c.entryHandler.Stop()
 	}
+	if c.pool != nil {
+		c.pool.Stop()
+		c.pool = nil
+	}
+	if newArgs.Tailing.pooled() {
+		c.pool = newTailPool(c.opts.Logger, c.metrics, c.posFile, c.handler, newArgs.Tailing)
+	}
 
-	if len(newArgs.Targets) == 0 {
+	if len(targets) == 0 {
 		level.Debug(c.opts.Logger).Log("msg", "no files targets were passed, nothing will be tailed")
-		return nil
+		return
 	}
 
-	for _, target := range newArgs.Targets {
+	for _, target := range targets {
 		path := target[pathLabel]
 
 		var labels = make(model.LabelSet)
@@ -190,6 +382,13 @@ func (c *Component) Update(args component.Arguments) error {
 		}
 
 		c.reportSize(path, labels.String())
+
+		// Compressed files are read once, so they're never limited by the pool.
+		if c.pool != nil && !isCompressed(path) {
+			c.readers[readersKey] = c.pool.Add(path, labels)
+			continue
+		}
+
 		c.entryHandler = loki.AddLabelsMiddleware(labels).Wrap(loki.NewEntryHandler(c.handler, func() {}))
 
 		reader, err := c.startTailing(path, labels, c.entryHandler)
@@ -205,8 +404,6 @@ func (c *Component) Update(args component.Arguments) error {
 	for r := range missing(c.readers, oldPaths) {
 		c.posFile.Remove(r.Path, r.Labels)
 	}
-
-	return nil
 }
 
 // stopReaders stops existing readers and returns the set of paths which were
@@ -4,14 +4,17 @@ import (
 	"context"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
@@ -130,3 +133,151 @@ func TestTwoTargets(t *testing.T) {
 	require.True(t, foundF1)
 	require.True(t, foundF2)
 }
+
+func TestFileMatch(t *testing.T) {
+	opts := component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+		DataPath:      t.TempDir(),
+	}
+
+	logsDir := t.TempDir()
+	nestedDir := filepath.Join(logsDir, "pods", "app", "0")
+	require.NoError(t, os.MkdirAll(nestedDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(nestedDir, "excluded.log"), nil, 0644))
+
+	ch1 := make(chan loki.Entry)
+	args := DefaultArguments
+	args.Targets = []discovery.Target{{
+		"__path__":         filepath.Join(logsDir, "**", "*.log"),
+		"__path_exclude__": filepath.Join(logsDir, "**", "excluded.log"),
+		"foo":              "bar",
+	}}
+	args.ForwardTo = []loki.LogsReceiver{ch1}
+	args.FileMatch = FileMatch{Enabled: true, SyncPeriod: 10 * time.Millisecond}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	// Files created after the component started are found on the next sync.
+	path := filepath.Join(nestedDir, "app.log")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.Eventually(t, func() bool {
+		c.mut.RLock()
+		defer c.mut.RUnlock()
+		return len(c.readers) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = f.Write([]byte("writing some text\n"))
+	require.NoError(t, err)
+
+	select {
+	case logEntry := <-ch1:
+		require.Equal(t, "writing some text", logEntry.Line)
+		require.Equal(t, model.LabelSet{"filename": model.LabelValue(path), "foo": "bar"}, logEntry.Labels)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "failed waiting for log line")
+	}
+}
+
+func TestTailingLimits(t *testing.T) {
+	opts := component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+		DataPath:      t.TempDir(),
+	}
+
+	logsDir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.log", "b.log", "c.log"} {
+		path := filepath.Join(logsDir, name)
+		require.NoError(t, os.WriteFile(path, []byte(name+"\n"), 0644))
+		paths = append(paths, path)
+	}
+
+	ch1 := make(chan loki.Entry)
+	args := DefaultArguments
+	for _, path := range paths {
+		args.Targets = append(args.Targets, discovery.Target{"__path__": path})
+	}
+	args.ForwardTo = []loki.LogsReceiver{ch1}
+	args.Tailing = Tailing{
+		MaxOpenFiles: 1,
+		IdleTimeout:  200 * time.Millisecond,
+		CheckWorkers: 2,
+		CheckPeriod:  20 * time.Millisecond,
+		OpenRate:     100,
+	}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	readLine := func() string {
+		select {
+		case logEntry := <-ch1:
+			require.LessOrEqual(t, testutil.ToFloat64(c.metrics.filesActive), 1.0)
+			return logEntry.Line
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "failed waiting for log line")
+			return ""
+		}
+	}
+
+	// Every file is read, even though only one file is open at a time.
+	var lines []string
+	for range paths {
+		lines = append(lines, readLine())
+	}
+	require.ElementsMatch(t, []string{"a.log", "b.log", "c.log"}, lines)
+
+	// Idle files are closed, and opened again once they have new lines.
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(c.metrics.filesActive) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	f, err := os.OpenFile(paths[1], os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write([]byte("more text\n"))
+	require.NoError(t, err)
+	require.Equal(t, "more text", readLine())
+}
+
+func TestTailingConfig(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		targets    = []
+		forward_to = []
+
+		tailing {
+			max_open_files = 1000
+			idle_timeout   = "5m"
+		}
+	`), &args))
+	require.Equal(t, 1000, args.Tailing.MaxOpenFiles)
+	require.Equal(t, DefaultTailing.CheckWorkers, args.Tailing.CheckWorkers)
+	require.True(t, args.Tailing.pooled())
+
+	err := river.Unmarshal([]byte(`
+		targets    = []
+		forward_to = []
+
+		tailing {
+			check_workers = 0
+		}
+	`), &args)
+	require.ErrorContains(t, err, "check_workers must be greater than 0")
+}
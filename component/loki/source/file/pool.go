@@ -0,0 +1,428 @@
+package file
+
+import (
+	"context"
+	"hash/fnv"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/positions"
+	"github.com/prometheus/common/model"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+)
+
+// pooledReaderState is the state of a pooledReader within its tailPool.
+type pooledReaderState int
+
+const (
+	pooledReaderClosed  pooledReaderState = iota // The file isn't tailed and is checked for new data.
+	pooledReaderPending                          // The file is waiting to be tailed.
+	pooledReaderOpen                             // The file is tailed.
+	pooledReaderStopped                          // The reader was stopped.
+)
+
+// tailPool limits the number of files tailed at once. Files are only tailed
+// while they have data which wasn't read yet: a closed file is checked for
+// new data by one of the check workers, which shard the files between them,
+// and opened at a limited rate. Tailers which don't read any line for the idle
+// timeout are closed. Once the maximum number of open files is reached, the
+// tailer which read a line least recently is closed to open a queued file, as
+// long as it didn't read any line for a check period.
+type tailPool struct {
+	logger  log.Logger
+	metrics *metrics
+	posFile positions.Positions
+	entries chan<- loki.Entry
+	opts    Tailing
+	limiter *rate.Limiter
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	wake   chan struct{}
+
+	mut     sync.Mutex
+	shards  []map[*pooledReader]struct{}
+	open    map[*pooledReader]struct{}
+	pending []*pooledReader
+}
+
+// newTailPool creates a tailPool which sends the entries of tailed files to
+// entries, and starts its goroutines. Stop must be called to release them.
+func newTailPool(logger log.Logger, m *metrics, posFile positions.Positions, entries chan<- loki.Entry, opts Tailing) *tailPool {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	burst := int(opts.OpenRate)
+	if burst < 1 {
+		burst = 1
+	}
+	p := &tailPool{
+		logger:  logger,
+		metrics: m,
+		posFile: posFile,
+		entries: entries,
+		opts:    opts,
+		limiter: rate.NewLimiter(rate.Limit(opts.OpenRate), burst),
+
+		cancel: cancel,
+		wake:   make(chan struct{}, 1),
+
+		shards: make([]map[*pooledReader]struct{}, opts.CheckWorkers),
+		open:   make(map[*pooledReader]struct{}),
+	}
+	for i := range p.shards {
+		p.shards[i] = make(map[*pooledReader]struct{})
+	}
+
+	p.wg.Add(len(p.shards) + 1)
+	for i := range p.shards {
+		go p.runCheckWorker(ctx, i)
+	}
+	go p.runOpener(ctx)
+	return p
+}
+
+// Add returns a reader for path which is tailed by the pool.
+func (p *tailPool) Add(path string, labels model.LabelSet) reader {
+	r := &pooledReader{
+		pool:     p,
+		path:     path,
+		labelSet: labels,
+		labels:   labels.String(),
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(path))
+	r.shard = int(h.Sum32() % uint32(len(p.shards)))
+
+	p.mut.Lock()
+	p.shards[r.shard][r] = struct{}{}
+	p.mut.Unlock()
+	return r
+}
+
+// Stop stops the goroutines of the pool and closes the files it tails.
+func (p *tailPool) Stop() {
+	p.cancel()
+	p.wg.Wait()
+
+	p.mut.Lock()
+	readers := make([]*pooledReader, 0, len(p.open))
+	for r := range p.open {
+		readers = append(readers, r)
+	}
+	p.mut.Unlock()
+
+	for _, r := range readers {
+		r.Stop()
+	}
+}
+
+// runCheckWorker checks the closed files of a shard for new data every check
+// period, and queues the files which have new data to be opened.
+func (p *tailPool) runCheckWorker(ctx context.Context, shard int) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.opts.CheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		p.mut.Lock()
+		var closed []*pooledReader
+		for r := range p.shards[shard] {
+			if r.state == pooledReaderClosed {
+				closed = append(closed, r)
+			}
+		}
+		p.mut.Unlock()
+
+		for _, r := range closed {
+			if ctx.Err() != nil {
+				return
+			}
+			if r.hasNewData() {
+				p.request(r)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// request queues r to be opened if it's closed.
+func (p *tailPool) request(r *pooledReader) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if r.state != pooledReaderClosed {
+		return
+	}
+	r.state = pooledReaderPending
+	p.pending = append(p.pending, r)
+	p.wakeIfPending()
+}
+
+// runOpener opens the queued files at the open rate, and closes the tailers
+// which are idle or which stopped reading their file every check period.
+func (p *tailPool) runOpener(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.opts.CheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+			p.openPending(ctx)
+		case <-ticker.C:
+			p.closeIdle()
+
+			p.mut.Lock()
+			p.wakeIfPending()
+			p.mut.Unlock()
+		}
+	}
+}
+
+// wakeIfPending wakes up the opener if files are queued. p.mut must be held.
+func (p *tailPool) wakeIfPending() {
+	if len(p.pending) == 0 {
+		return
+	}
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// openPending opens the next queued file once the open rate allows it,
+// closing the least recently read tailer to stay within the maximum number of
+// open files. The opener is woken up again if more files are queued.
+func (p *tailPool) openPending(ctx context.Context) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return
+	}
+
+	p.mut.Lock()
+	var r *pooledReader
+	for r == nil && len(p.pending) > 0 {
+		r = p.pending[0]
+		p.pending = p.pending[1:]
+		if r.state != pooledReaderPending {
+			r = nil
+		}
+	}
+	if r == nil {
+		p.mut.Unlock()
+		return
+	}
+	var evicted *pooledReader
+	if p.opts.MaxOpenFiles > 0 && len(p.open) >= p.opts.MaxOpenFiles {
+		evicted = p.leastRecentlyRead()
+		if evicted == nil {
+			// Every open file read a line recently. The file stays queued until
+			// one of them goes idle.
+			p.pending = append([]*pooledReader{r}, p.pending...)
+			p.mut.Unlock()
+			return
+		}
+		p.setClosed(evicted)
+	}
+	r.state = pooledReaderOpen
+	p.open[r] = struct{}{}
+	p.wakeIfPending()
+	p.mut.Unlock()
+
+	if evicted != nil {
+		level.Debug(p.logger).Log("msg", "closing least recently read file to stay within max_open_files", "filename", evicted.path)
+		p.metrics.tailersClosed.WithLabelValues("evicted").Inc()
+		evicted.close()
+	}
+
+	if !r.open() {
+		p.mut.Lock()
+		if r.state == pooledReaderOpen {
+			p.setClosed(r)
+		}
+		p.mut.Unlock()
+	}
+}
+
+// closeIdle closes the tailers which didn't read any line for the idle
+// timeout, or which stopped reading their file.
+func (p *tailPool) closeIdle() {
+	p.mut.Lock()
+	var idle, exited []*pooledReader
+	for r := range p.open {
+		t := r.tailer.Load()
+		switch {
+		case t == nil:
+			continue
+		case t.Exited():
+			exited = append(exited, r)
+		case p.opts.IdleTimeout > 0 && time.Since(t.LastRead()) >= p.opts.IdleTimeout:
+			idle = append(idle, r)
+		default:
+			continue
+		}
+		p.setClosed(r)
+	}
+	p.mut.Unlock()
+
+	for _, r := range idle {
+		level.Debug(p.logger).Log("msg", "closing idle file", "filename", r.path)
+		p.metrics.tailersClosed.WithLabelValues("idle").Inc()
+		r.close()
+	}
+	for _, r := range exited {
+		r.close()
+	}
+}
+
+// leastRecentlyRead returns the open reader whose tailer read a line least
+// recently, ignoring tailers which read a line or were opened within the last
+// check period. p.mut must be held.
+func (p *tailPool) leastRecentlyRead() *pooledReader {
+	var (
+		oldest   *pooledReader
+		oldestTS = time.Now().Add(-p.opts.CheckPeriod)
+	)
+	for r := range p.open {
+		t := r.tailer.Load()
+		if t == nil {
+			continue
+		}
+		if ts := t.LastRead(); ts.Before(oldestTS) {
+			oldest, oldestTS = r, ts
+		}
+	}
+	return oldest
+}
+
+// setClosed marks r as closed, so it's checked for new data again. p.mut
+// must be held.
+func (p *tailPool) setClosed(r *pooledReader) {
+	delete(p.open, r)
+	r.state = pooledReaderClosed
+}
+
+// remove removes r from the pool. p.mut must not be held.
+func (p *tailPool) remove(r *pooledReader) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	delete(p.shards[r.shard], r)
+	delete(p.open, r)
+	r.state = pooledReaderStopped
+}
+
+// pooledReader implements reader for a file tailed by a tailPool. The file is
+// only open while the pool tails it.
+type pooledReader struct {
+	pool     *tailPool
+	path     string
+	labelSet model.LabelSet
+	labels   string
+	shard    int
+
+	// state is guarded by the mutex of the pool.
+	state pooledReaderState
+
+	// mut serializes opening and closing the tailer.
+	mut     sync.Mutex
+	tailer  atomic.Pointer[tailer]
+	handler loki.EntryHandler
+	stopped bool
+}
+
+var _ reader = (*pooledReader)(nil)
+
+// hasNewData reports whether the file has a size different from its saved
+// position, as it either grew or was truncated.
+func (r *pooledReader) hasNewData() bool {
+	fi, err := os.Stat(r.path)
+	if err != nil || fi.IsDir() {
+		return false
+	}
+	pos, err := r.pool.posFile.Get(r.path, r.labels)
+	if err != nil {
+		return false
+	}
+	return fi.Size() != pos
+}
+
+// open starts tailing the file. It returns false if the file couldn't be
+// tailed.
+func (r *pooledReader) open() bool {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.stopped || r.tailer.Load() != nil {
+		return false
+	}
+
+	handler := loki.AddLabelsMiddleware(r.labelSet).Wrap(loki.NewEntryHandler(r.pool.entries, func() {}))
+	t, err := newTailer(r.pool.metrics, r.pool.logger, handler, r.pool.posFile, r.path, r.labels, "")
+	if err != nil {
+		level.Error(r.pool.logger).Log("msg", "failed to start tailer", "error", err, "filename", r.path)
+		handler.Stop()
+		return false
+	}
+	r.handler = handler
+	r.tailer.Store(t)
+	return true
+}
+
+// close stops tailing the file, saving its position.
+func (r *pooledReader) close() {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	t := r.tailer.Load()
+	if t == nil {
+		return
+	}
+	t.Stop()
+	r.handler.Stop()
+	r.tailer.Store(nil)
+	r.handler = nil
+}
+
+// Stop implements reader.
+func (r *pooledReader) Stop() {
+	r.pool.remove(r)
+
+	r.mut.Lock()
+	r.stopped = true
+	r.mut.Unlock()
+	r.close()
+}
+
+// IsRunning implements reader. It returns true while the file is tailed.
+func (r *pooledReader) IsRunning() bool {
+	t := r.tailer.Load()
+	return t != nil && t.IsRunning()
+}
+
+// Path implements reader.
+func (r *pooledReader) Path() string {
+	return r.path
+}
+
+// MarkPositionAndSize implements reader.
+func (r *pooledReader) MarkPositionAndSize() error {
+	if t := r.tailer.Load(); t != nil {
+		return t.MarkPositionAndSize()
+	}
+	return nil
+}
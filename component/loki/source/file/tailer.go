@@ -36,10 +36,11 @@ type tailer struct {
 	posAndSizeMtx sync.Mutex
 	stopOnce      sync.Once
 
-	running *atomic.Bool
-	posquit chan struct{}
-	posdone chan struct{}
-	done    chan struct{}
+	running  *atomic.Bool
+	lastRead *atomic.Time
+	posquit  chan struct{}
+	posdone  chan struct{}
+	done     chan struct{}
 
 	decoder *encoding.Decoder
 }
@@ -85,6 +86,7 @@ func newTailer(metrics *metrics, logger log.Logger, handler loki.EntryHandler, p
 		labels:    labels,
 		tail:      tail,
 		running:   atomic.NewBool(false),
+		lastRead:  atomic.NewTime(time.Now()),
 		posquit:   make(chan struct{}),
 		posdone:   make(chan struct{}),
 		done:      make(chan struct{}),
@@ -185,6 +187,7 @@ func (t *tailer) readLines() {
 		}
 
 		t.metrics.readLines.WithLabelValues(t.path).Inc()
+		t.lastRead.Store(time.Now())
 		entries <- loki.Entry{
 			Labels: model.LabelSet{},
 			Entry: logproto.Entry{
@@ -251,6 +254,23 @@ func (t *tailer) IsRunning() bool {
 	return t.running.Load()
 }
 
+// LastRead returns when the tailer last read a line, or when it was created if
+// it didn't read any line yet.
+func (t *tailer) LastRead() time.Time {
+	return t.lastRead.Load()
+}
+
+// Exited reports whether the tailer stopped reading the file, such as because
+// the file couldn't be read anymore.
+func (t *tailer) Exited() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
 func (t *tailer) convertToUTF8(text string) (string, error) {
 	res, _, err := transform.String(t.decoder, text)
 	if err != nil {
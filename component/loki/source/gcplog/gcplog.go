@@ -16,8 +16,9 @@ import (
 
 func init() {
 	component.Register(component.Registration{
-		Name: "loki.source.gcplog",
-		Args: Arguments{},
+		Name:     "loki.source.gcplog",
+		Args:     Arguments{},
+		Listener: true,
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
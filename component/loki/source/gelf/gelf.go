@@ -15,8 +15,9 @@ import (
 
 func init() {
 	component.Register(component.Registration{
-		Name: "loki.source.gelf",
-		Args: Arguments{},
+		Name:     "loki.source.gelf",
+		Args:     Arguments{},
+		Listener: true,
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -2,8 +2,9 @@ package heroku
 
 import (
 	"context"
-	"fmt"
+	"net"
 	"reflect"
+	"strconv"
 	"sync"
 
 	"github.com/go-kit/log/level"
@@ -18,8 +19,9 @@ import (
 
 func init() {
 	component.Register(component.Registration{
-		Name: "loki.source.heroku",
-		Args: Arguments{},
+		Name:     "loki.source.heroku",
+		Args:     Arguments{},
+		Listener: true,
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -183,7 +185,7 @@ func (c *Component) DebugInfo() interface{} {
 
 	var res readerDebugInfo = readerDebugInfo{
 		Ready:   c.target.Ready(),
-		Address: fmt.Sprintf("%s:%d", c.target.ListenAddress(), c.target.ListenPort()),
+		Address: net.JoinHostPort(c.target.ListenAddress(), strconv.Itoa(c.target.ListenPort())),
 	}
 
 	return res
@@ -6,7 +6,9 @@ package herokutarget
 
 import (
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -189,7 +191,7 @@ func (h *HerokuTarget) HealthyEndpoint() string {
 }
 
 func (h *HerokuTarget) Ready() bool {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s:%d%s", h.ListenAddress(), h.ListenPort(), h.HealthyEndpoint()), nil)
+	req, err := http.NewRequest(http.MethodGet, "http://"+net.JoinHostPort(h.ListenAddress(), strconv.Itoa(h.ListenPort()))+h.HealthyEndpoint(), nil)
 	if err != nil {
 		return false
 	}
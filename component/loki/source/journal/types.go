@@ -15,8 +15,19 @@ type Arguments struct {
 	RelabelRules flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
 	Matches      string              `river:"matches,attr,optional"`
 	Receivers    []loki.LogsReceiver `river:"forward_to,attr"`
+	Clustering   Clustering          `river:"clustering,block,optional"`
 }
 
+// Clustering holds values that configure how loki.source.journal behaves
+// when the agent runs in clustered mode.
+type Clustering struct {
+	// LeaderOnly restricts the component to the leader of the cluster.
+	LeaderOnly bool `river:"leader_only,attr"`
+}
+
+// LeaderOnly implements component.LeaderOnlyArguments.
+func (r Arguments) LeaderOnly() bool { return r.Clustering.LeaderOnly }
+
 func defaultArgs() Arguments {
 	return Arguments{
 		FormatAsJson: false,
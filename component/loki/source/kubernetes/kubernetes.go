@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/loki/source/kubernetes/kubetail"
 	"github.com/grafana/agent/pkg/river"
+	promconfig "github.com/prometheus/common/config"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -42,6 +43,42 @@ type Arguments struct {
 
 	// Client settings to connect to Kubernetes.
 	Client commonk8s.ClientArguments `river:"client,block,optional"`
+
+	// Kubelet settings to read logs from kubelets instead of the API server.
+	Kubelet *KubeletArguments `river:"kubelet,block,optional"`
+}
+
+// KubeletArguments configures reading logs from the kubelet of the node
+// running each pod.
+type KubeletArguments struct {
+	Port             int                     `river:"port,attr,optional"`
+	HTTPClientConfig config.HTTPClientConfig `river:",squash"`
+}
+
+// DefaultKubeletArguments holds default settings for KubeletArguments.
+var DefaultKubeletArguments = KubeletArguments{
+	Port:             10250,
+	HTTPClientConfig: config.DefaultHTTPClientConfig,
+}
+
+var _ river.Unmarshaler = (*KubeletArguments)(nil)
+
+// UnmarshalRiver implements river.Unmarshaler, applying defaults and
+// validating the provided config.
+func (args *KubeletArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultKubeletArguments
+
+	type arguments KubeletArguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.Port <= 0 || args.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	// We must explicitly Validate because HTTPClientConfig is squashed and it
+	// won't run otherwise.
+	return args.HTTPClientConfig.Validate()
 }
 
 var _ river.Unmarshaler = (*Arguments)(nil)
@@ -207,11 +244,28 @@ func (c *Component) Update(args component.Arguments) error {
 //
 // getTailerOptions must only be called when c.mut is held.
 func (c *Component) getTailerOptions(args Arguments) (*kubetail.Options, error) {
-	if reflect.DeepEqual(c.args.Client, args.Client) && c.lastOptions != nil {
+	if reflect.DeepEqual(c.args.Client, args.Client) && reflect.DeepEqual(c.args.Kubelet, args.Kubelet) && c.lastOptions != nil {
 		return c.lastOptions, nil
 	}
 
-	cfg, err := args.Client.BuildRESTConfig(c.log)
+	if args.Kubelet != nil {
+		// Logs are read from kubelets, so there's no need for a client to the
+		// API server.
+		client, err := promconfig.NewClientFromConfig(*args.Kubelet.HTTPClientConfig.Convert(), c.opts.ID, args.Kubelet.HTTPClientConfig.ClientOptions()...)
+		if err != nil {
+			return c.lastOptions, fmt.Errorf("building kubelet client: %w", err)
+		}
+		return &kubetail.Options{
+			Kubelet: &kubetail.KubeletClient{
+				Client: client,
+				Port:   args.Kubelet.Port,
+			},
+			Handler:   loki.NewEntryHandler(c.handler, func() {}),
+			Positions: c.positions,
+		}, nil
+	}
+
+	cfg, err := args.Client.BuildRESTConfig(c.log, c.opts.Registerer)
 	if err != nil {
 		return c.lastOptions, fmt.Errorf("building Kubernetes config: %w", err)
 	}
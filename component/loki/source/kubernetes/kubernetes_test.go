@@ -43,3 +43,23 @@ func TestBadRiverConfig(t *testing.T) {
 	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
+
+func TestKubeletRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	targets    = []
+	forward_to = []
+	kubelet {
+		bearer_token_file = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+		tls_config {
+			insecure_skip_verify = true
+		}
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+	require.NotNil(t, args.Kubelet)
+	require.Equal(t, 10250, args.Kubelet.Port)
+	require.True(t, args.Kubelet.HTTPClientConfig.TLSConfig.InsecureSkipVerify)
+}
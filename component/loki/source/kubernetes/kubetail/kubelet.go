@@ -0,0 +1,97 @@
+package kubetail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// KubeletClient requests logs and pod status from the kubelet of the node
+// running a pod instead of from the Kubernetes API server. The node is
+// determined by the [LabelPodHostIP] label of a target.
+type KubeletClient struct {
+	// Client to use for requests to kubelets. It must authenticate with the
+	// kubelet API.
+	Client *http.Client
+
+	// Port of the kubelet API on every node.
+	Port int
+}
+
+// hostAddr returns the host and port of the kubelet API on host.
+func (kc *KubeletClient) hostAddr(host string) string {
+	return net.JoinHostPort(host, strconv.Itoa(kc.Port))
+}
+
+// StreamLogs opens a stream of the logs of a container running on host. Each
+// line of the stream is prefixed with its timestamp. If since is non-zero,
+// only logs written after since are returned.
+func (kc *KubeletClient) StreamLogs(ctx context.Context, host string, key types.NamespacedName, container string, since time.Time) (io.ReadCloser, error) {
+	query := url.Values{}
+	query.Set("follow", "true")
+	query.Set("timestamps", "true")
+	if !since.IsZero() {
+		query.Set("sinceTime", since.UTC().Format(time.RFC3339))
+	}
+
+	u := url.URL{
+		Scheme:   "https",
+		Host:     kc.hostAddr(host),
+		Path:     fmt.Sprintf("/containerLogs/%s/%s/%s", key.Namespace, key.Name, container),
+		RawQuery: query.Encode(),
+	}
+	resp, err := kc.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// GetPod returns the pod identified by key which runs on host.
+func (kc *KubeletClient) GetPod(ctx context.Context, host string, key types.NamespacedName) (*corev1.Pod, error) {
+	u := url.URL{Scheme: "https", Host: kc.hostAddr(host), Path: "/pods"}
+	resp, err := kc.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var pods corev1.PodList
+	if err := json.NewDecoder(resp.Body).Decode(&pods); err != nil {
+		return nil, fmt.Errorf("decoding pods: %w", err)
+	}
+	for i, pod := range pods.Items {
+		if pod.Namespace == key.Namespace && pod.Name == key.Name {
+			return &pods.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("pod %s not found on node %s", key, host)
+}
+
+// get sends a GET request to u. The caller must close the body of the
+// returned response.
+func (kc *KubeletClient) get(ctx context.Context, u url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := kc.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %s from kubelet: %s", resp.Status, body)
+	}
+	return resp, nil
+}
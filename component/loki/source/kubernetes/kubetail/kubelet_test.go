@@ -0,0 +1,72 @@
+package kubetail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestKubeletClient(t *testing.T) {
+	var sinceTime string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containerLogs/default/app-0/app", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "true", r.URL.Query().Get("follow"))
+		require.Equal(t, "true", r.URL.Query().Get("timestamps"))
+		sinceTime = r.URL.Query().Get("sinceTime")
+		fmt.Fprintln(w, "2023-01-23T17:00:10Z hello")
+	})
+	mux.HandleFunc("/pods", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(corev1.PodList{
+			Items: []corev1.Pod{
+				{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other", UID: "1"}},
+				{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app-0", UID: "2"}},
+			},
+		})
+	})
+
+	srv := httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	host, portStr, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+
+	kc := &KubeletClient{Client: srv.Client(), Port: port}
+	key := types.NamespacedName{Namespace: "default", Name: "app-0"}
+
+	since := time.Date(2023, time.January, 23, 17, 0, 0, 0, time.UTC)
+	stream, err := kc.StreamLogs(context.Background(), host, key, "app", since)
+	require.NoError(t, err)
+	logs, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	require.NoError(t, stream.Close())
+	require.Equal(t, "2023-01-23T17:00:10Z hello\n", string(logs))
+	require.Equal(t, "2023-01-23T17:00:00Z", sinceTime)
+
+	pod, err := kc.GetPod(context.Background(), host, key)
+	require.NoError(t, err)
+	require.Equal(t, types.UID("2"), pod.UID)
+
+	_, err = kc.GetPod(context.Background(), host, types.NamespacedName{Namespace: "default", Name: "missing"})
+	require.ErrorContains(t, err, "pod default/missing not found")
+
+	_, err = kc.StreamLogs(context.Background(), host, key, "missing", time.Time{})
+	require.ErrorContains(t, err, "404 Not Found")
+}
@@ -18,6 +18,10 @@ type Options struct {
 	// Client to use to request logs from Kubernetes.
 	Client *kubernetes.Clientset
 
+	// Kubelet, if set, is used to request logs from the kubelet of the node
+	// running each pod instead of using Client.
+	Kubelet *KubeletClient
+
 	// Handler to send discovered logs to.
 	Handler loki.EntryHandler
 
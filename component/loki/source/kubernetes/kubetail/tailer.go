@@ -151,19 +151,7 @@ func (t *tailer) tail(ctx context.Context, handler loki.EntryHandler) error {
 		lastReadTime = lastEntry
 	}
 
-	var offsetTime *metav1.Time
-	if !lastReadTime.IsZero() {
-		offsetTime = &metav1.Time{Time: lastReadTime}
-	}
-
-	req := t.opts.Client.CoreV1().Pods(key.Namespace).GetLogs(key.Name, &corev1.PodLogOptions{
-		Follow:     true,
-		Container:  containerName,
-		SinceTime:  offsetTime,
-		Timestamps: true, // Should be forced to true so we can parse the original timestamp back out.
-	})
-
-	stream, err := req.Stream(ctx)
+	stream, err := t.openStream(ctx, key, containerName, lastReadTime)
 	if err != nil {
 		return err
 	}
@@ -222,6 +210,38 @@ func (t *tailer) tail(ctx context.Context, handler loki.EntryHandler) error {
 	}
 }
 
+// openStream opens a stream of the logs of a container, starting at since
+// unless since is zero.
+func (t *tailer) openStream(ctx context.Context, key kubetypes.NamespacedName, containerName string, since time.Time) (io.ReadCloser, error) {
+	if t.opts.Kubelet != nil {
+		if t.target.HostIP() == "" {
+			return nil, fmt.Errorf("missing pod host IP label, which is required to read logs from the kubelet")
+		}
+		return t.opts.Kubelet.StreamLogs(ctx, t.target.HostIP(), key, containerName, since)
+	}
+
+	var offsetTime *metav1.Time
+	if !since.IsZero() {
+		offsetTime = &metav1.Time{Time: since}
+	}
+
+	req := t.opts.Client.CoreV1().Pods(key.Namespace).GetLogs(key.Name, &corev1.PodLogOptions{
+		Follow:     true,
+		Container:  containerName,
+		SinceTime:  offsetTime,
+		Timestamps: true, // Should be forced to true so we can parse the original timestamp back out.
+	})
+	return req.Stream(ctx)
+}
+
+// getPod returns the pod of the container being tailed.
+func (t *tailer) getPod(ctx context.Context, key kubetypes.NamespacedName) (*corev1.Pod, error) {
+	if t.opts.Kubelet != nil {
+		return t.opts.Kubelet.GetPod(ctx, t.target.HostIP(), key)
+	}
+	return t.opts.Client.CoreV1().Pods(key.Namespace).Get(ctx, key.Name, metav1.GetOptions{})
+}
+
 // containerTerminated determines whether the container this tailer was
 // watching has terminated and won't restart. If containerTerminated returns
 // true, it means that no more logs will appear for the watched target.
@@ -231,7 +251,7 @@ func (t *tailer) containerTerminated(ctx context.Context) (terminated bool, err
 		containerName = t.target.ContainerName()
 	)
 
-	podInfo, err := t.opts.Client.CoreV1().Pods(key.Namespace).Get(ctx, key.Name, metav1.GetOptions{})
+	podInfo, err := t.getPod(ctx, key)
 	if err != nil {
 		return false, err
 	}
@@ -18,11 +18,13 @@ const (
 	LabelPodName          = "__pod_name__"
 	LabelPodContainerName = "__pod_container_name__"
 	LabelPodUID           = "__pod_uid__"
+	LabelPodHostIP        = "__pod_host_ip__"
 
 	kubePodNamespace     = "__meta_kubernetes_namespace"
 	kubePodName          = "__meta_kubernetes_pod_name"
 	kubePodContainerName = "__meta_kubernetes_pod_container_name"
 	kubePodUID           = "__meta_kubernetes_pod_uid"
+	kubePodHostIP        = "__meta_kubernetes_pod_host_ip"
 )
 
 // Target represents an individual container being tailed for logs.
@@ -34,6 +36,7 @@ type Target struct {
 	containerName  string
 	id             string // String representation of "namespace/pod:container"; not fully unique
 	uid            string // UID from pod
+	hostIP         string // IP of the node running the pod; may be empty
 	hash           uint64 // Hash of public labels and id
 
 	mut       sync.RWMutex
@@ -53,6 +56,7 @@ func NewTarget(origLabels labels.Labels, lset labels.Labels) *Target {
 
 		containerName = lset.Get(LabelPodContainerName)
 		uid           = lset.Get(LabelPodUID)
+		hostIP        = lset.Get(LabelPodHostIP)
 
 		id           = fmt.Sprintf("%s:%s", namespacedName, containerName)
 		publicLabels = publicLabels(lset)
@@ -74,6 +78,7 @@ func NewTarget(origLabels labels.Labels, lset labels.Labels) *Target {
 		containerName:  containerName,
 		id:             id,
 		uid:            uid,
+		hostIP:         hostIP,
 		hash:           hash,
 	}
 }
@@ -113,6 +118,10 @@ func (t *Target) Hash() uint64 { return t.hash }
 // UID returns the UID for this target, based on the pod's UID.
 func (t *Target) UID() string { return t.uid }
 
+// HostIP returns the IP of the node running the pod. It is empty if the
+// target has no [LabelPodHostIP] label.
+func (t *Target) HostIP() string { return t.hostIP }
+
 // Report reports information about the target.
 func (t *Target) Report(time time.Time, err error) {
 	t.mut.Lock()
@@ -154,6 +163,10 @@ func (t *Target) LastEntry() time.Time {
 // [LabelPodContainerName] label. If this label isn't present, PrepareLabels
 // falls back to __meta_kubernetes_pod_container_name.
 //
+// The IP of the node running the pod, which is required to read logs from the
+// kubelet, is determined by the [LabelPodHostIP] label. If this label isn't
+// present, PrepareLabels falls back to __meta_kubernetes_pod_host_ip.
+//
 // Validation of lset fails if there is no label indicating the pod namespace,
 // pod name, or container name.
 func PrepareLabels(lset labels.Labels, defaultJob string) (res labels.Labels, err error) {
@@ -183,6 +196,7 @@ func PrepareLabels(lset labels.Labels, defaultJob string) (res labels.Labels, er
 		podName          = firstLabelValue(LabelPodName, kubePodName)
 		podContainerName = firstLabelValue(LabelPodContainerName, kubePodContainerName)
 		podUID           = firstLabelValue(LabelPodUID, kubePodUID)
+		podHostIP        = firstLabelValue(LabelPodHostIP, kubePodHostIP)
 	)
 
 	switch {
@@ -210,6 +224,9 @@ func PrepareLabels(lset labels.Labels, defaultJob string) (res labels.Labels, er
 	if !lset.Has(LabelPodUID) {
 		lb.Set(LabelPodUID, podUID)
 	}
+	if !lset.Has(LabelPodHostIP) && podHostIP != "" {
+		lb.Set(LabelPodHostIP, podHostIP)
+	}
 
 	// Meta labels are deleted after relabelling. Other internal labels propagate
 	// to the target which decides whether they will be part of their label set.
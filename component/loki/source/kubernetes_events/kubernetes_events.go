@@ -202,7 +202,7 @@ func (c *Component) Update(args component.Arguments) error {
 	// Create a new restConfig if we don't have one or if our arguments changed.
 	if restConfig == nil || !reflect.DeepEqual(c.args.Client, newArgs.Client) {
 		var err error
-		restConfig, err = newArgs.Client.BuildRESTConfig(c.log)
+		restConfig, err = newArgs.Client.BuildRESTConfig(c.log, c.opts.Registerer)
 		if err != nil {
 			return fmt.Errorf("building Kubernetes client config: %w", err)
 		}
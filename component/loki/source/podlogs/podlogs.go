@@ -216,7 +216,7 @@ func (c *Component) updateTailer(args Arguments) error {
 		return nil
 	}
 
-	cfg, err := args.Client.BuildRESTConfig(c.log)
+	cfg, err := args.Client.BuildRESTConfig(c.log, c.opts.Registerer)
 	if err != nil {
 		return fmt.Errorf("building Kubernetes config: %w", err)
 	}
@@ -277,7 +277,7 @@ func (c *Component) updateController(args Arguments) error {
 		return nil
 	}
 
-	cfg, err := args.Client.BuildRESTConfig(c.log)
+	cfg, err := args.Client.BuildRESTConfig(c.log, c.opts.Registerer)
 	if err != nil {
 		return fmt.Errorf("building Kubernetes config: %w", err)
 	}
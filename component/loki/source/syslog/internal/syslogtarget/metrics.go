@@ -13,6 +13,9 @@ type Metrics struct {
 	syslogEntries       prometheus.Counter
 	syslogParsingErrors prometheus.Counter
 	syslogEmptyMessages prometheus.Counter
+
+	syslogConnections     *prometheus.CounterVec
+	syslogOpenConnections *prometheus.GaugeVec
 }
 
 // NewMetrics creates a new set of syslog metrics. If reg is non-nil, the
@@ -33,12 +36,22 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 		Name: "loki_source_syslog_empty_messages_total",
 		Help: "Total number of empty messages received from syslog",
 	})
+	m.syslogConnections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_source_syslog_connections_total",
+		Help: "Total number of TCP connections accepted by syslog listeners",
+	}, []string{"listen_address"})
+	m.syslogOpenConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "loki_source_syslog_open_connections",
+		Help: "Number of TCP connections currently open to syslog listeners",
+	}, []string{"listen_address"})
 
 	if reg != nil {
 		reg.MustRegister(
 			m.syslogEntries,
 			m.syslogParsingErrors,
 			m.syslogEmptyMessages,
+			m.syslogConnections,
+			m.syslogOpenConnections,
 		)
 	}
 
@@ -0,0 +1,154 @@
+package syslogtarget
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/grafana/loki/clients/pkg/promtail/targets/syslog/syslogparser"
+	"github.com/influxdata/go-syslog/v3"
+	"github.com/influxdata/go-syslog/v3/rfc3164"
+)
+
+// Formats of syslog messages supported by SyslogTarget.
+const (
+	SyslogFormatRFC5424 = "rfc5424"
+	SyslogFormatRFC3164 = "rfc3164"
+)
+
+// ParseStream parses a syslog stream of the given format from r, calling
+// callback with the parsed messages. Octet-counted and non-transparent
+// (newline-delimited) framing are detected from the first byte of the
+// stream. The function returns on EOF or unrecoverable errors.
+func ParseStream(format string, r io.Reader, callback func(res *syslog.Result), maxMessageLength int) error {
+	if format != SyslogFormatRFC3164 {
+		return syslogparser.ParseStream(r, callback, maxMessageLength)
+	}
+
+	// The framing parsers of go-syslog only support RFC5424 messages, so
+	// frames of RFC3164 messages are split here.
+	buf := bufio.NewReaderSize(r, 1<<10)
+
+	b, err := buf.ReadByte()
+	if err != nil {
+		return err
+	}
+	_ = buf.UnreadByte()
+
+	var readFrame func(*bufio.Reader, int) ([]byte, error)
+	switch {
+	case b == '<':
+		readFrame = readNonTransparentFrame
+	case b >= '0' && b <= '9':
+		readFrame = readOctetCountedFrame
+	default:
+		return fmt.Errorf("invalid or unsupported framing. first byte: '%s'", string(b))
+	}
+
+	parser := rfc3164.NewParser(
+		rfc3164.WithBestEffort(),
+		rfc3164.WithYear(rfc3164.CurrentYear{}),
+		rfc3164.WithRFC3339(),
+	)
+
+	for {
+		frame, err := readFrame(buf, maxMessageLength)
+		if len(frame) > 0 {
+			msg, perr := parser.Parse(frame)
+			callback(&syslog.Result{Message: msg, Error: perr})
+		}
+
+		var tooLong errMessageTooLong
+		switch {
+		case err == nil:
+		case errors.As(err, &tooLong):
+			callback(&syslog.Result{Error: err})
+		case errors.Is(err, io.EOF):
+			return nil
+		default:
+			callback(&syslog.Result{Error: err})
+			return nil
+		}
+	}
+}
+
+type errMessageTooLong struct {
+	max int
+}
+
+func (e errMessageTooLong) Error() string {
+	return fmt.Sprintf("message too long to parse. max length: %d", e.max)
+}
+
+// readNonTransparentFrame reads the next frame terminated by a newline from
+// buf. Frames longer than maxLength are discarded with an error.
+func readNonTransparentFrame(buf *bufio.Reader, maxLength int) ([]byte, error) {
+	var frame []byte
+	for {
+		chunk, err := buf.ReadSlice('\n')
+		if len(frame)+len(chunk) > maxLength+1 {
+			// Discard the rest of the frame.
+			for errors.Is(err, bufio.ErrBufferFull) {
+				_, err = buf.ReadSlice('\n')
+			}
+			if err != nil {
+				return nil, err
+			}
+			return nil, errMessageTooLong{max: maxLength}
+		}
+		frame = append(frame, chunk...)
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		return bytes.TrimRight(frame, "\r\n"), err
+	}
+}
+
+// maxLengthDigits is the maximum number of digits of the length prefix of an
+// octet-counted frame, so that a peer can't make the prefix grow without
+// bounds.
+const maxLengthDigits = 10
+
+// readOctetCountedFrame reads the next frame prefixed with its length from
+// buf, as described in RFC6587. Frames longer than maxLength are discarded
+// with an error.
+func readOctetCountedFrame(buf *bufio.Reader, maxLength int) ([]byte, error) {
+	var prefix []byte
+	for {
+		b, err := buf.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(prefix) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if b == ' ' {
+			break
+		}
+		if len(prefix) == maxLengthDigits {
+			return nil, fmt.Errorf("message length prefix is longer than %d digits", maxLengthDigits)
+		}
+		prefix = append(prefix, b)
+	}
+	length, err := strconv.Atoi(string(prefix))
+	if err != nil || length <= 0 {
+		return nil, fmt.Errorf("invalid message length %q", prefix)
+	}
+
+	if length > maxLength {
+		if _, err := buf.Discard(length); err != nil {
+			return nil, err
+		}
+		return nil, errMessageTooLong{max: maxLength}
+	}
+
+	frame := make([]byte, length)
+	if _, err := io.ReadFull(buf, frame); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(frame, "\r\n"), nil
+}
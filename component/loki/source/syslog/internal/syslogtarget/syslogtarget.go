@@ -14,6 +14,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/influxdata/go-syslog/v3"
+	"github.com/influxdata/go-syslog/v3/rfc3164"
 	"github.com/influxdata/go-syslog/v3/rfc5424"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
@@ -32,13 +33,23 @@ var (
 	DefaultProtocol         = protocolTCP
 )
 
+// Config configures a SyslogTarget. It extends the Promtail configuration
+// with settings which Promtail doesn't support.
+type Config struct {
+	scrapeconfig.SyslogTargetConfig
+
+	// SyslogFormat is the format of received messages, either
+	// SyslogFormatRFC5424 (the default) or SyslogFormatRFC3164.
+	SyslogFormat string
+}
+
 // SyslogTarget listens to syslog messages.
 // nolint:revive
 type SyslogTarget struct {
 	metrics       *Metrics
 	logger        log.Logger
 	handler       loki.EntryHandler
-	config        *scrapeconfig.SyslogTargetConfig
+	config        *Config
 	relabelConfig []*relabel.Config
 
 	transport Transport
@@ -59,7 +70,7 @@ func NewSyslogTarget(
 	logger log.Logger,
 	handler loki.EntryHandler,
 	relabel []*relabel.Config,
-	config *scrapeconfig.SyslogTargetConfig,
+	config *Config,
 ) (*SyslogTarget, error) {
 
 	t := &SyslogTarget{
@@ -75,6 +86,7 @@ func NewSyslogTarget(
 	case protocolTCP:
 		t.transport = NewSyslogTCPTransport(
 			config,
+			metrics,
 			t.handleMessage,
 			t.handleMessageError,
 			logger,
@@ -82,6 +94,7 @@ func NewSyslogTarget(
 	case protocolUDP:
 		t.transport = NewSyslogUDPTransport(
 			config,
+			metrics,
 			t.handleMessage,
 			t.handleMessageError,
 			logger,
@@ -111,34 +124,46 @@ func (t *SyslogTarget) handleMessageError(err error) {
 }
 
 func (t *SyslogTarget) handleMessage(connLabels labels.Labels, msg syslog.Message) {
-	rfc5424Msg := msg.(*rfc5424.SyslogMessage)
+	var (
+		base       *syslog.Base
+		rfc5424Msg *rfc5424.SyslogMessage
+	)
+	switch m := msg.(type) {
+	case *rfc5424.SyslogMessage:
+		base, rfc5424Msg = &m.Base, m
+	case *rfc3164.SyslogMessage:
+		base = &m.Base
+	default:
+		level.Debug(t.logger).Log("msg", "dropping syslog message of unknown type", "type", fmt.Sprintf("%T", msg))
+		return
+	}
 
-	if rfc5424Msg.Message == nil {
+	if base.Message == nil {
 		t.metrics.syslogEmptyMessages.Inc()
 		return
 	}
 
 	lb := labels.NewBuilder(connLabels)
-	if v := rfc5424Msg.SeverityLevel(); v != nil {
+	if v := base.SeverityLevel(); v != nil {
 		lb.Set("__syslog_message_severity", *v)
 	}
-	if v := rfc5424Msg.FacilityLevel(); v != nil {
+	if v := base.FacilityLevel(); v != nil {
 		lb.Set("__syslog_message_facility", *v)
 	}
-	if v := rfc5424Msg.Hostname; v != nil {
+	if v := base.Hostname; v != nil {
 		lb.Set("__syslog_message_hostname", *v)
 	}
-	if v := rfc5424Msg.Appname; v != nil {
+	if v := base.Appname; v != nil {
 		lb.Set("__syslog_message_app_name", *v)
 	}
-	if v := rfc5424Msg.ProcID; v != nil {
+	if v := base.ProcID; v != nil {
 		lb.Set("__syslog_message_proc_id", *v)
 	}
-	if v := rfc5424Msg.MsgID; v != nil {
+	if v := base.MsgID; v != nil {
 		lb.Set("__syslog_message_msg_id", *v)
 	}
 
-	if t.config.LabelStructuredData && rfc5424Msg.StructuredData != nil {
+	if t.config.LabelStructuredData && rfc5424Msg != nil && rfc5424Msg.StructuredData != nil {
 		for id, params := range *rfc5424Msg.StructuredData {
 			id = strings.ReplaceAll(id, "@", "_")
 			for name, value := range params {
@@ -159,14 +184,14 @@ func (t *SyslogTarget) handleMessage(connLabels labels.Labels, msg syslog.Messag
 	}
 
 	var timestamp time.Time
-	if t.config.UseIncomingTimestamp && rfc5424Msg.Timestamp != nil {
-		timestamp = *rfc5424Msg.Timestamp
+	if t.config.UseIncomingTimestamp && base.Timestamp != nil {
+		timestamp = *base.Timestamp
 	} else {
 		timestamp = time.Now()
 	}
 
-	m := *rfc5424Msg.Message
-	if t.config.UseRFC5424Message {
+	m := *base.Message
+	if t.config.UseRFC5424Message && rfc5424Msg != nil {
 		fullMsg, err := rfc5424Msg.String()
 		if err != nil {
 			level.Debug(t.logger).Log("msg", "failed to convert rfc5424 message to string; using message field instead", "err", err)
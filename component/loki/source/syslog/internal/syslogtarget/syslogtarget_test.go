@@ -11,6 +11,7 @@ import (
 	"io"
 	"net"
 	"os"
+	"strings"
 	"testing"
 	"time"
 	"unicode/utf8"
@@ -18,8 +19,8 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/component/loki/source/syslog/internal/fake"
 	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
-	"github.com/grafana/loki/clients/pkg/promtail/targets/syslog/syslogparser"
 	"github.com/influxdata/go-syslog/v3"
+	"github.com/influxdata/go-syslog/v3/rfc3164"
 	promconfig "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
@@ -313,14 +314,14 @@ func Benchmark_SyslogTarget(b *testing.B) {
 			client := fake.New(func() {})
 
 			metrics := NewMetrics(nil)
-			tgt, _ := NewSyslogTarget(metrics, log.NewNopLogger(), client, []*relabel.Config{}, &scrapeconfig.SyslogTargetConfig{
+			tgt, _ := NewSyslogTarget(metrics, log.NewNopLogger(), client, []*relabel.Config{}, &Config{SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
 				ListenAddress:       "127.0.0.1:0",
 				ListenProtocol:      tt.protocol,
 				LabelStructuredData: true,
 				Labels: model.LabelSet{
 					"test": "syslog_target",
 				},
-			})
+			}})
 			b.Cleanup(func() {
 				require.NoError(b, tgt.Stop())
 			})
@@ -375,7 +376,7 @@ func TestSyslogTarget(t *testing.T) {
 			client := fake.New(func() {})
 
 			metrics := NewMetrics(nil)
-			tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+			tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &Config{SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
 				MaxMessageLength:    1 << 12, // explicitly not use default value
 				ListenAddress:       "127.0.0.1:0",
 				ListenProtocol:      tt.protocol,
@@ -383,7 +384,7 @@ func TestSyslogTarget(t *testing.T) {
 				Labels: model.LabelSet{
 					"test": "syslog_target",
 				},
-			})
+			}})
 			require.NoError(t, err)
 
 			require.Eventually(t, tgt.Ready, time.Second, 10*time.Millisecond)
@@ -490,7 +491,7 @@ func TestSyslogTarget_RFC5424Messages(t *testing.T) {
 			client := fake.New(func() {})
 
 			metrics := NewMetrics(nil)
-			tgt, err := NewSyslogTarget(metrics, logger, client, []*relabel.Config{}, &scrapeconfig.SyslogTargetConfig{
+			tgt, err := NewSyslogTarget(metrics, logger, client, []*relabel.Config{}, &Config{SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
 				ListenAddress:       "127.0.0.1:0",
 				ListenProtocol:      tt.protocol,
 				LabelStructuredData: true,
@@ -498,7 +499,7 @@ func TestSyslogTarget_RFC5424Messages(t *testing.T) {
 					"test": "syslog_target",
 				},
 				UseRFC5424Message: true,
-			})
+			}})
 			require.NoError(t, err)
 			require.Eventually(t, tgt.Ready, time.Second, 10*time.Millisecond)
 			defer func() {
@@ -534,18 +535,155 @@ func TestSyslogTarget_RFC5424Messages(t *testing.T) {
 	}
 }
 
+func TestSyslogTarget_RFC3164Messages(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		protocol string
+		fmtFunc  formatFunc
+	}{
+		{"tcp newline separated", protocolTCP, fmtNewline},
+		{"tcp octetcounting", protocolTCP, fmtOctetCounting},
+		{"udp newline separated", protocolUDP, fmtNewline},
+		{"udp octetcounting", protocolUDP, fmtOctetCounting},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			w := log.NewSyncWriter(os.Stderr)
+			logger := log.NewLogfmtLogger(w)
+			client := fake.New(func() {})
+
+			metrics := NewMetrics(nil)
+			tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &Config{
+				SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
+					ListenAddress:  "127.0.0.1:0",
+					ListenProtocol: tt.protocol,
+					Labels: model.LabelSet{
+						"test": "syslog_target",
+					},
+					UseIncomingTimestamp: true,
+				},
+				SyslogFormat: SyslogFormatRFC3164,
+			})
+			require.NoError(t, err)
+			require.Eventually(t, tgt.Ready, time.Second, 10*time.Millisecond)
+			defer func() {
+				require.NoError(t, tgt.Stop())
+			}()
+
+			addr := tgt.ListenAddress().String()
+			c, err := net.Dial(tt.protocol, addr)
+			require.NoError(t, err)
+
+			messages := []string{
+				`<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`,
+				`<13>2018-10-11T22:14:15+02:00 mymachine app: An application event log entry...`,
+			}
+
+			err = writeMessagesToStream(c, messages, tt.fmtFunc)
+			require.NoError(t, err)
+			require.NoError(t, c.Close())
+
+			require.Eventuallyf(t, func() bool {
+				return len(client.Received()) == len(messages)
+			}, time.Second, time.Millisecond, "Expected to receive %d messages, got %d.", len(messages), len(client.Received()))
+
+			received := client.Received()
+			require.Equal(t, model.LabelSet{
+				"test":     "syslog_target",
+				"severity": "critical",
+				"facility": "auth",
+				"hostname": "mymachine",
+				"app_name": "su",
+				"proc_id":  "123",
+			}, received[0].Labels)
+			require.Equal(t, "'su root' failed for lonvick on /dev/pts/8", received[0].Line)
+			require.Equal(t, time.Date(time.Now().Year(), time.October, 11, 22, 14, 15, 0, time.UTC), received[0].Timestamp.UTC())
+
+			require.Equal(t, model.LabelSet{
+				"test":     "syslog_target",
+				"severity": "notice",
+				"facility": "user",
+				"hostname": "mymachine",
+				"app_name": "app",
+			}, received[1].Labels)
+			require.Equal(t, "An application event log entry...", received[1].Line)
+			require.Equal(t, time.Date(2018, time.October, 11, 20, 14, 15, 0, time.UTC), received[1].Timestamp.UTC())
+		})
+	}
+}
+
+func TestParseStream_RFC3164(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		input    string
+		messages []string
+		errors   int
+	}{
+		{
+			name:     "newline separated",
+			input:    "<34>Oct 11 22:14:15 host app: first\n\n<34>Oct 11 22:14:16 host app: second",
+			messages: []string{"first", "second"},
+		},
+		{
+			name:     "octetcounting",
+			input:    "28 <34>Oct 11 22:14:15 h a: one29 <34>Oct 11 22:14:16 h a: two\n",
+			messages: []string{"one", "two"},
+		},
+		{
+			name:     "newline separated message too long",
+			input:    "<34>Oct 11 22:14:15 host app: " + strings.Repeat("x", 100) + "\n<34>Oct 11 22:14:16 host app: short\n",
+			messages: []string{"short"},
+			errors:   1,
+		},
+		{
+			name:     "octetcounting message too long",
+			input:    "130 <34>Oct 11 22:14:15 host app: " + strings.Repeat("x", 100) + "28 <34>Oct 11 22:14:16 h a: two",
+			messages: []string{"two"},
+			errors:   1,
+		},
+		{
+			name:   "octetcounting invalid length",
+			input:  "1x <34>Oct 11 22:14:15 h a: one",
+			errors: 1,
+		},
+		{
+			name:   "octetcounting length prefix too long",
+			input:  strings.Repeat("1", 1000) + " <34>Oct 11 22:14:15 h a: one",
+			errors: 1,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				messages []string
+				errors   int
+			)
+			err := ParseStream(SyslogFormatRFC3164, strings.NewReader(tt.input), func(res *syslog.Result) {
+				if res.Error != nil {
+					errors++
+					return
+				}
+				messages = append(messages, *res.Message.(*rfc3164.SyslogMessage).Message)
+			}, 64)
+			require.NoError(t, err)
+			require.Equal(t, tt.messages, messages)
+			require.Equal(t, tt.errors, errors)
+		})
+	}
+}
+
 func TestSyslogTarget_TLSConfigWithoutServerCertificate(t *testing.T) {
 	w := log.NewSyncWriter(os.Stderr)
 	logger := log.NewLogfmtLogger(w)
 	client := fake.New(func() {})
 
 	metrics := NewMetrics(nil)
-	_, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+	_, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &Config{SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
 		ListenAddress: "127.0.0.1:0",
 		TLSConfig: promconfig.TLSConfig{
 			KeyFile: "foo",
 		},
-	})
+	}})
 	require.Error(t, err, "error setting up syslog target: certificate and key files are required")
 }
 
@@ -555,12 +693,12 @@ func TestSyslogTarget_TLSConfigWithoutServerKey(t *testing.T) {
 	client := fake.New(func() {})
 
 	metrics := NewMetrics(nil)
-	_, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+	_, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &Config{SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
 		ListenAddress: "127.0.0.1:0",
 		TLSConfig: promconfig.TLSConfig{
 			CertFile: "foo",
 		},
-	})
+	}})
 	require.Error(t, err, "error setting up syslog target: certificate and key files are required")
 }
 
@@ -594,7 +732,7 @@ func testSyslogTargetWithTLS(t *testing.T, fmtFunc formatFunc) {
 	client := fake.New(func() {})
 
 	metrics := NewMetrics(nil)
-	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &Config{SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
 		ListenAddress:       "127.0.0.1:0",
 		LabelStructuredData: true,
 		Labels: model.LabelSet{
@@ -604,7 +742,7 @@ func testSyslogTargetWithTLS(t *testing.T, fmtFunc formatFunc) {
 			CertFile: serverCertFile.Name(),
 			KeyFile:  serverKeyFile.Name(),
 		},
-	})
+	}})
 	require.NoError(t, err)
 	defer func() {
 		require.NoError(t, tgt.Stop())
@@ -726,7 +864,7 @@ func testSyslogTargetWithTLSVerifyClientCertificate(t *testing.T, fmtFunc format
 	client := fake.New(func() {})
 
 	metrics := NewMetrics(nil)
-	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &Config{SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
 		ListenAddress:       "127.0.0.1:0",
 		LabelStructuredData: true,
 		Labels: model.LabelSet{
@@ -737,7 +875,7 @@ func testSyslogTargetWithTLSVerifyClientCertificate(t *testing.T, fmtFunc format
 			CertFile: serverCertFile.Name(),
 			KeyFile:  serverKeyFile.Name(),
 		},
-	})
+	}})
 	require.NoError(t, err)
 	defer func() {
 		require.NoError(t, tgt.Stop())
@@ -804,9 +942,9 @@ func TestSyslogTarget_InvalidData(t *testing.T) {
 	client := fake.New(func() {})
 	metrics := NewMetrics(nil)
 
-	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &Config{SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
 		ListenAddress: "127.0.0.1:0",
-	})
+	}})
 	require.NoError(t, err)
 	defer func() {
 		require.NoError(t, tgt.Stop())
@@ -835,9 +973,9 @@ func TestSyslogTarget_NonUTF8Message(t *testing.T) {
 	client := fake.New(func() {})
 	metrics := NewMetrics(nil)
 
-	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &Config{SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
 		ListenAddress: "127.0.0.1:0",
-	})
+	}})
 	require.NoError(t, err)
 	defer func() {
 		require.NoError(t, tgt.Stop())
@@ -873,10 +1011,10 @@ func TestSyslogTarget_IdleTimeout(t *testing.T) {
 	client := fake.New(func() {})
 	metrics := NewMetrics(nil)
 
-	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &scrapeconfig.SyslogTargetConfig{
+	tgt, err := NewSyslogTarget(metrics, logger, client, relabelConfig(t), &Config{SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
 		ListenAddress: "127.0.0.1:0",
 		IdleTimeout:   time.Millisecond,
-	})
+	}})
 	require.NoError(t, err)
 	defer func() {
 		require.NoError(t, tgt.Stop())
@@ -918,7 +1056,7 @@ func TestParseStream_WithAsyncPipe(t *testing.T) {
 		results = append(results, res)
 	}
 
-	err := syslogparser.ParseStream(pipe, cb, DefaultMaxMessageLength)
+	err := ParseStream(SyslogFormatRFC5424, pipe, cb, DefaultMaxMessageLength)
 	require.NoError(t, err)
 	require.Equal(t, 3, len(results))
 }
@@ -16,6 +16,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/grafana/agent/pkg/handover"
 	"github.com/grafana/dskit/backoff"
 	"github.com/mwitkow/go-conntrack"
 
@@ -23,9 +24,6 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/influxdata/go-syslog/v3"
 	"github.com/prometheus/prometheus/model/labels"
-
-	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
-	"github.com/grafana/loki/clients/pkg/promtail/targets/syslog/syslogparser"
 )
 
 var (
@@ -45,8 +43,9 @@ type handleMessage func(labels.Labels, syslog.Message)
 type handleMessageError func(error)
 
 type baseTransport struct {
-	config *scrapeconfig.SyslogTargetConfig
-	logger log.Logger
+	config  *Config
+	metrics *Metrics
+	logger  log.Logger
 
 	openConnections *sync.WaitGroup
 
@@ -106,10 +105,11 @@ func lookupAddr(addr string) string {
 	return strings.Join(names, ",")
 }
 
-func newBaseTransport(config *scrapeconfig.SyslogTargetConfig, handleMessage handleMessage, handleError handleMessageError, logger log.Logger) *baseTransport {
+func newBaseTransport(config *Config, metrics *Metrics, handleMessage handleMessage, handleError handleMessageError, logger log.Logger) *baseTransport {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &baseTransport{
 		config:             config,
+		metrics:            metrics,
 		logger:             logger,
 		openConnections:    new(sync.WaitGroup),
 		handleMessage:      handleMessage,
@@ -165,19 +165,19 @@ type TCPTransport struct {
 	listener net.Listener
 }
 
-func NewSyslogTCPTransport(config *scrapeconfig.SyslogTargetConfig, handleMessage handleMessage, handleError handleMessageError, logger log.Logger) Transport {
+func NewSyslogTCPTransport(config *Config, metrics *Metrics, handleMessage handleMessage, handleError handleMessageError, logger log.Logger) Transport {
 	return &TCPTransport{
-		baseTransport: newBaseTransport(config, handleMessage, handleError, logger),
+		baseTransport: newBaseTransport(config, metrics, handleMessage, handleError, logger),
 	}
 }
 
 // Run implements SyslogTransport
 func (t *TCPTransport) Run() error {
-	l, err := net.Listen(protocolTCP, t.config.ListenAddress)
-	l = conntrack.NewListener(l, conntrack.TrackWithName("syslog_target/"+t.config.ListenAddress))
+	l, err := handover.Listen(protocolTCP, t.config.ListenAddress)
 	if err != nil {
 		return fmt.Errorf("error setting up syslog target: %w", err)
 	}
+	l = conntrack.NewListener(l, conntrack.TrackWithName("syslog_target/"+t.config.ListenAddress))
 
 	tlsEnabled := t.config.TLSConfig.CertFile != "" || t.config.TLSConfig.KeyFile != "" || t.config.TLSConfig.CAFile != ""
 	if tlsEnabled {
@@ -266,6 +266,11 @@ func (t *TCPTransport) acceptConnections() {
 func (t *TCPTransport) handleConnection(cn net.Conn) {
 	defer t.openConnections.Done()
 
+	listenAddress := t.listener.Addr().String()
+	t.metrics.syslogConnections.WithLabelValues(listenAddress).Inc()
+	t.metrics.syslogOpenConnections.WithLabelValues(listenAddress).Inc()
+	defer t.metrics.syslogOpenConnections.WithLabelValues(listenAddress).Dec()
+
 	c := &idleTimeoutConn{cn, t.idleTimeout()}
 
 	handlerCtx, cancel := context.WithCancel(t.ctx)
@@ -277,7 +282,7 @@ func (t *TCPTransport) handleConnection(cn net.Conn) {
 
 	lbs := t.connectionLabels(ipFromConn(c).String())
 
-	err := syslogparser.ParseStream(c, func(result *syslog.Result) {
+	err := ParseStream(t.config.SyslogFormat, c, func(result *syslog.Result) {
 		if err := result.Error; err != nil {
 			t.handleMessageError(err)
 			return
@@ -311,23 +316,22 @@ type UDPTransport struct {
 	udpConn *net.UDPConn
 }
 
-func NewSyslogUDPTransport(config *scrapeconfig.SyslogTargetConfig, handleMessage handleMessage, handleError handleMessageError, logger log.Logger) Transport {
+func NewSyslogUDPTransport(config *Config, metrics *Metrics, handleMessage handleMessage, handleError handleMessageError, logger log.Logger) Transport {
 	return &UDPTransport{
-		baseTransport: newBaseTransport(config, handleMessage, handleError, logger),
+		baseTransport: newBaseTransport(config, metrics, handleMessage, handleError, logger),
 	}
 }
 
 // Run implements SyslogTransport
 func (t *UDPTransport) Run() error {
-	var err error
-	addr, err := net.ResolveUDPAddr(protocolUDP, t.config.ListenAddress)
-	if err != nil {
+	if _, err := net.ResolveUDPAddr(protocolUDP, t.config.ListenAddress); err != nil {
 		return fmt.Errorf("error resolving UDP address: %w", err)
 	}
-	t.udpConn, err = net.ListenUDP(protocolUDP, addr)
+	conn, err := handover.ListenPacket(protocolUDP, t.config.ListenAddress)
 	if err != nil {
 		return fmt.Errorf("error setting up syslog target: %w", err)
 	}
+	t.udpConn = conn.(*net.UDPConn)
 	_ = t.udpConn.SetReadBuffer(1024 * 1024)
 	level.Info(t.logger).Log("msg", "syslog listening on address", "address", t.Addr().String(), "protocol", protocolUDP)
 
@@ -386,7 +390,7 @@ func (t *UDPTransport) handleRcv(c *ConnPipe) {
 	defer t.openConnections.Done()
 
 	lbs := t.connectionLabels(c.addr.String())
-	err := syslogparser.ParseStream(c, func(result *syslog.Result) {
+	err := ParseStream(t.config.SyslogFormat, c, func(result *syslog.Result) {
 		if err := result.Error; err != nil {
 			t.handleMessageError(err)
 		} else {
@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/loki"
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
 	"github.com/grafana/regexp"
 	"github.com/phayes/freeport"
@@ -201,3 +202,29 @@ func mustNewRegexp(s string) flow_relabel.Regexp {
 	}
 	return flow_relabel.Regexp{Regexp: re}
 }
+
+func TestListenerConfig_SyslogFormat(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		listener {
+			address = "localhost:1514"
+		}
+		listener {
+			address       = "localhost:1515"
+			syslog_format = "rfc3164"
+		}
+		forward_to = []
+	`), &args)
+	require.NoError(t, err)
+	require.Equal(t, "rfc5424", args.SyslogListeners[0].SyslogFormat)
+	require.Equal(t, "rfc3164", args.SyslogListeners[1].SyslogFormat)
+
+	err = river.Unmarshal([]byte(`
+		listener {
+			address       = "localhost:1514"
+			syslog_format = "rfc3339"
+		}
+		forward_to = []
+	`), &args)
+	require.ErrorContains(t, err, `syslog listener format should be either "rfc5424" or "rfc3164", got rfc3339`)
+}
@@ -21,6 +21,7 @@ type ListenerConfig struct {
 	UseIncomingTimestamp bool              `river:"use_incoming_timestamp,attr,optional"`
 	UseRFC5424Message    bool              `river:"use_rfc5424_message,attr,optional"`
 	MaxMessageLength     int               `river:"max_message_length,attr,optional"`
+	SyslogFormat         string            `river:"syslog_format,attr,optional"`
 	TLSConfig            config.TLSConfig  `river:"tls_config,block,optional"`
 }
 
@@ -29,6 +30,7 @@ var DefaultListenerConfig = ListenerConfig{
 	ListenProtocol:   st.DefaultProtocol,
 	IdleTimeout:      st.DefaultIdleTimeout,
 	MaxMessageLength: st.DefaultMaxMessageLength,
+	SyslogFormat:     st.SyslogFormatRFC5424,
 }
 
 var _ river.Unmarshaler = (*ListenerConfig)(nil)
@@ -47,25 +49,32 @@ func (sc *ListenerConfig) UnmarshalRiver(f func(interface{}) error) error {
 		return fmt.Errorf("syslog listener protocol should be either 'tcp' or 'udp', got %s", sc.ListenProtocol)
 	}
 
+	if sc.SyslogFormat != st.SyslogFormatRFC5424 && sc.SyslogFormat != st.SyslogFormatRFC3164 {
+		return fmt.Errorf("syslog listener format should be either %q or %q, got %s", st.SyslogFormatRFC5424, st.SyslogFormatRFC3164, sc.SyslogFormat)
+	}
+
 	return nil
 }
 
 // Convert is used to bridge between the River and Promtail types.
-func (sc ListenerConfig) Convert() *scrapeconfig.SyslogTargetConfig {
+func (sc ListenerConfig) Convert() *st.Config {
 	lbls := make(model.LabelSet, len(sc.Labels))
 	for k, v := range sc.Labels {
 		lbls[model.LabelName(k)] = model.LabelValue(v)
 	}
 
-	return &scrapeconfig.SyslogTargetConfig{
-		ListenAddress:        sc.ListenAddress,
-		ListenProtocol:       sc.ListenProtocol,
-		IdleTimeout:          sc.IdleTimeout,
-		LabelStructuredData:  sc.LabelStructuredData,
-		Labels:               lbls,
-		UseIncomingTimestamp: sc.UseIncomingTimestamp,
-		UseRFC5424Message:    sc.UseRFC5424Message,
-		MaxMessageLength:     sc.MaxMessageLength,
-		TLSConfig:            *sc.TLSConfig.Convert(),
+	return &st.Config{
+		SyslogTargetConfig: scrapeconfig.SyslogTargetConfig{
+			ListenAddress:        sc.ListenAddress,
+			ListenProtocol:       sc.ListenProtocol,
+			IdleTimeout:          sc.IdleTimeout,
+			LabelStructuredData:  sc.LabelStructuredData,
+			Labels:               lbls,
+			UseIncomingTimestamp: sc.UseIncomingTimestamp,
+			UseRFC5424Message:    sc.UseRFC5424Message,
+			MaxMessageLength:     sc.MaxMessageLength,
+			TLSConfig:            *sc.TLSConfig.Convert(),
+		},
+		SyslogFormat: sc.SyslogFormat,
 	}
 }
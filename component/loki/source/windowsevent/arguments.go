@@ -1,9 +1,13 @@
 package windowsevent
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/prometheus/common/model"
 )
 
 // Arguments holds values which are used to configure the loki.source.windowsevent
@@ -15,8 +19,10 @@ type Arguments struct {
 	BookmarkPath         string              `river:"bookmark_path,attr,optional"`
 	PollInterval         time.Duration       `river:"poll_interval,attr,optional"`
 	ExcludeEventData     bool                `river:"exclude_event_data,attr,optional"`
+	ExcludeEventMessage  bool                `river:"exclude_event_message,attr,optional"`
 	ExcludeUserdata      bool                `river:"exclude_user_data,attr,optional"`
 	UseIncomingTimestamp bool                `river:"use_incoming_timestamp,attr,optional"`
+	Labels               map[string]string   `river:"labels,attr,optional"`
 	ForwardTo            []loki.LogsReceiver `river:"forward_to,attr"`
 }
 
@@ -28,6 +34,7 @@ func defaultArgs() Arguments {
 		BookmarkPath:         "",
 		PollInterval:         3 * time.Second,
 		ExcludeEventData:     false,
+		ExcludeEventMessage:  false,
 		ExcludeUserdata:      false,
 		UseIncomingTimestamp: false,
 	}
@@ -42,5 +49,43 @@ func (r *Arguments) UnmarshalRiver(f func(v interface{}) error) error {
 		return err
 	}
 
+	// Queries in XML form select the event logs to read from themselves, while
+	// queries in short form are applied to eventlog_name.
+	if r.EventLogName == "" && !isXMLQuery(r.XPathQuery) {
+		return fmt.Errorf("eventlog_name must be set when xpath_query is in short form")
+	}
+	if r.PollInterval <= 0 {
+		return fmt.Errorf("poll_interval must be greater than 0")
+	}
+	for name := range r.Labels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
 	return nil
 }
+
+func isXMLQuery(query string) bool {
+	return strings.HasPrefix(strings.TrimSpace(query), "<")
+}
+
+// convertConfig converts Arguments to the Promtail type.
+func convertConfig(arg Arguments) *scrapeconfig.WindowsEventsTargetConfig {
+	lbls := make(model.LabelSet, len(arg.Labels))
+	for k, v := range arg.Labels {
+		lbls[model.LabelName(k)] = model.LabelValue(v)
+	}
+
+	return &scrapeconfig.WindowsEventsTargetConfig{
+		Locale:               uint32(arg.Locale),
+		EventlogName:         arg.EventLogName,
+		Query:                arg.XPathQuery,
+		UseIncomingTimestamp: arg.UseIncomingTimestamp,
+		BookmarkPath:         arg.BookmarkPath,
+		PollInterval:         arg.PollInterval,
+		ExcludeEventData:     arg.ExcludeEventData,
+		ExcludeEventMessage:  arg.ExcludeEventMessage,
+		ExcludeUserData:      arg.ExcludeUserdata,
+		Labels:               lbls,
+	}
+}
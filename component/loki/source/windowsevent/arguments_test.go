@@ -0,0 +1,83 @@
+package windowsevent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRiver(t *testing.T) {
+	var riverConfig = `
+	eventlog_name         = "Application"
+	xpath_query           = "Event/System[EventID=1000]"
+	exclude_event_message = true
+	labels                = { "job" = "windows" }
+	forward_to            = []
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverConfig), &args))
+	require.Equal(t, 3*time.Second, args.PollInterval)
+
+	cfg := convertConfig(args)
+	require.Equal(t, "Application", cfg.EventlogName)
+	require.Equal(t, "Event/System[EventID=1000]", cfg.Query)
+	require.True(t, cfg.ExcludeEventMessage)
+	require.Equal(t, model.LabelSet{"job": "windows"}, cfg.Labels)
+}
+
+func TestUnmarshalRiverInvalid(t *testing.T) {
+	tt := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name: "short query without event log",
+			config: `
+	xpath_query = "*"
+	forward_to  = []
+`,
+			expectedErr: "eventlog_name must be set when xpath_query is in short form",
+		},
+		{
+			name: "invalid poll interval",
+			config: `
+	eventlog_name = "Application"
+	poll_interval = "0s"
+	forward_to    = []
+`,
+			expectedErr: "poll_interval must be greater than 0",
+		},
+		{
+			name: "invalid label name",
+			config: `
+	eventlog_name = "Application"
+	labels        = { "not-valid" = "value" }
+	forward_to    = []
+`,
+			expectedErr: `invalid label name "not-valid"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestUnmarshalRiverXMLQuery(t *testing.T) {
+	var riverConfig = `
+	xpath_query = "<QueryList><Query Id='0' Path='Application'><Select Path='Application'>*</Select></Query></QueryList>"
+	forward_to  = []
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverConfig), &args))
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/loki/clients/pkg/promtail/api"
-	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/windows"
 )
 
@@ -111,7 +110,7 @@ func (c *Component) Update(args component.Arguments) error {
 	// Create the bookmark file and parent folders if they don't exist.
 	_, err := os.Stat(newArgs.BookmarkPath)
 	if os.IsNotExist(err) {
-		err := os.MkdirAll(path.Dir(newArgs.BookmarkPath), 644)
+		err := os.MkdirAll(path.Dir(newArgs.BookmarkPath), 0755)
 		if err != nil {
 			return err
 		}
@@ -139,17 +138,3 @@ func (c *Component) Update(args component.Arguments) error {
 	c.receivers = newArgs.ForwardTo
 	return nil
 }
-
-func convertConfig(arg Arguments) *scrapeconfig.WindowsEventsTargetConfig {
-	return &scrapeconfig.WindowsEventsTargetConfig{
-		Locale:               uint32(arg.Locale),
-		EventlogName:         arg.EventLogName,
-		Query:                arg.XPathQuery,
-		UseIncomingTimestamp: arg.UseIncomingTimestamp,
-		BookmarkPath:         arg.BookmarkPath,
-		PollInterval:         arg.PollInterval,
-		ExcludeEventData:     arg.ExcludeEventData,
-		ExcludeEventMessage:  false,
-		ExcludeUserData:      arg.ExcludeUserdata,
-	}
-}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/grafana/dskit/backoff"
+)
+
+// DefaultBackoffMultiplier is the factor the backoff grows by after every
+// retry if no multiplier is configured.
+const DefaultBackoffMultiplier = 2
+
+// retryBackoff implements the exponential backoff with randomized wait times
+// of dskit, but grows the wait time by a configurable multiplier and stops
+// retrying once the maximum elapsed time is reached.
+type retryBackoff struct {
+	cfg        backoff.Config
+	multiplier float64
+	ctx        context.Context
+	deadline   time.Time // Zero if there's no maximum elapsed time.
+
+	numRetries   int
+	nextDelayMin time.Duration
+	nextDelayMax time.Duration
+}
+
+func newRetryBackoff(ctx context.Context, cfg backoff.Config, multiplier float64, maxElapsedTime time.Duration) *retryBackoff {
+	if multiplier == 0 {
+		multiplier = DefaultBackoffMultiplier
+	}
+	b := &retryBackoff{
+		cfg:          cfg,
+		multiplier:   multiplier,
+		ctx:          ctx,
+		nextDelayMin: cfg.MinBackoff,
+	}
+	b.nextDelayMax = b.grow(cfg.MinBackoff)
+	if maxElapsedTime > 0 {
+		b.deadline = time.Now().Add(maxElapsedTime)
+	}
+	return b
+}
+
+// Ongoing returns true if the caller should keep retrying.
+func (b *retryBackoff) Ongoing() bool {
+	if b.ctx.Err() != nil {
+		return false
+	}
+	if b.cfg.MaxRetries != 0 && b.numRetries >= b.cfg.MaxRetries {
+		return false
+	}
+	return b.deadline.IsZero() || time.Now().Before(b.deadline)
+}
+
+// Wait sleeps for the backoff time, then increases the retry count and
+// backoff time. It returns early if the context is canceled or the maximum
+// elapsed time is reached.
+func (b *retryBackoff) Wait() {
+	sleepTime := b.nextDelay()
+	if !b.Ongoing() {
+		return
+	}
+	if !b.deadline.IsZero() {
+		if remaining := time.Until(b.deadline); remaining < sleepTime {
+			sleepTime = remaining
+		}
+	}
+
+	select {
+	case <-b.ctx.Done():
+	case <-time.After(sleepTime):
+	}
+}
+
+func (b *retryBackoff) nextDelay() time.Duration {
+	b.numRetries++
+
+	if b.nextDelayMin >= b.nextDelayMax {
+		return b.nextDelayMin
+	}
+
+	// Add a jitter within the next exponential backoff range.
+	sleepTime := b.nextDelayMin + time.Duration(rand.Int63n(int64(b.nextDelayMax-b.nextDelayMin)))
+
+	if b.nextDelayMax < b.cfg.MaxBackoff {
+		b.nextDelayMin = b.grow(b.nextDelayMin)
+		b.nextDelayMax = b.grow(b.nextDelayMax)
+	}
+	return sleepTime
+}
+
+// grow multiplies d by the multiplier, up to the maximum backoff.
+func (b *retryBackoff) grow(d time.Duration) time.Duration {
+	next := time.Duration(float64(d) * b.multiplier)
+	if next > b.cfg.MaxBackoff || next < d {
+		return b.cfg.MaxBackoff
+	}
+	return next
+}
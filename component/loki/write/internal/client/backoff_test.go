@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/backoff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryBackoff_Multiplier(t *testing.T) {
+	cfg := backoff.Config{MinBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	b := newRetryBackoff(context.Background(), cfg, 3, 0)
+
+	// The delay is picked within a range which grows by the multiplier until
+	// its upper bound reaches the maximum backoff.
+	for _, tc := range []struct{ min, max time.Duration }{
+		{1 * time.Second, 3 * time.Second},
+		{3 * time.Second, 9 * time.Second},
+		{9 * time.Second, 10 * time.Second},
+		{9 * time.Second, 10 * time.Second},
+	} {
+		delay := b.nextDelay()
+		require.GreaterOrEqual(t, delay, tc.min)
+		require.LessOrEqual(t, delay, tc.max)
+	}
+}
+
+func TestRetryBackoff_MaxElapsedTime(t *testing.T) {
+	cfg := backoff.Config{MinBackoff: time.Hour, MaxBackoff: time.Hour}
+	b := newRetryBackoff(context.Background(), cfg, 0, 50*time.Millisecond)
+	require.True(t, b.Ongoing())
+
+	start := time.Now()
+	b.Wait()
+	require.Less(t, time.Since(start), time.Second)
+	require.False(t, b.Ongoing())
+}
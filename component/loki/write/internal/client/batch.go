@@ -12,6 +12,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/columnar"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/prometheus/common/model"
 )
@@ -29,6 +30,11 @@ type batch struct {
 	bytes     int
 	createdAt time.Time
 
+	// structuredMetadata holds the structured metadata of the entries of
+	// each stream, keyed by the labels of the stream and aligned with its
+	// entries. It's nil unless an entry of the batch has structured metadata.
+	structuredMetadata map[string][]model.LabelSet
+
 	maxStreams int
 }
 
@@ -51,12 +57,13 @@ func newBatch(maxStreams int, entries ...loki.Entry) *batch {
 
 // add an entry to the batch
 func (b *batch) add(entry loki.Entry) error {
-	b.bytes += len(entry.Line)
+	b.bytes += len(entry.Line) + structuredMetadataSize(entry.StructuredMetadata)
 
 	// Append the entry to an already existing stream (if any)
 	labels := labelsMapToString(entry.Labels, ReservedLabelTenantID)
 	if stream, ok := b.streams[labels]; ok {
 		stream.Entries = append(stream.Entries, entry.Entry)
+		b.addStructuredMetadata(labels, len(stream.Entries), entry.StructuredMetadata)
 		return nil
 	}
 
@@ -69,9 +76,27 @@ func (b *batch) add(entry loki.Entry) error {
 		Labels:  labels,
 		Entries: []logproto.Entry{entry.Entry},
 	}
+	b.addStructuredMetadata(labels, 1, entry.StructuredMetadata)
 	return nil
 }
 
+// addStructuredMetadata records md as the structured metadata of the last of
+// the given number of entries of the stream with the given labels.
+func (b *batch) addStructuredMetadata(labels string, entries int, md model.LabelSet) {
+	if len(md) == 0 && b.structuredMetadata[labels] == nil {
+		return
+	}
+	if b.structuredMetadata == nil {
+		b.structuredMetadata = map[string][]model.LabelSet{}
+	}
+
+	mds := b.structuredMetadata[labels]
+	for len(mds) < entries-1 {
+		mds = append(mds, nil)
+	}
+	b.structuredMetadata[labels] = append(mds, md)
+}
+
 func labelsMapToString(ls model.LabelSet, without ...model.LabelName) string {
 	lstrs := make([]string, 0, len(ls))
 Outer:
@@ -96,7 +121,7 @@ func (b *batch) sizeBytes() int {
 // sizeBytesAfter returns the size of the batch after the input entry
 // will be added to the batch itself
 func (b *batch) sizeBytesAfter(entry loki.Entry) int {
-	return b.bytes + len(entry.Line)
+	return b.bytes + len(entry.Line) + structuredMetadataSize(entry.StructuredMetadata)
 }
 
 // age of the batch since its creation
@@ -108,7 +133,16 @@ func (b *batch) age() time.Duration {
 // the encoded bytes and the number of encoded entries
 func (b *batch) encode() ([]byte, int, error) {
 	req, entriesCount := b.createPushRequest()
-	buf, err := proto.Marshal(req)
+
+	var (
+		buf []byte
+		err error
+	)
+	if b.structuredMetadata != nil {
+		buf, err = encodePushRequest(req, b.structuredMetadata)
+	} else {
+		buf, err = proto.Marshal(req)
+	}
 	if err != nil {
 		return nil, 0, err
 	}
@@ -116,6 +150,14 @@ func (b *batch) encode() ([]byte, int, error) {
 	return buf, entriesCount, nil
 }
 
+// encodeColumnar encodes the batch in the columnar encoding, and returns the
+// encoded bytes and the number of encoded entries. Structured metadata isn't
+// encoded.
+func (b *batch) encodeColumnar() ([]byte, int) {
+	req, entriesCount := b.createPushRequest()
+	return columnar.Encode(req), entriesCount
+}
+
 // creates push request and returns it, together with number of entries
 func (b *batch) createPushRequest() (*logproto.PushRequest, int) {
 	req := logproto.PushRequest{
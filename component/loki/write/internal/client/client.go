@@ -18,8 +18,11 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	types "github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/component/common/loki"
-	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/agent/component/common/loki/columnar"
+	"github.com/grafana/agent/component/common/resolver"
+	"github.com/grafana/agent/pkg/flow/events"
 	lokiutil "github.com/grafana/loki/pkg/util"
 	"github.com/grafana/loki/pkg/util/build"
 	"github.com/prometheus/client_golang/prometheus"
@@ -51,6 +54,7 @@ type Metrics struct {
 	droppedEntries   *prometheus.CounterVec
 	requestDuration  *prometheus.HistogramVec
 	batchRetries     *prometheus.CounterVec
+	walPendingBytes  *prometheus.GaugeVec
 	countersWithHost []*prometheus.CounterVec
 	streamLag        *prometheus.GaugeVec
 }
@@ -86,6 +90,10 @@ func NewMetrics(reg prometheus.Registerer, streamLagLabels []string) *Metrics {
 		Name: "loki_write_batch_retries_total",
 		Help: "Number of times batches has had to be retried.",
 	}, []string{HostLabel})
+	m.walPendingBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "loki_write_wal_pending_bytes",
+		Help: "Size of the batches in the write-ahead log which weren't delivered yet.",
+	}, []string{HostLabel})
 
 	m.countersWithHost = []*prometheus.CounterVec{
 		m.encodedBytes, m.sentBytes, m.droppedBytes, m.sentEntries, m.droppedEntries,
@@ -106,6 +114,7 @@ func NewMetrics(reg prometheus.Registerer, streamLagLabels []string) *Metrics {
 		m.droppedEntries = mustRegisterOrGet(reg, m.droppedEntries).(*prometheus.CounterVec)
 		m.requestDuration = mustRegisterOrGet(reg, m.requestDuration).(*prometheus.HistogramVec)
 		m.batchRetries = mustRegisterOrGet(reg, m.batchRetries).(*prometheus.CounterVec)
+		m.walPendingBytes = mustRegisterOrGet(reg, m.walPendingBytes).(*prometheus.GaugeVec)
 		m.streamLag = mustRegisterOrGet(reg, m.streamLag).(*prometheus.GaugeVec)
 	}
 
@@ -149,6 +158,23 @@ type client struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	maxStreams int
+
+	// down is true while the endpoint is considered down because the last
+	// batch couldn't be delivered to it. It's only accessed from the
+	// goroutine sending batches: run, or runWAL if the WAL is enabled.
+	down bool
+
+	// useColumnar is true while batches are sent in the columnar encoding.
+	// It's only accessed from run.
+	useColumnar bool
+
+	// wal buffers batches until they're delivered if the WAL is enabled. The
+	// batches are sent by runWAL, which stops once walCtx is canceled.
+	wal       *diskQueue
+	walCtx    context.Context
+	walCancel context.CancelFunc
+	walOnce   sync.Once
+	walWg     sync.WaitGroup
 }
 
 // Tripperware can wrap a roundtripper.
@@ -181,6 +207,10 @@ func newClient(metrics *Metrics, cfg Config, streamLagLabels []string, maxStream
 		ctx:            ctx,
 		cancel:         cancel,
 		maxStreams:     maxStreams,
+
+		// Batches are buffered in the WAL as protobuf, so that they can be
+		// sent to endpoints which don't support the columnar encoding.
+		useColumnar: cfg.Encoding == EncodingColumnar && cfg.WAL == nil,
 	}
 	if cfg.Name != "" {
 		c.name = cfg.Name
@@ -191,7 +221,17 @@ func newClient(metrics *Metrics, cfg Config, streamLagLabels []string, maxStream
 		return nil, err
 	}
 
-	c.client, err = config.NewClientFromConfig(cfg.Client, "GrafanaAgent", config.WithHTTP2Disabled())
+	// Options of the client take precedence over the HTTP client defaults.
+	clientOpts := cfg.HTTPClientOptions
+	if clientOpts == nil {
+		clientOpts = types.HTTPClientOptions()
+	}
+	clientOpts = append(clientOpts[:len(clientOpts):len(clientOpts)], config.WithHTTP2Disabled())
+	if cfg.DNS != nil {
+		clientOpts = append(clientOpts, config.WithDialContextFunc(resolver.New(*cfg.DNS).DialContext))
+	}
+
+	c.client, err = config.NewClientFromConfig(cfg.Client, "GrafanaAgent", clientOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -204,6 +244,18 @@ func newClient(metrics *Metrics, cfg Config, streamLagLabels []string, maxStream
 		counter.WithLabelValues(c.cfg.URL.Host).Add(0)
 	}
 
+	if cfg.WAL != nil {
+		c.wal, err = openDiskQueue(c.logger, cfg.WAL.Dir, cfg.WAL.MaxSize, cfg.WAL.MaxSegmentSize)
+		if err != nil {
+			return nil, err
+		}
+		c.metrics.walPendingBytes.WithLabelValues(c.cfg.URL.Host).Set(float64(c.wal.Size()))
+
+		c.walCtx, c.walCancel = context.WithCancel(ctx)
+		c.walWg.Add(1)
+		go c.runWAL()
+	}
+
 	c.wg.Add(1)
 	go c.run()
 	return c, nil
@@ -307,8 +359,19 @@ func asSha256(o interface{}) string {
 	return temp[:6]
 }
 
-func (c *client) sendBatch(tenantID string, batch *batch) {
+// encodeBatch encodes batch, and returns the encoded bytes, the number of
+// encoded entries and the content type of the encoded bytes.
+func (c *client) encodeBatch(batch *batch) ([]byte, int, string, error) {
+	if c.useColumnar && batch.structuredMetadata == nil {
+		buf, entriesCount := batch.encodeColumnar()
+		return buf, entriesCount, columnar.ContentType, nil
+	}
 	buf, entriesCount, err := batch.encode()
+	return buf, entriesCount, contentType, err
+}
+
+func (c *client) sendBatch(tenantID string, batch *batch) {
+	buf, entriesCount, ct, err := c.encodeBatch(batch)
 	if err != nil {
 		level.Error(c.logger).Log("msg", "error encoding batch", "error", err)
 		return
@@ -316,16 +379,22 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 	bufBytes := float64(len(buf))
 	c.metrics.encodedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
 
-	backoff := backoff.New(c.ctx, c.cfg.BackoffConfig)
+	if c.wal != nil {
+		c.writeWAL(queueRecord{TenantID: tenantID, Entries: entriesCount, Buf: buf})
+		return
+	}
+
+	backoff := newRetryBackoff(c.ctx, c.cfg.BackoffConfig, c.cfg.BackoffMultiplier, c.cfg.MaxElapsedTime)
 	var status int
 	for {
 		start := time.Now()
 		// send uses `timeout` internally, so `context.Background` is good enough.
-		status, err = c.send(context.Background(), tenantID, buf)
+		status, err = c.send(context.Background(), tenantID, buf, ct)
 
 		c.metrics.requestDuration.WithLabelValues(strconv.Itoa(status), c.cfg.URL.Host).Observe(time.Since(start).Seconds())
 
 		if err == nil {
+			c.setDown(false, nil)
 			c.metrics.sentBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
 			c.metrics.sentEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
 			for _, s := range batch.streams {
@@ -365,8 +434,25 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 			return
 		}
 
-		// Only retry 429s, 500s and connection-level errors.
-		if status > 0 && status != 429 && status/100 != 5 {
+		// Endpoints which don't support the columnar encoding reject it as a
+		// bad request. The batch is sent again as protobuf, which is used for
+		// all further batches.
+		if ct == columnar.ContentType && (status == http.StatusBadRequest || status == http.StatusUnsupportedMediaType) {
+			level.Warn(c.logger).Log("msg", "endpoint rejected batch in the columnar encoding, falling back to protobuf", "status", status, "error", err)
+			c.useColumnar = false
+
+			buf, entriesCount, ct, err = c.encodeBatch(batch)
+			if err != nil {
+				level.Error(c.logger).Log("msg", "error encoding batch", "error", err)
+				return
+			}
+			bufBytes = float64(len(buf))
+			c.metrics.encodedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
+			continue
+		}
+
+		// Only retry retryable status codes and connection-level errors.
+		if status > 0 && !c.isRetryable(status) {
 			break
 		}
 
@@ -384,10 +470,132 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 		level.Error(c.logger).Log("msg", "final error sending batch", "status", status, "error", err)
 		c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
 		c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
+
+		// A batch rejected with a non-retryable status still reached the
+		// endpoint.
+		c.setDown(status == 0 || c.isRetryable(status), err)
+	}
+}
+
+// writeWAL appends rec to the WAL, waiting while the WAL is full. The batch
+// is dropped if it can't be written.
+func (c *client) writeWAL(rec queueRecord) {
+	if err := c.wal.Push(rec, true); err != nil {
+		level.Error(c.logger).Log("msg", "error writing batch to WAL", "error", err)
+		c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host).Add(float64(len(rec.Buf)))
+		c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(rec.Entries))
+		return
 	}
+	c.metrics.walPendingBytes.WithLabelValues(c.cfg.URL.Host).Set(float64(c.wal.Size()))
 }
 
-func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, error) {
+// runWAL sends the batches of the WAL in order. A batch is removed from the
+// WAL once it's delivered or rejected with a non-retryable status.
+func (c *client) runWAL() {
+	defer c.walWg.Done()
+
+	for {
+		rec, ok, err := c.wal.Peek()
+		if !ok {
+			return
+		}
+		if err != nil {
+			level.Error(c.logger).Log("msg", "dropping unreadable batch from WAL", "error", err)
+		} else if !c.sendWALBatch(rec) {
+			return
+		}
+		c.wal.Ack()
+		c.metrics.walPendingBytes.WithLabelValues(c.cfg.URL.Host).Set(float64(c.wal.Size()))
+	}
+}
+
+// sendWALBatch sends a batch of the WAL, retrying until the batch is
+// delivered or rejected with a non-retryable status. Unlike batches which
+// aren't buffered, the batch isn't dropped once the retries run out; the
+// endpoint is reported as down instead, and the retries carry on with the
+// maximum backoff. sendWALBatch returns false if the WAL is stopped before
+// the batch is delivered.
+func (c *client) sendWALBatch(rec queueRecord) bool {
+	bufBytes := float64(len(rec.Buf))
+
+	cfg := c.cfg.BackoffConfig
+	cfg.MaxRetries = 0
+	backoff := newRetryBackoff(c.walCtx, cfg, c.cfg.BackoffMultiplier, 0)
+	start := time.Now()
+	for retries := 0; ; retries++ {
+		sendStart := time.Now()
+		// send uses `timeout` internally, so `context.Background` is good enough.
+		status, err := c.send(context.Background(), rec.TenantID, rec.Buf, contentType)
+
+		c.metrics.requestDuration.WithLabelValues(strconv.Itoa(status), c.cfg.URL.Host).Observe(time.Since(sendStart).Seconds())
+
+		if err == nil {
+			c.setDown(false, nil)
+			c.metrics.sentBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
+			c.metrics.sentEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(rec.Entries))
+			return true
+		}
+
+		if status > 0 && !c.isRetryable(status) {
+			level.Error(c.logger).Log("msg", "final error sending batch", "status", status, "error", err)
+			c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
+			c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(rec.Entries))
+			c.setDown(false, err)
+			return true
+		}
+
+		retriesExhausted := c.cfg.BackoffConfig.MaxRetries > 0 && retries >= c.cfg.BackoffConfig.MaxRetries
+		elapsed := c.cfg.MaxElapsedTime > 0 && time.Since(start) >= c.cfg.MaxElapsedTime
+		if retriesExhausted || elapsed {
+			c.setDown(true, err)
+		}
+
+		level.Warn(c.logger).Log("msg", "error sending batch, will retry", "status", status, "error", err)
+		c.metrics.batchRetries.WithLabelValues(c.cfg.URL.Host).Inc()
+		backoff.Wait()
+		if c.walCtx.Err() != nil {
+			return false
+		}
+	}
+}
+
+// setDown records whether the endpoint is down, publishing an event when it
+// goes down or comes back up. err is the error which made the endpoint go
+// down.
+func (c *client) setDown(down bool, err error) {
+	if c.down == down {
+		return
+	}
+	c.down = down
+
+	e := events.Event{
+		Type:    events.TypeRemoteEndpointUp,
+		Message: "endpoint is reachable again",
+		Fields:  map[string]string{"endpoint": c.cfg.URL.Host},
+	}
+	if down {
+		e.Type = events.TypeRemoteEndpointDown
+		e.Message = fmt.Sprintf("batch could not be delivered to endpoint: %s", err)
+	}
+	c.cfg.Events.Publish(e)
+}
+
+// isRetryable reports whether a response with the given status code should
+// be retried.
+func (c *client) isRetryable(status int) bool {
+	if len(c.cfg.RetryableStatusCodes) == 0 {
+		return status == 429 || status/100 == 5
+	}
+	for _, code := range c.cfg.RetryableStatusCodes {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// send sends buf, which has the content type ct, to the endpoint.
+func (c *client) send(ctx context.Context, tenantID string, buf []byte, ct string) (int, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
 	defer cancel()
 	req, err := http.NewRequest("POST", c.cfg.URL.String(), bytes.NewReader(buf))
@@ -395,7 +603,7 @@ func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, er
 		return -1, err
 	}
 	req = req.WithContext(ctx)
-	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Content-Type", ct)
 	req.Header.Set("User-Agent", UserAgent)
 
 	// If the tenant ID is not empty, the component is running in multi-tenant
@@ -437,10 +645,24 @@ func (c *client) getTenantID(labels model.LabelSet) string {
 	return ""
 }
 
-// Stop the client.
+// Stop the client. Batches which weren't delivered are kept in the WAL if
+// it's enabled.
 func (c *client) Stop() {
 	c.once.Do(func() { close(c.entries) })
+	if c.wal == nil {
+		c.wg.Wait()
+		return
+	}
+
+	// The pending batches are written to the WAL even if it's full, as the
+	// WAL isn't consumed anymore once the client is stopped.
+	c.wal.StopWaiting()
 	c.wg.Wait()
+	c.walOnce.Do(func() {
+		c.walCancel()
+		c.wal.Close()
+		c.walWg.Wait()
+	})
 }
 
 // StopNow stops the client without retries
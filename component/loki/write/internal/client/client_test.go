@@ -4,6 +4,8 @@ package client
 // and run the clients that can send log entries to a Loki instance.
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"math"
 	"net/http"
@@ -15,6 +17,8 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/columnar"
+	"github.com/grafana/agent/pkg/flow/events"
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/loki/pkg/logproto"
@@ -26,6 +30,7 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 )
 
 var logEntries = []loki.Entry{
@@ -47,6 +52,7 @@ func TestClient_Handle(t *testing.T) {
 		clientBatchSize      int
 		clientBatchWait      time.Duration
 		clientMaxRetries     int
+		clientRetryableCodes []int
 		clientTenantID       string
 		serverResponseStatus int
 		inputEntries         []loki.Entry
@@ -155,6 +161,54 @@ func TestClient_Handle(t *testing.T) {
 				loki_write_sent_entries_total{host="__HOST__"} 0
 			`,
 		},
+		"retry send a batch in case the server responds with a retryable 4xx": {
+			clientBatchSize:      10,
+			clientBatchWait:      10 * time.Millisecond,
+			clientMaxRetries:     2,
+			clientRetryableCodes: []int{408},
+			serverResponseStatus: 408,
+			inputEntries:         []loki.Entry{logEntries[0]},
+			expectedReqs: []receivedReq{
+				{
+					tenantID: "",
+					pushReq:  logproto.PushRequest{Streams: []logproto.Stream{{Labels: "{}", Entries: []logproto.Entry{logEntries[0].Entry}}}},
+				},
+				{
+					tenantID: "",
+					pushReq:  logproto.PushRequest{Streams: []logproto.Stream{{Labels: "{}", Entries: []logproto.Entry{logEntries[0].Entry}}}},
+				},
+			},
+			expectedMetrics: `
+				# HELP loki_write_dropped_entries_total Number of log entries dropped because failed to be sent to the ingester after all retries.
+				# TYPE loki_write_dropped_entries_total counter
+				loki_write_dropped_entries_total{host="__HOST__"} 1.0
+				# HELP loki_write_sent_entries_total Number of log entries sent to the ingester.
+				# TYPE loki_write_sent_entries_total counter
+				loki_write_sent_entries_total{host="__HOST__"} 0
+			`,
+		},
+		"do not retry send a batch in case the server responds with a 5xx which isn't retryable": {
+			clientBatchSize:      10,
+			clientBatchWait:      10 * time.Millisecond,
+			clientMaxRetries:     3,
+			clientRetryableCodes: []int{429},
+			serverResponseStatus: 500,
+			inputEntries:         []loki.Entry{logEntries[0]},
+			expectedReqs: []receivedReq{
+				{
+					tenantID: "",
+					pushReq:  logproto.PushRequest{Streams: []logproto.Stream{{Labels: "{}", Entries: []logproto.Entry{logEntries[0].Entry}}}},
+				},
+			},
+			expectedMetrics: `
+				# HELP loki_write_dropped_entries_total Number of log entries dropped because failed to be sent to the ingester after all retries.
+				# TYPE loki_write_dropped_entries_total counter
+				loki_write_dropped_entries_total{host="__HOST__"} 1.0
+				# HELP loki_write_sent_entries_total Number of log entries sent to the ingester.
+				# TYPE loki_write_sent_entries_total counter
+				loki_write_sent_entries_total{host="__HOST__"} 0
+			`,
+		},
 		"do retry sending a batch in case the server responds with a 429": {
 			clientBatchSize:      10,
 			clientBatchWait:      10 * time.Millisecond,
@@ -265,6 +319,8 @@ func TestClient_Handle(t *testing.T) {
 				ExternalLabels: lokiflag.LabelSet{},
 				Timeout:        1 * time.Second,
 				TenantID:       testData.clientTenantID,
+
+				RetryableStatusCodes: testData.clientRetryableCodes,
 			}
 
 			m := NewMetrics(reg, nil)
@@ -446,6 +502,189 @@ func TestClient_StopNow(t *testing.T) {
 	}
 }
 
+func TestClient_EndpointEvents(t *testing.T) {
+	var status atomic.Int64
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+
+	received := make(loki.LogsReceiver, 10)
+	bus := events.New(log.NewNopLogger(), nil)
+	require.NoError(t, bus.Update(events.Options{ForwardTo: []loki.LogsReceiver{received}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go bus.Run(ctx)
+
+	c, err := New(NewMetrics(prometheus.NewRegistry(), nil), Config{
+		URL:           serverURL,
+		BatchWait:     10 * time.Millisecond,
+		BatchSize:     10,
+		BackoffConfig: backoff.Config{MinBackoff: 1 * time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 1},
+		Timeout:       1 * time.Second,
+		Events:        bus,
+	}, nil, 0, log.NewNopLogger())
+	require.NoError(t, err)
+	defer c.Stop()
+
+	nextEventType := func() model.LabelValue {
+		select {
+		case e := <-received:
+			return e.Labels["event_type"]
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no event was published")
+			return ""
+		}
+	}
+
+	c.Chan() <- logEntries[0]
+	require.Equal(t, model.LabelValue(events.TypeRemoteEndpointDown), nextEventType())
+
+	status.Store(http.StatusNoContent)
+	c.Chan() <- logEntries[1]
+	require.Equal(t, model.LabelValue(events.TypeRemoteEndpointUp), nextEventType())
+}
+
+func TestClient_WAL(t *testing.T) {
+	var status atomic.Int64
+	status.Store(http.StatusServiceUnavailable)
+	receivedReqsChan := make(chan receivedReq, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if status.Load() != http.StatusNoContent {
+			rw.WriteHeader(int(status.Load()))
+			return
+		}
+		createServerHandler(receivedReqsChan, http.StatusNoContent)(rw, req)
+	}))
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+
+	reg := prometheus.NewRegistry()
+	cfg := Config{
+		URL:           serverURL,
+		BatchWait:     10 * time.Millisecond,
+		BatchSize:     10,
+		BackoffConfig: backoff.Config{MinBackoff: 1 * time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 1},
+		Timeout:       1 * time.Second,
+		WAL:           &WALConfig{Dir: t.TempDir(), MaxSize: 1024 * 1024, MaxSegmentSize: 1024},
+	}
+
+	// The batch stays in the WAL while the endpoint is down, even once the
+	// retries run out.
+	c, err := New(NewMetrics(reg, nil), cfg, nil, 0, log.NewNopLogger())
+	require.NoError(t, err)
+	c.Chan() <- logEntries[0]
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(c.(*client).metrics.batchRetries.WithLabelValues(serverURL.Host)) > 2
+	}, 5*time.Second, 10*time.Millisecond)
+	c.Stop()
+
+	expectedMetrics := fmt.Sprintf(`
+		# HELP loki_write_dropped_entries_total Number of log entries dropped because failed to be sent to the ingester after all retries.
+		# TYPE loki_write_dropped_entries_total counter
+		loki_write_dropped_entries_total{host=%q} 0
+	`, serverURL.Host)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "loki_write_dropped_entries_total"))
+
+	// The batch is replayed by the next client once the endpoint is up.
+	status.Store(http.StatusNoContent)
+	c, err = New(NewMetrics(reg, nil), cfg, nil, 0, log.NewNopLogger())
+	require.NoError(t, err)
+	defer c.Stop()
+
+	select {
+	case req := <-receivedReqsChan:
+		require.Equal(t, logproto.PushRequest{Streams: []logproto.Stream{{Labels: "{}", Entries: []logproto.Entry{logEntries[0].Entry}}}}, req.pushReq)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the batch in the WAL was not sent")
+	}
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(c.(*client).metrics.walPendingBytes.WithLabelValues(serverURL.Host)) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestClient_Columnar(t *testing.T) {
+	for _, supported := range []bool{true, false} {
+		t.Run(fmt.Sprintf("supported=%t", supported), func(t *testing.T) {
+			contentTypes := make(chan string, 10)
+			receivedReqsChan := make(chan receivedReq, 10)
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				ct := req.Header.Get("Content-Type")
+				contentTypes <- ct
+				if ct != columnar.ContentType {
+					createServerHandler(receivedReqsChan, http.StatusNoContent)(rw, req)
+					return
+				}
+				if !supported {
+					// Older versions of loki.source.api fail to parse the
+					// request as protobuf.
+					rw.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				buf, _ := io.ReadAll(req.Body)
+				pushReq, err := columnar.Decode(buf)
+				if err != nil {
+					rw.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				receivedReqsChan <- receivedReq{pushReq: *pushReq}
+				rw.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			serverURL := flagext.URLValue{}
+			require.NoError(t, serverURL.Set(server.URL))
+
+			reg := prometheus.NewRegistry()
+			c, err := New(NewMetrics(reg, nil), Config{
+				URL:           serverURL,
+				BatchWait:     10 * time.Millisecond,
+				BatchSize:     10,
+				BackoffConfig: backoff.Config{MinBackoff: 1 * time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 1},
+				Timeout:       1 * time.Second,
+				Encoding:      EncodingColumnar,
+			}, nil, 0, log.NewNopLogger())
+			require.NoError(t, err)
+
+			expectedContentTypes := []string{columnar.ContentType, columnar.ContentType}
+			if !supported {
+				// The first batch is sent again as protobuf, which is used for
+				// the following batches.
+				expectedContentTypes = []string{columnar.ContentType, contentType, contentType}
+			}
+
+			for _, e := range logEntries[:2] {
+				c.Chan() <- e
+				select {
+				case req := <-receivedReqsChan:
+					require.Len(t, req.pushReq.Streams, 1)
+					require.Len(t, req.pushReq.Streams[0].Entries, 1)
+					require.Equal(t, e.Line, req.pushReq.Streams[0].Entries[0].Line)
+					require.True(t, e.Timestamp.Equal(req.pushReq.Streams[0].Entries[0].Timestamp))
+				case <-time.After(5 * time.Second):
+					require.FailNow(t, "the batch was not sent")
+				}
+			}
+			c.Stop()
+			close(contentTypes)
+
+			var actualContentTypes []string
+			for ct := range contentTypes {
+				actualContentTypes = append(actualContentTypes, ct)
+			}
+			require.Equal(t, expectedContentTypes, actualContentTypes)
+			require.Zero(t, testutil.ToFloat64(c.(*client).metrics.droppedEntries.WithLabelValues(serverURL.Host)))
+		})
+	}
+}
+
 func createServerHandler(receivedReqsChan chan receivedReq, status int) http.HandlerFunc {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		// Parse the request
@@ -7,6 +7,8 @@ import (
 	"flag"
 	"time"
 
+	"github.com/grafana/agent/component/common/resolver"
+	"github.com/grafana/agent/pkg/flow/events"
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/common/config"
@@ -34,6 +36,17 @@ type Config struct {
 	Client config.HTTPClientConfig `yaml:",inline"`
 
 	BackoffConfig backoff.Config `yaml:"backoff_config"`
+
+	// BackoffMultiplier is the factor the backoff grows by after every retry.
+	// Defaults to DefaultBackoffMultiplier.
+	BackoffMultiplier float64 `yaml:"-"`
+	// MaxElapsedTime limits the time spent retrying a batch. Zero means no
+	// limit.
+	MaxElapsedTime time.Duration `yaml:"-"`
+	// RetryableStatusCodes are the status codes of responses which are
+	// retried. If empty, 429 and 5xx responses are retried.
+	RetryableStatusCodes []int `yaml:"-"`
+
 	// The labels to add to any time series or alerts when communicating with loki
 	ExternalLabels lokiflag.LabelSet `yaml:"external_labels,omitempty"`
 	Timeout        time.Duration     `yaml:"timeout"`
@@ -44,6 +57,46 @@ type Config struct {
 
 	// deprecated use StreamLagLabels from config.Config instead
 	StreamLagLabels flagext.StringSliceCSV `yaml:"stream_lag_labels"`
+
+	// DNS optionally overrides how the client resolves the host of URL.
+	DNS *resolver.Arguments `yaml:"-"`
+
+	// HTTPClientOptions are the options the client is created with before
+	// its own. Defaults to the options of the HTTP client defaults.
+	HTTPClientOptions []config.HTTPClientOption `yaml:"-"`
+
+	// Events is where the client publishes the endpoint going down or coming
+	// back up. Events may be nil.
+	Events *events.Bus `yaml:"-"`
+
+	// WAL optionally buffers batches on disk until they're delivered.
+	WAL *WALConfig `yaml:"-"`
+
+	// Encoding is the encoding of the batches sent to the endpoint. Defaults
+	// to EncodingProtobuf.
+	Encoding string `yaml:"-"`
+}
+
+// Encodings of the batches sent to the endpoint.
+const (
+	// EncodingProtobuf is the protobuf encoding of the Loki push API.
+	EncodingProtobuf = "protobuf"
+	// EncodingColumnar is the experimental columnar encoding understood by
+	// loki.source.api. It's only used while the WAL is disabled, and for
+	// batches without structured metadata.
+	EncodingColumnar = "columnar"
+)
+
+// WALConfig configures the on-disk queue of a client.
+type WALConfig struct {
+	// Dir is the directory holding the segments of the queue.
+	Dir string
+	// MaxSize is the size the queue may grow to before the client stops
+	// accepting entries.
+	MaxSize int64
+	// MaxSegmentSize is the size a segment may grow to before a new segment
+	// is started.
+	MaxSegmentSize int64
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
@@ -0,0 +1,399 @@
+package client
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// queueHeaderSize is the size of the header preceding each record in a
+// segment: the length of the payload followed by its CRC32 checksum.
+const queueHeaderSize = 8
+
+var (
+	errQueueClosed = errors.New("queue is closed")
+
+	queueCRCTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// queueRecord is an encoded batch stored in a diskQueue.
+type queueRecord struct {
+	TenantID string
+	Entries  int
+	Buf      []byte
+}
+
+// queueSegment is a file of a diskQueue holding consecutive records.
+type queueSegment struct {
+	index    int
+	file     *os.File
+	size     int64 // Number of bytes written to the file.
+	unacked  int   // Number of records which weren't acknowledged.
+	finished bool  // No more records are written to the segment.
+}
+
+// queueRef locates a record in a segment.
+type queueRef struct {
+	segment *queueSegment
+	offset  int64
+	size    int64
+}
+
+// diskQueue is a FIFO queue of encoded batches persisted in segment files, so
+// that batches which weren't delivered survive restarts. Records are appended
+// to the last segment, which is rotated once it reaches the maximum segment
+// size. Segments are deleted once all of their records are acknowledged.
+type diskQueue struct {
+	log            log.Logger
+	dir            string
+	maxSize        int64
+	maxSegmentSize int64
+
+	mut      sync.Mutex
+	cond     *sync.Cond // Broadcast when the queue changes.
+	closed   bool
+	noWait   bool // Push doesn't wait for space in the queue.
+	segments []*queueSegment
+	pending  []queueRef
+	size     int64 // Size of the records which weren't acknowledged.
+}
+
+// openDiskQueue opens the queue stored in dir, creating dir if it doesn't
+// exist. Records left in dir by a previous queue are replayed first. Push
+// waits while the records which weren't acknowledged exceed maxSize.
+func openDiskQueue(logger log.Logger, dir string, maxSize, maxSegmentSize int64) (*diskQueue, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating queue directory: %w", err)
+	}
+
+	q := &diskQueue{
+		log:            logger,
+		dir:            dir,
+		maxSize:        maxSize,
+		maxSegmentSize: maxSegmentSize,
+	}
+	q.cond = sync.NewCond(&q.mut)
+
+	indices, err := q.segmentIndices()
+	if err != nil {
+		return nil, err
+	}
+	next := 0
+	for _, index := range indices {
+		next = index + 1
+		if err := q.loadSegment(index); err != nil {
+			q.closeFiles()
+			return nil, err
+		}
+	}
+
+	// Records are never appended to segments of a previous queue, as their
+	// last record may have been partially written.
+	if err := q.createSegment(next); err != nil {
+		q.closeFiles()
+		return nil, err
+	}
+	return q, nil
+}
+
+// segmentIndices returns the indices of the segments in the queue directory
+// in ascending order.
+func (q *diskQueue) segmentIndices() ([]int, error) {
+	dirents, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading queue directory: %w", err)
+	}
+
+	var indices []int
+	for _, dirent := range dirents {
+		if dirent.IsDir() {
+			continue
+		}
+		index, err := strconv.Atoi(dirent.Name())
+		if err != nil {
+			continue
+		}
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	return indices, nil
+}
+
+func (q *diskQueue) segmentPath(index int) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%08d", index))
+}
+
+// loadSegment adds the records of an existing segment to the queue. A
+// segment is truncated at the first record which can't be read, and removed
+// if it doesn't hold any record.
+func (q *diskQueue) loadSegment(index int) error {
+	path := q.segmentPath(index)
+	f, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("opening queue segment: %w", err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("opening queue segment: %w", err)
+	}
+
+	seg := &queueSegment{index: index, file: f, finished: true}
+	var refs []queueRef
+	for {
+		size, err := readRecordSize(f, seg.size, fi.Size())
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			level.Warn(q.log).Log("msg", "truncating corrupted queue segment", "segment", path, "offset", seg.size, "error", err)
+			if err := f.Truncate(seg.size); err != nil {
+				_ = f.Close()
+				return fmt.Errorf("truncating queue segment: %w", err)
+			}
+			break
+		}
+		refs = append(refs, queueRef{segment: seg, offset: seg.size, size: size})
+		seg.size += size
+	}
+
+	if len(refs) == 0 {
+		_ = f.Close()
+		return os.Remove(path)
+	}
+
+	seg.unacked = len(refs)
+	q.segments = append(q.segments, seg)
+	q.pending = append(q.pending, refs...)
+	for _, ref := range refs {
+		q.size += ref.size
+	}
+	return nil
+}
+
+// createSegment creates the segment which records are appended to.
+func (q *diskQueue) createSegment(index int) error {
+	f, err := os.OpenFile(q.segmentPath(index), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("creating queue segment: %w", err)
+	}
+	q.segments = append(q.segments, &queueSegment{index: index, file: f})
+	return nil
+}
+
+// Push appends rec to the queue. If wait is true, Push waits until the
+// queue has room for rec. The queue always accepts a record if it's empty,
+// even if the record exceeds the maximum size of the queue.
+func (q *diskQueue) Push(rec queueRecord, wait bool) error {
+	data := encodeQueueRecord(rec)
+	size := int64(len(data))
+
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	for wait && !q.noWait && !q.closed && q.size > 0 && q.size+size > q.maxSize {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return errQueueClosed
+	}
+
+	seg := q.segments[len(q.segments)-1]
+	if seg.size > 0 && seg.size+size > q.maxSegmentSize {
+		if err := q.createSegment(seg.index + 1); err != nil {
+			return err
+		}
+		seg.finished = true
+		if seg.unacked == 0 {
+			q.removeSegment(seg)
+		}
+		seg = q.segments[len(q.segments)-1]
+	}
+
+	if _, err := seg.file.WriteAt(data, seg.size); err != nil {
+		return fmt.Errorf("writing to queue segment: %w", err)
+	}
+	if err := seg.file.Sync(); err != nil {
+		return fmt.Errorf("syncing queue segment: %w", err)
+	}
+
+	q.pending = append(q.pending, queueRef{segment: seg, offset: seg.size, size: size})
+	seg.size += size
+	seg.unacked++
+	q.size += size
+	q.cond.Broadcast()
+	return nil
+}
+
+// Peek waits for the queue to hold a record, and returns the oldest record
+// without removing it. ok is false once the queue is closed.
+func (q *diskQueue) Peek() (rec queueRecord, ok bool, err error) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	for !q.closed && len(q.pending) == 0 {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return queueRecord{}, false, nil
+	}
+
+	ref := q.pending[0]
+	data := make([]byte, ref.size)
+	if _, err := ref.segment.file.ReadAt(data, ref.offset); err != nil {
+		return queueRecord{}, true, fmt.Errorf("reading queue segment: %w", err)
+	}
+	rec, err = decodeQueueRecord(data)
+	return rec, true, err
+}
+
+// Ack removes the oldest record from the queue. The segment holding the
+// record is deleted if all of its records are acknowledged.
+func (q *diskQueue) Ack() {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if len(q.pending) == 0 {
+		return
+	}
+	ref := q.pending[0]
+	q.pending = q.pending[1:]
+	q.size -= ref.size
+
+	ref.segment.unacked--
+	if ref.segment.unacked == 0 && ref.segment.finished {
+		q.removeSegment(ref.segment)
+	}
+	q.cond.Broadcast()
+}
+
+// removeSegment closes and deletes seg. q.mut must be held.
+func (q *diskQueue) removeSegment(seg *queueSegment) {
+	for i, s := range q.segments {
+		if s == seg {
+			q.segments = append(q.segments[:i], q.segments[i+1:]...)
+			break
+		}
+	}
+	_ = seg.file.Close()
+	if err := os.Remove(seg.file.Name()); err != nil && !os.IsNotExist(err) {
+		level.Warn(q.log).Log("msg", "failed to remove queue segment", "segment", seg.file.Name(), "err", err)
+	}
+}
+
+// Size returns the size of the records in the queue.
+func (q *diskQueue) Size() int64 {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	return q.size
+}
+
+// StopWaiting makes Push stop waiting for room in the queue, so that records
+// can be pushed while the queue isn't consumed anymore.
+func (q *diskQueue) StopWaiting() {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	q.noWait = true
+	q.cond.Broadcast()
+}
+
+// Close closes the queue, waking up any waiting calls. Records which weren't
+// acknowledged are kept on disk for the next queue opened in the same
+// directory.
+func (q *diskQueue) Close() {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.closeFiles()
+	q.cond.Broadcast()
+}
+
+func (q *diskQueue) closeFiles() {
+	for _, seg := range q.segments {
+		_ = seg.file.Close()
+	}
+}
+
+// encodeQueueRecord encodes rec with its header. The payload holds the tenant
+// ID and the number of entries as uvarints-prefixed fields, followed by the
+// encoded batch.
+func encodeQueueRecord(rec queueRecord) []byte {
+	payload := make([]byte, 0, 2*binary.MaxVarintLen64+len(rec.TenantID)+len(rec.Buf))
+	payload = binary.AppendUvarint(payload, uint64(len(rec.TenantID)))
+	payload = append(payload, rec.TenantID...)
+	payload = binary.AppendUvarint(payload, uint64(rec.Entries))
+	payload = append(payload, rec.Buf...)
+
+	data := make([]byte, queueHeaderSize, queueHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(data[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(data[4:8], crc32.Checksum(payload, queueCRCTable))
+	return append(data, payload...)
+}
+
+// decodeQueueRecord decodes a record encoded by encodeQueueRecord.
+func decodeQueueRecord(data []byte) (queueRecord, error) {
+	if len(data) < queueHeaderSize {
+		return queueRecord{}, fmt.Errorf("queue record is too short")
+	}
+	payload := data[queueHeaderSize:]
+	if int(binary.BigEndian.Uint32(data[0:4])) != len(payload) {
+		return queueRecord{}, fmt.Errorf("queue record has an invalid length")
+	}
+	if binary.BigEndian.Uint32(data[4:8]) != crc32.Checksum(payload, queueCRCTable) {
+		return queueRecord{}, fmt.Errorf("queue record has an invalid checksum")
+	}
+
+	tenantLen, n := binary.Uvarint(payload)
+	if n <= 0 || uint64(len(payload)-n) < tenantLen {
+		return queueRecord{}, fmt.Errorf("queue record has an invalid tenant ID")
+	}
+	payload = payload[n:]
+	rec := queueRecord{TenantID: string(payload[:tenantLen])}
+	payload = payload[tenantLen:]
+
+	entries, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return queueRecord{}, fmt.Errorf("queue record has an invalid number of entries")
+	}
+	rec.Entries = int(entries)
+	rec.Buf = payload[n:]
+	return rec, nil
+}
+
+// readRecordSize validates the record of f at offset and returns its size,
+// including its header. io.EOF is returned if there's no record at offset.
+func readRecordSize(f *os.File, offset, fileSize int64) (int64, error) {
+	header := make([]byte, queueHeaderSize)
+	n, err := f.ReadAt(header, offset)
+	if n == 0 && errors.Is(err, io.EOF) {
+		return 0, io.EOF
+	} else if n < queueHeaderSize {
+		return 0, fmt.Errorf("queue record header is truncated")
+	}
+
+	size := queueHeaderSize + int64(binary.BigEndian.Uint32(header[0:4]))
+	if offset+size > fileSize {
+		return 0, fmt.Errorf("queue record is truncated")
+	}
+	data := make([]byte, size)
+	if _, err := f.ReadAt(data, offset); err != nil {
+		return 0, fmt.Errorf("queue record is truncated")
+	}
+	if _, err := decodeQueueRecord(data); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
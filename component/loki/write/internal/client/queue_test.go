@@ -0,0 +1,122 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskQueue(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := openDiskQueue(log.NewNopLogger(), dir, 1024, 32)
+	require.NoError(t, err)
+
+	recs := []queueRecord{
+		{TenantID: "", Entries: 1, Buf: []byte("batch 1")},
+		{TenantID: "tenant-1", Entries: 2, Buf: []byte("batch 2")},
+		{TenantID: "tenant-2", Entries: 3, Buf: []byte("batch 3")},
+	}
+	for _, rec := range recs {
+		require.NoError(t, q.Push(rec, true))
+	}
+
+	// Every record exceeds half of the segment size, so each one is written
+	// to its own segment.
+	requireSegments(t, dir, "00000000", "00000001", "00000002")
+
+	rec, ok, err := q.Peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, recs[0], rec)
+	q.Ack()
+
+	// The segment of the acknowledged record is deleted.
+	requireSegments(t, dir, "00000001", "00000002")
+	q.Close()
+
+	// Records which weren't acknowledged are replayed by the next queue.
+	q, err = openDiskQueue(log.NewNopLogger(), dir, 1024, 32)
+	require.NoError(t, err)
+	defer q.Close()
+
+	for _, want := range recs[1:] {
+		rec, ok, err := q.Peek()
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, want, rec)
+		q.Ack()
+	}
+	require.Zero(t, q.Size())
+	requireSegments(t, dir, "00000003")
+}
+
+func TestDiskQueue_TruncatedSegment(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := openDiskQueue(log.NewNopLogger(), dir, 1024, 1024)
+	require.NoError(t, err)
+	require.NoError(t, q.Push(queueRecord{Entries: 1, Buf: []byte("batch 1")}, true))
+	require.NoError(t, q.Push(queueRecord{Entries: 1, Buf: []byte("batch 2")}, true))
+	q.Close()
+
+	// Simulate a record which was partially written.
+	path := filepath.Join(dir, "00000000")
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, fi.Size()-3))
+
+	q, err = openDiskQueue(log.NewNopLogger(), dir, 1024, 1024)
+	require.NoError(t, err)
+	defer q.Close()
+
+	rec, ok, err := q.Peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("batch 1"), rec.Buf)
+	q.Ack()
+	require.Zero(t, q.Size())
+}
+
+func TestDiskQueue_Backpressure(t *testing.T) {
+	q, err := openDiskQueue(log.NewNopLogger(), t.TempDir(), 32, 32)
+	require.NoError(t, err)
+	defer q.Close()
+
+	// The first record is accepted even though it exceeds the maximum size.
+	require.NoError(t, q.Push(queueRecord{Buf: make([]byte, 40)}, true))
+
+	pushed := make(chan error)
+	go func() {
+		pushed <- q.Push(queueRecord{Buf: make([]byte, 10)}, true)
+	}()
+
+	select {
+	case <-pushed:
+		require.FailNow(t, "push should wait while the queue is full")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	q.Ack()
+	require.NoError(t, <-pushed)
+
+	// Records can be pushed to a full queue without waiting.
+	require.NoError(t, q.Push(queueRecord{Buf: make([]byte, 40)}, false))
+}
+
+func requireSegments(t *testing.T, dir string, expect ...string) {
+	t.Helper()
+
+	dirents, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, dirent := range dirents {
+		names = append(names, dirent.Name())
+	}
+	require.Equal(t, expect, names)
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"sort"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the push request messages. The vendored push request
+// types predate structured metadata, so requests carrying it are encoded by
+// hand, as if EntryAdapter had the field:
+//
+//	repeated LabelPairAdapter structuredMetadata = 3;
+//
+// where LabelPairAdapter has the fields `string name = 1` and
+// `string value = 2`. Loki versions which don't support structured metadata
+// ignore the field.
+const (
+	fieldPushRequestStreams protowire.Number = 1
+
+	fieldStreamLabels  protowire.Number = 1
+	fieldStreamEntries protowire.Number = 2
+	fieldStreamHash    protowire.Number = 3
+
+	fieldEntryStructuredMetadata protowire.Number = 3
+
+	fieldLabelPairName  protowire.Number = 1
+	fieldLabelPairValue protowire.Number = 2
+)
+
+// encodePushRequest encodes req the same way proto.Marshal does, adding the
+// structured metadata of the entries of each stream. metadata is keyed by
+// the labels of the streams and is aligned with their entries.
+func encodePushRequest(req *logproto.PushRequest, metadata map[string][]model.LabelSet) ([]byte, error) {
+	var buf []byte
+	for _, stream := range req.Streams {
+		s, err := encodeStream(stream, metadata[stream.Labels])
+		if err != nil {
+			return nil, err
+		}
+		buf = protowire.AppendTag(buf, fieldPushRequestStreams, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, s)
+	}
+	return buf, nil
+}
+
+func encodeStream(stream logproto.Stream, metadata []model.LabelSet) ([]byte, error) {
+	var buf []byte
+	if len(stream.Labels) > 0 {
+		buf = protowire.AppendTag(buf, fieldStreamLabels, protowire.BytesType)
+		buf = protowire.AppendString(buf, stream.Labels)
+	}
+	for i := range stream.Entries {
+		entry, err := stream.Entries[i].Marshal()
+		if err != nil {
+			return nil, err
+		}
+		if i < len(metadata) {
+			entry = appendStructuredMetadata(entry, metadata[i])
+		}
+		buf = protowire.AppendTag(buf, fieldStreamEntries, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, entry)
+	}
+	if stream.Hash != 0 {
+		buf = protowire.AppendTag(buf, fieldStreamHash, protowire.VarintType)
+		buf = protowire.AppendVarint(buf, stream.Hash)
+	}
+	return buf, nil
+}
+
+// appendStructuredMetadata appends md to the encoded entry buf, sorted by
+// name.
+func appendStructuredMetadata(buf []byte, md model.LabelSet) []byte {
+	names := make([]string, 0, len(md))
+	for name := range md {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var pair []byte
+		pair = protowire.AppendTag(pair, fieldLabelPairName, protowire.BytesType)
+		pair = protowire.AppendString(pair, name)
+		pair = protowire.AppendTag(pair, fieldLabelPairValue, protowire.BytesType)
+		pair = protowire.AppendString(pair, string(md[model.LabelName(name)]))
+
+		buf = protowire.AppendTag(buf, fieldEntryStructuredMetadata, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, pair)
+	}
+	return buf
+}
+
+// structuredMetadataSize returns the number of bytes md adds to a batch.
+func structuredMetadataSize(md model.LabelSet) int {
+	var size int
+	for name, value := range md {
+		size += len(name) + len(value)
+	}
+	return size
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestEncodePushRequest_WithoutStructuredMetadata(t *testing.T) {
+	b := newBatch(0,
+		loki.Entry{Labels: model.LabelSet{"app": "a"}, Entry: logproto.Entry{Timestamp: time.Unix(1, 5), Line: "line1"}},
+		loki.Entry{Labels: model.LabelSet{"app": "a"}, Entry: logproto.Entry{Timestamp: time.Unix(0, 0), Line: ""}},
+		loki.Entry{Labels: model.LabelSet{"app": "b"}, Entry: logproto.Entry{Timestamp: time.Unix(3, 0), Line: "line3"}},
+	)
+	req, _ := b.createPushRequest()
+	req.Streams[0].Hash = 1234
+
+	expect, err := proto.Marshal(req)
+	require.NoError(t, err)
+	actual, err := encodePushRequest(req, nil)
+	require.NoError(t, err)
+	require.Equal(t, expect, actual)
+}
+
+func TestEncodePushRequest_WithStructuredMetadata(t *testing.T) {
+	b := newBatch(0,
+		loki.Entry{Labels: model.LabelSet{"app": "a"}, Entry: logproto.Entry{Timestamp: time.Unix(1, 0), Line: "line1"}},
+		loki.Entry{Labels: model.LabelSet{"app": "a"}, Entry: logproto.Entry{Timestamp: time.Unix(2, 0), Line: "line2"}, StructuredMetadata: model.LabelSet{"trace_id": "1234", "pod": "p"}},
+		loki.Entry{Labels: model.LabelSet{"app": "b"}, Entry: logproto.Entry{Timestamp: time.Unix(3, 0), Line: "line3"}},
+	)
+	require.Equal(t, len("line1line2line3trace_id1234podp"), b.sizeBytes())
+	require.Equal(t, map[string][]model.LabelSet{
+		`{app="a"}`: {nil, {"trace_id": "1234", "pod": "p"}},
+	}, b.structuredMetadata)
+
+	req, _ := b.createPushRequest()
+	buf, err := encodePushRequest(req, b.structuredMetadata)
+	require.NoError(t, err)
+
+	// Loki versions without support for structured metadata must still be
+	// able to decode the request.
+	var decoded logproto.PushRequest
+	require.NoError(t, proto.Unmarshal(buf, &decoded))
+	require.Len(t, decoded.Streams, 2)
+
+	var metadata [][]string
+	consumeFields(t, buf, func(num protowire.Number, stream []byte) {
+		consumeFields(t, stream, func(num protowire.Number, entry []byte) {
+			if num != fieldStreamEntries {
+				return
+			}
+			var pairs []string
+			consumeFields(t, entry, func(num protowire.Number, pair []byte) {
+				if num != fieldEntryStructuredMetadata {
+					return
+				}
+				consumeFields(t, pair, func(_ protowire.Number, v []byte) {
+					pairs = append(pairs, string(v))
+				})
+			})
+			metadata = append(metadata, pairs)
+		})
+	})
+	require.ElementsMatch(t, [][]string{nil, {"pod", "p", "trace_id", "1234"}, nil}, metadata)
+}
+
+// consumeFields calls f for every length-delimited field of buf.
+func consumeFields(t *testing.T, buf []byte, f func(num protowire.Number, v []byte)) {
+	t.Helper()
+	for len(buf) > 0 {
+		num, typ, n := protowire.ConsumeTag(buf)
+		require.GreaterOrEqual(t, n, 0)
+		buf = buf[n:]
+
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, buf)
+			require.GreaterOrEqual(t, n, 0)
+			buf = buf[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(buf)
+		require.GreaterOrEqual(t, n, 0)
+		buf = buf[n:]
+		f(num, v)
+	}
+}
@@ -1,12 +1,15 @@
 package write
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"net/url"
+	"path/filepath"
 	"time"
 
 	"github.com/alecthomas/units"
 	types "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/resolver"
 	"github.com/grafana/agent/component/loki/write/internal/client"
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/flagext"
@@ -25,6 +28,9 @@ type EndpointOptions struct {
 	MaxBackoff        time.Duration           `river:"max_backoff_period,attr,optional"`  // increase exponentially to this level
 	MaxBackoffRetries int                     `river:"max_backoff_retries,attr,optional"` // give up after this many; zero means infinite retries
 	TenantID          string                  `river:"tenant_id,attr,optional"`
+	Encoding          string                  `river:"encoding,attr,optional"`
+	Retry             *types.RetryConfig      `river:"retry,block,optional"`
+	DNS               *resolver.Arguments     `river:"dns,block,optional"`
 	HTTPClientConfig  *types.HTTPClientConfig `river:",squash"`
 }
 
@@ -41,6 +47,7 @@ func GetDefaultEndpointOptions() EndpointOptions {
 		MinBackoff:        500 * time.Millisecond,
 		MaxBackoff:        5 * time.Minute,
 		MaxBackoffRetries: 10,
+		Encoding:          client.EncodingProtobuf,
 		HTTPClientConfig:  types.CloneDefaultHTTPClientConfig(),
 	}
 
@@ -60,6 +67,12 @@ func (r *EndpointOptions) UnmarshalRiver(f func(v interface{}) error) error {
 		return fmt.Errorf("failed to parse remote url %q: %w", r.URL, err)
 	}
 
+	switch r.Encoding {
+	case client.EncodingProtobuf, client.EncodingColumnar:
+	default:
+		return fmt.Errorf("unknown encoding %q, must be %q or %q", r.Encoding, client.EncodingProtobuf, client.EncodingColumnar)
+	}
+
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	if r.HTTPClientConfig != nil {
 		return r.HTTPClientConfig.Validate()
@@ -68,6 +81,51 @@ func (r *EndpointOptions) UnmarshalRiver(f func(v interface{}) error) error {
 	return nil
 }
 
+// DefaultWALOptions holds the default settings of the WAL.
+var DefaultWALOptions = WALOptions{
+	MaxSize:        1 * units.GiB,
+	MaxSegmentSize: 64 * units.MiB,
+}
+
+// WALOptions configures the write-ahead log which buffers batches on disk
+// until they're delivered.
+type WALOptions struct {
+	Enabled        bool             `river:"enabled,attr,optional"`
+	MaxSize        units.Base2Bytes `river:"max_size,attr,optional"`
+	MaxSegmentSize units.Base2Bytes `river:"max_segment_size,attr,optional"`
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (o *WALOptions) UnmarshalRiver(f func(v interface{}) error) error {
+	*o = DefaultWALOptions
+
+	type options WALOptions
+	if err := f((*options)(o)); err != nil {
+		return err
+	}
+
+	switch {
+	case o.MaxSize <= 0:
+		return fmt.Errorf("max_size must be greater than 0")
+	case o.MaxSegmentSize <= 0:
+		return fmt.Errorf("max_segment_size must be greater than 0")
+	case o.MaxSegmentSize > o.MaxSize:
+		return fmt.Errorf("max_segment_size must not be greater than max_size")
+	}
+	return nil
+}
+
+// walDir returns the directory of the WAL of an endpoint within dataPath.
+// The directory only depends on the name of the endpoint, or its URL if it
+// has no name, so that the WAL is kept when other settings change.
+func walDir(dataPath string, cfg client.Config) string {
+	key := cfg.Name
+	if key == "" {
+		key = cfg.URL.String()
+	}
+	return filepath.Join(dataPath, "wal", fmt.Sprintf("%x", sha256.Sum256([]byte(key)))[:16])
+}
+
 func (args Arguments) convertClientConfigs() []client.Config {
 	var res []client.Config
 	for _, cfg := range args.Endpoints {
@@ -86,6 +144,23 @@ func (args Arguments) convertClientConfigs() []client.Config {
 			ExternalLabels: lokiflagext.LabelSet{LabelSet: toLabelSet(args.ExternalLabels)},
 			Timeout:        cfg.RemoteTimeout,
 			TenantID:       cfg.TenantID,
+			DNS:            cfg.DNS,
+			Encoding:       cfg.Encoding,
+
+			HTTPClientOptions: cfg.HTTPClientConfig.ClientOptions(),
+		}
+		if r := cfg.Retry; r != nil {
+			// Settings of the retry block take precedence over the backoff
+			// attributes of the endpoint.
+			if r.InitialInterval > 0 {
+				cc.BackoffConfig.MinBackoff = r.InitialInterval
+			}
+			if r.MaxInterval > 0 {
+				cc.BackoffConfig.MaxBackoff = r.MaxInterval
+			}
+			cc.BackoffMultiplier = r.Multiplier
+			cc.MaxElapsedTime = r.MaxElapsedTime
+			cc.RetryableStatusCodes = r.RetryableStatusCodes
 		}
 		res = append(res, cc)
 	}
@@ -32,6 +32,7 @@ type Arguments struct {
 	Endpoints      []EndpointOptions `river:"endpoint,block,optional"`
 	ExternalLabels map[string]string `river:"external_labels,attr,optional"`
 	MaxStreams     int               `river:"max_streams,attr,optional"`
+	WAL            WALOptions        `river:"wal,block,optional"`
 }
 
 // Exports holds the receiver that is used to send log entries to the
@@ -41,7 +42,8 @@ type Exports struct {
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component            = (*Component)(nil)
+	_ component.DestinationComponent = (*Component)(nil)
 )
 
 // Component implements the loki.write component.
@@ -112,10 +114,28 @@ func (c *Component) Update(args component.Arguments) error {
 	c.clients = make([]client.Client, len(newArgs.Endpoints))
 
 	cfgs := newArgs.convertClientConfigs()
+	if newArgs.WAL.Enabled {
+		walDirs := make(map[string]string, len(cfgs))
+		for i := range cfgs {
+			dir := walDir(c.opts.DataPath, cfgs[i])
+			if other, ok := walDirs[dir]; ok {
+				return fmt.Errorf("endpoints %s and %s would share a WAL; set a different name for each endpoint", other, cfgs[i].URL.String())
+			}
+			walDirs[dir] = cfgs[i].URL.String()
+
+			cfgs[i].WAL = &client.WALConfig{
+				Dir:            dir,
+				MaxSize:        int64(newArgs.WAL.MaxSize),
+				MaxSegmentSize: int64(newArgs.WAL.MaxSegmentSize),
+			}
+		}
+	}
+
 	// TODO (@tpaschalis) We could use a client.NewMulti here to push the
 	// fanout logic back to the client layer, but I opted to keep it explicit
 	// here a) for easier debugging and b) possible improvements in the future.
 	for _, cfg := range cfgs {
+		cfg.Events = c.opts.Events
 		client, err := client.New(c.metrics, cfg, streamLagLabels, newArgs.MaxStreams, c.opts.Logger)
 		if err != nil {
 			return err
@@ -125,3 +145,15 @@ func (c *Component) Update(args component.Arguments) error {
 
 	return nil
 }
+
+// Destinations implements component.DestinationComponent.
+func (c *Component) Destinations() []string {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	urls := make([]string, 0, len(c.args.Endpoints))
+	for _, ep := range c.args.Endpoints {
+		urls = append(urls, ep.URL)
+	}
+	return urls
+}
@@ -9,7 +9,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/write/internal/client"
 	"github.com/grafana/agent/pkg/flow/componenttest"
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
@@ -50,6 +52,88 @@ func TestBadRiverConfig(t *testing.T) {
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
 
+func TestRetryRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	endpoint {
+		url                = "http://0.0.0.0:11111/loki/api/v1/push"
+		max_backoff_period = "1m"
+
+		retry {
+			initial_interval       = "1s"
+			multiplier             = 1.5
+			max_elapsed_time       = "10m"
+			retryable_status_codes = [429, 503]
+		}
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	cfgs := args.convertClientConfigs()
+	require.Len(t, cfgs, 1)
+	require.Equal(t, time.Second, cfgs[0].BackoffConfig.MinBackoff)
+	require.Equal(t, time.Minute, cfgs[0].BackoffConfig.MaxBackoff)
+	require.Equal(t, 10, cfgs[0].BackoffConfig.MaxRetries)
+	require.Equal(t, 1.5, cfgs[0].BackoffMultiplier)
+	require.Equal(t, 10*time.Minute, cfgs[0].MaxElapsedTime)
+	require.Equal(t, []int{429, 503}, cfgs[0].RetryableStatusCodes)
+}
+
+func TestEncodingRiverConfig(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`endpoint { url = "http://0.0.0.0:11111/loki/api/v1/push" }`), &args))
+	require.Equal(t, client.EncodingProtobuf, args.convertClientConfigs()[0].Encoding)
+
+	exampleRiverConfig := `
+	endpoint {
+		url      = "http://0.0.0.0:11111/loki/api/v1/push"
+		encoding = "columnar"
+	}
+`
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+	require.Equal(t, client.EncodingColumnar, args.convertClientConfigs()[0].Encoding)
+
+	exampleRiverConfig = `
+	endpoint {
+		url      = "http://0.0.0.0:11111/loki/api/v1/push"
+		encoding = "arrow"
+	}
+`
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.ErrorContains(t, err, `unknown encoding "arrow", must be "protobuf" or "columnar"`)
+}
+
+func TestWALRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	endpoint {
+		url = "http://0.0.0.0:11111/loki/api/v1/push"
+	}
+
+	wal {
+		enabled  = true
+		max_size = "512MiB"
+	}
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+	require.True(t, args.WAL.Enabled)
+	require.Equal(t, 512*units.MiB, args.WAL.MaxSize)
+	require.Equal(t, DefaultWALOptions.MaxSegmentSize, args.WAL.MaxSegmentSize)
+
+	exampleRiverConfig = `
+	wal {
+		enabled          = true
+		max_size         = "1MiB"
+		max_segment_size = "2MiB"
+	}
+`
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.ErrorContains(t, err, "max_segment_size must not be greater than max_size")
+}
+
 func Test(t *testing.T) {
 	// Set up the server that will receive the log entry, and expose it on ch.
 	ch := make(chan logproto.PushRequest)
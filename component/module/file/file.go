@@ -71,14 +71,17 @@ var (
 
 // New creates a new module.file component.
 func New(o component.Options, args Arguments) (*Component, error) {
+	mod, err := module.NewModuleComponent(o)
+	if err != nil {
+		return nil, err
+	}
 	c := &Component{
 		opts: o,
-		mod:  module.NewModuleComponent(o),
+		mod:  mod,
 		args: args,
 	}
 	defer c.isCreated.Store(true)
 
-	var err error
 	c.managedLocalFile, err = c.newManagedLocalComponent(o)
 	if err != nil {
 		return nil, err
@@ -32,8 +32,14 @@ type Exports struct {
 	Exports map[string]any `river:"exports,attr"`
 }
 
-// NewModuleComponent initializes a new ModuleComponent.
-func NewModuleComponent(o component.Options) *ModuleComponent {
+// NewModuleComponent initializes a new ModuleComponent. An error is returned
+// if the module would exceed the module depth limit.
+func NewModuleComponent(o component.Options) (*ModuleComponent, error) {
+	limits, err := o.Limits.Child()
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO(rfratto): replace these with a tracer/registry which properly
 	// propagates data back to the parent.
 	flowTracer, _ := tracing.New(tracing.DefaultOptions)
@@ -47,15 +53,23 @@ func NewModuleComponent(o component.Options) *ModuleComponent {
 			Tracer:       flowTracer,
 			Reg:          flowRegistry,
 
-			DataPath:       o.DataPath,
-			HTTPPathPrefix: o.HTTPPath,
-			HTTPListenAddr: o.HTTPListenAddr,
+			DataPath:              o.DataPath,
+			HTTPPathPrefix:        o.HTTPPath,
+			PeerHTTPPathPrefix:    o.PeerHTTPPath,
+			HTTPListenAddr:        o.HTTPListenAddr,
+			Cluster:               o.Cluster,
+			Limits:                limits,
+			ExportDebounce:        o.ExportDebounce,
+			MaxEvaluationDuration: o.MaxEvaluationDuration,
+			Events:                o.Events,
+			ReadOnly:              o.ReadOnly,
+			DryRun:                o.DryRun,
 
 			OnExportsChange: func(exports map[string]any) {
 				o.OnStateChange(Exports{Exports: exports})
 			},
 		}),
-	}
+	}, nil
 }
 
 // LoadFlowContent loads the flow controller with the current component content. It
@@ -44,8 +44,12 @@ var (
 
 // New creates a new module.string component.
 func New(o component.Options, args Arguments) (*Component, error) {
+	mod, err := module.NewModuleComponent(o)
+	if err != nil {
+		return nil, err
+	}
 	c := &Component{
-		mod: module.NewModuleComponent(o),
+		mod: mod,
 	}
 
 	if err := c.Update(args); err != nil {
@@ -13,6 +13,7 @@ import (
 
 	_ "github.com/grafana/agent/component/local/file"
 	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/limits"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/stretchr/testify/require"
 )
@@ -58,6 +59,29 @@ func TestExports(t *testing.T) {
 	testFile(t, fmtFile, "module.string.importer", []string{"password1", "bob"})
 }
 
+func TestPerOSContent(t *testing.T) {
+	// Each module only runs components on the operating systems it has content
+	// for; an empty module doesn't run any components.
+	riverFile := `
+		module.string "per_os" {
+			content = {
+				"linux"   = "export \"os\" { value = \"linux-module\" }",
+				"windows" = "export \"os\" { value = \"windows-module\" }",
+				"darwin"  = "export \"os\" { value = \"darwin-module\" }",
+			}[constants.os]
+		}
+
+		module.string "windows_only" {
+			content = {
+				"linux"   = "",
+				"windows" = "export \"os\" { value = \"windows-only-module\" }",
+				"darwin"  = "",
+			}[constants.os]
+		}`
+	testFile(t, riverFile, "module.string.per_os", []string{"linux-module"})
+	testFile(t, riverFile, "module.string.windows_only", []string{`"exports"`})
+}
+
 func TestUpdatingExports(t *testing.T) {
 	// The tick ensures that the dummy value will get exported multiple times.
 	// In previous versions this would cause ONLY the dummy value to be passed to exports.
@@ -103,6 +127,45 @@ func TestUpdatingExports(t *testing.T) {
 	testFile(t, fmtFile, "module.string.loadexport", []string{"address", "dummy"})
 }
 
+func TestRecursiveModule(t *testing.T) {
+	// The module loads itself with module.string, which would create nested
+	// modules forever without a depth limit.
+	moduleContent := `
+		argument "path" { }
+
+		local.file "self" {
+			filename = argument.path.value
+		}
+
+		module.string "self" {
+			content   = local.file.self.content
+			arguments = { path = argument.path.value }
+		}`
+
+	tmpDir := t.TempDir()
+	modulePath := filepath.Join(tmpDir, "module.river")
+	writeFile(t, modulePath, moduleContent)
+
+	riverFile := `
+		local.file "self" {
+			filename = "%path%"
+		}
+
+		module.string "self" {
+			content   = local.file.self.content
+			arguments = { path = "%path%" }
+		}`
+	fmtFile := strings.ReplaceAll(riverFile, "%path%", modulePath)
+
+	opts := testOptions(t)
+	opts.Limits = limits.New(limits.Options{MaxModuleDepth: 3})
+	f := flow.New(opts)
+	ff, err := flow.ReadFile("test", []byte(fmtFile))
+	require.NoError(t, err)
+	err = f.LoadFile(ff, nil)
+	require.ErrorContains(t, err, "module exceeds the limit of 3 nested modules")
+}
+
 func testFile(t *testing.T, fmtFile string, componentToFind string, searchable []string) {
 	f := flow.New(testOptions(t))
 	ff, err := flow.ReadFile("test", []byte(fmtFile))
@@ -5,6 +5,7 @@ import (
 
 	"github.com/alecthomas/units"
 	"github.com/grafana/agent/component/otelcol/auth"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
 	otelcomponent "go.opentelemetry.io/collector/component"
 	otelconfig "go.opentelemetry.io/collector/config"
 	otelconfigauth "go.opentelemetry.io/collector/config/configauth"
@@ -136,11 +137,11 @@ type GRPCClientArguments struct {
 	TLS       TLSClientArguments        `river:"tls,block,optional"`
 	Keepalive *KeepaliveClientArguments `river:"keepalive,block,optional"`
 
-	ReadBufferSize  units.Base2Bytes  `river:"read_buffer_size,attr,optional"`
-	WriteBufferSize units.Base2Bytes  `river:"write_buffer_size,attr,optional"`
-	WaitForReady    bool              `river:"wait_for_ready,attr,optional"`
-	Headers         map[string]string `river:"headers,attr,optional"`
-	BalancerName    string            `river:"balancer_name,attr,optional"`
+	ReadBufferSize  units.Base2Bytes                     `river:"read_buffer_size,attr,optional"`
+	WriteBufferSize units.Base2Bytes                     `river:"write_buffer_size,attr,optional"`
+	WaitForReady    bool                                 `river:"wait_for_ready,attr,optional"`
+	Headers         map[string]rivertypes.OptionalSecret `river:"headers,attr,optional"`
+	BalancerName    string                               `river:"balancer_name,attr,optional"`
 
 	// Auth is a binding to an otelcol.auth.* component extension which handles
 	// authentication.
@@ -170,7 +171,7 @@ func (args *GRPCClientArguments) Convert() *otelconfiggrpc.GRPCClientSettings {
 		ReadBufferSize:  int(args.ReadBufferSize),
 		WriteBufferSize: int(args.WriteBufferSize),
 		WaitForReady:    args.WaitForReady,
-		Headers:         args.Headers,
+		Headers:         convertHeaders(args.Headers),
 		BalancerName:    args.BalancerName,
 
 		Auth: auth,
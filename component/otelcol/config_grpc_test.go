@@ -0,0 +1,88 @@
+package otelcol_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+	otelconfiggrpc "go.opentelemetry.io/collector/config/configgrpc"
+)
+
+func TestGRPCServerArguments_Tuning(t *testing.T) {
+	in := `
+		endpoint               = "0.0.0.0:4317"
+		max_recv_msg_size      = "16MiB"
+		max_concurrent_streams = 100
+		read_buffer_size       = "1MiB"
+		write_buffer_size      = "2MiB"
+
+		keepalive {
+			server_parameters {
+				max_connection_idle      = "1m"
+				max_connection_age       = "10m"
+				max_connection_age_grace = "30s"
+				time                     = "2h"
+				timeout                  = "20s"
+			}
+
+			enforcement_policy {
+				min_time              = "5m"
+				permit_without_stream = true
+			}
+		}
+	`
+
+	var args otelcol.GRPCServerArguments
+	require.NoError(t, river.Unmarshal([]byte(in), &args))
+
+	actual := args.Convert()
+	require.Equal(t, uint64(16), actual.MaxRecvMsgSizeMiB)
+	require.Equal(t, uint32(100), actual.MaxConcurrentStreams)
+	require.Equal(t, 1<<20, actual.ReadBufferSize)
+	require.Equal(t, 2<<20, actual.WriteBufferSize)
+	require.Equal(t, &otelconfiggrpc.KeepaliveServerConfig{
+		ServerParameters: &otelconfiggrpc.KeepaliveServerParameters{
+			MaxConnectionIdle:     time.Minute,
+			MaxConnectionAge:      10 * time.Minute,
+			MaxConnectionAgeGrace: 30 * time.Second,
+			Time:                  2 * time.Hour,
+			Timeout:               20 * time.Second,
+		},
+		EnforcementPolicy: &otelconfiggrpc.KeepaliveEnforcementPolicy{
+			MinTime:             5 * time.Minute,
+			PermitWithoutStream: true,
+		},
+	}, actual.Keepalive)
+}
+
+func TestGRPCClientArguments_Tuning(t *testing.T) {
+	in := `
+		endpoint          = "localhost:4317"
+		read_buffer_size  = "1MiB"
+		write_buffer_size = "512KiB"
+		wait_for_ready    = true
+		balancer_name     = "round_robin"
+
+		keepalive {
+			ping_wait             = "30s"
+			ping_response_timeout = "10s"
+			ping_without_stream   = true
+		}
+	`
+
+	var args otelcol.GRPCClientArguments
+	require.NoError(t, river.Unmarshal([]byte(in), &args))
+
+	actual := args.Convert()
+	require.Equal(t, 1<<20, actual.ReadBufferSize)
+	require.Equal(t, 512<<10, actual.WriteBufferSize)
+	require.True(t, actual.WaitForReady)
+	require.Equal(t, "round_robin", actual.BalancerName)
+	require.Equal(t, &otelconfiggrpc.KeepaliveClientConfig{
+		Time:                30 * time.Second,
+		Timeout:             10 * time.Second,
+		PermitWithoutStream: true,
+	}, actual.Keepalive)
+}
@@ -0,0 +1,18 @@
+package otelcol
+
+import "github.com/grafana/agent/pkg/flow/rivertypes"
+
+// convertHeaders converts headers of exporter client arguments into the
+// upstream type. Header values may be secrets, so that headers holding
+// credentials or tenant IDs can be set from components such as remote.http.
+func convertHeaders(headers map[string]rivertypes.OptionalSecret) map[string]string {
+	if headers == nil {
+		return nil
+	}
+
+	res := make(map[string]string, len(headers))
+	for k, v := range headers {
+		res[k] = v.Value
+	}
+	return res
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/alecthomas/units"
 	"github.com/grafana/agent/component/otelcol/auth"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
 	otelcomponent "go.opentelemetry.io/collector/component"
 	otelconfig "go.opentelemetry.io/collector/config"
 	otelconfigauth "go.opentelemetry.io/collector/config/configauth"
@@ -79,10 +80,10 @@ type HTTPClientArguments struct {
 
 	TLS TLSClientArguments `river:"tls,block,optional"`
 
-	ReadBufferSize  units.Base2Bytes  `river:"read_buffer_size,attr,optional"`
-	WriteBufferSize units.Base2Bytes  `river:"write_buffer_size,attr,optional"`
-	Timeout         time.Duration     `river:"timeout,attr,optional"`
-	Headers         map[string]string `river:"headers,attr,optional"`
+	ReadBufferSize  units.Base2Bytes                     `river:"read_buffer_size,attr,optional"`
+	WriteBufferSize units.Base2Bytes                     `river:"write_buffer_size,attr,optional"`
+	Timeout         time.Duration                        `river:"timeout,attr,optional"`
+	Headers         map[string]rivertypes.OptionalSecret `river:"headers,attr,optional"`
 	// CustomRoundTripper  func(next http.RoundTripper) (http.RoundTripper, error) TODO (@tpaschalis)
 	MaxIdleConns        *int           `river:"max_idle_conns,attr,optional"`
 	MaxIdleConnsPerHost *int           `river:"max_idle_conns_per_host,attr,optional"`
@@ -116,7 +117,7 @@ func (args *HTTPClientArguments) Convert() *otelconfighttp.HTTPClientSettings {
 		ReadBufferSize:  int(args.ReadBufferSize),
 		WriteBufferSize: int(args.WriteBufferSize),
 		Timeout:         args.Timeout,
-		Headers:         args.Headers,
+		Headers:         convertHeaders(args.Headers),
 		// CustomRoundTripper: func(http.RoundTripper) (http.RoundTripper, error) { panic("not implemented") }, TODO (@tpaschalis)
 		MaxIdleConns:        args.MaxIdleConns,
 		MaxIdleConnsPerHost: args.MaxIdleConnsPerHost,
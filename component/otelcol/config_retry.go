@@ -1,8 +1,10 @@
 package otelcol
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/pkg/river"
 	otelexporterhelper "go.opentelemetry.io/collector/exporter/exporterhelper"
 )
@@ -10,27 +12,49 @@ import (
 // RetryArguments holds shared settings for components which can retry
 // requests.
 type RetryArguments struct {
-	Enabled         bool          `river:"enabled,attr,optional"`
-	InitialInterval time.Duration `river:"initial_interval,attr,optional"`
-	MaxInterval     time.Duration `river:"max_interval,attr,optional"`
-	MaxElapsedTime  time.Duration `river:"max_elapsed_time,attr,optional"`
+	Enabled bool `river:"enabled,attr,optional"`
+
+	// Backoff holds the retry schema shared with other components which write
+	// data to remote endpoints.
+	Backoff config.RetryConfig `river:",squash"`
 }
 
 var _ river.Unmarshaler = (*RetryArguments)(nil)
 
 // DefaultRetryArguments holds default settings for RetryArguments.
 var DefaultRetryArguments = RetryArguments{
-	Enabled:         true,
-	InitialInterval: 5 * time.Second,
-	MaxInterval:     30 * time.Second,
-	MaxElapsedTime:  5 * time.Minute,
+	Enabled: true,
+	Backoff: config.RetryConfig{
+		InitialInterval: 5 * time.Second,
+		MaxInterval:     30 * time.Second,
+		MaxElapsedTime:  5 * time.Minute,
+	},
+}
+
+// retrySupport describes the retry settings supported by exporters: the
+// backoff always grows by a factor of 1.5, and whether a request is retried
+// is decided by the exporter rather than by status code.
+var retrySupport = config.RetrySupport{
+	Multiplier:     1.5,
+	MaxElapsedTime: true,
 }
 
 // UnmarshalRiver implements river.Unmarshaler.
 func (args *RetryArguments) UnmarshalRiver(f func(interface{}) error) error {
 	*args = DefaultRetryArguments
 	type arguments RetryArguments
-	return f((*arguments)(args))
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	// The backoff settings are squashed, so they must be validated explicitly.
+	if err := args.Backoff.Validate(); err != nil {
+		return err
+	}
+	if err := args.Backoff.CheckSupported(retrySupport); err != nil {
+		return fmt.Errorf("invalid retry_on_failure block: %w", err)
+	}
+	return nil
 }
 
 // Convert converts args into the upstream type.
@@ -41,8 +65,8 @@ func (args *RetryArguments) Convert() *otelexporterhelper.RetrySettings {
 
 	return &otelexporterhelper.RetrySettings{
 		Enabled:         args.Enabled,
-		InitialInterval: args.InitialInterval,
-		MaxInterval:     args.MaxInterval,
-		MaxElapsedTime:  args.MaxElapsedTime,
+		InitialInterval: args.Backoff.InitialInterval,
+		MaxInterval:     args.Backoff.MaxInterval,
+		MaxElapsedTime:  args.Backoff.MaxElapsedTime,
 	}
 }
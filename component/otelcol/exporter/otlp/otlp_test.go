@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/agent/component/otelcol"
 	"github.com/grafana/agent/component/otelcol/exporter/otlp"
 	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
 	"github.com/grafana/dskit/backoff"
@@ -18,6 +19,7 @@ import (
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 // Test performs a basic integration test which runs the otelcol.exporter.otlp
@@ -86,16 +88,100 @@ func Test(t *testing.T) {
 	}
 }
 
+// TestHeaderRotation ensures that the headers of the exporter can be changed,
+// including to secret values, without recreating the component.
+func TestHeaderRotation(t *testing.T) {
+	tenantCh := make(chan string)
+	tracesServer := makeServer(t, &mockTenantReceiver{ch: tenantCh})
+
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.exporter.otlp")
+	require.NoError(t, err)
+
+	cfg := fmt.Sprintf(`
+		timeout = "250ms"
+
+		client {
+			endpoint = "%s"
+
+			compression = "none"
+			headers     = { "X-Scope-OrgID" = "tenant-a" }
+
+			tls {
+				insecure             = true
+				insecure_skip_verify = true
+			}
+		}
+	`, tracesServer)
+	var args otlp.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+
+	sendTraces := func() {
+		go func() {
+			bo := backoff.New(ctx, backoff.Config{
+				MinBackoff: 10 * time.Millisecond,
+				MaxBackoff: 100 * time.Millisecond,
+			})
+			for bo.Ongoing() {
+				err := exports.Input.ConsumeTraces(ctx, createTestTraces())
+				if err != nil {
+					level.Error(l).Log("msg", "failed to send traces", "err", err)
+					bo.Wait()
+					continue
+				}
+				return
+			}
+		}()
+	}
+	waitTenant := func(expect string) {
+		select {
+		case <-time.After(time.Second):
+			require.FailNow(t, "failed waiting for traces")
+		case tenant := <-tenantCh:
+			require.Equal(t, expect, tenant)
+		}
+	}
+
+	sendTraces()
+	waitTenant("tenant-a")
+
+	args.Client.Headers = map[string]rivertypes.OptionalSecret{
+		"X-Scope-OrgID": {IsSecret: true, Value: "tenant-b"},
+	}
+	require.NoError(t, ctrl.Update(args))
+
+	sendTraces()
+	waitTenant("tenant-b")
+}
+
 // makeTracesServer returns a host:port which will accept traces over insecure
 // gRPC.
 func makeTracesServer(t *testing.T, ch chan ptrace.Traces) string {
 	t.Helper()
+	return makeServer(t, &mockTracesReceiver{ch: ch})
+}
+
+// makeServer returns a host:port which will pass traces received over
+// insecure gRPC to receiver.
+func makeServer(t *testing.T, receiver ptraceotlp.GRPCServer) string {
+	t.Helper()
 
 	lis, err := net.Listen("tcp", "127.0.0.1:0")
 	require.NoError(t, err)
 
 	srv := grpc.NewServer()
-	ptraceotlp.RegisterGRPCServer(srv, &mockTracesReceiver{ch: ch})
+	ptraceotlp.RegisterGRPCServer(srv, receiver)
 
 	go func() {
 		err := srv.Serve(lis)
@@ -117,6 +203,24 @@ func (ms *mockTracesReceiver) Export(_ context.Context, req ptraceotlp.ExportReq
 	return ptraceotlp.NewExportResponse(), nil
 }
 
+// mockTenantReceiver sends the X-Scope-OrgID header of received traces to ch.
+type mockTenantReceiver struct {
+	ch chan string
+}
+
+var _ ptraceotlp.GRPCServer = (*mockTenantReceiver)(nil)
+
+func (ms *mockTenantReceiver) Export(ctx context.Context, _ ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
+	var tenant string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("X-Scope-OrgID"); len(values) > 0 {
+			tenant = values[0]
+		}
+	}
+	ms.ch <- tenant
+	return ptraceotlp.NewExportResponse(), nil
+}
+
 func createTestTraces() ptrace.Traces {
 	// Matches format from the protobuf definition:
 	// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
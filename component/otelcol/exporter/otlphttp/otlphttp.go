@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/otelcol"
 	"github.com/grafana/agent/component/otelcol/exporter"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
 	"github.com/grafana/agent/pkg/river"
 	otelcomponent "go.opentelemetry.io/collector/component"
 	otelconfig "go.opentelemetry.io/collector/config"
@@ -107,7 +108,7 @@ var (
 		IdleConnTimeout: &DefaultIdleConnTimeout,
 
 		Timeout:         30 * time.Second,
-		Headers:         map[string]string{},
+		Headers:         map[string]rivertypes.OptionalSecret{},
 		Compression:     otelcol.CompressionTypeGzip,
 		ReadBufferSize:  0,
 		WriteBufferSize: 512 * 1024,
@@ -92,8 +92,21 @@ func (series *memorySeries) SetValue(newValue float64) {
 func (series *memorySeries) WriteTo(app storage.Appender, ts time.Time) error {
 	series.Lock()
 	defer series.Unlock()
+	return series.writeValueTo(app, ts, series.value)
+}
+
+// WriteValueTo writes val at ts to app without updating the current value of
+// the series. It's used for writing out-of-order samples.
+func (series *memorySeries) WriteValueTo(app storage.Appender, ts time.Time, val float64) error {
+	series.Lock()
+	defer series.Unlock()
+	return series.writeValueTo(app, ts, val)
+}
 
-	newID, err := app.Append(series.id, series.labels, timestamp.FromTime(ts), series.value)
+// writeValueTo writes val at ts to app. series must be locked when calling
+// writeValueTo.
+func (series *memorySeries) writeValueTo(app storage.Appender, ts time.Time, val float64) error {
+	newID, err := app.Append(series.id, series.labels, timestamp.FromTime(ts), val)
 	if err != nil {
 		return err
 	}
@@ -9,6 +9,7 @@ package convert
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"sync"
@@ -305,15 +306,23 @@ type otelcolDataPoint interface {
 
 func writeSeries(app storage.Appender, series *memorySeries, dp otelcolDataPoint, val float64) error {
 	ts := dp.Timestamp().AsTime()
-	if ts.Before(series.Timestamp()) {
-		// Out-of-order; skip.
-		return nil
-	}
-	series.SetTimestamp(ts)
-
 	if dp.Flags().NoRecordedValue() {
 		val = float64(value.StaleNaN)
 	}
+
+	if ts.Before(series.Timestamp()) {
+		// Out-of-order; forward the sample without changing the current value
+		// of the series and let the next appender decide whether to accept it.
+		// Appenders such as the WAL of prometheus.remote_write reject it
+		// unless they're configured with an out-of-order time window.
+		err := series.WriteValueTo(app, ts, val)
+		if errors.Is(err, storage.ErrOutOfOrderSample) || errors.Is(err, storage.ErrTooOldSample) {
+			// Rejected out-of-order samples are tracked by the appender.
+			return nil
+		}
+		return err
+	}
+	series.SetTimestamp(ts)
 	series.SetValue(val)
 
 	return series.WriteTo(app, ts)
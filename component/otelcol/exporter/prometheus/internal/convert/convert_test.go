@@ -343,6 +343,47 @@ func TestConverter(t *testing.T) {
 	}
 }
 
+func TestConverter_OutOfOrder(t *testing.T) {
+	payloadAt := func(ts string, val string) pmetric.Metrics {
+		payload, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics([]byte(`{
+			"resource_metrics": [{
+				"scope_metrics": [{
+					"metrics": [{
+						"name": "test_metric_seconds",
+						"gauge": {
+							"data_points": [{
+								"time_unix_nano": ` + ts + `,
+								"as_double": ` + val + `
+							}]
+						}
+					}]
+				}]
+			}]
+		}`))
+		require.NoError(t, err)
+		return payload
+	}
+
+	var app testappender.Appender
+	aa := &appenderAppendable{Inner: &app}
+	conv := convert.New(util.TestLogger(t), aa, convert.Options{})
+	require.NoError(t, conv.ConsumeMetrics(context.Background(), payloadAt("2000000000", "2")))
+
+	// Late samples are forwarded to the appender rather than dropped. Metadata
+	// was already written with the first sample, so the type is unknown here.
+	var lateApp testappender.Appender
+	aa.Inner = &lateApp
+	require.NoError(t, conv.ConsumeMetrics(context.Background(), payloadAt("1000000000", "1")))
+
+	families, err := lateApp.MetricFamilies()
+	require.NoError(t, err)
+	c := testappender.Comparer{OpenMetrics: true}
+	require.NoError(t, c.Compare(families, `
+		# TYPE test_metric_seconds unknown
+		test_metric_seconds 1.0 1.0
+	`))
+}
+
 // appenderAppendable always returns the same Appender.
 type appenderAppendable struct {
 	Inner storage.Appender
@@ -22,7 +22,7 @@ func init() {
 		Exports: otelcol.ConsumerExports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
-			fact := memorylimiterprocessor.NewFactory()
+			fact := newSheddingFactory(opts.Registerer)
 			return processor.New(opts, fact, args.(Arguments))
 		},
 	})
@@ -36,6 +36,10 @@ type Arguments struct {
 	MemoryLimitPercentage uint32           `river:"limit_percentage,attr,optional"`
 	MemorySpikePercentage uint32           `river:"spike_limit_percentage,attr,optional"`
 
+	// LoadShedding enables shedding traces by priority instead of refusing
+	// all traces when memory usage is too high.
+	LoadShedding *LoadSheddingArguments `river:"load_shedding,block,optional"`
+
 	// Output configures where to send processed data. Required.
 	Output *otelcol.ConsumerArguments `river:"output,block"`
 }
@@ -97,14 +101,17 @@ func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 
 // Convert implements processor.Arguments.
 func (args Arguments) Convert() (otelconfig.Processor, error) {
-	return &memorylimiterprocessor.Config{
-		ProcessorSettings: otelconfig.NewProcessorSettings(otelconfig.NewComponentID("memory_limiter")),
-
-		CheckInterval:         args.CheckInterval,
-		MemoryLimitMiB:        uint32(args.MemoryLimit / units.Mebibyte),
-		MemorySpikeLimitMiB:   uint32(args.MemorySpikeLimit / units.Mebibyte),
-		MemoryLimitPercentage: args.MemoryLimitPercentage,
-		MemorySpikePercentage: args.MemorySpikePercentage,
+	return &sheddingConfig{
+		Config: &memorylimiterprocessor.Config{
+			ProcessorSettings: otelconfig.NewProcessorSettings(otelconfig.NewComponentID("memory_limiter")),
+
+			CheckInterval:         args.CheckInterval,
+			MemoryLimitMiB:        uint32(args.MemoryLimit / units.Mebibyte),
+			MemorySpikeLimitMiB:   uint32(args.MemorySpikeLimit / units.Mebibyte),
+			MemoryLimitPercentage: args.MemoryLimitPercentage,
+			MemorySpikePercentage: args.MemorySpikePercentage,
+		},
+		LoadShedding: args.LoadShedding,
 	}, nil
 }
 
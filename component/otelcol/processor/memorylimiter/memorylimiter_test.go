@@ -79,6 +79,65 @@ func Test(t *testing.T) {
 	}
 }
 
+// TestLoadShedding ensures that only high priority traces are forwarded when
+// memory usage is too high and load shedding is enabled.
+func TestLoadShedding(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.processor.memory_limiter")
+	require.NoError(t, err)
+
+	// The soft limit is far below the memory usage of the test while the
+	// hard limit is far above it, so traces are shed by priority once the
+	// memory limiter checked memory usage.
+	cfg := `
+		check_interval = "10ms"
+		limit          = "16GiB"
+		spike_limit    = "16383MiB"
+
+		load_shedding {}
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+	var args memorylimiter.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	traceCh := make(chan ptrace.Traces)
+	args.Output = makeTracesOutput(traceCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+
+	// Traces are forwarded unchanged until the memory limiter checked memory
+	// usage for the first time.
+	timeout := time.After(5 * time.Second)
+	for {
+		go func() {
+			_ = exports.Input.ConsumeTraces(ctx, createPriorityTestTraces())
+		}()
+
+		select {
+		case <-timeout:
+			require.FailNow(t, "traces were never shed")
+		case tr := <-traceCh:
+			if tr.SpanCount() == 1 {
+				span := tr.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+				require.Equal(t, "ErrorSpan", span.Name())
+				return
+			}
+		}
+	}
+}
+
 // makeTracesOutput returns ConsumerArguments which will forward traces to the
 // provided channel.
 func makeTracesOutput(ch chan ptrace.Traces) *otelcol.ConsumerArguments {
@@ -118,3 +177,27 @@ func createTestTraces() ptrace.Traces {
 	}
 	return data
 }
+
+func createPriorityTestTraces() ptrace.Traces {
+	var bb = `{
+		"resource_spans": [{
+			"scope_spans": [{
+				"spans": [{
+					"trace_id": "0102030405060708090a0b0c0d0e0f10",
+					"name": "ErrorSpan",
+					"status": { "code": 2 }
+				}, {
+					"trace_id": "1112131415161718191a1b1c1d1e1f20",
+					"name": "TestSpan"
+				}]
+			}]
+		}]
+	}`
+
+	decoder := &ptrace.JSONUnmarshaler{}
+	data, err := decoder.UnmarshalTraces([]byte(bb))
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,273 @@
+package memorylimiter
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/external/iruntime"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor/memorylimiterprocessor"
+)
+
+// LoadSheddingArguments configures which traces are kept when the memory
+// limiter refuses data.
+type LoadSheddingArguments struct {
+	// KeepErrors keeps traces with at least one span with an error status.
+	KeepErrors bool `river:"keep_errors,attr,optional"`
+
+	// PriorityAttribute is the name of a span attribute which marks traces to
+	// keep when it's set to a value greater than 0.
+	PriorityAttribute string `river:"priority_attribute,attr,optional"`
+}
+
+// DefaultLoadSheddingArguments holds default settings for
+// LoadSheddingArguments.
+var DefaultLoadSheddingArguments = LoadSheddingArguments{
+	KeepErrors:        true,
+	PriorityAttribute: "sampling.priority",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *LoadSheddingArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultLoadSheddingArguments
+
+	type arguments LoadSheddingArguments
+	return f((*arguments)(args))
+}
+
+// sheddingConfig extends the upstream configuration with settings for load
+// shedding.
+type sheddingConfig struct {
+	*memorylimiterprocessor.Config
+
+	LoadShedding *LoadSheddingArguments
+}
+
+// sheddingFactory wraps the upstream memory limiter factory. Traces refused by
+// the memory limiter are shed by priority if load shedding is enabled: while
+// memory usage is between the soft and the hard limit, high priority traces
+// are forwarded and the remaining traces are dropped rather than refused. All
+// traces are refused once memory usage reaches the hard limit.
+type sheddingFactory struct {
+	otelcomponent.ProcessorFactory
+
+	shedSpans prometheus.Counter
+}
+
+func newSheddingFactory(reg prometheus.Registerer) *sheddingFactory {
+	shedSpans := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "otelcol_processor_memory_limiter_shed_spans_total",
+		Help: "Total number of spans dropped by load shedding due to high memory usage.",
+	})
+	reg.MustRegister(shedSpans)
+
+	return &sheddingFactory{
+		ProcessorFactory: memorylimiterprocessor.NewFactory(),
+		shedSpans:        shedSpans,
+	}
+}
+
+// upstreamConfig returns the upstream configuration from cfg.
+func upstreamConfig(cfg otelconfig.Processor) otelconfig.Processor {
+	if sc, ok := cfg.(*sheddingConfig); ok {
+		return sc.Config
+	}
+	return cfg
+}
+
+// CreateTracesProcessor implements otelcomponent.ProcessorFactory.
+func (f *sheddingFactory) CreateTracesProcessor(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next consumer.Traces) (otelcomponent.TracesProcessor, error) {
+	sc, ok := cfg.(*sheddingConfig)
+	if !ok || sc.LoadShedding == nil {
+		return f.ProcessorFactory.CreateTracesProcessor(ctx, set, upstreamConfig(cfg), next)
+	}
+
+	inner, err := f.ProcessorFactory.CreateTracesProcessor(ctx, set, sc.Config, markingTraces{next})
+	if err != nil {
+		return nil, err
+	}
+	hardLimit, err := hardMemoryLimit(sc.Config)
+	if err != nil {
+		return nil, err
+	}
+	return &sheddingTraces{
+		TracesProcessor: inner,
+		next:            next,
+		args:            *sc.LoadShedding,
+		shedSpans:       f.shedSpans,
+		hardLimit:       hardLimit,
+		memUsage:        (&memoryReader{interval: sc.CheckInterval}).Alloc,
+	}, nil
+}
+
+// hardMemoryLimit returns the memory usage in bytes at which the memory
+// limiter configured by cfg refuses all data, computed like the upstream
+// memory limiter does.
+func hardMemoryLimit(cfg *memorylimiterprocessor.Config) (uint64, error) {
+	if cfg.MemoryLimitMiB != 0 {
+		return uint64(cfg.MemoryLimitMiB) << 20, nil
+	}
+	total, err := iruntime.TotalMemory()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(cfg.MemoryLimitPercentage) * total / 100, nil
+}
+
+// memoryReader reads the memory usage of the process like the upstream memory
+// limiter. Reading memory usage stops the world, so it's read at most once
+// per interval.
+type memoryReader struct {
+	interval time.Duration
+
+	mut      sync.Mutex
+	lastRead time.Time
+	alloc    uint64
+}
+
+// Alloc returns the number of bytes of allocated heap objects.
+func (r *memoryReader) Alloc() uint64 {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.lastRead.IsZero() || time.Since(r.lastRead) >= r.interval {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		r.alloc = ms.Alloc
+		r.lastRead = time.Now()
+	}
+	return r.alloc
+}
+
+// CreateMetricsProcessor implements otelcomponent.ProcessorFactory.
+func (f *sheddingFactory) CreateMetricsProcessor(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next consumer.Metrics) (otelcomponent.MetricsProcessor, error) {
+	return f.ProcessorFactory.CreateMetricsProcessor(ctx, set, upstreamConfig(cfg), next)
+}
+
+// CreateLogsProcessor implements otelcomponent.ProcessorFactory.
+func (f *sheddingFactory) CreateLogsProcessor(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next consumer.Logs) (otelcomponent.LogsProcessor, error) {
+	return f.ProcessorFactory.CreateLogsProcessor(ctx, set, upstreamConfig(cfg), next)
+}
+
+// forwardedKey is the context key of a *bool which is set to true once the
+// memory limiter forwards data to the next consumer. It distinguishes data
+// refused by the memory limiter from errors of the next consumer.
+type forwardedKey struct{}
+
+// markingTraces marks the data passed to it as forwarded.
+type markingTraces struct {
+	consumer.Traces
+}
+
+func (m markingTraces) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	if forwarded, ok := ctx.Value(forwardedKey{}).(*bool); ok {
+		*forwarded = true
+	}
+	return m.Traces.ConsumeTraces(ctx, td)
+}
+
+// sheddingTraces sheds traces refused by the wrapped memory limiter.
+type sheddingTraces struct {
+	otelcomponent.TracesProcessor
+
+	next      consumer.Traces
+	args      LoadSheddingArguments
+	shedSpans prometheus.Counter
+	hardLimit uint64        // Memory usage at which all traces are refused.
+	memUsage  func() uint64 // Returns the current memory usage.
+}
+
+func (s *sheddingTraces) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	// The memory limiter doesn't modify td, so it can still be used after
+	// being refused.
+	var forwarded bool
+	err := s.TracesProcessor.ConsumeTraces(context.WithValue(ctx, forwardedKey{}, &forwarded), td)
+	if err == nil || forwarded {
+		return err
+	}
+
+	// Priority traces are only forwarded between the soft and the hard limit,
+	// so they can't push memory usage past the hard limit.
+	if s.memUsage() >= s.hardLimit {
+		return err
+	}
+
+	kept := priorityTraces(td, s.args)
+	s.shedSpans.Add(float64(td.SpanCount() - kept.SpanCount()))
+	if kept.SpanCount() == 0 {
+		return nil
+	}
+	return s.next.ConsumeTraces(ctx, kept)
+}
+
+// priorityTraces returns the spans of td which belong to high priority traces
+// according to args. A trace is high priority if any of its spans in td is.
+func priorityTraces(td ptrace.Traces, args LoadSheddingArguments) ptrace.Traces {
+	priority := make(map[pcommon.TraceID]struct{})
+	forEachSpan(td, func(span ptrace.Span) {
+		if isPrioritySpan(span, args) {
+			priority[span.TraceID()] = struct{}{}
+		}
+	})
+
+	kept := ptrace.NewTraces()
+	if len(priority) == 0 {
+		return kept
+	}
+	td.CopyTo(kept)
+
+	kept.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			ss.Spans().RemoveIf(func(span ptrace.Span) bool {
+				_, ok := priority[span.TraceID()]
+				return !ok
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+	return kept
+}
+
+func isPrioritySpan(span ptrace.Span, args LoadSheddingArguments) bool {
+	if args.KeepErrors && span.Status().Code() == ptrace.StatusCodeError {
+		return true
+	}
+	if args.PriorityAttribute == "" {
+		return false
+	}
+
+	v, ok := span.Attributes().Get(args.PriorityAttribute)
+	if !ok {
+		return false
+	}
+	switch v.Type() {
+	case pcommon.ValueTypeInt:
+		return v.Int() > 0
+	case pcommon.ValueTypeDouble:
+		return v.Double() > 0
+	case pcommon.ValueTypeBool:
+		return v.Bool()
+	}
+	return false
+}
+
+func forEachSpan(td ptrace.Traces, f func(ptrace.Span)) {
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		sss := rss.At(i).ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				f(spans.At(k))
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package memorylimiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestSheddingTraces_HardLimit(t *testing.T) {
+	errRefused := errors.New("data refused due to high memory usage")
+
+	tt := []struct {
+		name      string
+		memUsage  uint64
+		expectErr error
+		expectFwd int
+	}{
+		{name: "between soft and hard limit", memUsage: 99, expectFwd: 1},
+		{name: "at hard limit", memUsage: 100, expectErr: errRefused},
+		{name: "above hard limit", memUsage: 200, expectErr: errRefused},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var forwarded int
+			next := &fakeconsumer.Consumer{
+				ConsumeTracesFunc: func(_ context.Context, td ptrace.Traces) error {
+					forwarded += td.SpanCount()
+					return nil
+				},
+			}
+
+			s := &sheddingTraces{
+				TracesProcessor: refusingTraces{err: errRefused},
+				next:            next,
+				args:            LoadSheddingArguments{KeepErrors: true},
+				shedSpans:       prometheus.NewCounter(prometheus.CounterOpts{Name: "shed_spans_total"}),
+				hardLimit:       100,
+				memUsage:        func() uint64 { return tc.memUsage },
+			}
+
+			td := ptrace.NewTraces()
+			spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+			errSpan := spans.AppendEmpty()
+			errSpan.SetTraceID(pcommon.TraceID([16]byte{1}))
+			errSpan.Status().SetCode(ptrace.StatusCodeError)
+			spans.AppendEmpty().SetTraceID(pcommon.TraceID([16]byte{2}))
+
+			err := s.ConsumeTraces(context.Background(), td)
+			require.Equal(t, tc.expectErr, err)
+			require.Equal(t, tc.expectFwd, forwarded)
+		})
+	}
+}
+
+// refusingTraces refuses all traces with err.
+type refusingTraces struct {
+	otelcomponent.TracesProcessor
+	err error
+}
+
+func (r refusingTraces) ConsumeTraces(context.Context, ptrace.Traces) error { return r.err }
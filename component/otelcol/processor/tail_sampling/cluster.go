@@ -0,0 +1,304 @@
+package tail_sampling
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rfratto/ckit/shard"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// ClusteringArguments configures how otelcol.processor.tail_sampling behaves
+// when the agent runs in clustered mode.
+type ClusteringArguments struct {
+	// Enabled routes the spans of each trace to the agent in the cluster which
+	// owns the trace ID, so that every trace is sampled by a single agent.
+	Enabled bool `river:"enabled,attr"`
+}
+
+// forwardTimeout bounds how long forwarding spans to another agent may take.
+const forwardTimeout = 5 * time.Second
+
+// tracesPath is the path of the component's HTTP handler which accepts spans
+// forwarded by other agents.
+const tracesPath = "/traces"
+
+// traceRouter is the consumer exported by the component. When clustering is
+// enabled, it sends the spans of each trace to the agent owning the trace ID
+// in the cluster. Spans owned by the local agent, and all metrics and logs,
+// are sent to the local tail sampling processor.
+//
+// Since all spans of a trace are sampled by the same agent, the sampling
+// decision cached by that agent applies to the whole trace, including spans
+// arriving late at other agents.
+type traceRouter struct {
+	opts   component.Options
+	client *http.Client
+
+	forwardedSpans prometheus.Counter
+	forwardErrors  prometheus.Counter
+
+	mut     sync.RWMutex
+	local   otelcol.Consumer
+	enabled bool
+}
+
+var _ otelcol.Consumer = (*traceRouter)(nil)
+
+func newTraceRouter(opts component.Options) *traceRouter {
+	r := &traceRouter{
+		opts:   opts,
+		client: &http.Client{Timeout: forwardTimeout},
+
+		forwardedSpans: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "otelcol_processor_tail_sampling_cluster_forwarded_spans_total",
+			Help: "Total number of spans forwarded to the agent owning their trace.",
+		}),
+		forwardErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "otelcol_processor_tail_sampling_cluster_forward_errors_total",
+			Help: "Total number of failures to forward spans to another agent. Spans which failed to be forwarded are sampled locally.",
+		}),
+	}
+	opts.Registerer.MustRegister(r.forwardedSpans, r.forwardErrors)
+	return r
+}
+
+func (r *traceRouter) setLocal(local otelcol.Consumer) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.local = local
+}
+
+func (r *traceRouter) setEnabled(enabled bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.enabled = enabled
+}
+
+func (r *traceRouter) getLocal() (local otelcol.Consumer, enabled bool) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	return r.local, r.enabled
+}
+
+// Capabilities implements otelconsumer.baseConsumer.
+func (r *traceRouter) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeTraces implements otelconsumer.Traces.
+func (r *traceRouter) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	local, enabled := r.getLocal()
+	if !enabled || r.opts.Cluster == nil {
+		return local.ConsumeTraces(ctx, td)
+	}
+
+	batches, err := r.splitByOwner(td)
+	if err != nil {
+		level.Warn(r.opts.Logger).Log("msg", "failed to look up owners of traces, sampling them locally", "err", err)
+		return local.ConsumeTraces(ctx, td)
+	}
+
+	var localBatches []ptrace.Traces
+	for addr, batch := range batches {
+		if addr == "" {
+			localBatches = append(localBatches, batch)
+			continue
+		}
+
+		if err := r.forward(ctx, addr, batch); err != nil {
+			// Sampling spans locally may sample a trace inconsistently, but it's
+			// preferable to losing the spans.
+			level.Warn(r.opts.Logger).Log("msg", "failed to forward spans to owning agent, sampling them locally", "peer", addr, "err", err)
+			r.forwardErrors.Inc()
+			localBatches = append(localBatches, batch)
+			continue
+		}
+		r.forwardedSpans.Add(float64(batch.SpanCount()))
+	}
+
+	for _, batch := range localBatches {
+		if err := local.ConsumeTraces(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConsumeMetrics implements otelconsumer.Metrics.
+func (r *traceRouter) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	local, _ := r.getLocal()
+	return local.ConsumeMetrics(ctx, md)
+}
+
+// ConsumeLogs implements otelconsumer.Logs.
+func (r *traceRouter) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	local, _ := r.getLocal()
+	return local.ConsumeLogs(ctx, ld)
+}
+
+// splitByOwner splits td into batches of spans by the agent owning their
+// trace ID. Batches are keyed by the address of the owning agent, where the
+// empty string is used for the local agent.
+func (r *traceRouter) splitByOwner(td ptrace.Traces) (map[string]ptrace.Traces, error) {
+	owners := make(map[pcommon.TraceID]string)
+	ownerOf := func(id pcommon.TraceID) (string, error) {
+		if addr, ok := owners[id]; ok {
+			return addr, nil
+		}
+		peers, err := r.opts.Cluster.Lookup(shard.StringKey(id.HexString()), 1, shard.OpReadWrite)
+		if err != nil {
+			return "", err
+		}
+		var addr string
+		if len(peers) > 0 && !peers[0].Self {
+			addr = peers[0].Addr
+		}
+		owners[id] = addr
+		return addr, nil
+	}
+
+	type batch struct {
+		td ptrace.Traces
+
+		// Resource and scope of the spans most recently appended to td, along
+		// with the indexes of the resource and scope in the input they were
+		// copied from.
+		rs               ptrace.ResourceSpans
+		ss               ptrace.ScopeSpans
+		rsIndex, ssIndex int
+	}
+	batches := make(map[string]*batch)
+
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			ss := sss.At(j)
+			spans := ss.Spans()
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+
+				addr, err := ownerOf(span.TraceID())
+				if err != nil {
+					return nil, err
+				}
+
+				b, ok := batches[addr]
+				if !ok {
+					b = &batch{td: ptrace.NewTraces(), rsIndex: -1, ssIndex: -1}
+					batches[addr] = b
+				}
+				if b.rsIndex != i {
+					b.rs = b.td.ResourceSpans().AppendEmpty()
+					rs.Resource().CopyTo(b.rs.Resource())
+					b.rs.SetSchemaUrl(rs.SchemaUrl())
+					b.rsIndex, b.ssIndex = i, -1
+				}
+				if b.ssIndex != j {
+					b.ss = b.rs.ScopeSpans().AppendEmpty()
+					ss.Scope().CopyTo(b.ss.Scope())
+					b.ss.SetSchemaUrl(ss.SchemaUrl())
+					b.ssIndex = j
+				}
+				span.CopyTo(b.ss.Spans().AppendEmpty())
+			}
+		}
+	}
+
+	res := make(map[string]ptrace.Traces, len(batches))
+	for addr, b := range batches {
+		res[addr] = b.td
+	}
+	return res, nil
+}
+
+// forward sends td to the same component of the agent at addr.
+func (r *traceRouter) forward(ctx context.Context, addr string, td ptrace.Traces) error {
+	var marshaler ptrace.ProtoMarshaler
+	body, err := marshaler.MarshalTraces(td)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, forwardTimeout)
+	defer cancel()
+
+	// Agents serve requests from their peers on a separate path which is
+	// available in read-only mode.
+	httpPath := r.opts.PeerHTTPPath
+	if httpPath == "" {
+		httpPath = r.opts.HTTPPath
+	}
+	url := fmt.Sprintf("http://%s%s%s", addr, strings.TrimSuffix(httpPath, "/"), tracesPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %s", resp.Status)
+	}
+	return nil
+}
+
+// handleTraces accepts spans forwarded by other agents and sends them to the
+// local tail sampling processor. Spans are never forwarded again, even if the
+// local agent doesn't consider itself the owner of their traces, so agents
+// with different views of the cluster don't forward spans in a loop.
+//
+// Only requests from peers of the cluster are accepted.
+func (r *traceRouter) handleTraces(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !cluster.IsPeerRequest(r.opts.Cluster, req) {
+		http.Error(w, "only peers of the cluster may forward spans", http.StatusForbidden)
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var unmarshaler ptrace.ProtoUnmarshaler
+	td, err := unmarshaler.UnmarshalTraces(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	local, _ := r.getLocal()
+	if err := local.ConsumeTraces(req.Context(), td); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,223 @@
+package tail_sampling
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rfratto/ckit/peer"
+	"github.com/rfratto/ckit/shard"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	localTraceID  = "0102030405060708090a0b0c0d0e0f10"
+	remoteTraceID = "1112131415161718191a1b1c1d1e1f20"
+)
+
+func TestTraceRouter(t *testing.T) {
+	const (
+		httpPath     = "/api/v0/component/otelcol.processor.tail_sampling.default/"
+		peerHTTPPath = "/api/v0/peer/component/otelcol.processor.tail_sampling.default/"
+	)
+
+	// Spans are forwarded to the path served to peers of the cluster.
+	var handler http.Handler
+	srv := httptest.NewServer(http.StripPrefix(strings.TrimSuffix(peerHTTPPath, "/"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	})))
+	defer srv.Close()
+
+	node := &fakeNode{owners: map[string]peer.Peer{
+		localTraceID:  {Name: "local", Addr: "127.0.0.1:12345", Self: true},
+		remoteTraceID: {Name: "remote", Addr: strings.TrimPrefix(srv.URL, "http://")},
+	}}
+
+	// The remote agent owns remoteTraceID and records the spans forwarded to
+	// it.
+	var remoteSpans spanRecorder
+	remote := newTraceRouter(component.Options{
+		Logger:       util.TestFlowLogger(t),
+		Registerer:   prometheus.NewRegistry(),
+		HTTPPath:     httpPath,
+		PeerHTTPPath: peerHTTPPath,
+		Cluster:      node,
+	})
+	remote.setLocal(remoteSpans.consumer())
+	handler = http.HandlerFunc(remote.handleTraces)
+
+	var localSpans spanRecorder
+	router := newTraceRouter(component.Options{
+		Logger:       util.TestFlowLogger(t),
+		Registerer:   prometheus.NewRegistry(),
+		HTTPPath:     httpPath,
+		PeerHTTPPath: peerHTTPPath,
+		Cluster:      node,
+	})
+	router.setLocal(localSpans.consumer())
+	router.setEnabled(true)
+
+	require.NoError(t, router.ConsumeTraces(context.Background(), createClusterTestTraces()))
+	require.Equal(t, []string{"LocalSpan"}, localSpans.names())
+	require.Equal(t, []string{"RemoteSpan", "RemoteSpan"}, remoteSpans.names())
+
+	// All spans are sampled locally when clustering is disabled.
+	router.setEnabled(false)
+	localSpans.reset()
+	require.NoError(t, router.ConsumeTraces(context.Background(), createClusterTestTraces()))
+	require.Equal(t, []string{"LocalSpan", "RemoteSpan", "RemoteSpan"}, localSpans.names())
+}
+
+func TestTraceRouter_ForwardError(t *testing.T) {
+	var localSpans spanRecorder
+	router := newTraceRouter(component.Options{
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prometheus.NewRegistry(),
+		HTTPPath:   "/",
+		Cluster: &fakeNode{owners: map[string]peer.Peer{
+			localTraceID:  {Name: "local", Self: true},
+			remoteTraceID: {Name: "remote", Addr: "127.0.0.1:0"},
+		}},
+	})
+	router.setLocal(localSpans.consumer())
+	router.setEnabled(true)
+
+	// Spans which can't be forwarded are sampled locally instead of being
+	// lost.
+	require.NoError(t, router.ConsumeTraces(context.Background(), createClusterTestTraces()))
+	require.ElementsMatch(t, []string{"LocalSpan", "RemoteSpan", "RemoteSpan"}, localSpans.names())
+}
+
+func TestTraceRouter_RejectsNonPeers(t *testing.T) {
+	var spans spanRecorder
+	router := newTraceRouter(component.Options{
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prometheus.NewRegistry(),
+		HTTPPath:   "/",
+		Cluster: &fakeNode{owners: map[string]peer.Peer{
+			localTraceID: {Name: "local", Addr: "10.0.0.1:12345", Self: true},
+		}},
+	})
+	router.setLocal(spans.consumer())
+
+	var marshaler ptrace.ProtoMarshaler
+	body, err := marshaler.MarshalTraces(createClusterTestTraces())
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, tracesPath, bytes.NewReader(body))
+	req.RemoteAddr = "192.168.0.1:54321"
+	rec := httptest.NewRecorder()
+	router.handleTraces(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.Empty(t, spans.names())
+
+	req = httptest.NewRequest(http.MethodPost, tracesPath, bytes.NewReader(body))
+	req.RemoteAddr = "10.0.0.1:54321"
+	rec = httptest.NewRecorder()
+	router.handleTraces(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Len(t, spans.names(), 3)
+}
+
+// fakeNode is a cluster.Node which assigns trace IDs to fixed owners.
+type fakeNode struct {
+	owners map[string]peer.Peer
+}
+
+func (n *fakeNode) Lookup(key shard.Key, _ int, _ shard.Op) ([]peer.Peer, error) {
+	for traceID, p := range n.owners {
+		if shard.StringKey(traceID) == key {
+			return []peer.Peer{p}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (n *fakeNode) Observe(cluster.Observer) {}
+
+func (n *fakeNode) Peers() []peer.Peer {
+	var peers []peer.Peer
+	for _, p := range n.owners {
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+// spanRecorder records the names of the spans it consumes.
+type spanRecorder struct {
+	mut   sync.Mutex
+	spans []string
+}
+
+func (r *spanRecorder) consumer() *fakeconsumer.Consumer {
+	return &fakeconsumer.Consumer{
+		ConsumeTracesFunc: func(_ context.Context, td ptrace.Traces) error {
+			r.mut.Lock()
+			defer r.mut.Unlock()
+
+			rss := td.ResourceSpans()
+			for i := 0; i < rss.Len(); i++ {
+				sss := rss.At(i).ScopeSpans()
+				for j := 0; j < sss.Len(); j++ {
+					spans := sss.At(j).Spans()
+					for k := 0; k < spans.Len(); k++ {
+						r.spans = append(r.spans, spans.At(k).Name())
+					}
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func (r *spanRecorder) names() []string {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.spans
+}
+
+func (r *spanRecorder) reset() {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.spans = nil
+}
+
+func createClusterTestTraces() ptrace.Traces {
+	var bb = `{
+		"resource_spans": [{
+			"scope_spans": [{
+				"spans": [{
+					"trace_id": "` + localTraceID + `",
+					"name": "LocalSpan"
+				}, {
+					"trace_id": "` + remoteTraceID + `",
+					"name": "RemoteSpan"
+				}]
+			}]
+		}, {
+			"scope_spans": [{
+				"spans": [{
+					"trace_id": "` + remoteTraceID + `",
+					"name": "RemoteSpan"
+				}]
+			}]
+		}]
+	}`
+
+	decoder := &ptrace.JSONUnmarshaler{}
+	data, err := decoder.UnmarshalTraces([]byte(bb))
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
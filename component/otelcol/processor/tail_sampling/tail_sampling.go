@@ -3,6 +3,7 @@ package tail_sampling
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/grafana/agent/component"
@@ -21,8 +22,7 @@ func init() {
 		Exports: otelcol.ConsumerExports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
-			fact := tsp.NewFactory()
-			return processor.New(opts, fact, args.(Arguments))
+			return New(opts, args.(Arguments))
 		},
 	})
 }
@@ -33,6 +33,9 @@ type Arguments struct {
 	DecisionWait            time.Duration `river:"decision_wait,attr,optional"`
 	NumTraces               uint64        `river:"num_traces,attr,optional"`
 	ExpectedNewTracesPerSec uint64        `river:"expected_new_traces_per_sec,attr,optional"`
+
+	Clustering ClusteringArguments `river:"clustering,block,optional"`
+
 	// Output configures where to send processed data. Required.
 	Output *otelcol.ConsumerArguments `river:"output,block"`
 }
@@ -70,6 +73,60 @@ func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	return nil
 }
 
+// Component implements the otelcol.processor.tail_sampling component.
+type Component struct {
+	*processor.Processor
+
+	router *traceRouter
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+	_ component.HTTPComponent   = (*Component)(nil)
+)
+
+// New creates a new otelcol.processor.tail_sampling component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	router := newTraceRouter(opts)
+
+	// Export the router instead of the consumer of the processor, so spans can
+	// be routed to other agents before being sampled.
+	processorOpts := opts
+	processorOpts.OnStateChange = func(e component.Exports) {
+		router.setLocal(e.(otelcol.ConsumerExports).Input)
+		opts.OnStateChange(otelcol.ConsumerExports{Input: router})
+	}
+
+	p, err := processor.New(processorOpts, tsp.NewFactory(), args)
+	if err != nil {
+		return nil, err
+	}
+	router.setEnabled(args.Clustering.Enabled)
+
+	return &Component{
+		Processor: p,
+		router:    router,
+	}, nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	if err := c.Processor.Update(args); err != nil {
+		return err
+	}
+	c.router.setEnabled(args.(Arguments).Clustering.Enabled)
+	return nil
+}
+
+// Handler implements component.HTTPComponent. The /traces endpoint accepts
+// spans forwarded by other agents in the cluster.
+func (c *Component) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(tracesPath, c.router.handleTraces)
+	return mux
+}
+
 // Convert implements processor.Arguments.
 func (args Arguments) Convert() (otelconfig.Processor, error) {
 	// TODO: Get rid of mapstructure once tailsamplingprocessor.Config has all public types
@@ -2,6 +2,8 @@
 package otlp
 
 import (
+	"fmt"
+
 	"github.com/alecthomas/units"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/otelcol"
@@ -18,7 +20,7 @@ func init() {
 		Args: Arguments{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
-			fact := otlpreceiver.NewFactory()
+			fact := newTenantFactory()
 			return receiver.New(opts, fact, args.(Arguments))
 		},
 	})
@@ -29,21 +31,51 @@ type Arguments struct {
 	GRPC *GRPCServerArguments `river:"grpc,block,optional"`
 	HTTP *HTTPServerArguments `river:"http,block,optional"`
 
+	// Tenant maps the TLS identity of clients to tenants.
+	Tenant *TenantArguments `river:"tenant,block,optional"`
+
 	// Output configures where to send received data. Required.
 	Output *otelcol.ConsumerArguments `river:"output,block"`
 }
 
-var _ receiver.Arguments = Arguments{}
+var (
+	_ receiver.Arguments = Arguments{}
+	_ river.Unmarshaler  = (*Arguments)(nil)
+)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	type arguments Arguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.Tenant != nil {
+		// The TLS identity of clients is only available to the consumers of the
+		// gRPC server.
+		if args.HTTP != nil {
+			return fmt.Errorf("the tenant block can't be used with the http block")
+		}
+		if args.GRPC == nil || args.GRPC.TLS == nil {
+			return fmt.Errorf("the tenant block requires the grpc block to configure tls")
+		}
+	}
+	return nil
+}
 
 // Convert implements receiver.Arguments.
 func (args Arguments) Convert() (otelconfig.Receiver, error) {
-	return &otlpreceiver.Config{
+	cfg := &otlpreceiver.Config{
 		ReceiverSettings: otelconfig.NewReceiverSettings(otelconfig.NewComponentID("otlp")),
 		Protocols: otlpreceiver.Protocols{
 			GRPC: (*otelcol.GRPCServerArguments)(args.GRPC).Convert(),
 			HTTP: (*otelcol.HTTPServerArguments)(args.HTTP).Convert(),
 		},
-	}, nil
+	}
+	if args.Tenant != nil {
+		return &tenantConfig{Config: cfg, Tenant: args.Tenant}, nil
+	}
+	return cfg, nil
 }
 
 // Extensions implements receiver.Arguments.
@@ -0,0 +1,212 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver/otlpreceiver"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// TenantArguments configures mapping the TLS identity of gRPC clients to
+// tenants.
+type TenantArguments struct {
+	// Attribute is the resource attribute the tenant is written to.
+	Attribute string `river:"attribute,attr,optional"`
+
+	// ClientCertificates maps the common name of verified client certificates
+	// to tenants.
+	ClientCertificates map[string]string `river:"client_certificates,attr,optional"`
+
+	// ServerNames maps the server name requested by clients with SNI to
+	// tenants.
+	ServerNames map[string]string `river:"server_names,attr,optional"`
+}
+
+// DefaultTenantArguments holds default settings for TenantArguments.
+var DefaultTenantArguments = TenantArguments{
+	Attribute: "tenant",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *TenantArguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultTenantArguments
+
+	type arguments TenantArguments
+	if err := f((*arguments)(args)); err != nil {
+		return err
+	}
+
+	if args.Attribute == "" {
+		return fmt.Errorf("attribute must not be empty")
+	}
+	if len(args.ClientCertificates) == 0 && len(args.ServerNames) == 0 {
+		return fmt.Errorf("at least one of client_certificates or server_names must be set")
+	}
+	return nil
+}
+
+// tenantOf returns the tenant of the client which sent the request of ctx.
+// The identity of a verified client certificate takes precedence over the
+// server name requested by the client.
+func (args *TenantArguments) tenantOf(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return "", false
+	}
+
+	// Only verified certificates are trusted to identify the client.
+	if chains := info.State.VerifiedChains; len(chains) > 0 && len(chains[0]) > 0 {
+		if tenant, ok := args.ClientCertificates[chains[0][0].Subject.CommonName]; ok {
+			return tenant, true
+		}
+	}
+
+	serverName := strings.ToLower(info.State.ServerName)
+	for name, tenant := range args.ServerNames {
+		if strings.ToLower(name) == serverName {
+			return tenant, true
+		}
+	}
+	return "", false
+}
+
+var errUnknownTenant = consumererror.NewPermanent(fmt.Errorf("no tenant matches the TLS identity of the client"))
+
+// tenantConfig extends the upstream configuration with settings for mapping
+// clients to tenants.
+type tenantConfig struct {
+	*otlpreceiver.Config
+
+	Tenant *TenantArguments
+}
+
+// tenantFactory wraps the upstream OTLP receiver factory. If tenants are
+// configured, received data is tagged with the tenant of the client which
+// sent it, and data from clients which don't map to a tenant is refused.
+type tenantFactory struct {
+	otelcomponent.ReceiverFactory
+}
+
+func newTenantFactory() *tenantFactory {
+	return &tenantFactory{ReceiverFactory: otlpreceiver.NewFactory()}
+}
+
+// splitConfig returns the upstream configuration and tenant settings from
+// cfg.
+func splitConfig(cfg otelconfig.Receiver) (otelconfig.Receiver, *TenantArguments) {
+	if tc, ok := cfg.(*tenantConfig); ok {
+		return tc.Config, tc.Tenant
+	}
+	return cfg, nil
+}
+
+// CreateTracesReceiver implements otelcomponent.ReceiverFactory.
+func (f *tenantFactory) CreateTracesReceiver(ctx context.Context, set otelcomponent.ReceiverCreateSettings, cfg otelconfig.Receiver, next consumer.Traces) (otelcomponent.TracesReceiver, error) {
+	upstream, tenant := splitConfig(cfg)
+	if tenant != nil {
+		next = &tenantTraces{next: next, args: tenant}
+	}
+	return f.ReceiverFactory.CreateTracesReceiver(ctx, set, upstream, next)
+}
+
+// CreateMetricsReceiver implements otelcomponent.ReceiverFactory.
+func (f *tenantFactory) CreateMetricsReceiver(ctx context.Context, set otelcomponent.ReceiverCreateSettings, cfg otelconfig.Receiver, next consumer.Metrics) (otelcomponent.MetricsReceiver, error) {
+	upstream, tenant := splitConfig(cfg)
+	if tenant != nil {
+		next = &tenantMetrics{next: next, args: tenant}
+	}
+	return f.ReceiverFactory.CreateMetricsReceiver(ctx, set, upstream, next)
+}
+
+// CreateLogsReceiver implements otelcomponent.ReceiverFactory.
+func (f *tenantFactory) CreateLogsReceiver(ctx context.Context, set otelcomponent.ReceiverCreateSettings, cfg otelconfig.Receiver, next consumer.Logs) (otelcomponent.LogsReceiver, error) {
+	upstream, tenant := splitConfig(cfg)
+	if tenant != nil {
+		next = &tenantLogs{next: next, args: tenant}
+	}
+	return f.ReceiverFactory.CreateLogsReceiver(ctx, set, upstream, next)
+}
+
+// tenantTraces writes the tenant of the client to the resource of received
+// spans. The attribute is overwritten if clients already set it, so clients
+// can't claim to be another tenant.
+type tenantTraces struct {
+	next consumer.Traces
+	args *TenantArguments
+}
+
+func (t *tenantTraces) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: true}
+}
+
+func (t *tenantTraces) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	tenant, ok := t.args.tenantOf(ctx)
+	if !ok {
+		return errUnknownTenant
+	}
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rss.At(i).Resource().Attributes().PutStr(t.args.Attribute, tenant)
+	}
+	return t.next.ConsumeTraces(ctx, td)
+}
+
+// tenantMetrics writes the tenant of the client to the resource of received
+// metrics.
+type tenantMetrics struct {
+	next consumer.Metrics
+	args *TenantArguments
+}
+
+func (t *tenantMetrics) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: true}
+}
+
+func (t *tenantMetrics) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	tenant, ok := t.args.tenantOf(ctx)
+	if !ok {
+		return errUnknownTenant
+	}
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rms.At(i).Resource().Attributes().PutStr(t.args.Attribute, tenant)
+	}
+	return t.next.ConsumeMetrics(ctx, md)
+}
+
+// tenantLogs writes the tenant of the client to the resource of received
+// logs.
+type tenantLogs struct {
+	next consumer.Logs
+	args *TenantArguments
+}
+
+func (t *tenantLogs) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: true}
+}
+
+func (t *tenantLogs) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	tenant, ok := t.args.tenantOf(ctx)
+	if !ok {
+		return errUnknownTenant
+	}
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rls.At(i).Resource().Attributes().PutStr(t.args.Attribute, tenant)
+	}
+	return t.next.ConsumeLogs(ctx, ld)
+}
@@ -0,0 +1,170 @@
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestTenantTraces(t *testing.T) {
+	args := &TenantArguments{
+		Attribute:          "tenant",
+		ClientCertificates: map[string]string{"collector-b": "team-b"},
+		ServerNames:        map[string]string{"team-a.otlp.example.com": "team-a"},
+	}
+
+	tt := []struct {
+		name       string
+		state      *tls.ConnectionState
+		expectTeam string
+	}{
+		{
+			name:       "server name",
+			state:      &tls.ConnectionState{ServerName: "TEAM-A.otlp.example.com"},
+			expectTeam: "team-a",
+		},
+		{
+			name: "verified client certificate takes precedence",
+			state: &tls.ConnectionState{
+				ServerName:     "team-a.otlp.example.com",
+				VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "collector-b"}}}},
+			},
+			expectTeam: "team-b",
+		},
+		{
+			name: "unverified client certificate is ignored",
+			state: &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "collector-b"}}},
+			},
+		},
+		{
+			name:  "unknown server name",
+			state: &tls.ConnectionState{ServerName: "other.example.com"},
+		},
+		{
+			name: "no TLS",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sink := new(consumertest.TracesSink)
+			consumer := &tenantTraces{next: sink, args: args}
+
+			ctx := context.Background()
+			if tc.state != nil {
+				ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: *tc.state}})
+			}
+
+			// Clients can't claim a tenant by setting the attribute themselves.
+			td := ptrace.NewTraces()
+			td.ResourceSpans().AppendEmpty().Resource().Attributes().PutStr("tenant", "spoofed")
+
+			err := consumer.ConsumeTraces(ctx, td)
+			if tc.expectTeam == "" {
+				require.True(t, consumererror.IsPermanent(err))
+				require.Empty(t, sink.AllTraces())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, sink.AllTraces(), 1)
+			tenant, ok := sink.AllTraces()[0].ResourceSpans().At(0).Resource().Attributes().Get("tenant")
+			require.True(t, ok)
+			require.Equal(t, tc.expectTeam, tenant.Str())
+		})
+	}
+}
+
+func TestTenantArguments(t *testing.T) {
+	tt := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			config: `
+				grpc {
+					tls {
+						cert_file = "/etc/tls/server.crt"
+						key_file  = "/etc/tls/server.key"
+					}
+				}
+				tenant {
+					server_names = { "team-a.otlp.example.com" = "team-a" }
+				}
+				output {}
+			`,
+		},
+		{
+			name: "no mappings",
+			config: `
+				grpc {
+					tls {
+						cert_file = "/etc/tls/server.crt"
+						key_file  = "/etc/tls/server.key"
+					}
+				}
+				tenant {}
+				output {}
+			`,
+			expectedErr: "at least one of client_certificates or server_names must be set",
+		},
+		{
+			name: "without tls",
+			config: `
+				grpc {}
+				tenant {
+					server_names = { "team-a.otlp.example.com" = "team-a" }
+				}
+				output {}
+			`,
+			expectedErr: "the tenant block requires the grpc block to configure tls",
+		},
+		{
+			name: "with http",
+			config: `
+				grpc {
+					tls {
+						cert_file = "/etc/tls/server.crt"
+						key_file  = "/etc/tls/server.key"
+					}
+				}
+				http {}
+				tenant {
+					server_names = { "team-a.otlp.example.com" = "team-a" }
+				}
+				output {}
+			`,
+			expectedErr: "the tenant block can't be used with the http block",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "tenant", args.Tenant.Attribute)
+
+			cfg, err := args.Convert()
+			require.NoError(t, err)
+			require.IsType(t, &tenantConfig{}, cfg)
+		})
+	}
+}
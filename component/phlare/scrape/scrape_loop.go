@@ -39,7 +39,7 @@ type scrapePool struct {
 }
 
 func newScrapePool(cfg Arguments, appendable phlare.Appendable, logger log.Logger) (*scrapePool, error) {
-	scrapeClient, err := commonconfig.NewClientFromConfig(*cfg.HTTPClientConfig.Convert(), cfg.JobName)
+	scrapeClient, err := commonconfig.NewClientFromConfig(*cfg.HTTPClientConfig.Convert(), cfg.JobName, cfg.HTTPClientConfig.ClientOptions()...)
 	if err != nil {
 		return nil, err
 	}
@@ -110,7 +110,7 @@ func (tg *scrapePool) reload(cfg Arguments) error {
 	}
 	tg.config = cfg
 
-	scrapeClient, err := commonconfig.NewClientFromConfig(*cfg.HTTPClientConfig.Convert(), cfg.JobName)
+	scrapeClient, err := commonconfig.NewClientFromConfig(*cfg.HTTPClientConfig.Convert(), cfg.JobName, cfg.HTTPClientConfig.ClientOptions()...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,108 @@
+package watchdog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// profileExt is the extension of stored profiles, which are gzipped pprof
+// protobufs.
+const profileExt = ".pb.gz"
+
+// timeFormat is used in the names of stored profiles. Its fixed width makes
+// names of the same profile type sort by time.
+const timeFormat = "20060102T150405.000Z"
+
+// store keeps the most recent profiles of each type in a directory.
+type store struct {
+	dir string
+
+	mut  sync.Mutex
+	keep int
+}
+
+func newStore(dir string, keep int) *store {
+	return &store{dir: dir, keep: keep}
+}
+
+// SetKeep changes the number of profiles kept per type. Excess profiles are
+// deleted when the next profile is saved.
+func (s *store) SetKeep(keep int) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.keep = keep
+}
+
+// storedProfile is a profile which was saved to the store.
+type storedProfile struct {
+	Type string
+	Path string
+}
+
+// Save writes a profile captured at t to the store and deletes the oldest
+// profiles of the same type which exceed the number of profiles to keep.
+func (s *store) Save(profileType string, t time.Time, b []byte) (string, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	path := filepath.Join(s.dir, profileType+"-"+t.UTC().Format(timeFormat)+profileExt)
+	if err := os.WriteFile(path, b, 0640); err != nil {
+		return "", err
+	}
+
+	profiles, err := s.list()
+	if err != nil {
+		return path, err
+	}
+	var ofType []storedProfile
+	for _, p := range profiles {
+		if p.Type == profileType {
+			ofType = append(ofType, p)
+		}
+	}
+	for len(ofType) > s.keep {
+		if err := os.Remove(ofType[0].Path); err != nil {
+			return path, fmt.Errorf("deleting old profile: %w", err)
+		}
+		ofType = ofType[1:]
+	}
+	return path, nil
+}
+
+// List returns the stored profiles, sorted by type and time.
+func (s *store) List() []storedProfile {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	profiles, _ := s.list()
+	return profiles
+}
+
+func (s *store) list() ([]storedProfile, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []storedProfile
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, profileExt) {
+			continue
+		}
+		profileType, _, ok := strings.Cut(name, "-")
+		if !ok {
+			continue
+		}
+		profiles = append(profiles, storedProfile{Type: profileType, Path: filepath.Join(s.dir, name)})
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Path < profiles[j].Path
+	})
+	return profiles, nil
+}
@@ -0,0 +1,337 @@
+// Package watchdog implements the phlare.watchdog component.
+package watchdog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/phlare"
+	"github.com/grafana/agent/pkg/river"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Profile types captured by the watchdog. The names match the profile names
+// used by phlare.scrape.
+const (
+	profileMemory     = "memory"
+	profileProcessCPU = "process_cpu"
+)
+
+// serviceName is the value of the service_name label of forwarded profiles.
+const serviceName = "grafana-agent"
+
+func init() {
+	component.Register(component.Registration{
+		Name: "phlare.watchdog",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the phlare.watchdog
+// component.
+type Arguments struct {
+	ForwardTo []phlare.Appendable `river:"forward_to,attr,optional"`
+
+	CheckInterval        time.Duration `river:"check_interval,attr,optional"`
+	MemoryThresholdBytes uint64        `river:"memory_threshold_bytes,attr,optional"`
+	CPUThreshold         float64       `river:"cpu_threshold,attr,optional"`
+	CPUProfileDuration   time.Duration `river:"cpu_profile_duration,attr,optional"`
+	Cooldown             time.Duration `river:"cooldown,attr,optional"`
+	Keep                 int           `river:"keep,attr,optional"`
+}
+
+// DefaultArguments holds the default settings for the phlare.watchdog
+// component.
+var DefaultArguments = Arguments{
+	CheckInterval:      15 * time.Second,
+	CPUProfileDuration: 10 * time.Second,
+	Cooldown:           5 * time.Minute,
+	Keep:               5,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	return f((*arguments)(args))
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	var errs river.ValidationErrors
+	if args.MemoryThresholdBytes == 0 && args.CPUThreshold == 0 {
+		errs.Add(fmt.Errorf("at least one of memory_threshold_bytes or cpu_threshold must be set"))
+	}
+	if args.CPUThreshold < 0 {
+		errs.Add(river.PathError("cpu_threshold", fmt.Errorf("cpu_threshold must not be negative")))
+	}
+	if args.CheckInterval <= 0 {
+		errs.Add(river.PathError("check_interval", fmt.Errorf("check_interval must be greater than 0")))
+	}
+	if args.CPUProfileDuration <= 0 {
+		errs.Add(river.PathError("cpu_profile_duration", fmt.Errorf("cpu_profile_duration must be greater than 0")))
+	}
+	if args.Cooldown < 0 {
+		errs.Add(river.PathError("cooldown", fmt.Errorf("cooldown must not be negative")))
+	}
+	if args.Keep <= 0 {
+		errs.Add(river.PathError("keep", fmt.Errorf("keep must be greater than 0")))
+	}
+	return errs.ErrorOrNil()
+}
+
+// usage is the resource usage of the process at a point in time.
+type usage struct {
+	CPUSeconds float64 // Total CPU time used by the process.
+	RSSBytes   uint64
+}
+
+// Component implements the phlare.watchdog component.
+type Component struct {
+	opts   component.Options
+	fanout *phlare.Fanout
+	store  *store
+
+	captures        *prometheus_client.CounterVec
+	captureFailures *prometheus_client.CounterVec
+
+	// Overridden by tests.
+	readUsage  func() (usage, error)
+	heapWriter func(w *bytes.Buffer) error
+	cpuWriter  func(ctx context.Context, w *bytes.Buffer, d time.Duration) error
+
+	mut  sync.Mutex
+	args Arguments
+
+	// Only accessed by Run.
+	lastUsage   usage
+	lastCheck   time.Time
+	lastCapture map[string]time.Time
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new phlare.watchdog component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	if err := os.MkdirAll(opts.DataPath, 0750); err != nil {
+		return nil, fmt.Errorf("creating profile directory: %w", err)
+	}
+
+	c := &Component{
+		opts:        opts,
+		fanout:      phlare.NewFanout(args.ForwardTo, opts.ID, opts.Registerer),
+		store:       newStore(opts.DataPath, args.Keep),
+		readUsage:   readProcessUsage,
+		heapWriter:  writeHeapProfile,
+		cpuWriter:   writeCPUProfile,
+		lastCapture: make(map[string]time.Time),
+	}
+
+	c.captures = prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+		Name: "agent_phlare_watchdog_captures_total",
+		Help: "Total number of profiles captured because a threshold was crossed",
+	}, []string{"type"})
+	c.captureFailures = prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+		Name: "agent_phlare_watchdog_capture_failures_total",
+		Help: "Total number of profiles which couldn't be captured, stored, or forwarded",
+	}, []string{"type"})
+	for _, metric := range []prometheus_client.Collector{c.captures, c.captureFailures} {
+		if err := opts.Registerer.Register(metric); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	interval := c.getArgs().CheckInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			c.check(ctx, now)
+
+			if newInterval := c.getArgs().CheckInterval; newInterval != interval {
+				interval = newInterval
+				ticker.Reset(interval)
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.args = newArgs
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+	c.store.SetKeep(newArgs.Keep)
+	return nil
+}
+
+func (c *Component) getArgs() Arguments {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.args
+}
+
+// check reads the resource usage of the process and captures profiles for
+// the thresholds which were crossed. CPU usage is averaged over the time
+// since the previous check, so CPU profiles are never captured on the first
+// check.
+func (c *Component) check(ctx context.Context, now time.Time) {
+	args := c.getArgs()
+
+	u, err := c.readUsage()
+	if err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to read resource usage", "err", err)
+		return
+	}
+
+	var cpuUsage float64
+	if !c.lastCheck.IsZero() {
+		if elapsed := now.Sub(c.lastCheck).Seconds(); elapsed > 0 {
+			cpuUsage = (u.CPUSeconds - c.lastUsage.CPUSeconds) / elapsed
+		}
+	}
+	c.lastUsage, c.lastCheck = u, now
+
+	if args.MemoryThresholdBytes > 0 && u.RSSBytes >= args.MemoryThresholdBytes && c.cooledDown(profileMemory, now, args.Cooldown) {
+		level.Info(c.opts.Logger).Log("msg", "memory usage crossed threshold, capturing heap profile", "rss_bytes", u.RSSBytes, "threshold_bytes", args.MemoryThresholdBytes)
+		c.capture(ctx, profileMemory, now, c.heapWriter)
+	}
+
+	if args.CPUThreshold > 0 && cpuUsage >= args.CPUThreshold && c.cooledDown(profileProcessCPU, now, args.Cooldown) {
+		level.Info(c.opts.Logger).Log("msg", "CPU usage crossed threshold, capturing CPU profile", "cpu_cores", cpuUsage, "threshold_cores", args.CPUThreshold)
+		c.capture(ctx, profileProcessCPU, now, func(w *bytes.Buffer) error {
+			return c.cpuWriter(ctx, w, args.CPUProfileDuration)
+		})
+	}
+}
+
+func (c *Component) cooledDown(profileType string, now time.Time, cooldown time.Duration) bool {
+	last, ok := c.lastCapture[profileType]
+	return !ok || now.Sub(last) >= cooldown
+}
+
+// capture captures a profile with write, stores it, and forwards it to the
+// receivers of the component.
+func (c *Component) capture(ctx context.Context, profileType string, now time.Time, write func(w *bytes.Buffer) error) {
+	// Cooldowns start even if the capture fails, so that a failing capture
+	// isn't retried on every check.
+	c.lastCapture[profileType] = now
+
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		c.captureFailures.WithLabelValues(profileType).Inc()
+		level.Error(c.opts.Logger).Log("msg", "failed to capture profile", "type", profileType, "err", err)
+		return
+	}
+	c.captures.WithLabelValues(profileType).Inc()
+
+	path, err := c.store.Save(profileType, now, buf.Bytes())
+	if err != nil {
+		c.captureFailures.WithLabelValues(profileType).Inc()
+		level.Error(c.opts.Logger).Log("msg", "failed to store profile", "type", profileType, "err", err)
+	} else {
+		level.Info(c.opts.Logger).Log("msg", "stored profile", "type", profileType, "path", path)
+	}
+
+	lbls := labels.FromStrings(
+		labels.MetricName, profileType,
+		"service_name", serviceName,
+	)
+	if err := c.fanout.Appender().Append(ctx, lbls, []*phlare.RawSample{{RawProfile: buf.Bytes()}}); err != nil {
+		c.captureFailures.WithLabelValues(profileType).Inc()
+		level.Error(c.opts.Logger).Log("msg", "failed to forward profile", "type", profileType, "err", err)
+	}
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	var info debugInfo
+	for _, p := range c.store.List() {
+		info.Profiles = append(info.Profiles, debugProfile{
+			Type: p.Type,
+			Path: p.Path,
+		})
+	}
+	return info
+}
+
+type debugInfo struct {
+	Profiles []debugProfile `river:"profile,block,optional"`
+}
+
+type debugProfile struct {
+	Type string `river:"type,attr"`
+	Path string `river:"path,attr"`
+}
+
+func readProcessUsage() (usage, error) {
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return usage{}, err
+	}
+	ts, err := proc.Times()
+	if err != nil {
+		return usage{}, err
+	}
+	mi, err := proc.MemoryInfo()
+	if err != nil {
+		return usage{}, err
+	}
+	return usage{CPUSeconds: ts.User + ts.System, RSSBytes: mi.RSS}, nil
+}
+
+func writeHeapProfile(w *bytes.Buffer) error {
+	return pprof.Lookup("heap").WriteTo(w, 0)
+}
+
+// writeCPUProfile profiles the CPU for d. It fails if another CPU profile,
+// such as one requested from /debug/pprof/profile, is already running.
+func writeCPUProfile(ctx context.Context, w *bytes.Buffer, d time.Duration) error {
+	if err := pprof.StartCPUProfile(w); err != nil {
+		return err
+	}
+	defer pprof.StopCPUProfile()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
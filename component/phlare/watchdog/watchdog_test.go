@@ -0,0 +1,100 @@
+package watchdog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/phlare"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverConfig(t *testing.T) {
+	in := `
+		memory_threshold_bytes = 1073741824
+		cpu_threshold          = 1.5
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(in), &args))
+	require.Equal(t, uint64(1<<30), args.MemoryThresholdBytes)
+	require.Equal(t, 5, args.Keep)
+
+	err := river.Unmarshal([]byte(`keep = 3`), &args)
+	require.ErrorContains(t, err, "at least one of memory_threshold_bytes or cpu_threshold must be set")
+}
+
+func TestCheck(t *testing.T) {
+	var forwarded []labels.Labels
+	receiver := phlare.AppendableFunc(func(_ context.Context, lbls labels.Labels, _ []*phlare.RawSample) error {
+		forwarded = append(forwarded, lbls)
+		return nil
+	})
+
+	dir := t.TempDir()
+	c, err := New(component.Options{
+		ID:         "phlare.watchdog.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prometheus.NewRegistry(),
+		DataPath:   dir,
+	}, Arguments{
+		ForwardTo:            []phlare.Appendable{receiver},
+		CheckInterval:        time.Second,
+		MemoryThresholdBytes: 100,
+		CPUThreshold:         1,
+		CPUProfileDuration:   time.Second,
+		Cooldown:             time.Minute,
+		Keep:                 2,
+	})
+	require.NoError(t, err)
+
+	var current usage
+	c.readUsage = func() (usage, error) { return current, nil }
+	c.heapWriter = func(w *bytes.Buffer) error { _, err := w.WriteString("heap"); return err }
+	c.cpuWriter = func(_ context.Context, w *bytes.Buffer, _ time.Duration) error {
+		_, err := w.WriteString("cpu")
+		return err
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// Below the thresholds.
+	current = usage{CPUSeconds: 10, RSSBytes: 50}
+	c.check(ctx, now)
+	require.Empty(t, c.store.List())
+
+	// Both thresholds are crossed: 2 CPU seconds were used in the last second.
+	now = now.Add(time.Second)
+	current = usage{CPUSeconds: 12, RSSBytes: 150}
+	c.check(ctx, now)
+	require.Len(t, c.store.List(), 2)
+	require.Equal(t, []labels.Labels{
+		labels.FromStrings("__name__", "memory", "service_name", "grafana-agent"),
+		labels.FromStrings("__name__", "process_cpu", "service_name", "grafana-agent"),
+	}, forwarded)
+
+	// Profiles aren't captured again until the cooldown passed.
+	now = now.Add(time.Second)
+	current = usage{CPUSeconds: 14, RSSBytes: 150}
+	c.check(ctx, now)
+	require.Len(t, c.store.List(), 2)
+
+	// Only the most recent heap profiles are kept.
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Minute)
+		c.check(ctx, now)
+	}
+	var heapProfiles int
+	for _, p := range c.store.List() {
+		if p.Type == profileMemory {
+			heapProfiles++
+		}
+	}
+	require.Equal(t, 2, heapProfiles)
+}
@@ -161,10 +161,10 @@ type fanOutClient struct {
 }
 
 // NewFanOut creates a new fan out client that will fan out to all endpoints.
-func NewFanOut(opts component.Options, config Arguments, metrics *metrics) (*fanOutClient, error) {
-	clients := make([]pushv1connect.PusherServiceClient, 0, len(config.Endpoints))
-	for _, endpoint := range config.Endpoints {
-		httpClient, err := commonconfig.NewClientFromConfig(*endpoint.HTTPClientConfig.Convert(), endpoint.Name)
+func NewFanOut(opts component.Options, args Arguments, metrics *metrics) (*fanOutClient, error) {
+	clients := make([]pushv1connect.PusherServiceClient, 0, len(args.Endpoints))
+	for _, endpoint := range args.Endpoints {
+		httpClient, err := commonconfig.NewClientFromConfig(*endpoint.HTTPClientConfig.Convert(), endpoint.Name, endpoint.HTTPClientConfig.ClientOptions()...)
 		if err != nil {
 			return nil, err
 		}
@@ -172,7 +172,7 @@ func NewFanOut(opts component.Options, config Arguments, metrics *metrics) (*fan
 	}
 	return &fanOutClient{
 		clients: clients,
-		config:  config,
+		config:  args,
 		opts:    opts,
 		metrics: metrics,
 	}, nil
@@ -0,0 +1,367 @@
+// Package aggregate implements the prometheus.aggregate component.
+package aggregate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/storage"
+	"go.uber.org/atomic"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.aggregate",
+		Args:    Arguments{},
+		Exports: Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the prometheus.aggregate
+// component.
+type Arguments struct {
+	// Where the aggregated and remaining metrics should be forwarded to.
+	ForwardTo []storage.Appendable `river:"forward_to,attr"`
+
+	Interval time.Duration `river:"interval,attr,optional"`
+	Window   time.Duration `river:"window,attr,optional"`
+	Rules    []Rule        `river:"rule,block,optional"`
+}
+
+// DefaultArguments holds the default settings for the prometheus.aggregate
+// component.
+var DefaultArguments = Arguments{
+	Interval: time.Minute,
+	Window:   5 * time.Minute,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	return f((*arguments)(args))
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	var errs river.ValidationErrors
+	if args.Interval <= 0 {
+		errs.Add(river.PathError("interval", fmt.Errorf("interval must be greater than 0")))
+	}
+	if args.Window <= 0 {
+		errs.Add(river.PathError("window", fmt.Errorf("window must be greater than 0")))
+	}
+
+	records := make(map[string]struct{}, len(args.Rules))
+	for i, r := range args.Rules {
+		if err := r.validate(); err != nil {
+			errs.Add(river.PathError(fmt.Sprintf("rule[%d]", i), err))
+			continue
+		}
+		if _, ok := records[r.Record]; ok {
+			errs.Add(river.PathError(fmt.Sprintf("rule[%d]", i), fmt.Errorf("record %q is used by another rule", r.Record)))
+		}
+		records[r.Record] = struct{}{}
+	}
+	return errs.ErrorOrNil()
+}
+
+// Exports holds values which are exported by the prometheus.aggregate
+// component.
+type Exports struct {
+	Receiver storage.Appendable `river:"receiver,attr"`
+}
+
+// Component implements the prometheus.aggregate component.
+type Component struct {
+	opts     component.Options
+	receiver *prometheus.Interceptor
+	fanout   *prometheus.Fanout
+	exited   atomic.Bool
+
+	samplesAggregated prometheus_client.Counter
+	samplesDropped    prometheus_client.Counter
+	seriesWritten     prometheus_client.Counter
+	evaluationErrors  prometheus_client.Counter
+
+	mut          sync.Mutex
+	args         Arguments
+	aggregations []*aggregation
+	update       chan struct{} // Signals that the interval changed.
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new prometheus.aggregate component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:   o,
+		update: make(chan struct{}, 1),
+	}
+
+	c.samplesAggregated = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_aggregate_samples_aggregated_total",
+		Help: "Total number of samples matched by at least one rule",
+	})
+	c.samplesDropped = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_aggregate_samples_dropped_total",
+		Help: "Total number of aggregated samples which weren't forwarded",
+	})
+	c.seriesWritten = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_aggregate_series_written_total",
+		Help: "Total number of aggregated samples written",
+	})
+	c.evaluationErrors = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_aggregate_evaluation_failures_total",
+		Help: "Total number of evaluations whose samples couldn't be written",
+	})
+	for _, metric := range []prometheus_client.Collector{c.samplesAggregated, c.samplesDropped, c.seriesWritten, c.evaluationErrors} {
+		if err := o.Registerer.Register(metric); err != nil {
+			return nil, err
+		}
+	}
+
+	c.fanout = prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer)
+	c.receiver = prometheus.NewInterceptor(
+		c.fanout,
+		prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
+			if err := c.check(); err != nil {
+				return 0, err
+			}
+			if !c.observe(l, t, v) {
+				return 0, nil
+			}
+			return next.Append(ref, l, t, v)
+		}),
+		prometheus.WithAppendHistogram(func(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram, next storage.Appender) (storage.SeriesRef, error) {
+			// Native histograms can't be aggregated and are always forwarded.
+			if err := c.check(); err != nil {
+				return 0, err
+			}
+			return next.AppendHistogram(ref, l, t, h, fh)
+		}),
+		prometheus.WithExemplarHook(func(ref storage.SeriesRef, l labels.Labels, e exemplar.Exemplar, next storage.Appender) (storage.SeriesRef, error) {
+			if err := c.check(); err != nil {
+				return 0, err
+			}
+			if !c.forwarded(l) {
+				return 0, nil
+			}
+			return next.AppendExemplar(ref, l, e)
+		}),
+		prometheus.WithMetadataHook(func(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata, next storage.Appender) (storage.SeriesRef, error) {
+			if err := c.check(); err != nil {
+				return 0, err
+			}
+			if !c.forwarded(l) {
+				return 0, nil
+			}
+			return next.UpdateMetadata(ref, l, m)
+		}),
+	)
+
+	// Immediately export the receiver which remains the same for the component
+	// lifetime.
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer c.exited.Store(true)
+
+	ticker := time.NewTicker(c.interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.update:
+			ticker.Reset(c.interval())
+		case now := <-ticker.C:
+			if err := c.evaluate(ctx, now); err != nil {
+				c.evaluationErrors.Inc()
+				level.Error(c.opts.Logger).Log("msg", "failed to write aggregated series", "err", err)
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	// Keep the state of rules which didn't change, so that updating the
+	// component doesn't cause a gap in the aggregated series.
+	aggregations := make([]*aggregation, 0, len(newArgs.Rules))
+	for _, r := range newArgs.Rules {
+		if a := c.findAggregation(r); a != nil {
+			aggregations = append(aggregations, a)
+			continue
+		}
+		a, err := newAggregation(r)
+		if err != nil {
+			return err
+		}
+		aggregations = append(aggregations, a)
+	}
+
+	intervalChanged := c.args.Interval != newArgs.Interval
+	c.args = newArgs
+	c.aggregations = aggregations
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	if intervalChanged {
+		select {
+		case c.update <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// findAggregation returns the existing aggregation for r, if any. c.mut must
+// be held when calling findAggregation.
+func (c *Component) findAggregation(r Rule) *aggregation {
+	for _, a := range c.aggregations {
+		if reflect.DeepEqual(a.rule, r) {
+			return a
+		}
+	}
+	return nil
+}
+
+func (c *Component) interval() time.Duration {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.args.Interval
+}
+
+func (c *Component) check() error {
+	if c.exited.Load() {
+		return fmt.Errorf("%s has exited", c.opts.ID)
+	}
+	return nil
+}
+
+// observe passes a sample to all rules matching its series. It returns true
+// if the sample should be forwarded, which is the case unless it's matched by
+// a rule which doesn't keep its source series.
+func (c *Component) observe(lbls labels.Labels, t int64, v float64) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	matched, forward := false, true
+	for _, a := range c.aggregations {
+		if !a.Matches(lbls) {
+			continue
+		}
+		a.Observe(lbls, t, v)
+		matched = true
+		forward = forward && a.rule.KeepSource
+	}
+
+	if matched {
+		c.samplesAggregated.Inc()
+		if !forward {
+			c.samplesDropped.Inc()
+		}
+	}
+	return forward
+}
+
+// forwarded is like observe, but doesn't record a sample.
+func (c *Component) forwarded(lbls labels.Labels) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	for _, a := range c.aggregations {
+		if a.Matches(lbls) && !a.rule.KeepSource {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluate writes the aggregated series of all rules with now as timestamp.
+func (c *Component) evaluate(ctx context.Context, now time.Time) error {
+	c.mut.Lock()
+	minTimestamp := timestamp.FromTime(now.Add(-c.args.Window))
+	var samples []Sample
+	for _, a := range c.aggregations {
+		samples = append(samples, a.Evaluate(minTimestamp)...)
+	}
+	c.mut.Unlock()
+
+	if len(samples) == 0 {
+		return nil
+	}
+
+	ts := timestamp.FromTime(now)
+	app := c.fanout.Appender(ctx)
+	for _, s := range samples {
+		if _, err := app.Append(0, s.Labels, ts, s.Value); err != nil {
+			_ = app.Rollback()
+			return err
+		}
+	}
+	if err := app.Commit(); err != nil {
+		return err
+	}
+	c.seriesWritten.Add(float64(len(samples)))
+	return nil
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var info debugInfo
+	for _, a := range c.aggregations {
+		info.Rules = append(info.Rules, debugRule{
+			Record:          a.rule.Record,
+			TrackedSeries:   a.Len(),
+			AggregateSeries: len(a.last),
+		})
+	}
+	return info
+}
+
+type debugInfo struct {
+	Rules []debugRule `river:"rule,block,optional"`
+}
+
+type debugRule struct {
+	Record          string `river:"record,attr"`
+	TrackedSeries   int    `river:"tracked_series,attr"`
+	AggregateSeries int    `river:"aggregate_series,attr"`
+}
@@ -0,0 +1,171 @@
+package aggregate
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/util"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverConfig(t *testing.T) {
+	in := `
+		forward_to = []
+
+		rule {
+			record = "job:http_requests_total:sum"
+			series = "http_requests_total"
+			by     = ["job"]
+		}
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(in), &args))
+	require.Equal(t, time.Minute, args.Interval)
+	require.Equal(t, OperationSum, args.Rules[0].Operation)
+
+	in = `
+		forward_to = []
+
+		rule {
+			record    = "job:http_requests_total:sum"
+			series    = "http_requests_total"
+			operation = "median"
+		}
+	`
+	err := river.Unmarshal([]byte(in), &args)
+	var diags diag.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Contains(t, diags[0].Message, `unsupported operation "median"`)
+}
+
+func TestAggregation(t *testing.T) {
+	tt := []struct {
+		name     string
+		rule     Rule
+		expected []Sample
+	}{
+		{
+			name: "sum by",
+			rule: Rule{Operation: OperationSum, By: []string{"job"}},
+			expected: []Sample{
+				{Labels: labels.FromStrings("__name__", "agg", "job", "a"), Value: 3},
+				{Labels: labels.FromStrings("__name__", "agg", "job", "b"), Value: 4},
+			},
+		},
+		{
+			name: "avg without",
+			rule: Rule{Operation: OperationAvg, Without: []string{"instance"}},
+			expected: []Sample{
+				{Labels: labels.FromStrings("__name__", "agg", "job", "a"), Value: 1.5},
+				{Labels: labels.FromStrings("__name__", "agg", "job", "b"), Value: 4},
+			},
+		},
+		{
+			name:     "max",
+			rule:     Rule{Operation: OperationMax},
+			expected: []Sample{{Labels: labels.FromStrings("__name__", "agg"), Value: 4}},
+		},
+		{
+			name:     "count",
+			rule:     Rule{Operation: OperationCount},
+			expected: []Sample{{Labels: labels.FromStrings("__name__", "agg"), Value: 3}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.rule.Record = "agg"
+			tc.rule.Series = "requests"
+			a, err := newAggregation(tc.rule)
+			require.NoError(t, err)
+
+			a.Observe(labels.FromStrings("__name__", "requests", "job", "a", "instance", "1"), 10, 5)
+			a.Observe(labels.FromStrings("__name__", "requests", "job", "a", "instance", "1"), 20, 1)
+			a.Observe(labels.FromStrings("__name__", "requests", "job", "a", "instance", "2"), 20, 2)
+			a.Observe(labels.FromStrings("__name__", "requests", "job", "b", "instance", "1"), 20, 4)
+			require.Equal(t, tc.expected, a.Evaluate(0))
+		})
+	}
+}
+
+func TestAggregation_Staleness(t *testing.T) {
+	a, err := newAggregation(Rule{Record: "agg", Series: "requests", Operation: OperationSum, By: []string{"job"}})
+	require.NoError(t, err)
+
+	a.Observe(labels.FromStrings("__name__", "requests", "job", "a"), 10, 1)
+	a.Observe(labels.FromStrings("__name__", "requests", "job", "b"), 20, 2)
+	require.Len(t, a.Evaluate(0), 2)
+
+	// The series of job a is too old, so its aggregated series becomes stale.
+	res := a.Evaluate(15)
+	require.Len(t, res, 2)
+	require.True(t, value.IsStaleNaN(res[0].Value))
+	require.Equal(t, 2.0, res[1].Value)
+	require.Equal(t, 1, a.Len())
+
+	// A stale marker removes the series immediately.
+	a.Observe(labels.FromStrings("__name__", "requests", "job", "b"), 30, math.Float64frombits(value.StaleNaN))
+	res = a.Evaluate(0)
+	require.Len(t, res, 1)
+	require.True(t, value.IsStaleNaN(res[0].Value))
+	require.Empty(t, a.Evaluate(0))
+}
+
+func TestComponent(t *testing.T) {
+	var received []labels.Labels
+	var values []float64
+	next := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, v float64, _ storage.Appender) (storage.SeriesRef, error) {
+		received = append(received, l)
+		values = append(values, v)
+		return ref, nil
+	}))
+
+	c, err := New(component.Options{
+		ID:            "prometheus.aggregate.test",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+		Registerer:    prom.NewRegistry(),
+	}, Arguments{
+		ForwardTo: []storage.Appendable{next},
+		Interval:  time.Minute,
+		Window:    5 * time.Minute,
+		Rules: []Rule{{
+			Record:    "job:requests:sum",
+			Series:    "requests",
+			Operation: OperationSum,
+			By:        []string{"job"},
+		}},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	app := c.receiver.Appender(context.Background())
+	for _, l := range []labels.Labels{
+		labels.FromStrings("__name__", "up", "job", "a"),
+		labels.FromStrings("__name__", "requests", "job", "a", "instance", "1"),
+		labels.FromStrings("__name__", "requests", "job", "a", "instance", "2"),
+	} {
+		_, err := app.Append(0, l, timestamp.FromTime(now), 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	// Only the series which isn't aggregated is forwarded immediately.
+	require.Equal(t, []labels.Labels{labels.FromStrings("__name__", "up", "job", "a")}, received)
+
+	received, values = nil, nil
+	require.NoError(t, c.evaluate(context.Background(), now))
+	require.Equal(t, []labels.Labels{labels.FromStrings("__name__", "job:requests:sum", "job", "a")}, received)
+	require.Equal(t, []float64{2}, values)
+}
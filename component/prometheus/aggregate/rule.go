@@ -0,0 +1,224 @@
+package aggregate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// Supported aggregation operations.
+const (
+	OperationSum   = "sum"
+	OperationAvg   = "avg"
+	OperationMin   = "min"
+	OperationMax   = "max"
+	OperationCount = "count"
+)
+
+// Rule configures an aggregation of the series matched by a series selector.
+type Rule struct {
+	Record     string   `river:"record,attr"`
+	Series     string   `river:"series,attr"`
+	Operation  string   `river:"operation,attr,optional"`
+	By         []string `river:"by,attr,optional"`
+	Without    []string `river:"without,attr,optional"`
+	KeepSource bool     `river:"keep_source,attr,optional"`
+}
+
+// DefaultRule holds the default settings of a Rule.
+var DefaultRule = Rule{
+	Operation: OperationSum,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (r *Rule) UnmarshalRiver(f func(interface{}) error) error {
+	*r = DefaultRule
+
+	type rule Rule
+	return f((*rule)(r))
+}
+
+func (r *Rule) validate() error {
+	if !model.IsValidMetricName(model.LabelValue(r.Record)) {
+		return fmt.Errorf("invalid metric name %q for record", r.Record)
+	}
+	if _, err := parser.ParseMetricSelector(r.Series); err != nil {
+		return fmt.Errorf("invalid series selector %q: %w", r.Series, err)
+	}
+	switch r.Operation {
+	case OperationSum, OperationAvg, OperationMin, OperationMax, OperationCount:
+	default:
+		return fmt.Errorf("unsupported operation %q", r.Operation)
+	}
+	if len(r.By) > 0 && len(r.Without) > 0 {
+		return fmt.Errorf("by and without can't both be set")
+	}
+	return nil
+}
+
+// aggregation holds the state of a Rule. It tracks the latest value of every
+// matching series, which are aggregated when the rule is evaluated.
+//
+// aggregation isn't safe for concurrent use.
+type aggregation struct {
+	rule     Rule
+	matchers []*labels.Matcher
+
+	series map[uint64]*seriesState  // Matching series by label hash.
+	last   map[uint64]labels.Labels // Series written by the last evaluation.
+}
+
+type seriesState struct {
+	group     labels.Labels
+	timestamp int64
+	value     float64
+}
+
+func newAggregation(r Rule) (*aggregation, error) {
+	matchers, err := parser.ParseMetricSelector(r.Series)
+	if err != nil {
+		return nil, err
+	}
+	return &aggregation{
+		rule:     r,
+		matchers: matchers,
+		series:   make(map[uint64]*seriesState),
+		last:     make(map[uint64]labels.Labels),
+	}, nil
+}
+
+// Matches returns true if the series with the given labels is aggregated by
+// a.
+func (a *aggregation) Matches(lbls labels.Labels) bool {
+	for _, m := range a.matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Observe records a sample of a matching series. Stale markers remove the
+// series from the aggregation.
+func (a *aggregation) Observe(lbls labels.Labels, t int64, v float64) {
+	hash := lbls.Hash()
+	if value.IsStaleNaN(v) {
+		delete(a.series, hash)
+		return
+	}
+
+	s, ok := a.series[hash]
+	if !ok {
+		s = &seriesState{group: a.groupLabels(lbls), timestamp: math.MinInt64}
+		a.series[hash] = s
+	}
+	if t >= s.timestamp {
+		s.timestamp, s.value = t, v
+	}
+}
+
+// groupLabels returns the labels of the aggregated series which lbls
+// contributes to. Like in PromQL, all series are aggregated into a single
+// series if neither by nor without is set.
+func (a *aggregation) groupLabels(lbls labels.Labels) labels.Labels {
+	b := labels.NewBuilder(lbls)
+	if len(a.rule.Without) > 0 {
+		b.Del(a.rule.Without...)
+	} else {
+		b.Keep(a.rule.By...)
+	}
+	b.Set(labels.MetricName, a.rule.Record)
+	return b.Labels(nil)
+}
+
+// Sample is a sample of an aggregated series.
+type Sample struct {
+	Labels labels.Labels
+	Value  float64
+}
+
+// Evaluate aggregates the latest values of all series which received a sample
+// at or after minTimestamp, and forgets about the other series. Aggregated
+// series which were returned by the previous evaluation but no longer have
+// any series are returned with a stale marker as value.
+func (a *aggregation) Evaluate(minTimestamp int64) []Sample {
+	type group struct {
+		labels labels.Labels
+		values []float64
+	}
+	groups := make(map[uint64]*group)
+
+	for hash, s := range a.series {
+		if s.timestamp < minTimestamp {
+			delete(a.series, hash)
+			continue
+		}
+		groupHash := s.group.Hash()
+		g, ok := groups[groupHash]
+		if !ok {
+			g = &group{labels: s.group}
+			groups[groupHash] = g
+		}
+		g.values = append(g.values, s.value)
+	}
+
+	res := make([]Sample, 0, len(groups)+len(a.last))
+	current := make(map[uint64]labels.Labels, len(groups))
+	for hash, g := range groups {
+		res = append(res, Sample{Labels: g.labels, Value: aggregate(a.rule.Operation, g.values)})
+		current[hash] = g.labels
+	}
+	for hash, lbls := range a.last {
+		if _, ok := current[hash]; !ok {
+			res = append(res, Sample{Labels: lbls, Value: math.Float64frombits(value.StaleNaN)})
+		}
+	}
+	a.last = current
+
+	sort.Slice(res, func(i, j int) bool {
+		return labels.Compare(res[i].Labels, res[j].Labels) < 0
+	})
+	return res
+}
+
+// Len returns the number of series tracked by a.
+func (a *aggregation) Len() int {
+	return len(a.series)
+}
+
+func aggregate(op string, values []float64) float64 {
+	switch op {
+	case OperationCount:
+		return float64(len(values))
+	case OperationMin:
+		res := values[0]
+		for _, v := range values[1:] {
+			if v < res || math.IsNaN(res) {
+				res = v
+			}
+		}
+		return res
+	case OperationMax:
+		res := values[0]
+		for _, v := range values[1:] {
+			if v > res || math.IsNaN(res) {
+				res = v
+			}
+		}
+		return res
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	if op == OperationAvg {
+		return sum / float64(len(values))
+	}
+	return sum
+}
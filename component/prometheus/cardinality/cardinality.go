@@ -0,0 +1,275 @@
+// Package cardinality implements the prometheus.cardinality component.
+package cardinality
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"go.uber.org/atomic"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.cardinality",
+		Args:    Arguments{},
+		Exports: Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the
+// prometheus.cardinality component.
+type Arguments struct {
+	// Where the metrics should be forwarded to.
+	ForwardTo []storage.Appendable `river:"forward_to,attr"`
+
+	Window     time.Duration `river:"window,attr,optional"`
+	MaxTracked int           `river:"max_tracked,attr,optional"`
+	Top        int           `river:"top,attr,optional"`
+}
+
+// DefaultArguments holds the default settings for the prometheus.cardinality
+// component.
+var DefaultArguments = Arguments{
+	Window:     10 * time.Minute,
+	MaxTracked: 10000,
+	Top:        20,
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (args *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*args = DefaultArguments
+
+	type arguments Arguments
+	return f((*arguments)(args))
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	var errs river.ValidationErrors
+	if args.Window <= 0 {
+		errs.Add(river.PathError("window", fmt.Errorf("window must be greater than 0")))
+	}
+	if args.MaxTracked <= 0 {
+		errs.Add(river.PathError("max_tracked", fmt.Errorf("max_tracked must be greater than 0")))
+	}
+	if args.Top < 0 {
+		errs.Add(river.PathError("top", fmt.Errorf("top must not be negative")))
+	}
+	return errs.ErrorOrNil()
+}
+
+// Exports holds values which are exported by the prometheus.cardinality
+// component.
+type Exports struct {
+	Receiver storage.Appendable `river:"receiver,attr"`
+}
+
+// Component implements the prometheus.cardinality component.
+type Component struct {
+	opts     component.Options
+	receiver *prometheus.Interceptor
+	fanout   *prometheus.Fanout
+	tracker  *tracker
+	exited   atomic.Bool
+
+	mut    sync.RWMutex
+	args   Arguments
+	update chan struct{} // Signals that the window changed.
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.HTTPComponent  = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// New creates a new prometheus.cardinality component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		tracker: newTracker(args.MaxTracked),
+		update:  make(chan struct{}, 1),
+	}
+
+	if err := o.Registerer.Register(&collector{c: c}); err != nil {
+		return nil, err
+	}
+
+	c.fanout = prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer)
+	c.receiver = prometheus.NewInterceptor(
+		c.fanout,
+		prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+			c.tracker.Add(l)
+			return next.Append(ref, l, t, v)
+		}),
+		prometheus.WithAppendHistogram(func(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+			c.tracker.Add(l)
+			return next.AppendHistogram(ref, l, t, h, fh)
+		}),
+	)
+
+	// Immediately export the receiver which remains the same for the component
+	// lifetime.
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer c.exited.Store(true)
+
+	ticker := time.NewTicker(c.window())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.update:
+			ticker.Reset(c.window())
+		case <-ticker.C:
+			c.tracker.Rotate()
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	windowChanged := c.args.Window != newArgs.Window
+	c.args = newArgs
+	c.tracker.SetMaxTracked(newArgs.MaxTracked)
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	if windowChanged {
+		select {
+		case c.update <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (c *Component) window() time.Duration {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.args.Window
+}
+
+func (c *Component) top() int {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.args.Top
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	return c.tracker.Stats(c.top())
+}
+
+// Handler implements component.HTTPComponent. The /cardinality endpoint
+// returns the estimated series counts as JSON. The number of counts returned
+// per dimension can be changed with the limit query parameter; a limit of 0
+// returns all counts.
+func (c *Component) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cardinality", func(w http.ResponseWriter, r *http.Request) {
+		limit := c.top()
+		if s := r.URL.Query().Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				http.Error(w, fmt.Sprintf("invalid limit %q", s), http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.tracker.Stats(limit))
+	})
+	return mux
+}
+
+var (
+	seriesDesc = prometheus_client.NewDesc(
+		"agent_prometheus_cardinality_series",
+		"Estimated number of series received",
+		nil, nil,
+	)
+	metricSeriesDesc = prometheus_client.NewDesc(
+		"agent_prometheus_cardinality_metric_series",
+		"Estimated number of series received per metric name",
+		[]string{"metric"}, nil,
+	)
+	labelSeriesDesc = prometheus_client.NewDesc(
+		"agent_prometheus_cardinality_label_series",
+		"Estimated number of series received per label name",
+		[]string{"label"}, nil,
+	)
+	jobSeriesDesc = prometheus_client.NewDesc(
+		"agent_prometheus_cardinality_job_series",
+		"Estimated number of series received per job",
+		[]string{"job"}, nil,
+	)
+)
+
+// collector exposes the top estimated series counts as metrics.
+type collector struct {
+	c *Component
+}
+
+// Describe implements prometheus.Collector.
+func (col *collector) Describe(ch chan<- *prometheus_client.Desc) {
+	ch <- seriesDesc
+	ch <- metricSeriesDesc
+	ch <- labelSeriesDesc
+	ch <- jobSeriesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (col *collector) Collect(ch chan<- prometheus_client.Metric) {
+	stats := col.c.tracker.Stats(col.c.top())
+
+	ch <- prometheus_client.MustNewConstMetric(seriesDesc, prometheus_client.GaugeValue, float64(stats.Total))
+	for _, dim := range []struct {
+		desc   *prometheus_client.Desc
+		counts []Count
+	}{
+		{metricSeriesDesc, stats.Metrics},
+		{labelSeriesDesc, stats.Labels},
+		{jobSeriesDesc, stats.Jobs},
+	} {
+		for _, count := range dim.counts {
+			ch <- prometheus_client.MustNewConstMetric(dim.desc, prometheus_client.GaugeValue, float64(count.Series), count.Name)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package cardinality
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSketch(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 100000} {
+		var s sketch
+		for i := 0; i < n; i++ {
+			// Add every series twice; duplicates must not be counted.
+			for j := 0; j < 2; j++ {
+				s.Add(labels.FromStrings("__name__", "test", "i", fmt.Sprint(i)).Hash())
+			}
+		}
+
+		estimate := float64(s.Estimate())
+		require.InDelta(t, n, estimate, math.Max(1, 0.1*float64(n)), "estimate for %d series", n)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tr := newTracker(2)
+	for i := 0; i < 100; i++ {
+		tr.Add(labels.FromStrings("__name__", "http_requests_total", "job", "api", "path", fmt.Sprint(i)))
+	}
+	tr.Add(labels.FromStrings("__name__", "up", "job", "api"))
+	tr.Add(labels.FromStrings("__name__", "untracked", "job", "db"))
+
+	stats := tr.Stats(0)
+	require.InDelta(t, 102, stats.Total, 5)
+	require.Len(t, stats.Metrics, 2, "untracked metric names must be ignored")
+	require.Equal(t, "http_requests_total", stats.Metrics[0].Name)
+	require.InDelta(t, 100, stats.Metrics[0].Series, 5)
+	require.Equal(t, Count{Name: "up", Series: 1}, stats.Metrics[1])
+	require.Equal(t, "api", stats.Jobs[0].Name)
+	require.Equal(t, "job", stats.Labels[0].Name)
+	require.Len(t, tr.Stats(1).Metrics, 1)
+
+	// Series are kept for one more window after they were last seen.
+	tr.Rotate()
+	require.Len(t, tr.Stats(0).Metrics, 2)
+	tr.Rotate()
+	require.Empty(t, tr.Stats(0).Metrics)
+	require.Zero(t, tr.Stats(0).Total)
+}
+
+func TestRiverConfig(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`forward_to = []`), &args))
+	require.Equal(t, DefaultArguments.Window, args.Window)
+	require.Equal(t, DefaultArguments.MaxTracked, args.MaxTracked)
+
+	err := river.Unmarshal([]byte(`
+		forward_to = []
+		window     = "0s"
+	`), &args)
+	require.ErrorContains(t, err, "window must be greater than 0")
+}
+
+func TestHandler(t *testing.T) {
+	args := DefaultArguments
+	args.ForwardTo = []storage.Appendable{}
+
+	c, err := New(component.Options{
+		ID:            "prometheus.cardinality.test",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+		Registerer:    prom.NewRegistry(),
+	}, args)
+	require.NoError(t, err)
+
+	app := c.receiver.Appender(context.Background())
+	for i := 0; i < 3; i++ {
+		_, err := app.Append(0, labels.FromStrings("__name__", "up", "instance", fmt.Sprint(i)), 0, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	srv := httptest.NewServer(c.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/cardinality?limit=1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var stats Stats
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+	require.Equal(t, uint64(3), stats.Total)
+	require.Equal(t, []Count{{Name: "up", Series: 3}}, stats.Metrics)
+	require.Equal(t, []Count{{Name: "instance", Series: 3}}, stats.Labels)
+
+	resp, err = http.Get(srv.URL + "/cardinality?limit=-1")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,68 @@
+package cardinality
+
+import (
+	"math"
+	"math/bits"
+)
+
+// precision is the number of bits of a hash used to select a register of a
+// sketch. Sketches use 2^precision bytes of memory and have a standard error
+// of about 1.04/sqrt(2^precision), or 3.25%.
+const precision = 10
+
+const numRegisters = 1 << precision
+
+// sketch is a HyperLogLog sketch which estimates the number of distinct
+// 64-bit hashes added to it.
+type sketch [numRegisters]uint8
+
+// Add adds a hash to s.
+func (s *sketch) Add(hash uint64) {
+	idx := hash >> (64 - precision)
+
+	// The remaining bits determine the rank. A sentinel bit bounds the rank in
+	// case all remaining bits are zero.
+	w := hash<<precision | 1<<(precision-1)
+	rank := uint8(bits.LeadingZeros64(w) + 1)
+
+	if rank > s[idx] {
+		s[idx] = rank
+	}
+}
+
+// Merge merges other into s, after which s estimates the number of distinct
+// hashes added to either sketch.
+func (s *sketch) Merge(other *sketch) {
+	for i, r := range other {
+		if r > s[i] {
+			s[i] = r
+		}
+	}
+}
+
+// Estimate returns the estimated number of distinct hashes added to s.
+func (s *sketch) Estimate() uint64 {
+	const (
+		m     = float64(numRegisters)
+		alpha = 0.7213 / (1 + 1.079/m)
+	)
+
+	var (
+		sum   float64
+		zeros int
+	)
+	for _, r := range s {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := alpha * m * m / sum
+
+	// Use linear counting for small cardinalities, where HyperLogLog is biased.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
@@ -0,0 +1,162 @@
+package cardinality
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// tracker estimates the number of series per metric name, label name, and
+// job.
+//
+// Series are tracked in generations which are rotated once per window.
+// Estimates cover the current and the previous generation, so a series is
+// counted for at least one and at most two windows after it was last seen.
+type tracker struct {
+	mut        sync.Mutex
+	maxTracked int
+	current    *generation
+	previous   *generation
+}
+
+// generation holds the sketches of a single window.
+type generation struct {
+	total   sketch
+	metrics map[string]*sketch
+	labels  map[string]*sketch
+	jobs    map[string]*sketch
+}
+
+func newGeneration() *generation {
+	return &generation{
+		metrics: make(map[string]*sketch),
+		labels:  make(map[string]*sketch),
+		jobs:    make(map[string]*sketch),
+	}
+}
+
+func newTracker(maxTracked int) *tracker {
+	return &tracker{
+		maxTracked: maxTracked,
+		current:    newGeneration(),
+		previous:   newGeneration(),
+	}
+}
+
+// SetMaxTracked changes the maximum number of keys tracked per dimension.
+// Keys which are already tracked are kept until they're rotated out.
+func (t *tracker) SetMaxTracked(n int) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.maxTracked = n
+}
+
+// Add records a series. Series for metric names, label names, or jobs which
+// aren't tracked yet are ignored for that dimension once maxTracked keys are
+// tracked, but are still counted in the total.
+func (t *tracker) Add(lbls labels.Labels) {
+	hash := lbls.Hash()
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	g := t.current
+	g.total.Add(hash)
+	lbls.Range(func(l labels.Label) {
+		switch l.Name {
+		case labels.MetricName:
+			t.add(g.metrics, l.Value, hash)
+		case model.JobLabel:
+			t.add(g.jobs, l.Value, hash)
+			t.add(g.labels, l.Name, hash)
+		default:
+			t.add(g.labels, l.Name, hash)
+		}
+	})
+}
+
+func (t *tracker) add(sketches map[string]*sketch, key string, hash uint64) {
+	s, ok := sketches[key]
+	if !ok {
+		if len(sketches) >= t.maxTracked {
+			return
+		}
+		s = &sketch{}
+		sketches[key] = s
+	}
+	s.Add(hash)
+}
+
+// Rotate starts a new generation, discarding the oldest one.
+func (t *tracker) Rotate() {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.previous = t.current
+	t.current = newGeneration()
+}
+
+// Stats holds estimated series counts.
+type Stats struct {
+	Total   uint64  `river:"total_series,attr" json:"totalSeries"`
+	Metrics []Count `river:"metric,block,optional" json:"metrics"`
+	Labels  []Count `river:"label,block,optional" json:"labels"`
+	Jobs    []Count `river:"job,block,optional" json:"jobs"`
+}
+
+// Count is the estimated number of series for a metric name, label name, or
+// job.
+type Count struct {
+	Name   string `river:"name,attr" json:"name"`
+	Series uint64 `river:"series,attr" json:"series"`
+}
+
+// Stats returns the estimated series counts, keeping up to top counts per
+// dimension sorted by the number of series. All counts are returned if top is
+// 0.
+func (t *tracker) Stats(top int) Stats {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	total := t.current.total
+	total.Merge(&t.previous.total)
+
+	return Stats{
+		Total:   total.Estimate(),
+		Metrics: topCounts(t.current.metrics, t.previous.metrics, top),
+		Labels:  topCounts(t.current.labels, t.previous.labels, top),
+		Jobs:    topCounts(t.current.jobs, t.previous.jobs, top),
+	}
+}
+
+func topCounts(current, previous map[string]*sketch, top int) []Count {
+	merged := make(map[string]*sketch, len(current)+len(previous))
+	for _, sketches := range []map[string]*sketch{current, previous} {
+		for key, s := range sketches {
+			m, ok := merged[key]
+			if !ok {
+				m = &sketch{}
+				merged[key] = m
+			}
+			m.Merge(s)
+		}
+	}
+
+	res := make([]Count, 0, len(merged))
+	for key, s := range merged {
+		res = append(res, Count{Name: key, Series: s.Estimate()})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Series != res[j].Series {
+			return res[i].Series > res[j].Series
+		}
+		return res[i].Name < res[j].Name
+	})
+
+	if top > 0 && len(res) > top {
+		res = res[:top]
+	}
+	return res
+}
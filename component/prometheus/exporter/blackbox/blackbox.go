@@ -1,6 +1,9 @@
 package blackbox
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/grafana/agent/component"
@@ -8,6 +11,7 @@ import (
 	"github.com/grafana/agent/component/prometheus/exporter"
 	"github.com/grafana/agent/pkg/integrations"
 	"github.com/grafana/agent/pkg/integrations/blackbox_exporter"
+	"github.com/prometheus/common/model"
 )
 
 func init() {
@@ -44,9 +48,37 @@ func buildBlackboxTargets(baseTarget discovery.Target, args component.Arguments)
 		targets = append(targets, target)
 	}
 
+	for _, tgt := range a.DynamicTargets {
+		target := make(discovery.Target)
+		for k, v := range baseTarget {
+			target[k] = v
+		}
+
+		address := tgt[model.AddressLabel]
+		target["job"] = target["job"] + "/" + address
+		target["__param_target"] = address
+		if module := tgt[moduleLabel]; module != "" {
+			target["__param_module"] = module
+		}
+
+		// Keep the public labels of the discovered target so that they end up
+		// on the probe metrics.
+		for k, v := range tgt {
+			if !strings.HasPrefix(k, model.ReservedLabelPrefix) {
+				target[k] = v
+			}
+		}
+
+		targets = append(targets, target)
+	}
+
 	return targets
 }
 
+// moduleLabel is the label of a target passed in the targets argument which
+// selects the module used to probe it.
+const moduleLabel = "__param_module"
+
 // DefaultArguments holds non-zero default options for Arguments when it is
 // unmarshaled from river.
 var DefaultArguments = Arguments{
@@ -76,9 +108,10 @@ func (t TargetBlock) Convert() []blackbox_exporter.BlackboxTarget {
 }
 
 type Arguments struct {
-	ConfigFile         string        `river:"config_file,attr"`
-	Targets            TargetBlock   `river:"target,block"`
-	ProbeTimeoutOffset time.Duration `river:"probe_timeout_offset,attr,optional"`
+	ConfigFile         string             `river:"config_file,attr"`
+	Targets            TargetBlock        `river:"target,block,optional"`
+	DynamicTargets     []discovery.Target `river:"targets,attr,optional"`
+	ProbeTimeoutOffset time.Duration      `river:"probe_timeout_offset,attr,optional"`
 }
 
 // UnmarshalRiver implements River unmarshalling for Arguments.
@@ -86,7 +119,23 @@ func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	*a = DefaultArguments
 
 	type args Arguments
-	return f((*args)(a))
+	if err := f((*args)(a)); err != nil {
+		return err
+	}
+	return a.Validate()
+}
+
+// Validate returns an error if the Arguments are invalid.
+func (a *Arguments) Validate() error {
+	if len(a.Targets) == 0 && len(a.DynamicTargets) == 0 {
+		return errors.New("at least one target block or the targets argument must be provided")
+	}
+	for _, tgt := range a.DynamicTargets {
+		if tgt[model.AddressLabel] == "" {
+			return fmt.Errorf("target %v is missing the %s label", tgt, model.AddressLabel)
+		}
+	}
+	return nil
 }
 
 // Convert converts the component's Arguments to the integration's Config.
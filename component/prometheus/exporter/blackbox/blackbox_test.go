@@ -89,3 +89,72 @@ func TestBuildBlackboxTargets(t *testing.T) {
 	require.Equal(t, "http://example.com", targets[0]["__param_target"])
 	require.Equal(t, "http_2xx", targets[0]["__param_module"])
 }
+
+func TestUnmarshalRiverDynamicTargets(t *testing.T) {
+	riverCfg := `
+		config_file = "modules.yml"
+		targets = [
+			{"__address__" = "http://example.com", "__param_module" = "http_2xx"},
+			{"__address__" = "192.168.0.1"},
+		]
+`
+	var args Arguments
+	err := river.Unmarshal([]byte(riverCfg), &args)
+	require.NoError(t, err)
+	require.Empty(t, args.Targets)
+	require.Equal(t, 2, len(args.DynamicTargets))
+}
+
+func TestBadRiverConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{
+			name:   "no targets",
+			config: `config_file = "modules.yml"`,
+		},
+		{
+			name: "target without address",
+			config: `
+				config_file = "modules.yml"
+				targets = [{"__param_module" = "http_2xx"}]
+			`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			require.Error(t, river.Unmarshal([]byte(tc.config), &args))
+		})
+	}
+}
+
+func TestBuildBlackboxDynamicTargets(t *testing.T) {
+	baseArgs := Arguments{
+		ConfigFile: "modules.yml",
+		DynamicTargets: []discovery.Target{
+			{"__address__": "http://example.com", "__param_module": "http_2xx", "__meta_kubernetes_pod_name": "pod", "env": "prod"},
+			{"__address__": "192.168.0.1"},
+		},
+	}
+	baseTarget := discovery.Target{
+		model.SchemeLabel:      "http",
+		model.MetricsPathLabel: "component/prometheus.exporter.blackbox.default/metrics",
+		"instance":             "prometheus.exporter.blackbox.default",
+		"job":                  "integrations/blackbox",
+	}
+	targets := buildBlackboxTargets(baseTarget, component.Arguments(baseArgs))
+	require.Equal(t, 2, len(targets))
+
+	require.Equal(t, "integrations/blackbox/http://example.com", targets[0]["job"])
+	require.Equal(t, "http://example.com", targets[0]["__param_target"])
+	require.Equal(t, "http_2xx", targets[0]["__param_module"])
+	require.Equal(t, "prod", targets[0]["env"])
+	require.NotContains(t, targets[0], "__meta_kubernetes_pod_name")
+	require.NotContains(t, targets[0], "__address__")
+
+	require.Equal(t, "integrations/blackbox/192.168.0.1", targets[1]["job"])
+	require.Equal(t, "192.168.0.1", targets[1]["__param_target"])
+	require.NotContains(t, targets[1], "__param_module")
+}
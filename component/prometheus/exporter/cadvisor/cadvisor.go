@@ -0,0 +1,110 @@
+package cadvisor
+
+import (
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/cadvisor"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.cadvisor",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "cadvisor"),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// DefaultArguments holds non-zero default options for Arguments when it is
+// unmarshaled from river.
+var DefaultArguments = Arguments{
+	StoreContainerLabels: true,
+	StorageDuration:      2 * time.Minute,
+
+	Containerd:          "/run/containerd/containerd.sock",
+	ContainerdNamespace: "k8s.io",
+
+	Docker:        "unix:///var/run/docker.sock",
+	DockerTLS:     false,
+	DockerTLSCert: "cert.pem",
+	DockerTLSKey:  "key.pem",
+	DockerTLSCA:   "ca.pem",
+}
+
+// Arguments configures the prometheus.exporter.cadvisor component.
+type Arguments struct {
+	StoreContainerLabels       bool          `river:"store_container_labels,attr,optional"`
+	AllowlistedContainerLabels []string      `river:"allowlisted_container_labels,attr,optional"`
+	EnvMetadataAllowlist       []string      `river:"env_metadata_allowlist,attr,optional"`
+	RawCgroupPrefixAllowlist   []string      `river:"raw_cgroup_prefix_allowlist,attr,optional"`
+	PerfEventsConfig           string        `river:"perf_events_config,attr,optional"`
+	ResctrlInterval            time.Duration `river:"resctrl_interval,attr,optional"`
+	DisabledMetrics            []string      `river:"disabled_metrics,attr,optional"`
+	EnabledMetrics             []string      `river:"enabled_metrics,attr,optional"`
+	StorageDuration            time.Duration `river:"storage_duration,attr,optional"`
+
+	Containerd          string `river:"containerd_host,attr,optional"`
+	ContainerdNamespace string `river:"containerd_namespace,attr,optional"`
+
+	Docker        string `river:"docker_host,attr,optional"`
+	DockerTLS     bool   `river:"use_docker_tls,attr,optional"`
+	DockerTLSCert string `river:"docker_tls_cert,attr,optional"`
+	DockerTLSKey  string `river:"docker_tls_key,attr,optional"`
+	DockerTLSCA   string `river:"docker_tls_ca,attr,optional"`
+
+	DockerOnly bool `river:"docker_only,attr,optional"`
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type args Arguments
+	return f((*args)(a))
+}
+
+// Convert returns the upstream-compatible configuration struct.
+func (a *Arguments) Convert() *cadvisor.Config {
+	// cAdvisor expects the allowlists to always have at least one element,
+	// like when they're passed as an empty comma-separated flag.
+	allowlistedContainerLabels := a.AllowlistedContainerLabels
+	if len(allowlistedContainerLabels) == 0 {
+		allowlistedContainerLabels = []string{""}
+	}
+	rawCgroupPrefixAllowlist := a.RawCgroupPrefixAllowlist
+	if len(rawCgroupPrefixAllowlist) == 0 {
+		rawCgroupPrefixAllowlist = []string{""}
+	}
+	envMetadataAllowlist := a.EnvMetadataAllowlist
+	if len(envMetadataAllowlist) == 0 {
+		envMetadataAllowlist = []string{""}
+	}
+
+	return &cadvisor.Config{
+		StoreContainerLabels:       a.StoreContainerLabels,
+		AllowlistedContainerLabels: allowlistedContainerLabels,
+		EnvMetadataAllowlist:       envMetadataAllowlist,
+		RawCgroupPrefixAllowlist:   rawCgroupPrefixAllowlist,
+		PerfEventsConfig:           a.PerfEventsConfig,
+		ResctrlInterval:            int(a.ResctrlInterval),
+		DisabledMetrics:            a.DisabledMetrics,
+		EnabledMetrics:             a.EnabledMetrics,
+		StorageDuration:            a.StorageDuration,
+		Containerd:                 a.Containerd,
+		ContainerdNamespace:        a.ContainerdNamespace,
+		Docker:                     a.Docker,
+		DockerTLS:                  a.DockerTLS,
+		DockerTLSCert:              a.DockerTLSCert,
+		DockerTLSKey:               a.DockerTLSKey,
+		DockerTLSCA:                a.DockerTLSCA,
+		DockerOnly:                 a.DockerOnly,
+	}
+}
@@ -0,0 +1,115 @@
+package elasticsearch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/component"
+	commonCfg "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/elasticsearch_exporter"
+	"github.com/grafana/agent/pkg/river"
+	config_util "github.com/prometheus/common/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.elasticsearch",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "elasticsearch"),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// DefaultArguments holds the default settings for the elasticsearch_exporter
+// integration.
+var DefaultArguments = Arguments{
+	Address:                   elasticsearch_exporter.DefaultConfig.Address,
+	Timeout:                   elasticsearch_exporter.DefaultConfig.Timeout,
+	Node:                      elasticsearch_exporter.DefaultConfig.Node,
+	ExportClusterInfoInterval: elasticsearch_exporter.DefaultConfig.ExportClusterInfoInterval,
+	IncludeAliases:            elasticsearch_exporter.DefaultConfig.IncludeAliases,
+}
+
+// Arguments controls the elasticsearch component.
+type Arguments struct {
+	Address                   string        `river:"address,attr,optional"`
+	Timeout                   time.Duration `river:"timeout,attr,optional"`
+	AllNodes                  bool          `river:"all,attr,optional"`
+	Node                      string        `river:"node,attr,optional"`
+	ExportIndices             bool          `river:"indices,attr,optional"`
+	ExportIndicesSettings     bool          `river:"indices_settings,attr,optional"`
+	ExportClusterSettings     bool          `river:"cluster_settings,attr,optional"`
+	ExportShards              bool          `river:"shards,attr,optional"`
+	IncludeAliases            bool          `river:"aliases,attr,optional"`
+	ExportSnapshots           bool          `river:"snapshots,attr,optional"`
+	ExportClusterInfoInterval time.Duration `river:"clusterinfo_interval,attr,optional"`
+	ExportDataStreams         bool          `river:"data_stream,attr,optional"`
+	ExportSLM                 bool          `river:"slm,attr,optional"`
+
+	CA                 string `river:"ca,attr,optional"`
+	ClientPrivateKey   string `river:"client_private_key,attr,optional"`
+	ClientCert         string `river:"client_cert,attr,optional"`
+	InsecureSkipVerify bool   `river:"ssl_skip_verify,attr,optional"`
+
+	APIKey    rivertypes.Secret    `river:"api_key,attr,optional"`
+	BasicAuth *commonCfg.BasicAuth `river:"basic_auth,block,optional"`
+}
+
+// UnmarshalRiver implements River unmarshalling for Arguments.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type args Arguments
+	return f((*args)(a))
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	var errs river.ValidationErrors
+	if a.Address == "" {
+		errs.Add(river.PathError("address", fmt.Errorf("address must not be empty")))
+	}
+	if (a.ClientCert == "") != (a.ClientPrivateKey == "") {
+		errs.Add(fmt.Errorf("client_cert and client_private_key must be set together"))
+	}
+	if a.BasicAuth != nil && a.APIKey != "" {
+		errs.Add(river.PathError("api_key", fmt.Errorf("at most one of basic_auth and api_key must be configured")))
+	}
+	if a.BasicAuth != nil && a.BasicAuth.Password != "" && a.BasicAuth.PasswordFile != "" {
+		errs.Add(river.PathError("basic_auth", fmt.Errorf("at most one of password and password_file must be configured")))
+	}
+	return errs.ErrorOrNil()
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *elasticsearch_exporter.Config {
+	return &elasticsearch_exporter.Config{
+		Address:                   a.Address,
+		Timeout:                   a.Timeout,
+		AllNodes:                  a.AllNodes,
+		Node:                      a.Node,
+		ExportIndices:             a.ExportIndices,
+		ExportIndicesSettings:     a.ExportIndicesSettings,
+		ExportClusterSettings:     a.ExportClusterSettings,
+		ExportShards:              a.ExportShards,
+		IncludeAliases:            a.IncludeAliases,
+		ExportSnapshots:           a.ExportSnapshots,
+		ExportClusterInfoInterval: a.ExportClusterInfoInterval,
+		CA:                        a.CA,
+		ClientPrivateKey:          a.ClientPrivateKey,
+		ClientCert:                a.ClientCert,
+		InsecureSkipVerify:        a.InsecureSkipVerify,
+		ExportDataStreams:         a.ExportDataStreams,
+		ExportSLM:                 a.ExportSLM,
+		BasicAuth:                 a.BasicAuth.Convert(),
+		APIKey:                    config_util.Secret(a.APIKey),
+	}
+}
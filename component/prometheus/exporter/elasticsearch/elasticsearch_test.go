@@ -0,0 +1,111 @@
+package elasticsearch
+
+import (
+	"testing"
+	"time"
+
+	commonCfg "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/pkg/integrations/elasticsearch_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/diag"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverConfig := `
+	address              = "http://localhost:9300"
+	timeout              = "10s"
+	all                  = true
+	node                 = "some_node"
+	indices              = true
+	indices_settings     = true
+	cluster_settings     = true
+	shards               = true
+	aliases              = false
+	snapshots            = true
+	clusterinfo_interval = "10m"
+	ca                   = "/etc/elasticsearch/ca.pem"
+	client_private_key   = "/etc/elasticsearch/client.key"
+	client_cert          = "/etc/elasticsearch/client.pem"
+	ssl_skip_verify      = true
+	data_stream          = true
+	slm                  = true
+
+	basic_auth {
+		username = "elastic"
+		password = "changeme"
+	}
+	`
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+
+	expected := Arguments{
+		Address:                   "http://localhost:9300",
+		Timeout:                   10 * time.Second,
+		AllNodes:                  true,
+		Node:                      "some_node",
+		ExportIndices:             true,
+		ExportIndicesSettings:     true,
+		ExportClusterSettings:     true,
+		ExportShards:              true,
+		IncludeAliases:            false,
+		ExportSnapshots:           true,
+		ExportClusterInfoInterval: 10 * time.Minute,
+		CA:                        "/etc/elasticsearch/ca.pem",
+		ClientPrivateKey:          "/etc/elasticsearch/client.key",
+		ClientCert:                "/etc/elasticsearch/client.pem",
+		InsecureSkipVerify:        true,
+		ExportDataStreams:         true,
+		ExportSLM:                 true,
+		BasicAuth: &commonCfg.BasicAuth{
+			Username: "elastic",
+			Password: "changeme",
+		},
+	}
+	require.Equal(t, expected, args)
+}
+
+func TestRiverUnmarshal_Invalid(t *testing.T) {
+	riverConfig := `
+	api_key = "c2VjcmV0"
+
+	basic_auth {
+		username = "elastic"
+		password = "changeme"
+	}
+	`
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+
+	var diags diag.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Len(t, diags, 1)
+	require.EqualError(t, diags[0], "2:2: at most one of basic_auth and api_key must be configured")
+}
+
+func TestConvert(t *testing.T) {
+	riverConfig := `
+	address = "http://localhost:9300"
+	indices = true
+	api_key = "c2VjcmV0"
+	`
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+
+	res := args.Convert()
+
+	expected := elasticsearch_exporter.DefaultConfig
+	expected.Address = "http://localhost:9300"
+	expected.ExportIndices = true
+	expected.APIKey = config_util.Secret("c2VjcmV0")
+	require.Equal(t, expected, *res)
+}
+
+// Checks that the flow and static default configs have not drifted
+func TestDefaultsSame(t *testing.T) {
+	convertedDefaults := DefaultArguments.Convert()
+	require.Equal(t, elasticsearch_exporter.DefaultConfig, *convertedDefaults)
+}
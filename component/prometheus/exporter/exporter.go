@@ -17,6 +17,18 @@ import (
 // Creator is a function provided by an implementation to create a concrete exporter instance.
 type Creator func(component.Options, component.Arguments) (integrations.Integration, error)
 
+// TargetsIntegration is implemented by integrations which determine their own
+// targets when they're created, such as integrations which discover the
+// instances they collect metrics from. Its targets take precedence over the
+// targets of a multi-target function.
+type TargetsIntegration interface {
+	integrations.Integration
+
+	// Targets returns the targets of the integration, derived from the target
+	// of the component.
+	Targets(baseTarget discovery.Target) []discovery.Target
+}
+
 // Exports are simply a list of targets for a scraper to consume.
 type Exports struct {
 	Targets []discovery.Target `river:"targets,attr"`
@@ -117,7 +129,9 @@ func (c *Component) Update(args component.Arguments) error {
 	c.exporter = exporter
 
 	var targets []discovery.Target
-	if c.multiTargetFunc == nil {
+	if ti, ok := exporter.(TargetsIntegration); ok {
+		targets = ti.Targets(c.baseTarget)
+	} else if c.multiTargetFunc == nil {
 		targets = []discovery.Target{c.baseTarget}
 	} else {
 		targets = c.multiTargetFunc(c.baseTarget, args)
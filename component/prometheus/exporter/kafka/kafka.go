@@ -0,0 +1,125 @@
+package kafka
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/kafka_exporter"
+	"github.com/grafana/agent/pkg/river"
+	config_util "github.com/prometheus/common/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.kafka",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "kafka"),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// DefaultArguments holds the default settings for the kafka_exporter
+// integration.
+var DefaultArguments = Arguments{
+	UseSASLHandshake:        kafka_exporter.DefaultConfig.UseSASLHandshake,
+	KafkaVersion:            kafka_exporter.DefaultConfig.KafkaVersion,
+	MetadataRefreshInterval: kafka_exporter.DefaultConfig.MetadataRefreshInterval,
+	AllowConcurrent:         kafka_exporter.DefaultConfig.AllowConcurrent,
+	MaxOffsets:              kafka_exporter.DefaultConfig.MaxOffsets,
+	PruneIntervalSeconds:    kafka_exporter.DefaultConfig.PruneIntervalSeconds,
+	TopicsFilter:            kafka_exporter.DefaultConfig.TopicsFilter,
+	GroupFilter:             kafka_exporter.DefaultConfig.GroupFilter,
+}
+
+// Arguments controls the kafka component.
+type Arguments struct {
+	KafkaURIs               []string          `river:"kafka_uris,attr"`
+	UseSASL                 bool              `river:"use_sasl,attr,optional"`
+	UseSASLHandshake        bool              `river:"use_sasl_handshake,attr,optional"`
+	SASLUsername            string            `river:"sasl_username,attr,optional"`
+	SASLPassword            rivertypes.Secret `river:"sasl_password,attr,optional"`
+	SASLMechanism           string            `river:"sasl_mechanism,attr,optional"`
+	UseTLS                  bool              `river:"use_tls,attr,optional"`
+	CAFile                  string            `river:"ca_file,attr,optional"`
+	CertFile                string            `river:"cert_file,attr,optional"`
+	KeyFile                 string            `river:"key_file,attr,optional"`
+	InsecureSkipVerify      bool              `river:"insecure_skip_verify,attr,optional"`
+	KafkaVersion            string            `river:"kafka_version,attr,optional"`
+	UseZooKeeperLag         bool              `river:"use_zookeeper_lag,attr,optional"`
+	ZookeeperURIs           []string          `river:"zookeeper_uris,attr,optional"`
+	ClusterName             string            `river:"kafka_cluster_name,attr,optional"`
+	MetadataRefreshInterval string            `river:"metadata_refresh_interval,attr,optional"`
+	AllowConcurrent         bool              `river:"allow_concurrency,attr,optional"`
+	MaxOffsets              int               `river:"max_offsets,attr,optional"`
+	PruneIntervalSeconds    int               `river:"prune_interval_seconds,attr,optional"`
+	TopicsFilter            string            `river:"topics_filter_regex,attr,optional"`
+	GroupFilter             string            `river:"groups_filter_regex,attr,optional"`
+}
+
+// UnmarshalRiver implements River unmarshalling for Arguments.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type args Arguments
+	return f((*args)(a))
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	var errs river.ValidationErrors
+	if len(a.KafkaURIs) == 0 || a.KafkaURIs[0] == "" {
+		errs.Add(river.PathError("kafka_uris", fmt.Errorf("at least one kafka_uris must be provided")))
+	}
+	if a.UseTLS && (a.CertFile == "" || a.KeyFile == "") {
+		errs.Add(river.PathError("use_tls", fmt.Errorf("tls is enabled but key pair was not provided")))
+	}
+	if a.UseSASL && (a.SASLPassword == "" || a.SASLUsername == "") {
+		errs.Add(river.PathError("use_sasl", fmt.Errorf("SASL is enabled but username or password was not provided")))
+	}
+	if a.UseZooKeeperLag && (len(a.ZookeeperURIs) == 0 || a.ZookeeperURIs[0] == "") {
+		errs.Add(river.PathError("use_zookeeper_lag", fmt.Errorf("zookeeper lag is enabled but no zookeeper_uris was provided")))
+	}
+	if _, err := regexp.Compile(a.TopicsFilter); err != nil {
+		errs.Add(river.PathError("topics_filter_regex", fmt.Errorf("invalid topics_filter_regex: %w", err)))
+	}
+	if _, err := regexp.Compile(a.GroupFilter); err != nil {
+		errs.Add(river.PathError("groups_filter_regex", fmt.Errorf("invalid groups_filter_regex: %w", err)))
+	}
+	return errs.ErrorOrNil()
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *kafka_exporter.Config {
+	return &kafka_exporter.Config{
+		KafkaURIs:               a.KafkaURIs,
+		UseSASL:                 a.UseSASL,
+		UseSASLHandshake:        a.UseSASLHandshake,
+		SASLUsername:            a.SASLUsername,
+		SASLPassword:            config_util.Secret(a.SASLPassword),
+		SASLMechanism:           a.SASLMechanism,
+		UseTLS:                  a.UseTLS,
+		CAFile:                  a.CAFile,
+		CertFile:                a.CertFile,
+		KeyFile:                 a.KeyFile,
+		InsecureSkipVerify:      a.InsecureSkipVerify,
+		KafkaVersion:            a.KafkaVersion,
+		UseZooKeeperLag:         a.UseZooKeeperLag,
+		ZookeeperURIs:           a.ZookeeperURIs,
+		ClusterName:             a.ClusterName,
+		MetadataRefreshInterval: a.MetadataRefreshInterval,
+		AllowConcurrent:         a.AllowConcurrent,
+		MaxOffsets:              a.MaxOffsets,
+		PruneIntervalSeconds:    a.PruneIntervalSeconds,
+		TopicsFilter:            a.TopicsFilter,
+		GroupFilter:             a.GroupFilter,
+	}
+}
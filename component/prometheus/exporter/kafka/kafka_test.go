@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/integrations/kafka_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/diag"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverConfig := `
+	kafka_uris                = ["localhost:9092", "localhost:19092"]
+	use_sasl                  = true
+	sasl_username             = "user"
+	sasl_password             = "password"
+	sasl_mechanism            = "scram-sha512"
+	use_tls                   = true
+	ca_file                   = "/etc/kafka/ca.pem"
+	cert_file                 = "/etc/kafka/client.pem"
+	key_file                  = "/etc/kafka/client.key"
+	kafka_version             = "3.3.0"
+	kafka_cluster_name        = "prod"
+	metadata_refresh_interval = "5m"
+	allow_concurrency         = false
+	topics_filter_regex       = "orders.*"
+	groups_filter_regex       = "billing-.*"
+	`
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+
+	expected := Arguments{
+		KafkaURIs:               []string{"localhost:9092", "localhost:19092"},
+		UseSASL:                 true,
+		UseSASLHandshake:        true,
+		SASLUsername:            "user",
+		SASLPassword:            "password",
+		SASLMechanism:           "scram-sha512",
+		UseTLS:                  true,
+		CAFile:                  "/etc/kafka/ca.pem",
+		CertFile:                "/etc/kafka/client.pem",
+		KeyFile:                 "/etc/kafka/client.key",
+		KafkaVersion:            "3.3.0",
+		ClusterName:             "prod",
+		MetadataRefreshInterval: "5m",
+		AllowConcurrent:         false,
+		MaxOffsets:              1000,
+		PruneIntervalSeconds:    30,
+		TopicsFilter:            "orders.*",
+		GroupFilter:             "billing-.*",
+	}
+	require.Equal(t, expected, args)
+}
+
+func TestRiverUnmarshal_Invalid(t *testing.T) {
+	riverConfig := `
+	kafka_uris          = ["localhost:9092"]
+	use_sasl            = true
+	topics_filter_regex = "(orders"
+	`
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+
+	var diags diag.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Len(t, diags, 2)
+	require.EqualError(t, diags[0], "3:2: SASL is enabled but username or password was not provided")
+	require.EqualError(t, diags[1], "4:2: invalid topics_filter_regex: error parsing regexp: missing closing ): `(orders`")
+}
+
+func TestConvert(t *testing.T) {
+	riverConfig := `
+	kafka_uris    = ["localhost:9092"]
+	use_sasl      = true
+	sasl_username = "user"
+	sasl_password = "password"
+	`
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+
+	res := args.Convert()
+
+	expected := kafka_exporter.DefaultConfig
+	expected.KafkaURIs = []string{"localhost:9092"}
+	expected.UseSASL = true
+	expected.SASLUsername = "user"
+	expected.SASLPassword = config_util.Secret("password")
+	require.Equal(t, expected, *res)
+}
+
+// Checks that the flow and static default configs have not drifted
+func TestDefaultsSame(t *testing.T) {
+	convertedDefaults := DefaultArguments.Convert()
+	require.Equal(t, kafka_exporter.DefaultConfig, *convertedDefaults)
+}
@@ -0,0 +1,117 @@
+package mongodb
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/flow/rivertypes"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/mongodb_exporter"
+	"github.com/grafana/agent/pkg/river"
+	config_util "github.com/prometheus/common/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.mongodb",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "mongodb"),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// DefaultArguments holds the default settings for the mongodb_exporter
+// integration.
+var DefaultArguments = Arguments{
+	DirectConnect:  mongodb_exporter.DefaultConfig.DirectConnect,
+	CompatibleMode: mongodb_exporter.DefaultConfig.CompatibleMode,
+	CollectAll:     mongodb_exporter.DefaultConfig.CollectAll,
+}
+
+// Arguments controls the mongodb component.
+type Arguments struct {
+	// MongoDB connection URI.
+	URI rivertypes.Secret `river:"mongodb_uri,attr"`
+
+	DirectConnect   bool `river:"direct_connect,attr,optional"`
+	DiscoveringMode bool `river:"discovering_mode,attr,optional"`
+	CompatibleMode  bool `river:"compatible_mode,attr,optional"`
+
+	// Collectors. CollectAll enables all of them.
+	CollectAll             bool     `river:"collect_all,attr,optional"`
+	EnableDBStats          bool     `river:"enable_db_stats,attr,optional"`
+	EnableDiagnosticData   bool     `river:"enable_diagnostic_data,attr,optional"`
+	EnableReplicasetStatus bool     `river:"enable_replicaset_status,attr,optional"`
+	EnableTopMetrics       bool     `river:"enable_top_metrics,attr,optional"`
+	EnableIndexStats       bool     `river:"enable_index_stats,attr,optional"`
+	EnableCollStats        bool     `river:"enable_coll_stats,attr,optional"`
+	CollStatsNamespaces    []string `river:"coll_stats_namespaces,attr,optional"`
+	CollStatsLimit         int      `river:"coll_stats_limit,attr,optional"`
+	IndexStatsCollections  []string `river:"index_stats_collections,attr,optional"`
+
+	TLS TLSConfig `river:"tls,block,optional"`
+}
+
+// TLSConfig configures the TLS settings used to connect to MongoDB.
+type TLSConfig struct {
+	CAFile             string `river:"ca_file,attr,optional"`
+	CertFile           string `river:"cert_file,attr,optional"`
+	KeyFile            string `river:"key_file,attr,optional"`
+	InsecureSkipVerify bool   `river:"insecure_skip_verify,attr,optional"`
+}
+
+// UnmarshalRiver implements River unmarshalling for Arguments.
+func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
+	*a = DefaultArguments
+
+	type args Arguments
+	return f((*args)(a))
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	var errs river.ValidationErrors
+	if a.TLS.CertFile != "" && a.TLS.KeyFile == "" {
+		errs.Add(river.PathError("tls.key_file", fmt.Errorf("key_file must be set when cert_file is set")))
+	}
+	if a.TLS.KeyFile != "" && a.TLS.CertFile == "" {
+		errs.Add(river.PathError("tls.cert_file", fmt.Errorf("cert_file must be set when key_file is set")))
+	}
+	if a.CollStatsLimit < 0 {
+		errs.Add(river.PathError("coll_stats_limit", fmt.Errorf("coll_stats_limit must not be negative")))
+	}
+	return errs.ErrorOrNil()
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *mongodb_exporter.Config {
+	return &mongodb_exporter.Config{
+		URI: config_util.Secret(a.URI),
+
+		TLSCAFile:             a.TLS.CAFile,
+		TLSCertFile:           a.TLS.CertFile,
+		TLSKeyFile:            a.TLS.KeyFile,
+		TLSInsecureSkipVerify: a.TLS.InsecureSkipVerify,
+
+		DirectConnect:   a.DirectConnect,
+		DiscoveringMode: a.DiscoveringMode,
+		CompatibleMode:  a.CompatibleMode,
+
+		CollectAll:             a.CollectAll,
+		EnableDBStats:          a.EnableDBStats,
+		EnableDiagnosticData:   a.EnableDiagnosticData,
+		EnableReplicasetStatus: a.EnableReplicasetStatus,
+		EnableTopMetrics:       a.EnableTopMetrics,
+		EnableIndexStats:       a.EnableIndexStats,
+		EnableCollStats:        a.EnableCollStats,
+		CollStatsNamespaces:    a.CollStatsNamespaces,
+		CollStatsLimit:         a.CollStatsLimit,
+		IndexStatsCollections:  a.IndexStatsCollections,
+	}
+}
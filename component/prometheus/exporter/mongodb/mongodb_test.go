@@ -0,0 +1,101 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/integrations/mongodb_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/diag"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverConfigUnmarshal(t *testing.T) {
+	var exampleRiverConfig = `
+	mongodb_uri      = "mongodb://127.0.0.1:27017"
+	direct_connect   = false
+	discovering_mode = true
+	collect_all      = false
+	enable_db_stats  = true
+	enable_coll_stats     = true
+	coll_stats_namespaces = ["db1.col1"]
+	coll_stats_limit      = 100
+
+	tls {
+		ca_file   = "/etc/mongodb/ca.pem"
+		cert_file = "/etc/mongodb/client.pem"
+		key_file  = "/etc/mongodb/client.key"
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	expected := Arguments{
+		URI:                 "mongodb://127.0.0.1:27017",
+		DirectConnect:       false,
+		DiscoveringMode:     true,
+		CompatibleMode:      true,
+		CollectAll:          false,
+		EnableDBStats:       true,
+		EnableCollStats:     true,
+		CollStatsNamespaces: []string{"db1.col1"},
+		CollStatsLimit:      100,
+		TLS: TLSConfig{
+			CAFile:   "/etc/mongodb/ca.pem",
+			CertFile: "/etc/mongodb/client.pem",
+			KeyFile:  "/etc/mongodb/client.key",
+		},
+	}
+	require.Equal(t, expected, args)
+}
+
+func TestRiverConfigInvalid(t *testing.T) {
+	var exampleRiverConfig = `
+	mongodb_uri = "mongodb://127.0.0.1:27017"
+
+	coll_stats_limit = -1
+
+	tls {
+		cert_file = "/etc/mongodb/client.pem"
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+
+	var diags diag.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Len(t, diags, 2)
+	require.EqualError(t, diags[0], "6:2: tls.key_file: key_file must be set when cert_file is set")
+	require.EqualError(t, diags[1], "4:2: coll_stats_limit must not be negative")
+}
+
+func TestConvert(t *testing.T) {
+	riverConfig := `
+	mongodb_uri = "mongodb://127.0.0.1:27017"
+	enable_top_metrics = true
+
+	tls {
+		insecure_skip_verify = true
+	}
+	`
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+
+	res := args.Convert()
+
+	expected := mongodb_exporter.DefaultConfig
+	expected.URI = config_util.Secret("mongodb://127.0.0.1:27017")
+	expected.EnableTopMetrics = true
+	expected.TLSInsecureSkipVerify = true
+	require.Equal(t, expected, *res)
+}
+
+// Checks that the flow and static default configs have not drifted
+func TestDefaultsSame(t *testing.T) {
+	convertedDefaults := DefaultArguments.Convert()
+	require.Equal(t, mongodb_exporter.DefaultConfig, *convertedDefaults)
+}
@@ -72,6 +72,9 @@ type Arguments struct {
 	PerfSchemaFileInstances    PerfSchemaFileInstances    `river:"perf_schema.file_instances,block,optional"`
 	Heartbeat                  Heartbeat                  `river:"heartbeat,block,optional"`
 	MySQLUser                  MySQLUser                  `river:"mysql.user,block,optional"`
+
+	// Queries whose results are exposed as metrics
+	CustomQueries []CustomQuery `river:"custom_query,block,optional"`
 }
 
 // InfoSchemaProcessList configures the info_schema.processlist collector
@@ -111,12 +114,32 @@ type MySQLUser struct {
 	Privileges bool `river:"privileges,attr,optional"`
 }
 
+// CustomQuery defines a query whose result rows are exposed as metrics.
+type CustomQuery struct {
+	Name    string              `river:",label"`
+	Query   string              `river:"query,attr"`
+	Labels  []string            `river:"labels,attr,optional"`
+	Metrics []CustomQueryMetric `river:"metric,block"`
+}
+
+// CustomQueryMetric defines a metric whose value is read from a column of the
+// result of a custom query.
+type CustomQueryMetric struct {
+	Column string `river:",label"`
+	Name   string `river:"name,attr,optional"`
+	Type   string `river:"type,attr"`
+	Help   string `river:"help,attr,optional"`
+}
+
 // UnmarshalRiver implements River unmarshalling for Config.
 func (c *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	*c = DefaultArguments
 
 	type args Arguments
-	return f((*args)(c))
+	if err := f((*args)(c)); err != nil {
+		return err
+	}
+	return c.Convert().Validate()
 }
 
 func (a *Arguments) Convert() *mysqld_exporter.Config {
@@ -140,5 +163,32 @@ func (a *Arguments) Convert() *mysqld_exporter.Config {
 		HeartbeatTable:                       a.Heartbeat.Table,
 		HeartbeatUTC:                         a.Heartbeat.UTC,
 		MySQLUserPrivileges:                  a.MySQLUser.Privileges,
+		CustomQueries:                        a.convertCustomQueries(),
+	}
+}
+
+func (a *Arguments) convertCustomQueries() []mysqld_exporter.CustomQuery {
+	if len(a.CustomQueries) == 0 {
+		return nil
+	}
+
+	res := make([]mysqld_exporter.CustomQuery, 0, len(a.CustomQueries))
+	for _, q := range a.CustomQueries {
+		metrics := make([]mysqld_exporter.CustomQueryMetric, 0, len(q.Metrics))
+		for _, m := range q.Metrics {
+			metrics = append(metrics, mysqld_exporter.CustomQueryMetric{
+				Column: m.Column,
+				Name:   m.Name,
+				Type:   m.Type,
+				Help:   m.Help,
+			})
+		}
+		res = append(res, mysqld_exporter.CustomQuery{
+			Name:    q.Name,
+			Query:   q.Query,
+			Labels:  q.Labels,
+			Metrics: metrics,
+		})
 	}
+	return res
 }
@@ -146,3 +146,57 @@ func TestDefaultsSame(t *testing.T) {
 	convertedDefaults := DefaultArguments.Convert()
 	require.Equal(t, mysqld_exporter.DefaultConfig, *convertedDefaults)
 }
+
+func TestRiverConfigCustomQueries(t *testing.T) {
+	var exampleRiverConfig = `
+	data_source_name = "DataSourceName"
+
+	custom_query "orders" {
+		query  = "SELECT status, COUNT(*) AS total, SUM(amount) AS amount FROM orders GROUP BY status"
+		labels = ["status"]
+
+		metric "total" {
+			type = "gauge"
+			help = "Number of orders."
+		}
+
+		metric "amount" {
+			name = "amount_total"
+			type = "counter"
+		}
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	c := args.Convert()
+	require.Equal(t, []mysqld_exporter.CustomQuery{{
+		Name:   "orders",
+		Query:  "SELECT status, COUNT(*) AS total, SUM(amount) AS amount FROM orders GROUP BY status",
+		Labels: []string{"status"},
+		Metrics: []mysqld_exporter.CustomQueryMetric{
+			{Column: "total", Type: "gauge", Help: "Number of orders."},
+			{Column: "amount", Name: "amount_total", Type: "counter"},
+		},
+	}}, c.CustomQueries)
+}
+
+func TestRiverConfigInvalidCustomQuery(t *testing.T) {
+	var exampleRiverConfig = `
+	data_source_name = "DataSourceName"
+
+	custom_query "orders" {
+		query = "SELECT COUNT(*) AS total FROM orders"
+
+		metric "total" {
+			type = "summary"
+		}
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.EqualError(t, err, `custom query "orders": metric "total": unsupported metric type "summary", must be gauge or counter`)
+}
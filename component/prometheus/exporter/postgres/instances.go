@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/integrations"
+	int_config "github.com/grafana/agent/pkg/integrations/config"
+	"github.com/grafana/agent/pkg/integrations/postgres_exporter"
+	"github.com/oklog/run"
+	config_util "github.com/prometheus/common/config"
+)
+
+// instanceParam is the query parameter of a scrape which selects the
+// instance whose metrics are returned.
+const instanceParam = "instance"
+
+// instancesIntegration runs a separate postgres_exporter for every data
+// source name, so that every Postgres instance is exposed as its own target.
+type instancesIntegration struct {
+	instances map[string]integrations.Integration
+}
+
+// newInstancesIntegration creates an instancesIntegration for the data source
+// names of c. The instances are keyed by the instance keys of their data
+// source names.
+func newInstancesIntegration(l log.Logger, c *postgres_exporter.Config) (*instancesIntegration, error) {
+	i := &instancesIntegration{
+		instances: make(map[string]integrations.Integration, len(c.DataSourceNames)),
+	}
+	for _, dsn := range c.DataSourceNames {
+		instanceConfig := *c
+		instanceConfig.DataSourceNames = []config_util.Secret{dsn}
+
+		key, err := instanceConfig.InstanceKey("")
+		if err != nil {
+			return nil, err
+		}
+		instance, err := instanceConfig.NewIntegration(log.With(l, "instance", key))
+		if err != nil {
+			return nil, err
+		}
+		i.instances[key] = instance
+	}
+	return i, nil
+}
+
+// MetricsHandler implements integrations.Integration. The handler returns the
+// metrics of the instance selected by the instance query parameter.
+func (i *instancesIntegration) MetricsHandler() (http.Handler, error) {
+	handlers := make(map[string]http.Handler, len(i.instances))
+	for key, instance := range i.instances {
+		h, err := instance.MetricsHandler()
+		if err != nil {
+			return nil, err
+		}
+		handlers[key] = h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get(instanceParam)
+		h, ok := handlers[key]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown instance %q", key), http.StatusBadRequest)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}), nil
+}
+
+// ScrapeConfigs implements integrations.Integration. Targets of the instances
+// are built by the component instead.
+func (i *instancesIntegration) ScrapeConfigs() []int_config.ScrapeConfig {
+	return nil
+}
+
+// Run implements integrations.Integration.
+func (i *instancesIntegration) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var rg run.Group
+	for _, instance := range i.instances {
+		instance := instance
+		rg.Add(func() error {
+			return instance.Run(ctx)
+		}, func(_ error) {
+			cancel()
+		})
+	}
+	return rg.Run()
+}
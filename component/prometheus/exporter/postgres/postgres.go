@@ -1,7 +1,10 @@
 package postgres
 
 import (
+	"fmt"
+
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/prometheus/exporter"
 	"github.com/grafana/agent/pkg/flow/rivertypes"
 	"github.com/grafana/agent/pkg/integrations"
@@ -14,13 +17,45 @@ func init() {
 		Name:    "prometheus.exporter.postgres",
 		Args:    Arguments{},
 		Exports: exporter.Exports{},
-		Build:   exporter.New(createExporter, "postgres"),
+		Build:   exporter.NewMultiTarget(createExporter, "postgres", buildPostgresTargets),
 	})
 }
 
 func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
 	a := args.(Arguments)
-	return a.Convert().NewIntegration(opts.Logger)
+	cfg := a.Convert()
+	if len(cfg.DataSourceNames) == 0 {
+		// The data source names are read from the environment, so the instances
+		// aren't known upfront.
+		return cfg.NewIntegration(opts.Logger)
+	}
+	return newInstancesIntegration(opts.Logger, cfg)
+}
+
+// buildPostgresTargets creates a target for every Postgres instance, which
+// selects the instance through a query parameter.
+func buildPostgresTargets(baseTarget discovery.Target, args component.Arguments) []discovery.Target {
+	a := args.(Arguments)
+	if len(a.DataSourceNames) == 0 {
+		return []discovery.Target{baseTarget}
+	}
+
+	// The instance keys were validated when unmarshaling the arguments.
+	keys, _ := a.instanceKeys()
+
+	targets := make([]discovery.Target, 0, len(keys))
+	for _, key := range keys {
+		target := make(discovery.Target)
+		for k, v := range baseTarget {
+			target[k] = v
+		}
+
+		target["instance"] = key
+		target["__param_"+instanceParam] = key
+
+		targets = append(targets, target)
+	}
+	return targets
 }
 
 // DefaultArguments holds the default arguments for the prometheus.exporter.postgres
@@ -62,7 +97,37 @@ func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	*a = DefaultArguments
 
 	type args Arguments
-	return f((*args)(a))
+	if err := f((*args)(a)); err != nil {
+		return err
+	}
+	return a.Validate()
+}
+
+// Validate returns an error if the data source names can't be parsed or if
+// several of them refer to the same Postgres instance.
+func (a *Arguments) Validate() error {
+	_, err := a.instanceKeys()
+	return err
+}
+
+// instanceKeys returns the instance key of every data source name, which
+// identifies the Postgres instance without exposing credentials.
+func (a *Arguments) instanceKeys() ([]string, error) {
+	keys := make([]string, 0, len(a.DataSourceNames))
+	seen := make(map[string]struct{}, len(a.DataSourceNames))
+	for i, dsn := range a.DataSourceNames {
+		cfg := postgres_exporter.Config{DataSourceNames: []config_util.Secret{config_util.Secret(dsn)}}
+		key, err := cfg.InstanceKey("")
+		if err != nil {
+			return nil, fmt.Errorf("data source name %d: %w", i, err)
+		}
+		if _, ok := seen[key]; ok {
+			return nil, fmt.Errorf("data source name %d: multiple data source names refer to %s", i, key)
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	return keys, nil
 }
 
 func (a *Arguments) Convert() *postgres_exporter.Config {
@@ -1,10 +1,14 @@
 package process
 
 import (
+	"fmt"
+	"text/template"
+
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/prometheus/exporter"
 	"github.com/grafana/agent/pkg/integrations"
 	"github.com/grafana/agent/pkg/integrations/process_exporter"
+	"github.com/grafana/agent/pkg/river"
 	exporter_config "github.com/ncabatoff/process-exporter/config"
 )
 
@@ -59,6 +63,23 @@ func (c *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	return f((*args)(c))
 }
 
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	var errs river.ValidationErrors
+	for i, m := range a.ProcessExporter {
+		path := fmt.Sprintf("matcher[%d]", i)
+		if len(m.CommRules) == 0 && len(m.ExeRules) == 0 && len(m.CmdlineRules) == 0 {
+			errs.Add(river.PathError(path, fmt.Errorf("at least one of comm, exe, or cmdline must be set")))
+		}
+		if m.Name != "" {
+			if _, err := template.New("name").Parse(m.Name); err != nil {
+				errs.Add(river.PathError(path+".name", fmt.Errorf("invalid name template %q: %w", m.Name, err)))
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
 func (a *Arguments) Convert() *process_exporter.Config {
 	return &process_exporter.Config{
 		ProcessExporter: convertMatcherGroups(a.ProcessExporter),
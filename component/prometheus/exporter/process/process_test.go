@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/diag"
 	"github.com/ncabatoff/process-exporter/config"
 	"github.com/stretchr/testify/require"
 )
@@ -86,3 +87,27 @@ func TestRiverConfigConvert(t *testing.T) {
 	}
 	require.Equal(t, e, c.ProcessExporter)
 }
+
+func TestRiverConfigValidate(t *testing.T) {
+	var exampleRiverConfig = `
+	matcher {
+		comm = ["grafana-agent"]
+	}
+	matcher {
+		name    = "{{.ExeBase"
+		cmdline = [".*run.*"]
+	}
+	matcher {
+		name = "empty"
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+
+	var diags diag.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Len(t, diags, 2)
+	require.EqualError(t, diags[0], "6:3: invalid name template \"{{.ExeBase\": template: name:1: unclosed action")
+	require.EqualError(t, diags[1], "9:2: at least one of comm, exe, or cmdline must be set")
+}
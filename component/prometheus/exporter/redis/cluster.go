@@ -0,0 +1,279 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/gomodule/redigo/redis"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/integrations"
+	int_config "github.com/grafana/agent/pkg/integrations/config"
+	"github.com/grafana/agent/pkg/integrations/redis_exporter"
+	"github.com/oklog/run"
+	re "github.com/oliver006/redis_exporter/exporter"
+)
+
+// instanceParam is the query parameter of a scrape which selects the cluster
+// node whose metrics are returned.
+const instanceParam = "instance"
+
+// clusterIntegration runs a separate redis_exporter for every node of a Redis
+// Cluster, so that every node is exposed as its own target.
+type clusterIntegration struct {
+	nodes     []string
+	instances map[string]integrations.Integration
+}
+
+// newClusterIntegration creates a clusterIntegration for the given nodes,
+// which are the addresses of the nodes as reported by CLUSTER NODES.
+func newClusterIntegration(l log.Logger, c *redis_exporter.Config, nodes []string) (*clusterIntegration, error) {
+	i := &clusterIntegration{
+		nodes:     nodes,
+		instances: make(map[string]integrations.Integration, len(nodes)),
+	}
+	for _, node := range nodes {
+		nodeConfig := *c
+		nodeConfig.RedisAddr = nodeAddr(c.RedisAddr, node)
+		// Every node is connected to directly, so only the keys held by the
+		// node are checked.
+		nodeConfig.IsCluster = false
+
+		instance, err := nodeConfig.NewIntegration(log.With(l, "node", node))
+		if err != nil {
+			return nil, err
+		}
+		i.instances[node] = instance
+	}
+	return i, nil
+}
+
+// nodeAddr returns the address used to connect to node, which uses the same
+// scheme as redisAddr.
+func nodeAddr(redisAddr, node string) string {
+	if scheme, _, ok := strings.Cut(redisAddr, "://"); ok {
+		return scheme + "://" + node
+	}
+	return node
+}
+
+// Targets implements exporter.TargetsIntegration. A target is returned for
+// every node, which selects the node through a query parameter.
+func (i *clusterIntegration) Targets(baseTarget discovery.Target) []discovery.Target {
+	targets := make([]discovery.Target, 0, len(i.nodes))
+	for _, node := range i.nodes {
+		target := make(discovery.Target)
+		for k, v := range baseTarget {
+			target[k] = v
+		}
+
+		target["instance"] = node
+		target["__param_"+instanceParam] = node
+
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// MetricsHandler implements integrations.Integration. The handler returns the
+// metrics of the node selected by the instance query parameter.
+func (i *clusterIntegration) MetricsHandler() (http.Handler, error) {
+	handlers := make(map[string]http.Handler, len(i.instances))
+	for node, instance := range i.instances {
+		h, err := instance.MetricsHandler()
+		if err != nil {
+			return nil, err
+		}
+		handlers[node] = h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		node := r.URL.Query().Get(instanceParam)
+		h, ok := handlers[node]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown cluster node %q", node), http.StatusBadRequest)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}), nil
+}
+
+// ScrapeConfigs implements integrations.Integration. Targets of the nodes are
+// returned by Targets instead.
+func (i *clusterIntegration) ScrapeConfigs() []int_config.ScrapeConfig {
+	return nil
+}
+
+// Run implements integrations.Integration.
+func (i *clusterIntegration) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var rg run.Group
+	for _, instance := range i.instances {
+		instance := instance
+		rg.Add(func() error {
+			return instance.Run(ctx)
+		}, func(_ error) {
+			cancel()
+		})
+	}
+	return rg.Run()
+}
+
+// discoverClusterNodes connects to the Redis instance of c and returns the
+// addresses of the nodes of its cluster.
+func discoverClusterNodes(c *redis_exporter.Config) ([]string, error) {
+	conn, err := dialRedis(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", c.RedisAddr, err)
+	}
+	defer conn.Close()
+
+	out, err := redis.String(conn.Do("CLUSTER", "NODES"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster nodes: %w", err)
+	}
+
+	nodes, err := parseClusterNodes(out)
+	if err != nil {
+		return nil, err
+	} else if len(nodes) == 0 {
+		return nil, fmt.Errorf("no cluster nodes found")
+	}
+	return nodes, nil
+}
+
+// dialRedis connects to the Redis instance of c with the same settings the
+// exporter uses.
+func dialRedis(c *redis_exporter.Config) (redis.Conn, error) {
+	addr := c.RedisAddr
+	if !strings.Contains(addr, "://") {
+		addr = "redis://" + addr
+	}
+
+	tlsConfig, err := clientTLSConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	options := []redis.DialOption{
+		redis.DialConnectTimeout(c.ConnectionTimeout),
+		redis.DialReadTimeout(c.ConnectionTimeout),
+		redis.DialWriteTimeout(c.ConnectionTimeout),
+		redis.DialTLSConfig(tlsConfig),
+	}
+
+	if c.RedisUser != "" {
+		options = append(options, redis.DialUsername(c.RedisUser))
+	}
+	password, err := redisPassword(c, addr)
+	if err != nil {
+		return nil, err
+	}
+	if password != "" {
+		options = append(options, redis.DialPassword(password))
+	}
+
+	return redis.DialURL(addr, options...)
+}
+
+// clientTLSConfig returns the TLS config to connect to Redis with, which
+// matches the TLS config of the exporter.
+func clientTLSConfig(c *redis_exporter.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.SkipTLSVerification,
+	}
+
+	if c.TLSClientCertFile != "" && c.TLSClientKeyFile != "" {
+		cert, err := re.LoadKeyPair(c.TLSClientCertFile, c.TLSClientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{*cert}
+	}
+
+	if c.TLSCaCertFile != "" {
+		certificates, err := re.LoadCAFile(c.TLSCaCertFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = certificates
+	}
+
+	return tlsConfig, nil
+}
+
+// redisPassword returns the password to connect to the Redis instance at uri
+// with, which is read from the password file or password map file if set.
+func redisPassword(c *redis_exporter.Config, uri string) (string, error) {
+	switch {
+	case c.RedisPasswordFile != "":
+		password, err := os.ReadFile(c.RedisPasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("error loading password file %s: %w", c.RedisPasswordFile, err)
+		}
+		return strings.TrimSpace(string(password)), nil
+	case c.RedisPasswordMapFile != "":
+		passwordMap, err := re.LoadPwdFile(c.RedisPasswordMapFile)
+		if err != nil {
+			return "", fmt.Errorf("error loading password map file %s: %w", c.RedisPasswordMapFile, err)
+		}
+		return passwordMap[uri], nil
+	default:
+		return string(c.RedisPassword), nil
+	}
+}
+
+// parseClusterNodes parses the output of CLUSTER NODES and returns the sorted
+// addresses of the nodes. Nodes which are failing, are still being added to
+// the cluster, or have no address are skipped.
+func parseClusterNodes(out string) ([]string, error) {
+	var nodes []string
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// Every line has the form:
+		//   <id> <ip:port@cport[,hostname]> <flags> <master> <ping-sent> <pong-recv> <config-epoch> <link-state> <slot> ...
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("malformed cluster node %q", line)
+		}
+		if skipClusterNode(strings.Split(fields[2], ",")) {
+			continue
+		}
+
+		addr, _, _ := strings.Cut(fields[1], ",")
+		addr, _, _ = strings.Cut(addr, "@")
+		if strings.HasPrefix(addr, ":") {
+			continue
+		}
+		nodes = append(nodes, addr)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+func skipClusterNode(flags []string) bool {
+	for _, flag := range flags {
+		switch flag {
+		case "fail", "handshake", "noaddr":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/integrations/redis_exporter"
+	"github.com/stretchr/testify/require"
+)
+
+const clusterNodes = `07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004,node-4 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected
+67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002 master - 0 1426238316232 2 connected 5461-10922
+292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 127.0.0.1:30003@31003 master - 0 1426238318243 3 connected 10923-16383
+6ec23923021cf3ffec47632106199cb7f496ce01 127.0.0.1:30005@31005 slave,fail 67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 0 1426238316232 5 disconnected
+824fe116063bc5fcf9f4ffd895bc17aee7731ac3 127.0.0.1:30006@31006 handshake - 0 1426238317741 6 connected
+e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-5460
+1b9a4ac94f4fa43a6ac0c5d0ef0c6e0e5a0e8b8b :0@0 slave,noaddr e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238316232 7 disconnected
+`
+
+func TestParseClusterNodes(t *testing.T) {
+	nodes, err := parseClusterNodes(clusterNodes)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"127.0.0.1:30001",
+		"127.0.0.1:30002",
+		"127.0.0.1:30003",
+		"127.0.0.1:30004",
+	}, nodes)
+
+	_, err = parseClusterNodes("e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001")
+	require.Error(t, err)
+}
+
+func TestDiscoverClusterNodes(t *testing.T) {
+	addr := serveClusterNodes(t, clusterNodes)
+
+	nodes, err := discoverClusterNodes(&redis_exporter.Config{RedisAddr: addr})
+	require.NoError(t, err)
+	require.Len(t, nodes, 4)
+}
+
+func TestClusterIntegration_Targets(t *testing.T) {
+	i := &clusterIntegration{nodes: []string{"127.0.0.1:30001", "127.0.0.1:30002"}}
+
+	baseTarget := discovery.Target{
+		"__address__": "localhost:12345",
+		"instance":    "prometheus.exporter.redis.example",
+		"job":         "integrations/redis",
+	}
+	require.Equal(t, []discovery.Target{
+		{
+			"__address__":      "localhost:12345",
+			"__param_instance": "127.0.0.1:30001",
+			"instance":         "127.0.0.1:30001",
+			"job":              "integrations/redis",
+		},
+		{
+			"__address__":      "localhost:12345",
+			"__param_instance": "127.0.0.1:30002",
+			"instance":         "127.0.0.1:30002",
+			"job":              "integrations/redis",
+		},
+	}, i.Targets(baseTarget))
+}
+
+func TestNodeAddr(t *testing.T) {
+	require.Equal(t, "127.0.0.1:30001", nodeAddr("localhost:6379", "127.0.0.1:30001"))
+	require.Equal(t, "rediss://127.0.0.1:30001", nodeAddr("rediss://localhost:6379", "127.0.0.1:30001"))
+}
+
+// serveClusterNodes starts a server which replies to every command with out
+// and returns its address.
+func serveClusterNodes(t *testing.T, out string) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = lis.Close() })
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					if err := readCommand(r); err != nil {
+						return
+					}
+					_, _ = fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(out), out)
+				}
+			}()
+		}
+	}()
+	return lis.Addr().String()
+}
+
+// readCommand reads a command encoded as a RESP array of bulk strings.
+func readCommand(r *bufio.Reader) error {
+	var n int
+	if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+		return err
+	}
+	for i := 0; i < 2*n; i++ {
+		if _, err := r.ReadString('\n'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
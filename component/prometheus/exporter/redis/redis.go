@@ -5,11 +5,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/prometheus/exporter"
 	"github.com/grafana/agent/pkg/flow/rivertypes"
 	"github.com/grafana/agent/pkg/integrations"
 	"github.com/grafana/agent/pkg/integrations/redis_exporter"
+	"github.com/grafana/agent/pkg/river"
 	config_util "github.com/prometheus/common/config"
 )
 
@@ -24,7 +26,17 @@ func init() {
 
 func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
 	a := args.(Arguments)
-	return a.Convert().NewIntegration(opts.Logger)
+	cfg := a.Convert()
+	if !a.ClusterDiscovery {
+		return cfg.NewIntegration(opts.Logger)
+	}
+
+	nodes, err := discoverClusterNodes(cfg)
+	if err != nil {
+		level.Warn(opts.Logger).Log("msg", "failed to discover Redis Cluster nodes, collecting metrics from redis_addr only", "err", err)
+		return cfg.NewIntegration(opts.Logger)
+	}
+	return newClusterIntegration(opts.Logger, cfg, nodes)
 }
 
 // DefaultArguments holds non-zero default options for Arguments when it is
@@ -76,6 +88,10 @@ type Arguments struct {
 	PingOnConnect           bool              `river:"ping_on_connect,attr,optional"`
 	InclSystemMetrics       bool              `river:"incl_system_metrics,attr,optional"`
 	SkipTLSVerification     bool              `river:"skip_tls_verification,attr,optional"`
+
+	// ClusterDiscovery discovers the nodes of a Redis Cluster through
+	// CLUSTER NODES and exports a target for every node.
+	ClusterDiscovery bool `river:"cluster_discovery,attr,optional"`
 }
 
 // UnmarshalRiver implements River unmarshalling for Config.
@@ -83,17 +99,19 @@ func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	*a = DefaultArguments
 
 	type args Arguments
-	if err := f((*args)(a)); err != nil {
-		return err
-	}
-	return a.Validate()
+	return f((*args)(a))
 }
 
+// Validate implements river.Validator.
 func (a *Arguments) Validate() error {
+	var errs river.ValidationErrors
 	if a.ScriptPath != "" && len(a.ScriptPaths) > 0 {
-		return fmt.Errorf("only one of script_path and script_paths should be specified")
+		errs.Add(river.PathError("script_paths", fmt.Errorf("only one of script_path and script_paths should be specified")))
+	}
+	if a.ClusterDiscovery && !a.IsCluster {
+		errs.Add(river.PathError("cluster_discovery", fmt.Errorf("cluster_discovery requires is_cluster to be true")))
 	}
-	return nil
+	return errs.ErrorOrNil()
 }
 
 func (a *Arguments) Convert() *redis_exporter.Config {
@@ -38,6 +38,7 @@ func TestRiverUnmarshal(t *testing.T) {
 		incl_system_metrics         = true
 		skip_tls_verification       = false
 		is_cluster                  = true
+		cluster_discovery           = true
 	`
 	var args Arguments
 	err := river.Unmarshal([]byte(riverConfig), &args)
@@ -76,6 +77,7 @@ func TestRiverUnmarshal(t *testing.T) {
 		InclSystemMetrics:   true,
 		SkipTLSVerification: false,
 		IsCluster:           true,
+		ClusterDiscovery:    true,
 	}
 	require.Equal(t, expected, args)
 }
@@ -99,6 +101,16 @@ func TestUnmarshalInvalid(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestValidate(t *testing.T) {
+	riverConfig := `
+	redis_addr        = "localhost:1234"
+	cluster_discovery = true`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.EqualError(t, err, "3:2: cluster_discovery requires is_cluster to be true")
+}
+
 func TestRiverConvert(t *testing.T) {
 	orig := Arguments{
 		RedisAddr:         "localhost:6379",
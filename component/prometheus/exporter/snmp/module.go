@@ -0,0 +1,233 @@
+package snmp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	snmp_config "github.com/prometheus/snmp_exporter/config"
+)
+
+// Module defines an SNMP module inline, as an alternative to defining it in
+// the config file.
+type Module struct {
+	Name string   `river:",label"`
+	Walk []string `river:"walk,attr,optional"`
+	Get  []string `river:"get,attr,optional"`
+
+	Version                 int           `river:"version,attr,optional"`
+	MaxRepetitions          uint32        `river:"max_repetitions,attr,optional"`
+	Retries                 int           `river:"retries,attr,optional"`
+	Timeout                 time.Duration `river:"timeout,attr,optional"`
+	Auth                    Auth          `river:"auth,block,optional"`
+	UseUnconnectedUDPSocket bool          `river:"use_unconnected_udp_socket,attr,optional"`
+
+	Metrics []Metric `river:"metric,block,optional"`
+}
+
+// DefaultModule holds the default settings of inline modules, which match
+// the defaults of modules in the config file.
+var DefaultModule = Module{
+	Version:        snmp_config.DefaultWalkParams.Version,
+	MaxRepetitions: snmp_config.DefaultWalkParams.MaxRepetitions,
+	Retries:        snmp_config.DefaultWalkParams.Retries,
+	Timeout:        snmp_config.DefaultWalkParams.Timeout,
+	Auth: Auth{
+		Community:     "public",
+		SecurityLevel: snmp_config.DefaultAuth.SecurityLevel,
+		AuthProtocol:  snmp_config.DefaultAuth.AuthProtocol,
+		PrivProtocol:  snmp_config.DefaultAuth.PrivProtocol,
+	},
+}
+
+// UnmarshalRiver implements River unmarshalling for Module.
+func (m *Module) UnmarshalRiver(f func(interface{}) error) error {
+	*m = DefaultModule
+
+	type module Module
+	if err := f((*module)(m)); err != nil {
+		return err
+	}
+	return m.Validate()
+}
+
+// Validate returns an error if the module is invalid.
+func (m *Module) Validate() error {
+	if m.Version < 1 || m.Version > 3 {
+		return fmt.Errorf("module %q: SNMP version must be 1, 2 or 3, got %d", m.Name, m.Version)
+	}
+	for _, metric := range m.Metrics {
+		if err := metric.validate(); err != nil {
+			return fmt.Errorf("module %q: metric %q: %w", m.Name, metric.Name, err)
+		}
+	}
+	return nil
+}
+
+// Metric defines how an OID of a module is exposed as a metric.
+type Metric struct {
+	Name           string            `river:",label"`
+	Oid            string            `river:"oid,attr"`
+	Type           string            `river:"type,attr"`
+	Help           string            `river:"help,attr,optional"`
+	Indexes        []Index           `river:"index,block,optional"`
+	Lookups        []Lookup          `river:"lookup,block,optional"`
+	RegexpExtracts []RegexpExtract   `river:"regex_extract,block,optional"`
+	EnumValues     map[string]string `river:"enum_values,attr,optional"`
+}
+
+func (m *Metric) validate() error {
+	if _, err := convertEnumValues(m.EnumValues); err != nil {
+		return err
+	}
+	for _, idx := range m.Indexes {
+		if _, err := convertEnumValues(idx.EnumValues); err != nil {
+			return fmt.Errorf("index %q: %w", idx.Labelname, err)
+		}
+	}
+	for _, re := range m.RegexpExtracts {
+		if _, err := compileRegexp(re.Regex); err != nil {
+			return fmt.Errorf("regex_extract %q: %w", re.Name, err)
+		}
+	}
+	return nil
+}
+
+// Index defines an index of the OID of a metric which is exposed as a label.
+type Index struct {
+	Labelname  string            `river:",label"`
+	Type       string            `river:"type,attr"`
+	FixedSize  int               `river:"fixed_size,attr,optional"`
+	Implied    bool              `river:"implied,attr,optional"`
+	EnumValues map[string]string `river:"enum_values,attr,optional"`
+}
+
+// Lookup defines a label whose value is looked up from another OID using the
+// values of indexes.
+type Lookup struct {
+	Labelname string   `river:",label"`
+	Labels    []string `river:"labels,attr"`
+	Oid       string   `river:"oid,attr,optional"`
+	Type      string   `river:"type,attr,optional"`
+}
+
+// RegexpExtract defines a metric which is extracted from the string value of
+// an OID. Name is appended to the name of the metric, and multiple blocks may
+// use the same name.
+type RegexpExtract struct {
+	Name  string `river:",label"`
+	Value string `river:"value,attr,optional"`
+	Regex string `river:"regex,attr"`
+}
+
+// DefaultRegexpExtract holds the default settings of a RegexpExtract.
+var DefaultRegexpExtract = RegexpExtract{
+	Value: snmp_config.DefaultRegexpExtract.Value,
+}
+
+// UnmarshalRiver implements River unmarshalling for RegexpExtract.
+func (r *RegexpExtract) UnmarshalRiver(f func(interface{}) error) error {
+	*r = DefaultRegexpExtract
+
+	type regexpExtract RegexpExtract
+	return f((*regexpExtract)(r))
+}
+
+// Modules is a list of inline modules.
+type Modules []Module
+
+// Convert converts the component's Modules to the integration's modules.
+// Convert must only be called on modules which have been validated.
+func (m Modules) Convert() snmp_config.Config {
+	if len(m) == 0 {
+		return nil
+	}
+
+	res := make(snmp_config.Config, len(m))
+	for _, module := range m {
+		metrics := make([]*snmp_config.Metric, 0, len(module.Metrics))
+		for _, metric := range module.Metrics {
+			metrics = append(metrics, metric.convert())
+		}
+
+		res[module.Name] = &snmp_config.Module{
+			Walk:    module.Walk,
+			Get:     module.Get,
+			Metrics: metrics,
+			WalkParams: snmp_config.WalkParams{
+				Version:                 module.Version,
+				MaxRepetitions:          module.MaxRepetitions,
+				Retries:                 module.Retries,
+				Timeout:                 module.Timeout,
+				Auth:                    module.Auth.Convert(),
+				UseUnconnectedUDPSocket: module.UseUnconnectedUDPSocket,
+			},
+		}
+	}
+	return res
+}
+
+func (m *Metric) convert() *snmp_config.Metric {
+	res := &snmp_config.Metric{
+		Name: m.Name,
+		Oid:  m.Oid,
+		Type: m.Type,
+		Help: m.Help,
+	}
+	res.EnumValues, _ = convertEnumValues(m.EnumValues)
+
+	for _, idx := range m.Indexes {
+		enumValues, _ := convertEnumValues(idx.EnumValues)
+		res.Indexes = append(res.Indexes, &snmp_config.Index{
+			Labelname:  idx.Labelname,
+			Type:       idx.Type,
+			FixedSize:  idx.FixedSize,
+			Implied:    idx.Implied,
+			EnumValues: enumValues,
+		})
+	}
+	for _, lookup := range m.Lookups {
+		res.Lookups = append(res.Lookups, &snmp_config.Lookup{
+			Labels:    lookup.Labels,
+			Labelname: lookup.Labelname,
+			Oid:       lookup.Oid,
+			Type:      lookup.Type,
+		})
+	}
+	for _, re := range m.RegexpExtracts {
+		if res.RegexpExtracts == nil {
+			res.RegexpExtracts = make(map[string][]snmp_config.RegexpExtract)
+		}
+		regex, _ := compileRegexp(re.Regex)
+		res.RegexpExtracts[re.Name] = append(res.RegexpExtracts[re.Name], snmp_config.RegexpExtract{
+			Value: re.Value,
+			Regex: snmp_config.Regexp{Regexp: regex},
+		})
+	}
+	return res
+}
+
+// convertEnumValues converts enum values with string keys, which is the only
+// kind of key River supports, to the integer keys used by snmp_exporter.
+func convertEnumValues(in map[string]string) (map[int]string, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+
+	res := make(map[int]string, len(in))
+	for k, v := range in {
+		i, err := strconv.Atoi(k)
+		if err != nil {
+			return nil, fmt.Errorf("enum value key %q is not an integer", k)
+		}
+		res[i] = v
+	}
+	return res, nil
+}
+
+// compileRegexp compiles the regular expression of a RegexpExtract. Like in
+// the config file, the expression is anchored on both ends.
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + expr + ")$")
+}
@@ -0,0 +1,147 @@
+package snmp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	snmp_config "github.com/prometheus/snmp_exporter/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRiver_Modules(t *testing.T) {
+	riverCfg := `
+		module "if_mib" {
+			walk    = ["1.3.6.1.2.1.2"]
+			retries = 5
+
+			metric "ifOperStatus" {
+				oid         = "1.3.6.1.2.1.2.2.1.8"
+				type        = "gauge"
+				help        = "The current operational state of the interface."
+				enum_values = {"1" = "up", "2" = "down"}
+
+				index "ifIndex" {
+					type = "gauge"
+				}
+				lookup "ifDescr" {
+					labels = ["ifIndex"]
+					oid    = "1.3.6.1.2.1.2.2.1.2"
+					type   = "DisplayString"
+				}
+			}
+
+			metric "sensorValue" {
+				oid  = "1.3.6.1.4.1.1.1"
+				type = "DisplayString"
+
+				regex_extract "Temp" {
+					regex = "Temp: ([0-9.]+)"
+				}
+				regex_extract "Temp" {
+					value = "1"
+					regex = "OK"
+				}
+			}
+		}
+		target "network_switch_1" {
+			address = "192.168.1.2"
+			module  = "if_mib"
+		}
+`
+	var args Arguments
+	err := river.Unmarshal([]byte(riverCfg), &args)
+	require.NoError(t, err)
+	require.Empty(t, args.ConfigFile)
+
+	modules := args.Convert().SnmpModules
+	require.Len(t, modules, 1)
+
+	module := modules["if_mib"]
+	require.Equal(t, []string{"1.3.6.1.2.1.2"}, module.Walk)
+	require.Equal(t, 2, module.WalkParams.Version)
+	require.Equal(t, 5, module.WalkParams.Retries)
+	require.Equal(t, 5*time.Second, module.WalkParams.Timeout)
+	require.Equal(t, snmp_config.Secret("public"), module.WalkParams.Auth.Community)
+
+	require.Len(t, module.Metrics, 2)
+	status := module.Metrics[0]
+	require.Equal(t, "ifOperStatus", status.Name)
+	require.Equal(t, map[int]string{1: "up", 2: "down"}, status.EnumValues)
+	require.Equal(t, []*snmp_config.Index{{Labelname: "ifIndex", Type: "gauge"}}, status.Indexes)
+	require.Equal(t, []*snmp_config.Lookup{{Labels: []string{"ifIndex"}, Labelname: "ifDescr", Oid: "1.3.6.1.2.1.2.2.1.2", Type: "DisplayString"}}, status.Lookups)
+
+	extracts := module.Metrics[1].RegexpExtracts["Temp"]
+	require.Len(t, extracts, 2)
+	require.Equal(t, "$1", extracts[0].Value)
+	require.True(t, extracts[0].Regex.MatchString("Temp: 21.5"))
+	require.Equal(t, "1", extracts[1].Value)
+	require.False(t, extracts[1].Regex.MatchString("NOT OK"))
+}
+
+func TestUnmarshalRiver_InvalidModules(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name: "duplicate module",
+			cfg: `
+				module "a" {}
+				module "a" {}
+			`,
+			expect: `module "a" is defined more than once`,
+		},
+		{
+			name: "invalid version",
+			cfg: `
+				module "a" {
+					version = 4
+				}
+			`,
+			expect: "SNMP version must be 1, 2 or 3",
+		},
+		{
+			name: "invalid enum value",
+			cfg: `
+				module "a" {
+					metric "m" {
+						oid         = "1.2.3"
+						type        = "gauge"
+						enum_values = {"up" = "1"}
+					}
+				}
+			`,
+			expect: `enum value key "up" is not an integer`,
+		},
+		{
+			name: "invalid regex",
+			cfg: `
+				module "a" {
+					metric "m" {
+						oid  = "1.2.3"
+						type = "DisplayString"
+
+						regex_extract "Temp" {
+							regex = "("
+						}
+					}
+				}
+			`,
+			expect: "error parsing regexp",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg+`
+				target "t" {
+					address = "192.168.1.2"
+				}
+			`), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package snmp
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/grafana/agent/component"
@@ -130,7 +131,8 @@ func (w WalkParams) Convert() map[string]snmp_config.WalkParams {
 }
 
 type Arguments struct {
-	ConfigFile string      `river:"config_file,attr"`
+	ConfigFile string      `river:"config_file,attr,optional"`
+	Modules    Modules     `river:"module,block,optional"`
 	Targets    TargetBlock `river:"target,block"`
 	WalkParams WalkParams  `river:"walk_param,block,optional"`
 }
@@ -138,13 +140,29 @@ type Arguments struct {
 // UnmarshalRiver implements River unmarshalling for Arguments.
 func (a *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	type args Arguments
-	return f((*args)(a))
+	if err := f((*args)(a)); err != nil {
+		return err
+	}
+	return a.Validate()
+}
+
+// Validate returns an error if the arguments are invalid.
+func (a *Arguments) Validate() error {
+	names := make(map[string]struct{}, len(a.Modules))
+	for _, m := range a.Modules {
+		if _, ok := names[m.Name]; ok {
+			return fmt.Errorf("module %q is defined more than once", m.Name)
+		}
+		names[m.Name] = struct{}{}
+	}
+	return nil
 }
 
 // Convert converts the component's Arguments to the integration's Config.
 func (a *Arguments) Convert() *snmp_exporter.Config {
 	return &snmp_exporter.Config{
 		SnmpConfigFile: a.ConfigFile,
+		SnmpModules:    a.Modules.Convert(),
 		SnmpTargets:    a.Targets.Convert(),
 		WalkParams:     a.WalkParams.Convert(),
 	}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/grafana/agent/pkg/integrations/statsd_exporter"
+	"github.com/grafana/agent/pkg/river"
 	"github.com/prometheus/statsd_exporter/pkg/mapper"
 )
 
@@ -16,6 +17,9 @@ type Arguments struct {
 	UnixSocketMode string `river:"unix_socket_mode,attr,optional"`
 	MappingConfig  string `river:"mapping_config_path,attr,optional"`
 
+	MappingDefaults *MappingDefaults `river:"mapping_defaults,block,optional"`
+	Mappings        []Mapping        `river:"mapping,block,optional"`
+
 	ReadBuffer          int           `river:"read_buffer,attr,optional"`
 	CacheSize           int           `river:"cache_size,attr,optional"`
 	CacheType           string        `river:"cache_type,attr,optional"`
@@ -53,7 +57,16 @@ var DefaultConfig = Arguments{
 
 // Convert gives a config suitable for use with github.com/grafana/agent/pkg/integrations/statsd_exporter.
 func (c *Arguments) Convert() (*statsd_exporter.Config, error) {
-	mappingConfig, err := readMappingFromYAML(c.MappingConfig)
+	var (
+		mappingConfig *mapper.MetricMapper
+		err           error
+	)
+	switch {
+	case c.MappingConfig != "":
+		mappingConfig, err = readMappingFromYAML(c.MappingConfig)
+	case c.MappingDefaults != nil || len(c.Mappings) > 0:
+		mappingConfig, err = newMetricMapper(c.MappingDefaults, c.Mappings)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert statsd config: %w", err)
 	}
@@ -85,24 +98,39 @@ func (c *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	return f((*args)(c))
 }
 
-// function to read a yaml file from a path and convert it to a mapper.MappingConfig
-// this is used to convert the MappingConfig field in to a mapper.MappingConfig
-// which is used by the statsd_exporter
-func readMappingFromYAML(path string) (*mapper.MetricMapper, error) {
-	yfile, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read mapping config file: %w", err)
+// Validate implements river.Validator.
+func (c *Arguments) Validate() error {
+	if c.MappingConfig != "" && (c.MappingDefaults != nil || len(c.Mappings) > 0) {
+		return river.PathError("mapping_config_path", fmt.Errorf("mapping_config_path can't be used together with mapping_defaults or mapping blocks"))
 	}
 
-	yBytes := make([]byte, 0)
-	count, err := yfile.Read(yBytes)
+	// Mappings are checked one at a time so that errors can be reported at the
+	// mapping which caused them.
+	var errs river.ValidationErrors
+	if c.MappingDefaults != nil {
+		if _, err := newMetricMapper(c.MappingDefaults, nil); err != nil {
+			errs.Add(river.PathError("mapping_defaults", err))
+			return errs.ErrorOrNil()
+		}
+	}
+	for i, m := range c.Mappings {
+		if _, err := newMetricMapper(c.MappingDefaults, []Mapping{m}); err != nil {
+			errs.Add(river.PathError(fmt.Sprintf("mapping[%d]", i), err))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// readMappingFromYAML reads a statsd_exporter mapping config file from path.
+func readMappingFromYAML(path string) (*mapper.MetricMapper, error) {
+	bb, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read mapping config file: %w", err)
 	}
 
 	statsdMapper := mapper.MetricMapper{}
 
-	err = statsdMapper.InitFromYAMLString(string(yBytes[:count]))
+	err = statsdMapper.InitFromYAMLString(string(bb))
 	if err != nil {
 		return nil, fmt.Errorf("failed to load mapping config: %w", err)
 	}
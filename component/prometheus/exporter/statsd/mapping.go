@@ -0,0 +1,169 @@
+package statsd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/statsd_exporter/pkg/mapper"
+	"gopkg.in/yaml.v2"
+)
+
+// MappingDefaults holds the settings used by mappings which don't set them
+// themselves.
+type MappingDefaults struct {
+	ObserverType        string            `river:"observer_type,attr,optional"`
+	MatchType           string            `river:"match_type,attr,optional"`
+	GlobDisableOrdering bool              `river:"glob_disable_ordering,attr,optional"`
+	TTL                 time.Duration     `river:"ttl,attr,optional"`
+	HistogramOptions    *HistogramOptions `river:"histogram_options,block,optional"`
+	SummaryOptions      *SummaryOptions   `river:"summary_options,block,optional"`
+}
+
+// Mapping maps StatsD metrics which match a pattern to a Prometheus metric.
+type Mapping struct {
+	Match            string            `river:"match,attr"`
+	Name             string            `river:"name,attr"`
+	Labels           map[string]string `river:"labels,attr,optional"`
+	Help             string            `river:"help,attr,optional"`
+	MatchType        string            `river:"match_type,attr,optional"`
+	MatchMetricType  string            `river:"match_metric_type,attr,optional"`
+	Action           string            `river:"action,attr,optional"`
+	ObserverType     string            `river:"observer_type,attr,optional"`
+	TTL              time.Duration     `river:"ttl,attr,optional"`
+	HistogramOptions *HistogramOptions `river:"histogram_options,block,optional"`
+	SummaryOptions   *SummaryOptions   `river:"summary_options,block,optional"`
+}
+
+// HistogramOptions configures the histograms of observed metrics.
+type HistogramOptions struct {
+	Buckets []float64 `river:"buckets,attr"`
+}
+
+// SummaryOptions configures the summaries of observed metrics.
+type SummaryOptions struct {
+	Quantiles  []Quantile    `river:"quantile,block,optional"`
+	MaxAge     time.Duration `river:"max_age,attr,optional"`
+	AgeBuckets uint32        `river:"age_buckets,attr,optional"`
+	BufCap     uint32        `river:"buf_cap,attr,optional"`
+}
+
+// Quantile is a quantile of a summary with its allowed error.
+type Quantile struct {
+	Quantile float64 `river:"quantile,attr"`
+	Error    float64 `river:"error,attr"`
+}
+
+// The mapper package doesn't allow building a MetricMapper directly, so the
+// River mappings are converted to the YAML format of mapping config files
+// instead.
+type (
+	yamlMappingConfig struct {
+		Defaults yamlMappingDefaults `yaml:"defaults,omitempty"`
+		Mappings []yamlMapping       `yaml:"mappings,omitempty"`
+	}
+
+	yamlMappingDefaults struct {
+		ObserverType        string                `yaml:"observer_type,omitempty"`
+		MatchType           string                `yaml:"match_type,omitempty"`
+		GlobDisableOrdering bool                  `yaml:"glob_disable_ordering,omitempty"`
+		TTL                 time.Duration         `yaml:"ttl,omitempty"`
+		HistogramOptions    *yamlHistogramOptions `yaml:"histogram_options,omitempty"`
+		SummaryOptions      *yamlSummaryOptions   `yaml:"summary_options,omitempty"`
+	}
+
+	yamlMapping struct {
+		Match            string                `yaml:"match"`
+		Name             string                `yaml:"name"`
+		Labels           map[string]string     `yaml:"labels,omitempty"`
+		Help             string                `yaml:"help,omitempty"`
+		MatchType        string                `yaml:"match_type,omitempty"`
+		MatchMetricType  string                `yaml:"match_metric_type,omitempty"`
+		Action           string                `yaml:"action,omitempty"`
+		ObserverType     string                `yaml:"observer_type,omitempty"`
+		TTL              time.Duration         `yaml:"ttl,omitempty"`
+		HistogramOptions *yamlHistogramOptions `yaml:"histogram_options,omitempty"`
+		SummaryOptions   *yamlSummaryOptions   `yaml:"summary_options,omitempty"`
+	}
+
+	yamlHistogramOptions struct {
+		Buckets []float64 `yaml:"buckets,omitempty"`
+	}
+
+	yamlSummaryOptions struct {
+		Quantiles  []yamlQuantile `yaml:"quantiles,omitempty"`
+		MaxAge     time.Duration  `yaml:"max_age,omitempty"`
+		AgeBuckets uint32         `yaml:"age_buckets,omitempty"`
+		BufCap     uint32         `yaml:"buf_cap,omitempty"`
+	}
+
+	yamlQuantile struct {
+		Quantile float64 `yaml:"quantile"`
+		Error    float64 `yaml:"error"`
+	}
+)
+
+// newMetricMapper creates a MetricMapper from River mappings.
+func newMetricMapper(defaults *MappingDefaults, mappings []Mapping) (*mapper.MetricMapper, error) {
+	cfg := yamlMappingConfig{
+		Mappings: make([]yamlMapping, 0, len(mappings)),
+	}
+	if defaults != nil {
+		cfg.Defaults = yamlMappingDefaults{
+			ObserverType:        defaults.ObserverType,
+			MatchType:           defaults.MatchType,
+			GlobDisableOrdering: defaults.GlobDisableOrdering,
+			TTL:                 defaults.TTL,
+			HistogramOptions:    defaults.HistogramOptions.toYAML(),
+			SummaryOptions:      defaults.SummaryOptions.toYAML(),
+		}
+	}
+	for _, m := range mappings {
+		cfg.Mappings = append(cfg.Mappings, yamlMapping{
+			Match:            m.Match,
+			Name:             m.Name,
+			Labels:           m.Labels,
+			Help:             m.Help,
+			MatchType:        m.MatchType,
+			MatchMetricType:  m.MatchMetricType,
+			Action:           m.Action,
+			ObserverType:     m.ObserverType,
+			TTL:              m.TTL,
+			HistogramOptions: m.HistogramOptions.toYAML(),
+			SummaryOptions:   m.SummaryOptions.toYAML(),
+		})
+	}
+
+	bb, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize mappings: %w", err)
+	}
+
+	var statsdMapper mapper.MetricMapper
+	if err := statsdMapper.InitFromYAMLString(string(bb)); err != nil {
+		return nil, err
+	}
+	return &statsdMapper, nil
+}
+
+func (o *HistogramOptions) toYAML() *yamlHistogramOptions {
+	if o == nil {
+		return nil
+	}
+	return &yamlHistogramOptions{Buckets: o.Buckets}
+}
+
+func (o *SummaryOptions) toYAML() *yamlSummaryOptions {
+	if o == nil {
+		return nil
+	}
+
+	res := &yamlSummaryOptions{
+		MaxAge:     o.MaxAge,
+		AgeBuckets: o.AgeBuckets,
+		BufCap:     o.BufCap,
+	}
+	for _, q := range o.Quantiles {
+		res.Quantiles = append(res.Quantiles, yamlQuantile(q))
+	}
+	return res
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/prometheus/statsd_exporter/pkg/mapper"
 	"github.com/stretchr/testify/require"
 )
 
@@ -71,3 +73,113 @@ func TestConvert(t *testing.T) {
 	require.Equal(t, false, configStatsd.ParseLibrato)
 	require.Equal(t, false, configStatsd.ParseSignalFX)
 }
+
+func TestConvert_Mappings(t *testing.T) {
+	riverConfig := `
+		mapping_defaults {
+			observer_type = "histogram"
+			ttl           = "10m"
+
+			histogram_options {
+				buckets = [0.01, 0.1, 1]
+			}
+		}
+
+		mapping {
+			match  = "test.timing.*.*.*"
+			name   = "my_timer"
+			labels = {
+				provider = "$2",
+				outcome  = "$3",
+			}
+		}
+
+		mapping {
+			match         = "other.distribution.*"
+			name          = "other_distribution"
+			observer_type = "summary"
+
+			summary_options {
+				quantile {
+					quantile = 0.99
+					error    = 0.001
+				}
+				max_age = "30s"
+			}
+		}
+
+		mapping {
+			match      = "."
+			match_type = "regex"
+			name       = "dropped"
+			action     = "drop"
+		}
+	`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverConfig), &args))
+
+	configStatsd, err := args.Convert()
+	require.NoError(t, err)
+
+	m := configStatsd.MappingConfig
+	require.NotNil(t, m)
+	require.Equal(t, 10*time.Minute, m.Defaults.Ttl)
+	require.Len(t, m.Mappings, 3)
+
+	require.Equal(t, "my_timer", m.Mappings[0].Name)
+	require.Equal(t, mapper.ObserverTypeHistogram, m.Mappings[0].ObserverType)
+	require.Equal(t, []float64{0.01, 0.1, 1}, m.Mappings[0].HistogramOptions.Buckets)
+	require.Equal(t, 10*time.Minute, m.Mappings[0].Ttl)
+
+	require.Equal(t, mapper.ObserverTypeSummary, m.Mappings[1].ObserverType)
+	require.Equal(t, 30*time.Second, m.Mappings[1].SummaryOptions.MaxAge)
+	require.Len(t, m.Mappings[1].SummaryOptions.Quantiles, 1)
+
+	require.Equal(t, mapper.MatchTypeRegex, m.Mappings[2].MatchType)
+	require.Equal(t, mapper.ActionTypeDrop, m.Mappings[2].Action)
+
+	mapping, labels, present := m.GetMapping("test.timing.a.b.c", mapper.MetricTypeObserver)
+	require.True(t, present)
+	require.Equal(t, "my_timer", mapping.Name)
+	require.Equal(t, map[string]string{"provider": "b", "outcome": "c"}, map[string]string(labels))
+}
+
+func TestValidate(t *testing.T) {
+	riverConfig := `
+		mapping {
+			match = "test.*"
+			name  = "valid"
+		}
+
+		mapping {
+			match      = "test.(["
+			match_type = "regex"
+			name       = "invalid_regex"
+		}
+
+		mapping {
+			match = "test.*"
+			name  = "invalid-name"
+		}
+	`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+
+	var diags diag.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Len(t, diags, 2)
+	require.ErrorContains(t, diags[0], "7:3: invalid regex test.([ in mapping")
+	require.ErrorContains(t, diags[1], "13:3: metric name 'invalid-name' doesn't match regex")
+
+	err = river.Unmarshal([]byte(`
+		mapping_config_path = "./testdata/mapTest.yaml"
+
+		mapping {
+			match = "test.*"
+			name  = "valid"
+		}
+	`), &args)
+	require.ErrorContains(t, err, "mapping_config_path can't be used together with mapping_defaults or mapping blocks")
+}
@@ -2,6 +2,7 @@ package prometheus
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -20,6 +21,11 @@ import (
 
 var _ storage.Appendable = (*Fanout)(nil)
 
+// ErrDeadlineExceeded is returned by appenders of a Fanout when data is
+// appended or committed after the deadline of the context the appender was
+// created with.
+var ErrDeadlineExceeded = fmt.Errorf("deadline for forwarding data to downstream components exceeded: %w", context.DeadlineExceeded)
+
 // Fanout supports the default Flow style of appendables since it can go to multiple outputs. It also allows the intercepting of appends.
 type Fanout struct {
 	mut sync.RWMutex
@@ -60,7 +66,9 @@ func (f *Fanout) UpdateChildren(children []storage.Appendable) {
 	f.children = children
 }
 
-// Appender satisfies the Appendable interface.
+// Appender satisfies the Appendable interface. If ctx has a deadline, data
+// appended or committed after the deadline is rejected with
+// ErrDeadlineExceeded, rather than delaying the downstream components.
 func (f *Fanout) Appender(ctx context.Context) storage.Appender {
 	f.mut.RLock()
 	defer f.mut.RUnlock()
@@ -73,7 +81,9 @@ func (f *Fanout) Appender(ctx context.Context) storage.Appender {
 	ctx = scrape.ContextWithTarget(ctx, &scrape.Target{})
 	ctx = scrape.ContextWithMetricMetadataStore(ctx, NoopMetadataStore{})
 
+	deadline, _ := ctx.Deadline()
 	app := &appender{
+		deadline:       deadline,
 		children:       make([]storage.Appender, 0),
 		componentID:    f.componentID,
 		writeLatency:   f.writeLatency,
@@ -95,12 +105,16 @@ type appender struct {
 	writeLatency   prometheus.Histogram
 	samplesCounter prometheus.Counter
 	start          time.Time
+	deadline       time.Time // Zero if there is no deadline.
 }
 
 var _ storage.Appender = (*appender)(nil)
 
 // Append satisfies the Appender interface.
 func (a *appender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	if err := a.checkDeadline(); err != nil {
+		return 0, err
+	}
 	if a.start.IsZero() {
 		a.start = time.Now()
 	}
@@ -123,8 +137,16 @@ func (a *appender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v flo
 	return ref, multiErr
 }
 
-// Commit satisfies the Appender interface.
+// Commit satisfies the Appender interface. If the deadline of the appender
+// has passed, the appended data is rolled back instead.
 func (a *appender) Commit() error {
+	if err := a.checkDeadline(); err != nil {
+		if rerr := a.Rollback(); rerr != nil {
+			return multierror.Append(err, rerr)
+		}
+		return err
+	}
+
 	defer a.recordLatency()
 	var multiErr error
 	for _, x := range a.children {
@@ -149,6 +171,14 @@ func (a *appender) Rollback() error {
 	return multiErr
 }
 
+// checkDeadline returns ErrDeadlineExceeded if the deadline of a has passed.
+func (a *appender) checkDeadline() error {
+	if !a.deadline.IsZero() && time.Now().After(a.deadline) {
+		return ErrDeadlineExceeded
+	}
+	return nil
+}
+
 func (a *appender) recordLatency() {
 	if a.start.IsZero() {
 		return
@@ -159,6 +189,9 @@ func (a *appender) recordLatency() {
 
 // AppendExemplar satisfies the Appender interface.
 func (a *appender) AppendExemplar(ref storage.SeriesRef, l labels.Labels, e exemplar.Exemplar) (storage.SeriesRef, error) {
+	if err := a.checkDeadline(); err != nil {
+		return 0, err
+	}
 	if a.start.IsZero() {
 		a.start = time.Now()
 	}
@@ -177,6 +210,9 @@ func (a *appender) AppendExemplar(ref storage.SeriesRef, l labels.Labels, e exem
 
 // UpdateMetadata satisfies the Appender interface.
 func (a *appender) UpdateMetadata(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
+	if err := a.checkDeadline(); err != nil {
+		return 0, err
+	}
 	if a.start.IsZero() {
 		a.start = time.Now()
 	}
@@ -194,6 +230,9 @@ func (a *appender) UpdateMetadata(ref storage.SeriesRef, l labels.Labels, m meta
 }
 
 func (a *appender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	if err := a.checkDeadline(); err != nil {
+		return 0, err
+	}
 	if a.start.IsZero() {
 		a.start = time.Now()
 	}
@@ -2,9 +2,11 @@ package prometheus
 
 import (
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/storage"
 
 	"github.com/stretchr/testify/require"
@@ -24,3 +26,31 @@ func TestCommit(t *testing.T) {
 	err := app.Commit()
 	require.NoError(t, err)
 }
+
+func TestDeadline(t *testing.T) {
+	var appended int
+	child := NewInterceptor(nil, WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
+		appended++
+		return ref, nil
+	}))
+	fanout := NewFanout([]storage.Appendable{child}, "", prometheus.NewRegistry())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	app := fanout.Appender(ctx)
+
+	_, err := app.Append(0, labels.FromStrings("__name__", "before"), 0, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, appended)
+
+	<-ctx.Done()
+
+	// Data appended after the deadline is rejected.
+	_, err = app.Append(0, labels.FromStrings("__name__", "after"), 0, 1)
+	require.ErrorIs(t, err, ErrDeadlineExceeded)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 1, appended)
+
+	// Committing after the deadline fails.
+	require.ErrorIs(t, app.Commit(), ErrDeadlineExceeded)
+}
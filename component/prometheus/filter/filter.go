@@ -0,0 +1,237 @@
+// Package filter implements the prometheus.filter component.
+package filter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/storage"
+	"go.uber.org/atomic"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.filter",
+		Args:    Arguments{},
+		Exports: Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the prometheus.filter
+// component.
+type Arguments struct {
+	// Where the filtered metrics should be forwarded to.
+	ForwardTo []storage.Appendable `river:"forward_to,attr"`
+
+	// Metric name patterns. A "*" in a pattern matches any sequence of
+	// characters.
+	AllowMetrics []string `river:"allow_metrics,attr,optional"`
+	DenyMetrics  []string `river:"deny_metrics,attr,optional"`
+
+	// Series selectors, such as {job="example"}.
+	KeepSeries []string `river:"keep_series,attr,optional"`
+	DropSeries []string `river:"drop_series,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	var errs river.ValidationErrors
+	if _, err := parseSelectors(args.KeepSeries); err != nil {
+		errs.Add(river.PathError("keep_series", err))
+	}
+	if _, err := parseSelectors(args.DropSeries); err != nil {
+		errs.Add(river.PathError("drop_series", err))
+	}
+	return errs.ErrorOrNil()
+}
+
+// Exports holds values which are exported by the prometheus.filter component.
+type Exports struct {
+	Receiver storage.Appendable `river:"receiver,attr"`
+}
+
+// filter decides which series are forwarded.
+type filter struct {
+	allow, deny *nameMatcher
+	keep, drop  selectors
+}
+
+func newFilter(args Arguments) (*filter, error) {
+	keep, err := parseSelectors(args.KeepSeries)
+	if err != nil {
+		return nil, err
+	}
+	drop, err := parseSelectors(args.DropSeries)
+	if err != nil {
+		return nil, err
+	}
+	return &filter{
+		allow: newNameMatcher(args.AllowMetrics),
+		deny:  newNameMatcher(args.DenyMetrics),
+		keep:  keep,
+		drop:  drop,
+	}, nil
+}
+
+// Keep returns true if the series with the given labels should be forwarded.
+// Metric names are checked before series selectors since they're cheaper to
+// evaluate.
+func (f *filter) Keep(lbls labels.Labels) bool {
+	name := lbls.Get(labels.MetricName)
+	if !f.allow.Empty() && !f.allow.Matches(name) {
+		return false
+	}
+	if f.deny.Matches(name) {
+		return false
+	}
+	if len(f.keep) > 0 && !f.keep.Matches(lbls) {
+		return false
+	}
+	return !f.drop.Matches(lbls)
+}
+
+// Component implements the prometheus.filter component.
+type Component struct {
+	opts     component.Options
+	receiver *prometheus.Interceptor
+	fanout   *prometheus.Fanout
+	exited   atomic.Bool
+
+	samplesProcessed prometheus_client.Counter
+	samplesDropped   prometheus_client.Counter
+
+	mut    sync.RWMutex
+	filter *filter
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new prometheus.filter component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{opts: o}
+
+	c.samplesProcessed = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_filter_samples_processed_total",
+		Help: "Total number of samples processed",
+	})
+	c.samplesDropped = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_filter_samples_dropped_total",
+		Help: "Total number of samples dropped by the filter",
+	})
+	for _, metric := range []prometheus_client.Collector{c.samplesProcessed, c.samplesDropped} {
+		if err := o.Registerer.Register(metric); err != nil {
+			return nil, err
+		}
+	}
+
+	c.fanout = prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer)
+	c.receiver = prometheus.NewInterceptor(
+		c.fanout,
+		prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
+			if err := c.check(); err != nil {
+				return 0, err
+			}
+			if !c.keep(l) {
+				return 0, nil
+			}
+			return next.Append(ref, l, t, v)
+		}),
+		prometheus.WithAppendHistogram(func(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram, next storage.Appender) (storage.SeriesRef, error) {
+			if err := c.check(); err != nil {
+				return 0, err
+			}
+			if !c.keep(l) {
+				return 0, nil
+			}
+			return next.AppendHistogram(ref, l, t, h, fh)
+		}),
+		prometheus.WithExemplarHook(func(ref storage.SeriesRef, l labels.Labels, e exemplar.Exemplar, next storage.Appender) (storage.SeriesRef, error) {
+			if err := c.check(); err != nil {
+				return 0, err
+			}
+			if !c.matches(l) {
+				return 0, nil
+			}
+			return next.AppendExemplar(ref, l, e)
+		}),
+		prometheus.WithMetadataHook(func(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata, next storage.Appender) (storage.SeriesRef, error) {
+			if err := c.check(); err != nil {
+				return 0, err
+			}
+			if !c.matches(l) {
+				return 0, nil
+			}
+			return next.UpdateMetadata(ref, l, m)
+		}),
+	)
+
+	// Immediately export the receiver which remains the same for the component
+	// lifetime.
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer c.exited.Store(true)
+
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	f, err := newFilter(newArgs)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.filter = f
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+	return nil
+}
+
+func (c *Component) check() error {
+	if c.exited.Load() {
+		return fmt.Errorf("%s has exited", c.opts.ID)
+	}
+	return nil
+}
+
+// matches returns true if the series with the given labels passes the filter.
+func (c *Component) matches(lbls labels.Labels) bool {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.filter.Keep(lbls)
+}
+
+// keep is like matches, but also counts the sample.
+func (c *Component) keep(lbls labels.Labels) bool {
+	c.samplesProcessed.Inc()
+	if !c.matches(lbls) {
+		c.samplesDropped.Inc()
+		return false
+	}
+	return true
+}
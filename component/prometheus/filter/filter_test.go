@@ -0,0 +1,159 @@
+package filter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/util"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverConfig(t *testing.T) {
+	in := `
+		forward_to    = []
+		allow_metrics = ["up", "node_*"]
+		deny_metrics  = ["node_scrape_collector_*"]
+		drop_series   = ["{job=\"test\", instance=~\"dev-.*\"}"]
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(in), &args))
+	require.Equal(t, []string{"up", "node_*"}, args.AllowMetrics)
+
+	in = `
+		forward_to  = []
+		keep_series = ["{job=}"]
+	`
+	err := river.Unmarshal([]byte(in), &args)
+	var diags diag.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Contains(t, diags[0].Message, `invalid series selector "{job=}"`)
+	require.Equal(t, 3, diags[0].StartPos.Line)
+}
+
+func TestFilter(t *testing.T) {
+	tt := []struct {
+		name   string
+		args   Arguments
+		series labels.Labels
+		keep   bool
+	}{
+		{
+			name:   "no filters",
+			series: labels.FromStrings("__name__", "up"),
+			keep:   true,
+		},
+		{
+			name:   "allowed by name",
+			args:   Arguments{AllowMetrics: []string{"up"}},
+			series: labels.FromStrings("__name__", "up"),
+			keep:   true,
+		},
+		{
+			name:   "not allowed by name",
+			args:   Arguments{AllowMetrics: []string{"up"}},
+			series: labels.FromStrings("__name__", "node_load1"),
+			keep:   false,
+		},
+		{
+			name:   "allowed by wildcard",
+			args:   Arguments{AllowMetrics: []string{"node_*_total"}},
+			series: labels.FromStrings("__name__", "node_cpu_seconds_total"),
+			keep:   true,
+		},
+		{
+			name:   "denied by wildcard",
+			args:   Arguments{AllowMetrics: []string{"node_*"}, DenyMetrics: []string{"*_bucket"}},
+			series: labels.FromStrings("__name__", "node_latency_bucket"),
+			keep:   false,
+		},
+		{
+			name:   "kept by selector",
+			args:   Arguments{KeepSeries: []string{`{job="node"}`}},
+			series: labels.FromStrings("__name__", "up", "job", "node"),
+			keep:   true,
+		},
+		{
+			name:   "not kept by selector",
+			args:   Arguments{KeepSeries: []string{`{job="node"}`}},
+			series: labels.FromStrings("__name__", "up", "job", "mysql"),
+			keep:   false,
+		},
+		{
+			name:   "dropped by selector",
+			args:   Arguments{DropSeries: []string{`up{instance=~"dev-.*"}`}},
+			series: labels.FromStrings("__name__", "up", "instance", "dev-1"),
+			keep:   false,
+		},
+		{
+			name:   "selector requires all matchers",
+			args:   Arguments{DropSeries: []string{`up{instance=~"dev-.*"}`}},
+			series: labels.FromStrings("__name__", "go_goroutines", "instance", "dev-1"),
+			keep:   true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := newFilter(tc.args)
+			require.NoError(t, err)
+			require.Equal(t, tc.keep, f.Keep(tc.series))
+		})
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	tt := []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"*", "anything", true},
+		{"node_*", "node_", true},
+		{"node_*", "nod", false},
+		{"*_total", "http_requests_total", true},
+		{"a*a", "a", false},
+		{"a*a", "aa", true},
+		{"*b*c", "bcbc", true},
+		{"*b*c", "bcb", false},
+	}
+	for _, tc := range tt {
+		m := newNameMatcher([]string{tc.pattern})
+		require.Equal(t, tc.match, m.Matches(tc.name), "pattern %q, name %q", tc.pattern, tc.name)
+	}
+}
+
+func TestComponent(t *testing.T) {
+	var received []labels.Labels
+	next := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+		received = append(received, l)
+		return ref, nil
+	}))
+
+	c, err := New(component.Options{
+		ID:            "prometheus.filter.test",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+		Registerer:    prom.NewRegistry(),
+	}, Arguments{
+		ForwardTo:   []storage.Appendable{next},
+		DenyMetrics: []string{"go_*"},
+	})
+	require.NoError(t, err)
+
+	app := c.receiver.Appender(context.Background())
+	for _, l := range []labels.Labels{
+		labels.FromStrings("__name__", "up"),
+		labels.FromStrings("__name__", "go_goroutines"),
+	} {
+		_, err := app.Append(0, l, 0, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+	require.Equal(t, []labels.Labels{labels.FromStrings("__name__", "up")}, received)
+}
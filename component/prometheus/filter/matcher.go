@@ -0,0 +1,96 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// nameMatcher matches metric names against a list of patterns. Patterns
+// without wildcards are looked up in a map, so that long lists of metric
+// names are cheap to evaluate.
+type nameMatcher struct {
+	exact    map[string]struct{}
+	wildcard [][]string // Patterns split on "*".
+}
+
+func newNameMatcher(patterns []string) *nameMatcher {
+	m := &nameMatcher{exact: make(map[string]struct{})}
+	for _, p := range patterns {
+		if strings.Contains(p, "*") {
+			m.wildcard = append(m.wildcard, strings.Split(p, "*"))
+		} else {
+			m.exact[p] = struct{}{}
+		}
+	}
+	return m
+}
+
+// Empty returns true if m has no patterns.
+func (m *nameMatcher) Empty() bool {
+	return len(m.exact) == 0 && len(m.wildcard) == 0
+}
+
+// Matches returns true if name matches any of the patterns of m.
+func (m *nameMatcher) Matches(name string) bool {
+	if _, ok := m.exact[name]; ok {
+		return true
+	}
+	for _, parts := range m.wildcard {
+		if matchWildcard(parts, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWildcard reports whether s matches a pattern which has been split on
+// its wildcards. Each wildcard matches any sequence of characters, including
+// the empty one.
+func matchWildcard(parts []string, s string) bool {
+	first, last := parts[0], parts[len(parts)-1]
+	if !strings.HasPrefix(s, first) {
+		return false
+	}
+	s = s[len(first):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, last)
+}
+
+// selectors is a list of series selectors, such as {job="example"}.
+type selectors [][]*labels.Matcher
+
+func parseSelectors(in []string) (selectors, error) {
+	res := make(selectors, 0, len(in))
+	for _, s := range in {
+		ms, err := parser.ParseMetricSelector(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid series selector %q: %w", s, err)
+		}
+		res = append(res, ms)
+	}
+	return res, nil
+}
+
+// Matches returns true if lbls matches any of the selectors.
+func (ss selectors) Matches(lbls labels.Labels) bool {
+Outer:
+	for _, ms := range ss {
+		for _, m := range ms {
+			if !m.Matches(lbls.Get(m.Name)) {
+				continue Outer
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -115,7 +115,7 @@ func (c *crdManager) DebugInfo() interface{} {
 
 // runInformers starts all the informers that are required to discover PodMonitors.
 func (c *crdManager) runInformers(ctx context.Context) error {
-	config, err := c.args.Client.BuildRESTConfig(c.logger)
+	config, err := c.args.Client.BuildRESTConfig(c.logger, c.opts.Registerer)
 	if err != nil {
 		return fmt.Errorf("creating rest config: %w", err)
 	}
@@ -0,0 +1,202 @@
+// Package receive_http implements the prometheus.receive_http component,
+// which receives metrics over the Prometheus remote_write protocol.
+package receive_http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/handover"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+)
+
+// writePath is the path of the remote_write endpoint.
+const writePath = "/api/v1/metrics/write"
+
+func init() {
+	component.Register(component.Registration{
+		Name:     "prometheus.receive_http",
+		Args:     Arguments{},
+		Listener: true,
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the
+// prometheus.receive_http component.
+type Arguments struct {
+	HTTP      HTTPConfig           `river:"http,block"`
+	ForwardTo []storage.Appendable `river:"forward_to,attr"`
+}
+
+// HTTPConfig configures the HTTP server which receives remote_write requests.
+type HTTPConfig struct {
+	ListenAddress string `river:"listen_address,attr,optional"`
+	ListenPort    int    `river:"listen_port,attr"`
+}
+
+// DefaultHTTPConfig holds the default settings of the HTTP server.
+var DefaultHTTPConfig = HTTPConfig{
+	ListenAddress: "0.0.0.0",
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (c *HTTPConfig) UnmarshalRiver(f func(interface{}) error) error {
+	*c = DefaultHTTPConfig
+
+	type httpConfig HTTPConfig
+	return f((*httpConfig)(c))
+}
+
+// Validate implements river.Validator.
+func (c *HTTPConfig) Validate() error {
+	if c.ListenPort < 0 || c.ListenPort > 65535 {
+		return river.PathError("listen_port", fmt.Errorf("listen_port must be between 0 and 65535, got %d", c.ListenPort))
+	}
+	return nil
+}
+
+// Addr returns the address the HTTP server listens on.
+func (c HTTPConfig) Addr() string {
+	return net.JoinHostPort(c.ListenAddress, strconv.Itoa(c.ListenPort))
+}
+
+// Component implements the prometheus.receive_http component.
+type Component struct {
+	opts    component.Options
+	fanout  *prometheus.Fanout
+	handler http.Handler
+
+	mut    sync.Mutex
+	args   Arguments
+	server *server
+}
+
+// New creates a new prometheus.receive_http component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	fanout := prometheus.NewFanout(args.ForwardTo, opts.ID, opts.Registerer)
+
+	mux := http.NewServeMux()
+	mux.Handle(writePath, remote.NewWriteHandler(opts.Logger, fanout))
+
+	c := &Component{
+		opts:    opts,
+		fanout:  fanout,
+		handler: mux,
+	}
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	<-ctx.Done()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.server != nil {
+		c.server.Stop()
+		c.server = nil
+	}
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+
+	if c.server != nil && c.args.HTTP == newArgs.HTTP {
+		c.args = newArgs
+		return nil
+	}
+
+	// The old server must be stopped first so that a server on the same
+	// address can be started.
+	if c.server != nil {
+		c.server.Stop()
+		c.server = nil
+	}
+
+	srv, err := startServer(c.opts, newArgs.HTTP.Addr(), c.handler)
+	if err != nil {
+		return err
+	}
+	c.server = srv
+	c.args = newArgs
+	return nil
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var info debugInfo
+	if c.server != nil {
+		info.Address = c.server.Addr()
+	}
+	return info
+}
+
+type debugInfo struct {
+	Address string `river:"address,attr,optional"`
+}
+
+// server serves remote_write requests over HTTP.
+type server struct {
+	lis  net.Listener
+	srv  *http.Server
+	done chan struct{}
+}
+
+func startServer(opts component.Options, addr string, handler http.Handler) (*server, error) {
+	lis, err := handover.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	s := &server{
+		lis:  lis,
+		srv:  &http.Server{Handler: handler},
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+
+		level.Info(opts.Logger).Log("msg", "starting remote_write receiver", "addr", lis.Addr())
+		if err := s.srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			level.Error(opts.Logger).Log("msg", "remote_write receiver stopped", "err", err)
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *server) Addr() string { return s.lis.Addr().String() }
+
+// Stop stops the server and waits for it to exit.
+func (s *server) Stop() {
+	_ = s.srv.Close()
+	<-s.done
+}
@@ -0,0 +1,88 @@
+package receive_http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverConfig(t *testing.T) {
+	in := `
+		http {
+			listen_port = 9999
+		}
+		forward_to = []
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(in), &args))
+	require.Equal(t, "0.0.0.0", args.HTTP.ListenAddress)
+	require.Equal(t, 9999, args.HTTP.ListenPort)
+
+	in = `
+		http {
+			listen_port = 70000
+		}
+		forward_to = []
+	`
+	require.ErrorContains(t, river.Unmarshal([]byte(in), &args), "listen_port must be between 0 and 65535")
+}
+
+func TestReceive(t *testing.T) {
+	received := make(chan labels.Labels, 1)
+	receiver := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+		received <- l
+		return ref, nil
+	}))
+
+	c, err := New(component.Options{
+		ID:            "prometheus.receive_http.test",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+		Registerer:    prom.NewRegistry(),
+	}, Arguments{
+		HTTP:      HTTPConfig{ListenAddress: "127.0.0.1", ListenPort: 0},
+		ForwardTo: []storage.Appendable{receiver},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "test_metric"}, {Name: "foo", Value: "bar"}},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: time.Now().UnixMilli()}},
+		}},
+	}
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+
+	addr := c.DebugInfo().(debugInfo).Address
+	resp, err := http.Post(fmt.Sprintf("http://%s%s", addr, writePath), "application/x-protobuf", bytes.NewReader(snappy.Encode(nil, data)))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	select {
+	case l := <-received:
+		require.Equal(t, labels.FromStrings("__name__", "test_metric", "foo", "bar"), l)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for sample")
+	}
+}
@@ -11,9 +11,12 @@ import (
 
 	"github.com/grafana/agent/component"
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/component/common/tap"
 	"github.com/grafana/agent/component/prometheus"
 	prometheus_client "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/intern"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/metadata"
 
@@ -59,15 +62,21 @@ type Component struct {
 	cacheHits        prometheus_client.Counter
 	cacheMisses      prometheus_client.Counter
 	cacheSize        prometheus_client.Gauge
+	ruleSeries       *prometheus_client.CounterVec
+	ruleStats        []*ruleStats
 	fanout           *prometheus.Fanout
 	exited           atomic.Bool
+	tap              tap.Tapper
 
 	cacheMut sync.RWMutex
 	cache    map[uint64]*labelAndID
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component      = (*Component)(nil)
+	_ component.TapComponent   = (*Component)(nil)
+	_ component.HTTPComponent  = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
 )
 
 // New creates a new prometheus.relabel component.
@@ -96,9 +105,13 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		Name: "agent_prometheus_relabel_cache_size",
 		Help: "Total size of relabel cache",
 	})
+	c.ruleSeries = prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_relabel_rule_series",
+		Help: "Total number of series kept or dropped by each relabeling rule",
+	}, []string{"rule", "result"})
 
 	var err error
-	for _, metric := range []prometheus_client.Collector{c.metricsProcessed, c.metricsOutgoing, c.cacheMisses, c.cacheHits, c.cacheSize} {
+	for _, metric := range []prometheus_client.Collector{c.metricsProcessed, c.metricsOutgoing, c.cacheMisses, c.cacheHits, c.cacheSize, c.ruleSeries} {
 		err = o.Registerer.Register(metric)
 		if err != nil {
 			return nil, err
@@ -114,12 +127,35 @@ func New(o component.Options, args Arguments) (*Component, error) {
 			}
 
 			newLbl := c.relabel(v, l)
+			if c.tap.Active() {
+				c.publishTap(l, newLbl, t, v)
+			}
 			if newLbl == nil {
 				return 0, nil
 			}
 			c.metricsOutgoing.Inc()
 			return next.Append(0, newLbl, t, v)
 		}),
+		prometheus.WithAppendHistogram(func(_ storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			// Staleness markers for histograms are stored in their sum.
+			var sum float64
+			if h != nil {
+				sum = h.Sum
+			} else if fh != nil {
+				sum = fh.Sum
+			}
+
+			newLbl := c.relabel(sum, l)
+			if newLbl == nil {
+				return 0, nil
+			}
+			c.metricsOutgoing.Inc()
+			return next.AppendHistogram(0, newLbl, t, h, fh)
+		}),
 		prometheus.WithExemplarHook(func(_ storage.SeriesRef, l labels.Labels, e exemplar.Exemplar, next storage.Appender) (storage.SeriesRef, error) {
 			if c.exited.Load() {
 				return 0, fmt.Errorf("%s has exited", o.ID)
@@ -172,6 +208,7 @@ func (c *Component) Update(args component.Arguments) error {
 	newArgs := args.(Arguments)
 	c.clearCache()
 	c.mrc = flow_relabel.ComponentToPromRelabelConfigs(newArgs.MetricRelabelConfigs)
+	c.ruleStats = newRuleStats(c.ruleSeries, c.mrc)
 	c.fanout.UpdateChildren(newArgs.ForwardTo)
 
 	c.opts.OnStateChange(Exports{Receiver: c.receiver, Rules: newArgs.MetricRelabelConfigs})
@@ -179,6 +216,20 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+// Tap implements component.TapComponent.
+func (c *Component) Tap(ctx context.Context, fn func(record string)) {
+	c.tap.Tap(ctx, fn)
+}
+
+// publishTap publishes the result of relabeling a sample to active taps.
+func (c *Component) publishTap(in, out labels.Labels, t int64, v float64) {
+	result := "dropped"
+	if out != nil {
+		result = out.String()
+	}
+	c.tap.Publish(fmt.Sprintf("%s => %s %d %v", in.String(), result, t, v))
+}
+
 func (c *Component) relabel(val float64, lbls labels.Labels) labels.Labels {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
@@ -195,10 +246,16 @@ func (c *Component) relabel(val float64, lbls labels.Labels) labels.Labels {
 	} else {
 		// Relabel against a copy of the labels to prevent modifying the original
 		// slice.
-		relabelled, keep := relabel.Process(lbls.Copy(), c.mrc...)
+		var keep bool
+		relabelled, keep = processRules(lbls.Copy(), c.mrc, func(i int, _ labels.Labels, keep bool) {
+			c.ruleStats[i].observe(keep)
+		})
 		c.cacheMisses.Inc()
 		c.cacheSize.Inc()
 		c.addToCache(globalRef, relabelled, keep)
+		if !keep {
+			relabelled = nil
+		}
 	}
 
 	// If stale remove from the cache, the reason we don't exit early is so the stale value can propagate.
@@ -226,6 +283,7 @@ func (c *Component) deleteFromCache(id uint64) {
 	c.cacheMut.Lock()
 	defer c.cacheMut.Unlock()
 
+	c.releaseCached(id)
 	delete(c.cache, id)
 }
 
@@ -233,17 +291,36 @@ func (c *Component) clearCache() {
 	c.cacheMut.Lock()
 	defer c.cacheMut.Unlock()
 
+	for id := range c.cache {
+		c.releaseCached(id)
+	}
 	c.cache = make(map[uint64]*labelAndID)
 }
 
+// releaseCached releases the interned labels of a cache entry. c.cacheMut must
+// be held when calling releaseCached.
+func (c *Component) releaseCached(id uint64) {
+	if fm := c.cache[id]; fm != nil {
+		intern.Release(intern.Global, fm.labels)
+	}
+}
+
 func (c *Component) addToCache(originalID uint64, lbls labels.Labels, keep bool) {
 	c.cacheMut.Lock()
 	defer c.cacheMut.Unlock()
 
+	// The same series may have been relabeled concurrently.
+	c.releaseCached(originalID)
+
 	if !keep {
 		c.cache[originalID] = nil
 		return
 	}
+
+	// Relabeled labels are interned with the interner shared by the scrape
+	// cache, the WAL, and the remote_write queues, so that relabeled series
+	// don't keep their own copy of new label values in memory.
+	intern.Intern(intern.Global, lbls)
 	newGlobal := prometheus.GlobalRefMapping.GetOrAddGlobalRefID(lbls)
 	c.cache[originalID] = &labelAndID{
 		labels: lbls,
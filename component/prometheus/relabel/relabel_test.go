@@ -1,7 +1,11 @@
 package relabel
 
 import (
+	"encoding/json"
 	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -14,6 +18,9 @@ import (
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
 	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/intern"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/prometheus/prometheus/model/value"
@@ -44,6 +51,40 @@ func TestEviction(t *testing.T) {
 	require.Len(t, relabeller.cache, 0)
 }
 
+func TestCacheInterning(t *testing.T) {
+	relabeller, err := New(component.Options{
+		ID:            "1",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+		Registerer:    prom.NewRegistry(),
+	}, Arguments{
+		ForwardTo: []storage.Appendable{},
+		MetricRelabelConfigs: []*flow_relabel.Config{
+			{
+				SourceLabels: []string{"__address__"},
+				Regex:        flow_relabel.Regexp(relabel.MustNewRegexp("(.+)")),
+				TargetLabel:  "new_label",
+				Replacement:  "${1}-relabeled",
+				Action:       "replace",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	interned := func() float64 {
+		return testutil.ToFloat64(intern.Global.Metrics().Strings)
+	}
+	before := interned()
+
+	// Cached labels are interned until they're evicted.
+	lbls := labels.FromStrings("__address__", "interning-test")
+	relabeller.relabel(0, lbls)
+	require.Greater(t, interned(), before)
+
+	relabeller.relabel(math.Float64frombits(value.StaleNaN), lbls)
+	require.Equal(t, before, interned())
+}
+
 func TestUpdateReset(t *testing.T) {
 	relabeller := generateRelabel(t)
 	lbls := labels.FromStrings("__address__", "localhost")
@@ -82,6 +123,43 @@ func TestNil(t *testing.T) {
 	relabeller.relabel(0, lbls)
 }
 
+func TestHistograms(t *testing.T) {
+	var received labels.Labels
+	fanout := prometheus.NewInterceptor(nil, prometheus.WithAppendHistogram(func(ref storage.SeriesRef, l labels.Labels, _ int64, h *histogram.Histogram, _ *histogram.FloatHistogram, _ storage.Appender) (storage.SeriesRef, error) {
+		require.NotNil(t, h)
+		received = l
+		return ref, nil
+	}))
+	var entry storage.Appendable
+	_, err := New(component.Options{
+		ID:     "1",
+		Logger: util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {
+			entry = e.(Exports).Receiver
+		},
+		Registerer: prom.NewRegistry(),
+	}, Arguments{
+		ForwardTo: []storage.Appendable{fanout},
+		MetricRelabelConfigs: []*flow_relabel.Config{
+			{
+				SourceLabels: []string{"__address__"},
+				Regex:        flow_relabel.Regexp(relabel.MustNewRegexp("(.+)")),
+				TargetLabel:  "new_label",
+				Replacement:  "new_value",
+				Action:       "replace",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	lbls := labels.FromStrings("__address__", "localhost")
+	app := entry.Appender(context.Background())
+	_, err = app.AppendHistogram(0, lbls, time.Now().UnixMilli(), &histogram.Histogram{Count: 1, Sum: 1}, nil)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+	require.Equal(t, "new_value", received.Get("new_label"))
+}
+
 func BenchmarkCache(b *testing.B) {
 	fanout := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
 		require.True(b, l.Has("new_label"))
@@ -191,3 +269,113 @@ func TestRuleGetter(t *testing.T) {
 	require.Equal(t, gotUpdated[0].SourceLabels, gotOriginal[0].SourceLabels)
 	require.Equal(t, gotUpdated[0].Regex, gotOriginal[0].Regex)
 }
+
+func TestTap(t *testing.T) {
+	relabeller := generateRelabel(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	records := make(chan string, 10)
+	go relabeller.Tap(ctx, func(record string) { records <- record })
+	util.Eventually(t, func(t require.TestingT) {
+		require.True(t, relabeller.tap.Active())
+	})
+
+	lbls := labels.FromStrings("__address__", "localhost")
+	app := relabeller.receiver.Appender(context.Background())
+	_, err := app.Append(0, lbls, 1234, 1)
+	require.NoError(t, err)
+
+	require.Contains(t, <-records, `{__address__="localhost"} => `)
+}
+
+func TestRuleStats(t *testing.T) {
+	relabeller := generateRelabelWithDrop(t)
+
+	app := relabeller.receiver.Appender(context.Background())
+	for _, lbls := range []labels.Labels{
+		labels.FromStrings("__address__", "localhost"),
+		labels.FromStrings("__address__", "localhost", "drop", "true"),
+		labels.FromStrings("__address__", "otherhost"),
+	} {
+		_, err := app.Append(0, lbls, time.Now().UnixMilli(), 0)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	info := relabeller.DebugInfo().(debugInfo)
+	require.Equal(t, []debugRule{
+		{Index: 0, Action: "replace", Kept: 3, Dropped: 0},
+		{Index: 1, Action: "drop", Kept: 2, Dropped: 1},
+	}, info.Rules)
+}
+
+func TestHandler_Test(t *testing.T) {
+	relabeller := generateRelabelWithDrop(t)
+	srv := httptest.NewServer(relabeller.Handler())
+	defer srv.Close()
+
+	get := func(t *testing.T, lbls string) (*http.Response, testResult) {
+		resp, err := http.Get(srv.URL + "/test?labels=" + url.QueryEscape(lbls))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var res testResult
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+		}
+		return resp, res
+	}
+
+	t.Run("kept", func(t *testing.T) {
+		resp, res := get(t, `up{__address__="localhost"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.True(t, res.Kept)
+		require.Len(t, res.Steps, 2)
+		require.Equal(t, "new_value", res.Output["new_label"])
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		resp, res := get(t, `up{__address__="localhost", drop="true"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.False(t, res.Kept)
+		require.Nil(t, res.Output)
+		require.Equal(t, testStep{Rule: 1, Action: "drop", Kept: false}, res.Steps[1])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		resp, _ := get(t, `up{`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	// Testing label sets doesn't affect the rule stats.
+	require.Equal(t, uint64(0), relabeller.ruleStats[0].kept.Load())
+}
+
+func generateRelabelWithDrop(t *testing.T) *Component {
+	relabeller, err := New(component.Options{
+		ID:            "1",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+		Registerer:    prom.NewRegistry(),
+	}, Arguments{
+		ForwardTo: []storage.Appendable{},
+		MetricRelabelConfigs: []*flow_relabel.Config{
+			{
+				SourceLabels: []string{"__address__"},
+				Regex:        flow_relabel.Regexp(relabel.MustNewRegexp("(.+)")),
+				TargetLabel:  "new_label",
+				Replacement:  "new_value",
+				Action:       "replace",
+			},
+			{
+				SourceLabels: []string{"drop"},
+				Regex:        flow_relabel.Regexp(relabel.MustNewRegexp("true")),
+				Action:       "drop",
+			},
+		},
+	})
+	require.NoError(t, err)
+	return relabeller
+}
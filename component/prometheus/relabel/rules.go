@@ -0,0 +1,147 @@
+package relabel
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/promql/parser"
+	"go.uber.org/atomic"
+)
+
+// ruleStats counts the series kept and dropped by a single relabeling rule.
+// Series are only counted once until they're evicted from the cache.
+type ruleStats struct {
+	kept, dropped               atomic.Uint64
+	keptCounter, droppedCounter prometheus_client.Counter
+}
+
+func (rs *ruleStats) observe(keep bool) {
+	if keep {
+		rs.kept.Inc()
+		rs.keptCounter.Inc()
+	} else {
+		rs.dropped.Inc()
+		rs.droppedCounter.Inc()
+	}
+}
+
+// newRuleStats returns stats for each of the given rules. Series counts
+// exposed by vec are reset, since they refer to the rules by index.
+func newRuleStats(vec *prometheus_client.CounterVec, cfgs []*relabel.Config) []*ruleStats {
+	vec.Reset()
+
+	res := make([]*ruleStats, len(cfgs))
+	for i := range cfgs {
+		rule := strconv.Itoa(i)
+		res[i] = &ruleStats{
+			keptCounter:    vec.WithLabelValues(rule, "kept"),
+			droppedCounter: vec.WithLabelValues(rule, "dropped"),
+		}
+	}
+	return res
+}
+
+// processRules applies cfgs to lbls one rule at a time, calling fn with the
+// result of each rule. Processing stops at the first rule which drops the
+// series.
+func processRules(lbls labels.Labels, cfgs []*relabel.Config, fn func(i int, out labels.Labels, keep bool)) (labels.Labels, bool) {
+	for i, cfg := range cfgs {
+		var keep bool
+		lbls, keep = relabel.Process(lbls, cfg)
+		fn(i, lbls, keep)
+		if !keep {
+			return nil, false
+		}
+	}
+	return lbls, true
+}
+
+type debugInfo struct {
+	Rules []debugRule `river:"rule,block,optional"`
+}
+
+type debugRule struct {
+	Index   int    `river:"index,attr"`
+	Action  string `river:"action,attr"`
+	Kept    uint64 `river:"series_kept,attr"`
+	Dropped uint64 `river:"series_dropped,attr"`
+}
+
+// DebugInfo implements component.DebugComponent.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	var info debugInfo
+	for i, rs := range c.ruleStats {
+		info.Rules = append(info.Rules, debugRule{
+			Index:   i,
+			Action:  string(c.mrc[i].Action),
+			Kept:    rs.kept.Load(),
+			Dropped: rs.dropped.Load(),
+		})
+	}
+	return info
+}
+
+// testResult is the result of testing a label set against the relabeling
+// rules.
+type testResult struct {
+	Input  map[string]string `json:"input"`
+	Steps  []testStep        `json:"steps"`
+	Output map[string]string `json:"output"`
+	Kept   bool              `json:"kept"`
+}
+
+// testStep is the result of applying a single rule while testing a label set.
+type testStep struct {
+	Rule   int               `json:"rule"`
+	Action string            `json:"action"`
+	Labels map[string]string `json:"labels"`
+	Kept   bool              `json:"kept"`
+}
+
+// test returns the result of applying the relabeling rules to lbls.
+func (c *Component) test(lbls labels.Labels) testResult {
+	c.mut.RLock()
+	mrc := c.mrc
+	c.mut.RUnlock()
+
+	res := testResult{Input: lbls.Map(), Steps: []testStep{}}
+	out, keep := processRules(lbls, mrc, func(i int, out labels.Labels, keep bool) {
+		step := testStep{Rule: i, Action: string(mrc[i].Action), Kept: keep}
+		if keep {
+			step.Labels = out.Map()
+		}
+		res.Steps = append(res.Steps, step)
+	})
+	res.Kept = keep
+	if keep {
+		res.Output = out.Map()
+	}
+	return res
+}
+
+// Handler implements component.HTTPComponent. The /test endpoint applies the
+// relabeling rules to the label set given in the labels query parameter, such
+// as up{job="example"}, and returns the result of each rule as JSON. Testing
+// a label set doesn't affect the series counts of the rules.
+func (c *Component) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		lbls, err := parser.ParseMetric(r.URL.Query().Get("labels"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid labels: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.test(lbls))
+	})
+	return mux
+}
@@ -0,0 +1,164 @@
+package remotewrite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/hashicorp/go-multierror"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// appendableFunc implements storage.Appendable with a function.
+type appendableFunc func(ctx context.Context) storage.Appender
+
+// Appender satisfies the Appendable interface.
+func (f appendableFunc) Appender(ctx context.Context) storage.Appender { return f(ctx) }
+
+// appender returns a storage.Appender which writes data to the shard of the
+// tenant of each series.
+func (c *Component) appender(ctx context.Context) storage.Appender {
+	c.mut.RLock()
+	var sharding *TenantShardingOptions
+	if c.cfg.TenantSharding != nil {
+		opts := *c.cfg.TenantSharding
+		sharding = &opts
+	}
+	c.mut.RUnlock()
+
+	return &shardAppender{
+		c:        c,
+		ctx:      ctx,
+		sharding: sharding,
+		children: make(map[*walShard]storage.Appender),
+	}
+}
+
+// shardAppender routes data to the shard of the tenant of each series. The
+// refs passed to shardAppender are global refs, which are translated to the
+// local refs of the shard which the series is written to.
+type shardAppender struct {
+	c        *Component
+	ctx      context.Context
+	sharding *TenantShardingOptions // nil if tenant sharding is disabled.
+
+	children map[*walShard]storage.Appender
+}
+
+var _ storage.Appender = (*shardAppender)(nil)
+
+// next returns the appender and labels to use for a series. If tenant
+// sharding is enabled, the tenant label is removed from the returned labels.
+func (a *shardAppender) next(l labels.Labels) (*walShard, storage.Appender, labels.Labels, error) {
+	if a.c.exited.Load() {
+		return nil, nil, l, fmt.Errorf("%s has exited", a.c.opts.ID)
+	}
+
+	var tenant string
+	if a.sharding != nil {
+		tenant = l.Get(a.sharding.Label)
+		if tenant == "" {
+			tenant = a.sharding.DefaultTenant
+		} else {
+			l = labels.NewBuilder(l).Del(a.sharding.Label).Labels(labels.EmptyLabels())
+		}
+	}
+
+	shard, err := a.c.shard(tenant)
+	if err != nil {
+		return nil, nil, l, err
+	}
+
+	app, ok := a.children[shard]
+	if !ok {
+		app = shard.storage.Appender(a.ctx)
+		a.children[shard] = app
+	}
+	return shard, app, l, nil
+}
+
+// Append satisfies the Appender interface.
+func (a *shardAppender) Append(globalRef storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	shard, app, shardLabels, err := a.next(l)
+	if err != nil {
+		return 0, err
+	}
+
+	localID := prometheus.GlobalRefMapping.GetLocalRefID(shard.refID, uint64(globalRef))
+	newRef, nextErr := app.Append(storage.SeriesRef(localID), shardLabels, t, v)
+	if localID == 0 {
+		prometheus.GlobalRefMapping.GetOrAddLink(shard.refID, uint64(newRef), l)
+	}
+	return globalRef, nextErr
+}
+
+// AppendExemplar satisfies the Appender interface.
+func (a *shardAppender) AppendExemplar(globalRef storage.SeriesRef, l labels.Labels, e exemplar.Exemplar) (storage.SeriesRef, error) {
+	shard, app, shardLabels, err := a.next(l)
+	if err != nil {
+		return 0, err
+	}
+
+	localID := prometheus.GlobalRefMapping.GetLocalRefID(shard.refID, uint64(globalRef))
+	newRef, nextErr := app.AppendExemplar(storage.SeriesRef(localID), shardLabels, e)
+	if localID == 0 {
+		prometheus.GlobalRefMapping.GetOrAddLink(shard.refID, uint64(newRef), l)
+	}
+	return globalRef, nextErr
+}
+
+// UpdateMetadata satisfies the Appender interface.
+func (a *shardAppender) UpdateMetadata(globalRef storage.SeriesRef, l labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
+	shard, app, shardLabels, err := a.next(l)
+	if err != nil {
+		return 0, err
+	}
+
+	localID := prometheus.GlobalRefMapping.GetLocalRefID(shard.refID, uint64(globalRef))
+	newRef, nextErr := app.UpdateMetadata(storage.SeriesRef(localID), shardLabels, m)
+	if localID == 0 {
+		prometheus.GlobalRefMapping.GetOrAddLink(shard.refID, uint64(newRef), l)
+	}
+	return globalRef, nextErr
+}
+
+// AppendHistogram satisfies the Appender interface.
+func (a *shardAppender) AppendHistogram(globalRef storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	shard, app, shardLabels, err := a.next(l)
+	if err != nil {
+		return 0, err
+	}
+
+	localID := prometheus.GlobalRefMapping.GetLocalRefID(shard.refID, uint64(globalRef))
+	newRef, nextErr := app.AppendHistogram(storage.SeriesRef(localID), shardLabels, t, h, fh)
+	if localID == 0 {
+		prometheus.GlobalRefMapping.GetOrAddLink(shard.refID, uint64(newRef), l)
+	}
+	return globalRef, nextErr
+}
+
+// Commit satisfies the Appender interface.
+func (a *shardAppender) Commit() error {
+	var errs error
+	for _, app := range a.children {
+		if err := app.Commit(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Rollback satisfies the Appender interface.
+func (a *shardAppender) Rollback() error {
+	var errs error
+	for _, app := range a.children {
+		if err := app.Rollback(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
@@ -3,7 +3,6 @@ package remotewrite
 import (
 	"context"
 	"fmt"
-	"math"
 	"os"
 	"path/filepath"
 	"sync"
@@ -11,19 +10,14 @@ import (
 
 	"go.uber.org/atomic"
 
-	"github.com/prometheus/prometheus/model/exemplar"
-	"github.com/prometheus/prometheus/model/labels"
-	"github.com/prometheus/prometheus/model/metadata"
-
 	"github.com/grafana/agent/component/prometheus"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/build"
-	"github.com/grafana/agent/pkg/metrics/wal"
-	"github.com/prometheus/prometheus/model/timestamp"
-	"github.com/prometheus/prometheus/storage"
+	client_prometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/storage/remote"
 )
 
@@ -32,6 +26,10 @@ import (
 // TODO(rfratto): This should be exposed. How do we want to expose this?
 var remoteFlushDeadline = 1 * time.Minute
 
+// sizeCheckFrequency is how often the size of the WAL is compared to the
+// max_size argument.
+var sizeCheckFrequency = 1 * time.Minute
+
 func init() {
 	remote.UserAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
 
@@ -50,13 +48,11 @@ type Component struct {
 	log  log.Logger
 	opts component.Options
 
-	walStore    *wal.Storage
-	remoteStore *remote.Storage
-	storage     storage.Storage
-	exited      atomic.Bool
+	exited atomic.Bool
 
-	mut sync.RWMutex
-	cfg Arguments
+	mut    sync.RWMutex
+	cfg    Arguments
+	shards map[string]*walShard // WAL shards by tenant. The empty tenant is used when tenant sharding is disabled.
 
 	receiver *prometheus.Interceptor
 }
@@ -72,99 +68,43 @@ func NewComponent(o component.Options, c Arguments) (*Component, error) {
 	oldDataPath := filepath.Join(o.DataPath, "wal", o.ID)
 	_ = os.RemoveAll(oldDataPath)
 
-	walLogger := log.With(o.Logger, "subcomponent", "wal")
-	walStorage, err := wal.NewStorage(walLogger, o.Registerer, o.DataPath)
-	if err != nil {
-		return nil, err
-	}
-
-	remoteLogger := log.With(o.Logger, "subcomponent", "rw")
-	remoteStore := remote.NewStorage(remoteLogger, o.Registerer, startTime, o.DataPath, remoteFlushDeadline, nil)
-
 	res := &Component{
-		log:         o.Logger,
-		opts:        o,
-		walStore:    walStorage,
-		remoteStore: remoteStore,
-		storage:     storage.NewFanout(o.Logger, walStorage, remoteStore),
-	}
-	res.receiver = prometheus.NewInterceptor(
-		res.storage,
-
-		// In the methods below, conversion is needed because remote_writes assume
-		// they are responsible for generating ref IDs. This means two
-		// remote_writes may return the same ref ID for two different series. We
-		// treat the remote_write ID as a "local ID" and translate it to a "global
-		// ID" to ensure Flow compatibility.
-
-		prometheus.WithAppendHook(func(globalRef storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
-			if res.exited.Load() {
-				return 0, fmt.Errorf("%s has exited", o.ID)
-			}
-
-			localID := prometheus.GlobalRefMapping.GetLocalRefID(res.opts.ID, uint64(globalRef))
-			newRef, nextErr := next.Append(storage.SeriesRef(localID), l, t, v)
-			if localID == 0 {
-				prometheus.GlobalRefMapping.GetOrAddLink(res.opts.ID, uint64(newRef), l)
-			}
-			return globalRef, nextErr
-		}),
-		prometheus.WithMetadataHook(func(globalRef storage.SeriesRef, l labels.Labels, m metadata.Metadata, next storage.Appender) (storage.SeriesRef, error) {
-			if res.exited.Load() {
-				return 0, fmt.Errorf("%s has exited", o.ID)
-			}
-
-			localID := prometheus.GlobalRefMapping.GetLocalRefID(res.opts.ID, uint64(globalRef))
-			newRef, nextErr := next.UpdateMetadata(storage.SeriesRef(localID), l, m)
-			if localID == 0 {
-				prometheus.GlobalRefMapping.GetOrAddLink(res.opts.ID, uint64(newRef), l)
-			}
-			return globalRef, nextErr
-		}),
-		prometheus.WithExemplarHook(func(globalRef storage.SeriesRef, l labels.Labels, e exemplar.Exemplar, next storage.Appender) (storage.SeriesRef, error) {
-			if res.exited.Load() {
-				return 0, fmt.Errorf("%s has exited", o.ID)
-			}
+		log:    o.Logger,
+		opts:   o,
+		shards: make(map[string]*walShard),
+	}
 
-			localID := prometheus.GlobalRefMapping.GetLocalRefID(res.opts.ID, uint64(globalRef))
-			newRef, nextErr := next.AppendExemplar(storage.SeriesRef(localID), l, e)
-			if localID == 0 {
-				prometheus.GlobalRefMapping.GetOrAddLink(res.opts.ID, uint64(newRef), l)
-			}
-			return globalRef, nextErr
-		}),
-	)
+	// Shards assume they are responsible for generating ref IDs. This means
+	// two shards may return the same ref ID for two different series. The
+	// appender treats the shard's ref ID as a "local ID" and translates it to
+	// a "global ID" to ensure Flow compatibility.
+	res.receiver = prometheus.NewInterceptor(appendableFunc(res.appender))
 
 	// Immediately export the receiver which remains the same for the component
 	// lifetime.
 	o.OnStateChange(Exports{Receiver: res.receiver})
 
 	if err := res.Update(c); err != nil {
+		res.closeShards()
 		return nil, err
 	}
 	return res, nil
 }
 
-func startTime() (int64, error) { return 0, nil }
-
-var _ component.Component = (*Component)(nil)
+var (
+	_ component.Component            = (*Component)(nil)
+	_ component.DestinationComponent = (*Component)(nil)
+)
 
 // Run implements Component.
 func (c *Component) Run(ctx context.Context) error {
 	defer func() {
 		c.exited.Store(true)
-
-		level.Debug(c.log).Log("msg", "closing storage")
-		err := c.storage.Close()
-		level.Debug(c.log).Log("msg", "storage closed")
-		if err != nil {
-			level.Error(c.log).Log("msg", "error when closing storage", "err", err)
-		}
+		c.closeShards()
 	}()
 
-	// Track the last timestamp we truncated for to prevent segments from getting
-	// deleted until at least some new data has been sent.
-	var lastTs = int64(math.MinInt64)
+	sizeTicker := time.NewTicker(sizeCheckFrequency)
+	defer sizeTicker.Stop()
 
 	for {
 		select {
@@ -174,50 +114,48 @@ func (c *Component) Run(ctx context.Context) error {
 			// We retrieve the current min/max keepalive time at once, since
 			// retrieving them separately could lead to issues where we have an older
 			// value for min which is now larger than max.
-			c.mut.RLock()
-			var (
-				minWALTime = c.cfg.WALOptions.MinKeepaliveTime
-				maxWALTime = c.cfg.WALOptions.MaxKeepaliveTime
-			)
-			c.mut.RUnlock()
-
-			// The timestamp ts is used to determine which series are not receiving
-			// samples and may be deleted from the WAL. Their most recent append
-			// timestamp is compared to ts, and if that timestamp is older than ts,
-			// they are considered inactive and may be deleted.
-			//
-			// Subtracting a duration from ts will delay when it will be considered
-			// inactive and scheduled for deletion.
-			ts := c.remoteStore.LowestSentTimestamp() - minWALTime.Milliseconds()
-			if ts < 0 {
-				ts = 0
+			walOptions, shards := c.walOptionsAndShards()
+			for _, s := range shards {
+				s.Truncate(walOptions)
 			}
-
-			// Network issues can prevent the result of getRemoteWriteTimestamp from
-			// changing. We don't want data in the WAL to grow forever, so we set a cap
-			// on the maximum age data can be. If our ts is older than this cutoff point,
-			// we'll shift it forward to start deleting very stale data.
-			if maxTS := timestamp.FromTime(time.Now().Add(-maxWALTime)); ts < maxTS {
-				ts = maxTS
-			}
-
-			if ts == lastTs {
-				level.Debug(c.log).Log("msg", "not truncating the WAL, remote_write timestamp is unchanged", "ts", ts)
+		case <-sizeTicker.C:
+			walOptions, shards := c.walOptionsAndShards()
+			if walOptions.MaxSize == 0 {
 				continue
 			}
-			lastTs = ts
-
-			level.Debug(c.log).Log("msg", "truncating the WAL", "ts", ts)
-			err := c.walStore.Truncate(ts)
-			if err != nil {
-				// The only issue here is larger disk usage and a greater replay time,
-				// so we'll only log this as a warning.
-				level.Warn(c.log).Log("msg", "could not truncate WAL", "err", err)
+			for _, s := range shards {
+				s.TruncateSize(int64(walOptions.MaxSize))
 			}
 		}
 	}
 }
 
+// walOptionsAndShards returns the current WAL options and shards.
+func (c *Component) walOptionsAndShards() (WALOptions, []*walShard) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	shards := make([]*walShard, 0, len(c.shards))
+	for _, s := range c.shards {
+		shards = append(shards, s)
+	}
+	return c.cfg.WALOptions, shards
+}
+
+func (c *Component) closeShards() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	level.Debug(c.log).Log("msg", "closing storage")
+	for tenant, s := range c.shards {
+		if err := s.Close(); err != nil {
+			level.Error(c.log).Log("msg", "error when closing storage", "tenant", tenant, "err", err)
+		}
+		delete(c.shards, tenant)
+	}
+	level.Debug(c.log).Log("msg", "storage closed")
+}
+
 func (c *Component) truncateFrequency() time.Duration {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
@@ -231,15 +169,113 @@ func (c *Component) Update(newConfig component.Arguments) error {
 	c.mut.Lock()
 	defer c.mut.Unlock()
 
-	convertedConfig, err := convertConfigs(cfg)
-	if err != nil {
+	// Validate the new config before applying it to any shards.
+	if _, err := convertConfigs(cfg); err != nil {
 		return err
 	}
-	err = c.remoteStore.ApplyConfig(convertedConfig)
-	if err != nil {
-		return err
+
+	if cfg.TenantSharding == nil {
+		if _, err := c.getOrCreateShard(cfg, ""); err != nil {
+			return err
+		}
+	} else {
+		// Open the WALs of tenants from previous runs so their data is sent
+		// even if the tenants don't receive any new samples.
+		tenants, err := existingTenants(c.opts.DataPath)
+		if err != nil {
+			return fmt.Errorf("finding tenant WALs: %w", err)
+		}
+		for _, tenant := range tenants {
+			if _, err := c.getOrCreateShard(cfg, tenant); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Shards which are no longer used, such as the shards for tenants after
+	// tenant sharding is disabled, are kept open so their remaining data is
+	// still sent.
+	for tenant, s := range c.shards {
+		if err := s.ApplyConfig(shardConfig(cfg, tenant), cfg.WALOptions); err != nil {
+			return err
+		}
 	}
 
 	c.cfg = cfg
 	return nil
 }
+
+// Destinations implements component.DestinationComponent.
+func (c *Component) Destinations() []string {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	urls := make([]string, 0, len(c.cfg.Endpoints))
+	for _, ep := range c.cfg.Endpoints {
+		urls = append(urls, ep.URL)
+	}
+	return urls
+}
+
+// shard returns the shard for tenant, lazily creating it for tenants which
+// haven't sent data before.
+func (c *Component) shard(tenant string) (*walShard, error) {
+	c.mut.RLock()
+	s, ok := c.shards[tenant]
+	c.mut.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.exited.Load() {
+		return nil, fmt.Errorf("%s has exited", c.opts.ID)
+	}
+	return c.getOrCreateShard(c.cfg, tenant)
+}
+
+// getOrCreateShard returns the shard for tenant, creating it with cfg if it
+// doesn't exist. c.mut must be held for writing when calling
+// getOrCreateShard.
+func (c *Component) getOrCreateShard(cfg Arguments, tenant string) (*walShard, error) {
+	if s, ok := c.shards[tenant]; ok {
+		return s, nil
+	}
+
+	var (
+		logger = c.log
+		reg    = c.opts.Registerer
+		dir    = c.opts.DataPath
+		refID  = c.opts.ID
+	)
+	if tenant != "" {
+		logger = log.With(c.log, "tenant", tenant)
+		reg = client_prometheus.WrapRegistererWith(client_prometheus.Labels{"tenant": tenant}, reg)
+		dir = tenantDir(c.opts.DataPath, tenant)
+		refID = c.opts.ID + "/" + tenant
+	}
+
+	s, err := newWALShard(logger, reg, c.opts.Events, dir, refID, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ApplyConfig(shardConfig(cfg, tenant), cfg.WALOptions); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+
+	c.shards[tenant] = s
+	return s, nil
+}
+
+// shardConfig returns the remote_write config for the shard of tenant.
+// shardConfig must only be called with arguments which were previously
+// validated with convertConfigs.
+func shardConfig(cfg Arguments, tenant string) *config.Config {
+	if tenant != "" && cfg.TenantSharding != nil {
+		cfg = tenantArguments(cfg, tenant)
+	}
+	converted, _ := convertConfigs(cfg)
+	return converted
+}
@@ -114,3 +114,91 @@ func Test(t *testing.T) {
 		require.Equal(t, expect, res.Timeseries)
 	}
 }
+
+// TestTenantSharding ensures that samples are sent separately for each tenant
+// with the tenant header set and the tenant label removed when tenant
+// sharding is enabled.
+func TestTenantSharding(t *testing.T) {
+	type tenantRequest struct {
+		tenant string
+		req    *prompb.WriteRequest
+	}
+	writeResult := make(chan tenantRequest, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := remote.DecodeWriteRequest(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case writeResult <- tenantRequest{tenant: r.Header.Get("X-Scope-OrgID"), req: req}:
+		default:
+			require.Fail(t, "failed to send remote_write result over channel")
+		}
+	}))
+	defer srv.Close()
+
+	cfg := fmt.Sprintf(`
+		tenant_sharding { }
+
+		endpoint {
+			url            = "%s/api/v1/write"
+			remote_timeout = "100ms"
+
+			queue_config {
+				batch_send_deadline = "100ms"
+			}
+		}
+	`, srv.URL)
+
+	var args remotewrite.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	tc, err := componenttest.NewControllerFromID(util.TestLogger(t), "prometheus.remote_write")
+	require.NoError(t, err)
+	go func() {
+		err = tc.Run(componenttest.TestContext(t), args)
+		require.NoError(t, err)
+	}()
+	require.NoError(t, tc.WaitRunning(time.Second))
+
+	sampleTimestamp := time.Now().Add(time.Minute).UnixMilli()
+
+	rwExports := tc.Exports().(remotewrite.Exports)
+	appender := rwExports.Receiver.Appender(context.Background())
+	_, err = appender.Append(0, labels.FromStrings("__tenant_id__", "team-a", "foo", "bar"), sampleTimestamp, 12)
+	require.NoError(t, err)
+	_, err = appender.Append(0, labels.FromStrings("__tenant_id__", "team-b", "foo", "bar"), sampleTimestamp, 34)
+	require.NoError(t, err)
+	_, err = appender.Append(0, labels.FromStrings("fizz", "buzz"), sampleTimestamp, 56)
+	require.NoError(t, err)
+	require.NoError(t, appender.Commit())
+
+	expect := map[string][]prompb.TimeSeries{
+		"team-a": {{
+			Labels:  []prompb.Label{{Name: "foo", Value: "bar"}},
+			Samples: []prompb.Sample{{Timestamp: sampleTimestamp, Value: 12}},
+		}},
+		"team-b": {{
+			Labels:  []prompb.Label{{Name: "foo", Value: "bar"}},
+			Samples: []prompb.Sample{{Timestamp: sampleTimestamp, Value: 34}},
+		}},
+		"anonymous": {{
+			Labels:  []prompb.Label{{Name: "fizz", Value: "buzz"}},
+			Samples: []prompb.Sample{{Timestamp: sampleTimestamp, Value: 56}},
+		}},
+	}
+
+	actual := make(map[string][]prompb.TimeSeries)
+	for len(actual) < len(expect) {
+		select {
+		case <-time.After(time.Minute):
+			require.FailNow(t, "timed out waiting for metrics")
+		case res := <-writeResult:
+			actual[res.tenant] = append(actual[res.tenant], res.req.Timeseries...)
+		}
+	}
+	require.Equal(t, expect, actual)
+}
@@ -0,0 +1,176 @@
+package remotewrite
+
+import (
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/flow/events"
+	"github.com/grafana/agent/pkg/metrics/wal"
+	client_prometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/storage/remote"
+)
+
+// tenantsDir is the directory inside of the component's data path which
+// holds a WAL for each tenant when tenant sharding is enabled.
+const tenantsDir = "tenants"
+
+// walShard is a WAL and the remote_write queues which send its data.
+// Without tenant sharding, the component writes to a single shard. With
+// tenant sharding, every tenant has its own shard so that a tenant whose
+// endpoints are slow or failing can't back up other tenants.
+type walShard struct {
+	// refID is the ID used with prometheus.GlobalRefMapping for the ref IDs
+	// generated by the WAL of this shard.
+	refID string
+
+	tenant      string      // Empty without tenant sharding.
+	events      *events.Bus // Bus where truncations are published.
+	log         log.Logger
+	walStore    *wal.Storage
+	remoteStore *remote.Storage
+	storage     storage.Storage
+
+	// lastTs is the last timestamp the WAL was truncated for. It's only
+	// accessed from the truncation loop.
+	lastTs int64
+}
+
+func newWALShard(logger log.Logger, reg client_prometheus.Registerer, bus *events.Bus, dir, refID, tenant string) (*walShard, error) {
+	walLogger := log.With(logger, "subcomponent", "wal")
+	walStorage, err := wal.NewStorage(walLogger, reg, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteLogger := log.With(logger, "subcomponent", "rw")
+	remoteStore := remote.NewStorage(remoteLogger, reg, startTime, dir, remoteFlushDeadline, nil)
+
+	return &walShard{
+		refID:       refID,
+		tenant:      tenant,
+		events:      bus,
+		log:         logger,
+		walStore:    walStorage,
+		remoteStore: remoteStore,
+		storage:     storage.NewFanout(logger, walStorage, remoteStore),
+		lastTs:      math.MinInt64,
+	}, nil
+}
+
+func startTime() (int64, error) { return 0, nil }
+
+// ApplyConfig applies the remote_write configuration and WAL options for the
+// shard.
+func (s *walShard) ApplyConfig(cfg *config.Config, opts WALOptions) error {
+	s.walStore.SetOutOfOrderTimeWindow(opts.OutOfOrderTimeWindow.Milliseconds())
+	return s.remoteStore.ApplyConfig(cfg)
+}
+
+// Truncate removes data from the WAL which has been sent or has become older
+// than the keepalive times in opts.
+func (s *walShard) Truncate(opts WALOptions) {
+	// The timestamp ts is used to determine which series are not receiving
+	// samples and may be deleted from the WAL. Their most recent append
+	// timestamp is compared to ts, and if that timestamp is older than ts,
+	// they are considered inactive and may be deleted.
+	//
+	// Subtracting a duration from ts will delay when it will be considered
+	// inactive and scheduled for deletion.
+	ts := s.remoteStore.LowestSentTimestamp() - opts.MinKeepaliveTime.Milliseconds()
+	if ts < 0 {
+		ts = 0
+	}
+
+	// Network issues can prevent the result of getRemoteWriteTimestamp from
+	// changing. We don't want data in the WAL to grow forever, so we set a cap
+	// on the maximum age data can be. If our ts is older than this cutoff point,
+	// we'll shift it forward to start deleting very stale data.
+	if maxTS := timestamp.FromTime(time.Now().Add(-opts.MaxKeepaliveTime)); ts < maxTS {
+		ts = maxTS
+	}
+
+	if ts == s.lastTs {
+		level.Debug(s.log).Log("msg", "not truncating the WAL, remote_write timestamp is unchanged", "ts", ts)
+		return
+	}
+	s.lastTs = ts
+
+	level.Debug(s.log).Log("msg", "truncating the WAL", "ts", ts)
+	err := s.walStore.Truncate(ts)
+	if err != nil {
+		// The only issue here is larger disk usage and a greater replay time,
+		// so we'll only log this as a warning.
+		level.Warn(s.log).Log("msg", "could not truncate WAL", "err", err)
+		return
+	}
+
+	fields := map[string]string{"truncated_before": timestamp.Time(ts).UTC().Format(time.RFC3339)}
+	if s.tenant != "" {
+		fields["tenant"] = s.tenant
+	}
+	s.events.Publish(events.Event{
+		Type:    events.TypeWALTruncated,
+		Message: "WAL truncated",
+		Fields:  fields,
+	})
+}
+
+// TruncateSize removes the oldest data from the WAL if it's larger than
+// maxSize bytes.
+func (s *walShard) TruncateSize(maxSize int64) {
+	if err := s.walStore.TruncateSize(maxSize); err != nil {
+		level.Warn(s.log).Log("msg", "could not truncate WAL to its maximum size", "err", err)
+	}
+}
+
+// Close closes the WAL and stops sending data.
+func (s *walShard) Close() error {
+	return s.storage.Close()
+}
+
+// tenantDir returns the directory holding the WAL for tenant.
+func tenantDir(dataPath, tenant string) string {
+	name := url.PathEscape(tenant)
+
+	// PathEscape leaves dots alone, which would allow tenants to refer to
+	// other directories.
+	switch name {
+	case ".":
+		name = "%2E"
+	case "..":
+		name = "%2E%2E"
+	}
+	return filepath.Join(dataPath, tenantsDir, name)
+}
+
+// existingTenants returns the tenants which have a WAL in dataPath from a
+// previous run.
+func existingTenants(dataPath string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dataPath, tenantsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, ent := range entries {
+		if !ent.IsDir() {
+			continue
+		}
+		tenant, err := url.PathUnescape(ent.Name())
+		if err != nil {
+			continue
+		}
+		res = append(res, tenant)
+	}
+	return res, nil
+}
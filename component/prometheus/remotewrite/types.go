@@ -6,6 +6,8 @@ import (
 	"sort"
 	"time"
 
+	"github.com/alecthomas/units"
+
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/storage"
 
@@ -46,15 +48,22 @@ var (
 		MaxKeepaliveTime:  8 * time.Hour,
 	}
 
+	DefaultTenantShardingOptions = TenantShardingOptions{
+		Label:         "__tenant_id__",
+		DefaultTenant: "anonymous",
+		Header:        "X-Scope-OrgID",
+	}
+
 	_ river.Unmarshaler = (*QueueOptions)(nil)
 )
 
 // Arguments represents the input state of the prometheus.remote_write
 // component.
 type Arguments struct {
-	ExternalLabels map[string]string  `river:"external_labels,attr,optional"`
-	Endpoints      []*EndpointOptions `river:"endpoint,block,optional"`
-	WALOptions     WALOptions         `river:"wal,block,optional"`
+	ExternalLabels map[string]string      `river:"external_labels,attr,optional"`
+	Endpoints      []*EndpointOptions     `river:"endpoint,block,optional"`
+	WALOptions     WALOptions             `river:"wal,block,optional"`
+	TenantSharding *TenantShardingOptions `river:"tenant_sharding,block,optional"`
 }
 
 // UnmarshalRiver implements river.Unmarshaler.
@@ -62,7 +71,18 @@ func (rc *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 	*rc = DefaultArguments
 
 	type config Arguments
-	return f((*config)(rc))
+	if err := f((*config)(rc)); err != nil {
+		return err
+	}
+
+	if rc.TenantSharding == nil {
+		for _, ep := range rc.Endpoints {
+			if len(ep.Tenants) > 0 {
+				return fmt.Errorf("tenants can only be set for endpoints when the tenant_sharding block is provided")
+			}
+		}
+	}
+	return nil
 }
 
 // EndpointOptions describes an individual location for where metrics in the WAL
@@ -77,6 +97,12 @@ type EndpointOptions struct {
 	HTTPClientConfig     *types.HTTPClientConfig `river:",squash"`
 	QueueOptions         *QueueOptions           `river:"queue_config,block,optional"`
 	MetadataOptions      *MetadataOptions        `river:"metadata_config,block,optional"`
+	Retry                *types.RetryConfig      `river:"retry,block,optional"`
+
+	// Tenants restricts the endpoint to receive data for the listed tenants
+	// when tenant sharding is enabled. An empty list receives data for every
+	// tenant.
+	Tenants []string `river:"tenants,attr,optional"`
 }
 
 func GetDefaultEndpointOptions() EndpointOptions {
@@ -99,6 +125,12 @@ func (r *EndpointOptions) UnmarshalRiver(f func(v interface{}) error) error {
 		return err
 	}
 
+	if r.Retry != nil {
+		if err := r.Retry.CheckSupported(retrySupport); err != nil {
+			return fmt.Errorf("invalid retry block: %w", err)
+		}
+	}
+
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	if r.HTTPClientConfig != nil {
 		return r.HTTPClientConfig.Validate()
@@ -144,6 +176,35 @@ func (r *QueueOptions) toPrometheusType() config.QueueConfig {
 	}
 }
 
+// retrySupport describes the retry settings supported by the remote_write
+// queue: the backoff always doubles, and 5xx responses are always retried.
+// The queue can only toggle whether 429 responses are retried, so 429 is the
+// only status code which can be listed.
+var retrySupport = types.RetrySupport{
+	Multiplier: 2,
+	StatusCodes: func(code int) bool {
+		return code == 429
+	},
+}
+
+// applyRetry overrides the backoff settings of qc with the settings of the
+// retry block r.
+func applyRetry(qc *config.QueueConfig, r *types.RetryConfig) {
+	if r == nil {
+		return
+	}
+	if r.InitialInterval > 0 {
+		qc.MinBackoff = model.Duration(r.InitialInterval)
+	}
+	if r.MaxInterval > 0 {
+		qc.MaxBackoff = model.Duration(r.MaxInterval)
+	}
+	if len(r.RetryableStatusCodes) > 0 {
+		// retrySupport only allows listing 429.
+		qc.RetryOnRateLimit = true
+	}
+}
+
 // MetadataOptions configures how metadata gets sent over the remote_write
 // protocol.
 type MetadataOptions struct {
@@ -174,9 +235,12 @@ func (o *MetadataOptions) toPrometheusType() config.MetadataConfig {
 
 // WALOptions configures behavior within the WAL.
 type WALOptions struct {
-	TruncateFrequency time.Duration `river:"truncate_frequency,attr,optional"`
-	MinKeepaliveTime  time.Duration `river:"min_keepalive_time,attr,optional"`
-	MaxKeepaliveTime  time.Duration `river:"max_keepalive_time,attr,optional"`
+	TruncateFrequency time.Duration    `river:"truncate_frequency,attr,optional"`
+	MinKeepaliveTime  time.Duration    `river:"min_keepalive_time,attr,optional"`
+	MaxKeepaliveTime  time.Duration    `river:"max_keepalive_time,attr,optional"`
+	MaxSize           units.Base2Bytes `river:"max_size,attr,optional"`
+
+	OutOfOrderTimeWindow time.Duration `river:"out_of_order_time_window,attr,optional"`
 }
 
 // UnmarshalRiver implements river.Unmarshaler.
@@ -193,11 +257,46 @@ func (o *WALOptions) UnmarshalRiver(f func(interface{}) error) error {
 		return fmt.Errorf("truncate_frequency must not be 0")
 	case o.MaxKeepaliveTime <= o.MinKeepaliveTime:
 		return fmt.Errorf("min_keepalive_time must be smaller than max_keepalive_time")
+	case o.MaxSize < 0:
+		return fmt.Errorf("max_size must not be negative")
+	case o.OutOfOrderTimeWindow < 0:
+		return fmt.Errorf("out_of_order_time_window must not be negative")
 	}
 
 	return nil
 }
 
+// TenantShardingOptions configures routing samples into a separate WAL and
+// set of queues for each tenant.
+type TenantShardingOptions struct {
+	// Label holds the tenant of a sample. The label is removed from samples
+	// before they're written to the WAL.
+	Label string `river:"label,attr,optional"`
+	// DefaultTenant is used for samples which don't have Label set.
+	DefaultTenant string `river:"default_tenant,attr,optional"`
+	// Header is the HTTP header used to send the tenant of samples to
+	// endpoints. No header is sent if Header is empty.
+	Header string `river:"header,attr,optional"`
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (o *TenantShardingOptions) UnmarshalRiver(f func(interface{}) error) error {
+	*o = DefaultTenantShardingOptions
+
+	type options TenantShardingOptions
+	if err := f((*options)(o)); err != nil {
+		return err
+	}
+
+	switch {
+	case o.Label == "":
+		return fmt.Errorf("label must not be empty")
+	case o.DefaultTenant == "":
+		return fmt.Errorf("default_tenant must not be empty")
+	}
+	return nil
+}
+
 // Exports are the set of fields exposed by the prometheus.remote_write
 // component.
 type Exports struct {
@@ -212,6 +311,9 @@ func convertConfigs(cfg Arguments) (*config.Config, error) {
 			return nil, fmt.Errorf("cannot parse remote_write url %q: %w", rw.URL, err)
 		}
 
+		queueConfig := rw.QueueOptions.toPrometheusType()
+		applyRetry(&queueConfig, rw.Retry)
+
 		rwConfigs = append(rwConfigs, &config.RemoteWriteConfig{
 			URL:                  &common.URL{URL: parsedURL},
 			RemoteTimeout:        model.Duration(rw.RemoteTimeout),
@@ -222,7 +324,7 @@ func convertConfigs(cfg Arguments) (*config.Config, error) {
 			SendNativeHistograms: rw.SendNativeHistograms,
 
 			HTTPClientConfig: *rw.HTTPClientConfig.Convert(),
-			QueueConfig:      rw.QueueOptions.toPrometheusType(),
+			QueueConfig:      queueConfig,
 			MetadataConfig:   rw.MetadataOptions.toPrometheusType(),
 			// TODO(rfratto): SigV4Config
 		})
@@ -236,6 +338,41 @@ func convertConfigs(cfg Arguments) (*config.Config, error) {
 	}, nil
 }
 
+// tenantArguments returns the arguments used for the WAL shard of tenant:
+// endpoints which don't receive data for tenant are removed, and the tenant
+// header is added to the remaining endpoints.
+func tenantArguments(cfg Arguments, tenant string) Arguments {
+	res := cfg
+	res.Endpoints = nil
+
+	for _, ep := range cfg.Endpoints {
+		if len(ep.Tenants) > 0 && !containsString(ep.Tenants, tenant) {
+			continue
+		}
+
+		tenantEndpoint := *ep
+		if header := cfg.TenantSharding.Header; header != "" {
+			tenantEndpoint.Headers = make(map[string]string, len(ep.Headers)+1)
+			for k, v := range ep.Headers {
+				tenantEndpoint.Headers[k] = v
+			}
+			tenantEndpoint.Headers[header] = tenant
+		}
+		res.Endpoints = append(res.Endpoints, &tenantEndpoint)
+	}
+
+	return res
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func toLabels(in map[string]string) labels.Labels {
 	res := make(labels.Labels, 0, len(in))
 	for k, v := range in {
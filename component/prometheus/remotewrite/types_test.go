@@ -1,9 +1,13 @@
 package remotewrite
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 )
 
@@ -53,3 +57,158 @@ func TestBadRiverConfig(t *testing.T) {
 	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
+
+func TestTenantShardingConfig(t *testing.T) {
+	var exampleRiverConfig = `
+		tenant_sharding { }
+
+		endpoint {
+			url     = "http://0.0.0.0:11111/api/v1/write"
+			headers = { "X-Custom" = "value" }
+		}
+
+		endpoint {
+			url     = "http://0.0.0.0:22222/api/v1/write"
+			tenants = ["team-a"]
+		}
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+	require.Equal(t, &DefaultTenantShardingOptions, args.TenantSharding)
+
+	teamA := tenantArguments(args, "team-a")
+	require.Len(t, teamA.Endpoints, 2)
+	require.Equal(t, map[string]string{"X-Custom": "value", "X-Scope-OrgID": "team-a"}, teamA.Endpoints[0].Headers)
+	require.Equal(t, map[string]string{"X-Scope-OrgID": "team-a"}, teamA.Endpoints[1].Headers)
+
+	teamB := tenantArguments(args, "team-b")
+	require.Len(t, teamB.Endpoints, 1)
+	require.Equal(t, map[string]string{"X-Custom": "value", "X-Scope-OrgID": "team-b"}, teamB.Endpoints[0].Headers)
+
+	// The original arguments must not be modified.
+	require.Equal(t, map[string]string{"X-Custom": "value"}, args.Endpoints[0].Headers)
+}
+
+func TestBadTenantShardingConfig(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name: "tenants without tenant_sharding",
+			cfg: `
+				endpoint {
+					url     = "http://0.0.0.0:11111/api/v1/write"
+					tenants = ["team-a"]
+				}
+			`,
+			expect: "tenants can only be set for endpoints when the tenant_sharding block is provided",
+		},
+		{
+			name:   "empty label",
+			cfg:    `tenant_sharding { label = "" }`,
+			expect: "label must not be empty",
+		},
+		{
+			name:   "empty default tenant",
+			cfg:    `tenant_sharding { default_tenant = "" }`,
+			expect: "default_tenant must not be empty",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
+
+func TestWALMaxSizeConfig(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		wal {
+			max_size = "2GiB"
+		}
+	`), &args)
+	require.NoError(t, err)
+	require.Equal(t, 2*units.GiB, args.WALOptions.MaxSize)
+	require.Equal(t, DefaultWALOptions.TruncateFrequency, args.WALOptions.TruncateFrequency)
+}
+
+func TestRetryConfig(t *testing.T) {
+	var exampleRiverConfig = `
+		endpoint {
+			url = "http://0.0.0.0:11111/api/v1/write"
+
+			queue_config {
+				max_backoff = "10s"
+			}
+
+			retry {
+				initial_interval       = "1s"
+				retryable_status_codes = [429]
+			}
+		}
+`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(exampleRiverConfig), &args))
+
+	cfg, err := convertConfigs(args)
+	require.NoError(t, err)
+	qc := cfg.RemoteWriteConfigs[0].QueueConfig
+	require.Equal(t, model.Duration(time.Second), qc.MinBackoff)
+	require.Equal(t, model.Duration(10*time.Second), qc.MaxBackoff)
+	require.True(t, qc.RetryOnRateLimit)
+}
+
+func TestBadRetryConfig(t *testing.T) {
+	tt := []struct {
+		name  string
+		retry string
+		err   string
+	}{
+		{
+			name:  "unsupported multiplier",
+			retry: `multiplier = 1.5`,
+			err:   "invalid retry block: multiplier must be 2",
+		},
+		{
+			name:  "unsupported max_elapsed_time",
+			retry: `max_elapsed_time = "5m"`,
+			err:   "invalid retry block: max_elapsed_time is not supported",
+		},
+		{
+			name:  "unsupported status code",
+			retry: `retryable_status_codes = [408]`,
+			err:   "invalid retry block: status code 408 can't be listed in retryable_status_codes",
+		},
+		{
+			name:  "always retried status code",
+			retry: `retryable_status_codes = [429, 503]`,
+			err:   "invalid retry block: status code 503 can't be listed in retryable_status_codes",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			exampleRiverConfig := fmt.Sprintf(`
+				endpoint {
+					url = "http://0.0.0.0:11111/api/v1/write"
+
+					retry {
+						%s
+					}
+				}
+			`, tc.retry)
+
+			var args Arguments
+			err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
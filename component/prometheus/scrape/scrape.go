@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"reflect"
 	"sync"
 	"time"
 
@@ -16,19 +17,24 @@ import (
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/prometheus"
 	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/cluster"
 	client_prometheus "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/scrape"
+	"github.com/rfratto/ckit/peer"
+	"github.com/rfratto/ckit/shard"
 )
 
 func init() {
 	scrape.UserAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
 
 	component.Register(component.Registration{
-		Name: "prometheus.scrape",
-		Args: Arguments{},
+		Name:    "prometheus.scrape",
+		Args:    Arguments{},
+		Exports: Exports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
@@ -54,6 +60,9 @@ type Arguments struct {
 	ScrapeInterval time.Duration `river:"scrape_interval,attr,optional"`
 	// The timeout for scraping targets of this config.
 	ScrapeTimeout time.Duration `river:"scrape_timeout,attr,optional"`
+	// The maximum time to scrape a target and forward its samples to
+	// downstream components. 0 means no budget.
+	ScrapeBudget time.Duration `river:"scrape_budget,attr,optional"`
 	// The HTTP resource path on which to fetch metrics from targets.
 	MetricsPath string `river:"metrics_path,attr,optional"`
 	// The URL scheme with which to fetch metrics from targets.
@@ -81,6 +90,67 @@ type Arguments struct {
 
 	// Scrape Options
 	ExtraMetrics bool `river:"extra_metrics,attr,optional"`
+	// Whether to request the protobuf exposition format, which is required to
+	// scrape native histograms.
+	EnableProtobufNegotiation bool `river:"enable_protobuf_negotiation,attr,optional"`
+	// Exposition format preferred when negotiating with targets. Defaults to
+	// protobuf if EnableProtobufNegotiation is set, and OpenMetrics otherwise.
+	ScrapeProtocol ScrapeProtocol `river:"scrape_protocol,attr,optional"`
+	// Seed mixed into the hash which spreads the scrapes of targets across the
+	// scrape interval.
+	JitterSeed string `river:"jitter_seed,attr,optional"`
+
+	Clustering Clustering `river:"clustering,block,optional"`
+}
+
+// ScrapeProtocol is an exposition format requested from targets. Targets
+// which don't support the requested format fall back to the formats after it
+// in the order protobuf, OpenMetrics, Prometheus text.
+type ScrapeProtocol string
+
+// Supported ScrapeProtocol values.
+const (
+	ScrapeProtocolDefault     ScrapeProtocol = ""
+	ScrapeProtocolOpenMetrics ScrapeProtocol = "openmetrics"
+	ScrapeProtocolProtobuf    ScrapeProtocol = "protobuf"
+)
+
+// scrapeProtocolText is the Prometheus text format. It can't be requested on
+// its own: the vendored scrape package hard-codes the Accept headers of
+// scrape requests, and always accepts OpenMetrics before the text format.
+const scrapeProtocolText ScrapeProtocol = "text"
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *ScrapeProtocol) UnmarshalText(text []byte) error {
+	switch v := ScrapeProtocol(text); v {
+	case ScrapeProtocolOpenMetrics, ScrapeProtocolProtobuf:
+		*p = v
+		return nil
+	case scrapeProtocolText:
+		return fmt.Errorf("scrape_protocol %q isn't supported: targets are always asked for %q before the Prometheus text format", v, ScrapeProtocolOpenMetrics)
+	default:
+		return fmt.Errorf("unknown scrape_protocol %q, expected one of %q, %q", v, ScrapeProtocolOpenMetrics, ScrapeProtocolProtobuf)
+	}
+}
+
+// protobufNegotiation returns whether the protobuf exposition format is
+// requested from targets.
+func (arg *Arguments) protobufNegotiation() bool {
+	switch arg.ScrapeProtocol {
+	case ScrapeProtocolProtobuf:
+		return true
+	case ScrapeProtocolOpenMetrics:
+		return false
+	default:
+		return arg.EnableProtobufNegotiation
+	}
+}
+
+// Clustering holds values that configure clustering-specific behavior.
+type Clustering struct {
+	// Enabled distributes targets across the agents in the cluster, so that
+	// each target is only scraped by one agent.
+	Enabled bool `river:"enabled,attr"`
 }
 
 // DefaultArguments defines the default settings for a scrape job.
@@ -104,6 +174,16 @@ func (arg *Arguments) UnmarshalRiver(f func(interface{}) error) error {
 		return err
 	}
 
+	if arg.ScrapeBudget < 0 {
+		return fmt.Errorf("scrape_budget must not be negative")
+	}
+	if arg.ScrapeBudget > 0 && arg.ScrapeBudget < arg.ScrapeTimeout {
+		return fmt.Errorf("scrape_budget (%s) must not be less than scrape_timeout (%s)", arg.ScrapeBudget, arg.ScrapeTimeout)
+	}
+	if arg.EnableProtobufNegotiation && arg.ScrapeProtocol == ScrapeProtocolOpenMetrics {
+		return fmt.Errorf("enable_protobuf_negotiation can't be set when scrape_protocol is %q", ScrapeProtocolOpenMetrics)
+	}
+
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	return arg.HTTPClientConfig.Validate()
 }
@@ -114,27 +194,60 @@ type Component struct {
 
 	reloadTargets chan struct{}
 
-	mut          sync.RWMutex
-	args         Arguments
-	scraper      *scrape.Manager
-	appendable   *prometheus.Fanout
-	targetsGauge client_prometheus.Gauge
+	mut           sync.RWMutex
+	args          Arguments
+	scraper       *scrape.Manager
+	scrapeOptions *scrape.Options // Shared with scraper; only changed by Update
+	httpDefaults  component_config.HTTPDefaults
+	appendable    *prometheus.Fanout
+	budget        *budgetAppendable
+	samples       *sampleCounts
+	targetsGauge  client_prometheus.Gauge
+	limitsGauge   *client_prometheus.GaugeVec
+	invalidGauge  client_prometheus.Gauge
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component     = (*Component)(nil)
+	_ component.HTTPComponent = (*Component)(nil)
 )
 
 // New creates a new prometheus.scrape component.
 func New(o component.Options, args Arguments) (*Component, error) {
 	flowAppendable := prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer)
-	scrapeOptions := &scrape.Options{ExtraMetrics: args.ExtraMetrics}
-	scraper := scrape.NewManager(scrapeOptions, o.Logger, flowAppendable)
+	samples := newSampleCounts()
+	httpDefaults := args.HTTPClientConfig.ClientDefaults()
+	scrapeOptions := &scrape.Options{
+		ExtraMetrics:              args.ExtraMetrics,
+		EnableProtobufNegotiation: args.protobufNegotiation(),
+		HTTPClientOptions:         httpDefaults.ClientOptions(),
+	}
+	budget, err := newBudgetAppendable(samples.Interceptor(flowAppendable), o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+	scraper := scrape.NewManager(scrapeOptions, o.Logger, budget)
 
 	targetsGauge := client_prometheus.NewGauge(client_prometheus.GaugeOpts{
 		Name: "agent_prometheus_scrape_targets_gauge",
 		Help: "Number of targets this component is configured to scrape"})
-	err := o.Registerer.Register(targetsGauge)
+	err = o.Registerer.Register(targetsGauge)
+	if err != nil {
+		return nil, err
+	}
+
+	limitsGauge := client_prometheus.NewGaugeVec(client_prometheus.GaugeOpts{
+		Name: "agent_prometheus_scrape_targets_exceeding_limit",
+		Help: "Number of targets whose latest scrape failed because it exceeded a limit"}, []string{"limit"})
+	err = o.Registerer.Register(limitsGauge)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidGauge := client_prometheus.NewGauge(client_prometheus.GaugeOpts{
+		Name: "agent_prometheus_scrape_targets_invalid_limits",
+		Help: "Number of targets which aren't scraped because their labels set invalid limits"})
+	err = o.Registerer.Register(invalidGauge)
 	if err != nil {
 		return nil, err
 	}
@@ -143,10 +256,20 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		opts:          o,
 		reloadTargets: make(chan struct{}, 1),
 		scraper:       scraper,
+		scrapeOptions: scrapeOptions,
+		httpDefaults:  httpDefaults,
 		appendable:    flowAppendable,
+		budget:        budget,
+		samples:       samples,
 		targetsGauge:  targetsGauge,
+		limitsGauge:   limitsGauge,
+		invalidGauge:  invalidGauge,
 	}
 
+	// Export an empty set of targets so the export can be referenced before
+	// the first scrape.
+	o.OnStateChange(Exports{})
+
 	// Call to Update() to set the receivers and targets once at the start.
 	if err := c.Update(args); err != nil {
 		return nil, err
@@ -169,21 +292,49 @@ func (c *Component) Run(ctx context.Context) error {
 		}
 	}()
 
+	// Targets need to be redistributed whenever the set of agents in the
+	// cluster changes.
+	if c.opts.Cluster != nil {
+		c.opts.Cluster.Observe(cluster.FuncObserver(func(_ []peer.Peer) (reregister bool) {
+			select {
+			case c.reloadTargets <- struct{}{}:
+			default:
+			}
+			return ctx.Err() == nil
+		}))
+	}
+
+	// The status of targets is exported once per scrape interval; exporting
+	// it more often wouldn't report anything new.
+	statusTicker := time.NewTicker(c.scrapeInterval())
+	defer statusTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-statusTicker.C:
+			c.exportTargetStatus()
 		case <-c.reloadTargets:
+			statusTicker.Reset(c.scrapeInterval())
+
 			c.mut.RLock()
 			var (
-				tgs     = c.args.Targets
-				jobName = c.opts.ID
+				tgs        = c.args.Targets
+				jobName    = c.opts.ID
+				clustering = c.args.Clustering.Enabled
 			)
 			if c.args.JobName != "" {
 				jobName = c.args.JobName
 			}
 			c.mut.RUnlock()
-			promTargets := c.componentTargetsToProm(jobName, tgs)
+
+			owned := tgs
+			if clustering {
+				owned = c.ownedTargets(tgs)
+			}
+			c.targetsGauge.Set(float64(len(owned)))
+			promTargets := c.componentTargetsToProm(jobName, tgs, owned)
 
 			select {
 			case targetSetsChan <- promTargets:
@@ -200,14 +351,37 @@ func (c *Component) Update(args component.Arguments) error {
 
 	c.mut.Lock()
 	defer c.mut.Unlock()
-	c.args = newArgs
 
 	c.appendable.UpdateChildren(newArgs.ForwardTo)
+	c.budget.SetBudget(newArgs.ScrapeBudget)
+
+	// Scrape pools read the scrape options and the jitter seed when they're
+	// created, so the scrape pool is removed and recreated with the new
+	// targets when either changes. The HTTP client defaults are part of the
+	// scrape options.
+	httpDefaults := newArgs.HTTPClientConfig.ClientDefaults()
+	if c.scrapeOptions.EnableProtobufNegotiation != newArgs.protobufNegotiation() ||
+		c.args.JitterSeed != newArgs.JitterSeed ||
+		!reflect.DeepEqual(c.httpDefaults, httpDefaults) {
+
+		if err := c.scraper.ApplyConfig(&config.Config{}); err != nil {
+			return fmt.Errorf("error removing scrape pool: %w", err)
+		}
+		c.scrapeOptions.EnableProtobufNegotiation = newArgs.protobufNegotiation()
+		c.scrapeOptions.HTTPClientOptions = httpDefaults.ClientOptions()
+		c.httpDefaults = httpDefaults
+	}
 
-	sc := getPromScrapeConfigs(c.opts.ID, newArgs)
-	err := c.scraper.ApplyConfig(&config.Config{
-		ScrapeConfigs: []*config.ScrapeConfig{sc},
-	})
+	validTargets := dropInvalidLimits(c.opts.Logger, newArgs.Targets)
+	c.invalidGauge.Set(float64(len(newArgs.Targets) - len(validTargets)))
+	newArgs.Targets = validTargets
+	c.args = newArgs
+
+	promConfig, err := getPromConfig(c.opts.ID, newArgs)
+	if err != nil {
+		return fmt.Errorf("error building scrape configs: %w", err)
+	}
+	err = c.scraper.ApplyConfig(promConfig)
 	if err != nil {
 		return fmt.Errorf("error applying scrape configs: %w", err)
 	}
@@ -218,10 +392,79 @@ func (c *Component) Update(args component.Arguments) error {
 	default:
 	}
 
-	c.targetsGauge.Set(float64(len(c.args.Targets)))
 	return nil
 }
 
+// ownedTargets returns the subset of tgs which the local agent is responsible
+// for scraping. Targets are assigned to agents by consistently hashing their
+// labels, so every agent discovering the same set of targets agrees on the
+// owner of each target without coordination.
+//
+// If target owners can't be determined, such as when no agent in the cluster
+// is ready to receive work yet, all targets are kept so they continue to be
+// scraped.
+func (c *Component) ownedTargets(tgs []discovery.Target) []discovery.Target {
+	if c.opts.Cluster == nil {
+		return tgs
+	}
+
+	res := make([]discovery.Target, 0, len(tgs))
+	for _, tg := range tgs {
+		key := shard.Key(convertLabelSet(tg).Fingerprint())
+
+		peers, err := c.opts.Cluster.Lookup(key, 1, shard.OpReadWrite)
+		if err != nil {
+			level.Warn(c.opts.Logger).Log("msg", "failed to determine target owners; scraping all targets", "err", err)
+			return tgs
+		}
+		if len(peers) == 0 || peers[0].Self {
+			res = append(res, tg)
+		}
+	}
+	return res
+}
+
+// getPromConfig returns the Prometheus config to apply to the scrape manager
+// for the arguments c.
+func getPromConfig(jobName string, c Arguments) (*config.Config, error) {
+	base := getPromScrapeConfigs(jobName, c)
+	cfg := &config.Config{
+		ScrapeConfigs: []*config.ScrapeConfig{base},
+	}
+
+	// Targets with target-level authentication settings or limits get a
+	// scrape config for every distinct set of settings.
+	pools := map[string]struct{}{base.JobName: {}}
+	for _, tg := range c.Targets {
+		name := poolName(base.JobName, tg)
+		if _, ok := pools[name]; ok {
+			continue
+		}
+		pools[name] = struct{}{}
+
+		sc := *base
+		sc.JobName = name
+		getTargetAuth(tg).apply(&sc.HTTPClientConfig)
+		if err := getTargetLimits(tg).apply(&sc); err != nil {
+			return nil, fmt.Errorf("target %s: %w", tg[model.AddressLabel], err)
+		}
+		cfg.ScrapeConfigs = append(cfg.ScrapeConfigs, &sc)
+	}
+
+	// The scrape manager only uses the external labels to seed the offsets of
+	// targets, together with the hostname of the agent. The seed is XORed
+	// with the hash of each target, so no seed aligns all targets to the
+	// start of the interval.
+	if c.JitterSeed != "" {
+		cfg.GlobalConfig.ExternalLabels = labels.FromStrings(jitterSeedLabel, c.JitterSeed)
+	}
+	return cfg, nil
+}
+
+// jitterSeedLabel is the external label used to pass the jitter seed to the
+// scrape manager. It's never added to scraped samples.
+const jitterSeedLabel = "__jitter_seed__"
+
 // Helper function to bridge the in-house configuration with the Prometheus
 // scrape_config.
 // As explained in the Config struct, the following fields are purposefully
@@ -262,13 +505,20 @@ type ScraperStatus struct {
 
 // TargetStatus reports on the status of the latest scrape for a target.
 type TargetStatus struct {
-	JobName            string            `river:"job,attr"`
-	URL                string            `river:"url,attr"`
-	Health             string            `river:"health,attr"`
-	Labels             map[string]string `river:"labels,attr"`
-	LastError          string            `river:"last_error,attr,optional"`
-	LastScrape         time.Time         `river:"last_scrape,attr"`
-	LastScrapeDuration time.Duration     `river:"last_scrape_duration,attr,optional"`
+	JobName            string            `river:"job,attr" json:"job"`
+	URL                string            `river:"url,attr" json:"url"`
+	Health             string            `river:"health,attr" json:"health"`
+	Labels             map[string]string `river:"labels,attr" json:"labels"`
+	LastError          string            `river:"last_error,attr,optional" json:"last_error,omitempty"`
+	LastScrape         time.Time         `river:"last_scrape,attr" json:"last_scrape"`
+	LastScrapeDuration time.Duration     `river:"last_scrape_duration,attr,optional" json:"last_scrape_duration"`
+	// SamplesScraped is the number of samples exposed by the target in the
+	// latest scrape. It's only reported by prometheus.scrape.
+	SamplesScraped int `river:"samples_scraped,attr,optional" json:"samples_scraped"`
+	// Stale is true once a target which has been scraped before goes more than
+	// two scrape intervals without being scraped again. It's only reported by
+	// prometheus.scrape.
+	Stale bool `river:"stale,attr,optional" json:"stale"`
 }
 
 // BuildTargetStatuses transforms the targets from a scrape manager into our internal status type for debug info.
@@ -304,13 +554,33 @@ func (c *Component) DebugInfo() interface{} {
 	}
 }
 
-func (c *Component) componentTargetsToProm(jobName string, tgs []discovery.Target) map[string][]*targetgroup.Group {
-	promGroup := &targetgroup.Group{Source: jobName}
+// componentTargetsToProm returns the target groups of the scrape pools of
+// jobName, which scrape the owned subset of tgs. Every scrape pool of tgs gets
+// a target group, even if none of its targets are owned, so that it stops
+// scraping the targets it owned before.
+func (c *Component) componentTargetsToProm(jobName string, tgs, owned []discovery.Target) map[string][]*targetgroup.Group {
+	res := map[string][]*targetgroup.Group{
+		jobName: {{Source: jobName}},
+	}
 	for _, tg := range tgs {
-		promGroup.Targets = append(promGroup.Targets, convertLabelSet(tg))
+		name := poolName(jobName, tg)
+		if _, ok := res[name]; ok {
+			continue
+		}
+
+		// The scrape pool is named differently from the job, so the job label
+		// must be set explicitly.
+		res[name] = []*targetgroup.Group{{
+			Source: name,
+			Labels: model.LabelSet{model.JobLabel: model.LabelValue(jobName)},
+		}}
 	}
 
-	return map[string][]*targetgroup.Group{jobName: {promGroup}}
+	for _, tg := range owned {
+		promGroup := res[poolName(jobName, tg)][0]
+		promGroup.Targets = append(promGroup.Targets, convertLabelSet(tg))
+	}
+	return res
 }
 
 func convertLabelSet(tg discovery.Target) model.LabelSet {
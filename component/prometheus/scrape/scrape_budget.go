@@ -0,0 +1,110 @@
+package scrape
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grafana/agent/component/prometheus"
+	client_prometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/storage"
+	"go.uber.org/atomic"
+)
+
+// budgetAppendable sets a deadline on the appenders used by scrapes, so that
+// a scrape which can't be forwarded to downstream components within the
+// scrape budget fails instead of delaying them.
+//
+// The scrape manager creates the appender of a scrape before the target is
+// scraped, so the budget covers both scraping the target and forwarding its
+// samples.
+type budgetAppendable struct {
+	next     storage.Appendable
+	budget   atomic.Duration // No deadline is set if zero.
+	exceeded client_prometheus.Counter
+}
+
+var _ storage.Appendable = (*budgetAppendable)(nil)
+
+func newBudgetAppendable(next storage.Appendable, reg client_prometheus.Registerer) (*budgetAppendable, error) {
+	exceeded := client_prometheus.NewCounter(client_prometheus.CounterOpts{
+		Name: "agent_prometheus_scrape_budget_exceeded_total",
+		Help: "Number of scrapes which failed because they exceeded the scrape budget",
+	})
+	if err := reg.Register(exceeded); err != nil {
+		return nil, err
+	}
+	return &budgetAppendable{next: next, exceeded: exceeded}, nil
+}
+
+// SetBudget changes the budget of appenders created after the call.
+func (b *budgetAppendable) SetBudget(budget time.Duration) {
+	b.budget.Store(budget)
+}
+
+// Appender implements storage.Appendable.
+func (b *budgetAppendable) Appender(ctx context.Context) storage.Appender {
+	budget := b.budget.Load()
+	if budget <= 0 {
+		return b.next.Appender(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	return &budgetAppender{
+		Appender: b.next.Appender(ctx),
+		cancel:   cancel,
+		exceeded: b.exceeded,
+	}
+}
+
+type budgetAppender struct {
+	storage.Appender
+
+	cancel   context.CancelFunc
+	exceeded client_prometheus.Counter
+	reported bool // True once exceeding the budget has been counted.
+}
+
+func (a *budgetAppender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	ref, err := a.Appender.Append(ref, l, t, v)
+	return ref, a.observe(err)
+}
+
+func (a *budgetAppender) AppendExemplar(ref storage.SeriesRef, l labels.Labels, e exemplar.Exemplar) (storage.SeriesRef, error) {
+	ref, err := a.Appender.AppendExemplar(ref, l, e)
+	return ref, a.observe(err)
+}
+
+func (a *budgetAppender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	ref, err := a.Appender.AppendHistogram(ref, l, t, h, fh)
+	return ref, a.observe(err)
+}
+
+func (a *budgetAppender) UpdateMetadata(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
+	ref, err := a.Appender.UpdateMetadata(ref, l, m)
+	return ref, a.observe(err)
+}
+
+func (a *budgetAppender) Commit() error {
+	defer a.cancel()
+	return a.observe(a.Appender.Commit())
+}
+
+func (a *budgetAppender) Rollback() error {
+	defer a.cancel()
+	return a.Appender.Rollback()
+}
+
+// observe counts the scrape of a as exceeding the budget if err is caused by
+// the deadline of a.
+func (a *budgetAppender) observe(err error) error {
+	if !a.reported && errors.Is(err, prometheus.ErrDeadlineExceeded) {
+		a.reported = true
+		a.exceeded.Inc()
+	}
+	return err
+}
@@ -2,16 +2,33 @@ package scrape
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
 	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/storage"
+	"github.com/rfratto/ckit/peer"
+	"github.com/rfratto/ckit/shard"
 	"github.com/stretchr/testify/require"
 )
 
@@ -62,10 +79,248 @@ func TestBadRiverConfig(t *testing.T) {
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
 
+func TestScrapeBudgetRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+	targets        = [{ "target1" = "target1" }]
+	forward_to     = []
+	scrape_timeout = "10s"
+	scrape_budget  = "5s"
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.ErrorContains(t, err, "scrape_budget (5s) must not be less than scrape_timeout (10s)")
+}
+
+func TestScrapeProtocolRiverConfig(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+	targets         = [{ "target1" = "target1" }]
+	forward_to      = []
+	scrape_protocol = "protobuf"
+`), &args))
+	require.True(t, args.protobufNegotiation())
+
+	err := river.Unmarshal([]byte(`
+	targets         = [{ "target1" = "target1" }]
+	forward_to      = []
+	scrape_protocol = "text"
+`), &args)
+	require.ErrorContains(t, err, `scrape_protocol "text" isn't supported`)
+
+	err = river.Unmarshal([]byte(`
+	targets         = [{ "target1" = "target1" }]
+	forward_to      = []
+	scrape_protocol = "json"
+`), &args)
+	require.ErrorContains(t, err, `unknown scrape_protocol "json"`)
+
+	err = river.Unmarshal([]byte(`
+	targets                     = [{ "target1" = "target1" }]
+	forward_to                  = []
+	scrape_protocol             = "openmetrics"
+	enable_protobuf_negotiation = true
+`), &args)
+	require.ErrorContains(t, err, `enable_protobuf_negotiation can't be set when scrape_protocol is "openmetrics"`)
+}
+
+func TestScrapeProtocol(t *testing.T) {
+	tt := []struct {
+		name         string
+		protocol     ScrapeProtocol
+		expectAccept string
+	}{
+		{name: "default", protocol: ScrapeProtocolDefault, expectAccept: "application/openmetrics-text;"},
+		{name: "openmetrics", protocol: ScrapeProtocolOpenMetrics, expectAccept: "application/openmetrics-text;"},
+		{name: "protobuf", protocol: ScrapeProtocolProtobuf, expectAccept: "application/vnd.google.protobuf;"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			accepts := make(chan string, 10)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case accepts <- r.Header.Get("Accept"):
+				default:
+				}
+				fmt.Fprintln(w, "metric_a 1")
+			}))
+			defer srv.Close()
+
+			opts := component.Options{
+				ID:            "prometheus.scrape.test",
+				Logger:        util.TestFlowLogger(t),
+				Registerer:    prometheus_client.NewRegistry(),
+				OnStateChange: func(e component.Exports) {},
+			}
+
+			args := DefaultArguments
+			args.Targets = []discovery.Target{{"__address__": strings.TrimPrefix(srv.URL, "http://")}}
+			args.ScrapeInterval = 100 * time.Millisecond
+			args.ScrapeTimeout = 50 * time.Millisecond
+			args.ScrapeProtocol = tc.protocol
+
+			c, err := New(opts, args)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() { _ = c.Run(ctx) }()
+
+			// The scrape manager only applies new targets every 5 seconds, so the
+			// first scrape takes a while.
+			select {
+			case accept := <-accepts:
+				require.True(t, strings.HasPrefix(accept, tc.expectAccept), "unexpected Accept header %q", accept)
+			case <-time.After(15 * time.Second):
+				require.FailNow(t, "target wasn't scraped")
+			}
+		})
+	}
+}
+
+func TestJitterSeed(t *testing.T) {
+	args := DefaultArguments
+	cfg, err := getPromConfig("job", args)
+	require.NoError(t, err)
+	require.True(t, cfg.GlobalConfig.ExternalLabels.IsEmpty())
+
+	args.JitterSeed = "agent-1"
+	cfg, err = getPromConfig("job", args)
+	require.NoError(t, err)
+	require.Equal(t, labels.FromStrings(jitterSeedLabel, "agent-1"), cfg.GlobalConfig.ExternalLabels)
+}
+
+func TestTargetAuth(t *testing.T) {
+	args := DefaultArguments
+	args.HTTPClientConfig.BearerToken = "component-token"
+	args.Targets = []discovery.Target{
+		{"__address__": "a:80"},
+		{"__address__": "b:80", bearerTokenFileLabel: "/tokens/b"},
+		{"__address__": "c:80", bearerTokenFileLabel: "/tokens/b"},
+		{"__address__": "d:80", tlsCertFileLabel: "/certs/d.crt", tlsKeyFileLabel: "/certs/d.key"},
+	}
+
+	var (
+		tokenPool = poolName("job", args.Targets[1])
+		tlsPool   = poolName("job", args.Targets[3])
+	)
+
+	cfg, err := getPromConfig("job", args)
+	require.NoError(t, err)
+	configs := map[string]*config.ScrapeConfig{}
+	for _, sc := range cfg.ScrapeConfigs {
+		configs[sc.JobName] = sc
+	}
+	require.Len(t, configs, 3)
+
+	require.Equal(t, config_util.Secret("component-token"), configs["job"].HTTPClientConfig.BearerToken)
+
+	require.Empty(t, configs[tokenPool].HTTPClientConfig.BearerToken)
+	require.Equal(t, "/tokens/b", configs[tokenPool].HTTPClientConfig.Authorization.CredentialsFile)
+
+	require.Equal(t, config_util.Secret("component-token"), configs[tlsPool].HTTPClientConfig.BearerToken)
+	require.Equal(t, "/certs/d.crt", configs[tlsPool].HTTPClientConfig.TLSConfig.CertFile)
+	require.Equal(t, "/certs/d.key", configs[tlsPool].HTTPClientConfig.TLSConfig.KeyFile)
+
+	// Targets are passed to the scrape pool for their authentication settings,
+	// and scrape pools without owned targets get an empty target group.
+	var c Component
+	groups := c.componentTargetsToProm("job", args.Targets, args.Targets[:2])
+	require.Len(t, groups, 3)
+	require.Len(t, groups["job"][0].Targets, 1)
+	require.Len(t, groups[tokenPool][0].Targets, 1)
+	require.Equal(t, model.LabelValue("job"), groups[tokenPool][0].Labels[model.JobLabel])
+	require.Empty(t, groups[tlsPool][0].Targets)
+}
+
+func TestTargetLimits(t *testing.T) {
+	args := DefaultArguments
+	args.SampleLimit = 1000
+	args.LabelLimit = 30
+	args.Targets = []discovery.Target{
+		{"__address__": "a:80"},
+		{"__address__": "b:80", sampleLimitLabel: "50000", bodySizeLimitLabel: "10MB"},
+		{"__address__": "c:80", labelLimitLabel: "0"},
+	}
+
+	var (
+		bigPool       = poolName("job", args.Targets[1])
+		unlimitedPool = poolName("job", args.Targets[2])
+	)
+
+	cfg, err := getPromConfig("job", args)
+	require.NoError(t, err)
+	configs := map[string]*config.ScrapeConfig{}
+	for _, sc := range cfg.ScrapeConfigs {
+		configs[sc.JobName] = sc
+	}
+	require.Len(t, configs, 3)
+
+	require.Equal(t, uint(1000), configs["job"].SampleLimit)
+	require.Equal(t, uint(30), configs["job"].LabelLimit)
+
+	require.Equal(t, uint(50000), configs[bigPool].SampleLimit)
+	require.Equal(t, 10*units.MiB, configs[bigPool].BodySizeLimit)
+	require.Equal(t, uint(30), configs[bigPool].LabelLimit)
+
+	require.Equal(t, uint(1000), configs[unlimitedPool].SampleLimit)
+	require.Equal(t, uint(0), configs[unlimitedPool].LabelLimit)
+
+	args.Targets = append(args.Targets, discovery.Target{"__address__": "d:80", sampleLimitLabel: "lots"})
+	_, err = getPromConfig("job", args)
+	require.ErrorContains(t, err, `target d:80: invalid __sample_limit__ label "lots"`)
+}
+
+func TestInvalidTargetLimits(t *testing.T) {
+	reg := prometheus_client.NewRegistry()
+	opts := component.Options{
+		ID:            "prometheus.scrape.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    reg,
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	args := DefaultArguments
+	args.Targets = []discovery.Target{
+		{"__address__": "a:80"},
+		{"__address__": "b:80", sampleLimitLabel: "lots"},
+		{"__address__": "c:80", bodySizeLimitLabel: "big"},
+	}
+
+	// Targets with invalid limits are dropped instead of failing the update.
+	c, err := New(opts, args)
+	require.NoError(t, err)
+	require.Equal(t, []discovery.Target{{"__address__": "a:80"}}, c.args.Targets)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP agent_prometheus_scrape_targets_invalid_limits Number of targets which aren't scraped because their labels set invalid limits
+		# TYPE agent_prometheus_scrape_targets_invalid_limits gauge
+		agent_prometheus_scrape_targets_invalid_limits 2
+	`), "agent_prometheus_scrape_targets_invalid_limits"))
+
+	args.Targets = args.Targets[:1]
+	require.NoError(t, c.Update(args))
+	require.Equal(t, float64(0), testutil.ToFloat64(c.invalidGauge))
+}
+
+func TestExceededLimit(t *testing.T) {
+	limit, ok := exceededLimit("sample limit exceeded")
+	require.True(t, ok)
+	require.Equal(t, "sample_limit", limit)
+
+	limit, ok = exceededLimit("label_value_length_limit exceeded (metric: up, label name: a, value: \"b\", length: 1, limit: 0)")
+	require.True(t, ok)
+	require.Equal(t, "label_value_length_limit", limit)
+
+	_, ok = exceededLimit("server returned HTTP status 500 Internal Server Error")
+	require.False(t, ok)
+}
+
 func TestForwardingToAppendable(t *testing.T) {
 	opts := component.Options{
-		Logger:     util.TestFlowLogger(t),
-		Registerer: prometheus_client.NewRegistry(),
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus_client.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
 	}
 
 	nilReceivers := []storage.Appendable{nil, nil}
@@ -111,3 +366,249 @@ func TestForwardingToAppendable(t *testing.T) {
 	require.Len(t, receivedSamples, 1)
 	require.Equal(t, receivedSamples, sample)
 }
+
+func TestOwnedTargets(t *testing.T) {
+	var tgs []discovery.Target
+	for i := 0; i < 100; i++ {
+		tgs = append(tgs, discovery.Target{"__address__": fmt.Sprintf("host-%d:9090", i)})
+	}
+
+	var (
+		nodeA = &fakeCluster{self: "a", peers: []string{"a", "b"}}
+		nodeB = &fakeCluster{self: "b", peers: []string{"a", "b"}}
+	)
+
+	ownedA := (&Component{opts: component.Options{Logger: util.TestFlowLogger(t), Cluster: nodeA}}).ownedTargets(tgs)
+	ownedB := (&Component{opts: component.Options{Logger: util.TestFlowLogger(t), Cluster: nodeB}}).ownedTargets(tgs)
+
+	// Every target must be owned by exactly one of the nodes.
+	require.Equal(t, len(tgs), len(ownedA)+len(ownedB))
+	require.NotEmpty(t, ownedA)
+	require.NotEmpty(t, ownedB)
+
+	seen := make(map[string]struct{})
+	for _, tg := range append(ownedA, ownedB...) {
+		seen[tg["__address__"]] = struct{}{}
+	}
+	require.Len(t, seen, len(tgs))
+
+	t.Run("all targets are kept when owners are unknown", func(t *testing.T) {
+		c := &Component{opts: component.Options{
+			Logger:  util.TestFlowLogger(t),
+			Cluster: &fakeCluster{self: "a"},
+		}}
+		require.Equal(t, tgs, c.ownedTargets(tgs))
+	})
+}
+
+// fakeCluster implements cluster.Node, assigning keys to peers by modulo.
+type fakeCluster struct {
+	self  string
+	peers []string
+}
+
+func (fc *fakeCluster) Lookup(key shard.Key, _ int, _ shard.Op) ([]peer.Peer, error) {
+	if len(fc.peers) == 0 {
+		return nil, fmt.Errorf("no peers")
+	}
+	name := fc.peers[int(uint64(key)%uint64(len(fc.peers)))]
+	return []peer.Peer{{Name: name, Self: name == fc.self}}, nil
+}
+
+func (fc *fakeCluster) Observe(cluster.Observer) {}
+
+func (fc *fakeCluster) Peers() []peer.Peer {
+	res := make([]peer.Peer, 0, len(fc.peers))
+	for _, name := range fc.peers {
+		res = append(res, peer.Peer{Name: name, Self: name == fc.self})
+	}
+	return res
+}
+
+func TestTargetStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "metric_a 1")
+		fmt.Fprintln(w, "metric_b 2")
+	}))
+	defer srv.Close()
+
+	exports := make(chan Exports, 10)
+	opts := component.Options{
+		ID:         "prometheus.scrape.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prometheus_client.NewRegistry(),
+		OnStateChange: func(e component.Exports) {
+			select {
+			case exports <- e.(Exports):
+			default:
+			}
+		},
+	}
+
+	args := DefaultArguments
+	args.Targets = []discovery.Target{{"__address__": strings.TrimPrefix(srv.URL, "http://")}}
+	args.ScrapeInterval = 100 * time.Millisecond
+	args.ScrapeTimeout = 50 * time.Millisecond
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+	require.Empty(t, (<-exports).Targets)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	// The scrape manager only applies new targets every 5 seconds, so the
+	// first scrape takes a while.
+	var status TargetStatus
+	require.Eventually(t, func() bool {
+		select {
+		case e := <-exports:
+			if len(e.Targets) == 1 && !e.Targets[0].LastScrape.IsZero() {
+				status = e.Targets[0]
+				return true
+			}
+		default:
+		}
+		return false
+	}, 15*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, "up", status.Health)
+	require.Equal(t, srv.URL+"/metrics", status.URL)
+	require.Equal(t, 2, status.SamplesScraped)
+	require.False(t, status.Stale)
+
+	// The same status is available from the component's HTTP handler.
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/targets", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var fromHandler []TargetStatus
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&fromHandler))
+	require.Len(t, fromHandler, 1)
+	require.Equal(t, 2, fromHandler[0].SamplesScraped)
+}
+
+func TestNativeHistograms(t *testing.T) {
+	reg := prometheus_client.NewRegistry()
+	hist := prometheus_client.NewHistogram(prometheus_client.HistogramOpts{
+		Name:                        "test_native_histogram",
+		Help:                        "A native histogram.",
+		NativeHistogramBucketFactor: 1.1,
+	})
+	reg.MustRegister(hist)
+	hist.Observe(1)
+	hist.Observe(5)
+
+	srv := httptest.NewServer(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	defer srv.Close()
+
+	histograms := make(chan *histogram.Histogram, 10)
+	receiver := prometheus.NewInterceptor(nil, prometheus.WithAppendHistogram(func(ref storage.SeriesRef, l labels.Labels, _ int64, h *histogram.Histogram, _ *histogram.FloatHistogram, _ storage.Appender) (storage.SeriesRef, error) {
+		if l.Get("__name__") == "test_native_histogram" && h != nil {
+			select {
+			case histograms <- h:
+			default:
+			}
+		}
+		return ref, nil
+	}), prometheus.WithAppendHook(func(ref storage.SeriesRef, _ labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+		return ref, nil
+	}), prometheus.WithMetadataHook(func(ref storage.SeriesRef, _ labels.Labels, _ metadata.Metadata, _ storage.Appender) (storage.SeriesRef, error) {
+		return ref, nil
+	}))
+
+	opts := component.Options{
+		ID:            "prometheus.scrape.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus_client.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	args := DefaultArguments
+	args.Targets = []discovery.Target{{"__address__": strings.TrimPrefix(srv.URL, "http://")}}
+	args.ForwardTo = []storage.Appendable{receiver}
+	args.ScrapeInterval = 100 * time.Millisecond
+	args.ScrapeTimeout = 50 * time.Millisecond
+	args.EnableProtobufNegotiation = true
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	// The scrape manager only applies new targets every 5 seconds, so the
+	// first scrape takes a while.
+	select {
+	case h := <-histograms:
+		require.Equal(t, uint64(2), h.Count)
+		require.Equal(t, 6.0, h.Sum)
+	case <-time.After(15 * time.Second):
+		require.FailNow(t, "native histogram wasn't scraped")
+	}
+}
+
+func TestScrapeBudget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "metric_a 1")
+		fmt.Fprintln(w, "metric_b 2")
+	}))
+	defer srv.Close()
+
+	// The downstream component is too slow to receive the samples of a scrape
+	// within the budget.
+	slow := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+		if l.Get(model.MetricNameLabel) == "metric_a" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return ref, nil
+	}))
+
+	exports := make(chan Exports, 10)
+	reg := prometheus_client.NewRegistry()
+	opts := component.Options{
+		ID:         "prometheus.scrape.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: reg,
+		OnStateChange: func(e component.Exports) {
+			select {
+			case exports <- e.(Exports):
+			default:
+			}
+		},
+	}
+
+	args := DefaultArguments
+	args.Targets = []discovery.Target{{"__address__": strings.TrimPrefix(srv.URL, "http://")}}
+	args.ForwardTo = []storage.Appendable{slow}
+	args.ScrapeInterval = 500 * time.Millisecond
+	args.ScrapeTimeout = 50 * time.Millisecond
+	args.ScrapeBudget = 60 * time.Millisecond
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	// The scrape which exceeds the budget is reported as failed.
+	var status TargetStatus
+	require.Eventually(t, func() bool {
+		select {
+		case e := <-exports:
+			if len(e.Targets) == 1 && !e.Targets[0].LastScrape.IsZero() {
+				status = e.Targets[0]
+				return true
+			}
+		default:
+		}
+		return false
+	}, 15*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, "down", status.Health)
+	require.Contains(t, status.LastError, prometheus.ErrDeadlineExceeded.Error())
+	require.GreaterOrEqual(t, testutil.ToFloat64(c.budget.exceeded), 1.0)
+}
@@ -0,0 +1,162 @@
+package scrape
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// Exports holds values which are exported by the prometheus.scrape
+// component.
+type Exports struct {
+	// Targets holds the status of the latest scrape of every target. Targets
+	// is refreshed once per scrape interval.
+	Targets []TargetStatus `river:"targets,attr"`
+}
+
+// samplesScrapedMetric is the name of the report series which the scrape
+// loop writes the number of samples exposed by a target to.
+const samplesScrapedMetric = "scrape_samples_scraped"
+
+// sampleCounts tracks the number of samples scraped from each target by
+// watching the report series written by the scrape loop.
+type sampleCounts struct {
+	mut    sync.RWMutex
+	counts map[uint64]int // Hash of target labels -> samples scraped.
+}
+
+func newSampleCounts() *sampleCounts {
+	return &sampleCounts{counts: make(map[uint64]int)}
+}
+
+// Interceptor returns a storage.Appendable which records sample counts before
+// forwarding all data to next.
+func (sc *sampleCounts) Interceptor(next storage.Appendable) storage.Appendable {
+	return prometheus.NewInterceptor(next, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
+		if l.Get(labels.MetricName) == samplesScrapedMetric && !value.IsStaleNaN(v) {
+			// Report series are labeled with the target labels and the metric
+			// name, so the target is found by removing the metric name again.
+			targetLabels := labels.NewBuilder(l).Del(labels.MetricName).Labels(labels.EmptyLabels())
+
+			sc.mut.Lock()
+			sc.counts[targetLabels.Hash()] = int(v)
+			sc.mut.Unlock()
+		}
+		return next.Append(ref, l, t, v)
+	}))
+}
+
+// Get returns the number of samples scraped from the target with the given
+// labels.
+func (sc *sampleCounts) Get(targetLabels labels.Labels) int {
+	sc.mut.RLock()
+	defer sc.mut.RUnlock()
+	return sc.counts[targetLabels.Hash()]
+}
+
+// Retain removes counts for all targets which aren't in keep.
+func (sc *sampleCounts) Retain(keep map[uint64]struct{}) {
+	sc.mut.Lock()
+	defer sc.mut.Unlock()
+
+	for hash := range sc.counts {
+		if _, ok := keep[hash]; !ok {
+			delete(sc.counts, hash)
+		}
+	}
+}
+
+// targetStatus returns the status of every active target, sorted by job and
+// URL.
+func (c *Component) targetStatus() []TargetStatus {
+	var (
+		now      = time.Now()
+		interval = c.scrapeInterval()
+		active   = make(map[uint64]struct{})
+
+		res = []TargetStatus{}
+	)
+
+	// Targets of the job may be split across multiple scrape pools, so the job
+	// is read from the labels of the target rather than the scrape pool name.
+	for _, targets := range c.scraper.TargetsActive() {
+		for _, st := range targets {
+			if st == nil {
+				continue
+			}
+			lset := st.Labels()
+			active[lset.Hash()] = struct{}{}
+
+			var lastError string
+			if err := st.LastError(); err != nil {
+				lastError = err.Error()
+			}
+			lastScrape := st.LastScrape()
+
+			res = append(res, TargetStatus{
+				JobName:            lset.Get(model.JobLabel),
+				URL:                st.URL().String(),
+				Health:             string(st.Health()),
+				Labels:             lset.Map(),
+				LastError:          lastError,
+				LastScrape:         lastScrape,
+				LastScrapeDuration: st.LastScrapeDuration(),
+				SamplesScraped:     c.samples.Get(lset),
+				Stale:              !lastScrape.IsZero() && now.Sub(lastScrape) > 2*interval,
+			})
+		}
+	}
+	c.samples.Retain(active)
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].JobName != res[j].JobName {
+			return res[i].JobName < res[j].JobName
+		}
+		return res[i].URL < res[j].URL
+	})
+	return res
+}
+
+// exportTargetStatus exports the current status of every target and updates
+// the number of targets exceeding a limit.
+func (c *Component) exportTargetStatus() {
+	targets := c.targetStatus()
+
+	exceeding := make(map[string]int, len(limitErrors))
+	for _, t := range targets {
+		if limit, ok := exceededLimit(t.LastError); ok {
+			exceeding[limit]++
+		}
+	}
+	for _, le := range limitErrors {
+		c.limitsGauge.WithLabelValues(le.limit).Set(float64(exceeding[le.limit]))
+	}
+
+	c.opts.OnStateChange(Exports{Targets: targets})
+}
+
+func (c *Component) scrapeInterval() time.Duration {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.args.ScrapeInterval
+}
+
+// Handler implements component.HTTPComponent. The /targets endpoint returns
+// the current status of every target as JSON, similar to the targets page of
+// Prometheus.
+func (c *Component) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/targets", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.targetStatus())
+	})
+	return mux
+}
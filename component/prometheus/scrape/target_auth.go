@@ -0,0 +1,62 @@
+package scrape
+
+import (
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/common/config"
+)
+
+// Labels of targets which override the authentication settings of the
+// component when scraping the target. Like other labels prefixed with a
+// double underscore, they're removed before scraping.
+const (
+	bearerTokenFileLabel = "__bearer_token_file__"
+	tlsCAFileLabel       = "__tls_ca_file__"
+	tlsCertFileLabel     = "__tls_cert_file__"
+	tlsKeyFileLabel      = "__tls_key_file__"
+)
+
+// targetAuth holds the target-level authentication settings of a target.
+//
+// The scrape manager uses a single HTTP client for all targets of a scrape
+// pool, so targets are scraped by a separate scrape pool for every distinct
+// set of target-level authentication settings.
+type targetAuth struct {
+	BearerTokenFile string
+	CAFile          string
+	CertFile        string
+	KeyFile         string
+}
+
+func getTargetAuth(tg discovery.Target) targetAuth {
+	return targetAuth{
+		BearerTokenFile: tg[bearerTokenFileLabel],
+		CAFile:          tg[tlsCAFileLabel],
+		CertFile:        tg[tlsCertFileLabel],
+		KeyFile:         tg[tlsKeyFileLabel],
+	}
+}
+
+// apply overrides the settings of cfg with the settings of a. A bearer token
+// file replaces any other authentication method of cfg, while the TLS files
+// replace their counterparts in cfg.
+func (a targetAuth) apply(cfg *config.HTTPClientConfig) {
+	if a.BearerTokenFile != "" {
+		cfg.BasicAuth = nil
+		cfg.OAuth2 = nil
+		cfg.BearerToken = ""
+		cfg.BearerTokenFile = ""
+		cfg.Authorization = &config.Authorization{
+			Type:            "Bearer",
+			CredentialsFile: a.BearerTokenFile,
+		}
+	}
+	if a.CAFile != "" {
+		cfg.TLSConfig.CAFile = a.CAFile
+	}
+	if a.CertFile != "" {
+		cfg.TLSConfig.CertFile = a.CertFile
+	}
+	if a.KeyFile != "" {
+		cfg.TLSConfig.KeyFile = a.KeyFile
+	}
+}
@@ -0,0 +1,153 @@
+package scrape
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/config"
+)
+
+// Labels of targets which override the limits of the component when scraping
+// the target. A value of 0 disables the limit for the target. Like other
+// labels prefixed with a double underscore, they're removed before scraping.
+const (
+	bodySizeLimitLabel         = "__body_size_limit__"
+	sampleLimitLabel           = "__sample_limit__"
+	labelLimitLabel            = "__label_limit__"
+	labelNameLengthLimitLabel  = "__label_name_length_limit__"
+	labelValueLengthLimitLabel = "__label_value_length_limit__"
+)
+
+// targetLimits holds the target-level limits of a target as they're set in
+// its labels. Empty fields don't override the limits of the component.
+//
+// Like target-level authentication settings, limits are set per scrape pool,
+// so targets are scraped by a separate scrape pool for every distinct set of
+// target-level limits.
+type targetLimits struct {
+	BodySizeLimit         string
+	SampleLimit           string
+	LabelLimit            string
+	LabelNameLengthLimit  string
+	LabelValueLengthLimit string
+}
+
+func getTargetLimits(tg discovery.Target) targetLimits {
+	return targetLimits{
+		BodySizeLimit:         tg[bodySizeLimitLabel],
+		SampleLimit:           tg[sampleLimitLabel],
+		LabelLimit:            tg[labelLimitLabel],
+		LabelNameLengthLimit:  tg[labelNameLengthLimitLabel],
+		LabelValueLengthLimit: tg[labelValueLengthLimitLabel],
+	}
+}
+
+// apply overrides the limits of sc with the limits of l. An error is returned
+// if any limit of l is invalid.
+func (l targetLimits) apply(sc *config.ScrapeConfig) error {
+	if l.BodySizeLimit != "" {
+		v, err := units.ParseBase2Bytes(l.BodySizeLimit)
+		if err != nil {
+			return fmt.Errorf("invalid %s label %q: %w", bodySizeLimitLabel, l.BodySizeLimit, err)
+		}
+		sc.BodySizeLimit = v
+	}
+
+	uintLimits := []struct {
+		label string
+		value string
+		dst   *uint
+	}{
+		{sampleLimitLabel, l.SampleLimit, &sc.SampleLimit},
+		{labelLimitLabel, l.LabelLimit, &sc.LabelLimit},
+		{labelNameLengthLimitLabel, l.LabelNameLengthLimit, &sc.LabelNameLengthLimit},
+		{labelValueLengthLimitLabel, l.LabelValueLengthLimit, &sc.LabelValueLengthLimit},
+	}
+	for _, limit := range uintLimits {
+		if limit.value == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(limit.value, 10, 0)
+		if err != nil {
+			return fmt.Errorf("invalid %s label %q: %w", limit.label, limit.value, err)
+		}
+		*limit.dst = uint(v)
+	}
+	return nil
+}
+
+// dropInvalidLimits returns the targets of tgs whose target-level limits are
+// valid. Targets with invalid limits are dropped with a warning, so that they
+// don't prevent the remaining targets from being scraped.
+func dropInvalidLimits(logger log.Logger, tgs []discovery.Target) []discovery.Target {
+	res := make([]discovery.Target, 0, len(tgs))
+	for _, tg := range tgs {
+		if err := getTargetLimits(tg).apply(&config.ScrapeConfig{}); err != nil {
+			level.Warn(logger).Log("msg", "dropping target with invalid limits", "target", tg[model.AddressLabel], "err", err)
+			continue
+		}
+		res = append(res, tg)
+	}
+	return res
+}
+
+// targetSettingsLabels are the labels of targets which override the settings
+// of the component.
+var targetSettingsLabels = []string{
+	bearerTokenFileLabel,
+	tlsCAFileLabel,
+	tlsCertFileLabel,
+	tlsKeyFileLabel,
+	bodySizeLimitLabel,
+	sampleLimitLabel,
+	labelLimitLabel,
+	labelNameLengthLimitLabel,
+	labelValueLengthLimitLabel,
+}
+
+// poolName returns the name of the scrape pool which scrapes tg for jobName.
+// Targets without target-level settings are scraped by the scrape pool named
+// after the job.
+func poolName(jobName string, tg discovery.Target) string {
+	settings := model.LabelSet{}
+	for _, name := range targetSettingsLabels {
+		if v := tg[name]; v != "" {
+			settings[model.LabelName(name)] = model.LabelValue(v)
+		}
+	}
+	if len(settings) == 0 {
+		return jobName
+	}
+	return jobName + "/" + settings.Fingerprint().String()
+}
+
+// Limits which may cause a scrape to fail, and the prefix of the error the
+// scrape fails with.
+var limitErrors = []struct {
+	limit  string
+	prefix string
+}{
+	{"body_size_limit", "body size limit exceeded"},
+	{"sample_limit", "sample limit exceeded"},
+	{"target_limit", "target_limit exceeded"},
+	{"label_limit", "label_limit exceeded"},
+	{"label_name_length_limit", "label_name_length_limit exceeded"},
+	{"label_value_length_limit", "label_value_length_limit exceeded"},
+}
+
+// exceededLimit returns the limit which caused a scrape to fail with
+// lastError, if any.
+func exceededLimit(lastError string) (string, bool) {
+	for _, le := range limitErrors {
+		if strings.HasPrefix(lastError, le.prefix) {
+			return le.limit, true
+		}
+	}
+	return "", false
+}
@@ -3,8 +3,13 @@ package component
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/flow/events"
+	"github.com/grafana/agent/pkg/flow/limits"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/regexp"
 	"github.com/prometheus/client_golang/prometheus"
@@ -67,6 +72,56 @@ type Options struct {
 	// HTTPPath is the base path that requests need in order to route to this component.
 	// Requests received by a component handler will have this already trimmed off.
 	HTTPPath string
+
+	// PeerHTTPPath is the base path other agents of the cluster use to send
+	// requests to this component. Requests to PeerHTTPPath are routed to the
+	// same handler as HTTPPath, but are only accepted from cluster peers, and
+	// are accepted regardless of ReadOnly. PeerHTTPPath is empty when the Flow
+	// controller doesn't serve such a route.
+	PeerHTTPPath string
+
+	// Cluster is the cluster of agents the process is a member of. Components
+	// may use Cluster to distribute work across agents. When clustering is
+	// disabled, Cluster only contains the local agent.
+	//
+	// Cluster may be nil in tests; components should treat a nil Cluster as a
+	// cluster containing only the local agent.
+	Cluster cluster.Node
+
+	// Limits tracks the components loaded by the Flow controller running the
+	// component. Components which load modules must pass a child of Limits
+	// to the Flow controllers of those modules. Limits may be nil, in which
+	// case no limits are enforced.
+	Limits *limits.Tracker
+
+	// ExportDebounce is the window used by the Flow controller running the
+	// component to coalesce export changes. Components which load modules
+	// should pass ExportDebounce to the Flow controllers of those modules.
+	ExportDebounce time.Duration
+
+	// MaxEvaluationDuration is the maximum time the Flow controller running the
+	// component allows for building or updating a component. Components which
+	// load modules should pass MaxEvaluationDuration to the Flow controllers of
+	// those modules.
+	MaxEvaluationDuration time.Duration
+
+	// Events is the bus where the component can publish significant
+	// operational events. Events published through it have the ID of the
+	// component as their source. Components which load modules should pass
+	// Events to the Flow controllers of those modules. Events may be nil, in
+	// which case published events are discarded.
+	Events *events.Bus
+
+	// ReadOnly is set when the Flow controller running the component refuses
+	// to load Exec components. Components which load modules must pass
+	// ReadOnly to the Flow controllers of those modules.
+	ReadOnly bool
+
+	// DryRun is set when the Flow controller running the component only
+	// checks the config, and doesn't build Listener components. Components
+	// which load modules must pass DryRun to the Flow controllers of those
+	// modules.
+	DryRun bool
 }
 
 // Registration describes a single component.
@@ -105,6 +160,17 @@ type Registration struct {
 	// with different fully-qualified names.
 	Singleton bool
 
+	// Exec marks components which can execute commands or arbitrary code on
+	// the host running the agent. Exec components can't be loaded by a Flow
+	// controller running in read-only mode.
+	Exec bool
+
+	// Listener marks components which accept connections on network sockets
+	// of their own when they're built or updated. Listener components aren't
+	// built by a Flow controller running a dry run, so that a dry run doesn't
+	// compete for the ports of a running agent.
+	Listener bool
+
 	// An example Arguments value that the registered component expects to
 	// receive as input. Components should provide the zero value of their
 	// Arguments type here.
@@ -190,3 +256,13 @@ func Get(name string) (Registration, bool) {
 	r, ok := registered[name]
 	return r, ok
 }
+
+// AllNames returns the sorted names of all registered components.
+func AllNames() []string {
+	names := make([]string, 0, len(registered))
+	for name := range registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
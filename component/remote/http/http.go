@@ -13,6 +13,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/component"
 	common_config "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/resolver"
 	"github.com/grafana/agent/pkg/build"
 	"github.com/grafana/agent/pkg/flow/rivertypes"
 	"github.com/grafana/agent/pkg/river"
@@ -40,6 +41,7 @@ type Arguments struct {
 	IsSecret      bool          `river:"is_secret,attr,optional"`
 
 	Client common_config.HTTPClientConfig `river:"client,block,optional"`
+	DNS    *resolver.Arguments            `river:"dns,block,optional"`
 }
 
 // DefaultArguments holds default settings for Arguments.
@@ -245,10 +247,19 @@ func (c *Component) Update(args component.Arguments) (err error) {
 	newArgs := args.(Arguments)
 	c.args = newArgs
 
+	// The HTTP client defaults replace the built-in user agent, but not the
+	// settings of the component.
+	clientOpts := append([]prom_config.HTTPClientOption{
+		prom_config.WithUserAgent(userAgent),
+	}, newArgs.Client.ClientOptions()...)
+	if newArgs.DNS != nil {
+		clientOpts = append(clientOpts, prom_config.WithDialContextFunc(resolver.New(*newArgs.DNS).DialContext))
+	}
+
 	cli, err := prom_config.NewClientFromConfig(
 		*newArgs.Client.Convert(),
 		c.opts.ID,
-		prom_config.WithUserAgent(userAgent),
+		clientOpts...,
 	)
 	if err != nil {
 		return err
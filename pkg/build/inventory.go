@@ -0,0 +1,140 @@
+package build
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// Inventory describes what is built into the running agent, so that agents
+// embedding a specific version of a dependency can be found across a fleet.
+type Inventory struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Branch    string `json:"branch"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+
+	// BuildTags holds the build tags the agent was built with.
+	BuildTags []string `json:"build_tags"`
+	// Components holds the names of the components which can be used.
+	Components []string `json:"components"`
+	// Exporters holds the embedded Prometheus exporters.
+	Exporters []Module `json:"exporters"`
+	// Collector holds the embedded OpenTelemetry Collector modules.
+	Collector []Module `json:"collector"`
+}
+
+// Module is a Go module built into the agent.
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+
+	// Replacement is set when the module is replaced by a fork.
+	Replacement *Module `json:"replacement,omitempty"`
+}
+
+// collectorPrefixes are the path prefixes of OpenTelemetry Collector modules.
+var collectorPrefixes = []string{
+	"go.opentelemetry.io/collector",
+	"github.com/open-telemetry/opentelemetry-collector-contrib/",
+}
+
+// GetInventory returns the inventory of the running agent. components are
+// the names of the components available in the agent.
+func GetInventory(components []string) Inventory {
+	bi, _ := debug.ReadBuildInfo()
+	return newInventory(bi, components)
+}
+
+func newInventory(bi *debug.BuildInfo, components []string) Inventory {
+	inv := Inventory{
+		Version:    Version,
+		Revision:   Revision,
+		Branch:     Branch,
+		BuildDate:  BuildDate,
+		GoVersion:  runtime.Version(),
+		BuildTags:  []string{},
+		Components: append([]string{}, components...),
+		Exporters:  []Module{},
+		Collector:  []Module{},
+	}
+	sort.Strings(inv.Components)
+	if bi == nil {
+		return inv
+	}
+
+	for _, s := range bi.Settings {
+		if s.Key == "-tags" && s.Value != "" {
+			inv.BuildTags = strings.Split(s.Value, ",")
+		}
+	}
+	sort.Strings(inv.BuildTags)
+
+	for _, dep := range bi.Deps {
+		switch {
+		case isCollectorModule(dep.Path):
+			inv.Collector = append(inv.Collector, newModule(dep))
+		case isExporterModule(dep.Path):
+			inv.Exporters = append(inv.Exporters, newModule(dep))
+		}
+	}
+	return inv
+}
+
+func newModule(m *debug.Module) Module {
+	res := Module{Path: m.Path, Version: m.Version}
+	if m.Replace != nil {
+		replacement := newModule(m.Replace)
+		res.Replacement = &replacement
+	}
+	return res
+}
+
+func isCollectorModule(modPath string) bool {
+	for _, prefix := range collectorPrefixes {
+		if strings.HasPrefix(modPath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExporterModule reports whether modPath is a Prometheus exporter, which by
+// convention have "exporter" in the last element of their path, such as
+// github.com/prometheus/node_exporter.
+func isExporterModule(modPath string) bool {
+	name := path.Base(modPath)
+	if isMajorVersion(name) {
+		name = path.Base(path.Dir(modPath))
+	}
+	return strings.Contains(name, "exporter")
+}
+
+// isMajorVersion reports whether elem is a major version suffix such as v2.
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	for _, r := range elem[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// InventoryHandler returns an http.Handler which writes the inventory of the
+// running agent as JSON.
+func InventoryHandler(components []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(GetInventory(components))
+	})
+}
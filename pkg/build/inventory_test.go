@@ -0,0 +1,68 @@
+package build
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInventory(t *testing.T) {
+	bi := &debug.BuildInfo{
+		Settings: []debug.BuildSetting{
+			{Key: "-tags", Value: "netgo,builtinassets,promtail_journal_enabled"},
+			{Key: "CGO_ENABLED", Value: "1"},
+		},
+		Deps: []*debug.Module{
+			{Path: "github.com/prometheus/node_exporter", Version: "v1.5.0"},
+			{Path: "github.com/davidmparrott/kafka_exporter/v2", Version: "v2.0.1"},
+			{
+				Path:    "github.com/prometheus/mysqld_exporter",
+				Version: "v0.14.0",
+				Replace: &debug.Module{Path: "github.com/grafana/mysqld_exporter", Version: "v0.12.2"},
+			},
+			{Path: "go.opentelemetry.io/collector/exporter/otlpexporter", Version: "v0.63.0"},
+			{Path: "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/jaegerreceiver", Version: "v0.63.0"},
+			{Path: "go.opentelemetry.io/otel/exporters/prometheus", Version: "v0.33.0"},
+			{Path: "github.com/prometheus/prometheus", Version: "v0.42.0"},
+		},
+	}
+
+	inv := newInventory(bi, []string{"prometheus.scrape", "loki.write"})
+	require.Equal(t, []string{"builtinassets", "netgo", "promtail_journal_enabled"}, inv.BuildTags)
+	require.Equal(t, []string{"loki.write", "prometheus.scrape"}, inv.Components)
+	require.Equal(t, []Module{
+		{Path: "github.com/prometheus/node_exporter", Version: "v1.5.0"},
+		{Path: "github.com/davidmparrott/kafka_exporter/v2", Version: "v2.0.1"},
+		{
+			Path:        "github.com/prometheus/mysqld_exporter",
+			Version:     "v0.14.0",
+			Replacement: &Module{Path: "github.com/grafana/mysqld_exporter", Version: "v0.12.2"},
+		},
+	}, inv.Exporters)
+	require.Equal(t, []Module{
+		{Path: "go.opentelemetry.io/collector/exporter/otlpexporter", Version: "v0.63.0"},
+		{Path: "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/jaegerreceiver", Version: "v0.63.0"},
+	}, inv.Collector)
+}
+
+func TestNewInventory_NoBuildInfo(t *testing.T) {
+	inv := newInventory(nil, nil)
+	require.Empty(t, inv.BuildTags)
+	require.NotNil(t, inv.Exporters)
+	require.NotEmpty(t, inv.GoVersion)
+}
+
+func TestInventoryHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	InventoryHandler([]string{"loki.write"}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v0/inventory", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var inv Inventory
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&inv))
+	require.Equal(t, []string{"loki.write"}, inv.Components)
+}
@@ -0,0 +1,239 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// stdlibModule is the name OSV entries use for the Go standard library.
+const stdlibModule = "stdlib"
+
+// SBOM is a software bill of materials of the running agent, listing the Go
+// modules it's built from.
+type SBOM struct {
+	GoVersion string   `json:"go_version"`
+	Main      Module   `json:"main"`
+	Modules   []Module `json:"modules"`
+}
+
+// GetSBOM returns the SBOM of the running agent.
+func GetSBOM() SBOM {
+	bi, _ := debug.ReadBuildInfo()
+	return newSBOM(bi)
+}
+
+func newSBOM(bi *debug.BuildInfo) SBOM {
+	sbom := SBOM{
+		GoVersion: runtime.Version(),
+		Modules:   []Module{},
+	}
+	if bi == nil {
+		return sbom
+	}
+
+	sbom.Main = newModule(&bi.Main)
+	if bi.GoVersion != "" {
+		sbom.GoVersion = bi.GoVersion
+	}
+	for _, dep := range bi.Deps {
+		sbom.Modules = append(sbom.Modules, newModule(dep))
+	}
+	return sbom
+}
+
+// Vulnerability is a vulnerability affecting a module of an SBOM.
+type Vulnerability struct {
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases,omitempty"`
+	Summary string   `json:"summary,omitempty"`
+
+	// Module and Version identify the affected module. When a module is
+	// replaced, the replacement is evaluated instead.
+	Module  string `json:"module"`
+	Version string `json:"version"`
+	// Fixed holds the versions which fix the vulnerability, if known.
+	Fixed []string `json:"fixed,omitempty"`
+}
+
+// osvEntry is the subset of the OSV format used to evaluate an SBOM. See
+// https://ossf.github.io/osv-schema/.
+type osvEntry struct {
+	ID       string        `json:"id"`
+	Aliases  []string      `json:"aliases"`
+	Summary  string        `json:"summary"`
+	Affected []osvAffected `json:"affected"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Ranges   []osvRange `json:"ranges"`
+	Versions []string   `json:"versions"`
+}
+
+type osvRange struct {
+	Type   string     `json:"type"`
+	Events []osvEvent `json:"events"`
+}
+
+type osvEvent struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// VulnerabilityDB is a set of known vulnerabilities of Go modules.
+type VulnerabilityDB struct {
+	entries []osvEntry
+}
+
+// LoadVulnerabilityDB loads a vulnerability database from a file holding a
+// JSON array of OSV entries, such as entries of the Go vulnerability
+// database.
+func LoadVulnerabilityDB(path string) (*VulnerabilityDB, error) {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var db VulnerabilityDB
+	if err := json.Unmarshal(bb, &db.entries); err != nil {
+		return nil, fmt.Errorf("failed to decode vulnerability database %s: %w", path, err)
+	}
+	return &db, nil
+}
+
+// Evaluate returns the vulnerabilities of db which affect the modules of
+// sbom, including the Go standard library.
+func (db *VulnerabilityDB) Evaluate(sbom SBOM) []Vulnerability {
+	modules := []Module{{Path: stdlibModule, Version: sbom.GoVersion}}
+	for _, m := range sbom.Modules {
+		if m.Replacement != nil {
+			m = *m.Replacement
+		}
+		modules = append(modules, m)
+	}
+
+	res := []Vulnerability{}
+	for _, m := range modules {
+		version := canonicalVersion(m.Version)
+		if version == "" {
+			continue
+		}
+
+		for _, entry := range db.entries {
+			for _, affected := range entry.Affected {
+				if affected.Package.Name != m.Path || !affected.affects(version) {
+					continue
+				}
+				res = append(res, Vulnerability{
+					ID:      entry.ID,
+					Aliases: entry.Aliases,
+					Summary: entry.Summary,
+					Module:  m.Path,
+					Version: m.Version,
+					Fixed:   affected.fixedVersions(),
+				})
+			}
+		}
+	}
+	return res
+}
+
+// affects reports whether the canonical version is affected.
+func (a osvAffected) affects(version string) bool {
+	for _, v := range a.Versions {
+		if canonicalVersion(v) == version {
+			return true
+		}
+	}
+
+	for _, r := range a.Ranges {
+		if r.Type != "SEMVER" {
+			continue
+		}
+
+		// Events are ordered by version, so the last event at or before
+		// version decides whether version is affected.
+		var affected bool
+		for _, e := range r.Events {
+			switch {
+			case e.Introduced == "0":
+				affected = true
+			case e.Introduced != "" && semver.Compare(version, canonicalVersion(e.Introduced)) >= 0:
+				affected = true
+			case e.Fixed != "" && semver.Compare(version, canonicalVersion(e.Fixed)) >= 0:
+				affected = false
+			case e.LastAffected != "" && semver.Compare(version, canonicalVersion(e.LastAffected)) > 0:
+				affected = false
+			}
+		}
+		if affected {
+			return true
+		}
+	}
+	return false
+}
+
+func (a osvAffected) fixedVersions() []string {
+	var res []string
+	for _, r := range a.Ranges {
+		for _, e := range r.Events {
+			if e.Fixed != "" {
+				res = append(res, e.Fixed)
+			}
+		}
+	}
+	return res
+}
+
+// canonicalVersion converts module versions, OSV versions which lack the "v"
+// prefix, and Go versions such as go1.20.3 to semantic versions which can be
+// compared. It returns an empty string for invalid versions such as (devel).
+func canonicalVersion(v string) string {
+	v = strings.TrimPrefix(v, "go")
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	if !semver.IsValid(v) {
+		return ""
+	}
+	return v
+}
+
+// SBOMHandler returns an http.Handler which writes the SBOM of the running
+// agent as JSON. If vulnDBPath is set, the SBOM is evaluated against the
+// vulnerability database at that path, which is read on every request.
+func SBOMHandler(vulnDBPath string) http.Handler {
+	type response struct {
+		SBOM
+		Vulnerabilities *[]Vulnerability `json:"vulnerabilities,omitempty"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		res := response{SBOM: GetSBOM()}
+		if vulnDBPath != "" {
+			db, err := LoadVulnerabilityDB(vulnDBPath)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			vulns := db.Evaluate(res.SBOM)
+			res.Vulnerabilities = &vulns
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(res)
+	})
+}
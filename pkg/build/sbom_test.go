@@ -0,0 +1,127 @@
+package build
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testVulnerabilityDB = `[
+	{
+		"id": "GO-2023-0001",
+		"aliases": ["CVE-2023-0001"],
+		"summary": "Vulnerability in golang.org/x/net",
+		"affected": [{
+			"package": {"name": "golang.org/x/net", "ecosystem": "Go"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.7.0"}]}]
+		}]
+	},
+	{
+		"id": "GO-2023-0002",
+		"summary": "Fixed vulnerability in golang.org/x/text",
+		"affected": [{
+			"package": {"name": "golang.org/x/text", "ecosystem": "Go"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "0.3.0"}, {"fixed": "0.3.8"}]}]
+		}]
+	},
+	{
+		"id": "GO-2023-0003",
+		"summary": "Vulnerability in a fork",
+		"affected": [{
+			"package": {"name": "github.com/grafana/mysqld_exporter", "ecosystem": "Go"},
+			"versions": ["0.12.2"]
+		}]
+	},
+	{
+		"id": "GO-2023-0004",
+		"summary": "Vulnerability in the standard library",
+		"affected": [{
+			"package": {"name": "stdlib", "ecosystem": "Go"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "1.20.0"}, {"last_affected": "1.20.2"}]}]
+		}]
+	}
+]`
+
+func TestVulnerabilityDB_Evaluate(t *testing.T) {
+	sbom := newSBOM(&debug.BuildInfo{
+		GoVersion: "go1.20.1",
+		Main:      debug.Module{Path: "github.com/grafana/agent", Version: "(devel)"},
+		Deps: []*debug.Module{
+			{Path: "golang.org/x/net", Version: "v0.5.0"},
+			{Path: "golang.org/x/text", Version: "v0.8.0"},
+			{
+				Path:    "github.com/prometheus/mysqld_exporter",
+				Version: "v0.14.0",
+				Replace: &debug.Module{Path: "github.com/grafana/mysqld_exporter", Version: "v0.12.2"},
+			},
+		},
+	})
+	require.Equal(t, "github.com/grafana/agent", sbom.Main.Path)
+	require.Len(t, sbom.Modules, 3)
+
+	path := filepath.Join(t.TempDir(), "vulndb.json")
+	require.NoError(t, os.WriteFile(path, []byte(testVulnerabilityDB), 0644))
+	db, err := LoadVulnerabilityDB(path)
+	require.NoError(t, err)
+
+	require.Equal(t, []Vulnerability{
+		{
+			ID:      "GO-2023-0004",
+			Summary: "Vulnerability in the standard library",
+			Module:  "stdlib",
+			Version: "go1.20.1",
+		},
+		{
+			ID:      "GO-2023-0001",
+			Aliases: []string{"CVE-2023-0001"},
+			Summary: "Vulnerability in golang.org/x/net",
+			Module:  "golang.org/x/net",
+			Version: "v0.5.0",
+			Fixed:   []string{"0.7.0"},
+		},
+		{
+			ID:      "GO-2023-0003",
+			Summary: "Vulnerability in a fork",
+			Module:  "github.com/grafana/mysqld_exporter",
+			Version: "v0.12.2",
+		},
+	}, db.Evaluate(sbom))
+}
+
+func TestLoadVulnerabilityDB_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vulndb.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+	_, err := LoadVulnerabilityDB(path)
+	require.Error(t, err)
+}
+
+func TestSBOMHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vulndb.json")
+	require.NoError(t, os.WriteFile(path, []byte(testVulnerabilityDB), 0644))
+
+	rec := httptest.NewRecorder()
+	SBOMHandler(path).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v0/sbom", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	require.Contains(t, res, "modules")
+	require.Contains(t, res, "vulnerabilities")
+
+	rec = httptest.NewRecorder()
+	SBOMHandler("").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v0/sbom", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	res = nil
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	require.NotContains(t, res, "vulnerabilities")
+
+	rec = httptest.NewRecorder()
+	SBOMHandler(filepath.Join(t.TempDir(), "missing.json")).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v0/sbom", nil))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+}
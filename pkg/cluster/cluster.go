@@ -1,19 +1,20 @@
 // Package cluster enables an agent-wide cluster mechanism which subsystems can
 // use to determine ownership of some key.
+//
+// Package cluster only defines the Node interface and a local Node so that
+// components can depend on it cheaply. The gossip subpackage implements a
+// Node which joins other agents.
 package cluster
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 
-	"github.com/rfratto/ckit"
 	"github.com/rfratto/ckit/peer"
 	"github.com/rfratto/ckit/shard"
 )
 
-// NOTE(rfratto): pkg/cluster currently isn't wired in yet, but will be used
-// for the implementation of RFC-0003. Try to remember to remove this comment
-// once it gets used :)
-
 // Node is a read-only view of a cluster node.
 type Node interface {
 	// Lookup determines the set of replicationFactor owners for a given key.
@@ -27,12 +28,26 @@ type Node interface {
 
 	// Observe registers an Observer to receive notifications when the set of
 	// Peers for a Node changes.
-	Observe(ckit.Observer)
+	Observe(Observer)
 
 	// Peers returns the current set of peers for a Node.
 	Peers() []peer.Peer
 }
 
+// Observer is notified when the set of peers of a Node changes. It has the
+// same method set as ckit.Observer.
+type Observer interface {
+	// NotifyPeersChanged is invoked any time the set of peers changes. The
+	// Observer stops receiving notifications once it returns false.
+	NotifyPeersChanged(peers []peer.Peer) (reregister bool)
+}
+
+// FuncObserver implements Observer.
+type FuncObserver func(peers []peer.Peer) (reregister bool)
+
+// NotifyPeersChanged implements Observer.
+func (f FuncObserver) NotifyPeersChanged(peers []peer.Peer) (reregister bool) { return f(peers) }
+
 // NewLocalNode returns a Node which forms a single-node cluster and never
 // connects to other nodes.
 //
@@ -60,10 +75,51 @@ func (ln *localNode) Lookup(key shard.Key, replicationFactor int, op shard.Op) (
 	return []peer.Peer{ln.self}, nil
 }
 
-func (ln *localNode) Observe(ckit.Observer) {
+func (ln *localNode) Observe(Observer) {
 	// no-op: the cluster will never change for a local-only node.
 }
 
 func (ln *localNode) Peers() []peer.Peer {
 	return []peer.Peer{ln.self}
 }
+
+// IsPeerRequest reports whether r was sent from the host of one of the peers
+// of n. Peers are identified by the host of their address, which is resolved
+// if it isn't an IP address. IsPeerRequest returns false if n is nil.
+func IsPeerRequest(n Node, r *http.Request) bool {
+	if n == nil {
+		return false
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	remoteIP := net.ParseIP(host)
+	if remoteIP == nil {
+		return false
+	}
+
+	for _, p := range n.Peers() {
+		peerHost, _, err := net.SplitHostPort(p.Addr)
+		if err != nil {
+			continue
+		}
+		if ip := net.ParseIP(peerHost); ip != nil {
+			if ip.Equal(remoteIP) {
+				return true
+			}
+			continue
+		}
+
+		addrs, err := net.DefaultResolver.LookupIPAddr(r.Context(), peerHost)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if addr.IP.Equal(remoteIP) {
+				return true
+			}
+		}
+	}
+	return false
+}
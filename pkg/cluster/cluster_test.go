@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"net/http/httptest"
 	"testing"
 
 	"github.com/rfratto/ckit/peer"
@@ -52,3 +53,26 @@ func TestLocalNode_Peers(t *testing.T) {
 		require.Equal(t, expect, ln.Peers())
 	})
 }
+
+func TestIsPeerRequest(t *testing.T) {
+	ln := NewLocalNode("127.0.0.1:12345")
+
+	tt := []struct {
+		remoteAddr string
+		expect     bool
+	}{
+		{remoteAddr: "127.0.0.1:54321", expect: true},
+		{remoteAddr: "10.0.0.1:54321", expect: false},
+		{remoteAddr: "invalid", expect: false},
+	}
+
+	for _, tc := range tt {
+		req := httptest.NewRequest("POST", "/", nil)
+		req.RemoteAddr = tc.remoteAddr
+		require.Equal(t, tc.expect, IsPeerRequest(ln, req), tc.remoteAddr)
+	}
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.RemoteAddr = "127.0.0.1:54321"
+	require.False(t, IsPeerRequest(nil, req))
+}
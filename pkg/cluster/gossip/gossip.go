@@ -1,4 +1,6 @@
-package cluster
+// Package gossip implements a cluster.Node which discovers peers through
+// gRPC-based gossip.
+package gossip
 
 import (
 	"context"
@@ -7,8 +9,11 @@ import (
 	stdlog "log"
 	"net"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/dskit/flagext"
 	"github.com/hashicorp/go-discover"
 	"github.com/hashicorp/go-discover/provider/k8s"
@@ -101,7 +106,7 @@ func (c *GossipConfig) ApplyDefaults(defaultPort int) error {
 		if err != nil {
 			return fmt.Errorf("determining advertise address: %w", err)
 		}
-		c.AdvertiseAddr = fmt.Sprintf("%s:%d", addr.String(), defaultPort)
+		c.AdvertiseAddr = net.JoinHostPort(addr.String(), strconv.Itoa(defaultPort))
 	} else {
 		c.AdvertiseAddr = appendDefaultPort(c.AdvertiseAddr, defaultPort)
 	}
@@ -149,10 +154,14 @@ func appendDefaultPort(addr string, port int) string {
 		// No error means there was a port in the string
 		return addr
 	}
-	return fmt.Sprintf("%s:%d", addr, port)
+
+	// IPv6 addresses may be provided with or without brackets; remove them so
+	// JoinHostPort doesn't add a second pair.
+	host := strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	return net.JoinHostPort(host, strconv.Itoa(port))
 }
 
-// GossipNode is a Node which uses gRPC and gossip to discover peers.
+// GossipNode is a cluster.Node which uses gRPC and gossip to discover peers.
 type GossipNode struct {
 	// NOTE(rfratto): GossipNode is a *very* thin wrapper over ckit.Node, but it
 	// still abstracted out as its own type to have more agent-specific control
@@ -166,6 +175,8 @@ type GossipNode struct {
 	started atomic.Bool
 }
 
+var _ cluster.Node = (*GossipNode)(nil)
+
 // NewGossipNode creates an unstarted GossipNode. The GossipNode will register
 // itself as a gRPC service to srv. GossipConfig is expected to be valid and
 // have already had ApplyDefaults called on it.
@@ -234,7 +245,7 @@ func (n *GossipNode) Lookup(key shard.Key, numOwners int, op shard.Op) ([]peer.P
 //
 // Calls will have to filter events if they are only interested in a subset of
 // changes.
-func (n *GossipNode) Observe(o ckit.Observer) {
+func (n *GossipNode) Observe(o cluster.Observer) {
 	n.innerNode.Observe(o)
 }
 
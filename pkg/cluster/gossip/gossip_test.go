@@ -1,4 +1,4 @@
-package cluster
+package gossip
 
 import (
 	"fmt"
@@ -95,6 +95,17 @@ func TestConfig_ApplyDefaults(t *testing.T) {
 		require.Equal(t, fmt.Sprintf("foobar:%d", examplePort), gc.AdvertiseAddr)
 	})
 
+	t.Run("explicit IPv6 advertise address can use default port", func(t *testing.T) {
+		for _, addr := range []string{"::1", "[::1]"} {
+			gc := defaultConfig
+			gc.AdvertiseAddr = addr
+
+			err := gc.ApplyDefaults(examplePort)
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("[::1]:%d", examplePort), gc.AdvertiseAddr)
+		}
+	})
+
 	t.Run("join peers and discover peers can't both be set", func(t *testing.T) {
 		gc := defaultConfig
 		gc.JoinPeers = []string{"foobar:9999"}
@@ -131,6 +142,19 @@ func TestConfig_ApplyDefaults(t *testing.T) {
 		require.Equal(t, []string{fmt.Sprintf("foobar:%d", examplePort)}, []string(gc.JoinPeers))
 	})
 
+	t.Run("IPv6 peers can use default port", func(t *testing.T) {
+		gc := defaultConfig
+		gc.JoinPeers = []string{"fd00::1", "[fd00::2]", "[fd00::3]:9999"}
+
+		err := gc.ApplyDefaults(examplePort)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			fmt.Sprintf("[fd00::1]:%d", examplePort),
+			fmt.Sprintf("[fd00::2]:%d", examplePort),
+			"[fd00::3]:9999",
+		}, []string(gc.JoinPeers))
+	})
+
 	t.Run("discovered peers can use default port", func(t *testing.T) {
 		gc := defaultConfig
 		gc.DiscoverPeers = `provider=static addrs=fizzbuzz`
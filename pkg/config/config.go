@@ -40,6 +40,12 @@ var (
 		featExtraMetrics,
 		featAgentManagement,
 	}
+
+	// FeatureToggles holds runtime overrides for features. Only features which
+	// can be safely changed when the config file is reloaded are toggleable.
+	FeatureToggles = features.NewToggles([]features.Feature{
+		featExtraMetrics,
+	})
 )
 
 var (
@@ -92,6 +98,9 @@ type Config struct {
 	// Report enabled features options
 	EnableUsageReport bool     `yaml:"-"`
 	EnabledFeatures   []string `yaml:"-"`
+
+	// Features holds the state of all features, including runtime overrides.
+	Features []features.Info `yaml:"-"`
 }
 
 // UnmarshalYAML implements yaml.Unmarshaler.
@@ -450,6 +459,7 @@ func applyIntegrationValuesFromFlagset(fs *flag.FlagSet, args []string, path str
 	if err := fs.Parse(args); err != nil {
 		return fmt.Errorf("error parsing flags: %w", err)
 	}
+	FeatureToggles.Apply(fs)
 
 	// Complete unmarshaling integrations using the version from the flag. This
 	// MUST be called before ApplyDefaults.
@@ -491,6 +501,7 @@ func load(fs *flag.FlagSet, args []string, loader loaderFunc) (*Config, error) {
 	if err := fs.Parse(args); err != nil {
 		return nil, fmt.Errorf("error parsing flags: %w", err)
 	}
+	FeatureToggles.Apply(fs)
 
 	if printVersion {
 		fmt.Println(version.Print("agent"))
@@ -518,6 +529,7 @@ func load(fs *flag.FlagSet, args []string, loader loaderFunc) (*Config, error) {
 	}
 
 	cfg.AgentManagement.Enabled = features.Enabled(fs, featAgentManagement)
+	cfg.Features = features.List(fs, FeatureToggles)
 
 	if disableSupportBundles {
 		cfg.DisableSupportBundle = true
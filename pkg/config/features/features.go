@@ -140,6 +140,7 @@ type set struct {
 	validString string // Comma-delimited list of acceptable values
 
 	enabled map[Feature]struct{}
+	cli     map[Feature]struct{} // Features provided at the command line, set by Toggles.Apply
 }
 
 // Set implements flag.Value.
@@ -168,5 +169,6 @@ func (s *set) Set(in string) error {
 	}
 
 	s.enabled = m
+	s.cli = nil
 	return nil
 }
@@ -0,0 +1,148 @@
+package features
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Info describes the state of a registered feature.
+type Info struct {
+	Name Feature `json:"name"`
+	// Enabled is true if the feature is currently enabled, either through the
+	// command line or a runtime override.
+	Enabled bool `json:"enabled"`
+	// Toggleable is true if the feature can be changed at runtime.
+	Toggleable bool `json:"toggleable"`
+	// Overridden is true if the state of the feature was changed at runtime
+	// and differs from what was provided at the command line.
+	Overridden bool `json:"overridden"`
+}
+
+// Toggles holds runtime overrides for features. Overrides take precedence
+// over the features provided at the command line, and are applied to a
+// FlagSet with Apply. Toggles is safe for concurrent use.
+type Toggles struct {
+	toggleable map[Feature]struct{}
+
+	mut       sync.RWMutex
+	overrides map[Feature]bool
+}
+
+// NewToggles creates a new Toggles where only the features in toggleable may
+// be overridden.
+func NewToggles(toggleable []Feature) *Toggles {
+	t := &Toggles{
+		toggleable: make(map[Feature]struct{}, len(toggleable)),
+		overrides:  make(map[Feature]bool),
+	}
+	for _, f := range toggleable {
+		t.toggleable[normalize(f)] = struct{}{}
+	}
+	return t
+}
+
+// Toggleable returns true if f can be overridden at runtime.
+func (t *Toggles) Toggleable(f Feature) bool {
+	_, ok := t.toggleable[normalize(f)]
+	return ok
+}
+
+// Set overrides the state of f. Set returns an error if f isn't toggleable.
+func (t *Toggles) Set(f Feature, enabled bool) error {
+	f = normalize(f)
+	if !t.Toggleable(f) {
+		return fmt.Errorf("feature %q cannot be changed at runtime", f)
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	t.overrides[f] = enabled
+	return nil
+}
+
+// Override returns the runtime override for f, if one exists.
+func (t *Toggles) Override(f Feature) (enabled bool, ok bool) {
+	t.mut.RLock()
+	defer t.mut.RUnlock()
+	enabled, ok = t.overrides[normalize(f)]
+	return
+}
+
+// Clear removes any runtime override for f.
+func (t *Toggles) Clear(f Feature) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	delete(t.overrides, normalize(f))
+}
+
+// Apply applies overrides to the features registered in fs. Apply must be
+// called after fs is parsed, since parsing resets the set of enabled
+// features. Apply will panic if fs has not been passed to Register.
+func (t *Toggles) Apply(fs *flag.FlagSet) {
+	s := lookupSet(fs)
+
+	t.mut.RLock()
+	defer t.mut.RUnlock()
+
+	if s.enabled == nil {
+		s.enabled = make(map[Feature]struct{})
+	}
+	if s.cli == nil {
+		// Remember what was provided at the command line so overrides can be
+		// reported.
+		s.cli = make(map[Feature]struct{}, len(s.enabled))
+		for f := range s.enabled {
+			s.cli[f] = struct{}{}
+		}
+	}
+
+	for f, enabled := range t.overrides {
+		if _, valid := s.valid[f]; !valid {
+			continue
+		}
+		if enabled {
+			s.enabled[f] = struct{}{}
+		} else {
+			delete(s.enabled, f)
+		}
+	}
+}
+
+// List returns the state of all features registered in fs, sorted by name.
+// t may be nil if no runtime overrides are used. List will panic if fs has
+// not been passed to Register.
+func List(fs *flag.FlagSet, t *Toggles) []Info {
+	s := lookupSet(fs)
+
+	res := make([]Info, 0, len(s.valid))
+	for f := range s.valid {
+		_, enabled := s.enabled[f]
+
+		info := Info{Name: f, Enabled: enabled}
+		if t != nil {
+			info.Toggleable = t.Toggleable(f)
+			if override, ok := t.Override(f); ok && s.cli != nil {
+				_, cliEnabled := s.cli[f]
+				info.Overridden = override != cliEnabled
+			}
+		}
+		res = append(res, info)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+func lookupSet(fs *flag.FlagSet) *set {
+	f := fs.Lookup(setFlagName)
+	if f == nil {
+		panic("feature flag not registered to fs")
+	}
+	s, ok := f.Value.(*set)
+	if !ok {
+		panic("registered feature flag not appropriate type")
+	}
+	return s
+}
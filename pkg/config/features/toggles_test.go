@@ -0,0 +1,71 @@
+package features
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToggles(t *testing.T) {
+	var (
+		toggleFeature = Feature("toggle-feature")
+		staticFeature = Feature("static-feature")
+	)
+
+	newFlagSet := func(t *testing.T, args ...string) *flag.FlagSet {
+		fs := flag.NewFlagSet(t.Name(), flag.PanicOnError)
+		Register(fs, []Feature{toggleFeature, staticFeature})
+		require.NoError(t, fs.Parse(args))
+		return fs
+	}
+
+	t.Run("non-toggleable features can't be set", func(t *testing.T) {
+		toggles := NewToggles([]Feature{toggleFeature})
+		require.EqualError(t, toggles.Set(staticFeature, true), `feature "static-feature" cannot be changed at runtime`)
+	})
+
+	t.Run("overrides take precedence over the command line", func(t *testing.T) {
+		toggles := NewToggles([]Feature{toggleFeature})
+		require.NoError(t, toggles.Set("TOGGLE-FEATURE", true))
+
+		fs := newFlagSet(t)
+		toggles.Apply(fs)
+		require.True(t, Enabled(fs, toggleFeature))
+
+		require.NoError(t, toggles.Set(toggleFeature, false))
+		fs = newFlagSet(t, "--enable-features=toggle-feature,static-feature")
+		toggles.Apply(fs)
+		require.False(t, Enabled(fs, toggleFeature))
+		require.True(t, Enabled(fs, staticFeature))
+	})
+
+	t.Run("List reports overrides", func(t *testing.T) {
+		toggles := NewToggles([]Feature{toggleFeature})
+		require.NoError(t, toggles.Set(toggleFeature, true))
+
+		fs := newFlagSet(t, "--enable-features=static-feature")
+		toggles.Apply(fs)
+
+		require.Equal(t, []Info{
+			{Name: staticFeature, Enabled: true},
+			{Name: toggleFeature, Enabled: true, Toggleable: true, Overridden: true},
+		}, List(fs, toggles))
+
+		// Overrides which match the command line aren't reported as overridden.
+		fs = newFlagSet(t, "--enable-features=toggle-feature")
+		toggles.Apply(fs)
+		require.Equal(t, []Info{
+			{Name: staticFeature, Enabled: false},
+			{Name: toggleFeature, Enabled: true, Toggleable: true, Overridden: false},
+		}, List(fs, toggles))
+
+		toggles.Clear(toggleFeature)
+		fs = newFlagSet(t)
+		toggles.Apply(fs)
+		require.Equal(t, []Info{
+			{Name: staticFeature, Enabled: false},
+			{Name: toggleFeature, Enabled: false, Toggleable: true},
+		}, List(fs, toggles))
+	})
+}
@@ -0,0 +1,303 @@
+// Package crash writes crash reports which help diagnose crashes after the
+// fact.
+//
+// A crash report contains the reason for the report, build information, a
+// dump of all goroutines, and the most recent log lines. Reports are written
+// to a directory and can optionally be uploaded to a URL.
+//
+// Reports are written when a panic is recovered by Recover, and by Reporter.Run
+// when the memory usage of the process crosses a threshold, which often
+// precedes the process being killed for running out of memory.
+package crash
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/version"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const (
+	reportPrefix = "crash-"
+	reportExt    = ".txt"
+
+	// timeFormat is used in the names of reports. Its fixed width makes names
+	// sort by time.
+	timeFormat = "20060102T150405.000000000Z"
+
+	// uploadTimeout bounds how long uploading a report may take, since a
+	// panicking process should exit promptly.
+	uploadTimeout = 10 * time.Second
+)
+
+// Options configures a Reporter.
+type Options struct {
+	// Dir is the directory reports are written to.
+	Dir string
+
+	// MaxReports is the number of reports to keep in Dir. Older reports are
+	// deleted when a new report is written.
+	MaxReports int
+
+	// MemoryThreshold is the resident memory usage in bytes which triggers a
+	// report. 0 disables reports on high memory usage.
+	MemoryThreshold uint64
+
+	// UploadURL is a URL reports are sent to with a POST request. Empty
+	// disables uploading reports.
+	UploadURL string
+}
+
+// DefaultOptions holds the default settings for a Reporter.
+var DefaultOptions = Options{
+	MaxReports: 10,
+}
+
+// Reporter writes crash reports.
+type Reporter struct {
+	log  log.Logger
+	opts Options
+	logs *LogBuffer
+
+	// Overridden by tests.
+	readRSS func() (uint64, error)
+
+	mut sync.Mutex
+}
+
+// NewReporter creates a new Reporter. Recent log lines are read from logs,
+// which may be nil.
+func NewReporter(l log.Logger, logs *LogBuffer, o Options) (*Reporter, error) {
+	if err := os.MkdirAll(o.Dir, 0750); err != nil {
+		return nil, fmt.Errorf("creating crash report directory: %w", err)
+	}
+	return &Reporter{
+		log:     l,
+		opts:    o,
+		logs:    logs,
+		readRSS: readRSS,
+	}, nil
+}
+
+// Reports returns the paths of the reports in the report directory, from
+// oldest to newest.
+func (r *Reporter) Reports() ([]string, error) {
+	entries, err := os.ReadDir(r.opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, reportPrefix) || !strings.HasSuffix(name, reportExt) {
+			continue
+		}
+		reports = append(reports, filepath.Join(r.opts.Dir, name))
+	}
+	sort.Strings(reports)
+	return reports, nil
+}
+
+// Report writes a report with the given reason and details, such as the stack
+// of a panic. It returns the path of the report.
+func (r *Reporter) Report(reason string, details []byte) (string, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	now := time.Now().UTC()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "reason: %s\n", reason)
+	fmt.Fprintf(&buf, "time: %s\n", now.Format(time.RFC3339Nano))
+	fmt.Fprintf(&buf, "pid: %d\n\n", os.Getpid())
+
+	buf.WriteString("=== build ===\n")
+	buf.WriteString(version.Print("agent"))
+	buf.WriteString("\n\n")
+
+	if len(details) > 0 {
+		buf.WriteString("=== details ===\n")
+		buf.Write(details)
+		buf.WriteString("\n\n")
+	}
+
+	buf.WriteString("=== goroutines ===\n")
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		fmt.Fprintf(&buf, "failed to dump goroutines: %s\n", err)
+	}
+	buf.WriteString("\n")
+
+	if r.logs != nil {
+		buf.WriteString("=== recent logs ===\n")
+		_, _ = r.logs.WriteTo(&buf)
+	}
+
+	path := filepath.Join(r.opts.Dir, reportPrefix+now.Format(timeFormat)+reportExt)
+	if err := os.WriteFile(path, buf.Bytes(), 0640); err != nil {
+		return "", err
+	}
+	if err := r.prune(); err != nil {
+		level.Warn(r.log).Log("msg", "failed to delete old crash reports", "err", err)
+	}
+
+	if r.opts.UploadURL != "" {
+		if err := r.upload(buf.Bytes()); err != nil {
+			level.Warn(r.log).Log("msg", "failed to upload crash report", "url", r.opts.UploadURL, "err", err)
+		}
+	}
+	return path, nil
+}
+
+// prune deletes the oldest reports which exceed MaxReports.
+func (r *Reporter) prune() error {
+	if r.opts.MaxReports <= 0 {
+		return nil
+	}
+	reports, err := r.Reports()
+	if err != nil {
+		return err
+	}
+	for len(reports) > r.opts.MaxReports {
+		if err := os.Remove(reports[0]); err != nil {
+			return err
+		}
+		reports = reports[1:]
+	}
+	return nil
+}
+
+func (r *Reporter) upload(report []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.opts.UploadURL, bytes.NewReader(report))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %s", resp.Status)
+	}
+	return nil
+}
+
+// Run checks the memory usage of the process every interval until ctx is
+// canceled, and writes a report when it crosses MemoryThreshold. Another
+// report is only written once the memory usage dropped below the threshold
+// in between. Run returns immediately if MemoryThreshold is 0.
+func (r *Reporter) Run(ctx context.Context, interval time.Duration) {
+	if r.opts.MemoryThreshold == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var reported bool
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reported = r.checkMemory(reported)
+		}
+	}
+}
+
+// checkMemory writes a report if memory usage crossed the threshold and
+// reported is false. It returns whether memory usage is above the threshold.
+func (r *Reporter) checkMemory(reported bool) bool {
+	rss, err := r.readRSS()
+	if err != nil {
+		level.Warn(r.log).Log("msg", "failed to read memory usage", "err", err)
+		return reported
+	}
+	if rss < r.opts.MemoryThreshold {
+		return false
+	} else if reported {
+		return true
+	}
+
+	reason := fmt.Sprintf("memory usage of %d bytes crossed threshold of %d bytes", rss, r.opts.MemoryThreshold)
+	path, err := r.Report(reason, nil)
+	if err != nil {
+		level.Error(r.log).Log("msg", "failed to write crash report", "err", err)
+	} else {
+		level.Warn(r.log).Log("msg", "memory usage crossed threshold, wrote crash report", "path", path, "rss_bytes", rss)
+	}
+	return true
+}
+
+func readRSS() (uint64, error) {
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return 0, err
+	}
+	mi, err := proc.MemoryInfo()
+	if err != nil {
+		return 0, err
+	}
+	return mi.RSS, nil
+}
+
+var (
+	globalMut      sync.Mutex
+	globalReporter *Reporter
+)
+
+// SetReporter sets the Reporter used by Recover. A nil Reporter disables
+// writing reports from Recover.
+func SetReporter(r *Reporter) {
+	globalMut.Lock()
+	defer globalMut.Unlock()
+	globalReporter = r
+}
+
+// Recover writes a report for a panic, if any, and continues panicking.
+// Recover must be called directly by a deferred function:
+//
+//	defer crash.Recover()
+//
+// Recover is a no-op if there is no panic. If no Reporter was set with
+// SetReporter, the panic continues without writing a report.
+func Recover() {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	globalMut.Lock()
+	r := globalReporter
+	globalMut.Unlock()
+
+	if r != nil {
+		reason := fmt.Sprintf("panic: %v", v)
+		if path, err := r.Report(reason, debug.Stack()); err != nil {
+			level.Error(r.log).Log("msg", "failed to write crash report", "err", err)
+		} else {
+			level.Error(r.log).Log("msg", "recovered from panic, wrote crash report", "path", path)
+		}
+	}
+	panic(v)
+}
@@ -0,0 +1,114 @@
+package crash
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogBuffer(t *testing.T) {
+	b := NewLogBuffer(3)
+	for _, line := range []string{"a\n", "b\n", "c\n", "d\n", "e\n"} {
+		_, _ = b.Write([]byte(line))
+	}
+
+	var buf bytes.Buffer
+	_, err := b.WriteTo(&buf)
+	require.NoError(t, err)
+	require.Equal(t, "c\nd\ne\n", buf.String())
+}
+
+func TestReporter_Report(t *testing.T) {
+	var uploaded []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploaded, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	logs := NewLogBuffer(10)
+	_, _ = logs.Write([]byte("level=info msg=\"hello\"\n"))
+
+	r, err := NewReporter(log.NewNopLogger(), logs, Options{
+		Dir:        t.TempDir(),
+		MaxReports: 2,
+		UploadURL:  srv.URL,
+	})
+	require.NoError(t, err)
+
+	path, err := r.Report("test", []byte("some details"))
+	require.NoError(t, err)
+
+	report, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(report), "reason: test")
+	require.Contains(t, string(report), "some details")
+	require.Contains(t, string(report), "=== goroutines ===")
+	require.Contains(t, string(report), `msg="hello"`)
+	require.Equal(t, report, uploaded)
+
+	// Only the most recent reports are kept.
+	for i := 0; i < 3; i++ {
+		_, err := r.Report("test", nil)
+		require.NoError(t, err)
+	}
+	reports, err := r.Reports()
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+}
+
+func TestReporter_CheckMemory(t *testing.T) {
+	r, err := NewReporter(log.NewNopLogger(), nil, Options{
+		Dir:             t.TempDir(),
+		MemoryThreshold: 100,
+	})
+	require.NoError(t, err)
+
+	var rss uint64
+	r.readRSS = func() (uint64, error) { return rss, nil }
+
+	countReports := func() int {
+		reports, err := r.Reports()
+		require.NoError(t, err)
+		return len(reports)
+	}
+
+	rss = 50
+	reported := r.checkMemory(false)
+	require.False(t, reported)
+	require.Equal(t, 0, countReports())
+
+	// Only one report is written while memory usage stays above the
+	// threshold.
+	rss = 150
+	reported = r.checkMemory(reported)
+	reported = r.checkMemory(reported)
+	require.True(t, reported)
+	require.Equal(t, 1, countReports())
+}
+
+func TestRecover(t *testing.T) {
+	r, err := NewReporter(log.NewNopLogger(), nil, Options{Dir: t.TempDir()})
+	require.NoError(t, err)
+
+	SetReporter(r)
+	defer SetReporter(nil)
+
+	require.PanicsWithValue(t, "oops", func() {
+		defer Recover()
+		panic("oops")
+	})
+
+	reports, err := r.Reports()
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+
+	report, err := os.ReadFile(reports[0])
+	require.NoError(t, err)
+	require.Contains(t, string(report), "reason: panic: oops")
+}
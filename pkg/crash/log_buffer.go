@@ -0,0 +1,61 @@
+package crash
+
+import (
+	"io"
+	"sync"
+)
+
+// LogBuffer is an io.Writer which keeps the most recent log lines written to
+// it, so they can be included in crash reports. Each call to Write is treated
+// as one line.
+type LogBuffer struct {
+	mut   sync.Mutex
+	lines [][]byte
+	next  int // Index of the next line to overwrite once lines is full.
+	size  int
+}
+
+// NewLogBuffer creates a LogBuffer which keeps up to size lines.
+func NewLogBuffer(size int) *LogBuffer {
+	return &LogBuffer{
+		lines: make([][]byte, 0, size),
+		size:  size,
+	}
+}
+
+// Write implements io.Writer.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	if b.size <= 0 {
+		return len(p), nil
+	}
+
+	line := make([]byte, len(p))
+	copy(line, p)
+
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	if len(b.lines) < b.size {
+		b.lines = append(b.lines, line)
+	} else {
+		b.lines[b.next] = line
+		b.next = (b.next + 1) % b.size
+	}
+	return len(p), nil
+}
+
+// WriteTo writes the buffered lines to w, from oldest to newest.
+func (b *LogBuffer) WriteTo(w io.Writer) (int64, error) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	var total int64
+	for i := range b.lines {
+		n, err := w.Write(b.lines[(b.next+i)%len(b.lines)])
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
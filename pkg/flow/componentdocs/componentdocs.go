@@ -0,0 +1,53 @@
+// Package componentdocs describes registered Flow components from their
+// Arguments and Exports types. The descriptions are used to generate
+// reference documentation and to power editor tooling, so both are derived
+// from the same Go structs that River decodes into.
+package componentdocs
+
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/schema"
+)
+
+// Component describes a registered component.
+type Component struct {
+	Name      string      `json:"name"`
+	Singleton bool        `json:"singleton"`
+	Arguments schema.Body `json:"arguments"`
+	Exports   schema.Body `json:"exports"`
+}
+
+// Get describes the registered component called name.
+func Get(name string) (Component, bool) {
+	reg, ok := component.Get(name)
+	if !ok {
+		return Component{}, false
+	}
+	return describe(reg), true
+}
+
+// All describes every registered component, sorted by name.
+func All() []Component {
+	names := component.AllNames()
+
+	res := make([]Component, 0, len(names))
+	for _, name := range names {
+		reg, _ := component.Get(name)
+		res = append(res, describe(reg))
+	}
+	return res
+}
+
+func describe(reg component.Registration) Component {
+	c := Component{
+		Name:      reg.Name,
+		Singleton: reg.Singleton,
+	}
+	if reg.Args != nil {
+		c.Arguments = schema.For(reg.Args)
+	}
+	if reg.Exports != nil {
+		c.Exports = schema.For(reg.Exports)
+	}
+	return c
+}
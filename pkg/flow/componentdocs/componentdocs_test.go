@@ -0,0 +1,69 @@
+package componentdocs_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/agent/pkg/flow/componentdocs"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/grafana/agent/component/prometheus/relabel"
+)
+
+func TestAll(t *testing.T) {
+	var found bool
+	for _, c := range componentdocs.All() {
+		if c.Name == "prometheus.relabel" {
+			found = true
+		}
+	}
+	require.True(t, found, "prometheus.relabel should be described")
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	c, ok := componentdocs.Get("prometheus.relabel")
+	require.True(t, ok)
+
+	var dl componentdocs.DescriptionLoader
+	require.NoError(t, dl.Load(&c))
+
+	var buf bytes.Buffer
+	require.NoError(t, componentdocs.WriteMarkdown(&buf, c))
+
+	for _, expect := range []string{
+		"```river\nprometheus.relabel \"LABEL\" {\n  forward_to = FORWARD_TO\n}\n```\n",
+		"`forward_to` | `list(capsule(storage.Appendable))` | Where the relabelled metrics should be forwarded to. |  | yes\n",
+		"rule | [rule][] | The relabelling rules to apply to each metric before it's forwarded. | no\n",
+		"[rule]: #rule-block\n",
+		"The `rule` block may be specified multiple times.\n",
+		"`separator` | `string` |  | `\";\"` | no\n",
+		"`receiver` | `capsule(storage.Appendable)` | \n",
+	} {
+		require.Contains(t, buf.String(), expect)
+	}
+}
+
+func TestWriteRiver(t *testing.T) {
+	c, ok := componentdocs.Get("prometheus.relabel")
+	require.True(t, ok)
+
+	var buf bytes.Buffer
+	require.NoError(t, componentdocs.WriteRiver(&buf, c))
+
+	expect := `prometheus.relabel "LABEL" {
+  // forward_to: list(capsule(storage.Appendable)), required
+
+  // Block, optional, may be specified multiple times.
+  rule {
+    // source_labels: list(string)
+    separator = ";"
+    regex     = "^(?:(.*))$"
+    // modulus: number
+    // target_label: string
+    replacement = "$1"
+    action      = "replace"
+  }
+}
+`
+	require.Equal(t, expect, buf.String())
+}
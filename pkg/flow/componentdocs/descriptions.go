@@ -0,0 +1,135 @@
+package componentdocs
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/schema"
+)
+
+// DescriptionLoader fills in the descriptions of attributes and blocks from
+// the doc comments of the Go struct fields they decode into. Loading
+// descriptions requires the Go source code of the components to be
+// available, so it's only used when generating documentation.
+type DescriptionLoader struct {
+	// Dir is the directory used to resolve Go packages. The current working
+	// directory is used if empty.
+	Dir string
+
+	pkgs map[string]map[string]map[string]string // pkgPath -> type -> field -> comment
+}
+
+// Load fills in the descriptions for every attribute and block of c.
+func (dl *DescriptionLoader) Load(c *Component) error {
+	if err := dl.loadBody(&c.Arguments); err != nil {
+		return err
+	}
+	return dl.loadBody(&c.Exports)
+}
+
+func (dl *DescriptionLoader) loadBody(b *schema.Body) error {
+	for i := range b.Attributes {
+		attr := &b.Attributes[i]
+
+		desc, err := dl.describe(attr.Source)
+		if err != nil {
+			return err
+		}
+		attr.Description = desc
+	}
+
+	for i := range b.Blocks {
+		block := &b.Blocks[i]
+
+		desc, err := dl.describe(block.Source)
+		if err != nil {
+			return err
+		}
+		block.Description = desc
+
+		if err := dl.loadBody(&block.Body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (dl *DescriptionLoader) describe(src schema.Source) (string, error) {
+	if src.Type == nil || src.Type.PkgPath() == "" {
+		return "", nil
+	}
+
+	types, err := dl.loadPackage(src.Type.PkgPath())
+	if err != nil {
+		return "", err
+	}
+	return types[src.Type.Name()][src.Field], nil
+}
+
+// loadPackage parses the Go source code for pkgPath and returns the doc
+// comments of every struct field in the package.
+func (dl *DescriptionLoader) loadPackage(pkgPath string) (map[string]map[string]string, error) {
+	if types, ok := dl.pkgs[pkgPath]; ok {
+		return types, nil
+	}
+	if dl.pkgs == nil {
+		dl.pkgs = make(map[string]map[string]map[string]string)
+	}
+
+	bp, err := build.Import(pkgPath, dl.Dir, build.FindOnly)
+	if err != nil {
+		return nil, fmt.Errorf("finding source for %s: %w", pkgPath, err)
+	}
+
+	isSource := func(fi fs.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }
+	parsed, err := parser.ParseDir(token.NewFileSet(), bp.Dir, isSource, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing source for %s: %w", pkgPath, err)
+	}
+
+	types := make(map[string]map[string]string)
+	for _, pkg := range parsed {
+		for _, f := range pkg.Files {
+			ast.Inspect(f, func(n ast.Node) bool {
+				ts, ok := n.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					return true
+				}
+
+				fields := make(map[string]string)
+				for _, field := range st.Fields.List {
+					comment := field.Doc
+					if comment == nil {
+						comment = field.Comment
+					}
+					if comment == nil {
+						continue
+					}
+					for _, name := range field.Names {
+						fields[name.Name] = cleanComment(comment.Text())
+					}
+				}
+				types[ts.Name.Name] = fields
+				return true
+			})
+		}
+	}
+
+	dl.pkgs[pkgPath] = types
+	return types, nil
+}
+
+// cleanComment joins a multi-line Go comment into a single line.
+func cleanComment(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,218 @@
+package componentdocs
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/schema"
+)
+
+// WriteMarkdown writes the generated sections of the reference page for c to
+// w: the usage example, arguments, blocks, and exported fields. The sections
+// follow the structure of the component reference documentation; prose such
+// as component health and examples must be written by hand.
+func WriteMarkdown(w io.Writer, c Component) error {
+	mw := &markdownWriter{w: w}
+
+	mw.printf("---\ntitle: %s\n---\n\n", c.Name)
+	mw.printf("# %s\n\n", c.Name)
+
+	mw.printf("## Usage\n\n")
+	mw.writeUsage(c)
+
+	mw.printf("## Arguments\n\n")
+	if len(c.Arguments.Attributes) == 0 {
+		mw.printf("`%s` does not support any arguments.\n\n", c.Name)
+	} else {
+		mw.printf("The following arguments are supported:\n\n")
+		mw.writeArguments(c.Arguments.Attributes)
+	}
+
+	mw.printf("## Blocks\n\n")
+	if len(c.Arguments.Blocks) == 0 {
+		mw.printf("The `%s` component does not support any blocks, and is configured\nfully through arguments.\n\n", c.Name)
+	} else {
+		mw.writeBlocks(c.Name, c.Arguments.Blocks)
+	}
+
+	mw.printf("## Exported fields\n\n")
+	if len(c.Exports.Attributes) == 0 {
+		mw.printf("`%s` does not export any fields.\n", c.Name)
+	} else {
+		mw.printf("The following fields are exported and can be referenced by other components:\n\n")
+		mw.writeExports(c.Exports.Attributes)
+	}
+
+	return mw.err
+}
+
+type markdownWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (mw *markdownWriter) printf(format string, args ...interface{}) {
+	if mw.err != nil {
+		return
+	}
+	_, mw.err = fmt.Fprintf(mw.w, format, args...)
+}
+
+func (mw *markdownWriter) writeUsage(c Component) {
+	mw.printf("```river\n")
+	if c.Singleton {
+		mw.printf("%s {\n", c.Name)
+	} else {
+		mw.printf("%s \"LABEL\" {\n", c.Name)
+	}
+
+	var required []schema.Attribute
+	for _, attr := range c.Arguments.Attributes {
+		if !attr.Optional {
+			required = append(required, attr)
+		}
+	}
+
+	// Align the equal signs the same way the River formatter does.
+	var width int
+	for _, attr := range required {
+		if len(attr.Name) > width {
+			width = len(attr.Name)
+		}
+	}
+	for _, attr := range required {
+		mw.printf("  %-*s = %s\n", width, attr.Name, strings.ToUpper(attr.Name))
+	}
+
+	mw.printf("}\n```\n\n")
+}
+
+func (mw *markdownWriter) writeArguments(attrs []schema.Attribute) {
+	mw.printf("Name | Type | Description | Default | Required\n")
+	mw.printf("---- | ---- | ----------- | ------- | --------\n")
+	for _, attr := range attrs {
+		var def string
+		if attr.Default != "" {
+			def = "`" + attr.Default + "`"
+		}
+		mw.printf("`%s` | %s | %s | %s | %s\n", attr.Name, formatType(attr.Type), escapeCell(attr.Description), def, yesNo(!attr.Optional))
+	}
+	mw.printf("\n")
+}
+
+func (mw *markdownWriter) writeExports(attrs []schema.Attribute) {
+	mw.printf("Name | Type | Description\n")
+	mw.printf("---- | ---- | -----------\n")
+	for _, attr := range attrs {
+		mw.printf("`%s` | %s | %s\n", attr.Name, formatType(attr.Type), escapeCell(attr.Description))
+	}
+}
+
+// blockEntry is a block with the path of blocks it's nested in.
+type blockEntry struct {
+	path  []string
+	block schema.Block
+}
+
+func flattenBlocks(parent []string, blocks []schema.Block) []blockEntry {
+	var res []blockEntry
+	for _, b := range blocks {
+		path := append(append([]string{}, parent...), b.Name)
+		res = append(res, blockEntry{path: path, block: b})
+		res = append(res, flattenBlocks(path, b.Body.Blocks)...)
+	}
+	return res
+}
+
+func (mw *markdownWriter) writeBlocks(name string, blocks []schema.Block) {
+	entries := flattenBlocks(nil, blocks)
+
+	mw.printf("The following blocks are supported inside the definition of\n`%s`:\n\n", name)
+	mw.printf("Hierarchy | Block | Description | Required\n")
+	mw.printf("--------- | ----- | ----------- | --------\n")
+
+	var nested bool
+	for _, e := range entries {
+		if len(e.path) > 1 {
+			nested = true
+		}
+		mw.printf("%s | [%s][] | %s | %s\n", strings.Join(e.path, " > "), e.block.Name, escapeCell(e.block.Description), yesNo(!e.block.Optional))
+	}
+	mw.printf("\n")
+
+	if nested {
+		for _, e := range entries {
+			if len(e.path) > 1 {
+				parent := e.path[len(e.path)-2]
+				mw.printf("The `>` symbol indicates deeper levels of nesting. For example, `%s`\nrefers to a `%s` block defined inside %s `%s` block.\n\n",
+					strings.Join(e.path, " > "), e.path[len(e.path)-1], article(parent), parent)
+				break
+			}
+		}
+	}
+
+	// Blocks which appear more than once in the hierarchy are only
+	// documented once.
+	var unique []blockEntry
+	seen := make(map[string]struct{})
+	for _, e := range entries {
+		if _, ok := seen[e.block.Name]; ok {
+			continue
+		}
+		seen[e.block.Name] = struct{}{}
+		unique = append(unique, e)
+	}
+
+	for _, e := range unique {
+		mw.printf("[%s]: #%s-block\n", e.block.Name, strings.ReplaceAll(e.block.Name, ".", ""))
+	}
+	mw.printf("\n")
+
+	for _, e := range unique {
+		mw.printf("### %s block\n\n", e.block.Name)
+		if e.block.Description != "" {
+			mw.printf("%s\n\n", e.block.Description)
+		}
+		if e.block.Repeated {
+			mw.printf("The `%s` block may be specified multiple times.\n\n", e.block.Name)
+		}
+
+		if len(e.block.Body.Attributes) == 0 {
+			mw.printf("The `%s` block does not support any arguments.\n\n", e.block.Name)
+			continue
+		}
+		mw.printf("The following arguments are supported:\n\n")
+		mw.writeArguments(e.block.Body.Attributes)
+	}
+}
+
+// formatType formats a type name from the schema package. Type names which
+// describe alternatives, such as "string or secret", have each alternative
+// formatted separately.
+func formatType(ty string) string {
+	parts := strings.Split(ty, " or ")
+	for i, p := range parts {
+		parts[i] = "`" + p + "`"
+	}
+	return strings.Join(parts, " or ")
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// article returns the indefinite article for word.
+func article(word string) string {
+	if word != "" && strings.ContainsRune("aeiou", rune(word[0])) {
+		return "an"
+	}
+	return "a"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,100 @@
+package componentdocs
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/schema"
+)
+
+// WriteRiver writes a River block for c to w which sets every argument with a
+// non-zero default to its default value. Arguments without a default are
+// listed as comments along with their type, so the output shows the
+// effective configuration of a component which only sets its required
+// arguments.
+func WriteRiver(w io.Writer, c Component) error {
+	rw := &riverWriter{w: w}
+
+	if c.Singleton {
+		rw.printf("%s {\n", c.Name)
+	} else {
+		rw.printf("%s \"LABEL\" {\n", c.Name)
+	}
+	rw.writeBody(c.Arguments, 1)
+	rw.printf("}\n")
+
+	return rw.err
+}
+
+type riverWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (rw *riverWriter) printf(format string, args ...interface{}) {
+	if rw.err != nil {
+		return
+	}
+	_, rw.err = fmt.Fprintf(rw.w, format, args...)
+}
+
+func (rw *riverWriter) writeBody(body schema.Body, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	if body.Freeform {
+		rw.printf("%s// Accepts any attribute.\n", indent)
+		return
+	}
+
+	// Align the equal signs of consecutive attributes with defaults the same
+	// way the River formatter does.
+	for i := 0; i < len(body.Attributes); {
+		attr := body.Attributes[i]
+		if attr.Default == "" {
+			if attr.Optional {
+				rw.printf("%s// %s: %s\n", indent, attr.Name, attr.Type)
+			} else {
+				rw.printf("%s// %s: %s, required\n", indent, attr.Name, attr.Type)
+			}
+			i++
+			continue
+		}
+
+		end := i
+		width := 0
+		for end < len(body.Attributes) && body.Attributes[end].Default != "" {
+			if n := len(body.Attributes[end].Name); n > width {
+				width = n
+			}
+			end++
+		}
+		for _, attr := range body.Attributes[i:end] {
+			rw.printf("%s%-*s = %s\n", indent, width, attr.Name, attr.Default)
+		}
+		i = end
+	}
+
+	for _, block := range body.Blocks {
+		rw.printf("\n")
+
+		var notes []string
+		if block.Optional {
+			notes = append(notes, "optional")
+		} else {
+			notes = append(notes, "required")
+		}
+		if block.Repeated {
+			notes = append(notes, "may be specified multiple times")
+		}
+		rw.printf("%s// Block, %s.\n", indent, strings.Join(notes, ", "))
+
+		if block.Labeled {
+			rw.printf("%s%s \"LABEL\" {\n", indent, block.Name)
+		} else {
+			rw.printf("%s%s {\n", indent, block.Name)
+		}
+		rw.writeBody(block.Body, depth+1)
+		rw.printf("%s}\n", indent)
+	}
+}
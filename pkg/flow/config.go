@@ -76,6 +76,10 @@ func ReadFile(name string, bb []byte) (*File, error) {
 				configs = append(configs, stmt)
 			case "tracing":
 				configs = append(configs, stmt)
+			case "http_defaults":
+				configs = append(configs, stmt)
+			case "events":
+				configs = append(configs, stmt)
 			case "argument":
 				var arg Argument
 				if err := vm.New(stmt).Evaluate(nil, &arg); err != nil {
@@ -117,3 +121,54 @@ func ReadFile(name string, bb []byte) (*File, error) {
 		ConfigBlocks: configs,
 	}, nil
 }
+
+// MergeFiles merges files into a single File named name, so that a config can
+// be split across several files. The blocks of files are merged in the order
+// files are given, and keep the positions of the file they were read from.
+// Blocks declared more than once across files are reported as diagnostics.
+func MergeFiles(name string, files []*File) (*File, error) {
+	merged := &File{
+		Name: name,
+		Node: &ast.File{Name: name},
+	}
+
+	var (
+		diags diag.Diagnostics
+
+		declared = make(map[string]*ast.BlockStmt)
+	)
+	for _, f := range files {
+		for _, stmt := range f.Node.Body {
+			block, ok := stmt.(*ast.BlockStmt)
+			if !ok {
+				continue
+			}
+
+			id := strings.Join(block.Name, ".")
+			if block.Label != "" {
+				id += "." + block.Label
+			}
+			if orig, ok := declared[id]; ok {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: ast.StartPos(block).Position(),
+					EndPos:   ast.EndPos(block).Position(),
+					Message:  fmt.Sprintf("Block %s already declared at %s", id, ast.StartPos(orig).Position()),
+				})
+				continue
+			}
+			declared[id] = block
+		}
+
+		merged.Node.Body = append(merged.Node.Body, f.Node.Body...)
+		merged.Node.Comments = append(merged.Node.Comments, f.Node.Comments...)
+		merged.Arguments = append(merged.Arguments, f.Arguments...)
+		merged.Components = append(merged.Components, f.Components...)
+		merged.ConfigBlocks = append(merged.ConfigBlocks, f.ConfigBlocks...)
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return merged, nil
+}
@@ -60,6 +60,43 @@ func TestReadFile_Defaults(t *testing.T) {
 	require.Len(t, f.Components, 0)
 }
 
+func TestMergeFiles(t *testing.T) {
+	a, err := flow.ReadFile("a.river", []byte(`
+		logging {
+			level = "debug"
+		}
+
+		testcomponents.passthrough "a" {
+			input = "a"
+		}
+	`))
+	require.NoError(t, err)
+	b, err := flow.ReadFile("b.river", []byte(`
+		testcomponents.passthrough "b" {
+			input = "b"
+		}
+	`))
+	require.NoError(t, err)
+
+	f, err := flow.MergeFiles("config", []*flow.File{a, b})
+	require.NoError(t, err)
+	require.Equal(t, "config", f.Name)
+	require.Len(t, f.ConfigBlocks, 1)
+	require.Len(t, f.Components, 2)
+	require.Equal(t, "testcomponents.passthrough.a", getBlockID(f.Components[0]))
+	require.Equal(t, "testcomponents.passthrough.b", getBlockID(f.Components[1]))
+
+	c, err := flow.ReadFile("c.river", []byte(`
+		testcomponents.passthrough "a" {
+			input = "c"
+		}
+	`))
+	require.NoError(t, err)
+
+	_, err = flow.MergeFiles("config", []*flow.File{a, b, c})
+	require.EqualError(t, err, "c.river:2:3: Block testcomponents.passthrough.a already declared at a.river:6:3")
+}
+
 func getBlockID(b *ast.BlockStmt) string {
 	var parts []string
 	parts = append(parts, b.Name...)
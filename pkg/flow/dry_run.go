@@ -0,0 +1,213 @@
+package flow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+)
+
+// DryRunOptions configures a dry run of the loaded components.
+type DryRunOptions struct {
+	// DiscoveryTimeout is the maximum time to wait for discovery components to
+	// export targets. There is no deadline if DiscoveryTimeout is 0, other
+	// than the context passed to DryRun.
+	DiscoveryTimeout time.Duration
+
+	// SkipDiscovery disables running discovery components, such as when only
+	// the processing of inputs is of interest.
+	SkipDiscovery bool
+
+	// Inputs are processed by the components they name, in order.
+	Inputs []DryRunInput
+}
+
+// DryRunInput is sample input processed by a component during a dry run.
+// The format of Input depends on the component.
+type DryRunInput struct {
+	Component string          `json:"component"`
+	Input     json.RawMessage `json:"input"`
+}
+
+// DryRunReport is the result of a dry run.
+type DryRunReport struct {
+	// Components lists the health of every loaded component.
+	Components []DryRunComponentHealth `json:"components"`
+	// Targets holds the targets exported by components, by component ID.
+	Targets map[string][]discovery.Target `json:"targets"`
+	// Inputs holds the result of processing each input, in order.
+	Inputs []DryRunInputResult `json:"inputs"`
+	// Destinations holds the URLs data would be sent to, by component ID.
+	Destinations map[string][]string `json:"destinations"`
+}
+
+// DryRunComponentHealth is the health of a component after a dry run.
+type DryRunComponentHealth struct {
+	ID      string `json:"id"`
+	Health  string `json:"health"`
+	Message string `json:"message,omitempty"`
+}
+
+// DryRunInputResult describes how a component processed an input.
+type DryRunInputResult struct {
+	Component string   `json:"component"`
+	Steps     []string `json:"steps,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Failed returns whether a component is unhealthy or failed to process an
+// input.
+func (r *DryRunReport) Failed() bool {
+	for _, c := range r.Components {
+		if c.Health == component.HealthTypeUnhealthy.String() || c.Health == component.HealthTypeExited.String() {
+			return true
+		}
+	}
+	for _, in := range r.Inputs {
+		if in.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// DryRun checks the components of the loaded config file without sending any
+// data. LoadFile must be called first, and DryRun must not be called on a
+// controller which is running. Controllers created with Options.DryRun don't
+// build Listener components, which are reported with an unknown health.
+//
+// Only discovery components are run, until all of them exported targets or
+// the discovery timeout passed, unless discovery is skipped. Then, the inputs in opts are processed by the
+// components they name, and the report lists the targets found and the
+// destinations which data would be sent to.
+func (c *Flow) DryRun(ctx context.Context, opts DryRunOptions) (*DryRunReport, error) {
+	if !c.loadedOnce.Load() {
+		return nil, fmt.Errorf("no config file loaded")
+	}
+
+	if opts.DiscoveryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.DiscoveryTimeout)
+		defer cancel()
+	}
+
+	var discoverers []controller.RunnableNode
+	for _, cn := range c.loader.Components() {
+		if !opts.SkipDiscovery && strings.HasPrefix(cn.ComponentName(), "discovery.") {
+			discoverers = append(discoverers, cn)
+		}
+	}
+
+	sched := controller.NewScheduler()
+	if err := sched.Synchronize(discoverers); err != nil {
+		return nil, err
+	}
+	c.waitForTargets(ctx, discoverers)
+
+	report := &DryRunReport{
+		Targets:      make(map[string][]discovery.Target),
+		Destinations: make(map[string][]string),
+	}
+	for _, cn := range c.loader.Components() {
+		h := cn.CurrentHealth()
+		if cn.SkipsBuild() {
+			h.Health = component.HealthTypeUnknown
+			h.Message = "not built during dry runs because it listens on the network"
+		}
+		report.Components = append(report.Components, DryRunComponentHealth{
+			ID:      cn.NodeID(),
+			Health:  h.Health.String(),
+			Message: h.Message,
+		})
+		if targets, ok := exportedTargets(cn.Exports()); ok {
+			report.Targets[cn.NodeID()] = targets
+		}
+		if dc := cn.DestinationComponent(); dc != nil {
+			report.Destinations[cn.NodeID()] = dc.Destinations()
+		}
+	}
+	_ = sched.Close()
+
+	g := c.loader.Graph()
+	for _, in := range opts.Inputs {
+		res := DryRunInputResult{Component: in.Component}
+		cn, ok := g.GetByID(in.Component).(*controller.ComponentNode)
+		switch {
+		case !ok:
+			res.Error = fmt.Sprintf("component %s does not exist", in.Component)
+		case cn.DryRunComponent() == nil:
+			res.Error = fmt.Sprintf("component %s does not support dry runs", in.Component)
+		default:
+			steps, err := cn.DryRunComponent().DryRun(in.Input)
+			res.Steps = steps
+			if err != nil {
+				res.Error = err.Error()
+			}
+		}
+		report.Inputs = append(report.Inputs, res)
+	}
+
+	return report, nil
+}
+
+// waitForTargets evaluates the components which depend on discoverers as
+// their exports change, until every discoverer exported targets or ctx is
+// canceled.
+func (c *Flow) waitForTargets(ctx context.Context, discoverers []controller.RunnableNode) {
+	for {
+		for {
+			updated := c.updateQueue.TryDequeue()
+			if updated == nil {
+				break
+			}
+			c.loader.EvaluateDependencies(nil, updated)
+		}
+
+		found := true
+		for _, n := range discoverers {
+			targets, _ := exportedTargets(n.(*controller.ComponentNode).Exports())
+			if len(targets) == 0 {
+				found = false
+				break
+			}
+		}
+		if found {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			level.Warn(c.log).Log("msg", "not all discovery components exported targets before the timeout")
+			return
+		case <-c.updateQueue.Chan():
+		}
+	}
+}
+
+var targetsType = reflect.TypeOf([]discovery.Target(nil))
+
+// exportedTargets returns the targets held by the first field of exports
+// which is a list of targets.
+func exportedTargets(exports component.Exports) ([]discovery.Target, bool) {
+	v := reflect.ValueOf(exports)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).IsExported() && v.Field(i).Type() == targetsType {
+			return v.Field(i).Interface().([]discovery.Target), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,116 @@
+package flow
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.dryrun_test",
+		Args:    dryRunDiscoveryArgs{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return &dryRunDiscovery{opts: opts, args: args.(dryRunDiscoveryArgs)}, nil
+		},
+	})
+}
+
+type dryRunDiscoveryArgs struct {
+	Address string `river:"address,attr"`
+}
+
+// dryRunDiscovery exports a single target once it runs.
+type dryRunDiscovery struct {
+	opts component.Options
+	args dryRunDiscoveryArgs
+}
+
+func (d *dryRunDiscovery) Run(ctx context.Context) error {
+	d.opts.OnStateChange(discovery.Exports{
+		Targets: []discovery.Target{{"__address__": d.args.Address}},
+	})
+	<-ctx.Done()
+	return nil
+}
+
+func (d *dryRunDiscovery) Update(args component.Arguments) error {
+	d.args = args.(dryRunDiscoveryArgs)
+	return nil
+}
+
+func TestController_DryRun(t *testing.T) {
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(`
+		discovery.dryrun_test "example" {
+			address = "localhost:9090"
+		}
+
+		testcomponents.passthrough "static" {
+			input = "hello, world!"
+		}
+	`))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	report, err := ctrl.DryRun(context.Background(), DryRunOptions{
+		DiscoveryTimeout: 5 * time.Second,
+		Inputs: []DryRunInput{
+			{Component: "testcomponents.passthrough.static", Input: json.RawMessage(`{}`)},
+			{Component: "testcomponents.passthrough.missing", Input: json.RawMessage(`{}`)},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, map[string][]discovery.Target{
+		"discovery.dryrun_test.example": {{"__address__": "localhost:9090"}},
+	}, report.Targets)
+	require.Empty(t, report.Destinations)
+
+	require.Len(t, report.Components, 2)
+	for _, c := range report.Components {
+		require.Equal(t, component.HealthTypeHealthy.String(), c.Health, c.ID)
+	}
+
+	require.Equal(t, []DryRunInputResult{
+		{Component: "testcomponents.passthrough.static", Error: "component testcomponents.passthrough.static does not support dry runs"},
+		{Component: "testcomponents.passthrough.missing", Error: "component testcomponents.passthrough.missing does not exist"},
+	}, report.Inputs)
+	require.True(t, report.Failed())
+}
+
+func TestController_DryRun_Listener(t *testing.T) {
+	opts := testOptions(t)
+	opts.DryRun = true
+	ctrl := New(opts)
+
+	f, err := ReadFile(t.Name(), []byte(`
+		testcomponents.listener "example" { }
+	`))
+	require.NoError(t, err)
+	// The listener would fail to load if it was built.
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	report, err := ctrl.DryRun(context.Background(), DryRunOptions{SkipDiscovery: true})
+	require.NoError(t, err)
+	require.Equal(t, []DryRunComponentHealth{{
+		ID:      "testcomponents.listener.example",
+		Health:  component.HealthTypeUnknown.String(),
+		Message: "not built during dry runs because it listens on the network",
+	}}, report.Components)
+	require.False(t, report.Failed())
+}
+
+func TestController_DryRun_NotLoaded(t *testing.T) {
+	ctrl := New(testOptions(t))
+	_, err := ctrl.DryRun(context.Background(), DryRunOptions{})
+	require.EqualError(t, err, "no config file loaded")
+}
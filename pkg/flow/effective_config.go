@@ -0,0 +1,76 @@
+package flow
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/token/builder"
+)
+
+// WriteEffectiveConfig writes the evaluated arguments of every component to w
+// in River syntax, in the order the components are defined in the config
+// file. The arguments are written after defaults have been applied and
+// expressions have been evaluated, so the output shows the configuration the
+// components are running with. Secrets are redacted.
+//
+// Components which have not been evaluated successfully yet are written as
+// comments.
+func (f *Flow) WriteEffectiveConfig(w io.Writer) error {
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+
+	components := append([]*controller.ComponentNode(nil), f.loader.Components()...)
+	sort.SliceStable(components, func(i, j int) bool {
+		return ast.StartPos(components[i].Block()).Offset() < ast.StartPos(components[j].Block()).Offset()
+	})
+
+	for i, cn := range components {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := writeEffectiveComponent(w, cn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEffectiveComponent(w io.Writer, cn *controller.ComponentNode) error {
+	args := cn.Arguments()
+	if args == nil {
+		_, err := fmt.Fprintf(w, "// %s: arguments have not been evaluated\n", cn.NodeID())
+		return err
+	}
+
+	block := builder.NewBlock(strings.Split(cn.ComponentName(), "."), cn.Label())
+
+	encodeErr := func() (err error) {
+		// The builder panics on arguments which can't be encoded to a River
+		// body; report those as comments rather than failing the whole config.
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("%v", r)
+			}
+		}()
+		block.Body().AppendFrom(args)
+		return nil
+	}()
+	if encodeErr != nil {
+		_, err := fmt.Fprintf(w, "// %s: failed to encode arguments: %s\n", cn.NodeID(), encodeErr)
+		return err
+	}
+
+	f := builder.NewFile()
+	f.Body().AppendBlock(block)
+	if _, err := f.WriteTo(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,47 @@
+package flow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestController_WriteEffectiveConfig(t *testing.T) {
+	const configFile = `
+		testcomponents.passthrough "forwarded" {
+			input = testcomponents.passthrough.static.output
+		}
+
+		testcomponents.tick "ticker" {
+			frequency = "1m"
+		}
+
+		testcomponents.passthrough "static" {
+			input = "hello, " + "world!"
+		}
+	`
+
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(configFile))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	var buf bytes.Buffer
+	require.NoError(t, ctrl.WriteEffectiveConfig(&buf))
+
+	expect := `testcomponents.passthrough "forwarded" {
+	input = "hello, world!"
+}
+
+testcomponents.tick "ticker" {
+	frequency = "1m0s"
+}
+
+testcomponents.passthrough "static" {
+	input = "hello, world!"
+}
+`
+	require.Equal(t, expect, buf.String())
+}
@@ -0,0 +1,210 @@
+// Package events implements the events subsystem of Grafana Agent Flow.
+// Subsystems publish significant operational events, such as a config reload
+// or a component crashing, to a Bus, which delivers them to the sinks
+// configured by the events block: the agent log, loki.* components, and
+// webhooks.
+package events
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	prom_config "github.com/prometheus/common/config"
+)
+
+// Type is the type of an event.
+type Type string
+
+// Types of events published by the agent.
+const (
+	TypeReloadApplied      Type = "reload_applied"
+	TypeReloadFailed       Type = "reload_failed"
+	TypeComponentCrashed   Type = "component_crashed"
+	TypeWALTruncated       Type = "wal_truncated"
+	TypeRemoteEndpointDown Type = "remote_endpoint_down"
+	TypeRemoteEndpointUp   Type = "remote_endpoint_up"
+)
+
+var (
+	_ encoding.TextMarshaler   = TypeReloadApplied
+	_ encoding.TextUnmarshaler = (*Type)(nil)
+)
+
+// MarshalText implements encoding.TextMarshaler.
+func (t Type) MarshalText() (text []byte, err error) {
+	return []byte(t), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *Type) UnmarshalText(text []byte) error {
+	switch Type(text) {
+	case TypeReloadApplied, TypeReloadFailed, TypeComponentCrashed,
+		TypeWALTruncated, TypeRemoteEndpointDown, TypeRemoteEndpointUp:
+		*t = Type(text)
+		return nil
+	default:
+		return fmt.Errorf("unrecognized event type %q", string(text))
+	}
+}
+
+// Event is a significant operational event.
+type Event struct {
+	Time    time.Time         `json:"time"`
+	Type    Type              `json:"type"`
+	Source  string            `json:"source,omitempty"` // ID of the component which published the event, if any.
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// queueSize is the number of published events which may wait to be
+// delivered. Events published while the queue is full are dropped.
+const queueSize = 1024
+
+// Bus delivers published events to sinks. Buses for components are created
+// with WithSource and share the sinks and queue of their parent.
+//
+// A nil Bus discards events.
+type Bus struct {
+	source string
+	core   *core
+}
+
+type core struct {
+	log     log.Logger
+	metrics *metrics
+	queue   chan Event
+
+	mut      sync.RWMutex
+	opts     Options
+	webhooks []*webhook
+}
+
+// New creates a new Bus. Events aren't delivered until Run is called.
+func New(l log.Logger, reg prometheus.Registerer) *Bus {
+	if l == nil {
+		l = log.NewNopLogger()
+	}
+
+	return &Bus{
+		core: &core{
+			log:     l,
+			metrics: newMetrics(reg),
+			queue:   make(chan Event, queueSize),
+			opts:    DefaultOptions,
+		},
+	}
+}
+
+// WithSource returns a Bus which sets the source of events published through
+// it to source.
+func (b *Bus) WithSource(source string) *Bus {
+	if b == nil {
+		return nil
+	}
+	return &Bus{source: source, core: b.core}
+}
+
+// Publish queues e for delivery without blocking. The time and source of e
+// are set if they're empty.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Source == "" {
+		e.Source = b.source
+	}
+
+	b.core.metrics.published.WithLabelValues(string(e.Type)).Inc()
+	select {
+	case b.core.queue <- e:
+	default:
+		b.core.metrics.dropped.Inc()
+	}
+}
+
+// Update reconfigures the sinks of the Bus.
+func (b *Bus) Update(opts Options) error {
+	if b == nil {
+		return nil
+	}
+
+	webhooks := make([]*webhook, 0, len(opts.Webhooks))
+	for _, wo := range opts.Webhooks {
+		cli, err := prom_config.NewClientFromConfig(*wo.HTTPClientConfig.Convert(), "events_webhook", wo.HTTPClientConfig.ClientOptions()...)
+		if err != nil {
+			return err
+		}
+		cli.Timeout = wo.Timeout
+		webhooks = append(webhooks, &webhook{url: wo.URL.String(), client: cli})
+	}
+
+	b.core.mut.Lock()
+	defer b.core.mut.Unlock()
+	b.core.opts = opts
+	b.core.webhooks = webhooks
+	return nil
+}
+
+// Run delivers published events until ctx is canceled. Events which are still
+// queued when Run exits are dropped.
+func (b *Bus) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-b.core.queue:
+			b.core.deliver(ctx, e)
+		}
+	}
+}
+
+func (c *core) deliver(ctx context.Context, e Event) {
+	c.mut.RLock()
+	var (
+		opts     = c.opts
+		webhooks = c.webhooks
+	)
+	c.mut.RUnlock()
+
+	if !opts.wants(e.Type) {
+		return
+	}
+
+	if opts.Log {
+		c.logEvent(e)
+	}
+	if len(opts.ForwardTo) > 0 {
+		c.forwardEvent(ctx, opts.ForwardTo, e)
+	}
+	for _, w := range webhooks {
+		c.postEvent(ctx, w, e)
+	}
+}
+
+// wants returns true if events of type t should be delivered.
+func (opts Options) wants(t Type) bool {
+	if len(opts.Types) == 0 {
+		return true
+	}
+	for _, want := range opts.Types {
+		if want == t {
+			return true
+		}
+	}
+	return false
+}
+
+type webhook struct {
+	url    string
+	client *http.Client
+}
@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptions_UnmarshalRiver(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		var opts Options
+		require.NoError(t, river.Unmarshal([]byte(``), &opts))
+		require.Equal(t, DefaultOptions, opts)
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		var opts Options
+		require.NoError(t, river.Unmarshal([]byte(`
+			types = ["component_crashed", "remote_endpoint_down"]
+			webhook {
+				url = "http://localhost:8080/events"
+			}
+		`), &opts))
+		require.True(t, opts.Log)
+		require.Equal(t, []Type{TypeComponentCrashed, TypeRemoteEndpointDown}, opts.Types)
+		require.Len(t, opts.Webhooks, 1)
+		require.Equal(t, DefaultWebhookOptions.Timeout, opts.Webhooks[0].Timeout)
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		var opts Options
+		err := river.Unmarshal([]byte(`types = ["component_exploded"]`), &opts)
+		require.ErrorContains(t, err, `unrecognized event type "component_exploded"`)
+	})
+
+	t.Run("invalid webhook url", func(t *testing.T) {
+		var opts Options
+		err := river.Unmarshal([]byte(`
+			webhook {
+				url = "ftp://localhost/events"
+			}
+		`), &opts)
+		require.EqualError(t, err, "url scheme must be http or https")
+	})
+}
+
+func TestBus(t *testing.T) {
+	received := make(chan Event, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		received <- e
+	}))
+	defer srv.Close()
+
+	var webhook WebhookOptions
+	require.NoError(t, river.Unmarshal([]byte(`url = "`+srv.URL+`"`), &webhook))
+
+	ch := make(loki.LogsReceiver, 10)
+	bus := New(nil, prometheus.NewRegistry())
+	require.NoError(t, bus.Update(Options{
+		Types:     []Type{TypeComponentCrashed},
+		ForwardTo: []loki.LogsReceiver{ch},
+		Webhooks:  []WebhookOptions{webhook},
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go bus.Run(ctx)
+
+	// Events with other types are filtered out.
+	bus.Publish(Event{Type: TypeReloadApplied, Message: "config loaded"})
+	bus.WithSource("prometheus.scrape.default").Publish(Event{
+		Type:    TypeComponentCrashed,
+		Message: "component shut down with error",
+		Fields:  map[string]string{"key": "value"},
+	})
+
+	select {
+	case e := <-received:
+		require.Equal(t, TypeComponentCrashed, e.Type)
+		require.Equal(t, "prometheus.scrape.default", e.Source)
+		require.Equal(t, "component shut down with error", e.Message)
+		require.Equal(t, map[string]string{"key": "value"}, e.Fields)
+		require.False(t, e.Time.IsZero())
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "webhook didn't receive the event")
+	}
+
+	select {
+	case entry := <-ch:
+		require.Equal(t, model.LabelSet{"job": "agent_events", "event_type": "component_crashed"}, entry.Labels)
+
+		var e Event
+		require.NoError(t, json.Unmarshal([]byte(entry.Line), &e))
+		require.Equal(t, "prometheus.scrape.default", e.Source)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "receiver didn't receive the event")
+	}
+	require.Empty(t, ch)
+}
+
+func TestBus_Nil(t *testing.T) {
+	var bus *Bus
+	require.Nil(t, bus.WithSource("component"))
+	require.NoError(t, bus.Update(DefaultOptions))
+	bus.Publish(Event{Type: TypeReloadApplied})
+}
+
+func TestBus_QueueFull(t *testing.T) {
+	bus := New(nil, prometheus.NewRegistry())
+
+	// Events aren't delivered until Run is called, so publishing never blocks
+	// once the queue is full.
+	for i := 0; i < queueSize+5; i++ {
+		bus.Publish(Event{Type: TypeWALTruncated})
+	}
+	require.Equal(t, 5.0, testutil.ToFloat64(bus.core.metrics.dropped))
+}
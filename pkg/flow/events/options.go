@@ -0,0 +1,84 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+)
+
+// Defaults for all Options structs.
+var (
+	DefaultOptions = Options{
+		Log: true,
+	}
+
+	DefaultWebhookOptions = WebhookOptions{
+		HTTPClientConfig: config.DefaultHTTPClientConfig,
+		Timeout:          10 * time.Second,
+	}
+)
+
+// Options control the sinks events are delivered to.
+type Options struct {
+	// Log writes events to the log of the agent.
+	Log bool `river:"log,attr,optional"`
+
+	// Types holds the types of events to deliver. All events are delivered if
+	// Types is empty.
+	Types []Type `river:"types,attr,optional"`
+
+	// ForwardTo holds the receivers of loki.* components to send events to as
+	// log entries.
+	ForwardTo []loki.LogsReceiver `river:"forward_to,attr,optional"`
+
+	// Webhooks holds the HTTP endpoints events are posted to.
+	Webhooks []WebhookOptions `river:"webhook,block,optional"`
+}
+
+// WebhookOptions configures an HTTP endpoint events are posted to as JSON.
+type WebhookOptions struct {
+	URL              config.URL              `river:"url,attr"`
+	Timeout          time.Duration           `river:"timeout,attr,optional"`
+	HTTPClientConfig config.HTTPClientConfig `river:",squash"`
+}
+
+// Implementations to apply defaults and perform validations
+var (
+	_ river.Unmarshaler = (*Options)(nil)
+	_ river.Unmarshaler = (*WebhookOptions)(nil)
+)
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (opts *Options) UnmarshalRiver(f func(interface{}) error) error {
+	*opts = DefaultOptions
+
+	type options Options
+	return f((*options)(opts))
+}
+
+// UnmarshalRiver implements river.Unmarshaler.
+func (opts *WebhookOptions) UnmarshalRiver(f func(interface{}) error) error {
+	*opts = DefaultWebhookOptions
+
+	type webhookOptions WebhookOptions
+	if err := f((*webhookOptions)(opts)); err != nil {
+		return err
+	}
+
+	if opts.URL.URL == nil {
+		return fmt.Errorf("url must not be empty")
+	}
+	if opts.URL.Scheme != "http" && opts.URL.Scheme != "https" {
+		return fmt.Errorf("url scheme must be http or https")
+	}
+	if opts.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+
+	// We must explicitly Validate because HTTPClientConfig is squashed and it
+	// won't run otherwise.
+	return opts.HTTPClientConfig.Validate()
+}
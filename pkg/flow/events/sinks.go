@@ -0,0 +1,147 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// Names of the sinks, used as the value of the sink label of the sink errors
+// metric.
+const (
+	sinkLoki    = "loki"
+	sinkWebhook = "webhook"
+)
+
+// forwardTimeout is the maximum time to wait for a loki.* component to accept
+// an event.
+const forwardTimeout = 5 * time.Second
+
+// Labels of the log entries created for events.
+const (
+	jobLabel       = "job"
+	jobLabelValue  = "agent_events"
+	eventTypeLabel = "event_type"
+)
+
+type metrics struct {
+	published  *prometheus.CounterVec
+	dropped    prometheus.Counter
+	sinkErrors *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	var m metrics
+
+	m.published = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "agent_events_published_total",
+		Help: "Number of events published, by event type.",
+	}, []string{"type"})
+
+	m.dropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "agent_events_dropped_total",
+		Help: "Number of events dropped because the queue of events waiting to be delivered was full.",
+	})
+
+	m.sinkErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "agent_events_sink_errors_total",
+		Help: "Number of events which failed to be delivered to a sink, by sink.",
+	}, []string{"sink"})
+
+	if reg != nil {
+		reg.MustRegister(m.published, m.dropped, m.sinkErrors)
+	}
+	return &m
+}
+
+// logEvent writes e to the agent log.
+func (c *core) logEvent(e Event) {
+	keyvals := []interface{}{"msg", "event", "type", e.Type, "message", e.Message}
+	if e.Source != "" {
+		keyvals = append(keyvals, "source", e.Source)
+	}
+
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		keyvals = append(keyvals, name, e.Fields[name])
+	}
+
+	level.Info(c.log).Log(keyvals...)
+}
+
+// forwardEvent sends e as a log entry to receivers. The line of the entry is
+// the JSON representation of e.
+func (c *core) forwardEvent(ctx context.Context, receivers []loki.LogsReceiver, e Event) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		c.sinkError(sinkLoki, err)
+		return
+	}
+
+	entry := loki.Entry{
+		Labels: model.LabelSet{
+			jobLabel:       jobLabelValue,
+			eventTypeLabel: model.LabelValue(e.Type),
+		},
+		Entry: logproto.Entry{
+			Timestamp: e.Time,
+			Line:      string(line),
+		},
+	}
+
+	for _, receiver := range receivers {
+		select {
+		case <-ctx.Done():
+			return
+		case receiver <- entry:
+		case <-time.After(forwardTimeout):
+			c.sinkError(sinkLoki, fmt.Errorf("receiver didn't accept the event within %s", forwardTimeout))
+		}
+	}
+}
+
+// postEvent posts e as JSON to w.
+func (c *core) postEvent(ctx context.Context, w *webhook, e Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		c.sinkError(sinkWebhook, err)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		c.sinkError(sinkWebhook, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		c.sinkError(sinkWebhook, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		c.sinkError(sinkWebhook, fmt.Errorf("webhook %s returned status %s", w.url, resp.Status))
+	}
+}
+
+func (c *core) sinkError(sink string, err error) {
+	c.metrics.sinkErrors.WithLabelValues(sink).Inc()
+	level.Warn(c.log).Log("msg", "failed to deliver event", "sink", sink, "err", err)
+}
@@ -49,13 +49,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/flow/events"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
 	"github.com/grafana/agent/pkg/flow/internal/stdlib"
+	"github.com/grafana/agent/pkg/flow/limits"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/flow/tracing"
 	"github.com/grafana/agent/pkg/river/vm"
@@ -102,6 +108,12 @@ type Options struct {
 	// different value for HTTPPathPrefix to prevent components from colliding.
 	HTTPPathPrefix string
 
+	// PeerHTTPPathPrefix is the path prefix where other agents of the cluster
+	// reach managed components. May be empty if no such route is served.
+	// Like HTTPPathPrefix, components are given a path relative to it using
+	// their local ID.
+	PeerHTTPPathPrefix string
+
 	// HTTPListenAddr is the base address that the server is listening on.
 	// The controller does not itself listen here, but some components
 	// need to know this to set the correct targets.
@@ -112,14 +124,63 @@ type Options struct {
 	// OnExportsChange is nil, export configuration blocks are not allowed in the
 	// loaded config file.
 	OnExportsChange func(exports map[string]any)
+
+	// Cluster is the cluster of agents the process is a member of, passed to
+	// components so they can distribute work. If nil, a single-node cluster
+	// containing only the local agent is used.
+	Cluster cluster.Node
+
+	// Limits tracks the components loaded by the controller against limits.
+	// Controllers for modules must use a child of the Limits of the parent
+	// controller. No limits are enforced if Limits is nil.
+	Limits *limits.Tracker
+
+	// ExportDebounce is the minimum time between re-evaluations of the
+	// components which depend on a component whose exports changed. Export
+	// changes within the window are coalesced into a single re-evaluation at
+	// the end of the window. Export changes are never delayed if
+	// ExportDebounce is 0.
+	ExportDebounce time.Duration
+
+	// ShutdownTimeout is the maximum time to wait for components to shut down
+	// when the controller stops. Components which haven't exited by then are
+	// reported and left to exit in the background. There is no deadline if
+	// ShutdownTimeout is 0.
+	ShutdownTimeout time.Duration
+
+	// MaxEvaluationDuration is the maximum time building or updating a single
+	// component may take. Components can't be interrupted, so an evaluation
+	// which exceeds it runs to completion but is treated as failed.
+	// There is no maximum if MaxEvaluationDuration is 0.
+	MaxEvaluationDuration time.Duration
+
+	// Events is the bus where the controller and its components publish
+	// operational events. Controllers for modules must use the Events of the
+	// component loading the module. If Events is nil, a bus is created which
+	// delivers events while the controller runs.
+	Events *events.Bus
+
+	// ReadOnly prevents the controller from loading components which can
+	// execute commands on the host, so that the agent can't be made to run
+	// commands by changing its config. Controllers for modules must use the
+	// ReadOnly setting of the component loading the module.
+	ReadOnly bool
+
+	// DryRun prevents the controller from building Listener components, for
+	// controllers which are only used for DryRun. Controllers for modules must
+	// use the DryRun setting of the component loading the module.
+	DryRun bool
 }
 
 // Flow is the Flow system.
 type Flow struct {
 	log    *logging.Logger
 	tracer *tracing.Tracer
+	events *events.Bus
 	opts   Options
 
+	ownsEvents bool // Whether the controller created events and must run it.
+
 	updateQueue *controller.Queue
 	sched       *controller.Scheduler
 	loader      *controller.Loader
@@ -128,6 +189,7 @@ type Flow struct {
 
 	loadMut    sync.RWMutex
 	loadedOnce atomic.Bool
+	files      *stdlib.Files // Files read by the file function since the last load.
 }
 
 // New creates and starts a new Flow controller. Call Close to stop
@@ -138,6 +200,16 @@ func New(o Options) *Flow {
 		tracer = o.Tracer
 	)
 
+	clusterNode := o.Cluster
+	if clusterNode == nil {
+		clusterNode = cluster.NewLocalNode(o.HTTPListenAddr)
+	}
+
+	ownsEvents := o.Events == nil
+	if ownsEvents {
+		o.Events = events.New(log, o.Reg)
+	}
+
 	if tracer == nil {
 		var err error
 		tracer, err = tracing.New(tracing.DefaultOptions)
@@ -159,36 +231,64 @@ func New(o Options) *Flow {
 				// Changed components should be queued for reevaluation.
 				queue.Enqueue(cn)
 			},
-			OnExportsChange: o.OnExportsChange,
-			Registerer:      o.Reg,
-			HTTPPathPrefix:  o.HTTPPathPrefix,
-			HTTPListenAddr:  o.HTTPListenAddr,
-			ControllerID:    o.ControllerID,
+			OnExportsChange:       o.OnExportsChange,
+			Registerer:            o.Reg,
+			HTTPPathPrefix:        o.HTTPPathPrefix,
+			PeerHTTPPathPrefix:    o.PeerHTTPPathPrefix,
+			HTTPListenAddr:        o.HTTPListenAddr,
+			ControllerID:          o.ControllerID,
+			Cluster:               clusterNode,
+			Limits:                o.Limits,
+			ExportDebounce:        o.ExportDebounce,
+			MaxEvaluationDuration: o.MaxEvaluationDuration,
+			Events:                o.Events,
+			ReadOnly:              o.ReadOnly,
+			DryRun:                o.DryRun,
 		})
 	)
 
 	return &Flow{
 		log:    log,
 		tracer: tracer,
+		events: o.Events,
 		opts:   o,
 
+		ownsEvents: ownsEvents,
+
 		updateQueue: queue,
 		sched:       sched,
 		loader:      loader,
 
 		loadFinished: make(chan struct{}, 1),
+		files:        stdlib.NewFiles(),
 	}
 }
 
 // Run starts the Flow controller, blocking until the provided context is
 // canceled. Run must only be called once.
 func (c *Flow) Run(ctx context.Context) {
-	defer c.sched.Close()
+	defer c.opts.Limits.Release()
 	defer level.Debug(c.log).Log("msg", "flow controller exiting")
 
+	if c.ownsEvents {
+		// Events are delivered until components are shut down, so that
+		// components crashing during shutdown are reported.
+		eventsCtx, cancel := context.WithCancel(context.Background())
+		eventsDone := make(chan struct{})
+		go func() {
+			defer close(eventsDone)
+			c.events.Run(eventsCtx)
+		}()
+		defer func() {
+			cancel()
+			<-eventsDone
+		}()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
+			c.shutdown()
 			return
 
 		case <-c.updateQueue.Chan():
@@ -221,6 +321,42 @@ func (c *Flow) Run(ctx context.Context) {
 	}
 }
 
+// shutdown stops all running components in the order given by
+// Loader.ShutdownOrder: components which receive data are stopped first, so
+// that the components they forward data to can flush everything they
+// buffered before they're stopped themselves.
+func (c *Flow) shutdown() {
+	ctx := context.Background()
+	if c.opts.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.ShutdownTimeout)
+		defer cancel()
+	}
+
+	var pending []string
+	for _, stage := range c.loader.ShutdownOrder() {
+		ids := make([]string, 0, len(stage))
+		for _, cn := range stage {
+			ids = append(ids, cn.NodeID())
+		}
+
+		// Once the deadline passed, the remaining components are stopped
+		// without waiting for them.
+		pending = append(pending, c.sched.Stop(ctx, ids)...)
+	}
+
+	if len(pending) > 0 {
+		level.Warn(c.log).Log(
+			"msg", "components did not shut down before the shutdown timeout; data they buffered may be lost",
+			"timeout", c.opts.ShutdownTimeout,
+			"components", strings.Join(pending, ","),
+		)
+		go func() { _ = c.sched.Close() }()
+		return
+	}
+	_ = c.sched.Close()
+}
+
 // LoadFile synchronizes the state of the controller with the current config
 // file. Components in the graph will be marked as unhealthy if there was an
 // error encountered during Load.
@@ -248,6 +384,10 @@ func (c *Flow) LoadFile(file *File, args map[string]any) error {
 		evaluatedArgs[arg.Name] = map[string]any{"value": val}
 	}
 
+	// Files are tracked from scratch for every load so that files which are no
+	// longer used by the config aren't reported as changed.
+	c.files = stdlib.NewFiles()
+
 	argumentScope := &vm.Scope{
 		// The top scope is the Flow-specific stdlib.
 		Parent: &vm.Scope{
@@ -255,10 +395,23 @@ func (c *Flow) LoadFile(file *File, args map[string]any) error {
 		},
 		Variables: map[string]interface{}{
 			"argument": evaluatedArgs,
+			"file":     c.files.Read,
 		},
 	}
 
 	diags := c.loader.Apply(argumentScope, file.Components, file.ConfigBlocks)
+	if err := diags.ErrorOrNil(); err != nil {
+		c.events.Publish(events.Event{
+			Type:    events.TypeReloadFailed,
+			Message: fmt.Sprintf("failed to load config: %s", err),
+		})
+	} else {
+		c.events.Publish(events.Event{
+			Type:    events.TypeReloadApplied,
+			Message: "config loaded",
+			Fields:  map[string]string{"components": strconv.Itoa(len(c.loader.Components()))},
+		})
+	}
 	if !c.loadedOnce.Load() && diags.HasErrors() {
 		// The first call to Load should not run any components if there were
 		// errors in the configuration file.
@@ -274,11 +427,40 @@ func (c *Flow) LoadFile(file *File, args map[string]any) error {
 	return diags.ErrorOrNil()
 }
 
+// ChangedFiles returns the sorted paths of files read with the file function
+// whose contents changed since they were read. Loading the config file again
+// reads the changed files.
+func (c *Flow) ChangedFiles() []string {
+	c.loadMut.RLock()
+	defer c.loadMut.RUnlock()
+	return c.files.Changed()
+}
+
 // Ready returns whether the Flow controller has finished its initial load.
 func (c *Flow) Ready() bool {
 	return c.loadedOnce.Load()
 }
 
+// CheckHealthy returns an error if any of the components with the given IDs
+// doesn't exist or isn't healthy. The error describes the first such
+// component.
+func (c *Flow) CheckHealthy(ids []string) error {
+	c.loadMut.RLock()
+	defer c.loadMut.RUnlock()
+
+	g := c.loader.Graph()
+	for _, id := range ids {
+		cn, ok := g.GetByID(id).(*controller.ComponentNode)
+		if !ok {
+			return fmt.Errorf("component %s does not exist", id)
+		}
+		if h := cn.CurrentHealth(); h.Health != component.HealthTypeHealthy {
+			return fmt.Errorf("component %s is %s: %s", id, h.Health, h.Message)
+		}
+	}
+	return nil
+}
+
 // ComponentInfos returns the component infos.
 func (c *Flow) ComponentInfos() []*ComponentInfo {
 	c.loadMut.RLock()
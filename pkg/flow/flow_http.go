@@ -1,12 +1,15 @@
 package flow
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/grafana/agent/pkg/river/encoding"
 
@@ -21,14 +24,7 @@ func (f *Flow) ComponentHandler() http.HandlerFunc {
 		vars := mux.Vars(r)
 		id := vars["id"]
 
-		// find node with ID
-		var node *controller.ComponentNode
-		for _, n := range f.loader.Components() {
-			if n.ID().String() == id {
-				node = n
-				break
-			}
-		}
+		node := f.getComponent(id)
 		if node == nil {
 			w.WriteHeader(http.StatusNotFound)
 			return
@@ -46,6 +42,202 @@ func (f *Flow) ComponentHandler() http.HandlerFunc {
 	}
 }
 
+// getComponent returns the component with the given ID, or nil if it
+// doesn't exist.
+func (f *Flow) getComponent(id string) *controller.ComponentNode {
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+
+	cn, _ := f.loader.Graph().GetByID(id).(*controller.ComponentNode)
+	return cn
+}
+
+// Limits for requests made to TapHandler.
+var tapLimits = streamLimits{
+	DefaultDuration: 10 * time.Second,
+	MaxDuration:     5 * time.Minute,
+	DefaultLimit:    1000,
+}
+
+// TapHandler returns an http.HandlerFunc which streams a sample of the records
+// flowing through the component named by the id path variable. Records are
+// written as newline-delimited text until the duration query parameter
+// (default 10s) elapses or limit records (default 1000) have been written.
+//
+// Records are dropped rather than slowing down the component when the client
+// can't keep up.
+func (f *Flow) TapHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		node := f.getComponent(id)
+		if node == nil {
+			http.Error(w, fmt.Sprintf("component %q not found", id), http.StatusNotFound)
+			return
+		}
+		tc := node.TapComponent()
+		if tc == nil {
+			http.Error(w, fmt.Sprintf("component %q does not support tapping", id), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		serveStream(w, r, tapLimits, 100, tc.Tap, func(record string) error {
+			_, err := fmt.Fprintln(w, record)
+			return err
+		})
+	}
+}
+
+// Limits for requests made to CaptureHandler.
+var captureLimits = streamLimits{
+	DefaultDuration: time.Minute,
+	MaxDuration:     time.Hour,
+	DefaultLimit:    10000,
+}
+
+// CaptureHandler returns an http.HandlerFunc which records the data received
+// by the component named by the id path variable, so it can be replayed
+// later. Records are written as JSON lines in the format of DryRunInput until
+// the duration query parameter (default 1m) elapses or limit records (default
+// 10000) have been written.
+//
+// Records are dropped rather than slowing down the component when the client
+// can't keep up.
+func (f *Flow) CaptureHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		node := f.getComponent(id)
+		if node == nil {
+			http.Error(w, fmt.Sprintf("component %q not found", id), http.StatusNotFound)
+			return
+		}
+		cc := node.CaptureComponent()
+		if cc == nil {
+			http.Error(w, fmt.Sprintf("component %q does not support capturing", id), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		serveStream(w, r, captureLimits, 1000, cc.Capture, func(input json.RawMessage) error {
+			return enc.Encode(DryRunInput{Component: id, Input: input})
+		})
+	}
+}
+
+// streamLimits holds the defaults and maximums of the query parameters
+// accepted by serveStream.
+type streamLimits struct {
+	DefaultDuration time.Duration
+	MaxDuration     time.Duration
+	DefaultLimit    int
+}
+
+// serveStream writes the records sent by run to w until the duration query
+// parameter of r elapses or the limit query parameter of r is reached. run is
+// called in a new goroutine and must return once ctx is canceled. Up to
+// buffer records are queued; records are dropped when the client can't keep
+// up.
+func serveStream[T any](
+	w http.ResponseWriter, r *http.Request, limits streamLimits, buffer int,
+	run func(ctx context.Context, send func(T)), write func(T) error,
+) {
+	duration := limits.DefaultDuration
+	if raw := r.URL.Query().Get("duration"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration %q", raw), http.StatusBadRequest)
+			return
+		}
+		duration = d
+	}
+	if duration > limits.MaxDuration {
+		duration = limits.MaxDuration
+	}
+
+	limit := limits.DefaultLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		l, err := strconv.Atoi(raw)
+		if err != nil || l <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", raw), http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), duration)
+	defer cancel()
+
+	records := make(chan T, buffer)
+	go run(ctx, func(record T) {
+		select {
+		case records <- record:
+		default:
+			// Drop the record; the client isn't keeping up.
+		}
+	})
+
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	for written := 0; written < limit; written++ {
+		select {
+		case <-ctx.Done():
+			return
+		case record := <-records:
+			if err := write(record); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// EvaluationTrigger describes why a component was re-evaluated.
+type EvaluationTrigger struct {
+	// Time the re-evaluation started.
+	Time time.Time `json:"time"`
+
+	// Chain of node IDs which led to the re-evaluation, starting with the
+	// component which updated its exports and ending with the re-evaluated
+	// component.
+	Chain []string `json:"chain"`
+}
+
+// EvaluationsHandler returns an http.HandlerFunc which writes the most recent
+// re-evaluations of the component named by the id path variable as JSON,
+// newest first. Each re-evaluation includes the chain of components whose
+// export changes caused it.
+func (f *Flow) EvaluationsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		node := f.getComponent(id)
+		if node == nil {
+			http.Error(w, fmt.Sprintf("component %q not found", id), http.StatusNotFound)
+			return
+		}
+
+		triggers := node.EvaluationTriggers()
+		res := make([]EvaluationTrigger, 0, len(triggers))
+		for _, t := range triggers {
+			res = append(res, EvaluationTrigger{Time: t.Time, Chain: t.Chain})
+		}
+
+		bb, err := json.Marshal(res)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bb)
+	}
+}
+
 // ComponentJSON returns the json representation of the flow component.
 func (f *Flow) ComponentJSON(w io.Writer, ci *ComponentInfo) error {
 	f.loadMut.RLock()
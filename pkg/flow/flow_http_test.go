@@ -0,0 +1,67 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeStream(t *testing.T) {
+	limits := streamLimits{
+		DefaultDuration: time.Second,
+		MaxDuration:     time.Second,
+		DefaultLimit:    3,
+	}
+
+	// run sends 5 records and then waits for the stream to end.
+	run := func(ctx context.Context, send func(int)) {
+		for i := 0; i < 5; i++ {
+			send(i)
+		}
+		<-ctx.Done()
+	}
+
+	tt := []struct {
+		name       string
+		query      string
+		expectCode int
+		expectBody string
+	}{
+		{name: "default limit", expectCode: http.StatusOK, expectBody: "0\n1\n2\n"},
+		{name: "limit", query: "?limit=1", expectCode: http.StatusOK, expectBody: "0\n"},
+		{name: "duration elapses", query: "?limit=10&duration=50ms", expectCode: http.StatusOK, expectBody: "0\n1\n2\n3\n4\n"},
+		{name: "invalid duration", query: "?duration=-1s", expectCode: http.StatusBadRequest, expectBody: "invalid duration \"-1s\"\n"},
+		{name: "invalid limit", query: "?limit=zero", expectCode: http.StatusBadRequest, expectBody: "invalid limit \"zero\"\n"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/stream"+tc.query, nil)
+			rec := httptest.NewRecorder()
+
+			serveStream(rec, req, limits, 10, run, func(record int) error {
+				_, err := fmt.Fprintln(rec, record)
+				return err
+			})
+			require.Equal(t, tc.expectCode, rec.Code)
+			require.Equal(t, tc.expectBody, rec.Body.String())
+		})
+	}
+}
+
+func TestController_getComponent(t *testing.T) {
+	f, err := ReadFile(t.Name(), []byte(testFile))
+	require.NoError(t, err)
+	require.NotNil(t, f)
+
+	ctrl := New(testOptions(t))
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	require.NotNil(t, ctrl.getComponent("testcomponents.passthrough.static"))
+	require.Nil(t, ctrl.getComponent("testcomponents.passthrough.missing"))
+}
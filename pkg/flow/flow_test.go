@@ -1,10 +1,18 @@
 package flow
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/events"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
 	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
@@ -49,6 +57,121 @@ func TestController_LoadFile_Evaluation(t *testing.T) {
 	require.Equal(t, "hello, world!", out.(testcomponents.PassthroughExports).Output)
 }
 
+func TestController_ChangedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input")
+	require.NoError(t, os.WriteFile(path, []byte("hello, world!"), 0644))
+
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(fmt.Sprintf(`
+		testcomponents.passthrough "static" {
+			input = file(%q)
+		}
+	`, path)))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	in, _ := getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.static")
+	require.Equal(t, "hello, world!", in.(testcomponents.PassthroughConfig).Input)
+	require.Empty(t, ctrl.ChangedFiles())
+
+	require.NoError(t, os.WriteFile(path, []byte("goodbye, world!"), 0644))
+	require.Equal(t, []string{path}, ctrl.ChangedFiles())
+
+	// Loading the file again reads the new contents.
+	require.NoError(t, ctrl.LoadFile(f, nil))
+	in, _ = getFields(t, ctrl.loader.Graph(), "testcomponents.passthrough.static")
+	require.Equal(t, "goodbye, world!", in.(testcomponents.PassthroughConfig).Input)
+	require.Empty(t, ctrl.ChangedFiles())
+}
+
+func TestController_CheckHealthy(t *testing.T) {
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(testFile))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	// Components are healthy once they've been evaluated.
+	require.NoError(t, ctrl.CheckHealthy(nil))
+	require.NoError(t, ctrl.CheckHealthy([]string{"testcomponents.passthrough.static"}))
+	require.EqualError(t, ctrl.CheckHealthy([]string{"testcomponents.passthrough.missing"}), "component testcomponents.passthrough.missing does not exist")
+}
+
+func TestController_Shutdown(t *testing.T) {
+	opts := testOptions(t)
+	opts.ShutdownTimeout = 5 * time.Second
+	ctrl := New(opts)
+
+	f, err := ReadFile(t.Name(), []byte(testFile))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctrl.Run(ctx)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(opts.ShutdownTimeout):
+		t.Fatal("controller did not shut down")
+	}
+}
+
+func TestController_Events(t *testing.T) {
+	received := make(chan events.Event, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e events.Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err == nil {
+			received <- e
+		}
+	}))
+	defer srv.Close()
+
+	ctrl := New(testOptions(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctrl.Run(ctx)
+
+	eventsBlock := fmt.Sprintf(`
+		events {
+			log = false
+			webhook {
+				url = %q
+			}
+		}
+	`, srv.URL)
+
+	f, err := ReadFile(t.Name(), []byte(eventsBlock+testFile))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+	e := receiveEvent(t, received)
+	require.Equal(t, events.TypeReloadApplied, e.Type)
+	require.Equal(t, "4", e.Fields["components"])
+
+	f, err = ReadFile(t.Name(), []byte(eventsBlock+`testcomponents.passthrough "static" { input = missing.value }`))
+	require.NoError(t, err)
+	require.Error(t, ctrl.LoadFile(f, nil))
+	require.Equal(t, events.TypeReloadFailed, receiveEvent(t, received).Type)
+}
+
+func receiveEvent(t *testing.T, ch chan events.Event) events.Event {
+	t.Helper()
+
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event was delivered")
+		return events.Event{}
+	}
+}
+
 func getFields(t *testing.T, g *dag.Graph, nodeID string) (component.Arguments, component.Exports) {
 	t.Helper()
 
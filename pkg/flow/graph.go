@@ -0,0 +1,177 @@
+package flow
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/flow/internal/stdlib"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/river/vm"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GraphInfo describes the dependency graph between components and config
+// blocks in a Flow controller.
+type GraphInfo struct {
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+}
+
+// GraphNode is a single node in a GraphInfo.
+type GraphNode struct {
+	ID   string `json:"id"`
+	Type string `json:"type"` // "component" or "block"
+	Name string `json:"name,omitempty"`
+
+	// Orphaned is true for nodes which neither reference nor are referenced by
+	// any other node.
+	Orphaned bool `json:"orphaned"`
+}
+
+// GraphEdge is a dependency between two nodes in a GraphInfo. From depends on
+// To: the Argument of From is (partially) computed from the Export of To.
+type GraphEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Argument string `json:"argument,omitempty"`
+	Export   string `json:"export,omitempty"`
+}
+
+// GraphInfo returns the current dependency graph of the controller.
+func (c *Flow) GraphInfo() *GraphInfo {
+	c.loadMut.RLock()
+	defer c.loadMut.RUnlock()
+
+	return newGraphInfo(c.loader.OriginalGraph(), c.loader.References())
+}
+
+// BuildGraph parses the dependency graph of file without building or
+// evaluating any of its components. Diagnostics are returned if the graph is
+// invalid, such as when it contains a cycle or references an unknown
+// component.
+func BuildGraph(file *File) (*GraphInfo, error) {
+	argNames := make(map[string]any, len(file.Arguments))
+	for _, arg := range file.Arguments {
+		argNames[arg.Name] = map[string]any{"value": arg.Default}
+	}
+
+	scope := &vm.Scope{
+		Parent: &vm.Scope{
+			Variables: stdlib.Identifiers,
+		},
+		Variables: map[string]interface{}{
+			"argument": argNames,
+		},
+	}
+
+	loader := controller.NewLoader(controller.ComponentGlobals{
+		Logger:            logging.New(nil),
+		TraceProvider:     trace.NewNoopTracerProvider(),
+		OnComponentUpdate: func(cn *controller.ComponentNode) { /* no-op */ },
+	})
+	diags := loader.BuildGraph(scope, file.Components, file.ConfigBlocks)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return newGraphInfo(loader.OriginalGraph(), loader.References()), nil
+}
+
+func newGraphInfo(g *dag.Graph, refs map[string][]controller.Reference) *GraphInfo {
+	var (
+		gi        GraphInfo
+		connected = make(map[string]struct{})
+	)
+
+	for from, nodeRefs := range refs {
+		seen := make(map[GraphEdge]struct{}, len(nodeRefs))
+
+		for _, ref := range nodeRefs {
+			export := make([]string, 0, len(ref.Traversal))
+			for _, ident := range ref.Traversal {
+				export = append(export, ident.Name)
+			}
+
+			edge := GraphEdge{
+				From:     from,
+				To:       ref.Target.NodeID(),
+				Argument: ref.Argument,
+				Export:   strings.Join(export, "."),
+			}
+			if _, dup := seen[edge]; dup {
+				continue
+			}
+			seen[edge] = struct{}{}
+
+			gi.Edges = append(gi.Edges, &edge)
+			connected[edge.From] = struct{}{}
+			connected[edge.To] = struct{}{}
+		}
+	}
+
+	for _, n := range g.Nodes() {
+		gn := &GraphNode{ID: n.NodeID(), Type: "block"}
+		if cn, ok := n.(*controller.ComponentNode); ok {
+			gn.Type = "component"
+			gn.Name = cn.ComponentName()
+		}
+		_, isConnected := connected[gn.ID]
+		gn.Orphaned = !isConnected
+
+		gi.Nodes = append(gi.Nodes, gn)
+	}
+
+	sort.Slice(gi.Nodes, func(i, j int) bool { return gi.Nodes[i].ID < gi.Nodes[j].ID })
+	sort.Slice(gi.Edges, func(i, j int) bool {
+		a, b := gi.Edges[i], gi.Edges[j]
+		switch {
+		case a.From != b.From:
+			return a.From < b.From
+		case a.To != b.To:
+			return a.To < b.To
+		case a.Argument != b.Argument:
+			return a.Argument < b.Argument
+		default:
+			return a.Export < b.Export
+		}
+	})
+
+	return &gi
+}
+
+// WriteDOT writes gi to w in the Graphviz DOT format. Edges are labeled with
+// the argument and export which create the dependency.
+func (gi *GraphInfo) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+
+	sb.WriteString("digraph {\n")
+	sb.WriteString("\trankdir=\"LR\"\n")
+	for _, n := range gi.Nodes {
+		shape := "box"
+		if n.Type != "component" {
+			shape = "ellipse"
+		}
+		fmt.Fprintf(&sb, "\t%q [shape=%s]\n", n.ID, shape)
+	}
+	for _, e := range gi.Edges {
+		// Edges point from the node providing a value to the node consuming
+		// it so rendered diagrams read in the direction data flows.
+		var label string
+		switch {
+		case e.Export != "" && e.Argument != "":
+			label = e.Export + " -> " + e.Argument
+		case e.Export != "":
+			label = e.Export
+		default:
+			label = e.Argument
+		}
+		fmt.Fprintf(&sb, "\t%q -> %q [label=%q]\n", e.To, e.From, label)
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
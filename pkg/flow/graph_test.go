@@ -0,0 +1,93 @@
+package flow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildGraph(t *testing.T) {
+	f, err := ReadFile(t.Name(), []byte(testFile))
+	require.NoError(t, err)
+
+	gi, err := BuildGraph(f)
+	require.NoError(t, err)
+
+	var componentIDs []string
+	for _, n := range gi.Nodes {
+		if n.Type == "component" {
+			componentIDs = append(componentIDs, n.ID)
+		}
+	}
+	require.Equal(t, []string{
+		"testcomponents.passthrough.forwarded",
+		"testcomponents.passthrough.static",
+		"testcomponents.passthrough.ticker",
+		"testcomponents.tick.ticker",
+	}, componentIDs)
+
+	require.Equal(t, []*GraphEdge{
+		{
+			From:     "testcomponents.passthrough.forwarded",
+			To:       "testcomponents.passthrough.ticker",
+			Argument: "input",
+			Export:   "output",
+		},
+		{
+			From:     "testcomponents.passthrough.ticker",
+			To:       "testcomponents.tick.ticker",
+			Argument: "input",
+			Export:   "tick_time",
+		},
+	}, gi.Edges)
+
+	for _, n := range gi.Nodes {
+		expectOrphan := n.ID != "testcomponents.passthrough.forwarded" &&
+			n.ID != "testcomponents.passthrough.ticker" &&
+			n.ID != "testcomponents.tick.ticker"
+		require.Equal(t, expectOrphan, n.Orphaned, "unexpected orphan state for %s", n.ID)
+	}
+}
+
+func TestBuildGraph_Cycle(t *testing.T) {
+	f, err := ReadFile(t.Name(), []byte(`
+		testcomponents.passthrough "a" {
+			input = testcomponents.passthrough.b.output
+		}
+
+		testcomponents.passthrough "b" {
+			input = testcomponents.passthrough.a.output
+		}
+	`))
+	require.NoError(t, err)
+
+	_, err = BuildGraph(f)
+	require.ErrorContains(t, err, "cycle")
+}
+
+func TestGraphInfo_WriteDOT(t *testing.T) {
+	gi := &GraphInfo{
+		Nodes: []*GraphNode{
+			{ID: "a", Type: "component"},
+			{ID: "b", Type: "component"},
+			{ID: "logging", Type: "block"},
+		},
+		Edges: []*GraphEdge{
+			{From: "b", To: "a", Argument: "input", Export: "output"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, gi.WriteDOT(&buf))
+
+	expect := `digraph {
+	rankdir="LR"
+	"a" [shape=box]
+	"b" [shape=box]
+	"logging" [shape=ellipse]
+	"a" -> "b" [label="output -> input"]
+}
+`
+	require.Equal(t, expect, buf.String())
+}
@@ -8,16 +8,23 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"runtime/pprof"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/flow/events"
+	"github.com/grafana/agent/pkg/flow/limits"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/grafana/agent/pkg/river/vm"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rfratto/ckit/peer"
+	"github.com/rfratto/ckit/shard"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/atomic"
 )
@@ -58,16 +65,24 @@ func (id ComponentID) Equals(other ComponentID) bool {
 // ComponentGlobals are used by ComponentNodes to build managed components. All
 // ComponentNodes should use the same ComponentGlobals.
 type ComponentGlobals struct {
-	LogSink           *logging.Sink                // Sink used for Logging.
-	Logger            *logging.Logger              // Logger shared between all managed components.
-	TraceProvider     trace.TracerProvider         // Tracer shared between all managed components.
-	DataPath          string                       // Shared directory where component data may be stored
-	OnComponentUpdate func(cn *ComponentNode)      // Informs controller that we need to reevaluate
-	OnExportsChange   func(exports map[string]any) // Invoked when the managed component updated its exports
-	Registerer        prometheus.Registerer        // Registerer for serving agent and component metrics
-	HTTPPathPrefix    string                       // HTTP prefix for components.
-	HTTPListenAddr    string                       // Base address for server
-	ControllerID      string                       // ID of controller.
+	LogSink               *logging.Sink                // Sink used for Logging.
+	Logger                *logging.Logger              // Logger shared between all managed components.
+	TraceProvider         trace.TracerProvider         // Tracer shared between all managed components.
+	DataPath              string                       // Shared directory where component data may be stored
+	OnComponentUpdate     func(cn *ComponentNode)      // Informs controller that we need to reevaluate
+	OnExportsChange       func(exports map[string]any) // Invoked when the managed component updated its exports
+	Registerer            prometheus.Registerer        // Registerer for serving agent and component metrics
+	HTTPPathPrefix        string                       // HTTP prefix for components.
+	PeerHTTPPathPrefix    string                       // HTTP prefix for requests from cluster peers to components.
+	HTTPListenAddr        string                       // Base address for server
+	ControllerID          string                       // ID of controller.
+	Cluster               cluster.Node                 // Cluster the agent is a member of.
+	Limits                *limits.Tracker              // Limits on the number of loaded components.
+	ExportDebounce        time.Duration                // Window for coalescing export changes of a component.
+	MaxEvaluationDuration time.Duration                // Maximum time to build or update a component.
+	Events                *events.Bus                  // Bus where operational events are published.
+	ReadOnly              bool                         // Refuse to load Exec components.
+	DryRun                bool                         // Don't build Listener components.
 }
 
 // ComponentNode is a controller node which manages a user-defined component.
@@ -91,8 +106,26 @@ type ComponentNode struct {
 	eval    *vm.Evaluator
 	managed component.Component // Inner managed component
 	args    component.Arguments // Evaluated arguments for the managed component
+	staged  *stagedEval         // Evaluation waiting to be committed
+	undo    *undoCommit         // State before the last commit, used by Rollback
+
+	// httpDefaults are the HTTP client defaults the managed component was last
+	// built or updated with.
+	httpDefaults config.HTTPDefaults
+
+	cluster      cluster.Node // Cluster used to elect leaders for leader-only components
+	leaderChange func()       // Set while running; notifies Run to re-check leadership
+	observing    bool         // Set while an observer of cluster is registered
+
+	exportDebounce  time.Duration // Minimum time between calls to OnComponentUpdate
+	maxEvalDuration time.Duration // Maximum time to build or update the managed component
+
+	debounceMut   sync.Mutex
+	lastUpdate    time.Time   // Last time OnComponentUpdate was called
+	pendingUpdate *time.Timer // Set while a coalesced call to OnComponentUpdate is scheduled
 
 	doingEval atomic.Bool
+	evalTime  atomic.Duration // Total time spent evaluating the component.
 
 	// NOTE(rfratto): health and exports have their own mutex because they may be
 	// set asynchronously while mut is still being held (i.e., when calling Evaluate
@@ -104,6 +137,25 @@ type ComponentNode struct {
 
 	exportsMut sync.RWMutex
 	exports    component.Exports // Evaluated exports for the managed component
+
+	triggersMut sync.RWMutex
+	triggers    []EvaluationTrigger // Most recent re-evaluations, oldest first
+}
+
+// maxEvaluationTriggers is the number of recent re-evaluations tracked for
+// each ComponentNode.
+const maxEvaluationTriggers = 10
+
+// EvaluationTrigger describes a re-evaluation of a component which was caused
+// by another component updating its exports.
+type EvaluationTrigger struct {
+	// Time the re-evaluation started.
+	Time time.Time
+
+	// Chain of node IDs which led to the re-evaluation. The first element is
+	// the component which updated its exports, and the last element is the
+	// re-evaluated node.
+	Chain []string
 }
 
 var _ BlockNode = (*ComponentNode)(nil)
@@ -139,8 +191,12 @@ func NewComponentNode(globals ComponentGlobals, b *ast.BlockStmt) *ComponentNode
 		exportsType:       getExportsType(reg),
 		OnComponentUpdate: globals.OnComponentUpdate,
 
-		block: b,
-		eval:  vm.New(b.Body),
+		block:   b,
+		eval:    vm.New(b.Body),
+		cluster: globals.Cluster,
+
+		exportDebounce:  globals.ExportDebounce,
+		maxEvalDuration: globals.MaxEvaluationDuration,
 
 		// Prepopulate arguments and exports with their zero values.
 		args:    reg.Args,
@@ -171,6 +227,11 @@ func getManagedOptions(globals ComponentGlobals, cn *ComponentNode) component.Op
 		globalID = path.Join(globals.ControllerID, cn.nodeID)
 	}
 
+	var peerPath string
+	if globals.PeerHTTPPathPrefix != "" {
+		peerPath = path.Join("/", globals.PeerHTTPPathPrefix, cn.nodeID) + "/"
+	}
+
 	wrapped := newWrappedRegisterer()
 	cn.register = wrapped
 	return component.Options{
@@ -181,9 +242,17 @@ func getManagedOptions(globals ComponentGlobals, cn *ComponentNode) component.Op
 		}, wrapped),
 		Tracer: wrapTracer(globals.TraceProvider, globalID),
 
-		DataPath:       filepath.Join(globals.DataPath, cn.nodeID),
-		HTTPListenAddr: globals.HTTPListenAddr,
-		HTTPPath:       path.Join(prefix, cn.nodeID) + "/",
+		DataPath:              filepath.Join(globals.DataPath, cn.nodeID),
+		HTTPListenAddr:        globals.HTTPListenAddr,
+		HTTPPath:              path.Join(prefix, cn.nodeID) + "/",
+		PeerHTTPPath:          peerPath,
+		Cluster:               globals.Cluster,
+		Limits:                globals.Limits,
+		ExportDebounce:        globals.ExportDebounce,
+		MaxEvaluationDuration: globals.MaxEvaluationDuration,
+		Events:                globals.Events.WithSource(globalID),
+		ReadOnly:              globals.ReadOnly,
+		DryRun:                globals.DryRun,
 
 		OnStateChange: cn.setExports,
 	}
@@ -251,9 +320,59 @@ func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
 	cn.mut.Lock()
 	defer cn.mut.Unlock()
 
+	if err := cn.stage(scope); err != nil {
+		return err
+	}
+	err := cn.commit()
+	cn.undo = nil
+	return err
+}
+
+// stagedEval holds the result of evaluating a ComponentNode which hasn't been
+// applied to the managed component yet.
+type stagedEval struct {
+	args         component.Arguments
+	httpDefaults config.HTTPDefaults // HTTP client defaults at the time of evaluation.
+	managed      component.Component // Set if a new managed component was built.
+	exports      component.Exports   // Exports from before managed was built.
+}
+
+// undoCommit holds the state of a ComponentNode before its last commit.
+type undoCommit struct {
+	args         component.Arguments
+	httpDefaults config.HTTPDefaults
+	built        bool // True if the commit set a newly built managed component.
+}
+
+// Stage evaluates the River block of cn with the provided scope without
+// updating the managed component. The managed component is built if it
+// doesn't exist yet, but it isn't used until Commit is called.
+//
+// Stage returns an error if the River block cannot be evaluated or if the
+// managed component fails to build. Staged evaluations which aren't
+// committed must be thrown away with Discard.
+func (cn *ComponentNode) Stage(scope *vm.Scope) error {
+	cn.mut.Lock()
+	err := cn.stage(scope)
+	cn.mut.Unlock()
+
+	if err != nil {
+		msg := fmt.Sprintf("component evaluation failed: %s", err)
+		cn.setEvalHealth(component.HealthTypeUnhealthy, msg)
+	}
+	return err
+}
+
+// stage evaluates the River block of cn into cn.staged. cn.mut must be held.
+func (cn *ComponentNode) stage(scope *vm.Scope) error {
+	start := time.Now()
+	defer func() { cn.evalTime.Add(time.Since(start)) }()
+
 	cn.doingEval.Store(true)
 	defer cn.doingEval.Store(false)
 
+	cn.staged = nil
+
 	argsPointer := cn.reg.CloneArguments()
 	if err := cn.eval.Evaluate(scope, argsPointer); err != nil {
 		return fmt.Errorf("decoding River: %w", err)
@@ -262,58 +381,398 @@ func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
 	// args is always a pointer to the args type, so we want to deference it since
 	// components expect a non-pointer.
 	argsCopyValue := reflect.ValueOf(argsPointer).Elem().Interface()
+	staged := &stagedEval{args: argsCopyValue, httpDefaults: config.GetHTTPDefaults()}
 
-	if cn.managed == nil {
+	// Leader-only components are built by Run once this agent is elected as
+	// the leader. Listener components are never built during dry runs.
+	if cn.managed == nil && !isLeaderOnly(argsCopyValue) && !cn.SkipsBuild() {
 		// We haven't built the managed component successfully yet.
+		staged.exports = cn.Exports()
+
 		managed, err := cn.reg.Build(cn.managedOpts, argsCopyValue)
 		if err != nil {
 			return fmt.Errorf("building component: %w", err)
 		}
-		cn.managed = managed
-		cn.args = argsCopyValue
+		if err := cn.checkEvalDuration("building component", start); err != nil {
+			// The component is dropped without ever being run.
+			cn.shutdownUnrun(managed)
+			cn.restoreExports(staged.exports)
+			return err
+		}
+		staged.managed = managed
+	}
+
+	cn.staged = staged
+	return nil
+}
+
+// Commit applies the evaluation from the last call to Stage to the managed
+// component. Commit is a no-op if there is no staged evaluation.
+//
+// After a successful Commit, Rollback may be called to restore the
+// arguments the managed component had before the commit.
+func (cn *ComponentNode) Commit() error {
+	cn.mut.Lock()
+	err := cn.commit()
+	cn.mut.Unlock()
+
+	switch err {
+	case nil:
+		cn.setEvalHealth(component.HealthTypeHealthy, "component evaluated")
+	default:
+		msg := fmt.Sprintf("component evaluation failed: %s", err)
+		cn.setEvalHealth(component.HealthTypeUnhealthy, msg)
+	}
+	return err
+}
 
+// commit applies cn.staged. cn.mut must be held.
+func (cn *ComponentNode) commit() error {
+	staged := cn.staged
+	cn.staged = nil
+	cn.undo = nil
+	if staged == nil {
 		return nil
 	}
 
-	if reflect.DeepEqual(cn.args, argsCopyValue) {
+	start := time.Now()
+	defer func() { cn.evalTime.Add(time.Since(start)) }()
+
+	cn.doingEval.Store(true)
+	defer cn.doingEval.Store(false)
+
+	undo := &undoCommit{args: cn.args, httpDefaults: cn.httpDefaults}
+
+	switch {
+	case cn.managed == nil && staged.managed != nil:
+		cn.managed = staged.managed
+		undo.built = true
+
+	case cn.managed == nil:
+		// Leader-only component which hasn't been built yet; Run will build it
+		// with the new arguments. Listener components of dry runs are never
+		// built.
+
+	case reflect.DeepEqual(cn.args, staged.args) && reflect.DeepEqual(cn.httpDefaults, staged.httpDefaults):
 		// Ignore components which haven't changed. This reduces the cost of
 		// calling evaluate for components where evaluation is expensive (e.g., if
 		// re-evaluating requires re-starting some internal logic).
+		//
+		// Components are still updated when the HTTP client defaults changed,
+		// so that they rebuild their clients with the new defaults.
+		return nil
+
+	default:
+		// Update the existing managed component
+		if err := cn.managed.Update(staged.args); err != nil {
+			return fmt.Errorf("updating component: %w", err)
+		}
+	}
+
+	cn.args = staged.args
+	cn.httpDefaults = staged.httpDefaults
+	cn.undo = undo
+	cn.notifyLeaderChange()
+
+	// The update is kept so that the cached arguments match the managed
+	// component, but it's reported as failed; Rollback can revert it.
+	return cn.checkEvalDuration("updating component", start)
+}
+
+// checkEvalDuration returns an error if an operation which started at start
+// took longer than the maximum evaluation duration. The operation isn't
+// interrupted; it's only reported as failed once it completes.
+func (cn *ComponentNode) checkEvalDuration(op string, start time.Time) error {
+	if cn.maxEvalDuration <= 0 {
 		return nil
 	}
+	if took := time.Since(start); took > cn.maxEvalDuration {
+		return fmt.Errorf("%s took %s, exceeding the maximum evaluation duration of %s", op, took.Round(time.Millisecond), cn.maxEvalDuration)
+	}
+	return nil
+}
+
+// Discard throws away the evaluation from the last call to Stage. A managed
+// component built by Stage is shut down without ever being run.
+func (cn *ComponentNode) Discard() {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	if cn.staged != nil && cn.staged.managed != nil {
+		cn.doingEval.Store(true)
+		cn.shutdownUnrun(cn.staged.managed)
+		cn.doingEval.Store(false)
+
+		cn.restoreExports(cn.staged.exports)
+	}
+	cn.staged = nil
+}
+
+// Rollback restores the managed component to the state it had before the
+// last successful call to Commit. Rollback is a no-op if Commit wasn't
+// called or failed.
+func (cn *ComponentNode) Rollback() error {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
 
-	// Update the existing managed component
-	if err := cn.managed.Update(argsCopyValue); err != nil {
-		return fmt.Errorf("updating component: %w", err)
+	undo := cn.undo
+	cn.undo = nil
+	if undo == nil {
+		return nil
 	}
 
-	cn.args = argsCopyValue
+	cn.doingEval.Store(true)
+	defer cn.doingEval.Store(false)
+
+	switch {
+	case undo.built:
+		// The managed component was built as part of the commit and never ran;
+		// shut it down so it's built again on the next evaluation.
+		cn.shutdownUnrun(cn.managed)
+		cn.managed = nil
+	case cn.managed != nil:
+		if err := cn.managed.Update(undo.args); err != nil {
+			return fmt.Errorf("restoring component arguments: %w", err)
+		}
+	}
+
+	cn.args = undo.args
+	cn.httpDefaults = undo.httpDefaults
+	cn.notifyLeaderChange()
 	return nil
 }
 
+// shutdownUnrun shuts down a managed component which was built but never run.
+// Components release their resources when Run exits, so managed is run with
+// an already canceled context. cn.mut must be held.
+func (cn *ComponentNode) shutdownUnrun(managed component.Component) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := managed.Run(ctx); err != nil {
+		level.Error(cn.managedOpts.Logger).Log("msg", "failed to shut down discarded component", "err", err)
+	}
+}
+
+// restoreExports sets the exports of cn without informing the controller.
+// It's used to revert exports set by a managed component which was built
+// but discarded.
+func (cn *ComponentNode) restoreExports(e component.Exports) {
+	cn.exportsMut.Lock()
+	defer cn.exportsMut.Unlock()
+	cn.exports = e
+}
+
+// notifyLeaderChange informs Run that it needs to re-check whether the
+// managed component should be running. cn.mut must be held.
+func (cn *ComponentNode) notifyLeaderChange() {
+	if cn.leaderChange != nil {
+		cn.leaderChange()
+	}
+}
+
+func isLeaderOnly(args component.Arguments) bool {
+	lo, ok := args.(component.LeaderOnlyArguments)
+	return ok && lo.LeaderOnly()
+}
+
+// SkipsBuild returns true if the managed component is never built because
+// it's a Listener component loaded for a dry run.
+func (cn *ComponentNode) SkipsBuild() bool {
+	return cn.managedOpts.DryRun && cn.reg.Listener
+}
+
+// shouldRun returns true if the managed component should be running on this
+// agent. Components which aren't leader-only always run. Leader-only
+// components run on the cluster peer which owns the component's ID; they
+// don't run anywhere while the owner can't be determined.
+func (cn *ComponentNode) shouldRun() bool {
+	cn.mut.RLock()
+	args := cn.args
+	cn.mut.RUnlock()
+
+	if !isLeaderOnly(args) || cn.cluster == nil {
+		return true
+	}
+
+	peers, err := cn.cluster.Lookup(shard.StringKey(cn.managedOpts.ID), 1, shard.OpReadWrite)
+	if err != nil || len(peers) == 0 {
+		return false
+	}
+	return peers[0].Self
+}
+
 // Run runs the managed component in the calling goroutine until ctx is
 // canceled. Evaluate must have been called at least once without retuning an
 // error before calling Run.
 //
 // Run will immediately return ErrUnevaluated if Evaluate has never been called
 // successfully. Otherwise, Run will return nil.
+//
+// Leader-only components are only built and run while this agent is the
+// component's leader in the cluster, and are shut down when leadership moves
+// to another agent.
 func (cn *ComponentNode) Run(ctx context.Context) error {
-	cn.mut.RLock()
-	managed := cn.managed
-	cn.mut.RUnlock()
-
-	if managed == nil {
+	cn.mut.Lock()
+	if cn.managed == nil && !isLeaderOnly(cn.args) {
+		cn.mut.Unlock()
 		return ErrUnevaluated
 	}
 
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	cn.leaderChange = notify
+
+	// Only one observer is registered per node, even if Run is called again
+	// before the previous observer unregistered itself.
+	observe := cn.cluster != nil && !cn.observing
+	cn.observing = cn.observing || observe
+	cn.mut.Unlock()
+
+	if observe {
+		cn.cluster.Observe(cluster.FuncObserver(cn.onPeersChanged))
+	}
+
+	defer func() {
+		cn.mut.Lock()
+		cn.leaderChange = nil
+		cn.mut.Unlock()
+	}()
+
+	var (
+		cancel context.CancelFunc
+		exited chan error
+	)
+
+	// stop shuts down the running managed component. The component can't be
+	// run again after its Run exits, so it's discarded to be rebuilt if this
+	// agent becomes the leader again.
+	stop := func() {
+		cancel()
+		<-exited
+		exited = nil
+
+		cn.mut.Lock()
+		cn.managed = nil
+		cn.mut.Unlock()
+	}
+
+	for {
+		run := cn.shouldRun()
+
+		switch {
+		case run && exited == nil:
+			managed, err := cn.buildLeaderOnly()
+			if err != nil {
+				level.Error(cn.managedOpts.Logger).Log("msg", "failed to build component", "err", err)
+				cn.setEvalHealth(component.HealthTypeUnhealthy, fmt.Sprintf("building component: %s", err))
+				break
+			}
+
+			cancel, exited = cn.startManaged(ctx, managed)
+
+		case !run && exited != nil:
+			level.Info(cn.managedOpts.Logger).Log("msg", "no longer the cluster leader for component; stopping")
+			stop()
+
+			// Exports of the stopped component are stale now that it runs on
+			// another agent.
+			if cn.exportsType != nil {
+				cn.setExports(cn.reg.Exports)
+			}
+			fallthrough
+
+		case !run:
+			cn.setRunHealth(component.HealthTypeHealthy, "on standby; component is running on another agent in the cluster")
+		}
+
+		select {
+		case <-ctx.Done():
+			if exited != nil {
+				cancel()
+				return <-exited
+			}
+			return nil
+		case err := <-exited:
+			cancel()
+			return err
+		case <-changed:
+		}
+	}
+}
+
+// onPeersChanged is registered as an observer of cn.cluster. It notifies Run
+// to re-check leadership, and unregisters itself once Run has exited so that
+// observers don't pile up when a node is run several times.
+func (cn *ComponentNode) onPeersChanged(_ []peer.Peer) (reregister bool) {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	if cn.leaderChange == nil {
+		cn.observing = false
+		return false
+	}
+	cn.leaderChange()
+	return true
+}
+
+// buildLeaderOnly returns the managed component, building it first if it
+// was deferred because the component is leader-only.
+func (cn *ComponentNode) buildLeaderOnly() (component.Component, error) {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	if cn.managed != nil {
+		return cn.managed, nil
+	}
+
+	httpDefaults := config.GetHTTPDefaults()
+	managed, err := cn.reg.Build(cn.managedOpts, cn.args)
+	if err != nil {
+		return nil, err
+	}
+	cn.managed = managed
+	cn.httpDefaults = httpDefaults
+	cn.setEvalHealth(component.HealthTypeHealthy, "component evaluated")
+	return managed, nil
+}
+
+// startManaged runs managed in the background. The returned channel receives
+// the result of Run once managed exits.
+func (cn *ComponentNode) startManaged(ctx context.Context, managed component.Component) (context.CancelFunc, chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+	exited := make(chan error, 1)
+	go func() { exited <- cn.runManaged(ctx, managed) }()
+	return cancel, exited
+}
+
+// runManaged runs managed until ctx is canceled, updating the run health of
+// cn.
+func (cn *ComponentNode) runManaged(ctx context.Context, managed component.Component) error {
 	cn.setRunHealth(component.HealthTypeHealthy, "started component")
-	err := cn.managed.Run(ctx)
+
+	// Run the component with a profiler label so that goroutines and CPU
+	// samples can be attributed back to it.
+	var err error
+	pprof.Do(ctx, pprof.Labels(componentIDProfileLabel, cn.managedOpts.ID), func(ctx context.Context) {
+		err = managed.Run(ctx)
+	})
 
 	var exitMsg string
 	logger := cn.managedOpts.Logger
 	if err != nil {
 		level.Error(logger).Log("msg", "component exited with error", "err", err)
 		exitMsg = fmt.Sprintf("component shut down with error: %s", err)
+
+		cn.managedOpts.Events.Publish(events.Event{
+			Type:    events.TypeComponentCrashed,
+			Message: exitMsg,
+		})
 	} else {
 		level.Info(logger).Log("msg", "component exited")
 		exitMsg = "component shut down normally"
@@ -323,6 +782,16 @@ func (cn *ComponentNode) Run(ctx context.Context) error {
 	return err
 }
 
+// componentIDProfileLabel is the profiler label set on goroutines started by
+// running components.
+const componentIDProfileLabel = "component_id"
+
+// EvaluationTime returns the total time spent evaluating the component,
+// including building and updating the managed component.
+func (cn *ComponentNode) EvaluationTime() time.Duration {
+	return cn.evalTime.Load()
+}
+
 // ErrUnevaluated is returned if ComponentNode.Run is called before a managed
 // component is built.
 var ErrUnevaluated = errors.New("managed component not built")
@@ -387,8 +856,44 @@ func (cn *ComponentNode) setExports(e component.Exports) {
 
 	if changed {
 		// Inform the controller that we have new exports.
+		cn.notifyUpdate()
+	}
+}
+
+// notifyUpdate calls OnComponentUpdate, coalescing calls which happen within
+// exportDebounce of the previous call into a single call at the end of the
+// window. The dependants of a component whose exports change many times per
+// second are then re-evaluated at most once per window, with the latest
+// exports.
+func (cn *ComponentNode) notifyUpdate() {
+	if cn.exportDebounce <= 0 {
 		cn.OnComponentUpdate(cn)
+		return
+	}
+
+	cn.debounceMut.Lock()
+	defer cn.debounceMut.Unlock()
+
+	if cn.pendingUpdate != nil {
+		// An update is already scheduled and will pick up the latest exports.
+		return
 	}
+
+	wait := cn.exportDebounce - time.Since(cn.lastUpdate)
+	if wait <= 0 {
+		cn.lastUpdate = time.Now()
+		cn.OnComponentUpdate(cn)
+		return
+	}
+
+	cn.pendingUpdate = time.AfterFunc(wait, func() {
+		cn.debounceMut.Lock()
+		cn.pendingUpdate = nil
+		cn.lastUpdate = time.Now()
+		cn.debounceMut.Unlock()
+
+		cn.OnComponentUpdate(cn)
+	})
 }
 
 // CurrentHealth returns the current health of the ComponentNode.
@@ -402,6 +907,10 @@ func (cn *ComponentNode) setExports(e component.Exports) {
 //  4. Latest health from Run() or Evaluate(), if the managed component does not
 //     report health.
 func (cn *ComponentNode) CurrentHealth() component.Health {
+	cn.mut.RLock()
+	managed := cn.managed
+	cn.mut.RUnlock()
+
 	cn.healthMut.RLock()
 	defer cn.healthMut.RUnlock()
 
@@ -418,7 +927,7 @@ func (cn *ComponentNode) CurrentHealth() component.Health {
 	}
 
 	// Then, the health of a managed component takes precedence if it is exposed.
-	hc, _ := cn.managed.(component.HealthComponent)
+	hc, _ := managed.(component.HealthComponent)
 	if hc != nil {
 		return hc.CurrentHealth()
 	}
@@ -442,6 +951,32 @@ func (cn *ComponentNode) DebugInfo() interface{} {
 	return nil
 }
 
+// EvaluationTriggers returns the most recent re-evaluations of the
+// ComponentNode caused by other components updating their exports, newest
+// first.
+func (cn *ComponentNode) EvaluationTriggers() []EvaluationTrigger {
+	cn.triggersMut.RLock()
+	defer cn.triggersMut.RUnlock()
+
+	res := make([]EvaluationTrigger, 0, len(cn.triggers))
+	for i := len(cn.triggers) - 1; i >= 0; i-- {
+		res = append(res, cn.triggers[i])
+	}
+	return res
+}
+
+// addEvaluationTrigger records a re-evaluation of the ComponentNode, dropping
+// the oldest one if more than maxEvaluationTriggers are tracked.
+func (cn *ComponentNode) addEvaluationTrigger(t EvaluationTrigger) {
+	cn.triggersMut.Lock()
+	defer cn.triggersMut.Unlock()
+
+	if len(cn.triggers) >= maxEvaluationTriggers {
+		cn.triggers = append(cn.triggers[:0], cn.triggers[1:]...)
+	}
+	cn.triggers = append(cn.triggers, t)
+}
+
 // setEvalHealth sets the internal health from a call to Evaluate. See Health
 // for information on how overall health is calculated.
 func (cn *ComponentNode) setEvalHealth(t component.HealthType, msg string) {
@@ -468,10 +1003,66 @@ func (cn *ComponentNode) setRunHealth(t component.HealthType, msg string) {
 	}
 }
 
+// TapComponent returns the managed component if it implements
+// component.TapComponent, otherwise it returns nil.
+func (cn *ComponentNode) TapComponent() component.TapComponent {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	tc, ok := cn.managed.(component.TapComponent)
+	if !ok {
+		return nil
+	}
+	return tc
+}
+
+// DryRunComponent returns the managed component if it implements
+// component.DryRunComponent, otherwise it returns nil.
+func (cn *ComponentNode) DryRunComponent() component.DryRunComponent {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	dc, ok := cn.managed.(component.DryRunComponent)
+	if !ok {
+		return nil
+	}
+	return dc
+}
+
+// CaptureComponent returns the managed component if it implements
+// component.CaptureComponent, otherwise it returns nil.
+func (cn *ComponentNode) CaptureComponent() component.CaptureComponent {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	cc, ok := cn.managed.(component.CaptureComponent)
+	if !ok {
+		return nil
+	}
+	return cc
+}
+
+// DestinationComponent returns the managed component if it implements
+// component.DestinationComponent, otherwise it returns nil.
+func (cn *ComponentNode) DestinationComponent() component.DestinationComponent {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	dc, ok := cn.managed.(component.DestinationComponent)
+	if !ok {
+		return nil
+	}
+	return dc
+}
+
 // HTTPHandler returns an http handler for a component IF it implements HTTPComponent.
 // otherwise it will return nil.
 func (cn *ComponentNode) HTTPHandler() http.Handler {
-	handler, ok := cn.managed.(component.HTTPComponent)
+	cn.mut.RLock()
+	managed := cn.managed
+	cn.mut.RUnlock()
+
+	handler, ok := managed.(component.HTTPComponent)
 	if !ok {
 		return nil
 	}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/grafana/agent/pkg/flow/internal/dag"
 	"github.com/grafana/agent/pkg/river/ast"
@@ -22,13 +23,17 @@ type Reference struct {
 	// Traversal describes which nested field relative to Target is being
 	// accessed.
 	Traversal Traversal
+
+	// Argument is the name of the top-level attribute or block in the
+	// referencing node which contains the reference.
+	Argument string
 }
 
 // ComponentReferences returns the list of references a component is making to
 // other components.
 func ComponentReferences(parent *vm.Scope, cn dag.Node, g *dag.Graph) ([]Reference, diag.Diagnostics) {
 	var (
-		traversals []Traversal
+		traversals []argumentTraversal
 
 		diags diag.Diagnostics
 	)
@@ -36,12 +41,14 @@ func ComponentReferences(parent *vm.Scope, cn dag.Node, g *dag.Graph) ([]Referen
 	switch cn := cn.(type) {
 	case BlockNode:
 		if cn.Block() != nil {
-			traversals = expressionsFromBody(cn.Block().Body)
+			traversals = argumentTraversalsFromBody(cn.Block().Body)
 		}
 	}
 
 	refs := make([]Reference, 0, len(traversals))
-	for _, t := range traversals {
+	for _, at := range traversals {
+		t := at.Traversal
+
 		// Determine if a reference refers to something existing.
 		if _, ok := parent.Lookup(t[0].Name); ok {
 			continue
@@ -52,12 +59,42 @@ func ComponentReferences(parent *vm.Scope, cn dag.Node, g *dag.Graph) ([]Referen
 		if resolveDiags.HasErrors() {
 			continue
 		}
+		ref.Argument = at.Argument
 		refs = append(refs, ref)
 	}
 
 	return refs, diags
 }
 
+// argumentTraversal is a Traversal paired with the name of the top-level
+// statement it was found in.
+type argumentTraversal struct {
+	Argument  string
+	Traversal Traversal
+}
+
+// argumentTraversalsFromBody finds all variable references in body, grouped
+// by the top-level attribute or block they were found in.
+func argumentTraversalsFromBody(body ast.Body) []argumentTraversal {
+	var res []argumentTraversal
+
+	for _, stmt := range body {
+		var name string
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			name = stmt.Name.Name
+		case *ast.BlockStmt:
+			name = strings.Join(stmt.Name, ".")
+		}
+
+		for _, t := range expressionsFromBody(ast.Body{stmt}) {
+			res = append(res, argumentTraversal{Argument: name, Traversal: t})
+		}
+	}
+
+	return res
+}
+
 // expressionsFromSyntaxBody recurses through body and finds all variable
 // references.
 func expressionsFromBody(body ast.Body) []Traversal {
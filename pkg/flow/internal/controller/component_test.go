@@ -0,0 +1,179 @@
+package controller_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rfratto/ckit/peer"
+	"github.com/rfratto/ckit/shard"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/atomic"
+)
+
+func TestComponentNode_LeaderOnly(t *testing.T) {
+	cl := &fakeCluster{}
+
+	l := controller.NewLoader(controller.ComponentGlobals{
+		LogSink:           noOpSink(),
+		Logger:            logging.New(nil),
+		TraceProvider:     trace.NewNoopTracerProvider(),
+		DataPath:          t.TempDir(),
+		OnComponentUpdate: func(cn *controller.ComponentNode) { /* no-op */ },
+		Registerer:        prometheus.NewRegistry(),
+		Cluster:           cl,
+	})
+	diags := applyFromContent(t, l, []byte(`
+		testcomponents.leader_only "example" {
+			leader_only = true
+		}
+	`), nil)
+	require.NoError(t, diags.ErrorOrNil())
+
+	cn := l.Components()[0]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- cn.Run(ctx) }()
+
+	// The managed component is replaced while the HTTP handler of the node is
+	// read concurrently, which is caught by the race detector.
+	handlerDone := make(chan struct{})
+	defer func() { <-handlerDone }()
+	go func(ctx context.Context) {
+		defer close(handlerDone)
+		for ctx.Err() == nil {
+			_ = cn.HTTPHandler()
+			time.Sleep(time.Millisecond)
+		}
+	}(ctx)
+
+	requireHealthMessage := func(msg string) {
+		t.Helper()
+		util.Eventually(t, func(t require.TestingT) {
+			require.Equal(t, msg, cn.CurrentHealth().Message)
+		})
+	}
+
+	requireHealthMessage("on standby; component is running on another agent in the cluster")
+	cl.SetLeader(true)
+	requireHealthMessage("started component")
+	util.Eventually(t, func(t require.TestingT) {
+		require.Equal(t, testcomponents.LeaderOnlyExports{Running: true}, cn.Exports())
+	})
+	cl.SetLeader(false)
+	requireHealthMessage("on standby; component is running on another agent in the cluster")
+	require.Equal(t, testcomponents.LeaderOnlyExports{}, cn.Exports())
+
+	cancel()
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Run did not exit")
+	}
+
+	// Running the node again doesn't register another observer while the
+	// previous one is still registered.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() { runErr <- cn.Run(ctx) }()
+	requireHealthMessage("on standby; component is running on another agent in the cluster")
+	require.Equal(t, 1, cl.Observers())
+
+	// The observer unregisters itself once Run exits.
+	cancel()
+	require.NoError(t, <-runErr)
+	cl.SetLeader(true)
+	require.Equal(t, 0, cl.Observers())
+}
+
+func TestComponentNode_ExportDebounce(t *testing.T) {
+	var updates atomic.Int64
+
+	l := controller.NewLoader(controller.ComponentGlobals{
+		LogSink:           noOpSink(),
+		Logger:            logging.New(nil),
+		TraceProvider:     trace.NewNoopTracerProvider(),
+		DataPath:          t.TempDir(),
+		OnComponentUpdate: func(cn *controller.ComponentNode) { updates.Inc() },
+		Registerer:        prometheus.NewRegistry(),
+		ExportDebounce:    250 * time.Millisecond,
+	})
+	diags := applyFromContent(t, l, []byte(`
+		testcomponents.tick "example" {
+			frequency = "5ms"
+		}
+	`), nil)
+	require.NoError(t, diags.ErrorOrNil())
+
+	cn := l.Components()[0]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = cn.Run(ctx) }()
+
+	// The ticker updates its exports every 5ms, but the updates are coalesced
+	// to at most one every 250ms.
+	time.Sleep(time.Second)
+	cancel()
+	require.GreaterOrEqual(t, updates.Load(), int64(1))
+	require.LessOrEqual(t, updates.Load(), int64(5))
+}
+
+// fakeCluster implements cluster.Node where the local node is either the
+// owner of all keys or none of them.
+type fakeCluster struct {
+	mut       sync.Mutex
+	leader    bool
+	observers []cluster.Observer
+}
+
+func (fc *fakeCluster) SetLeader(leader bool) {
+	fc.mut.Lock()
+	fc.leader = leader
+	observers := fc.observers
+	fc.observers = nil
+	fc.mut.Unlock()
+
+	for _, o := range observers {
+		if o.NotifyPeersChanged(fc.Peers()) {
+			fc.Observe(o)
+		}
+	}
+}
+
+// Observers returns the number of registered observers.
+func (fc *fakeCluster) Observers() int {
+	fc.mut.Lock()
+	defer fc.mut.Unlock()
+	return len(fc.observers)
+}
+
+func (fc *fakeCluster) Lookup(_ shard.Key, _ int, _ shard.Op) ([]peer.Peer, error) {
+	fc.mut.Lock()
+	defer fc.mut.Unlock()
+	return []peer.Peer{{Name: "leader", Self: fc.leader}}, nil
+}
+
+func (fc *fakeCluster) Observe(o cluster.Observer) {
+	fc.mut.Lock()
+	defer fc.mut.Unlock()
+	fc.observers = append(fc.observers, o)
+}
+
+func (fc *fakeCluster) Peers() []peer.Peer {
+	fc.mut.Lock()
+	defer fc.mut.Unlock()
+	return []peer.Peer{{Name: "leader", Self: fc.leader}}
+}
@@ -3,14 +3,21 @@ package controller
 import (
 	"fmt"
 
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/pkg/flow/events"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/flow/tracing"
 	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/schema"
 )
 
 const (
-	exportBlockID  = "export"
-	loggingBlockID = "logging"
-	tracingBlockID = "tracing"
+	exportBlockID       = "export"
+	loggingBlockID      = "logging"
+	tracingBlockID      = "tracing"
+	httpDefaultsBlockID = "http_defaults"
+	eventsBlockID       = "events"
 )
 
 // NewConfigNode creates a new ConfigNode from an initial ast.BlockStmt.
@@ -23,6 +30,10 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals, isInModule bo
 		return NewLoggingConfigNode(block, globals, isInModule)
 	case tracingBlockID:
 		return NewTracingConfigNode(block, globals, isInModule)
+	case httpDefaultsBlockID:
+		return NewHTTPDefaultsConfigNode(block, globals, isInModule)
+	case eventsBlockID:
+		return NewEventsConfigNode(block, globals, isInModule)
 	default:
 		var diags diag.Diagnostics
 		diags.Add(diag.Diagnostic{
@@ -34,3 +45,22 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals, isInModule bo
 		return nil, diags
 	}
 }
+
+// ConfigBlockSchema returns the schema of the body of the config block
+// called name. ConfigBlockSchema returns false if name isn't a config block.
+func ConfigBlockSchema(name string) (schema.Body, bool) {
+	switch name {
+	case exportBlockID:
+		return schema.For(exportBlock{}), true
+	case loggingBlockID:
+		return schema.For(logging.DefaultSinkOptions), true
+	case tracingBlockID:
+		return schema.For(tracing.DefaultOptions), true
+	case httpDefaultsBlockID:
+		return schema.For(config.HTTPDefaults{}), true
+	case eventsBlockID:
+		return schema.For(events.DefaultOptions), true
+	default:
+		return schema.Body{}, false
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/grafana/agent/pkg/flow/events"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/vm"
+)
+
+// EventsConfigNode is a controller node which configures the sinks of the
+// events subsystem.
+type EventsConfigNode struct {
+	nodeID        string
+	componentName string
+	events        *events.Bus // Bus shared between the controller and all managed components.
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current River blocks to derive config from
+	eval  *vm.Evaluator
+}
+
+// NewEventsConfigNode creates a new EventsConfigNode from an initial
+// ast.BlockStmt. The underlying config isn't applied until Evaluate is called.
+func NewEventsConfigNode(block *ast.BlockStmt, globals ComponentGlobals, isInModule bool) (*EventsConfigNode, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if isInModule {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  "events block not allowed inside a module",
+			StartPos: ast.StartPos(block).Position(),
+			EndPos:   ast.EndPos(block).Position(),
+		})
+
+		return nil, diags
+	}
+
+	return &EventsConfigNode{
+		nodeID:        BlockComponentID(block).String(),
+		componentName: block.GetBlockName(),
+		events:        globals.Events,
+
+		block: block,
+		eval:  vm.New(block.Body),
+	}, diags
+}
+
+// NewDefaultEventsConfigNode creates a new EventsConfigNode with nil block and
+// eval. This will force evaluate to use the default events options for this
+// node.
+func NewDefaultEventsConfigNode(globals ComponentGlobals) *EventsConfigNode {
+	return &EventsConfigNode{
+		nodeID:        eventsBlockID,
+		componentName: eventsBlockID,
+		events:        globals.Events,
+
+		block: nil,
+		eval:  nil,
+	}
+}
+
+// Evaluate implements BlockNode and updates the sinks of the events bus by
+// re-evaluating its River block with the provided scope.
+//
+// Evaluate will return an error if the River block cannot be evaluated or if
+// decoding to arguments fails.
+func (cn *EventsConfigNode) Evaluate(scope *vm.Scope) error {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	args := events.DefaultOptions
+	if cn.eval != nil {
+		if err := cn.eval.Evaluate(scope, &args); err != nil {
+			return fmt.Errorf("decoding River: %w", err)
+		}
+	}
+
+	if err := cn.events.Update(args); err != nil {
+		return fmt.Errorf("could not update events: %w", err)
+	}
+	return nil
+}
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *EventsConfigNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *EventsConfigNode) NodeID() string { return cn.nodeID }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/flow/limits"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/grafana/agent/pkg/river/diag"
@@ -32,6 +35,7 @@ type Loader struct {
 	mut               sync.RWMutex
 	graph             *dag.Graph
 	originalGraph     *dag.Graph
+	references        map[string][]Reference // References made by each node, keyed by node ID.
 	components        []*ComponentNode
 	cache             *valueCache
 	blocks            []*ast.BlockStmt // Most recently loaded blocks, used for writing
@@ -71,6 +75,11 @@ func NewLoader(globals ComponentGlobals) *Loader {
 // The provided parentContext can be used to provide global variables and
 // functions to components. A child context will be constructed from the parent
 // to expose values of other components.
+//
+// Apply is transactional: every component is evaluated and newly defined
+// components are built before any existing component is updated. If any
+// component fails to evaluate, build, or update, the Loader is reverted to
+// the previously loaded set of components and the diagnostics are returned.
 func (l *Loader) Apply(parentScope *vm.Scope, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt) diag.Diagnostics {
 	start := time.Now()
 	l.mut.Lock()
@@ -78,8 +87,22 @@ func (l *Loader) Apply(parentScope *vm.Scope, componentBlocks []*ast.BlockStmt,
 	l.cm.controllerEvaluation.Set(1)
 	defer l.cm.controllerEvaluation.Set(0)
 
+	// Remember the state of the Loader so it can be restored if the new set of
+	// blocks fails to apply. Reused components have their block updated while
+	// the new graph is loaded.
+	prev := l.snapshot()
+
+	// Component limits are checked before any component is built so that a
+	// config which is too large doesn't consume resources.
+	restoreLimits, err := l.globals.Limits.SetComponents(len(componentBlocks))
+	if err != nil {
+		return limitDiags(componentBlocks, err)
+	}
+
 	newGraph, diags := l.loadNewGraph(parentScope, componentBlocks, configBlocks)
 	if diags.HasErrors() {
+		l.revert(parentScope, prev, nil)
+		restoreLimits()
 		return diags
 	}
 
@@ -103,6 +126,11 @@ func (l *Loader) Apply(parentScope *vm.Scope, componentBlocks []*ast.BlockStmt,
 	}()
 
 	l.cache.ClearModuleExports()
+
+	// The HTTP client defaults are evaluated first so that every component
+	// builds its clients with them.
+	diags = append(diags, l.evaluateHTTPDefaults(logger, parentScope, &newGraph)...)
+
 	// Evaluate all the components.
 	_ = dag.WalkTopological(&newGraph, newGraph.Leaves(), func(n dag.Node) error {
 		_, span := tracer.Start(spanCtx, "EvaluateNode", trace.WithSpanKind(trace.SpanKindInternal))
@@ -117,14 +145,25 @@ func (l *Loader) Apply(parentScope *vm.Scope, componentBlocks []*ast.BlockStmt,
 		var err error
 
 		switch c := n.(type) {
+		case *HTTPDefaultsConfigNode:
+			// Already evaluated before any other node.
 		case *ComponentNode:
 			components = append(components, c)
 			componentIDs = append(componentIDs, c.ID())
 
-			if err = l.evaluate(logger, parentScope, c); err != nil {
+			if err = l.stage(logger, parentScope, c); err != nil {
 				var evalDiags diag.Diagnostics
 				if errors.As(err, &evalDiags) {
-					diags = append(diags, evalDiags...)
+					for _, d := range evalDiags {
+						// Report diagnostics without a position, such as
+						// validation errors of a component with an empty
+						// body, at the component block.
+						if !d.StartPos.Valid() {
+							d.StartPos = ast.StartPos(c.Block()).Position()
+							d.EndPos = ast.EndPos(c.Block()).Position()
+						}
+						diags.Add(d)
+					}
 				} else {
 					diags.Add(diag.Diagnostic{
 						Severity: diag.SeverityLevelError,
@@ -159,6 +198,24 @@ func (l *Loader) Apply(parentScope *vm.Scope, componentBlocks []*ast.BlockStmt,
 		return nil
 	})
 
+	var changed []*ComponentNode
+	if !diags.HasErrors() {
+		var commitDiags diag.Diagnostics
+		changed, commitDiags = l.commit(logger, components)
+		diags = append(diags, commitDiags...)
+	}
+	if diags.HasErrors() {
+		level.Warn(logger).Log("msg", "reverting to previously loaded components after failed evaluation")
+		l.revert(parentScope, prev, components)
+		restoreLimits()
+		return diags
+	}
+
+	// Components were staged with the exports their dependencies had before
+	// the commit, so the dependants of components whose exports changed are
+	// evaluated again.
+	diags = append(diags, l.evaluateChanged(logger, parentScope, &newGraph, changed)...)
+
 	l.components = components
 	l.graph = &newGraph
 	l.cache.SyncIDs(componentIDs)
@@ -171,6 +228,218 @@ func (l *Loader) Apply(parentScope *vm.Scope, componentBlocks []*ast.BlockStmt,
 	return diags
 }
 
+// evaluateHTTPDefaults evaluates the http_defaults node of g, if any. The node
+// is evaluated before any other node, so it can't reference components.
+func (l *Loader) evaluateHTTPDefaults(logger log.Logger, parentScope *vm.Scope, g *dag.Graph) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, n := range g.Nodes() {
+		hn, ok := n.(*HTTPDefaultsConfigNode)
+		if !ok {
+			continue
+		}
+
+		if len(g.Dependencies(hn)) > 0 {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  "http_defaults block must not reference components",
+				StartPos: ast.StartPos(hn.Block()).Position(),
+				EndPos:   ast.EndPos(hn.Block()).Position(),
+			})
+			continue
+		}
+
+		if err := l.evaluate(logger, parentScope, hn); err != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("Failed to evaluate node for config block: %s", err),
+				StartPos: ast.StartPos(hn.Block()).Position(),
+				EndPos:   ast.EndPos(hn.Block()).Position(),
+			})
+		}
+	}
+
+	return diags
+}
+
+// limitDiags converts an error from checking component limits into
+// diagnostics. The diagnostic points at the first component which exceeds the
+// limit.
+func limitDiags(componentBlocks []*ast.BlockStmt, err error) diag.Diagnostics {
+	var limitErr *limits.Error
+	if !errors.As(err, &limitErr) || limitErr.Available >= len(componentBlocks) {
+		return diag.Diagnostics{{
+			Severity: diag.SeverityLevelError,
+			Message:  err.Error(),
+		}}
+	}
+
+	block := componentBlocks[limitErr.Available]
+	return diag.Diagnostics{{
+		Severity: diag.SeverityLevelError,
+		Message: fmt.Sprintf("Component %q %s; %d components are defined",
+			BlockComponentID(block).String(), limitErr, len(componentBlocks)),
+		StartPos: ast.StartPos(block).Position(),
+		EndPos:   ast.EndPos(block).Position(),
+	}}
+}
+
+// loaderSnapshot is the state of a Loader before a call to Apply.
+type loaderSnapshot struct {
+	originalGraph *dag.Graph
+	references    map[string][]Reference
+	blocks        map[*ComponentNode]*ast.BlockStmt // Block of each loaded component
+}
+
+// snapshot captures the current state of l. l.mut must be held.
+func (l *Loader) snapshot() loaderSnapshot {
+	blocks := make(map[*ComponentNode]*ast.BlockStmt, len(l.components))
+	for _, c := range l.components {
+		blocks[c] = c.Block()
+	}
+	return loaderSnapshot{
+		originalGraph: l.originalGraph,
+		references:    l.references,
+		blocks:        blocks,
+	}
+}
+
+// commit applies the staged evaluation of each component in order, and
+// returns the components whose exports changed when they were committed. If
+// any component fails to commit, the components which were already committed
+// are rolled back. l.mut must be held.
+func (l *Loader) commit(logger log.Logger, components []*ComponentNode) ([]*ComponentNode, diag.Diagnostics) {
+	var (
+		diags   diag.Diagnostics
+		changed []*ComponentNode
+	)
+
+	for i, c := range components {
+		prevExports := c.Exports()
+		err := c.Commit()
+		l.cache.CacheArguments(c.ID(), c.Arguments())
+		l.cache.CacheExports(c.ID(), c.Exports())
+		if !reflect.DeepEqual(prevExports, c.Exports()) {
+			changed = append(changed, c)
+		}
+		if err == nil {
+			continue
+		}
+
+		level.Error(logger).Log("msg", "failed to update component", "node", c.NodeID(), "err", err)
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  fmt.Sprintf("Failed to update component: %s", err),
+			StartPos: ast.StartPos(c.Block()).Position(),
+			EndPos:   ast.EndPos(c.Block()).Position(),
+		})
+
+		// The failed component is rolled back too, in case its update was
+		// applied but took too long.
+		for j := i; j >= 0; j-- {
+			if err := components[j].Rollback(); err != nil {
+				level.Error(logger).Log("msg", "failed to roll back component", "node", components[j].NodeID(), "err", err)
+			}
+		}
+		return nil, diags
+	}
+
+	return changed, diags
+}
+
+// evaluateChanged evaluates the nodes of g which depend on the components in
+// changed again, in dependency order. Unlike the staged evaluation, the
+// nodes are updated right away; failures are reported but don't revert the
+// loaded components. l.mut must be held.
+func (l *Loader) evaluateChanged(logger log.Logger, parentScope *vm.Scope, g *dag.Graph, changed []*ComponentNode) diag.Diagnostics {
+	if len(changed) == 0 {
+		return nil
+	}
+
+	var diags diag.Diagnostics
+
+	dirty := make(map[dag.Node]struct{}, len(changed))
+	for _, c := range changed {
+		dirty[c] = struct{}{}
+	}
+
+	_ = dag.WalkTopological(g, g.Leaves(), func(n dag.Node) error {
+		bn, ok := n.(BlockNode)
+		if !ok || !dependsOnAny(g, n, dirty) {
+			return nil
+		}
+		dirty[n] = struct{}{}
+
+		if err := l.evaluate(logger, parentScope, bn); err != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("Failed to evaluate node after dependencies changed: %s", err),
+				StartPos: ast.StartPos(bn.Block()).Position(),
+				EndPos:   ast.EndPos(bn.Block()).Position(),
+			})
+		}
+		if exp, ok := bn.(*ExportConfigNode); ok {
+			name, val := exp.NameAndValue()
+			l.cache.CacheModuleExportValue(name, val)
+		}
+		return nil
+	})
+
+	return diags
+}
+
+// dependsOnAny returns true if n directly depends on any node in set.
+func dependsOnAny(g *dag.Graph, n dag.Node, set map[dag.Node]struct{}) bool {
+	for _, dep := range g.Dependencies(n) {
+		if _, ok := set[dep]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// revert restores l to the state captured by prev, discarding any staged
+// evaluations of components. l.mut must be held.
+func (l *Loader) revert(parentScope *vm.Scope, prev loaderSnapshot, components []*ComponentNode) {
+	for _, c := range components {
+		c.Discard()
+	}
+	for c, block := range prev.blocks {
+		c.UpdateBlock(block)
+	}
+	l.originalGraph = prev.originalGraph
+	l.references = prev.references
+
+	// Drop any values cached for the new components.
+	componentIDs := make([]ComponentID, 0, len(l.components))
+	for _, c := range l.components {
+		componentIDs = append(componentIDs, c.ID())
+		l.cache.CacheArguments(c.ID(), c.Arguments())
+		l.cache.CacheExports(c.ID(), c.Exports())
+	}
+	l.cache.SyncIDs(componentIDs)
+
+	// Config blocks take effect as soon as they're evaluated, so the
+	// previously loaded ones are evaluated again to restore them.
+	l.cache.ClearModuleExports()
+	for _, n := range l.graph.Nodes() {
+		bn, ok := n.(BlockNode)
+		if !ok {
+			continue
+		}
+		if _, ok := bn.(*ComponentNode); ok {
+			continue
+		}
+		if err := l.evaluate(l.log, parentScope, bn); err != nil {
+			continue
+		}
+		if exp, ok := bn.(*ExportConfigNode); ok {
+			name, val := exp.NameAndValue()
+			l.cache.CacheModuleExportValue(name, val)
+		}
+	}
+}
+
 // loadNewGraph creates a new graph from the provided blocks and validates it.
 func (l *Loader) loadNewGraph(parentScope *vm.Scope, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt) (dag.Graph, diag.Diagnostics) {
 	var g dag.Graph
@@ -182,7 +451,7 @@ func (l *Loader) loadNewGraph(parentScope *vm.Scope, componentBlocks []*ast.Bloc
 	diags = append(diags, componentNodeDiags...)
 
 	// Write up the edges of the graph
-	wireDiags := l.wireGraphEdges(parentScope, &g)
+	refs, wireDiags := l.wireGraphEdges(parentScope, &g)
 	diags = append(diags, wireDiags...)
 
 	// Validate graph to detect cycles
@@ -195,6 +464,7 @@ func (l *Loader) loadNewGraph(parentScope *vm.Scope, componentBlocks []*ast.Bloc
 	// Copy the original graph, this is so we can have access to the original graph for things like displaying a UI or
 	// debug information.
 	l.originalGraph = g.Clone()
+	l.references = refs
 	// Perform a transitive reduction of the graph to clean it up.
 	dag.Reduce(&g)
 
@@ -239,6 +509,20 @@ func (l *Loader) populateConfigBlockNodes(g *dag.Graph, configBlocks []*ast.Bloc
 		g.Add(c)
 	}
 
+	// If an http_defaults config block is not provided, we create an empty node
+	// which resets the defaults.
+	if _, ok := blockMap[httpDefaultsBlockID]; !ok && !l.isModule() {
+		c := NewDefaultHTTPDefaultsConfigNode(l.globals)
+		g.Add(c)
+	}
+
+	// If an events config block is not provided, we create an empty node which
+	// uses defaults.
+	if _, ok := blockMap[eventsBlockID]; !ok && !l.isModule() {
+		c := NewDefaultEventsConfigNode(l.globals)
+		g.Add(c)
+	}
+
 	return diags
 }
 
@@ -271,9 +555,13 @@ func (l *Loader) populateComponentNodes(g *dag.Graph, componentBlocks []*ast.Blo
 			componentName := block.GetBlockName()
 			registration, exists := component.Get(componentName)
 			if !exists {
+				msg := fmt.Sprintf("Unrecognized component name %q", componentName)
+				if suggestion := diag.Suggest(componentName, component.AllNames()); suggestion != "" {
+					msg += fmt.Sprintf("; did you mean %q?", suggestion)
+				}
 				diags.Add(diag.Diagnostic{
 					Severity: diag.SeverityLevelError,
-					Message:  fmt.Sprintf("Unrecognized component name %q", componentName),
+					Message:  msg,
 					StartPos: block.NamePos.Position(),
 					EndPos:   block.NamePos.Add(len(componentName) - 1).Position(),
 				})
@@ -310,6 +598,16 @@ func (l *Loader) populateComponentNodes(g *dag.Graph, componentBlocks []*ast.Blo
 				continue
 			}
 
+			if registration.Exec && l.globals.ReadOnly {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  fmt.Sprintf("Component %q can execute commands and is disabled in read-only mode", componentName),
+					StartPos: block.NamePos.Position(),
+					EndPos:   block.NamePos.Add(len(componentName) - 1).Position(),
+				})
+				continue
+			}
+
 			// Create a new component
 			c = NewComponentNode(l.globals, block)
 		}
@@ -320,19 +618,26 @@ func (l *Loader) populateComponentNodes(g *dag.Graph, componentBlocks []*ast.Blo
 	return diags
 }
 
-// Wire up all the related nodes
-func (l *Loader) wireGraphEdges(parent *vm.Scope, g *dag.Graph) diag.Diagnostics {
-	var diags diag.Diagnostics
+// Wire up all the related nodes. The references made by each node are
+// returned, keyed by node ID.
+func (l *Loader) wireGraphEdges(parent *vm.Scope, g *dag.Graph) (map[string][]Reference, diag.Diagnostics) {
+	var (
+		diags   diag.Diagnostics
+		allRefs = make(map[string][]Reference)
+	)
 
 	for _, n := range g.Nodes() {
 		refs, nodeDiags := ComponentReferences(parent, n, g)
 		for _, ref := range refs {
 			g.AddEdge(dag.Edge{From: n, To: ref.Target})
 		}
+		if len(refs) > 0 {
+			allRefs[n.NodeID()] = refs
+		}
 		diags = append(diags, nodeDiags...)
 	}
 
-	return diags
+	return allRefs, diags
 }
 
 // Variables returns the Variables the Loader exposes for other Flow components
@@ -348,6 +653,57 @@ func (l *Loader) Components() []*ComponentNode {
 	return l.components
 }
 
+// ShutdownOrder groups the current set of loaded components into stages in
+// the order they should be shut down. A component is only placed in a stage
+// after every node which references it, so that components which receive
+// data are stopped before the components they forward data to. Components
+// within a stage don't depend on each other and can be stopped concurrently.
+func (l *Loader) ShutdownOrder() [][]*ComponentNode {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+
+	var (
+		res [][]*ComponentNode
+
+		// remaining tracks the number of dependants of each node which haven't
+		// been placed in a stage yet.
+		remaining = make(map[dag.Node]int)
+		stage     = l.graph.Roots()
+	)
+	for _, n := range l.graph.Nodes() {
+		remaining[n] = len(l.graph.Dependants(n))
+	}
+
+	for len(stage) > 0 {
+		var (
+			components []*ComponentNode
+			next       []dag.Node
+		)
+		for _, n := range stage {
+			if cn, ok := n.(*ComponentNode); ok {
+				components = append(components, cn)
+			}
+			for _, dep := range l.graph.Dependencies(n) {
+				remaining[dep]--
+				if remaining[dep] == 0 {
+					next = append(next, dep)
+				}
+			}
+		}
+
+		// Stages which only contain non-component nodes, such as export
+		// blocks, don't need to be stopped.
+		if len(components) > 0 {
+			sort.Slice(components, func(i, j int) bool {
+				return components[i].NodeID() < components[j].NodeID()
+			})
+			res = append(res, components)
+		}
+		stage = next
+	}
+	return res
+}
+
 // Graph returns a copy of the DAG managed by the Loader.
 func (l *Loader) Graph() *dag.Graph {
 	l.mut.RLock()
@@ -363,6 +719,26 @@ func (l *Loader) OriginalGraph() *dag.Graph {
 	return l.originalGraph.Clone()
 }
 
+// References returns the references made by each node in the original graph,
+// keyed by node ID. Nodes which don't reference any other node are omitted.
+func (l *Loader) References() map[string][]Reference {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+	return l.references
+}
+
+// BuildGraph builds and validates a graph from the provided blocks without
+// evaluating any of the nodes. BuildGraph should only be used on a Loader
+// which is not otherwise in use, as it replaces the original graph and
+// references returned by OriginalGraph and References.
+func (l *Loader) BuildGraph(parentScope *vm.Scope, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt) diag.Diagnostics {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	_, diags := l.loadNewGraph(parentScope, componentBlocks, configBlocks)
+	return diags
+}
+
 // EvaluateDependencies re-evaluates components which depend directly or
 // indirectly on c. EvaluateDependencies should be called whenever a component
 // updates its exports.
@@ -397,6 +773,10 @@ func (l *Loader) EvaluateDependencies(parentScope *vm.Scope, c *ComponentNode) {
 	// Make sure we're in-sync with the current exports of c.
 	l.cache.CacheExports(c.ID(), c.Exports())
 
+	// chains tracks how the walk reached each node from c so that components
+	// can report why they were re-evaluated.
+	chains := map[dag.Node][]string{c: {c.NodeID()}}
+
 	_ = dag.WalkReverse(l.graph, []dag.Node{c}, func(n dag.Node) error {
 		if n == c {
 			// Skip over the starting component; the starting component passed to
@@ -409,6 +789,11 @@ func (l *Loader) EvaluateDependencies(parentScope *vm.Scope, c *ComponentNode) {
 		span.SetAttributes(attribute.String("node_id", n.NodeID()))
 		defer span.End()
 
+		chain := triggerChain(l.graph, chains, n)
+		if cn, ok := n.(*ComponentNode); ok {
+			cn.addEvaluationTrigger(EvaluationTrigger{Time: time.Now(), Chain: chain})
+		}
+
 		var err error
 
 		switch n := n.(type) {
@@ -436,6 +821,49 @@ func (l *Loader) EvaluateDependencies(parentScope *vm.Scope, c *ComponentNode) {
 	}
 }
 
+// triggerChain returns the chain of node IDs which led to n being visited
+// during a reverse walk, extending the shortest chain of any of n's already
+// visited dependencies. The result is stored in chains.
+func triggerChain(g *dag.Graph, chains map[dag.Node][]string, n dag.Node) []string {
+	var parent []string
+	for _, dep := range g.Dependencies(n) {
+		chain, ok := chains[dep]
+		if !ok {
+			continue
+		}
+		// Prefer shorter chains, breaking ties by node ID so the result is
+		// deterministic.
+		if parent == nil || len(chain) < len(parent) ||
+			(len(chain) == len(parent) && chain[len(chain)-1] < parent[len(parent)-1]) {
+			parent = chain
+		}
+	}
+
+	chain := make([]string, 0, len(parent)+1)
+	chain = append(chain, parent...)
+	chain = append(chain, n.NodeID())
+	chains[n] = chain
+	return chain
+}
+
+// stage constructs the final context for the ComponentNode and stages its
+// evaluation. mut must be held when calling stage.
+func (l *Loader) stage(logger log.Logger, parent *vm.Scope, c *ComponentNode) error {
+	ectx := l.cache.BuildContext(parent)
+	err := c.Stage(ectx)
+
+	// Cache the exports so that components which depend on c are evaluated
+	// with the exports of a newly built component.
+	l.cache.CacheArguments(c.ID(), c.Arguments())
+	l.cache.CacheExports(c.ID(), c.Exports())
+
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to evaluate config", "node", c.NodeID(), "err", err)
+		return err
+	}
+	return nil
+}
+
 // evaluate constructs the final context for the BlockNode and
 // evaluates it. mut must be held when calling evaluate.
 func (l *Loader) evaluate(logger log.Logger, parent *vm.Scope, bn BlockNode) error {
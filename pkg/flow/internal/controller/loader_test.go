@@ -2,12 +2,17 @@ package controller_test
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
+	"github.com/grafana/agent/pkg/flow/limits"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/grafana/agent/pkg/river/diag"
@@ -56,6 +61,8 @@ func TestLoader(t *testing.T) {
 			"testcomponents.passthrough.forwarded",
 			"logging",
 			"tracing",
+			"http_defaults",
+			"events",
 		},
 		OutEdges: []edge{
 			{From: "testcomponents.passthrough.ticker", To: "testcomponents.tick.ticker"},
@@ -88,6 +95,63 @@ func TestLoader(t *testing.T) {
 		requireGraph(t, l.Graph(), testGraphDefinition)
 	})
 
+	t.Run("HTTP defaults", func(t *testing.T) {
+		t.Cleanup(func() { config.SetHTTPDefaults(config.HTTPDefaults{}) })
+
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(testFile), []byte(`
+			http_defaults {
+				user_agent   = "custom-agent"
+				dial_timeout = "5s"
+			}
+		`))
+		require.NoError(t, diags.ErrorOrNil())
+		require.Equal(t, config.HTTPDefaults{
+			UserAgent:   "custom-agent",
+			DialTimeout: 5 * time.Second,
+		}, config.GetHTTPDefaults())
+
+		// Removing the block resets the defaults.
+		diags = applyFromContent(t, l, []byte(testFile), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		require.Equal(t, config.HTTPDefaults{}, config.GetHTTPDefaults())
+	})
+
+	t.Run("HTTP defaults update components", func(t *testing.T) {
+		t.Cleanup(func() { config.SetHTTPDefaults(config.HTTPDefaults{}) })
+
+		l := controller.NewLoader(newGlobals())
+		content := []byte(`testcomponents.http_client "example" {}`)
+		requireUserAgent := func(userAgent string) {
+			t.Helper()
+			require.Equal(t, testcomponents.HTTPClientExports{UserAgent: userAgent}, l.Components()[0].Exports())
+		}
+
+		diags := applyFromContent(t, l, content, []byte(`http_defaults { user_agent = "first" }`))
+		require.NoError(t, diags.ErrorOrNil())
+		requireUserAgent("first")
+
+		// The arguments of the component don't change, but it's still updated
+		// with the new defaults.
+		diags = applyFromContent(t, l, content, []byte(`http_defaults { user_agent = "second" }`))
+		require.NoError(t, diags.ErrorOrNil())
+		requireUserAgent("second")
+
+		diags = applyFromContent(t, l, content, nil)
+		require.NoError(t, diags.ErrorOrNil())
+		requireUserAgent("")
+	})
+
+	t.Run("HTTP defaults referencing components", func(t *testing.T) {
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(testFile), []byte(`
+			http_defaults {
+				user_agent = testcomponents.passthrough.static.output
+			}
+		`))
+		require.ErrorContains(t, diags.ErrorOrNil(), "http_defaults block must not reference components")
+	})
+
 	t.Run("Copy existing components and delete stale ones", func(t *testing.T) {
 		startFile := `
 			// Component that should be copied over to the new graph
@@ -114,6 +178,34 @@ func TestLoader(t *testing.T) {
 		require.Nil(t, newGraph.GetByID("testcomponents.tick.remove_me")) // The new graph shouldn't have the old node
 	})
 
+	t.Run("Reload updates dependants of changed components", func(t *testing.T) {
+		config := `
+			testcomponents.passthrough "a" {
+				input = %q
+			}
+
+			testcomponents.passthrough "b" {
+				input = testcomponents.passthrough.a.output
+			}
+
+			testcomponents.passthrough "c" {
+				input = testcomponents.passthrough.b.output
+			}
+		`
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(fmt.Sprintf(config, "1")), nil)
+		require.NoError(t, diags.ErrorOrNil())
+
+		diags = applyFromContent(t, l, []byte(fmt.Sprintf(config, "2")), nil)
+		require.NoError(t, diags.ErrorOrNil())
+
+		for _, id := range []string{"testcomponents.passthrough.b", "testcomponents.passthrough.c"} {
+			n := l.Graph().GetByID(id).(*controller.ComponentNode)
+			require.Equal(t, "2", n.Arguments().(testcomponents.PassthroughConfig).Input, id)
+			require.Equal(t, "2", n.Exports().(testcomponents.PassthroughExports).Output, id)
+		}
+	})
+
 	t.Run("Load with invalid components", func(t *testing.T) {
 		invalidFile := `
 			doesnotexist "bad_component" {
@@ -148,6 +240,177 @@ func TestLoader(t *testing.T) {
 		})
 	})
 
+	t.Run("Failed reload keeps previous components", func(t *testing.T) {
+		startFile := `
+			testcomponents.tick "ticker" {
+				frequency = "1s"
+			}
+
+			testcomponents.passthrough "static" {
+				input = "hello, world!"
+			}
+
+			testcomponents.passthrough "forwarded" {
+				input = testcomponents.passthrough.static.output
+			}
+		`
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(startFile), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		origGraph := l.Graph()
+
+		tt := []struct {
+			name   string
+			config string
+		}{
+			{
+				name: "new component fails to build",
+				config: `
+					testcomponents.tick "ticker" {
+						frequency = "1s"
+					}
+
+					testcomponents.passthrough "static" {
+						input = "goodbye, world!"
+					}
+
+					testcomponents.passthrough "forwarded" {
+						input = testcomponents.passthrough.static.output
+					}
+
+					testcomponents.tick "broken" {
+						frequency = "0s"
+					}
+				`,
+			},
+			{
+				name: "existing component fails to update",
+				config: `
+					testcomponents.tick "ticker" {
+						frequency = "0s"
+					}
+
+					testcomponents.passthrough "static" {
+						input = "goodbye, world!"
+					}
+
+					testcomponents.passthrough "forwarded" {
+						input = testcomponents.passthrough.static.output
+					}
+				`,
+			},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.name, func(t *testing.T) {
+				diags := applyFromContent(t, l, []byte(tc.config), nil)
+				require.Error(t, diags.ErrorOrNil())
+
+				newGraph := l.Graph()
+				require.ElementsMatch(t, origGraph.Nodes(), newGraph.Nodes())
+				require.Len(t, l.Components(), 3)
+
+				for _, id := range []string{"testcomponents.passthrough.static", "testcomponents.passthrough.forwarded"} {
+					cn := newGraph.GetByID(id).(*controller.ComponentNode)
+					require.Equal(t, "hello, world!", cn.Arguments().(testcomponents.PassthroughConfig).Input, id)
+				}
+			})
+		}
+	})
+
+	t.Run("Failed reload shuts down new components", func(t *testing.T) {
+		startFile := `
+			testcomponents.closer "a" {}
+		`
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(startFile), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		open := testcomponents.OpenClosers()
+
+		tt := []struct {
+			name   string
+			config string
+		}{
+			{
+				// closer.b is staged and then discarded.
+				name: "new component fails to build",
+				config: `
+					testcomponents.closer "a" {}
+
+					testcomponents.closer "b" {
+						input = "hello"
+					}
+
+					testcomponents.closer "c" {
+						input       = testcomponents.closer.b.output
+						fail_update = true
+					}
+				`,
+			},
+			{
+				// closer.b is committed and then rolled back.
+				name: "existing component fails to update",
+				config: `
+					testcomponents.closer "a" {
+						input       = testcomponents.closer.b.output
+						fail_update = true
+					}
+
+					testcomponents.closer "b" {
+						input = "hello"
+					}
+				`,
+			},
+		}
+
+		for _, tc := range tt {
+			t.Run(tc.name, func(t *testing.T) {
+				diags := applyFromContent(t, l, []byte(tc.config), nil)
+				require.Error(t, diags.ErrorOrNil())
+				require.Len(t, l.Components(), 1)
+				require.Equal(t, open, testcomponents.OpenClosers())
+			})
+		}
+	})
+
+	t.Run("Maximum evaluation duration", func(t *testing.T) {
+		startFile := `
+			testcomponents.passthrough "static" {
+				input = "hello, world!"
+			}
+		`
+		globals := newGlobals()
+		globals.MaxEvaluationDuration = 50 * time.Millisecond
+		l := controller.NewLoader(globals)
+		diags := applyFromContent(t, l, []byte(startFile), nil)
+		require.NoError(t, diags.ErrorOrNil())
+
+		slowFile := `
+			testcomponents.passthrough "static" {
+				input = "goodbye, world!"
+				lag   = "100ms"
+			}
+		`
+		diags = applyFromContent(t, l, []byte(slowFile), nil)
+		require.ErrorContains(t, diags.ErrorOrNil(), "updating component took")
+		require.ErrorContains(t, diags.ErrorOrNil(), "exceeding the maximum evaluation duration of 50ms")
+
+		// The slow update is rolled back.
+		cn := l.Graph().GetByID("testcomponents.passthrough.static").(*controller.ComponentNode)
+		require.Equal(t, "hello, world!", cn.Arguments().(testcomponents.PassthroughConfig).Input)
+		require.Equal(t, "hello, world!", cn.Exports().(testcomponents.PassthroughExports).Output)
+
+		// New components which take too long to build aren't loaded.
+		diags = applyFromContent(t, l, []byte(startFile+`
+			testcomponents.passthrough "slow" {
+				input = "hello, world!"
+				lag   = "100ms"
+			}
+		`), nil)
+		require.ErrorContains(t, diags.ErrorOrNil(), "building component took")
+		require.Len(t, l.Components(), 1)
+	})
+
 	t.Run("File has cycles", func(t *testing.T) {
 		invalidFile := `
 			testcomponents.tick "ticker" {
@@ -171,6 +434,85 @@ func TestLoader(t *testing.T) {
 		require.Error(t, diags.ErrorOrNil())
 	})
 
+	t.Run("Component limits", func(t *testing.T) {
+		globals := newGlobals()
+		globals.Limits = limits.New(limits.Options{MaxComponents: 3})
+		l := controller.NewLoader(globals)
+
+		// testFile has four components, so the last one exceeds the limit.
+		diags := applyFromContent(t, l, []byte(testFile), nil)
+		require.Len(t, diags, 1)
+		require.Equal(t, `Component "testcomponents.passthrough.forwarded" exceeds the limit of 3 components across all modules; 4 components are defined`, diags[0].Message)
+		require.Empty(t, l.Components())
+
+		// A failed load doesn't use up any of the limit.
+		smallFile := `
+			testcomponents.passthrough "a" {
+				input = "a"
+			}
+			testcomponents.passthrough "b" {
+				input = "b"
+			}
+			testcomponents.passthrough "c" {
+				input = testcomponents.passthrough.missing.output
+			}
+		`
+		diags = applyFromContent(t, l, []byte(smallFile), nil)
+		require.True(t, diags.HasErrors())
+
+		module, err := globals.Limits.Child()
+		require.NoError(t, err)
+		_, err = module.SetComponents(3)
+		require.NoError(t, err)
+	})
+
+	t.Run("Evaluation triggers", func(t *testing.T) {
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(testFile), nil)
+		require.NoError(t, diags.ErrorOrNil())
+
+		ticker := l.Graph().GetByID("testcomponents.tick.ticker").(*controller.ComponentNode)
+		forwarded := l.Graph().GetByID("testcomponents.passthrough.forwarded").(*controller.ComponentNode)
+		static := l.Graph().GetByID("testcomponents.passthrough.static").(*controller.ComponentNode)
+		require.Empty(t, forwarded.EvaluationTriggers())
+
+		l.EvaluateDependencies(nil, ticker)
+		l.EvaluateDependencies(nil, ticker)
+
+		triggers := forwarded.EvaluationTriggers()
+		require.Len(t, triggers, 2)
+		require.False(t, triggers[0].Time.Before(triggers[1].Time), "triggers must be sorted newest first")
+		require.Equal(t, []string{
+			"testcomponents.tick.ticker",
+			"testcomponents.passthrough.ticker",
+			"testcomponents.passthrough.forwarded",
+		}, triggers[0].Chain)
+		require.Empty(t, static.EvaluationTriggers())
+		require.Empty(t, ticker.EvaluationTriggers())
+	})
+
+	t.Run("Shutdown order", func(t *testing.T) {
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(testFile), nil)
+		require.NoError(t, diags.ErrorOrNil())
+
+		var stages [][]string
+		for _, stage := range l.ShutdownOrder() {
+			var ids []string
+			for _, cn := range stage {
+				ids = append(ids, cn.NodeID())
+			}
+			stages = append(stages, ids)
+		}
+
+		// Components are stopped before the components they reference.
+		require.Equal(t, [][]string{
+			{"testcomponents.passthrough.forwarded", "testcomponents.passthrough.static"},
+			{"testcomponents.passthrough.ticker"},
+			{"testcomponents.tick.ticker"},
+		}, stages)
+	})
+
 	t.Run("Handling of singleton component labels", func(t *testing.T) {
 		invalidFile := `
 			testcomponents.tick {
@@ -183,6 +525,23 @@ func TestLoader(t *testing.T) {
 		require.ErrorContains(t, diags[0], `Component "testcomponents.tick" must have a label`)
 		require.ErrorContains(t, diags[1], `Component "testcomponents.singleton" does not support labels`)
 	})
+
+	t.Run("Exec components in read-only mode", func(t *testing.T) {
+		file := `
+			testcomponents.exec "cmd" {
+			}
+		`
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(file), nil)
+		require.NoError(t, diags.ErrorOrNil())
+
+		globals := newGlobals()
+		globals.ReadOnly = true
+		l = controller.NewLoader(globals)
+		diags = applyFromContent(t, l, []byte(file), nil)
+		require.ErrorContains(t, diags.ErrorOrNil(), `Component "testcomponents.exec" can execute commands and is disabled in read-only mode`)
+		require.Empty(t, l.Components())
+	})
 }
 
 // TestScopeWithFailingComponent is used to ensure that the scope is filled out, even if the component
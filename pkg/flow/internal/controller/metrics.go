@@ -1,6 +1,14 @@
 package controller
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"bytes"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 // controllerMetrics contains the metrics for components controller
 type controllerMetrics struct {
@@ -35,9 +43,20 @@ func newControllerMetrics(r prometheus.Registerer) *controllerMetrics {
 	return &cm
 }
 
+// goroutinesRefreshInterval is the minimum time between two attributions of
+// goroutines to components. Attributing goroutines requires a goroutine
+// profile, so it's reused across scrapes happening in quick succession.
+const goroutinesRefreshInterval = 15 * time.Second
+
 type controllerCollector struct {
 	l                      *Loader
 	runningComponentsTotal *prometheus.Desc
+	evaluationSeconds      *prometheus.Desc
+	goroutines             *prometheus.Desc
+
+	goroutinesMut     sync.Mutex
+	goroutinesUpdated time.Time      // Last time goroutinesCache was refreshed
+	goroutinesCache   map[string]int // Goroutines per component ID
 }
 
 func newControllerCollector(l *Loader) prometheus.Collector {
@@ -49,16 +68,33 @@ func newControllerCollector(l *Loader) prometheus.Collector {
 			[]string{"health_type"},
 			nil,
 		),
+		evaluationSeconds: prometheus.NewDesc(
+			"agent_component_evaluation_time_seconds_total",
+			"Total time spent building, evaluating, and updating a component.",
+			[]string{"component_id"},
+			nil,
+		),
+		goroutines: prometheus.NewDesc(
+			"agent_component_goroutines",
+			"Number of goroutines started by a running component.",
+			[]string{"component_id"},
+			nil,
+		),
 	}
 }
 
 func (cc *controllerCollector) Collect(ch chan<- prometheus.Metric) {
 	componentsByHealth := make(map[string]int)
+	goroutines := cc.goroutinesByComponent()
 
 	for _, component := range cc.l.Components() {
 		health := component.CurrentHealth().Health.String()
 		componentsByHealth[health]++
 		component.register.Collect(ch)
+
+		id := component.managedOpts.ID
+		ch <- prometheus.MustNewConstMetric(cc.evaluationSeconds, prometheus.CounterValue, component.EvaluationTime().Seconds(), id)
+		ch <- prometheus.MustNewConstMetric(cc.goroutines, prometheus.GaugeValue, float64(goroutines[id]), id)
 	}
 
 	for health, count := range componentsByHealth {
@@ -68,4 +104,44 @@ func (cc *controllerCollector) Collect(ch chan<- prometheus.Metric) {
 
 func (cc *controllerCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- cc.runningComponentsTotal
+	ch <- cc.evaluationSeconds
+	ch <- cc.goroutines
+}
+
+// goroutinesByComponent returns the number of goroutines per component ID. The
+// result is refreshed at most once per goroutinesRefreshInterval.
+func (cc *controllerCollector) goroutinesByComponent() map[string]int {
+	cc.goroutinesMut.Lock()
+	defer cc.goroutinesMut.Unlock()
+
+	if cc.goroutinesUpdated.IsZero() || time.Since(cc.goroutinesUpdated) >= goroutinesRefreshInterval {
+		cc.goroutinesCache = goroutinesByComponent()
+		cc.goroutinesUpdated = time.Now()
+	}
+	return cc.goroutinesCache
+}
+
+// goroutinesByComponent returns the number of goroutines per component ID,
+// using the profiler labels set by ComponentNode.Run. Goroutines inherit the
+// labels of the goroutine that started them, so goroutines spawned by a
+// component are attributed to it.
+func goroutinesByComponent() map[string]int {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 0); err != nil {
+		return nil
+	}
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		return nil
+	}
+
+	res := make(map[string]int)
+	for _, s := range p.Sample {
+		ids := s.Label[componentIDProfileLabel]
+		if len(ids) == 0 || len(s.Value) == 0 {
+			continue
+		}
+		res[ids[0]] += int(s.Value[0])
+	}
+	return res
 }
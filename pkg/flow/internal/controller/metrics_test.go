@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"runtime/pprof"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoroutinesByComponent(t *testing.T) {
+	var started, done sync.WaitGroup
+	stop := make(chan struct{})
+
+	labels := pprof.Labels(componentIDProfileLabel, "test.component")
+	pprof.Do(context.Background(), labels, func(ctx context.Context) {
+		// Goroutines spawned from a labeled goroutine inherit its labels.
+		for i := 0; i < 3; i++ {
+			started.Add(1)
+			done.Add(1)
+			go func() {
+				defer done.Done()
+				started.Done()
+				<-stop
+			}()
+		}
+	})
+	started.Wait()
+
+	require.Equal(t, 3, goroutinesByComponent()["test.component"])
+
+	close(stop)
+	done.Wait()
+}
+
+func TestControllerCollector_GoroutinesRefreshInterval(t *testing.T) {
+	cc := &controllerCollector{}
+	require.Zero(t, cc.goroutinesByComponent()["test.cached"])
+
+	var started, done sync.WaitGroup
+	stop := make(chan struct{})
+	started.Add(1)
+	done.Add(1)
+	pprof.Do(context.Background(), pprof.Labels(componentIDProfileLabel, "test.cached"), func(ctx context.Context) {
+		go func() {
+			defer done.Done()
+			started.Done()
+			<-stop
+		}()
+	})
+	started.Wait()
+
+	// The goroutines aren't attributed again until the refresh interval
+	// passed.
+	require.Zero(t, cc.goroutinesByComponent()["test.cached"])
+	cc.goroutinesUpdated = time.Now().Add(-goroutinesRefreshInterval)
+	require.Equal(t, 1, cc.goroutinesByComponent()["test.cached"])
+
+	close(stop)
+	done.Wait()
+}
@@ -3,7 +3,10 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+
+	"github.com/grafana/agent/pkg/crash"
 )
 
 // RunnableNode is any dag.Node which can also be run.
@@ -103,6 +106,39 @@ func (s *Scheduler) Synchronize(rr []RunnableNode) error {
 	return nil
 }
 
+// Stop stops the running tasks with the given IDs and waits for them to exit
+// or for ctx to be canceled. IDs which aren't running are ignored.
+//
+// Stop returns the sorted IDs of tasks which didn't exit before ctx was
+// canceled. These tasks continue shutting down in the background.
+func (s *Scheduler) Stop(ctx context.Context, ids []string) []string {
+	s.tasksMut.Lock()
+	stopping := make(map[string]*task, len(ids))
+	for _, id := range ids {
+		if t, ok := s.tasks[id]; ok {
+			stopping[id] = t
+			t.cancel()
+		}
+	}
+	s.tasksMut.Unlock()
+
+	var pending []string
+	for id, t := range stopping {
+		select {
+		case <-t.exited:
+		case <-ctx.Done():
+			// Tasks may have exited at the same time ctx was canceled.
+			select {
+			case <-t.exited:
+			default:
+				pending = append(pending, id)
+			}
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
+
 // Close stops the Scheduler and returns after all running goroutines have
 // exited.
 func (s *Scheduler) Close() error {
@@ -137,6 +173,7 @@ func newTask(opts taskOptions) *task {
 	go func() {
 		defer opts.OnDone()
 		defer close(t.exited)
+		defer crash.Recover()
 		_ = opts.Runnable.Run(t.ctx)
 	}()
 	return t
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
@@ -86,6 +87,62 @@ func TestScheduler_Synchronize(t *testing.T) {
 	})
 }
 
+func TestScheduler_Stop(t *testing.T) {
+	t.Run("Stops selected jobs", func(t *testing.T) {
+		var started sync.WaitGroup
+		started.Add(2)
+
+		stopped := make(chan string, 2)
+		runFunc := func(id string) func(ctx context.Context) error {
+			return func(ctx context.Context) error {
+				started.Done()
+				<-ctx.Done()
+				stopped <- id
+				return nil
+			}
+		}
+
+		sched := controller.NewScheduler()
+		sched.Synchronize([]controller.RunnableNode{
+			fakeRunnable{ID: "component-a", Component: mockComponent{RunFunc: runFunc("component-a")}},
+			fakeRunnable{ID: "component-b", Component: mockComponent{RunFunc: runFunc("component-b")}},
+		})
+		started.Wait()
+
+		require.Empty(t, sched.Stop(context.Background(), []string{"component-a", "component-missing"}))
+		require.Equal(t, "component-a", <-stopped)
+		require.Empty(t, stopped)
+
+		require.NoError(t, sched.Close())
+		require.Equal(t, "component-b", <-stopped)
+	})
+
+	t.Run("Reports jobs which don't exit in time", func(t *testing.T) {
+		var started sync.WaitGroup
+		started.Add(1)
+
+		release := make(chan struct{})
+		runFunc := func(ctx context.Context) error {
+			started.Done()
+			<-release
+			return nil
+		}
+
+		sched := controller.NewScheduler()
+		sched.Synchronize([]controller.RunnableNode{
+			fakeRunnable{ID: "component-a", Component: mockComponent{RunFunc: runFunc}},
+		})
+		started.Wait()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.Equal(t, []string{"component-a"}, sched.Stop(ctx, []string{"component-a"}))
+
+		close(release)
+		require.NoError(t, sched.Close())
+	})
+}
+
 type fakeRunnable struct {
 	ID        string
 	Component component.Component
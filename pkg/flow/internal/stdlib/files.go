@@ -0,0 +1,63 @@
+package stdlib
+
+import (
+	"crypto/sha256"
+	"os"
+	"sort"
+	"sync"
+)
+
+// readFile implements the file function, returning the contents of the file
+// at path as a string.
+func readFile(path string) (string, error) {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(bb), nil
+}
+
+// Files tracks the files read by the file function so that changes to them
+// can be detected.
+type Files struct {
+	mut   sync.Mutex
+	files map[string][sha256.Size]byte
+}
+
+// NewFiles creates a new Files which hasn't read any files.
+func NewFiles() *Files {
+	return &Files{files: make(map[string][sha256.Size]byte)}
+}
+
+// Read implements the file function, returning the contents of the file at
+// path as a string. The contents are recorded so that Changed can report
+// when they change.
+func (f *Files) Read(path string) (string, error) {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.files[path] = sha256.Sum256(bb)
+
+	return string(bb), nil
+}
+
+// Changed returns the sorted paths of files whose contents changed since they
+// were last read. Files which can no longer be read are reported as changed.
+func (f *Files) Changed() []string {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	var changed []string
+	for path, hash := range f.files {
+		bb, err := os.ReadFile(path)
+		if err != nil || sha256.Sum256(bb) != hash {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,36 @@
+package stdlib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		unchanged = filepath.Join(dir, "unchanged")
+		changed   = filepath.Join(dir, "changed")
+		removed   = filepath.Join(dir, "removed")
+	)
+	for _, path := range []string{unchanged, changed, removed} {
+		require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+	}
+
+	files := NewFiles()
+	for _, path := range []string{unchanged, changed, removed} {
+		contents, err := files.Read(path)
+		require.NoError(t, err)
+		require.Equal(t, "hello", contents)
+	}
+	require.Empty(t, files.Changed())
+
+	require.NoError(t, os.WriteFile(changed, []byte("world"), 0644))
+	require.NoError(t, os.Remove(removed))
+	require.Equal(t, []string{changed, removed}, files.Changed())
+
+	_, err := files.Read(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
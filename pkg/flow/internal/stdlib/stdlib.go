@@ -16,6 +16,10 @@ import (
 // value, with an optionally supported error return value as the second return
 // value.
 var Identifiers = map[string]interface{}{
+	// file is overridden by the Flow controller with Files.Read to track the
+	// files which are read.
+	"file": readFile,
+
 	"discovery_target_decode": func(in string) (interface{}, error) {
 		var targetGroups []*targetgroup.Group
 		if err := json.Unmarshal([]byte(in), &targetGroups); err != nil {
@@ -0,0 +1,78 @@
+package testcomponents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/agent/component"
+	"go.uber.org/atomic"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "testcomponents.closer",
+		Args:    CloserArguments{},
+		Exports: CloserExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return NewCloser(opts, args.(CloserArguments))
+		},
+	})
+}
+
+// openClosers counts the testcomponents.closer instances which have been
+// built but haven't exited Run yet.
+var openClosers atomic.Int64
+
+// OpenClosers returns the number of testcomponents.closer instances which
+// have been built but haven't exited Run yet.
+func OpenClosers() int64 { return openClosers.Load() }
+
+// CloserArguments configures the testcomponents.closer component.
+type CloserArguments struct {
+	Input      string `river:"input,attr,optional"`
+	FailUpdate bool   `river:"fail_update,attr,optional"` // Fail building and updating.
+}
+
+// CloserExports describes exported fields for the testcomponents.closer
+// component.
+type CloserExports struct {
+	Output string `river:"output,attr,optional"`
+}
+
+// Closer implements the testcomponents.closer component, which emits its
+// input as an output and tracks whether it has been shut down.
+type Closer struct {
+	opts component.Options
+}
+
+// NewCloser creates a new closer component.
+func NewCloser(o component.Options, cfg CloserArguments) (*Closer, error) {
+	t := &Closer{opts: o}
+	if err := t.Update(cfg); err != nil {
+		return nil, err
+	}
+	openClosers.Inc()
+	return t, nil
+}
+
+var (
+	_ component.Component = (*Closer)(nil)
+)
+
+// Run implements Component.
+func (t *Closer) Run(ctx context.Context) error {
+	defer openClosers.Dec()
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (t *Closer) Update(args component.Arguments) error {
+	c := args.(CloserArguments)
+	if c.FailUpdate {
+		return fmt.Errorf("update failed")
+	}
+	t.opts.OnStateChange(CloserExports{Output: c.Input})
+	return nil
+}
@@ -0,0 +1,41 @@
+package testcomponents
+
+import (
+	"context"
+
+	"github.com/grafana/agent/component"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "testcomponents.exec",
+		Args: ExecArguments{},
+		Exec: true,
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return &Exec{}, nil
+		},
+	})
+}
+
+// ExecArguments configures the testcomponents.exec component.
+type ExecArguments struct{}
+
+// Exec implements the testcomponents.exec component, which is a no-op
+// component registered as being able to execute commands.
+type Exec struct{}
+
+var (
+	_ component.Component = (*Exec)(nil)
+)
+
+// Run implements Component.
+func (t *Exec) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (t *Exec) Update(args component.Arguments) error {
+	return nil
+}
@@ -0,0 +1,55 @@
+package testcomponents
+
+import (
+	"context"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "testcomponents.http_client",
+		Args:    HTTPClientArguments{},
+		Exports: HTTPClientExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			t := &HTTPClient{opts: opts}
+			if err := t.Update(args); err != nil {
+				return nil, err
+			}
+			return t, nil
+		},
+	})
+}
+
+// HTTPClientArguments configures the testcomponents.http_client component.
+type HTTPClientArguments struct{}
+
+// HTTPClientExports describes exported fields for the
+// testcomponents.http_client component.
+type HTTPClientExports struct {
+	UserAgent string `river:"user_agent,attr,optional"`
+}
+
+// HTTPClient implements the testcomponents.http_client component, which
+// stands in for components that create HTTP clients when they're built or
+// updated. It exports the user agent of the HTTP client defaults it was last
+// built or updated with.
+type HTTPClient struct {
+	opts component.Options
+}
+
+var _ component.Component = (*HTTPClient)(nil)
+
+// Run implements Component.
+func (t *HTTPClient) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (t *HTTPClient) Update(args component.Arguments) error {
+	t.opts.OnStateChange(HTTPClientExports{UserAgent: config.GetHTTPDefaults().UserAgent})
+	return nil
+}
@@ -0,0 +1,57 @@
+package testcomponents
+
+import (
+	"context"
+
+	"github.com/grafana/agent/component"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "testcomponents.leader_only",
+		Args:    LeaderOnlyArguments{},
+		Exports: LeaderOnlyExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return &LeaderOnly{opts: opts}, nil
+		},
+	})
+}
+
+// LeaderOnlyArguments configures the testcomponents.leader_only component.
+type LeaderOnlyArguments struct {
+	Enabled bool `river:"leader_only,attr,optional"`
+}
+
+// LeaderOnlyExports describes exported fields for the
+// testcomponents.leader_only component.
+type LeaderOnlyExports struct {
+	Running bool `river:"running,attr,optional"`
+}
+
+// LeaderOnly implements component.LeaderOnlyArguments.
+func (args LeaderOnlyArguments) LeaderOnly() bool { return args.Enabled }
+
+// LeaderOnly implements the testcomponents.leader_only component, which is a
+// no-op component that may be restricted to the leader of a cluster. It
+// exports whether it's running.
+type LeaderOnly struct {
+	opts component.Options
+}
+
+var (
+	_ component.Component           = (*LeaderOnly)(nil)
+	_ component.LeaderOnlyArguments = LeaderOnlyArguments{}
+)
+
+// Run implements Component.
+func (t *LeaderOnly) Run(ctx context.Context) error {
+	t.opts.OnStateChange(LeaderOnlyExports{Running: true})
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (t *LeaderOnly) Update(args component.Arguments) error {
+	return nil
+}
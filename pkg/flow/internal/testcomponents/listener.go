@@ -0,0 +1,43 @@
+package testcomponents
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/agent/component"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:     "testcomponents.listener",
+		Args:     ListenerArguments{},
+		Listener: true,
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return nil, errors.New("testcomponents.listener can't listen in tests")
+		},
+	})
+}
+
+// ListenerArguments configures the testcomponents.listener component.
+type ListenerArguments struct{}
+
+// Listener implements the testcomponents.listener component, which is
+// registered as listening on the network. It always fails to be built, so
+// that it can only be loaded by controllers which don't build it.
+type Listener struct{}
+
+var (
+	_ component.Component = (*Listener)(nil)
+)
+
+// Run implements Component.
+func (t *Listener) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (t *Listener) Update(args component.Arguments) error {
+	return nil
+}
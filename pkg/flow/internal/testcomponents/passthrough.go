@@ -2,6 +2,7 @@ package testcomponents
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -22,7 +23,8 @@ func init() {
 
 // PassthroughConfig configures the testcomponents.passthrough component.
 type PassthroughConfig struct {
-	Input string `river:"input,attr"`
+	Input string        `river:"input,attr"`
+	Lag   time.Duration `river:"lag,attr,optional"` // Time to block each update for.
 }
 
 // PassthroughExports describes exported fields for the
@@ -61,6 +63,7 @@ func (t *Passthrough) Run(ctx context.Context) error {
 // Update implements Component.
 func (t *Passthrough) Update(args component.Arguments) error {
 	c := args.(PassthroughConfig)
+	time.Sleep(c.Lag)
 
 	level.Info(t.log).Log("msg", "passing through value", "value", c.Input)
 	t.opts.OnStateChange(PassthroughExports{Output: c.Input})
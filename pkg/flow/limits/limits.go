@@ -0,0 +1,157 @@
+// Package limits implements limits on the size of Flow component graphs,
+// including the graphs of nested modules. Limits protect an agent from
+// configs which accidentally load an unbounded number of components, such as
+// a module which recursively loads itself.
+package limits
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Options configures limits. Setting a limit to 0 disables it.
+type Options struct {
+	// MaxModuleDepth is the maximum number of modules which can be nested
+	// inside of each other. Components in the root config are at depth 0,
+	// and components inside of a module loaded from the root config are at
+	// depth 1.
+	MaxModuleDepth int
+
+	// MaxComponents is the maximum number of components which can be loaded
+	// across the root config and all modules.
+	MaxComponents int
+
+	// MaxModuleComponents is the maximum number of components which can be
+	// loaded by a single module. The root config is limited by MaxComponents
+	// only.
+	MaxModuleComponents int
+}
+
+// DefaultOptions holds the default limits.
+var DefaultOptions = Options{
+	MaxModuleDepth: 10,
+}
+
+// Tracker tracks the components loaded by a Flow controller against limits.
+// Trackers for the controllers of nested modules are created with Child and
+// share the count of components with their parent.
+//
+// A nil Tracker enforces no limits.
+type Tracker struct {
+	opts  Options
+	depth int
+	total *total
+
+	// components is the number of components loaded by the controller of this
+	// Tracker. It's protected by total.mut.
+	components int
+}
+
+// total is the number of components loaded across a tree of Trackers.
+type total struct {
+	mut   sync.Mutex
+	count int
+}
+
+// New creates a new Tracker for a root Flow controller.
+func New(opts Options) *Tracker {
+	return &Tracker{opts: opts, total: &total{}}
+}
+
+// Depth returns the module depth of the controller t tracks. Depth returns 0
+// for root controllers.
+func (t *Tracker) Depth() int {
+	if t == nil {
+		return 0
+	}
+	return t.depth
+}
+
+// Child returns a Tracker for the controller of a module loaded by the
+// controller of t. Child returns an error if the module would exceed the
+// maximum module depth.
+func (t *Tracker) Child() (*Tracker, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	depth := t.depth + 1
+	if limit := t.opts.MaxModuleDepth; limit > 0 && depth > limit {
+		return nil, fmt.Errorf("module exceeds the limit of %d nested modules; check for modules which load themselves", limit)
+	}
+	return &Tracker{opts: t.opts, depth: depth, total: t.total}, nil
+}
+
+// SetComponents updates the number of components loaded by the controller of
+// t to n. If n components would exceed a limit, SetComponents returns an
+// *Error and the count is unchanged.
+//
+// The returned function restores the previous count, and is called if the n
+// components fail to load.
+func (t *Tracker) SetComponents(n int) (restore func(), err error) {
+	if t == nil {
+		return func() {}, nil
+	}
+
+	t.total.mut.Lock()
+	defer t.total.mut.Unlock()
+
+	if limit := t.opts.MaxModuleComponents; limit > 0 && t.depth > 0 && n > limit {
+		return nil, &Error{
+			Limit:     fmt.Sprintf("%d components per module", limit),
+			Available: limit,
+		}
+	}
+
+	others := t.total.count - t.components
+	if limit := t.opts.MaxComponents; limit > 0 && others+n > limit {
+		available := limit - others
+		if available < 0 {
+			available = 0
+		}
+		return nil, &Error{
+			Limit:     fmt.Sprintf("%d components across all modules", limit),
+			Available: available,
+		}
+	}
+
+	prev := t.components
+	t.components = n
+	t.total.count = others + n
+
+	return func() {
+		t.total.mut.Lock()
+		defer t.total.mut.Unlock()
+		t.total.count += prev - t.components
+		t.components = prev
+	}, nil
+}
+
+// Release releases the components counted for the controller of t. Release
+// is called when the controller exits.
+func (t *Tracker) Release() {
+	if t == nil {
+		return
+	}
+
+	t.total.mut.Lock()
+	defer t.total.mut.Unlock()
+	t.total.count -= t.components
+	t.components = 0
+}
+
+// Error is returned by Tracker.SetComponents when loading components would
+// exceed a limit.
+type Error struct {
+	// Limit describes the exceeded limit, such as "10 components per module".
+	Limit string
+
+	// Available is the number of components which could be loaded without
+	// exceeding the limit.
+	Available int
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("exceeds the limit of %s", e.Limit)
+}
@@ -0,0 +1,67 @@
+package limits_test
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/flow/limits"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker_Child(t *testing.T) {
+	root := limits.New(limits.Options{MaxModuleDepth: 2})
+	require.Equal(t, 0, root.Depth())
+
+	child, err := root.Child()
+	require.NoError(t, err)
+	require.Equal(t, 1, child.Depth())
+
+	grandchild, err := child.Child()
+	require.NoError(t, err)
+	require.Equal(t, 2, grandchild.Depth())
+
+	_, err = grandchild.Child()
+	require.EqualError(t, err, "module exceeds the limit of 2 nested modules; check for modules which load themselves")
+}
+
+func TestTracker_SetComponents(t *testing.T) {
+	root := limits.New(limits.Options{MaxComponents: 10, MaxModuleComponents: 4})
+	module, err := root.Child()
+	require.NoError(t, err)
+
+	// The per-module limit doesn't apply to the root config.
+	_, err = root.SetComponents(6)
+	require.NoError(t, err)
+
+	_, err = module.SetComponents(5)
+	require.Equal(t, &limits.Error{Limit: "4 components per module", Available: 4}, err)
+
+	restore, err := module.SetComponents(4)
+	require.NoError(t, err)
+
+	_, err = root.SetComponents(7)
+	require.Equal(t, &limits.Error{Limit: "10 components across all modules", Available: 6}, err)
+
+	// Restoring the count of the module frees up space for the root config.
+	restore()
+	_, err = root.SetComponents(10)
+	require.NoError(t, err)
+
+	_, err = module.SetComponents(1)
+	require.Equal(t, &limits.Error{Limit: "10 components across all modules", Available: 0}, err)
+
+	root.Release()
+	_, err = module.SetComponents(1)
+	require.NoError(t, err)
+}
+
+func TestTracker_Nil(t *testing.T) {
+	var tracker *limits.Tracker
+
+	child, err := tracker.Child()
+	require.NoError(t, err)
+	require.Nil(t, child)
+
+	_, err = tracker.SetComponents(1000)
+	require.NoError(t, err)
+	tracker.Release()
+}
@@ -82,3 +82,6 @@ func (s OptionalSecret) RiverTokenize() []builder.Token {
 		Lit: fmt.Sprintf("%q", s.Value),
 	}}
 }
+
+// RiverTypeName reports the name of the type in generated documentation.
+func (s OptionalSecret) RiverTypeName() string { return "string or secret" }
@@ -63,3 +63,7 @@ func (s *Secret) ConvertFrom(src interface{}) error {
 func (s Secret) RiverTokenize() []builder.Token {
 	return []builder.Token{{Tok: token.LITERAL, Lit: "(secret)"}}
 }
+
+// RiverTypeName reports the name of the type as "secret" in generated
+// documentation.
+func (s Secret) RiverTypeName() string { return "secret" }
@@ -0,0 +1,39 @@
+package flow
+
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/schema"
+)
+
+// Validate strictly checks the bodies of the components and config blocks in
+// f against the schemas of their arguments. Unknown attributes and blocks are
+// reported as errors with a suggestion for the closest known name, along
+// with missing required attributes and blocks.
+//
+// Components are not built, and expressions are not evaluated, so Validate
+// doesn't report problems which are only found when evaluating a component,
+// such as type errors. Unknown components are not reported by Validate; use
+// BuildGraph to check the references between components.
+func Validate(f *File) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, block := range f.Components {
+		reg, ok := component.Get(block.GetBlockName())
+		if !ok || reg.Args == nil {
+			continue
+		}
+		diags = append(diags, schema.For(reg.Args).Validate(block)...)
+	}
+
+	for _, block := range f.ConfigBlocks {
+		body, ok := controller.ConfigBlockSchema(block.GetBlockName())
+		if !ok {
+			continue
+		}
+		diags = append(diags, body.Validate(block)...)
+	}
+
+	return diags
+}
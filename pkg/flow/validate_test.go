@@ -0,0 +1,42 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	f, err := ReadFile(t.Name(), []byte(testFile))
+	require.NoError(t, err)
+	require.Empty(t, Validate(f))
+}
+
+func TestValidate_Errors(t *testing.T) {
+	f, err := ReadFile(t.Name(), []byte(`
+		testcomponents.tick "ticker" {
+			frequenc = "1s"
+		}
+
+		testcomponents.passthrough "static" {
+			input = "hello, world!"
+			input_block { }
+		}
+
+		logging {
+			levl = "debug"
+		}
+	`))
+	require.NoError(t, err)
+
+	var messages []string
+	for _, d := range Validate(f) {
+		messages = append(messages, d.Message)
+	}
+	require.Equal(t, []string{
+		`unrecognized attribute name "frequenc"; did you mean "frequency"?`,
+		`missing required attribute "frequency"`,
+		`unrecognized block name "input_block"`,
+		`unrecognized attribute name "levl"; did you mean "level"?`,
+	}, messages)
+}
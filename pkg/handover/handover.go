@@ -0,0 +1,197 @@
+// Package handover passes listening sockets from a running process to a new
+// process which replaces it, such as after an upgrade.
+//
+// Sockets created with Listen and ListenPacket are inherited by the process
+// started with Restart. While the new process waits for the old one to exit,
+// the kernel queues new connections and datagrams on the inherited sockets
+// instead of refusing them, so clients aren't disconnected by the restart.
+package handover
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// envSockets holds the comma-separated keys of the sockets inherited by a
+// process started with Restart. The first extra file descriptor of the
+// process is a pipe to the parent process, followed by the sockets in the
+// order of the keys.
+const envSockets = "GRAFANA_AGENT_HANDOVER_SOCKETS"
+
+// firstFD is the first extra file descriptor passed to a child process.
+const firstFD = 3
+
+// filer is implemented by sockets which can be passed to a child process.
+type filer interface {
+	File() (*os.File, error)
+}
+
+var (
+	loadOnce sync.Once
+
+	mut       sync.Mutex
+	parent    *os.File            // Pipe which is closed when the parent process exits.
+	inherited map[string]*os.File // Sockets inherited from the parent process by key.
+	active    = map[string]filer{}
+	children  []*os.File // Pipes to child processes, kept open until exit.
+)
+
+// load reads the sockets inherited from the parent process, if any.
+func load() {
+	loadOnce.Do(func() {
+		keys := os.Getenv(envSockets)
+		if keys == "" {
+			return
+		}
+		// Processes started by this process must not inherit the variable.
+		_ = os.Unsetenv(envSockets)
+
+		mut.Lock()
+		defer mut.Unlock()
+
+		parent = os.NewFile(firstFD, "handover-parent")
+		inherited = make(map[string]*os.File)
+		for i, key := range strings.Split(keys, ",") {
+			inherited[key] = os.NewFile(uintptr(firstFD+1+i), key)
+		}
+	})
+}
+
+func socketKey(network, address string) string {
+	return network + "://" + address
+}
+
+// takeInherited returns the inherited socket for key, if any. Each inherited
+// socket can only be taken once.
+func takeInherited(key string) *os.File {
+	load()
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	f := inherited[key]
+	delete(inherited, key)
+	return f
+}
+
+func track(key string, s filer) {
+	mut.Lock()
+	defer mut.Unlock()
+	active[key] = s
+}
+
+// Listen is like net.Listen, but reuses the listener for the same network and
+// address inherited from the parent process, if there is one. The returned
+// listener is passed to processes started with Restart.
+func Listen(network, address string) (net.Listener, error) {
+	key := socketKey(network, address)
+
+	var (
+		l   net.Listener
+		err error
+	)
+	if f := takeInherited(key); f != nil {
+		// FileListener duplicates the file descriptor, so f can be closed.
+		l, err = net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("using inherited listener for %s: %w", key, err)
+		}
+	} else {
+		l, err = net.Listen(network, address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if f, ok := l.(filer); ok {
+		track(key, f)
+	}
+	return l, nil
+}
+
+// ListenPacket is like net.ListenPacket, but reuses the connection for the
+// same network and address inherited from the parent process, if there is
+// one. The returned connection is passed to processes started with Restart.
+func ListenPacket(network, address string) (net.PacketConn, error) {
+	key := socketKey(network, address)
+
+	var (
+		c   net.PacketConn
+		err error
+	)
+	if f := takeInherited(key); f != nil {
+		c, err = net.FilePacketConn(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("using inherited connection for %s: %w", key, err)
+		}
+	} else {
+		c, err = net.ListenPacket(network, address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if f, ok := c.(filer); ok {
+		track(key, f)
+	}
+	return c, nil
+}
+
+// Inherited returns true if the process was started with Restart.
+func Inherited() bool {
+	load()
+
+	mut.Lock()
+	defer mut.Unlock()
+	return parent != nil
+}
+
+// WaitForParent blocks until the process which started the current process
+// with Restart exits, or until ctx is canceled. WaitForParent returns
+// immediately if the process wasn't started with Restart.
+func WaitForParent(ctx context.Context) error {
+	load()
+
+	mut.Lock()
+	p := parent
+	mut.Unlock()
+	if p == nil {
+		return nil
+	}
+
+	// The parent never writes to the pipe, so reading only returns once the
+	// parent closed its end by exiting.
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		_, _ = p.Read(make([]byte, 1))
+	}()
+
+	select {
+	case <-exited:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CloseUnclaimed closes inherited sockets which haven't been reused by Listen
+// or ListenPacket, such as sockets for addresses which were removed from the
+// config. Connections queued on them are refused.
+func CloseUnclaimed() {
+	load()
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	for key, f := range inherited {
+		_ = f.Close()
+		delete(inherited, key)
+	}
+}
@@ -0,0 +1,95 @@
+//go:build !windows
+// +build !windows
+
+package handover
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	envTestChild = "HANDOVER_TEST_CHILD"
+	testAddress  = "127.0.0.1:0"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(envTestChild) != "" {
+		if err := runTestChild(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestChild accepts a single connection on the inherited listener.
+func runTestChild() error {
+	if !Inherited() {
+		return fmt.Errorf("process didn't inherit sockets")
+	}
+
+	l, err := Listen("tcp", testAddress)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	CloseUnclaimed()
+
+	conn, err := l.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = io.WriteString(conn, "hello from child")
+	return err
+}
+
+func TestRestart(t *testing.T) {
+	l, err := Listen("tcp", testAddress)
+	require.NoError(t, err)
+	addr := l.Addr().String()
+
+	t.Setenv(envTestChild, "1")
+	proc, err := Restart(os.Args[0])
+	require.NoError(t, err)
+
+	// Once the listener is closed in this process, connections are only
+	// accepted by the child process.
+	require.NoError(t, l.Close())
+
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+	msg, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	require.Equal(t, "hello from child", string(msg))
+
+	state, err := proc.Wait()
+	require.NoError(t, err)
+	require.True(t, state.Success())
+}
+
+func TestListen_NotInherited(t *testing.T) {
+	require.False(t, Inherited())
+	require.NoError(t, WaitForParent(context.Background()))
+
+	l, err := Listen("tcp", testAddress)
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	c, err := ListenPacket("udp", testAddress)
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+}
@@ -0,0 +1,65 @@
+//go:build !windows
+// +build !windows
+
+package handover
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// RestartSignals are the signals which request a restart with Restart.
+var RestartSignals = []os.Signal{syscall.SIGUSR2}
+
+// Restart starts the binary at path as a new process with the arguments and
+// environment of the current process. The new process inherits all sockets
+// created with Listen and ListenPacket which are still open.
+//
+// The caller is expected to shut down after Restart returns; the new process
+// can wait for it to exit with WaitForParent.
+func Restart(path string) (*os.Process, error) {
+	mut.Lock()
+	defer mut.Unlock()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating pipe: %w", err)
+	}
+	files := []*os.File{r}
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+
+	var keys []string
+	for key, s := range active {
+		f, err := s.File()
+		if err != nil {
+			// The socket was closed since it was created.
+			delete(active, key)
+			continue
+		}
+		files = append(files, f)
+		keys = append(keys, key)
+	}
+
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), envSockets+"="+strings.Join(keys, ","))
+	cmd.ExtraFiles = files
+	if err := cmd.Start(); err != nil {
+		_ = w.Close()
+		return nil, fmt.Errorf("starting %s: %w", path, err)
+	}
+
+	// The write end of the pipe stays open until the process exits, which is
+	// how the new process detects that it can start.
+	children = append(children, w)
+	return cmd.Process, nil
+}
@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package handover
+
+import (
+	"fmt"
+	"os"
+)
+
+// RestartSignals are the signals which request a restart with Restart. There
+// are none on Windows.
+var RestartSignals []os.Signal
+
+// Restart isn't supported on Windows.
+func Restart(path string) (*os.Process, error) {
+	return nil, fmt.Errorf("restarting with socket handover is not supported on Windows")
+}
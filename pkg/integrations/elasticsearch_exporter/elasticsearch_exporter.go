@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
@@ -15,6 +17,7 @@ import (
 	integrations_v2 "github.com/grafana/agent/pkg/integrations/v2"
 	"github.com/grafana/agent/pkg/integrations/v2/metricsutils"
 	"github.com/prometheus/client_golang/prometheus"
+	config_util "github.com/prometheus/common/config"
 
 	"github.com/prometheus-community/elasticsearch_exporter/collector"
 	"github.com/prometheus-community/elasticsearch_exporter/pkg/clusterinfo"
@@ -66,6 +69,10 @@ type Config struct {
 	ExportDataStreams bool `yaml:"data_stream,omitempty"`
 	// Export stats for Snapshot Lifecycle Management
 	ExportSLM bool `yaml:"slm,omitempty"`
+	// BasicAuth credentials used to authenticate with Elasticsearch.
+	BasicAuth *config_util.BasicAuth `yaml:"basic_auth,omitempty"`
+	// API key used to authenticate with Elasticsearch.
+	APIKey config_util.Secret `yaml:"api_key,omitempty"`
 }
 
 // UnmarshalYAML implements yaml.Unmarshaler for Config
@@ -73,7 +80,21 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	*c = DefaultConfig
 
 	type plain Config
-	return unmarshal((*plain)(c))
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate returns an error if the authentication settings of c are invalid.
+func (c *Config) Validate() error {
+	if c.BasicAuth != nil && c.APIKey != "" {
+		return fmt.Errorf("at most one of basic_auth and api_key must be configured")
+	}
+	if c.BasicAuth != nil && c.BasicAuth.Password != "" && c.BasicAuth.PasswordFile != "" {
+		return fmt.Errorf("at most one of basic_auth password and password_file must be configured")
+	}
+	return nil
 }
 
 // Name returns the name of the integration that this config represents.
@@ -112,15 +133,38 @@ func New(logger log.Logger, c *Config) (integrations.Integration, error) {
 		return nil, fmt.Errorf("failed to parse elasticsearch_address: %w", err)
 	}
 
-	// returns nil if not provided and falls back to simple TCP.
-	tlsConfig := createTLSConfig(c.CA, c.ClientCert, c.ClientPrivateKey, c.InsecureSkipVerify)
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	if c.BasicAuth != nil {
+		password := string(c.BasicAuth.Password)
+		if c.BasicAuth.PasswordFile != "" {
+			buf, err := os.ReadFile(c.BasicAuth.PasswordFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read basic_auth password_file: %w", err)
+			}
+			password = strings.TrimSpace(string(buf))
+		}
+		esURL.User = url.UserPassword(c.BasicAuth.Username, password)
+	}
+
+	tlsConfig, err := createTLSConfig(c.CA, c.ClientCert, c.ClientPrivateKey, c.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	var transport http.RoundTripper = &http.Transport{
+		TLSClientConfig: tlsConfig,
+		Proxy:           http.ProxyFromEnvironment,
+	}
+	if c.APIKey != "" {
+		transport = &apiKeyTransport{next: transport, apiKey: string(c.APIKey)}
+	}
 
 	httpClient := &http.Client{
-		Timeout: c.Timeout,
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
-			Proxy:           http.ProxyFromEnvironment,
-		},
+		Timeout:   c.Timeout,
+		Transport: transport,
 	}
 
 	clusterInfoRetriever := clusterinfo.New(logger, httpClient, esURL, c.ExportClusterInfoInterval)
@@ -184,3 +228,15 @@ func New(logger log.Logger, c *Config) (integrations.Integration, error) {
 		integrations.WithRunner(start),
 	), nil
 }
+
+// apiKeyTransport authenticates requests to Elasticsearch with an API key.
+type apiKeyTransport struct {
+	next   http.RoundTripper
+	apiKey string
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "ApiKey "+t.apiKey)
+	return t.next.RoundTrip(req)
+}
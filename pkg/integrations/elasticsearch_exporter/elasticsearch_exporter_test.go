@@ -0,0 +1,77 @@
+package elasticsearch_exporter //nolint:golint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tt := []struct {
+		name        string
+		input       string
+		expectError string
+	}{
+		{
+			name: "basic auth",
+			input: `
+basic_auth:
+  username: elastic
+  password: changeme`,
+		},
+		{
+			name:  "api key",
+			input: `api_key: c2VjcmV0`,
+		},
+		{
+			name: "basic auth and api key",
+			input: `
+api_key: c2VjcmV0
+basic_auth:
+  username: elastic
+  password: changeme`,
+			expectError: "at most one of basic_auth and api_key must be configured",
+		},
+		{
+			name: "password and password file",
+			input: `
+basic_auth:
+  username: elastic
+  password: changeme
+  password_file: /etc/elasticsearch/password`,
+			expectError: "at most one of basic_auth password and password_file must be configured",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var c Config
+			err := yaml.UnmarshalStrict([]byte(tc.input), &c)
+			if tc.expectError != "" {
+				require.EqualError(t, err, tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAPIKeyTransport(t *testing.T) {
+	var authHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{
+		Transport: &apiKeyTransport{next: http.DefaultTransport, apiKey: "c2VjcmV0"},
+	}
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, "ApiKey c2VjcmV0", authHeader)
+}
@@ -3,14 +3,15 @@ package elasticsearch_exporter //nolint:golint
 import (
 	"crypto/tls"
 	"crypto/x509"
-	"log"
+	"fmt"
 	"os"
 )
 
-// this file was copied as is from
+// this file was copied from
 // http://github.com/justwatchcom/elasticsearch_exporter/blob/c4c7d2bf2ed55725515dd27df4fd41b6c0b5c33c/tls.go
+// and modified to return errors instead of exiting the process.
 
-func createTLSConfig(pemFile, pemCertFile, pemPrivateKeyFile string, insecureSkipVerify bool) *tls.Config {
+func createTLSConfig(pemFile, pemCertFile, pemPrivateKeyFile string, insecureSkipVerify bool) (*tls.Config, error) {
 	tlsConfig := tls.Config{}
 	if insecureSkipVerify {
 		// pem settings are irrelevant if we're skipping verification anyway
@@ -19,20 +20,18 @@ func createTLSConfig(pemFile, pemCertFile, pemPrivateKeyFile string, insecureSki
 	if len(pemFile) > 0 {
 		rootCerts, err := loadCertificatesFrom(pemFile)
 		if err != nil {
-			log.Fatalf("Couldn't load root certificate from %s. Got %s.", pemFile, err)
-			return nil
+			return nil, fmt.Errorf("couldn't load root certificate from %s: %w", pemFile, err)
 		}
 		tlsConfig.RootCAs = rootCerts
 	}
 	if len(pemCertFile) > 0 && len(pemPrivateKeyFile) > 0 {
 		clientPrivateKey, err := loadPrivateKeyFrom(pemCertFile, pemPrivateKeyFile)
 		if err != nil {
-			log.Fatalf("Couldn't setup client authentication. Got %s.", err)
-			return nil
+			return nil, fmt.Errorf("couldn't setup client authentication: %w", err)
 		}
 		tlsConfig.Certificates = []tls.Certificate{*clientPrivateKey}
 	}
-	return &tlsConfig
+	return &tlsConfig, nil
 }
 
 func loadCertificatesFrom(pemFile string) (*x509.CertPool, error) {
@@ -0,0 +1,192 @@
+package mysqld_exporter //nolint:golint
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/mysqld_exporter/collector"
+)
+
+// customQueryNamespace is the prefix of metrics from custom queries, which
+// matches the prefix of the metrics of the built-in collectors.
+const customQueryNamespace = "mysql"
+
+// CustomQuery defines a query whose result rows are exposed as metrics.
+type CustomQuery struct {
+	// Name is used in the names of the metrics of the query, which are named
+	// mysql_<name>_<metric name>.
+	Name  string `yaml:"name"`
+	Query string `yaml:"query"`
+	// Columns whose values are used as labels of every metric of a row.
+	Labels  []string            `yaml:"labels,omitempty"`
+	Metrics []CustomQueryMetric `yaml:"metrics"`
+}
+
+// CustomQueryMetric defines a metric whose value is read from a column of the
+// result of a custom query.
+type CustomQueryMetric struct {
+	// Column holding the value of the metric.
+	Column string `yaml:"column"`
+	// Name of the metric. Defaults to the name of the column.
+	Name string `yaml:"name,omitempty"`
+	// Type of the metric; either gauge or counter.
+	Type string `yaml:"type"`
+	Help string `yaml:"help,omitempty"`
+}
+
+// Validate returns an error if the custom query is invalid.
+func (q *CustomQuery) Validate() error {
+	if !model.IsValidMetricName(model.LabelValue(customQueryNamespace + "_" + q.Name)) {
+		return fmt.Errorf("custom query %q: invalid name", q.Name)
+	}
+	if q.Query == "" {
+		return fmt.Errorf("custom query %q: query must not be empty", q.Name)
+	}
+	for _, l := range q.Labels {
+		if !model.LabelName(l).IsValid() {
+			return fmt.Errorf("custom query %q: label column %q is not a valid label name", q.Name, l)
+		}
+	}
+	if len(q.Metrics) == 0 {
+		return fmt.Errorf("custom query %q: at least one metric must be defined", q.Name)
+	}
+
+	names := make(map[string]struct{}, len(q.Metrics))
+	for _, m := range q.Metrics {
+		name := m.metricName()
+		if !model.IsValidMetricName(model.LabelValue(q.metricName(name))) {
+			return fmt.Errorf("custom query %q: metric %q: invalid name", q.Name, name)
+		}
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("custom query %q: metric %q defined multiple times", q.Name, name)
+		}
+		names[name] = struct{}{}
+
+		if _, err := m.valueType(); err != nil {
+			return fmt.Errorf("custom query %q: metric %q: %w", q.Name, name, err)
+		}
+	}
+	return nil
+}
+
+func (q *CustomQuery) metricName(name string) string {
+	return prometheus.BuildFQName(customQueryNamespace, q.Name, name)
+}
+
+func (m *CustomQueryMetric) metricName() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return m.Column
+}
+
+func (m *CustomQueryMetric) valueType() (prometheus.ValueType, error) {
+	switch m.Type {
+	case "gauge":
+		return prometheus.GaugeValue, nil
+	case "counter":
+		return prometheus.CounterValue, nil
+	default:
+		return 0, fmt.Errorf("unsupported metric type %q, must be gauge or counter", m.Type)
+	}
+}
+
+// customQueryScraper implements collector.Scraper for a custom query.
+type customQueryScraper struct {
+	query CustomQuery
+	descs []*prometheus.Desc
+}
+
+var _ collector.Scraper = (*customQueryScraper)(nil)
+
+// newCustomQueryScraper creates a scraper for q, which must have been
+// validated.
+func newCustomQueryScraper(q CustomQuery) *customQueryScraper {
+	s := &customQueryScraper{query: q}
+	for _, m := range q.Metrics {
+		help := m.Help
+		if help == "" {
+			help = fmt.Sprintf("Column %s of custom query %s.", m.Column, q.Name)
+		}
+		s.descs = append(s.descs, prometheus.NewDesc(q.metricName(m.metricName()), help, q.Labels, nil))
+	}
+	return s
+}
+
+// Name implements collector.Scraper.
+func (s *customQueryScraper) Name() string { return "custom_query." + s.query.Name }
+
+// Help implements collector.Scraper.
+func (s *customQueryScraper) Help() string { return "Collect metrics from a custom query" }
+
+// Version implements collector.Scraper.
+func (s *customQueryScraper) Version() float64 { return 5.1 }
+
+// Scrape implements collector.Scraper.
+func (s *customQueryScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, logger log.Logger) error {
+	rows, err := db.QueryContext(ctx, s.query.Query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+		for _, err := range s.collectRow(columns, values, ch) {
+			level.Warn(logger).Log("msg", "failed to collect metric of custom query", "query", s.query.Name, "err", err)
+		}
+	}
+	return rows.Err()
+}
+
+// collectRow sends the metrics of a row of the query result to ch. Metrics
+// whose column is missing or not numeric are skipped, and an error is
+// returned for each of them.
+func (s *customQueryScraper) collectRow(columns []string, values []sql.RawBytes, ch chan<- prometheus.Metric) []error {
+	row := make(map[string]string, len(columns))
+	for i, c := range columns {
+		row[strings.ToLower(c)] = string(values[i])
+	}
+
+	labelValues := make([]string, 0, len(s.query.Labels))
+	for _, l := range s.query.Labels {
+		labelValues = append(labelValues, row[strings.ToLower(l)])
+	}
+
+	var errs []error
+	for i, m := range s.query.Metrics {
+		raw, ok := row[strings.ToLower(m.Column)]
+		if !ok {
+			errs = append(errs, fmt.Errorf("column %q not found", m.Column))
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("column %q: value %q is not a number", m.Column, raw))
+			continue
+		}
+		valueType, _ := m.valueType()
+		ch <- prometheus.MustNewConstMetric(s.descs[i], valueType, v, labelValues...)
+	}
+	return errs
+}
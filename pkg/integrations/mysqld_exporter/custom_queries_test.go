@@ -0,0 +1,139 @@
+package mysqld_exporter //nolint:golint
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestCustomQuery_Validate(t *testing.T) {
+	valid := CustomQuery{
+		Name:   "orders",
+		Query:  "SELECT status, COUNT(*) AS total FROM orders GROUP BY status",
+		Labels: []string{"status"},
+		Metrics: []CustomQueryMetric{
+			{Column: "total", Type: "gauge"},
+		},
+	}
+	require.NoError(t, valid.Validate())
+
+	tt := []struct {
+		name   string
+		modify func(q *CustomQuery)
+		expect string
+	}{
+		{
+			name:   "invalid name",
+			modify: func(q *CustomQuery) { q.Name = "my-orders" },
+			expect: `custom query "my-orders": invalid name`,
+		},
+		{
+			name:   "empty query",
+			modify: func(q *CustomQuery) { q.Query = "" },
+			expect: `custom query "orders": query must not be empty`,
+		},
+		{
+			name:   "invalid label",
+			modify: func(q *CustomQuery) { q.Labels = []string{"order-status"} },
+			expect: `custom query "orders": label column "order-status" is not a valid label name`,
+		},
+		{
+			name:   "no metrics",
+			modify: func(q *CustomQuery) { q.Metrics = nil },
+			expect: `custom query "orders": at least one metric must be defined`,
+		},
+		{
+			name: "duplicate metric",
+			modify: func(q *CustomQuery) {
+				q.Metrics = append(q.Metrics, CustomQueryMetric{Column: "count", Name: "total", Type: "counter"})
+			},
+			expect: `custom query "orders": metric "total" defined multiple times`,
+		},
+		{
+			name:   "invalid type",
+			modify: func(q *CustomQuery) { q.Metrics = []CustomQueryMetric{{Column: "total", Type: "histogram"}} },
+			expect: `custom query "orders": metric "total": unsupported metric type "histogram", must be gauge or counter`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			q := valid
+			q.Metrics = append([]CustomQueryMetric(nil), valid.Metrics...)
+			tc.modify(&q)
+			require.EqualError(t, q.Validate(), tc.expect)
+		})
+	}
+}
+
+func TestConfig_CustomQueriesYAML(t *testing.T) {
+	cfg := `
+custom_queries:
+- name: orders
+  query: SELECT 1 AS total
+  metrics:
+  - column: total
+    type: gauge
+- name: orders
+  query: SELECT 2 AS total
+  metrics:
+  - column: total
+    type: gauge
+`
+	var c Config
+	require.EqualError(t, yaml.Unmarshal([]byte(cfg), &c), `custom query "orders" defined multiple times`)
+}
+
+func TestCustomQueryScraper_CollectRow(t *testing.T) {
+	s := newCustomQueryScraper(CustomQuery{
+		Name:   "orders",
+		Query:  "SELECT status, COUNT(*) AS total, SUM(amount) AS amount FROM orders GROUP BY status",
+		Labels: []string{"status"},
+		Metrics: []CustomQueryMetric{
+			{Column: "total", Type: "gauge", Help: "Number of orders."},
+			{Column: "amount", Name: "amount_total", Type: "counter"},
+			{Column: "missing", Type: "gauge"},
+		},
+	})
+	require.Equal(t, "custom_query.orders", s.Name())
+
+	ch := make(chan prometheus.Metric, 3)
+	errs := s.collectRow(
+		[]string{"STATUS", "Total", "amount"},
+		[]sql.RawBytes{sql.RawBytes("shipped"), sql.RawBytes("3"), sql.RawBytes("59.5")},
+		ch,
+	)
+	close(ch)
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], `column "missing" not found`)
+
+	var metrics []prometheus.Metric
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	require.Len(t, metrics, 2)
+
+	var total dto.Metric
+	require.NoError(t, metrics[0].Write(&total))
+	require.Contains(t, metrics[0].Desc().String(), `fqName: "mysql_orders_total", help: "Number of orders."`)
+	require.Equal(t, 3.0, total.GetGauge().GetValue())
+	require.Equal(t, "status", total.GetLabel()[0].GetName())
+	require.Equal(t, "shipped", total.GetLabel()[0].GetValue())
+
+	var amount dto.Metric
+	require.NoError(t, metrics[1].Write(&amount))
+	require.Contains(t, metrics[1].Desc().String(), `fqName: "mysql_orders_amount_total"`)
+	require.Equal(t, 59.5, amount.GetCounter().GetValue())
+
+	ch = make(chan prometheus.Metric, 3)
+	errs = s.collectRow(
+		[]string{"status", "total", "amount"},
+		[]sql.RawBytes{sql.RawBytes("shipped"), sql.RawBytes("NaN?"), sql.RawBytes("1")},
+		ch,
+	)
+	require.Len(t, errs, 2)
+	require.EqualError(t, errs[0], `column "total": value "NaN?" is not a number`)
+}
@@ -66,6 +66,10 @@ type Config struct {
 	HeartbeatTable                       string `yaml:"heartbeat_table,omitempty"`
 	HeartbeatUTC                         bool   `yaml:"heartbeat_utc,omitempty"`
 	MySQLUserPrivileges                  bool   `yaml:"mysql_user_privileges,omitempty"`
+
+	// Queries whose results are exposed as metrics, in addition to the
+	// metrics of the collectors.
+	CustomQueries []CustomQuery `yaml:"custom_queries,omitempty"`
 }
 
 // UnmarshalYAML implements yaml.Unmarshaler for Config.
@@ -73,7 +77,25 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	*c = DefaultConfig
 
 	type plain Config
-	return unmarshal((*plain)(c))
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate returns an error if the custom queries of the config are invalid.
+func (c *Config) Validate() error {
+	names := make(map[string]struct{}, len(c.CustomQueries))
+	for _, q := range c.CustomQueries {
+		if err := q.Validate(); err != nil {
+			return err
+		}
+		if _, ok := names[q.Name]; ok {
+			return fmt.Errorf("custom query %q defined multiple times", q.Name)
+		}
+		names[q.Name] = struct{}{}
+	}
+	return nil
 }
 
 // Name returns the name of the integration that this config represents.
@@ -239,5 +261,10 @@ func GetScrapers(c *Config) []collector.Scraper {
 			enabledScrapers = append(enabledScrapers, scraper)
 		}
 	}
+
+	// Custom queries are always enabled.
+	for _, q := range c.CustomQueries {
+		enabledScrapers = append(enabledScrapers, newCustomQueryScraper(q))
+	}
 	return enabledScrapers
 }
@@ -3,6 +3,7 @@ package oracledbexporter
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"time"
@@ -83,7 +84,7 @@ func (c *Config) InstanceKey(agentKey string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("%s:%s", u.Hostname(), u.Port()), nil
+	return net.JoinHostPort(u.Hostname(), u.Port()), nil
 }
 
 // NewIntegration returns the OracleDB Exporter Integration
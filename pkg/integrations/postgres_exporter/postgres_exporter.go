@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"unicode"
 
 	config_util "github.com/prometheus/common/config"
 
@@ -71,32 +72,79 @@ func (c *Config) InstanceKey(_ string) (string, error) {
 	return fmt.Sprintf("postgresql://%s/%s", hostport, s["dbname"]), nil
 }
 
+// parsePostgresURL parses a data source name in either the URL or the
+// key/value format into its key/value pairs.
 func parsePostgresURL(url string) (map[string]string, error) {
-	raw, err := pq.ParseURL(url)
-	if err != nil {
-		return nil, err
+	raw := url
+	if strings.HasPrefix(url, "postgres://") || strings.HasPrefix(url, "postgresql://") {
+		var err error
+		raw, err = pq.ParseURL(url)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return parseConnInfo(raw)
+}
 
+// parseConnInfo parses a connection string in the key/value format. Values may
+// be wrapped in single quotes, and backslashes escape the next character.
+func parseConnInfo(raw string) (map[string]string, error) {
 	res := map[string]string{}
 
-	unescaper := strings.NewReplacer(`\'`, `'`, `\\`, `\`)
-
-	for _, keypair := range strings.Split(raw, " ") {
-		parts := strings.SplitN(keypair, "=", 2)
-		if len(parts) != 2 {
-			panic(fmt.Sprintf("unexpected keypair %s from pq", keypair))
+	s := []rune(raw)
+	for i := 0; i < len(s); {
+		// Skip whitespace between pairs.
+		if unicode.IsSpace(s[i]) {
+			i++
+			continue
 		}
 
-		key := parts[0]
-		value := parts[1]
-
-		// Undo all the transformations ParseURL did: remove wrapping
-		// quotes and then unescape the escaped characters.
-		value = strings.TrimPrefix(value, "'")
-		value = strings.TrimSuffix(value, "'")
-		value = unescaper.Replace(value)
+		keyStart := i
+		for i < len(s) && s[i] != '=' && !unicode.IsSpace(s[i]) {
+			i++
+		}
+		key := string(s[keyStart:i])
+		for i < len(s) && unicode.IsSpace(s[i]) {
+			i++
+		}
+		if i == len(s) || s[i] != '=' {
+			return nil, fmt.Errorf("missing value for key %q", key)
+		}
+		i++
+		for i < len(s) && unicode.IsSpace(s[i]) {
+			i++
+		}
 
-		res[key] = value
+		var (
+			value  strings.Builder
+			quoted = i < len(s) && s[i] == '\''
+			closed = !quoted
+		)
+		if quoted {
+			i++
+		}
+		for i < len(s) {
+			c := s[i]
+			if c == '\\' && i+1 < len(s) {
+				value.WriteRune(s[i+1])
+				i += 2
+				continue
+			}
+			if quoted && c == '\'' {
+				closed = true
+				i++
+				break
+			}
+			if !quoted && unicode.IsSpace(c) {
+				break
+			}
+			value.WriteRune(c)
+			i++
+		}
+		if !closed {
+			return nil, fmt.Errorf("unterminated quoted value for key %q", key)
+		}
+		res[key] = value.String()
 	}
 
 	return res, nil
@@ -34,6 +34,11 @@ type Config struct {
 	WalkParams     map[string]snmp_config.WalkParams `yaml:"walk_params,omitempty"`
 	SnmpConfigFile string                            `yaml:"config_file,omitempty"`
 	SnmpTargets    []SNMPTarget                      `yaml:"snmp_targets"`
+
+	// SnmpModules holds modules defined inline by Flow, which are added to the
+	// modules loaded from SnmpConfigFile or the embedded config. Modules with
+	// the same name replace the loaded modules.
+	SnmpModules snmp_config.Config `yaml:"-"`
 }
 
 // UnmarshalYAML implements yaml.Unmarshaler for Config.
@@ -79,6 +84,10 @@ func New(log log.Logger, c *Config) (integrations.Integration, error) {
 		}
 	}
 
+	for name, module := range c.SnmpModules {
+		(*modules)[name] = module
+	}
+
 	// The `name` and `address` fields are mandatory for the SNMP targets are mandatory.
 	// Enforce this check and fail the creation of the integration if they're missing.
 	for _, target := range c.SnmpTargets {
@@ -2,7 +2,11 @@
 package autoscrape
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/go-kit/log"
@@ -48,6 +52,7 @@ type Config struct {
 	MetricsInstance string         `yaml:"metrics_instance,omitempty"` // Metrics instance name to send metrics to.
 	ScrapeInterval  model.Duration `yaml:"scrape_interval,omitempty"`  // Self-scraping frequency.
 	ScrapeTimeout   model.Duration `yaml:"scrape_timeout,omitempty"`   // Self-scraping timeout.
+	TenantID        string         `yaml:"tenant_id,omitempty"`        // Tenant ID to send as the X-Scope-OrgID header.
 
 	RelabelConfigs       []*relabel.Config `yaml:"relabel_configs,omitempty"`        // Relabel the autoscrape job
 	MetricRelabelConfigs []*relabel.Config `yaml:"metric_relabel_configs,omitempty"` // Relabel individual autoscrape metrics
@@ -58,6 +63,12 @@ type Config struct {
 type InstanceStore interface {
 	// GetInstance retrieves a ManagedInstance by name.
 	GetInstance(name string) (instance.ManagedInstance, error)
+
+	// ListConfigs, ApplyConfig, and DeleteConfig are used to manage the
+	// instances which send metrics on behalf of a tenant.
+	ListConfigs() map[string]instance.Config
+	ApplyConfig(instance.Config) error
+	DeleteConfig(name string) error
 }
 
 // ScrapeConfig bind a Prometheus scrape config with an instance to send
@@ -65,6 +76,10 @@ type InstanceStore interface {
 type ScrapeConfig struct {
 	Instance string
 	Config   prom_config.ScrapeConfig
+
+	// Tenant, if set, sends scraped metrics through a copy of Instance whose
+	// remote_write requests set the X-Scope-OrgID header to Tenant.
+	Tenant string
 }
 
 // Scraper is a metrics autoscraper.
@@ -84,6 +99,9 @@ type Scraper struct {
 	iscrapersMut sync.RWMutex
 	iscrapers    map[string]*instanceScraper
 	dialerFunc   server.DialContextFunc
+
+	// tenantInstances holds the names of instances created for tenants.
+	tenantInstances map[string]struct{}
 }
 
 // NewScraper creates a new autoscraper. Scraper will run until Stop is called.
@@ -102,6 +120,8 @@ func NewScraper(l log.Logger, is InstanceStore, dialerFunc server.DialContextFun
 		is:         is,
 		iscrapers:  map[string]*instanceScraper{},
 		dialerFunc: dialerFunc,
+
+		tenantInstances: map[string]struct{}{},
 	}
 	return s
 }
@@ -121,15 +141,28 @@ func (s *Scraper) ApplyConfig(jobs []*ScrapeConfig) error {
 
 	// Shard our jobs by target instance.
 	shardedJobs := map[string][]*prom_config.ScrapeConfig{}
+	tenantInstances := map[string]struct{}{}
 	for _, j := range jobs {
-		_, err := s.is.GetInstance(j.Instance)
+		instanceName := j.Instance
+		if j.Tenant != "" {
+			name, err := s.applyTenantInstance(j.Instance, j.Tenant)
+			if err != nil {
+				level.Error(s.log).Log("msg", "cannot autoscrape integration", "name", j.Config.JobName, "tenant", j.Tenant, "err", err)
+				saveError(err)
+				continue
+			}
+			instanceName = name
+			tenantInstances[name] = struct{}{}
+		}
+
+		_, err := s.is.GetInstance(instanceName)
 		if err != nil {
 			level.Error(s.log).Log("msg", "cannot autoscrape integration", "name", j.Config.JobName, "err", err)
 			saveError(err)
 			continue
 		}
 
-		shardedJobs[j.Instance] = append(shardedJobs[j.Instance], &j.Config)
+		shardedJobs[instanceName] = append(shardedJobs[instanceName], &j.Config)
 	}
 
 	// Then pass the jobs to instanceScraper, creating them if we need to.
@@ -155,9 +188,95 @@ func (s *Scraper) ApplyConfig(jobs []*ScrapeConfig) error {
 		}
 	}
 
+	// Delete tenant instances which are no longer used. This must happen after
+	// their scrapers were stopped.
+	for name := range s.tenantInstances {
+		if _, current := tenantInstances[name]; current {
+			continue
+		}
+		if err := s.is.DeleteConfig(name); err != nil {
+			level.Warn(s.log).Log("msg", "failed to delete unused tenant instance", "instance", name, "err", err)
+		}
+	}
+	s.tenantInstances = tenantInstances
+
 	return firstError
 }
 
+// applyTenantInstance creates or updates the instance which sends metrics to
+// tenant. The instance is a copy of the base instance with no scrape configs
+// of its own and an X-Scope-OrgID header on every remote_write. The name of
+// the tenant instance is returned.
+func (s *Scraper) applyTenantInstance(base, tenant string) (string, error) {
+	configs := s.is.ListConfigs()
+	baseConfig, ok := configs[base]
+	if !ok {
+		return "", fmt.Errorf("metrics instance %q not found", base)
+	}
+
+	cfg, err := baseConfig.Clone()
+	if err != nil {
+		return "", fmt.Errorf("copying metrics instance %q: %w", base, err)
+	}
+
+	suffix := "-tenant-" + sanitizeTenant(tenant)
+	cfg.Name = base + suffix
+	cfg.ScrapeConfigs = nil
+	for _, rw := range cfg.RemoteWrite {
+		// Remote write names must be unique across instances.
+		if rw.Name != "" {
+			rw.Name += suffix
+		}
+		headers := make(map[string]string, len(rw.Headers)+1)
+		for k, v := range rw.Headers {
+			headers[k] = v
+		}
+		headers[tenantHeader] = tenant
+		rw.Headers = headers
+	}
+
+	// Avoid needlessly updating the instance if its config didn't change.
+	if existing, ok := configs[cfg.Name]; ok && sameConfig(&existing, &cfg) {
+		return cfg.Name, nil
+	}
+	if err := s.is.ApplyConfig(cfg); err != nil {
+		return "", fmt.Errorf("applying instance for tenant %q: %w", tenant, err)
+	}
+	return cfg.Name, nil
+}
+
+// tenantHeader is the header used to identify the tenant metrics are sent
+// as.
+const tenantHeader = "X-Scope-OrgID"
+
+// sanitizeTenant makes tenant safe to use in an instance name, which is also
+// used as a directory name for the instance's WAL. Tenants which only differ
+// in replaced characters, like "a.b" and "a_b", are told apart by a hash of
+// tenant appended to the name.
+func sanitizeTenant(tenant string) string {
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(tenant)))[:8]
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, tenant) + "-" + hash
+}
+
+func sameConfig(a, b *instance.Config) bool {
+	aBytes, err := instance.MarshalConfig(a, false)
+	if err != nil {
+		return false
+	}
+	bBytes, err := instance.MarshalConfig(b, false)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aBytes, bBytes)
+}
+
 // TargetsActive returns the set of active scrape targets for all target
 // instances.
 func (s *Scraper) TargetsActive() map[string]metrics.TargetSet {
@@ -2,13 +2,16 @@ package autoscrape
 
 import (
 	"context"
+	"fmt"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/grafana/agent/pkg/metrics/instance"
 	"github.com/grafana/agent/pkg/util"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	config_util "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	prom_config "github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/discovery"
@@ -71,6 +74,79 @@ func TestAutoscrape(t *testing.T) {
 	require.NoError(t, wt.Wait(5*time.Second), "timed out waiting for scrape")
 }
 
+// TestAutoscrape_Tenant ensures that jobs with a tenant are sent through a
+// copy of their instance which sets the tenant header.
+func TestAutoscrape_Tenant(t *testing.T) {
+	rwURL, err := url.Parse("http://localhost:9009/api/prom/push")
+	require.NoError(t, err)
+
+	configs := map[string]instance.Config{
+		"default": {
+			Name: "default",
+			RemoteWrite: []*prom_config.RemoteWriteConfig{{
+				Name:    "default-rw",
+				URL:     &config_util.URL{URL: rwURL},
+				Headers: map[string]string{"X-Extra": "true"},
+			}},
+		},
+	}
+	im := instance.MockManager{
+		GetInstanceFunc: func(name string) (instance.ManagedInstance, error) {
+			if _, ok := configs[name]; !ok {
+				return nil, fmt.Errorf("instance %s not found", name)
+			}
+			return &mockInstance{app: &noOpAppender}, nil
+		},
+		ListConfigsFunc: func() map[string]instance.Config { return configs },
+		ApplyConfigFunc: func(c instance.Config) error {
+			configs[c.Name] = c
+			return nil
+		},
+		DeleteConfigFunc: func(name string) error {
+			delete(configs, name)
+			return nil
+		},
+	}
+	as := NewScraper(util.TestLogger(t), im, nil)
+	defer as.Stop()
+
+	job := &ScrapeConfig{
+		Instance: "default",
+		Tenant:   "team/a",
+		Config: func() prom_config.ScrapeConfig {
+			cfg := prom_config.DefaultScrapeConfig
+			cfg.JobName = t.Name()
+			return cfg
+		}(),
+	}
+	require.NoError(t, as.ApplyConfig([]*ScrapeConfig{job}))
+
+	tenantConfig, ok := configs["default-tenant-team_a-65f838a2"]
+	require.True(t, ok, "tenant instance was not created")
+	require.Len(t, tenantConfig.RemoteWrite, 1)
+	require.Equal(t, "default-rw-tenant-team_a-65f838a2", tenantConfig.RemoteWrite[0].Name)
+	require.Equal(t, map[string]string{
+		"X-Extra":       "true",
+		"X-Scope-OrgID": "team/a",
+	}, tenantConfig.RemoteWrite[0].Headers)
+
+	// The base instance must not be modified.
+	require.Equal(t, map[string]string{"X-Extra": "true"}, configs["default"].RemoteWrite[0].Headers)
+
+	// The tenant instance is deleted once no job uses it.
+	job.Tenant = ""
+	require.NoError(t, as.ApplyConfig([]*ScrapeConfig{job}))
+	require.NotContains(t, configs, "default-tenant-team_a-65f838a2")
+	require.Contains(t, configs, "default")
+}
+
+func TestSanitizeTenant(t *testing.T) {
+	require.Equal(t, "team_a-65f838a2", sanitizeTenant("team/a"))
+
+	// Tenants which sanitize to the same characters get different names.
+	require.NotEqual(t, sanitizeTenant("a.b"), sanitizeTenant("a_b"))
+}
+
 var globalRef atomic.Uint64
 var noOpAppender = mockAppender{
 	AppendFunc: func(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
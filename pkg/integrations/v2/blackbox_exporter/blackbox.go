@@ -83,6 +83,7 @@ func (bbh *blackboxHandler) ScrapeConfigs(sd discovery.Configs) []*autoscrape.Sc
 
 	return []*autoscrape.ScrapeConfig{{
 		Instance: bbh.cfg.Common.Autoscrape.MetricsInstance,
+		Tenant:   bbh.cfg.Common.Autoscrape.TenantID,
 		Config:   cfg,
 	}}
 }
@@ -147,6 +147,7 @@ func (i *metricsHandlerIntegration) ScrapeConfigs(sd discovery.Configs) []*autos
 
 	return []*autoscrape.ScrapeConfig{{
 		Instance: i.common.Autoscrape.MetricsInstance,
+		Tenant:   i.common.Autoscrape.TenantID,
 		Config:   cfg,
 	}}
 }
@@ -94,6 +94,7 @@ func (sh *snmpHandler) ScrapeConfigs(sd discovery.Configs) []*autoscrape.ScrapeC
 
 	return []*autoscrape.ScrapeConfig{{
 		Instance: sh.cfg.Common.Autoscrape.MetricsInstance,
+		Tenant:   sh.cfg.Common.Autoscrape.TenantID,
 		Config:   cfg,
 	}}
 }
@@ -227,7 +227,8 @@ func (a *Agent) ApplyConfig(cfg Config) error {
 	a.mut.Lock()
 	defer a.mut.Unlock()
 
-	if util.CompareYAML(a.cfg, cfg) {
+	// ExtraMetrics isn't part of the YAML and must be compared separately.
+	if util.CompareYAML(a.cfg, cfg) && a.cfg.Global.ExtraMetrics == cfg.Global.ExtraMetrics {
 		return nil
 	}
 
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"io"
 	"reflect"
+	"time"
 
 	"github.com/grafana/agent/pkg/agentproto"
 	"github.com/grafana/agent/pkg/util"
@@ -13,6 +14,7 @@ import (
 	"github.com/weaveworks/common/middleware"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 )
 
 // ScrapingServiceClient wraps agentproto.ScrapingServiceClient with a Close method.
@@ -29,6 +31,16 @@ var (
 // Config controls how scraping service clients are created.
 type Config struct {
 	GRPCClientConfig grpcclient.Config `yaml:"grpc_client_config,omitempty"`
+
+	// Keepalive pings sent to other agents. No pings are sent when
+	// KeepaliveTime is 0.
+	KeepaliveTime    time.Duration `yaml:"keepalive_time,omitempty"`
+	KeepaliveTimeout time.Duration `yaml:"keepalive_timeout,omitempty"`
+
+	// HTTP/2 flow control windows. gRPC sizes windows dynamically when they're
+	// 0.
+	InitialWindowSize     int32 `yaml:"initial_window_size,omitempty"`
+	InitialConnWindowSize int32 `yaml:"initial_conn_window_size,omitempty"`
 }
 
 // UnmarshalYAML implements yaml.Unmarshaler.
@@ -66,6 +78,7 @@ func New(cfg Config, addr string) (ScrapingServiceClient, error) {
 		return nil, err
 	}
 	opts = append(opts, grpcDialOpts...)
+	opts = append(opts, cfg.tuningDialOptions()...)
 	conn, err := grpc.Dial(addr, opts...)
 	if err != nil {
 		return nil, err
@@ -80,6 +93,25 @@ func New(cfg Config, addr string) (ScrapingServiceClient, error) {
 	}, nil
 }
 
+// tuningDialOptions returns the dial options for the keepalive and flow
+// control settings of cfg which aren't left to gRPC's defaults.
+func (c Config) tuningDialOptions() []grpc.DialOption {
+	var opts []grpc.DialOption
+	if c.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    c.KeepaliveTime,
+			Timeout: c.KeepaliveTimeout,
+		}))
+	}
+	if c.InitialWindowSize > 0 {
+		opts = append(opts, grpc.WithInitialWindowSize(c.InitialWindowSize))
+	}
+	if c.InitialConnWindowSize > 0 {
+		opts = append(opts, grpc.WithInitialConnWindowSize(c.InitialConnWindowSize))
+	}
+	return opts
+}
+
 func instrumentation() ([]grpc.UnaryClientInterceptor, []grpc.StreamClientInterceptor) {
 	unary := []grpc.UnaryClientInterceptor{
 		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
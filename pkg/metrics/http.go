@@ -26,6 +26,7 @@ func (a *Agent) WireAPI(r *mux.Router) {
 
 	r.HandleFunc("/agent/api/v1/metrics/instances", a.ListInstancesHandler).Methods("GET")
 	r.HandleFunc("/agent/api/v1/metrics/targets", a.ListTargetsHandler).Methods("GET")
+	r.HandleFunc("/agent/api/v1/metrics/targets/metadata", a.ListTargetsMetadataHandler).Methods("GET")
 	r.HandleFunc("/agent/api/v1/metrics/instance/{instance}/write", a.PushMetricsHandler).Methods("POST")
 }
 
@@ -134,6 +135,107 @@ type TargetInfo struct {
 	ScrapeError      string        `json:"scrape_error"`
 }
 
+// ListTargetsMetadataHandler retrieves the metric metadata cached by the
+// targets of all instances.
+func (a *Agent) ListTargetsMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	instances := a.mm.ListInstances()
+	allTargets := make(map[string]TargetSet, len(instances))
+	for instName, inst := range instances {
+		allTargets[instName] = inst.TargetsActive()
+	}
+	ListTargetsMetadataHandler(allTargets).ServeHTTP(w, r)
+}
+
+// ListTargetsMetadataHandler renders the metric metadata (type, help, and
+// unit) which targets exposed in their latest scrapes. Metadata is only kept
+// in memory while a target is being scraped.
+//
+// The results can be filtered with the instance, job, and metric query
+// parameters, which match the name of the metrics instance, the job label of
+// the target, and the name of the metric respectively.
+func ListTargetsMetadataHandler(targets map[string]TargetSet) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var (
+			query          = r.URL.Query()
+			instanceFilter = query.Get("instance")
+			jobFilter      = query.Get("job")
+			metricFilter   = query.Get("metric")
+		)
+
+		resp := ListTargetsMetadataResponse{}
+		for instance, tset := range targets {
+			if instanceFilter != "" && instance != instanceFilter {
+				continue
+			}
+			for _, targets := range tset {
+				for _, tgt := range targets {
+					lbls := tgt.Labels()
+					if jobFilter != "" && lbls.Get(model.JobLabel) != jobFilter {
+						continue
+					}
+
+					var metadata []scrape.MetricMetadata
+					if metricFilter != "" {
+						if md, ok := tgt.Metadata(metricFilter); ok {
+							metadata = append(metadata, md)
+						}
+					} else {
+						metadata = tgt.MetadataList()
+					}
+
+					for _, md := range metadata {
+						resp = append(resp, TargetMetadata{
+							InstanceName: instance,
+							Labels:       lbls,
+							Metric:       md.Metric,
+							Type:         string(md.Type),
+							Help:         md.Help,
+							Unit:         md.Unit,
+						})
+					}
+				}
+			}
+		}
+
+		sort.Slice(resp, func(i, j int) bool {
+			// sort by instance, then job label, then instance label, then metric
+			var (
+				iJobLabel      = resp[i].Labels.Get(model.JobLabel)
+				iInstanceLabel = resp[i].Labels.Get(model.InstanceLabel)
+				jJobLabel      = resp[j].Labels.Get(model.JobLabel)
+				jInstanceLabel = resp[j].Labels.Get(model.InstanceLabel)
+			)
+
+			switch {
+			case resp[i].InstanceName != resp[j].InstanceName:
+				return resp[i].InstanceName < resp[j].InstanceName
+			case iJobLabel != jJobLabel:
+				return iJobLabel < jJobLabel
+			case iInstanceLabel != jInstanceLabel:
+				return iInstanceLabel < jInstanceLabel
+			default:
+				return resp[i].Metric < resp[j].Metric
+			}
+		})
+
+		_ = configapi.WriteResponse(rw, http.StatusOK, resp)
+	})
+}
+
+// ListTargetsMetadataResponse is returned by the ListTargetsMetadataHandler.
+type ListTargetsMetadataResponse []TargetMetadata
+
+// TargetMetadata describes the metadata of a metric exposed by a target.
+type TargetMetadata struct {
+	InstanceName string        `json:"instance"`
+	Labels       labels.Labels `json:"labels"`
+
+	Metric string `json:"metric"`
+	Type   string `json:"type"`
+	Help   string `json:"help"`
+	Unit   string `json:"unit"`
+}
+
 // PushMetricsHandler provides a way to POST data directly into
 // an instance's WAL.
 func (a *Agent) PushMetricsHandler(w http.ResponseWriter, r *http.Request) {
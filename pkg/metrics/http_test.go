@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -13,6 +14,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/textparse"
 	"github.com/prometheus/prometheus/scrape"
 	"github.com/stretchr/testify/require"
 )
@@ -132,6 +134,94 @@ func TestAgent_ListTargetsHandler(t *testing.T) {
 	})
 }
 
+func TestAgent_ListTargetsMetadataHandler(t *testing.T) {
+	fact := newFakeInstanceFactory()
+	a, err := newAgent(prometheus.NewRegistry(), Config{
+		WALDir: "/tmp/agent",
+	}, log.NewNopLogger(), fact.factory)
+	require.NoError(t, err)
+
+	newTarget := func(job string) *scrape.Target {
+		tgt := scrape.NewTarget(labels.FromMap(map[string]string{
+			model.JobLabel:      job,
+			model.InstanceLabel: "instance",
+		}), nil, nil)
+		tgt.SetMetadataStore(mockMetadataStore{
+			{Metric: "up", Type: textparse.MetricTypeGauge, Help: "Whether the target is up."},
+			{Metric: "requests_total", Type: textparse.MetricTypeCounter, Help: "Total requests."},
+		})
+		return tgt
+	}
+
+	mockManager := &instance.MockManager{
+		ListInstancesFunc: func() map[string]instance.ManagedInstance {
+			return map[string]instance.ManagedInstance{
+				"test_instance": &mockInstanceScrape{
+					tgts: map[string][]*scrape.Target{
+						"group_a": {newTarget("a")},
+						"group_b": {newTarget("b")},
+					},
+				},
+			}
+		},
+		ListConfigsFunc:  func() map[string]instance.Config { return nil },
+		ApplyConfigFunc:  func(_ instance.Config) error { return nil },
+		DeleteConfigFunc: func(name string) error { return nil },
+		StopFunc:         func() {},
+	}
+	a.mm, err = instance.NewModalManager(prometheus.NewRegistry(), a.logger, mockManager, instance.ModeDistinct)
+	require.NoError(t, err)
+
+	t.Run("all metadata", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/agent/api/v1/metrics/targets/metadata", nil)
+		rr := httptest.NewRecorder()
+		a.ListTargetsMetadataHandler(rr, r)
+
+		var resp struct {
+			Data ListTargetsMetadataResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 4)
+		require.Equal(t, "a", resp.Data[0].Labels.Get(model.JobLabel))
+		require.Equal(t, "requests_total", resp.Data[0].Metric)
+	})
+
+	t.Run("filtered", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/agent/api/v1/metrics/targets/metadata?job=b&metric=up", nil)
+		rr := httptest.NewRecorder()
+		a.ListTargetsMetadataHandler(rr, r)
+		expect := `{
+			"status": "success",
+			"data": [{
+				"instance": "test_instance",
+				"labels": {
+					"instance": "instance",
+					"job": "b"
+				},
+				"metric": "up",
+				"type": "gauge",
+				"help": "Whether the target is up.",
+				"unit": ""
+			}]
+		}`
+		require.JSONEq(t, expect, rr.Body.String())
+	})
+}
+
+type mockMetadataStore []scrape.MetricMetadata
+
+func (s mockMetadataStore) ListMetadata() []scrape.MetricMetadata { return s }
+func (s mockMetadataStore) GetMetadata(metric string) (scrape.MetricMetadata, bool) {
+	for _, md := range s {
+		if md.Metric == metric {
+			return md, true
+		}
+	}
+	return scrape.MetricMetadata{}, false
+}
+func (s mockMetadataStore) SizeMetadata() int   { return 0 }
+func (s mockMetadataStore) LengthMetadata() int { return len(s) }
+
 type mockInstanceScrape struct {
 	instance.NoOpInstance
 	tgts map[string][]*scrape.Target
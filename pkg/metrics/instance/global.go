@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/prometheus/prometheus/config"
 )
 
@@ -14,8 +15,9 @@ var DefaultGlobalConfig = GlobalConfig{
 
 // GlobalConfig holds global settings that apply to all instances by default.
 type GlobalConfig struct {
-	Prometheus  config.GlobalConfig         `yaml:",inline"`
-	RemoteWrite []*config.RemoteWriteConfig `yaml:"remote_write,omitempty"`
+	Prometheus   config.GlobalConfig         `yaml:",inline"`
+	ScrapeLimits ScrapeLimits                `yaml:",inline"`
+	RemoteWrite  []*config.RemoteWriteConfig `yaml:"remote_write,omitempty"`
 
 	ExtraMetrics      bool          `yaml:"-"`
 	DisableKeepAlives bool          `yaml:"-"`
@@ -33,3 +35,61 @@ func (c *GlobalConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 func (c GlobalConfig) IsZero() bool {
 	return reflect.DeepEqual(c, GlobalConfig{}) || reflect.DeepEqual(c, DefaultGlobalConfig)
 }
+
+// ScrapeLimits holds limits for scrape configs. Limits set to 0 are inherited
+// from the next broader level of configuration: a scrape config inherits the
+// limits of its instance, and an instance inherits the global limits.
+type ScrapeLimits struct {
+	BodySizeLimit         units.Base2Bytes `yaml:"body_size_limit,omitempty"`
+	SampleLimit           uint             `yaml:"sample_limit,omitempty"`
+	TargetLimit           uint             `yaml:"target_limit,omitempty"`
+	LabelLimit            uint             `yaml:"label_limit,omitempty"`
+	LabelNameLengthLimit  uint             `yaml:"label_name_length_limit,omitempty"`
+	LabelValueLengthLimit uint             `yaml:"label_value_length_limit,omitempty"`
+}
+
+// Inherit returns a copy of l where limits set to 0 are replaced with the
+// limits from parent.
+func (l ScrapeLimits) Inherit(parent ScrapeLimits) ScrapeLimits {
+	if l.BodySizeLimit == 0 {
+		l.BodySizeLimit = parent.BodySizeLimit
+	}
+	if l.SampleLimit == 0 {
+		l.SampleLimit = parent.SampleLimit
+	}
+	if l.TargetLimit == 0 {
+		l.TargetLimit = parent.TargetLimit
+	}
+	if l.LabelLimit == 0 {
+		l.LabelLimit = parent.LabelLimit
+	}
+	if l.LabelNameLengthLimit == 0 {
+		l.LabelNameLengthLimit = parent.LabelNameLengthLimit
+	}
+	if l.LabelValueLengthLimit == 0 {
+		l.LabelValueLengthLimit = parent.LabelValueLengthLimit
+	}
+	return l
+}
+
+// applyTo sets the limits of sc which are 0 to the limits in l.
+func (l ScrapeLimits) applyTo(sc *config.ScrapeConfig) {
+	if sc.BodySizeLimit == 0 {
+		sc.BodySizeLimit = l.BodySizeLimit
+	}
+	if sc.SampleLimit == 0 {
+		sc.SampleLimit = l.SampleLimit
+	}
+	if sc.TargetLimit == 0 {
+		sc.TargetLimit = l.TargetLimit
+	}
+	if sc.LabelLimit == 0 {
+		sc.LabelLimit = l.LabelLimit
+	}
+	if sc.LabelNameLengthLimit == 0 {
+		sc.LabelNameLengthLimit = l.LabelNameLengthLimit
+	}
+	if sc.LabelValueLengthLimit == 0 {
+		sc.LabelValueLengthLimit = l.LabelValueLengthLimit
+	}
+}
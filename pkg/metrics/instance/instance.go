@@ -23,6 +23,7 @@ import (
 	"github.com/oklog/run"
 	"github.com/prometheus/client_golang/prometheus"
 	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/discovery"
 	"github.com/prometheus/prometheus/model/relabel"
@@ -64,6 +65,12 @@ type Config struct {
 	ScrapeConfigs            []*config.ScrapeConfig      `yaml:"scrape_configs,omitempty"`
 	RemoteWrite              []*config.RemoteWriteConfig `yaml:"remote_write,omitempty"`
 
+	// Defaults for scrape configs in this instance, overriding the global
+	// defaults.
+	ScrapeInterval model.Duration `yaml:"scrape_interval,omitempty"`
+	ScrapeTimeout  model.Duration `yaml:"scrape_timeout,omitempty"`
+	ScrapeLimits   ScrapeLimits   `yaml:",inline"`
+
 	// How frequently the WAL should be truncated.
 	WALTruncateFrequency time.Duration `yaml:"wal_truncate_frequency,omitempty"`
 
@@ -121,7 +128,21 @@ func (c *Config) ApplyDefaults(global GlobalConfig) error {
 		return errors.New("remote_flush_deadline must be greater than 0s")
 	case c.MinWALTime > c.MaxWALTime:
 		return errors.New("min_wal_time must be less than max_wal_time")
+	case c.ScrapeInterval != 0 && c.ScrapeTimeout > c.ScrapeInterval:
+		return errors.New("scrape_timeout must not be greater than scrape_interval")
+	}
+
+	// Scrape defaults are layered: scrape configs inherit unset values from
+	// the instance, which inherits unset values from the global config.
+	scrapeInterval := c.ScrapeInterval
+	if scrapeInterval == 0 {
+		scrapeInterval = c.global.Prometheus.ScrapeInterval
+	}
+	scrapeTimeout := c.ScrapeTimeout
+	if scrapeTimeout == 0 {
+		scrapeTimeout = c.global.Prometheus.ScrapeTimeout
 	}
+	scrapeLimits := c.ScrapeLimits.Inherit(c.global.ScrapeLimits)
 
 	jobNames := map[string]struct{}{}
 	for _, sc := range c.ScrapeConfigs {
@@ -132,7 +153,7 @@ func (c *Config) ApplyDefaults(global GlobalConfig) error {
 		// First set the correct scrape interval, then check that the timeout
 		// (inferred or explicit) is not greater than that.
 		if sc.ScrapeInterval == 0 {
-			sc.ScrapeInterval = c.global.Prometheus.ScrapeInterval
+			sc.ScrapeInterval = scrapeInterval
 		}
 		if sc.ScrapeTimeout > sc.ScrapeInterval {
 			return fmt.Errorf("scrape timeout greater than scrape interval for scrape config with job name %q", sc.JobName)
@@ -141,12 +162,13 @@ func (c *Config) ApplyDefaults(global GlobalConfig) error {
 			return fmt.Errorf("scrape interval greater than wal_truncate_frequency for scrape config with job name %q", sc.JobName)
 		}
 		if sc.ScrapeTimeout == 0 {
-			if c.global.Prometheus.ScrapeTimeout > sc.ScrapeInterval {
+			if scrapeTimeout > sc.ScrapeInterval {
 				sc.ScrapeTimeout = sc.ScrapeInterval
 			} else {
-				sc.ScrapeTimeout = c.global.Prometheus.ScrapeTimeout
+				sc.ScrapeTimeout = scrapeTimeout
 			}
 		}
+		scrapeLimits.applyTo(sc)
 
 		if _, exists := jobNames[sc.JobName]; exists {
 			return fmt.Errorf("found multiple scrape configs with job name %q", sc.JobName)
@@ -480,6 +502,8 @@ func (i *Instance) Update(c Config) (err error) {
 		err = errImmutableField{Field: "remote_flush_deadline"}
 	case i.cfg.WriteStaleOnShutdown != c.WriteStaleOnShutdown:
 		err = errImmutableField{Field: "write_stale_on_shutdown"}
+	case i.cfg.global.ExtraMetrics != c.global.ExtraMetrics:
+		err = errImmutableField{Field: "extra-scrape-metrics feature"}
 	}
 	if err != nil {
 		return ErrInvalidUpdate{Inner: err}
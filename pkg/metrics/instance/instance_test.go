@@ -24,6 +24,7 @@ import (
 	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
 )
 
 func TestConfig_Unmarshal_Defaults(t *testing.T) {
@@ -112,6 +113,14 @@ func TestConfig_ApplyDefaults_Validations(t *testing.T) {
 			func(c *Config) { c.ScrapeConfigs[0].ScrapeInterval = model.Duration(c.WALTruncateFrequency + 1) },
 			fmt.Errorf("scrape interval greater than wal_truncate_frequency for scrape config with job name \"scrape\""),
 		},
+		{
+			"instance scrape timeout too high",
+			func(c *Config) {
+				c.ScrapeInterval = model.Duration(time.Minute)
+				c.ScrapeTimeout = model.Duration(2 * time.Minute)
+			},
+			fmt.Errorf("scrape_timeout must not be greater than scrape_interval"),
+		},
 		{
 			"multiple scrape configs with same name",
 			func(c *Config) {
@@ -171,6 +180,53 @@ func TestConfig_ApplyDefaults_Validations(t *testing.T) {
 	}
 }
 
+func TestConfig_ApplyDefaults_ScrapeInheritance(t *testing.T) {
+	globalText := `
+scrape_interval: 1m
+scrape_timeout: 20s
+sample_limit: 1000
+label_limit: 30`
+
+	var global GlobalConfig
+	require.NoError(t, yaml.UnmarshalStrict([]byte(globalText), &global))
+
+	cfgText := `
+name: test
+scrape_interval: 30s
+sample_limit: 500
+scrape_configs:
+  - job_name: inherits
+    static_configs:
+      - targets: ['127.0.0.1:12345']
+  - job_name: overrides
+    scrape_interval: 15s
+    scrape_timeout: 5s
+    sample_limit: 100
+    label_limit: 10
+    static_configs:
+      - targets: ['127.0.0.1:12345']
+remote_write:
+  - url: http://localhost:9009/api/prom/push`
+
+	cfg, err := UnmarshalConfig(strings.NewReader(cfgText))
+	require.NoError(t, err)
+	require.NoError(t, cfg.ApplyDefaults(global))
+
+	// The first job inherits its interval and sample limit from the instance,
+	// and everything else from the global config.
+	inherits := cfg.ScrapeConfigs[0]
+	require.Equal(t, model.Duration(30*time.Second), inherits.ScrapeInterval)
+	require.Equal(t, model.Duration(20*time.Second), inherits.ScrapeTimeout)
+	require.Equal(t, uint(500), inherits.SampleLimit)
+	require.Equal(t, uint(30), inherits.LabelLimit)
+
+	overrides := cfg.ScrapeConfigs[1]
+	require.Equal(t, model.Duration(15*time.Second), overrides.ScrapeInterval)
+	require.Equal(t, model.Duration(5*time.Second), overrides.ScrapeTimeout)
+	require.Equal(t, uint(100), overrides.SampleLimit)
+	require.Equal(t, uint(10), overrides.LabelLimit)
+}
+
 func TestConfig_ApplyDefaults_HashedName(t *testing.T) {
 	cfgText := `
 name: default
@@ -0,0 +1,279 @@
+package wal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+	"github.com/prometheus/prometheus/tsdb/record"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+)
+
+// walPageSize is the size of the pages WAL segments are made of.
+const walPageSize = 32 * 1024
+
+// replayWAL loads the series of the WAL into memory. The checkpoint is loaded
+// first, after which the remaining segments are loaded concurrently.
+func (w *Storage) replayWAL() error {
+	w.walMtx.RLock()
+	defer w.walMtx.RUnlock()
+
+	if w.walClosed {
+		return ErrWALClosed
+	}
+
+	start := time.Now()
+	rs := newReplayState(w)
+
+	level.Info(w.logger).Log("msg", "replaying WAL, this may take a while", "dir", w.wal.Dir())
+	dir, startFrom, err := wlog.LastCheckpoint(w.wal.Dir())
+	if err != nil && err != record.ErrNotFound {
+		return fmt.Errorf("find last checkpoint: %w", err)
+	}
+
+	if err == nil {
+		sr, err := wlog.NewSegmentsReader(dir)
+		if err != nil {
+			return fmt.Errorf("open checkpoint: %w", err)
+		}
+		defer func() {
+			if err := sr.Close(); err != nil {
+				level.Warn(w.logger).Log("msg", "error while closing the wal segments reader", "err", err)
+			}
+		}()
+
+		// A corrupted checkpoint is a hard error for now and requires user
+		// intervention. There's likely little data that can be recovered anyway.
+		if err := w.loadWAL(wlog.NewReader(sr), rs); err != nil {
+			return fmt.Errorf("backfill checkpoint: %w", err)
+		}
+		startFrom++
+		level.Info(w.logger).Log("msg", "WAL checkpoint loaded")
+	}
+
+	// Find the last segment.
+	_, last, err := wlog.Segments(w.wal.Dir())
+	if err != nil {
+		return fmt.Errorf("finding WAL segments: %w", err)
+	}
+
+	// Backfill segments from the most recent checkpoint onwards. Samples may be
+	// read before the series they reference, which replayState accounts for.
+	var (
+		segments = make(chan int)
+		errs     []error
+		errsMut  sync.Mutex
+		wg       sync.WaitGroup
+	)
+	var numSegments int
+	if last >= startFrom {
+		numSegments = last - startFrom + 1
+	}
+	w.metrics.replaySegments.Set(float64(numSegments))
+	for n := 0; n < runtime.GOMAXPROCS(0); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range segments {
+				if err := w.replaySegment(i, rs); err != nil {
+					errsMut.Lock()
+					errs = append(errs, err)
+					errsMut.Unlock()
+					continue
+				}
+				w.metrics.replayedSegments.Inc()
+				level.Info(w.logger).Log("msg", "WAL segment loaded", "segment", i, "maxSegment", last)
+			}
+		}()
+	}
+	for i := startFrom; i <= last; i++ {
+		segments <- i
+	}
+	close(segments)
+	wg.Wait()
+
+	if err := firstCorruption(errs); err != nil {
+		// Series from segments after the corrupted one were loaded into memory,
+		// but will be deleted when the WAL is repaired. Forget about all series
+		// so that appends write new series records to the repaired WAL.
+		w.series = newStripeSeries()
+		w.metrics.numActiveSeries.Set(0)
+		w.ref.Store(rs.biggestRef)
+		return err
+	}
+	rs.finish()
+
+	w.metrics.replayDuration.Set(time.Since(start).Seconds())
+	level.Info(w.logger).Log("msg", "WAL replay completed", "segments", numSegments, "duration", time.Since(start))
+	return nil
+}
+
+// replaySegment loads the WAL segment with index i. The segment is
+// memory-mapped to avoid copying it through a read buffer.
+func (w *Storage) replaySegment(i int, rs *replayState) error {
+	name := wlog.SegmentName(w.wal.Dir(), i)
+	fi, err := os.Stat(name)
+	if err != nil {
+		return fmt.Errorf("open WAL segment %d: %w", i, err)
+	} else if fi.Size() == 0 {
+		// Empty files can't be memory-mapped, and don't have any records
+		// anyway.
+		return nil
+	}
+
+	f, err := fileutil.OpenMmapFile(name)
+	if err != nil {
+		return fmt.Errorf("open WAL segment %d: %w", i, err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			level.Warn(w.logger).Log("msg", "error while closing the wal segment", "segment", i, "err", err)
+		}
+	}()
+
+	err = w.loadWAL(wlog.NewReader(newPaddedReader(f.Bytes())), rs)
+
+	// The reader doesn't know which segment it read from, so the position of
+	// corruptions must be filled in for the WAL to be repaired.
+	var ce *wlog.CorruptionErr
+	if errors.As(err, &ce) {
+		ce.Dir, ce.Segment = w.wal.Dir(), i
+		return ce
+	}
+	return err
+}
+
+// newPaddedReader returns a reader for the contents of a segment. The last
+// segment may have been cut short when it was written, so its last page is
+// padded with zeros like the reader of the WAL package does.
+func newPaddedReader(b []byte) io.Reader {
+	var padding int
+	if rem := len(b) % walPageSize; rem != 0 {
+		padding = walPageSize - rem
+	}
+	return io.MultiReader(bytes.NewReader(b), bytes.NewReader(make([]byte, padding)))
+}
+
+// firstCorruption returns the error of the earliest corrupted segment in
+// errs. Other errors take precedence over corruptions, since they can't be
+// repaired.
+func firstCorruption(errs []error) error {
+	var first *wlog.CorruptionErr
+	for _, err := range errs {
+		var ce *wlog.CorruptionErr
+		if !errors.As(err, &ce) {
+			return err
+		}
+		if first == nil || ce.Segment < first.Segment {
+			first = ce
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	return first
+}
+
+// replayState rebuilds the in-memory series of a Storage from the records of
+// its WAL. Records may be added concurrently and in any order.
+type replayState struct {
+	w *Storage
+
+	mut        sync.Mutex
+	biggestRef uint64
+	pending    map[chunks.HeadSeriesRef]int64 // Latest timestamps of series which weren't read yet.
+}
+
+func newReplayState(w *Storage) *replayState {
+	return &replayState{
+		w:          w,
+		biggestRef: w.ref.Load(),
+		pending:    make(map[chunks.HeadSeriesRef]int64),
+	}
+}
+
+func (rs *replayState) addSeries(series []record.RefSeries) {
+	rs.mut.Lock()
+	defer rs.mut.Unlock()
+
+	for _, s := range series {
+		// If this is a new series, create it in memory without a timestamp.
+		// If we read in a sample for it, we'll use the timestamp of the latest
+		// sample. Otherwise, the series is stale and will be deleted once
+		// the truncation is performed.
+		if rs.w.series.getByID(s.Ref) != nil {
+			continue
+		}
+
+		series := &memSeries{ref: s.Ref, lset: s.Labels, lastTs: 0}
+		if t, ok := rs.pending[s.Ref]; ok {
+			series.lastTs = t
+			delete(rs.pending, s.Ref)
+		}
+		rs.w.series.setReplayed(s.Labels.Hash(), series)
+
+		rs.w.metrics.numActiveSeries.Inc()
+		rs.w.metrics.totalCreatedSeries.Inc()
+
+		if rs.biggestRef <= uint64(s.Ref) {
+			rs.biggestRef = uint64(s.Ref)
+		}
+	}
+}
+
+// updateTs updates the lastTs of the series identified by ref with a timestamp
+// read while replaying the WAL.
+func (rs *replayState) updateTs(ref chunks.HeadSeriesRef, t int64) {
+	if series := rs.w.series.getByID(ref); series != nil {
+		series.Lock()
+		if t > series.lastTs {
+			series.lastTs = t
+		}
+		series.Unlock()
+		return
+	}
+
+	// The series may be created concurrently, in which case the pending
+	// timestamp is applied by finish.
+	rs.mut.Lock()
+	if t > rs.pending[ref] {
+		rs.pending[ref] = t
+	}
+	rs.mut.Unlock()
+}
+
+// finish applies the timestamps of samples which were read before their
+// series, and stores the biggest ref which was read. finish must be called
+// once all records were added.
+func (rs *replayState) finish() {
+	rs.mut.Lock()
+	defer rs.mut.Unlock()
+
+	var missing int
+	for ref, t := range rs.pending {
+		series := rs.w.series.getByID(ref)
+		if series == nil {
+			missing++
+			continue
+		}
+
+		series.Lock()
+		if t > series.lastTs {
+			series.lastTs = t
+		}
+		series.Unlock()
+	}
+	if missing > 0 {
+		level.Warn(rs.w.logger).Log("msg", "found samples referencing non-existing series, skipping", "series", missing)
+	}
+
+	rs.w.ref.Store(rs.biggestRef)
+}
@@ -35,7 +35,10 @@ type memSeries struct {
 }
 
 func (s *memSeries) updateTs(ts int64) {
-	s.lastTs = ts
+	// Out-of-order samples must not move lastTs backwards.
+	if ts > s.lastTs {
+		s.lastTs = ts
+	}
 	s.willDelete = false
 	s.pendingCommit = true
 }
@@ -227,6 +230,23 @@ func (s *stripeSeries) set(hash uint64, series *memSeries) {
 	s.locks[i].Unlock()
 }
 
+// setReplayed is like set, but doesn't replace a series with the same labels
+// which has a higher ref, and so was created more recently. This allows series
+// to be replayed out of order.
+func (s *stripeSeries) setReplayed(hash uint64, series *memSeries) {
+	i := hash & uint64(s.size-1)
+	s.locks[i].Lock()
+	if prev := s.hashes[i].get(hash, series.lset); prev == nil || prev.ref < series.ref {
+		s.hashes[i].set(hash, series)
+	}
+	s.locks[i].Unlock()
+
+	i = uint64(series.ref) & uint64(s.size-1)
+	s.locks[i].Lock()
+	s.series[i][series.ref] = series
+	s.locks[i].Unlock()
+}
+
 func (s *stripeSeries) getLatestExemplar(id chunks.HeadSeriesRef) *exemplar.Exemplar {
 	i := id & chunks.HeadSeriesRef(s.size-1)
 
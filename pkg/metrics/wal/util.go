@@ -1,6 +1,7 @@
 package wal
 
 import (
+	"io/fs"
 	"path/filepath"
 	"sync"
 
@@ -151,3 +152,23 @@ func (*walDataCollector) UpdateSeriesSegment([]record.RefSeries, int) {}
 func SubDirectory(base string) string {
 	return filepath.Join(base, "wal")
 }
+
+// dirSize returns the total size of the files in dir and its subdirectories.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"os"
 	"sync"
 	"time"
 	"unicode/utf8"
@@ -39,6 +40,13 @@ type storageMetrics struct {
 	totalRemovedSeries     prometheus.Counter
 	totalAppendedSamples   prometheus.Counter
 	totalAppendedExemplars prometheus.Counter
+	totalSizeDropped       prometheus.Counter
+	walSize                prometheus.Gauge
+	totalOutOfOrderSamples prometheus.Counter
+	totalTooOldSamples     prometheus.Counter
+	replaySegments         prometheus.Gauge
+	replayedSegments       prometheus.Gauge
+	replayDuration         prometheus.Gauge
 }
 
 func newStorageMetrics(r prometheus.Registerer) *storageMetrics {
@@ -73,6 +81,41 @@ func newStorageMetrics(r prometheus.Registerer) *storageMetrics {
 		Help: "Total number of exemplars appended to the WAL",
 	})
 
+	m.totalSizeDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "agent_wal_samples_dropped_by_size_total",
+		Help: "Total number of samples dropped from the WAL because it exceeded its maximum size",
+	})
+
+	m.walSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "agent_wal_storage_size_bytes",
+		Help: "Size of the WAL on disk as of the last size check",
+	})
+
+	m.totalOutOfOrderSamples = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "agent_wal_out_of_order_samples_total",
+		Help: "Total number of out-of-order samples appended to the WAL",
+	})
+
+	m.totalTooOldSamples = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "agent_wal_too_old_samples_total",
+		Help: "Total number of out-of-order samples rejected for being outside of the out-of-order time window",
+	})
+
+	m.replaySegments = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "agent_wal_replay_segments",
+		Help: "Number of WAL segments to replay after the checkpoint on startup",
+	})
+
+	m.replayedSegments = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "agent_wal_replay_segments_replayed",
+		Help: "Number of WAL segments replayed so far on startup",
+	})
+
+	m.replayDuration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "agent_wal_replay_duration_seconds",
+		Help: "Time it took to replay the WAL on startup",
+	})
+
 	if r != nil {
 		r.MustRegister(
 			m.numActiveSeries,
@@ -81,6 +124,13 @@ func newStorageMetrics(r prometheus.Registerer) *storageMetrics {
 			m.totalRemovedSeries,
 			m.totalAppendedSamples,
 			m.totalAppendedExemplars,
+			m.totalSizeDropped,
+			m.walSize,
+			m.totalOutOfOrderSamples,
+			m.totalTooOldSamples,
+			m.replaySegments,
+			m.replayedSegments,
+			m.replayDuration,
 		)
 	}
 
@@ -98,6 +148,13 @@ func (m *storageMetrics) Unregister() {
 		m.totalRemovedSeries,
 		m.totalAppendedSamples,
 		m.totalAppendedExemplars,
+		m.totalSizeDropped,
+		m.walSize,
+		m.totalOutOfOrderSamples,
+		m.totalTooOldSamples,
+		m.replaySegments,
+		m.replayedSegments,
+		m.replayDuration,
 	}
 	for _, c := range cs {
 		m.r.Unregister(c)
@@ -127,6 +184,11 @@ type Storage struct {
 	ref    *atomic.Uint64
 	series *stripeSeries
 
+	// oooTimeWindow is how much older than the newest sample of a series an
+	// out-of-order sample may be, in milliseconds. Out-of-order samples are
+	// rejected when oooTimeWindow is 0.
+	oooTimeWindow *atomic.Int64
+
 	deletedMtx sync.Mutex
 	deleted    map[chunks.HeadSeriesRef]int // Deleted series, and what WAL segment they must be kept until.
 
@@ -148,6 +210,8 @@ func NewStorage(logger log.Logger, registerer prometheus.Registerer, path string
 		series:  newStripeSeries(),
 		metrics: newStorageMetrics(registerer),
 		ref:     atomic.NewUint64(0),
+
+		oooTimeWindow: atomic.NewInt64(0),
 	}
 
 	storage.bufPool.New = func() interface{} {
@@ -188,68 +252,21 @@ func NewStorage(logger log.Logger, registerer prometheus.Registerer, path string
 	return storage, nil
 }
 
-func (w *Storage) replayWAL() error {
-	w.walMtx.RLock()
-	defer w.walMtx.RUnlock()
-
-	if w.walClosed {
-		return ErrWALClosed
-	}
-
-	level.Info(w.logger).Log("msg", "replaying WAL, this may take a while", "dir", w.wal.Dir())
-	dir, startFrom, err := wlog.LastCheckpoint(w.wal.Dir())
-	if err != nil && err != record.ErrNotFound {
-		return fmt.Errorf("find last checkpoint: %w", err)
-	}
-
-	if err == nil {
-		sr, err := wlog.NewSegmentsReader(dir)
-		if err != nil {
-			return fmt.Errorf("open checkpoint: %w", err)
-		}
-		defer func() {
-			if err := sr.Close(); err != nil {
-				level.Warn(w.logger).Log("msg", "error while closing the wal segments reader", "err", err)
-			}
-		}()
-
-		// A corrupted checkpoint is a hard error for now and requires user
-		// intervention. There's likely little data that can be recovered anyway.
-		if err := w.loadWAL(wlog.NewReader(sr)); err != nil {
-			return fmt.Errorf("backfill checkpoint: %w", err)
-		}
-		startFrom++
-		level.Info(w.logger).Log("msg", "WAL checkpoint loaded")
-	}
-
-	// Find the last segment.
-	_, last, err := wlog.Segments(w.wal.Dir())
-	if err != nil {
-		return fmt.Errorf("finding WAL segments: %w", err)
-	}
-
-	// Backfill segments from the most recent checkpoint onwards.
-	for i := startFrom; i <= last; i++ {
-		s, err := wlog.OpenReadSegment(wlog.SegmentName(w.wal.Dir(), i))
-		if err != nil {
-			return fmt.Errorf("open WAL segment %d: %w", i, err)
-		}
-
-		sr := wlog.NewSegmentBufReader(s)
-		err = w.loadWAL(wlog.NewReader(sr))
-		if err := sr.Close(); err != nil {
-			level.Warn(w.logger).Log("msg", "error while closing the wal segments reader", "err", err)
-		}
-		if err != nil {
-			return err
-		}
-		level.Info(w.logger).Log("msg", "WAL segment loaded", "segment", i, "maxSegment", last)
-	}
-
-	return nil
+// SetOutOfOrderTimeWindow sets how much older than the newest sample of a
+// series an out-of-order sample may be, in milliseconds. Appending a sample
+// which is older than that fails with storage.ErrTooOldSample.
+//
+// Out-of-order samples are rejected with storage.ErrOutOfOrderSample when
+// window is 0, which is the default.
+func (w *Storage) SetOutOfOrderTimeWindow(window int64) {
+	if window < 0 {
+		window = 0
+	}
+	w.oooTimeWindow.Store(window)
 }
 
-func (w *Storage) loadWAL(r *wlog.Reader) (err error) {
+// loadWAL passes the records read from r to rs.
+func (w *Storage) loadWAL(r *wlog.Reader, rs *replayState) (err error) {
 	var (
 		dec record.Decoder
 	)
@@ -267,6 +284,16 @@ func (w *Storage) loadWAL(r *wlog.Reader) (err error) {
 				return []record.RefSample{}
 			},
 		}
+		histogramsPool = sync.Pool{
+			New: func() interface{} {
+				return []record.RefHistogramSample{}
+			},
+		}
+		floatHistogramsPool = sync.Pool{
+			New: func() interface{} {
+				return []record.RefFloatHistogramSample{}
+			},
+		}
 	)
 
 	go func() {
@@ -297,6 +324,30 @@ func (w *Storage) loadWAL(r *wlog.Reader) (err error) {
 					}
 				}
 				decoded <- samples
+			case record.HistogramSamples:
+				histograms := histogramsPool.Get().([]record.RefHistogramSample)[:0]
+				histograms, err = dec.HistogramSamples(rec, histograms)
+				if err != nil {
+					errCh <- &wlog.CorruptionErr{
+						Err:     fmt.Errorf("decode histogram samples: %w", err),
+						Segment: r.Segment(),
+						Offset:  r.Offset(),
+					}
+					return
+				}
+				decoded <- histograms
+			case record.FloatHistogramSamples:
+				floatHistograms := floatHistogramsPool.Get().([]record.RefFloatHistogramSample)[:0]
+				floatHistograms, err = dec.FloatHistogramSamples(rec, floatHistograms)
+				if err != nil {
+					errCh <- &wlog.CorruptionErr{
+						Err:     fmt.Errorf("decode float histogram samples: %w", err),
+						Segment: r.Segment(),
+						Offset:  r.Offset(),
+					}
+					return
+				}
+				decoded <- floatHistograms
 			case record.Tombstones, record.Exemplars:
 				// We don't care about decoding tombstones or exemplars
 				// TODO: If decide to decode exemplars, we should make sure to prepopulate
@@ -313,56 +364,39 @@ func (w *Storage) loadWAL(r *wlog.Reader) (err error) {
 		}
 	}()
 
-	var biggestRef uint64 = w.ref.Load()
-
 	for d := range decoded {
 		switch v := d.(type) {
 		case []record.RefSeries:
-			for _, s := range v {
-				// If this is a new series, create it in memory without a timestamp.
-				// If we read in a sample for it, we'll use the timestamp of the latest
-				// sample. Otherwise, the series is stale and will be deleted once
-				// the truncation is performed.
-				if w.series.getByID(s.Ref) == nil {
-					series := &memSeries{ref: s.Ref, lset: s.Labels, lastTs: 0}
-					w.series.set(s.Labels.Hash(), series)
-
-					w.metrics.numActiveSeries.Inc()
-					w.metrics.totalCreatedSeries.Inc()
-
-					if biggestRef <= uint64(s.Ref) {
-						biggestRef = uint64(s.Ref)
-					}
-				}
-			}
+			rs.addSeries(v)
 
 			//nolint:staticcheck
 			seriesPool.Put(v)
 		case []record.RefSample:
 			for _, s := range v {
-				// Update the lastTs for the series based
-				series := w.series.getByID(s.Ref)
-				if series == nil {
-					level.Warn(w.logger).Log("msg", "found sample referencing non-existing series, skipping")
-					continue
-				}
-
-				series.Lock()
-				if s.T > series.lastTs {
-					series.lastTs = s.T
-				}
-				series.Unlock()
+				rs.updateTs(s.Ref, s.T)
 			}
 
 			//nolint:staticcheck
 			samplesPool.Put(v)
+		case []record.RefHistogramSample:
+			for _, h := range v {
+				rs.updateTs(h.Ref, h.T)
+			}
+
+			//nolint:staticcheck
+			histogramsPool.Put(v)
+		case []record.RefFloatHistogramSample:
+			for _, fh := range v {
+				rs.updateTs(fh.Ref, fh.T)
+			}
+
+			//nolint:staticcheck
+			floatHistogramsPool.Put(v)
 		default:
 			panic(fmt.Errorf("unexpected decoded type: %T", d))
 		}
 	}
 
-	w.ref.Store(biggestRef)
-
 	select {
 	case err := <-errCh:
 		return err
@@ -432,6 +466,86 @@ func (w *Storage) Truncate(mint int64) error {
 		return nil
 	}
 
+	if _, err := w.checkpoint(first, last, mint); err != nil {
+		return err
+	}
+
+	level.Info(w.logger).Log("msg", "WAL checkpoint complete",
+		"first", first, "last", last, "duration", time.Since(start))
+	return nil
+}
+
+// TruncateSize removes the oldest segments from the WAL until the WAL is no
+// larger than maxSize bytes. Samples in the removed segments are dropped,
+// even if they haven't been sent yet, while series records are kept in a
+// checkpoint. The segment currently being written to is never removed, so
+// the WAL may still be larger than maxSize afterwards.
+//
+// TruncateSize is used to bound disk usage when samples can't be sent for a
+// long period of time.
+func (w *Storage) TruncateSize(maxSize int64) error {
+	w.walMtx.RLock()
+	defer w.walMtx.RUnlock()
+
+	if w.walClosed {
+		return ErrWALClosed
+	}
+
+	size, err := dirSize(w.wal.Dir())
+	if err != nil {
+		return fmt.Errorf("get WAL size: %w", err)
+	}
+	w.metrics.walSize.Set(float64(size))
+	if size <= maxSize {
+		return nil
+	}
+
+	start := time.Now()
+
+	first, last, err := wlog.Segments(w.wal.Dir())
+	if err != nil {
+		return fmt.Errorf("get segment range: %w", err)
+	}
+
+	// Start a new segment so that the segment which was being written to can
+	// be removed.
+	if _, err := w.wal.NextSegment(); err != nil {
+		return fmt.Errorf("next segment: %w", err)
+	}
+
+	// Find the oldest segments which need to be removed for the WAL to fit in
+	// maxSize.
+	drop := -1
+	for i := first; i <= last && size > maxSize; i++ {
+		fi, err := os.Stat(wlog.SegmentName(w.wal.Dir(), i))
+		if err != nil {
+			return fmt.Errorf("get segment size: %w", err)
+		}
+		size -= fi.Size()
+		drop = i
+	}
+	if drop < 0 {
+		return nil
+	}
+
+	// Using the maximum timestamp for the checkpoint drops every sample in the
+	// removed segments.
+	stats, err := w.checkpoint(first, drop, math.MaxInt64)
+	if err != nil {
+		return err
+	}
+	w.metrics.totalSizeDropped.Add(float64(stats.DroppedSamples))
+
+	level.Warn(w.logger).Log("msg", "WAL exceeded its maximum size; dropped oldest segments",
+		"max_size", maxSize, "first", first, "last", drop, "dropped_samples", stats.DroppedSamples,
+		"duration", time.Since(start))
+	return nil
+}
+
+// checkpoint creates a checkpoint of the segments from first to last,
+// keeping samples which are newer than mint, and then removes the segments.
+// w.walMtx must be held.
+func (w *Storage) checkpoint(first, last int, mint int64) (*wlog.CheckpointStats, error) {
 	keep := func(id chunks.HeadSeriesRef) bool {
 		if w.series.getByID(id) != nil {
 			return true
@@ -442,8 +556,9 @@ func (w *Storage) Truncate(mint int64) error {
 		w.deletedMtx.Unlock()
 		return ok
 	}
-	if _, err = wlog.Checkpoint(w.logger, w.wal, first, last, keep, mint); err != nil {
-		return fmt.Errorf("create checkpoint: %w", err)
+	stats, err := wlog.Checkpoint(w.logger, w.wal, first, last, keep, mint)
+	if err != nil {
+		return nil, fmt.Errorf("create checkpoint: %w", err)
 	}
 	if err := w.wal.Truncate(last + 1); err != nil {
 		// If truncating fails, we'll just try again at the next checkpoint.
@@ -470,10 +585,7 @@ func (w *Storage) Truncate(mint int64) error {
 		// They will just be ignored since a higher checkpoint exists.
 		level.Error(w.logger).Log("msg", "delete old checkpoints", "err", err)
 	}
-
-	level.Info(w.logger).Log("msg", "WAL checkpoint complete",
-		"first", first, "last", last, "duration", time.Since(start))
-	return nil
+	return stats, nil
 }
 
 // gc removes data before the minimum timestamp from the head.
@@ -617,6 +729,10 @@ func (a *appender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v flo
 	series.Lock()
 	defer series.Unlock()
 
+	if err := a.checkOutOfOrder(series, t); err != nil {
+		return 0, err
+	}
+
 	// Update last recorded timestamp. Used by Storage.gc to determine if a
 	// series is stale.
 	series.updateTs(t)
@@ -661,6 +777,10 @@ func (a *appender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int
 	series.Lock()
 	defer series.Unlock()
 
+	if err := a.checkOutOfOrder(series, t); err != nil {
+		return 0, err
+	}
+
 	// Update last recorded timestamp. Used by Storage.gc to determine if a
 	// series is stale.
 	series.updateTs(t)
@@ -684,6 +804,26 @@ func (a *appender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int
 	return storage.SeriesRef(series.ref), nil
 }
 
+// checkOutOfOrder returns an error if t is too old to be appended to series.
+// series must be locked when calling checkOutOfOrder.
+func (a *appender) checkOutOfOrder(series *memSeries, t int64) error {
+	if t >= series.lastTs {
+		return nil
+	}
+
+	window := a.w.oooTimeWindow.Load()
+	switch {
+	case window <= 0:
+		a.w.metrics.totalTooOldSamples.Inc()
+		return storage.ErrOutOfOrderSample
+	case t < series.lastTs-window:
+		a.w.metrics.totalTooOldSamples.Inc()
+		return storage.ErrTooOldSample
+	}
+	a.w.metrics.totalOutOfOrderSamples.Inc()
+	return nil
+}
+
 func (a *appender) getOrCreate(l labels.Labels) (series *memSeries, created bool) {
 	hash := l.Hash()
 
@@ -3,19 +3,25 @@ package wal
 import (
 	"context"
 	"math"
+	"os"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/record"
+	"github.com/prometheus/prometheus/tsdb/wlog"
 	"github.com/stretchr/testify/require"
 )
 
@@ -59,6 +65,43 @@ func TestStorage_InvalidSeries(t *testing.T) {
 	require.NoError(t, err, "should not reject valid exemplars")
 }
 
+func TestStorage_OutOfOrder(t *testing.T) {
+	walDir := t.TempDir()
+
+	s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	lbls := labels.FromStrings("__name__", "foo")
+
+	app := s.Appender(context.Background())
+	ref, err := app.Append(0, lbls, 10_000, 1)
+	require.NoError(t, err)
+
+	// Out-of-order samples are rejected when there is no time window.
+	_, err = app.Append(ref, lbls, 9_999, 2)
+	require.ErrorIs(t, err, storage.ErrOutOfOrderSample)
+	require.Equal(t, 0.0, testutil.ToFloat64(s.metrics.totalOutOfOrderSamples))
+	require.Equal(t, 1.0, testutil.ToFloat64(s.metrics.totalTooOldSamples))
+
+	// Out-of-order samples within the time window are accepted, and older
+	// samples are rejected.
+	s.SetOutOfOrderTimeWindow(5_000)
+	_, err = app.Append(ref, lbls, 5_000, 3)
+	require.NoError(t, err)
+	_, err = app.Append(ref, lbls, 4_999, 4)
+	require.ErrorIs(t, err, storage.ErrTooOldSample)
+	require.Equal(t, 1.0, testutil.ToFloat64(s.metrics.totalOutOfOrderSamples))
+	require.Equal(t, 2.0, testutil.ToFloat64(s.metrics.totalTooOldSamples))
+	require.NoError(t, app.Commit())
+
+	// Out-of-order samples must not move the series timestamp backwards.
+	series := s.series.getByID(chunks.HeadSeriesRef(ref))
+	require.Equal(t, int64(10_000), series.lastTs)
+}
+
 func TestStorage(t *testing.T) {
 	walDir := t.TempDir()
 
@@ -204,6 +247,122 @@ func TestStorage_ExistingWAL(t *testing.T) {
 	require.Equal(t, expectedExemplars, actualExemplars)
 }
 
+func TestStorage_ExistingWAL_Histograms(t *testing.T) {
+	walDir := t.TempDir()
+
+	s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+
+	h := &histogram.Histogram{
+		Count:           2,
+		ZeroThreshold:   0.001,
+		Sum:             6,
+		Schema:          0,
+		PositiveSpans:   []histogram.Span{{Offset: 0, Length: 2}},
+		PositiveBuckets: []int64{1, 0},
+	}
+
+	app := s.Appender(context.Background())
+	_, err = app.AppendHistogram(0, labels.FromStrings("__name__", "int_histogram"), 10, h, nil)
+	require.NoError(t, err)
+	_, err = app.AppendHistogram(0, labels.FromStrings("__name__", "float_histogram"), 20, nil, h.ToFloat())
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+	require.NoError(t, s.Close())
+
+	// Replaying histogram records must not be treated as WAL corruption.
+	s, err = NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	lastTs := map[string]int64{}
+	for series := range s.series.iterator().Channel() {
+		lastTs[series.lset.Get("__name__")] = series.lastTs
+	}
+	require.Equal(t, map[string]int64{"int_histogram": 10, "float_histogram": 20}, lastTs)
+}
+
+func TestStorage_ExistingWAL_Segments(t *testing.T) {
+	walDir := t.TempDir()
+	lbls := labels.FromStrings("__name__", "metric")
+
+	// Every storage writes to a new segment, so samples are spread across
+	// segments which are replayed concurrently, and most of them reference a
+	// series from an earlier segment.
+	for i := 1; i <= 10; i++ {
+		s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+		require.NoError(t, err)
+
+		app := s.Appender(context.Background())
+		_, err = app.Append(0, lbls, int64(i), float64(i))
+		require.NoError(t, err)
+		require.NoError(t, app.Commit())
+		require.NoError(t, s.Close())
+	}
+
+	reg := prometheus.NewRegistry()
+	s, err := NewStorage(log.NewNopLogger(), reg, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	series := s.series.getByHash(lbls.Hash(), lbls)
+	require.NotNil(t, series)
+	require.Equal(t, int64(10), series.lastTs)
+	require.Equal(t, uint64(1), s.ref.Load())
+
+	// The storage opened a new segment before replaying, which is replayed as
+	// well.
+	err = testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP agent_wal_replay_segments Number of WAL segments to replay after the checkpoint on startup
+		# TYPE agent_wal_replay_segments gauge
+		agent_wal_replay_segments 11
+		# HELP agent_wal_replay_segments_replayed Number of WAL segments replayed so far on startup
+		# TYPE agent_wal_replay_segments_replayed gauge
+		agent_wal_replay_segments_replayed 11
+	`), "agent_wal_replay_segments", "agent_wal_replay_segments_replayed")
+	require.NoError(t, err)
+}
+
+func TestStorage_CorruptedSegment(t *testing.T) {
+	walDir := t.TempDir()
+	lbls := labels.FromStrings("__name__", "metric")
+
+	for i := 1; i <= 4; i++ {
+		s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+		require.NoError(t, err)
+
+		app := s.Appender(context.Background())
+		_, err = app.Append(0, lbls, int64(i), float64(i))
+		require.NoError(t, err)
+		require.NoError(t, app.Commit())
+		require.NoError(t, s.Close())
+	}
+
+	// Corrupt the record type of the first record in segment 1.
+	segment := wlog.SegmentName(SubDirectory(walDir), 1)
+	f, err := os.OpenFile(segment, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte{0xff}, 0)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// The WAL is repaired by removing the segments after the corrupted one,
+	// after which a new segment is started.
+	s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	_, last, err := wlog.Segments(SubDirectory(walDir))
+	require.NoError(t, err)
+	require.Equal(t, 2, last)
+}
+
 func TestStorage_ExistingWAL_RefID(t *testing.T) {
 	l := util.TestLogger(t)
 
@@ -297,6 +456,65 @@ func TestStorage_Truncate(t *testing.T) {
 	require.Equal(t, expectedExemplars, actualExemplars)
 }
 
+func TestStorage_TruncateSize(t *testing.T) {
+	// Write two sets of series into separate segments, then truncate the WAL
+	// to a size which only fits the second segment. Expect the samples from
+	// the first segment to be dropped while all series are kept.
+	walDir := t.TempDir()
+
+	s, err := NewStorage(log.NewNopLogger(), nil, walDir)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, s.Close())
+	}()
+
+	dropped := buildSeries([]string{"foo", "bar"})
+	kept := buildSeries([]string{"baz", "blerg"})
+
+	app := s.Appender(context.Background())
+	for _, metric := range dropped {
+		metric.Write(t, app)
+	}
+	require.NoError(t, app.Commit())
+	_, err = s.wal.NextSegmentSync()
+	require.NoError(t, err)
+
+	app = s.Appender(context.Background())
+	for _, metric := range kept {
+		metric.Write(t, app)
+	}
+	require.NoError(t, app.Commit())
+	_, err = s.wal.NextSegmentSync()
+	require.NoError(t, err)
+
+	firstSegment, err := os.Stat(wlog.SegmentName(s.wal.Dir(), 0))
+	require.NoError(t, err)
+	totalSize, err := dirSize(s.wal.Dir())
+	require.NoError(t, err)
+
+	require.NoError(t, s.TruncateSize(totalSize-firstSegment.Size()))
+	require.Equal(t, float64(len(dropped.ExpectedSamples())), testutil.ToFloat64(s.metrics.totalSizeDropped))
+
+	// Read back the WAL, collect series and samples.
+	collector := walDataCollector{}
+	replayer := walReplayer{w: &collector}
+	require.NoError(t, replayer.Replay(s.wal.Dir()))
+
+	names := []string{}
+	for _, series := range collector.series {
+		names = append(names, series.Labels.Get("__name__"))
+	}
+	require.ElementsMatch(t, append(dropped.SeriesNames(), kept.SeriesNames()...), names)
+
+	actualSamples := collector.samples
+	sort.Sort(byRefSample(actualSamples))
+	require.Equal(t, kept.ExpectedSamples(), actualSamples)
+
+	// The WAL is now small enough, so truncating again does nothing.
+	require.NoError(t, s.TruncateSize(totalSize))
+	require.Equal(t, float64(len(dropped.ExpectedSamples())), testutil.ToFloat64(s.metrics.totalSizeDropped))
+}
+
 func TestStorage_WriteStalenessMarkers(t *testing.T) {
 	walDir := t.TempDir()
 
@@ -0,0 +1,196 @@
+package diag
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/grafana/agent/pkg/river/token"
+)
+
+// String returns the lowercase name of the severity level.
+func (s Severity) String() string {
+	switch s {
+	case SeverityLevelWarn:
+		return "warning"
+	case SeverityLevelError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// jsonDiagnostic is the JSON representation of a Diagnostic.
+type jsonDiagnostic struct {
+	Severity string        `json:"severity"`
+	Message  string        `json:"message"`
+	Value    string        `json:"value,omitempty"`
+	Start    *jsonPosition `json:"start,omitempty"`
+	End      *jsonPosition `json:"end,omitempty"`
+}
+
+type jsonPosition struct {
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Offset   int    `json:"offset"`
+}
+
+func newJSONPosition(pos token.Position) *jsonPosition {
+	if !pos.Valid() {
+		return nil
+	}
+	return &jsonPosition{
+		Filename: pos.Filename,
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Offset:   pos.Offset,
+	}
+}
+
+// FprintJSON writes diags to w as a JSON array. Each element holds the
+// severity, message, and start and end positions of a diagnostic. End
+// positions are inclusive, and are omitted along with start positions when
+// unknown.
+func FprintJSON(w io.Writer, diags Diagnostics) error {
+	res := make([]jsonDiagnostic, 0, len(diags))
+	for _, d := range diags {
+		res = append(res, jsonDiagnostic{
+			Severity: d.Severity.String(),
+			Message:  d.Message,
+			Value:    d.Value,
+			Start:    newJSONPosition(d.StartPos),
+			End:      newJSONPosition(d.EndPos),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// Tool describes the program which reported diagnostics in SARIF output.
+type Tool struct {
+	Name           string
+	Version        string
+	InformationURI string
+}
+
+// SARIF 2.1.0 types. Only the subset of the format used to report
+// diagnostics is defined.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name           string `json:"name"`
+		Version        string `json:"version,omitempty"`
+		InformationURI string `json:"informationUri,omitempty"`
+	}
+
+	sarifResult struct {
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations,omitempty"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+		EndLine     int `json:"endLine,omitempty"`
+		EndColumn   int `json:"endColumn,omitempty"`
+	}
+)
+
+// FprintSARIF writes diags to w as a SARIF 2.1.0 log with a single run of
+// tool. SARIF is understood by many CI systems and code review tools, which
+// can use it to annotate the lines of a file which have problems.
+//
+// Diagnostics without a filename are reported without a location.
+func FprintSARIF(w io.Writer, tool Tool, diags Diagnostics) error {
+	results := make([]sarifResult, 0, len(diags))
+	for _, d := range diags {
+		res := sarifResult{
+			Level:   sarifLevel(d.Severity),
+			Message: sarifMessage{Text: d.Message},
+		}
+		if d.StartPos.Filename != "" {
+			loc := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: d.StartPos.Filename},
+			}
+			if d.StartPos.Valid() {
+				end := d.EndPos
+				if !end.Valid() {
+					end = d.StartPos
+				}
+
+				// SARIF end columns are exclusive, while diagnostic end positions
+				// are inclusive.
+				loc.Region = &sarifRegion{
+					StartLine:   d.StartPos.Line,
+					StartColumn: d.StartPos.Column,
+					EndLine:     end.Line,
+					EndColumn:   end.Column + 1,
+				}
+			}
+			res.Locations = []sarifLocation{{PhysicalLocation: loc}}
+		}
+		results = append(results, res)
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           tool.Name,
+				Version:        tool.Version,
+				InformationURI: tool.InformationURI,
+			}},
+			Results: results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityLevelWarn:
+		return "warning"
+	case SeverityLevelError:
+		return "error"
+	default:
+		return "none"
+	}
+}
@@ -0,0 +1,74 @@
+package diag_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/token"
+	"github.com/stretchr/testify/require"
+)
+
+var encodeDiags = diag.Diagnostics{
+	{
+		Severity: diag.SeverityLevelError,
+		StartPos: token.Position{Filename: "config.river", Line: 2, Column: 3, Offset: 12},
+		EndPos:   token.Position{Filename: "config.river", Line: 2, Column: 8, Offset: 17},
+		Message:  "unrecognized attribute name \"targts\"",
+	},
+	{
+		Severity: diag.SeverityLevelWarn,
+		Message:  "something without a position",
+	},
+}
+
+func TestFprintJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, diag.FprintJSON(&buf, encodeDiags))
+
+	require.JSONEq(t, `[
+		{
+			"severity": "error",
+			"message": "unrecognized attribute name \"targts\"",
+			"start": {"filename": "config.river", "line": 2, "column": 3, "offset": 12},
+			"end": {"filename": "config.river", "line": 2, "column": 8, "offset": 17}
+		},
+		{
+			"severity": "warning",
+			"message": "something without a position"
+		}
+	]`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, diag.FprintJSON(&buf, nil))
+	require.JSONEq(t, `[]`, buf.String())
+}
+
+func TestFprintSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, diag.FprintSARIF(&buf, diag.Tool{Name: "test", Version: "v1.0.0"}, encodeDiags))
+
+	require.JSONEq(t, `{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": [{
+			"tool": {"driver": {"name": "test", "version": "v1.0.0"}},
+			"results": [
+				{
+					"level": "error",
+					"message": {"text": "unrecognized attribute name \"targts\""},
+					"locations": [{
+						"physicalLocation": {
+							"artifactLocation": {"uri": "config.river"},
+							"region": {"startLine": 2, "startColumn": 3, "endLine": 2, "endColumn": 9}
+						}
+					}]
+				},
+				{
+					"level": "warning",
+					"message": {"text": "something without a position"}
+				}
+			]
+		}]
+	}`, buf.String())
+}
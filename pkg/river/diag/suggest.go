@@ -0,0 +1,47 @@
+package diag
+
+import "sort"
+
+// Suggest returns the candidate which is closest to name by edit distance,
+// for use in "did you mean" hints for misspelled names. Suggest returns an
+// empty string if no candidate is close enough to name to be a likely typo.
+func Suggest(name string, candidates []string) string {
+	sorted := append([]string(nil), candidates...)
+	sort.Strings(sorted)
+
+	var (
+		best     string
+		bestDist = len(name)/3 + 1 // Maximum distance to consider a typo.
+	)
+	for _, c := range sorted {
+		if c == name {
+			continue
+		}
+		if d := editDistance(name, c); d <= bestDist && (best == "" || d < bestDist) {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(min(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
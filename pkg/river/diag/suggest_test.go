@@ -0,0 +1,29 @@
+package diag_test
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"frequency", "filename", "url", "targets", "forward_to"}
+
+	tt := []struct {
+		name   string
+		expect string
+	}{
+		{"frequenc", "frequency"},
+		{"filname", "filename"},
+		{"uri", "url"},
+		{"targts", "targets"},
+		{"forwardto", "forward_to"},
+		{"something_else", ""},
+		{"id", ""},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.expect, diag.Suggest(tc.name, candidates), tc.name)
+	}
+}
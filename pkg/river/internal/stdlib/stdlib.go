@@ -20,6 +20,22 @@ var Identifiers = map[string]interface{}{
 
 	"env": os.Getenv,
 
+	// coalesce returns the first argument which isn't a zero value, which allows
+	// environment variables to override defaults, such as
+	// coalesce(env("NAME"), "default").
+	"coalesce": value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+		if len(args) == 0 {
+			return value.Null, nil
+		}
+
+		for _, arg := range args {
+			if !isZero(arg) {
+				return arg, nil
+			}
+		}
+		return args[len(args)-1], nil
+	}),
+
 	// concat is implemented as a raw function so it can bypass allocations
 	// converting arguments into []interface{}. concat is optimized to allow it
 	// to perform well when it is in the hot path for combining targets from many
@@ -74,3 +90,22 @@ var Identifiers = map[string]interface{}{
 		return res, nil
 	},
 }
+
+// isZero returns true if v is the zero value of its type. Arrays and objects
+// are zero values when they're empty.
+func isZero(v value.Value) bool {
+	switch v.Type() {
+	case value.TypeNull:
+		return true
+	case value.TypeString:
+		return v.Text() == ""
+	case value.TypeNumber:
+		return v.Float() == 0
+	case value.TypeBool:
+		return !v.Bool()
+	case value.TypeArray, value.TypeObject:
+		return v.Len() == 0
+	default:
+		return v.Reflect().IsZero()
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/grafana/agent/pkg/river/diag"
 	"github.com/grafana/agent/pkg/river/internal/reflectutil"
 )
 
@@ -17,6 +18,15 @@ type Unmarshaler interface {
 	UnmarshalRiver(f func(v interface{}) error) error
 }
 
+// Validator is a custom type which validates itself once it has been decoded
+// from a block.
+type Validator interface {
+	// Validate is called after decoding a block. Errors returned by Validate
+	// which wrap a PathError are reported at the attribute or block named by
+	// the path.
+	Validate() error
+}
+
 // Decode assigns a Value val to a Go pointer target. Pointers will be
 // allocated as necessary when decoding.
 //
@@ -582,7 +592,7 @@ func (d *decoder) decodeObjectToStruct(val Value, rt reflect.Value, fields *obje
 
 		switch fields.Has(key) {
 		case objectKeyTypeInvalid:
-			return MissingKeyError{Value: value, Missing: key}
+			return MissingKeyError{Value: value, Missing: key, Suggestion: diag.Suggest(key, fields.Keys())}
 		case objectKeyTypeNestedField: // Block with multiple name fragments
 			next, _ := fields.NestedField(key)
 			// Recurse the call with the inner value.
@@ -28,10 +28,17 @@ func (de Error) Error() string { return de.Inner.Error() }
 type MissingKeyError struct {
 	Value   Value
 	Missing string
+
+	// Suggestion is the name of an existing key which is similar to Missing,
+	// if any.
+	Suggestion string
 }
 
 // Error returns the string form of the MissingKeyError.
 func (mke MissingKeyError) Error() string {
+	if mke.Suggestion != "" {
+		return fmt.Sprintf("key %q does not exist; did you mean %q?", mke.Missing, mke.Suggestion)
+	}
 	return fmt.Sprintf("key %q does not exist", mke.Missing)
 }
 
@@ -66,6 +73,22 @@ type ArgError struct {
 // Error returns the text of the inner error.
 func (ae ArgError) Error() string { return ae.Inner.Error() }
 
+// PathError is used by Validator implementations to report an error with a
+// specific attribute or block of the validated value.
+type PathError struct {
+	// Path is the dot-separated path of River names to the attribute or block,
+	// relative to the validated block. Repeated blocks may be selected with an
+	// index, such as "rule[1].regex".
+	Path  string
+	Inner error
+}
+
+// Error returns the text of the inner error.
+func (pe PathError) Error() string { return pe.Inner.Error() }
+
+// Unwrap returns the inner error.
+func (pe PathError) Unwrap() error { return pe.Inner }
+
 // WalkError walks err for all value-related errors in this package.
 // WalkError returns false if err is not an error from this package.
 func WalkError(err error, f func(err error)) bool {
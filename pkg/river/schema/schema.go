@@ -0,0 +1,308 @@
+// Package schema describes the River attributes and blocks which Go types are
+// decoded from. It's used to generate reference documentation and editor
+// tooling from the same Go structs that River decodes into.
+package schema
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/internal/rivertags"
+)
+
+// Body describes the attributes and blocks of a River block body.
+type Body struct {
+	Attributes []Attribute `json:"attributes,omitempty"`
+	Blocks     []Block     `json:"blocks,omitempty"`
+
+	// Freeform is true if the body accepts arbitrary attributes, such as when
+	// it decodes into a map. Attributes and Blocks are empty for freeform
+	// bodies.
+	Freeform bool `json:"freeform,omitempty"`
+}
+
+// Attribute describes a River attribute.
+type Attribute struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Optional bool   `json:"optional"`
+	// Default is the River representation of the default value of the
+	// attribute. Default is empty for attributes which default to the zero
+	// value of their type.
+	Default string `json:"default,omitempty"`
+	// Description of the attribute. Description is never set by For; see
+	// Source.
+	Description string `json:"description,omitempty"`
+
+	// Source is the Go struct field the attribute decodes into.
+	Source Source `json:"-"`
+}
+
+// Block describes a River block.
+type Block struct {
+	Name     string `json:"name"`
+	Optional bool   `json:"optional"`
+	// Repeated is true if the block may be defined more than once.
+	Repeated bool `json:"repeated"`
+	// Labeled is true if the block takes a label.
+	Labeled bool `json:"labeled"`
+	// Description of the block. Description is never set by For; see Source.
+	Description string `json:"description,omitempty"`
+	Body        Body   `json:"body"`
+
+	// Source is the Go struct field the block decodes into.
+	Source Source `json:"-"`
+}
+
+// Source identifies a field of a Go struct type.
+type Source struct {
+	Type  reflect.Type // Struct type declaring the field.
+	Field string       // Name of the field in Type.
+}
+
+// Namer may be implemented by capsule types to override the type name
+// reported for attributes of that type.
+type Namer interface {
+	RiverTypeName() string
+}
+
+// For describes the River body which v decodes from. v must be a struct or a
+// pointer to a struct. The values of the fields in v are used as the defaults
+// for each attribute; if v implements river.Unmarshaler, its defaults are
+// applied first.
+func For(v interface{}) Body {
+	return describeBody(defaulted(reflect.ValueOf(v)), nil)
+}
+
+// defaulted returns an addressable copy of rv with its defaults from
+// river.Unmarshaler applied.
+func defaulted(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv = reflect.Zero(rv.Type().Elem())
+			continue
+		}
+		rv = rv.Elem()
+	}
+
+	out := reflect.New(rv.Type())
+	out.Elem().Set(rv)
+
+	if u, ok := out.Interface().(river.Unmarshaler); ok {
+		func() {
+			// Unmarshalers are written to be invoked by the River decoder, and
+			// some may not expect to be called with an empty body.
+			defer func() { _ = recover() }()
+
+			// The error is ignored, since it's normally a validation error from
+			// the empty body.
+			_ = u.UnmarshalRiver(func(interface{}) error { return nil })
+		}()
+	}
+	return out.Elem()
+}
+
+func describeBody(rv reflect.Value, seen []reflect.Type) Body {
+	var (
+		body Body
+		ty   = rv.Type()
+	)
+
+	// Guard against recursive types.
+	for _, s := range seen {
+		if s == ty {
+			return body
+		}
+	}
+	seen = append(seen, ty)
+
+	for _, tf := range rivertags.Get(ty) {
+		var (
+			name   = strings.Join(tf.Name, ".")
+			field  = ty.FieldByIndex(tf.Index)
+			fv     = fieldValue(rv, tf.Index)
+			source = sourceOf(ty, tf.Index)
+		)
+
+		switch {
+		case tf.IsAttr():
+			attr := Attribute{
+				Name:     name,
+				Type:     TypeName(field.Type),
+				Optional: tf.IsOptional(),
+				Source:   source,
+			}
+			if fv.IsValid() && !fv.IsZero() {
+				attr.Default = formatDefault(fv)
+			}
+			body.Attributes = append(body.Attributes, attr)
+
+		case tf.IsBlock():
+			body.Blocks = append(body.Blocks, describeBlock(name, field.Type, tf.IsOptional(), source, seen))
+
+		case tf.IsEnum():
+			// Each block inside of the enum element is exposed as a block named
+			// after the enum and the inner block.
+			elemType := dereferenceType(field.Type.Elem())
+			for _, inner := range rivertags.Get(elemType) {
+				innerName := name + "." + strings.Join(inner.Name, ".")
+				innerField := elemType.FieldByIndex(inner.Index)
+
+				block := describeBlock(innerName, innerField.Type, true, sourceOf(elemType, inner.Index), seen)
+				block.Repeated = true
+				body.Blocks = append(body.Blocks, block)
+			}
+		}
+	}
+
+	return body
+}
+
+func describeBlock(name string, ty reflect.Type, optional bool, source Source, seen []reflect.Type) Block {
+	block := Block{
+		Name:     name,
+		Optional: optional,
+		Source:   source,
+	}
+
+	ty = dereferenceType(ty)
+	if ty.Kind() == reflect.Slice || ty.Kind() == reflect.Array {
+		block.Repeated = true
+		ty = dereferenceType(ty.Elem())
+	}
+
+	switch ty.Kind() {
+	case reflect.Struct:
+		for _, tf := range rivertags.Get(ty) {
+			if tf.IsLabel() {
+				block.Labeled = true
+			}
+		}
+		block.Body = describeBody(defaulted(reflect.New(ty)), seen)
+	case reflect.Map, reflect.Interface:
+		block.Body.Freeform = true
+	}
+	return block
+}
+
+// fieldValue returns the value of the field at index in rv. fieldValue
+// returns an invalid value if a pointer along the way is nil.
+func fieldValue(rv reflect.Value, index []int) reflect.Value {
+	for i, idx := range index {
+		if i > 0 {
+			for rv.Kind() == reflect.Pointer {
+				if rv.IsNil() {
+					return reflect.Value{}
+				}
+				rv = rv.Elem()
+			}
+		}
+		rv = rv.Field(idx)
+	}
+	return rv
+}
+
+// sourceOf returns the struct type which declares the field at index in ty,
+// following squashed fields.
+func sourceOf(ty reflect.Type, index []int) Source {
+	for _, idx := range index[:len(index)-1] {
+		ty = dereferenceType(ty.Field(idx).Type)
+	}
+	return Source{Type: ty, Field: ty.Field(index[len(index)-1]).Name}
+}
+
+func formatDefault(fv reflect.Value) string {
+	if fv.Type() == durationType {
+		return `"` + formatDuration(time.Duration(fv.Int())) + `"`
+	}
+
+	bb, err := river.MarshalValue(fv.Interface())
+	if err != nil {
+		return ""
+	}
+
+	// Only report defaults which fit in a single line.
+	s := string(bb)
+	if strings.Contains(s, "\n") {
+		return ""
+	}
+	return s
+}
+
+// formatDuration formats d without trailing zero units, such as "1m"
+// instead of "1m0s".
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	namerType           = reflect.TypeOf((*Namer)(nil)).Elem()
+	capsuleType         = reflect.TypeOf((*river.Capsule)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// TypeName returns the name of the River type which values of the Go type ty
+// are decoded from, such as "string", "duration", or "list(string)".
+func TypeName(ty reflect.Type) string {
+	switch {
+	case ty.Kind() == reflect.Pointer:
+		return TypeName(ty.Elem())
+	case ty == durationType:
+		return "duration"
+	case ty.Kind() != reflect.Interface && ty.Implements(namerType):
+		return reflect.Zero(ty).Interface().(Namer).RiverTypeName()
+	case reflect.PointerTo(ty).Implements(namerType):
+		return reflect.New(ty).Interface().(Namer).RiverTypeName()
+	case ty.Implements(capsuleType) || reflect.PointerTo(ty).Implements(capsuleType):
+		return "capsule(" + ty.String() + ")"
+	case ty.Kind() != reflect.Interface && reflect.PointerTo(ty).Implements(textUnmarshalerType):
+		return "string"
+	}
+
+	switch ty.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "list(" + TypeName(ty.Elem()) + ")"
+	case reflect.Map:
+		if ty.Key().Kind() != reflect.String {
+			return "capsule(" + ty.String() + ")"
+		}
+		return "map(" + TypeName(ty.Elem()) + ")"
+	case reflect.Struct:
+		if len(rivertags.Get(ty)) > 0 {
+			return "object"
+		}
+	case reflect.Func:
+		return "function"
+	case reflect.Interface:
+		if ty.NumMethod() == 0 {
+			return "any"
+		}
+	}
+	return "capsule(" + ty.String() + ")"
+}
+
+func dereferenceType(ty reflect.Type) reflect.Type {
+	for ty.Kind() == reflect.Pointer {
+		ty = ty.Elem()
+	}
+	return ty
+}
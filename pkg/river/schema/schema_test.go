@@ -0,0 +1,126 @@
+package schema_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river/schema"
+	"github.com/stretchr/testify/require"
+)
+
+type testArgs struct {
+	Name     string            `river:"name,attr"`
+	Timeout  time.Duration     `river:"timeout,attr,optional"`
+	Labels   map[string]string `river:"labels,attr,optional"`
+	Targets  []string          `river:"targets,attr,optional"`
+	Password testSecret        `river:"password,attr,optional"`
+
+	Common testCommon `river:",squash"`
+
+	Endpoints []testEndpoint `river:"endpoint,block,optional"`
+	Stages    []testStage    `river:"stage,enum,optional"`
+}
+
+type testCommon struct {
+	Enabled bool `river:"enabled,attr,optional"`
+}
+
+type testEndpoint struct {
+	Label string `river:",label"`
+	URL   string `river:"url,attr"`
+}
+
+type testStage struct {
+	JSON *testEndpoint `river:"json,block,optional"`
+}
+
+type testSecret string
+
+func (testSecret) RiverCapsule()         {}
+func (testSecret) RiverTypeName() string { return "secret" }
+
+func (a *testArgs) UnmarshalRiver(f func(interface{}) error) error {
+	*a = testArgs{Timeout: 90 * time.Second, Common: testCommon{Enabled: true}}
+
+	type args testArgs
+	return f((*args)(a))
+}
+
+func TestFor(t *testing.T) {
+	body := schema.For(testArgs{})
+
+	// Clear out sources to simplify comparison; they're checked separately.
+	for i := range body.Attributes {
+		body.Attributes[i].Source = schema.Source{}
+	}
+	for i := range body.Blocks {
+		body.Blocks[i].Source = schema.Source{}
+		for j := range body.Blocks[i].Body.Attributes {
+			body.Blocks[i].Body.Attributes[j].Source = schema.Source{}
+		}
+	}
+
+	expect := schema.Body{
+		Attributes: []schema.Attribute{
+			{Name: "name", Type: "string"},
+			{Name: "timeout", Type: "duration", Optional: true, Default: `"1m30s"`},
+			{Name: "labels", Type: "map(string)", Optional: true},
+			{Name: "targets", Type: "list(string)", Optional: true},
+			{Name: "password", Type: "secret", Optional: true},
+			{Name: "enabled", Type: "bool", Optional: true, Default: "true"},
+		},
+		Blocks: []schema.Block{
+			{
+				Name:     "endpoint",
+				Optional: true,
+				Repeated: true,
+				Labeled:  true,
+				Body: schema.Body{
+					Attributes: []schema.Attribute{{Name: "url", Type: "string"}},
+				},
+			},
+			{
+				Name:     "stage.json",
+				Optional: true,
+				Repeated: true,
+				Labeled:  true,
+				Body: schema.Body{
+					Attributes: []schema.Attribute{{Name: "url", Type: "string"}},
+				},
+			},
+		},
+	}
+	require.Equal(t, expect, body)
+}
+
+func TestFor_Source(t *testing.T) {
+	body := schema.For(&testArgs{})
+
+	require.Equal(t, schema.Source{Type: reflect.TypeOf(testArgs{}), Field: "Name"}, body.Attributes[0].Source)
+	require.Equal(t, schema.Source{Type: reflect.TypeOf(testCommon{}), Field: "Enabled"}, body.Attributes[5].Source)
+	require.Equal(t, schema.Source{Type: reflect.TypeOf(testStage{}), Field: "JSON"}, body.Blocks[1].Source)
+}
+
+func TestTypeName(t *testing.T) {
+	tt := []struct {
+		value  interface{}
+		expect string
+	}{
+		{"", "string"},
+		{0, "number"},
+		{1.5, "number"},
+		{true, "bool"},
+		{time.Second, "duration"},
+		{[]map[string]string{}, "list(map(string))"},
+		{map[string]interface{}{}, "map(any)"},
+		{testEndpoint{}, "object"},
+		{(*testSecret)(nil), "secret"},
+		{time.Time{}, "string"},
+		{struct{}{}, "capsule(struct {})"},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.expect, schema.TypeName(reflect.TypeOf(tc.value)), "%T", tc.value)
+	}
+}
@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+)
+
+// Validate checks the body of block against b. Every attribute and block in
+// the body must be known to b, required attributes and blocks must be set,
+// and blocks must be used with the correct labels and number of times. The
+// label of block itself isn't checked. Expressions are not evaluated, so
+// Validate can check bodies which reference values that don't exist yet.
+//
+// Unlike decoding, which stops at the first problem found, Validate reports
+// every problem in the body. Unknown names are reported with a suggestion for
+// the closest known name, if any.
+func (b Body) Validate(block *ast.BlockStmt) diag.Diagnostics {
+	var diags diag.Diagnostics
+	b.validate(block, &diags)
+	return diags
+}
+
+func (b Body) validate(parent *ast.BlockStmt, diags *diag.Diagnostics) {
+	if b.Freeform {
+		for _, stmt := range parent.Body {
+			if block, ok := stmt.(*ast.BlockStmt); ok {
+				diags.Add(errorAt(block, "nested blocks not supported here"))
+			}
+		}
+		return
+	}
+
+	var (
+		attrs  = make(map[string]Attribute, len(b.Attributes))
+		blocks = make(map[string]Block, len(b.Blocks))
+
+		attrNames, blockNames []string
+
+		seenAttrs  = make(map[string]struct{})
+		seenBlocks = make(map[string]struct{})
+	)
+	for _, attr := range b.Attributes {
+		attrs[attr.Name] = attr
+		attrNames = append(attrNames, attr.Name)
+	}
+	for _, block := range b.Blocks {
+		blocks[block.Name] = block
+		blockNames = append(blockNames, block.Name)
+	}
+
+	for _, stmt := range parent.Body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			name := stmt.Name.Name
+
+			if _, seen := seenAttrs[name]; seen {
+				diags.Add(errorAt(stmt, "attribute %q may only be provided once", name))
+				continue
+			}
+			seenAttrs[name] = struct{}{}
+
+			if _, ok := attrs[name]; ok {
+				continue
+			} else if _, ok := blocks[name]; ok {
+				diags.Add(errorAt(stmt, "%q must be a block, but is used as an attribute", name))
+				continue
+			}
+			diags.Add(errorAt(stmt, "unrecognized attribute name %q%s", name, didYouMean(name, attrNames)))
+
+		case *ast.BlockStmt:
+			name := stmt.GetBlockName()
+
+			block, ok := blocks[name]
+			if !ok {
+				if _, ok := attrs[name]; ok {
+					diags.Add(errorAt(stmt, "%q must be an attribute, but is used as a block", name))
+					continue
+				}
+				diags.Add(errorAt(stmt, "unrecognized block name %q%s", name, didYouMean(name, blockNames)))
+				continue
+			}
+
+			if _, seen := seenBlocks[name]; seen && !block.Repeated {
+				diags.Add(errorAt(stmt, "block %q may only be specified once", name))
+			}
+			seenBlocks[name] = struct{}{}
+
+			switch {
+			case stmt.Label == "" && block.Labeled:
+				diags.Add(errorAt(stmt, "block %q requires non-empty label", name))
+			case stmt.Label != "" && !block.Labeled:
+				diags.Add(errorAt(stmt, "block %q does not support specifying labels", name))
+			}
+
+			block.Body.validate(stmt, diags)
+		}
+	}
+
+	// Missing names are reported at the name of the parent block, since
+	// there's no statement to point at.
+	for _, attr := range b.Attributes {
+		if _, seen := seenAttrs[attr.Name]; !seen && !attr.Optional {
+			diags.Add(missing(parent, "missing required attribute %q", attr.Name))
+		}
+	}
+	for _, block := range b.Blocks {
+		if _, seen := seenBlocks[block.Name]; !seen && !block.Optional {
+			diags.Add(missing(parent, "missing required block %q", block.Name))
+		}
+	}
+}
+
+func errorAt(n ast.Node, format string, args ...interface{}) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: ast.StartPos(n).Position(),
+		EndPos:   ast.EndPos(n).Position(),
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+func missing(block *ast.BlockStmt, format string, args ...interface{}) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: block.NamePos.Position(),
+		EndPos:   block.LCurlyPos.Position(),
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// didYouMean returns a hint suggesting the closest candidate to name, or an
+// empty string if there is no close candidate.
+func didYouMean(name string, candidates []string) string {
+	if suggestion := diag.Suggest(name, candidates); suggestion != "" {
+		return fmt.Sprintf("; did you mean %q?", suggestion)
+	}
+	return ""
+}
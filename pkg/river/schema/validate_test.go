@@ -0,0 +1,85 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBody_Validate(t *testing.T) {
+	body := schema.For(testArgs{})
+
+	tt := []struct {
+		name   string
+		input  string
+		expect []string
+	}{
+		{
+			name: "valid",
+			input: `
+				name    = some_component.output
+				enabled = false
+				endpoint "a" { url = "http://localhost" }
+				endpoint "b" { url = "http://localhost" }
+				stage.json "c" { url = "http://localhost" }
+			`,
+		},
+		{
+			name: "unknown names",
+			input: `
+				nam = "foo"
+				endpont "a" { url = "http://localhost" }
+				something_else = true
+			`,
+			expect: []string{
+				`unrecognized attribute name "nam"; did you mean "name"?`,
+				`unrecognized block name "endpont"; did you mean "endpoint"?`,
+				`unrecognized attribute name "something_else"`,
+				`missing required attribute "name"`,
+			},
+		},
+		{
+			name: "nested blocks",
+			input: `
+				name = "foo"
+				endpoint "a" { uri = "http://localhost" }
+			`,
+			expect: []string{
+				`unrecognized attribute name "uri"; did you mean "url"?`,
+				`missing required attribute "url"`,
+			},
+		},
+		{
+			name: "misused names",
+			input: `
+				name = "foo"
+				name = "bar"
+				endpoint = []
+				timeout { }
+				endpoint { url = "http://localhost" }
+			`,
+			expect: []string{
+				`attribute "name" may only be provided once`,
+				`"endpoint" must be a block, but is used as an attribute`,
+				`"timeout" must be an attribute, but is used as a block`,
+				`block "endpoint" requires non-empty label`,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := parser.ParseFile(t.Name(), []byte("block {\n"+tc.input+"\n}"))
+			require.NoError(t, err)
+
+			var messages []string
+			for _, d := range body.Validate(f.Body[0].(*ast.BlockStmt)) {
+				messages = append(messages, d.Message)
+			}
+			require.Equal(t, tc.expect, messages)
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package river
 
-import "github.com/grafana/agent/pkg/river/internal/value"
+import (
+	"fmt"
+
+	"github.com/grafana/agent/pkg/river/internal/value"
+)
 
 // Our types in this file are re-implementations of interfaces from
 // value.Capsule. They are *not* defined as type aliases, since pkg.go.dev
@@ -11,6 +15,7 @@ import "github.com/grafana/agent/pkg/river/internal/value"
 // checks below ensure they're compatible.
 var (
 	_ value.Unmarshaler            = (Unmarshaler)(nil)
+	_ value.Validator              = (Validator)(nil)
 	_ value.Capsule                = (Capsule)(nil)
 	_ value.ConvertibleFromCapsule = (ConvertibleFromCapsule)(nil)
 	_ value.ConvertibleIntoCapsule = (ConvertibleIntoCapsule)(nil)
@@ -26,6 +31,67 @@ type Unmarshaler interface {
 	UnmarshalRiver(f func(v interface{}) error) error
 }
 
+// The Validator interface allows a type to validate itself after being
+// decoded from a River block, such as the arguments of a component.
+//
+// Errors returned by Validate are reported as diagnostics at the position of
+// the block. Errors created with PathError are instead reported at the
+// attribute or block they name, and multiple problems can be reported at once
+// by returning ValidationErrors.
+type Validator interface {
+	// Validate is invoked after a River block has been decoded into the Go
+	// value, including after calling UnmarshalRiver if the type implements
+	// Unmarshaler. Validate is not invoked for values decoded from
+	// expressions.
+	Validate() error
+}
+
+// PathError returns an error which reports err at the attribute or block
+// named by path when returned from Validate. path is a dot-separated list of
+// River names relative to the validated block, such as "tls.cert_file".
+// Blocks which are defined more than once can be selected by their index,
+// such as "rule[1].regex".
+//
+// If the attribute or block named by path isn't defined, the error is
+// reported at the closest block along the path which is.
+func PathError(path string, err error) error {
+	return value.PathError{Path: path, Inner: err}
+}
+
+// ValidationErrors is a list of errors returned from Validate to report
+// multiple problems at once.
+type ValidationErrors []error
+
+// Add appends err to the list of errors if err is non-nil.
+func (ve *ValidationErrors) Add(err error) {
+	if err != nil {
+		*ve = append(*ve, err)
+	}
+}
+
+// ErrorOrNil returns ve as an error if it contains any errors, nil otherwise.
+func (ve ValidationErrors) ErrorOrNil() error {
+	if len(ve) == 0 {
+		return nil
+	}
+	return ve
+}
+
+// Error implements error.
+func (ve ValidationErrors) Error() string {
+	switch len(ve) {
+	case 0:
+		return "no errors"
+	case 1:
+		return ve[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", ve[0], len(ve)-1)
+	}
+}
+
+// Unwrap returns the list of errors.
+func (ve ValidationErrors) Unwrap() []error { return ve }
+
 // Capsule is an interface marker which tells River that a type should always
 // be treated as a "capsule type" instead of the default type River would
 // assign.
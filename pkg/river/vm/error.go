@@ -1,7 +1,9 @@
 package vm
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/grafana/agent/pkg/river/ast"
@@ -37,6 +39,9 @@ func makeDiagnostic(err error, assoc map[value.Value]ast.Node) error {
 			val = ne.Value
 		case value.MissingKeyError:
 			message = fmt.Sprintf("does not have field named %q", ne.Missing)
+			if ne.Suggestion != "" {
+				message += fmt.Sprintf("; did you mean %q?", ne.Suggestion)
+			}
 			val = ne.Value
 		case value.ElementError:
 			fmt.Fprintf(&expr, "[%d]", ne.Index)
@@ -104,3 +109,107 @@ func makeDiagnostic(err error, assoc map[value.Value]ast.Node) error {
 	}
 	return d
 }
+
+// validationDiagnostics converts an error returned by a Validator for the
+// block or body node into diagnostics. Errors wrapping a value.PathError are
+// reported at the attribute or block named by their path, while other errors
+// are reported at node.
+func validationDiagnostics(node ast.Node, err error) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, err := range flattenErrors(err) {
+		d := diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  err.Error(),
+		}
+
+		target := node
+		var pathErr value.PathError
+		if errors.As(err, &pathErr) {
+			var found bool
+			target, found = findPath(node, pathErr.Path)
+			if !found {
+				d.Message = fmt.Sprintf("%s: %s", pathErr.Path, err)
+			}
+		}
+
+		d.StartPos = ast.StartPos(target).Position()
+		d.EndPos = ast.EndPos(target).Position()
+		diags.Add(d)
+	}
+
+	return diags
+}
+
+// flattenErrors returns the list of errors wrapped by err if it implements
+// Unwrap() []error, or err otherwise.
+func flattenErrors(err error) []error {
+	multi, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+
+	var res []error
+	for _, err := range multi.Unwrap() {
+		if err != nil {
+			res = append(res, flattenErrors(err)...)
+		}
+	}
+	return res
+}
+
+// findPath finds the attribute or block named by the dot-separated path
+// relative to the block or body node. If the full path can't be found, the
+// closest node along the path is returned along with false.
+func findPath(node ast.Node, path string) (ast.Node, bool) {
+	for _, segment := range strings.Split(path, ".") {
+		name, index := segment, 0
+		if open := strings.IndexByte(segment, '['); open != -1 && strings.HasSuffix(segment, "]") {
+			i, err := strconv.Atoi(segment[open+1 : len(segment)-1])
+			if err != nil {
+				return node, false
+			}
+			name, index = segment[:open], i
+		}
+
+		var body ast.Body
+		switch n := node.(type) {
+		case *ast.BlockStmt:
+			body = n.Body
+		case ast.Body:
+			body = n
+		default:
+			// Attributes don't have any children.
+			return node, false
+		}
+
+		next := findStmt(body, name, index)
+		if next == nil {
+			return node, false
+		}
+		node = next
+	}
+	return node, true
+}
+
+// findStmt returns the attribute called name or the index'th block called
+// name in body.
+func findStmt(body ast.Body, name string, index int) ast.Node {
+	for _, stmt := range body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			if stmt.Name.Name == name {
+				return stmt
+			}
+		case *ast.BlockStmt:
+			if strings.Join(stmt.Name, ".") != name {
+				continue
+			}
+			if index == 0 {
+				return stmt
+			}
+			index--
+		}
+	}
+	return nil
+}
@@ -139,6 +139,41 @@ type decodeOptions struct {
 	EnumIndex map[*ast.BlockStmt]int // Index of a block within a set of enum blocks of the same enum.
 }
 
+// attrNames returns the names of all attributes which may be decoded.
+func (state *decodeOptions) attrNames() []string {
+	var names []string
+	for name, tf := range state.Tags {
+		if tf.IsAttr() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// blockNames returns the names of all blocks which may be decoded, including
+// the blocks of enums.
+func (state *decodeOptions) blockNames() []string {
+	var names []string
+	for name, tf := range state.Tags {
+		if tf.IsBlock() {
+			names = append(names, name)
+		}
+	}
+	for name := range state.EnumBlocks {
+		names = append(names, name)
+	}
+	return names
+}
+
+// didYouMean returns a hint suggesting the closest candidate to name, or an
+// empty string if there is no close candidate.
+func didYouMean(name string, candidates []string) string {
+	if suggestion := diag.Suggest(name, candidates); suggestion != "" {
+		return fmt.Sprintf("; did you mean %q?", suggestion)
+	}
+	return ""
+}
+
 func (st *structDecoder) decodeAttr(attr *ast.AttributeStmt, rv reflect.Value, state *decodeOptions) error {
 	fullName := attr.Name.Name
 	if _, seen := state.SeenAttrs[fullName]; seen {
@@ -157,7 +192,7 @@ func (st *structDecoder) decodeAttr(attr *ast.AttributeStmt, rv reflect.Value, s
 			Severity: diag.SeverityLevelError,
 			StartPos: ast.StartPos(attr).Position(),
 			EndPos:   ast.EndPos(attr).Position(),
-			Message:  fmt.Sprintf("unrecognized attribute name %q", fullName),
+			Message:  fmt.Sprintf("unrecognized attribute name %q", fullName) + didYouMean(fullName, state.attrNames()),
 		}}
 	} else if tf.IsBlock() {
 		return diag.Diagnostics{{
@@ -203,7 +238,7 @@ func (st *structDecoder) decodeNormalBlock(fullName string, block *ast.BlockStmt
 			Severity: diag.SeverityLevelError,
 			StartPos: ast.StartPos(block).Position(),
 			EndPos:   ast.EndPos(block).Position(),
-			Message:  fmt.Sprintf("unrecognized block name %q", fullName),
+			Message:  fmt.Sprintf("unrecognized block name %q", fullName) + didYouMean(fullName, state.blockNames()),
 		}}
 	} else if tf.IsAttr() {
 		return diag.Diagnostics{{
@@ -80,11 +80,27 @@ func (vm *Evaluator) evaluateBlockOrBody(scope *Scope, assoc map[value.Value]ast
 	// be able to print line numbers. We need to return decorated error types.
 
 	// Before decoding the block, we need to temporarily take the address of rv
-	// to handle the case of it implementing the unmarshaler interface.
+	// to handle the case of it implementing the unmarshaler or validator
+	// interfaces.
 	if rv.CanAddr() {
 		rv = rv.Addr()
 	}
 
+	if err := vm.decodeBlockOrBody(scope, assoc, node, rv); err != nil {
+		return err
+	}
+
+	if v, ok := rv.Interface().(value.Validator); ok {
+		if err := v.Validate(); err != nil {
+			return validationDiagnostics(node, err)
+		}
+	}
+	return nil
+}
+
+// decodeBlockOrBody decodes node into rv, which must be a pointer if rv
+// implements the unmarshaler interface.
+func (vm *Evaluator) decodeBlockOrBody(scope *Scope, assoc map[value.Value]ast.Node, node ast.Node, rv reflect.Value) error {
 	if ru, ok := rv.Interface().(value.Unmarshaler); ok {
 		return ru.UnmarshalRiver(func(v interface{}) error {
 			rv := reflect.ValueOf(v)
@@ -377,7 +393,7 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 		case value.TypeObject:
 			// Objects are indexed with a string.
 			if idx.Type() != value.TypeString {
-				return value.Null, value.TypeError{Value: idx, Expected: value.TypeNumber}
+				return value.Null, value.TypeError{Value: idx, Expected: value.TypeString}
 			}
 
 			field, ok := val.Key(idx.Text())
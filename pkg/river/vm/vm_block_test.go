@@ -1,9 +1,13 @@
 package vm_test
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
 	"github.com/grafana/agent/pkg/river/parser"
 	"github.com/grafana/agent/pkg/river/vm"
 	"github.com/stretchr/testify/require"
@@ -128,6 +132,20 @@ func TestVM_Block_Attributes(t *testing.T) {
 		require.EqualError(t, err, `3:4: unrecognized attribute name "invalid"`)
 	})
 
+	t.Run("Suggests similar attribute names", func(t *testing.T) {
+		type block struct {
+			Number int `river:"number,attr"`
+		}
+
+		input := `some_block {
+			numbr = 15
+		}`
+		eval := vm.New(parseBlock(t, input))
+
+		err := eval.Evaluate(nil, &block{})
+		require.EqualError(t, err, `2:4: unrecognized attribute name "numbr"; did you mean "number"?`)
+	})
+
 	t.Run("Supports arbitrarily nested struct pointer fields", func(t *testing.T) {
 		type block struct {
 			NumberA int    `river:"number_a,attr"`
@@ -357,6 +375,22 @@ func TestVM_Block_Children_Blocks(t *testing.T) {
 		require.EqualError(t, err, `4:4: unrecognized block name "child.block"`)
 	})
 
+	t.Run("Suggests similar block names", func(t *testing.T) {
+		type block struct {
+			Child struct {
+				Attr bool `river:"attr,attr"`
+			} `river:"child.block,block"`
+		}
+
+		input := `some_block {
+			child.blok { attr = true }
+		}`
+		eval := vm.New(parseBlock(t, input))
+
+		err := eval.Evaluate(nil, &block{})
+		require.EqualError(t, err, `2:4: unrecognized block name "child.blok"; did you mean "child.block"?`)
+	})
+
 	t.Run("Supports arbitrarily nested struct pointer fields", func(t *testing.T) {
 		type block struct {
 			BlockA childBlock    `river:"block_a,block"`
@@ -627,6 +661,127 @@ func TestVM_Block_Unmarshaler(t *testing.T) {
 	require.True(t, actual.Settings.Called, "UnmarshalRiver did not get invoked")
 }
 
+func TestVM_Block_Validator(t *testing.T) {
+	type OuterBlock struct {
+		Validated ValidatedBlock `river:"validated,block"`
+	}
+
+	tt := []struct {
+		name   string
+		input  string
+		expect []string
+	}{
+		{
+			name: "valid",
+			input: `
+				validated {
+					min = 1
+					max = 2
+				}
+			`,
+		},
+		{
+			name: "error reported at attribute",
+			input: `
+				validated {
+					min = 5
+					max = 2
+				}
+			`,
+			expect: []string{"4:6: max must not be less than min"},
+		},
+		{
+			name: "error reported at repeated block",
+			input: `
+				validated {
+					min = 1
+					max = 2
+
+					rule { name = "a" }
+					rule { name = "" }
+				}
+			`,
+			expect: []string{"7:6: name must not be empty"},
+		},
+		{
+			name: "missing attribute reported at closest block",
+			input: `
+				validated {
+					max = 2
+				}
+			`,
+			expect: []string{"2:5: min: must be set"},
+		},
+		{
+			name: "multiple errors",
+			input: `
+				validated {
+					min = 5
+					max = 2
+					rule { name = "" }
+				}
+			`,
+			expect: []string{
+				"4:6: max must not be less than min",
+				"5:6: name must not be empty",
+				"2:5: validation failed",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := parser.ParseFile("", []byte(tc.input))
+			require.NoError(t, err)
+
+			var actual OuterBlock
+			err = vm.New(file).Evaluate(nil, &actual)
+			if len(tc.expect) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			var diags diag.Diagnostics
+			require.True(t, errors.As(err, &diags), "expected diagnostics, got %T", err)
+
+			var actualErrors []string
+			for _, d := range diags {
+				actualErrors = append(actualErrors, d.Error())
+			}
+			require.Equal(t, tc.expect, actualErrors)
+		})
+	}
+}
+
+type ValidatedBlock struct {
+	Min   int    `river:"min,attr,optional"`
+	Max   int    `river:"max,attr"`
+	Rules []Rule `river:"rule,block,optional"`
+}
+
+type Rule struct {
+	Name string `river:"name,attr"`
+}
+
+func (b *ValidatedBlock) Validate() error {
+	var errs river.ValidationErrors
+	if b.Min == 0 {
+		errs.Add(river.PathError("min", errors.New("must be set")))
+	}
+	if b.Max < b.Min {
+		errs.Add(river.PathError("max", errors.New("max must not be less than min")))
+	}
+	for i, r := range b.Rules {
+		if r.Name == "" {
+			errs.Add(river.PathError(fmt.Sprintf("rule[%d]", i), errors.New("name must not be empty")))
+		}
+	}
+	if len(errs) > 1 {
+		errs.Add(errors.New("validation failed"))
+	}
+	return errs.ErrorOrNil()
+}
+
 func TestVM_Block_UnmarshalToMap(t *testing.T) {
 	type OuterBlock struct {
 		Settings map[string]interface{} `river:"some.settings,block"`
@@ -20,6 +20,14 @@ func TestVM_Stdlib(t *testing.T) {
 	}{
 		{"env", `env("TEST_VAR")`, string("Hello!")},
 		{"concat", `concat([true, "foo"], [], [false, 1])`, []interface{}{true, "foo", false, 1}},
+		{"coalesce set env", `coalesce(env("TEST_VAR"), "default")`, string("Hello!")},
+		{"coalesce unset env", `coalesce(env("TEST_UNSET_VAR"), "default")`, string("default")},
+		{"coalesce numbers", `coalesce(0, 5, 10)`, 5},
+		{"coalesce bools", `coalesce(false, true)`, true},
+		{"coalesce arrays", `coalesce([], ["foo"])`, []string{"foo"}},
+		{"coalesce objects", `coalesce({}, {"foo" = "bar"})`, map[string]string{"foo": "bar"}},
+		{"coalesce all zero", `coalesce("", "")`, string("")},
+		{"coalesce null", `coalesce(null, "foo")`, string("foo")},
 		{"json_decode object", `json_decode("{\"foo\": \"bar\"}")`, map[string]interface{}{"foo": "bar"}},
 		{"json_decode array", `json_decode("[0, 1, 2]")`, []interface{}{float64(0), float64(1), float64(2)}},
 		{"json_decode nil field", `json_decode("{\"foo\": null}")`, map[string]interface{}{"foo": nil}},
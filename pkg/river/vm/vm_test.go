@@ -229,6 +229,17 @@ func TestVM_Evaluate_AccessExpr(t *testing.T) {
 		require.EqualError(t, err, `1:12: field "b" does not exist`)
 	})
 
+	t.Run("Invalid index type", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`{ a = 15 }[0]`)
+		require.NoError(t, err)
+
+		eval := vm.New(expr)
+
+		var v interface{}
+		err = eval.Evaluate(nil, &v)
+		require.EqualError(t, err, `1:12: 0 should be string, got number`)
+	})
+
 	t.Run("Invalid lookup 2", func(t *testing.T) {
 		_, err := parser.ParseExpression(`{ a = 15 }.7`)
 		require.EqualError(t, err, `1:11: expected TERMINATOR, got FLOAT`)
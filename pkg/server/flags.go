@@ -68,6 +68,11 @@ type GRPCFlags struct {
 	KeepaliveTimeout         time.Duration
 	MinTimeBetweenPings      time.Duration
 	PingWithoutStreamAllowed bool
+
+	// HTTP/2 flow control windows. gRPC sizes windows dynamically when they're
+	// 0.
+	InitialWindowSize     int
+	InitialConnWindowSize int
 }
 
 // ListenHostPort splits the ListenAddress into a listen host and listen port.
@@ -165,5 +170,7 @@ func (f *GRPCFlags) RegisterFlags(fs *flag.FlagSet) {
 	fs.DurationVar(&f.KeepaliveTimeout, "server.grpc.keepalive.timeout", d.KeepaliveTimeout, "How long to wait for a keepalive pong before closing the connection")
 	fs.DurationVar(&f.MinTimeBetweenPings, "server.grpc.keepalive.min-time-between-pings", d.MinTimeBetweenPings, "Maximum frequency that clients may send pings at")
 	fs.BoolVar(&f.PingWithoutStreamAllowed, "server.grpc.keepalive.ping-without-stream-allowed", d.PingWithoutStreamAllowed, "Allow clients to send pings without having a gRPC stream")
+	fs.IntVar(&f.InitialWindowSize, "server.grpc.initial-window-size-bytes", d.InitialWindowSize, "Initial HTTP/2 flow control window size in bytes of gRPC streams (0 = sized dynamically)")
+	fs.IntVar(&f.InitialConnWindowSize, "server.grpc.initial-conn-window-size-bytes", d.InitialConnWindowSize, "Initial HTTP/2 flow control window size in bytes of gRPC connections (0 = sized dynamically)")
 	fs.StringVar(&f.InMemoryAddr, "server.grpc.in-memory-addr", d.InMemoryAddr, "Address used to internally make in-memory requests to the gRPC server. Override if it collides with a real URL.")
 }
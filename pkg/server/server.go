@@ -302,6 +302,12 @@ func newGRPCServer(l logging.Interface, opts *GRPCFlags, m *metrics) *grpc.Serve
 		grpc.MaxConcurrentStreams(uint32(opts.MaxConcurrentStreams)),
 		grpc.StatsHandler(middleware.NewStatsHandler(m.receivedMessageSize, m.sentMessageSize, m.inflightRequests)),
 	}
+	if opts.InitialWindowSize > 0 {
+		grpcOptions = append(grpcOptions, grpc.InitialWindowSize(int32(opts.InitialWindowSize)))
+	}
+	if opts.InitialConnWindowSize > 0 {
+		grpcOptions = append(grpcOptions, grpc.InitialConnWindowSize(int32(opts.InitialConnWindowSize)))
+	}
 
 	return grpc.NewServer(grpcOptions...)
 }
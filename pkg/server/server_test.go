@@ -38,6 +38,25 @@ func TestServer(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestServer_GRPCWindowSizes(t *testing.T) {
+	cfg := newTestConfig()
+	flags := newTestFlags()
+	flags.GRPC.InitialWindowSize = 1 << 20
+	flags.GRPC.InitialConnWindowSize = 4 << 20
+	srv := runExampleServer(t, cfg, flags)
+
+	cc, err := grpc.Dial(
+		srv.GRPCAddress().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithInitialWindowSize(1<<20),
+		grpc.WithInitialConnWindowSize(4<<20),
+	)
+	require.NoError(t, err)
+	defer cc.Close()
+	_, err = grpc_health_v1.NewHealthClient(cc).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+}
+
 func TestServer_InMemory(t *testing.T) {
 	cfg := newTestConfig()
 	flags := newTestFlags()
@@ -42,6 +42,7 @@ import (
 	"github.com/grafana/agent/pkg/traces/pushreceiver"
 	"github.com/grafana/agent/pkg/traces/remotewriteexporter"
 	"github.com/grafana/agent/pkg/traces/servicegraphprocessor"
+	"github.com/grafana/agent/pkg/traces/spanmetricslimitsprocessor"
 	"github.com/grafana/agent/pkg/util"
 )
 
@@ -308,6 +309,10 @@ type SpanMetricsConfig struct {
 	// DimensionsCacheSize defines the size of cache for storing Dimensions, which helps to avoid cache memory growing
 	// indefinitely over the lifetime of the collector.
 	DimensionsCacheSize int `yaml:"dimensions_cache_size"`
+
+	// DimensionLimits limit the values of dimensions, to avoid a single dimension with many distinct values
+	// creating a large number of series.
+	DimensionLimits []spanmetricslimitsprocessor.DimensionLimit `yaml:"dimension_limits,omitempty"`
 }
 
 // tailSamplingConfig is the configuration for tail-based sampling
@@ -676,10 +681,17 @@ func (c *InstanceConfig) otelConfig() (*config.Config, error) {
 		}
 		processors["spanmetrics"] = spanMetrics
 
-		pipelines[spanMetricsPipelineName] = map[string]interface{}{
+		spanMetricsPipeline := map[string]interface{}{
 			"receivers": []string{noopreceiver.TypeStr},
 			"exporters": []string{exporterName},
 		}
+		if len(c.SpanMetrics.DimensionLimits) > 0 {
+			processors[spanmetricslimitsprocessor.TypeStr] = map[string]interface{}{
+				"dimensions": c.SpanMetrics.DimensionLimits,
+			}
+			spanMetricsPipeline["processors"] = []string{spanmetricslimitsprocessor.TypeStr}
+		}
+		pipelines[spanMetricsPipelineName] = spanMetricsPipeline
 	}
 
 	// receivers
@@ -852,6 +864,7 @@ func tracingFactories() (component.Factories, error) {
 		automaticloggingprocessor.NewFactory(),
 		tailsamplingprocessor.NewFactory(),
 		servicegraphprocessor.NewFactory(),
+		spanmetricslimitsprocessor.NewFactory(),
 	)
 	if err != nil {
 		return component.Factories{}, err
@@ -618,6 +618,61 @@ service:
     metrics/spanmetrics:
       exporters: ["prometheus"]
       receivers: ["noop"]
+`,
+		},
+		{
+			name: "span metrics dimension limits",
+			cfg: `
+receivers:
+  jaeger:
+    protocols:
+      grpc:
+remote_write:
+  - endpoint: example.com:12345
+spanmetrics:
+  handler_endpoint: "0.0.0.0:8889"
+  dimensions:
+    - name: http.url
+  dimension_limits:
+    - name: http.url
+      max_values: 100
+`,
+			expectedConfig: `
+receivers:
+  push_receiver: {}
+  noop:
+  jaeger:
+    protocols:
+      grpc:
+exporters:
+  otlp/0:
+    endpoint: example.com:12345
+    compression: gzip
+    retry_on_failure:
+      max_elapsed_time: 60s
+  prometheus:
+    endpoint: "0.0.0.0:8889"
+    namespace: traces_spanmetrics
+processors:
+  spanmetrics:
+    metrics_exporter: prometheus
+    dimensions:
+      - name: http.url
+  spanmetrics_limits:
+    dimensions:
+      - name: http.url
+        max_values: 100
+extensions: {}
+service:
+  pipelines:
+    traces:
+      exporters: ["otlp/0"]
+      processors: ["spanmetrics"]
+      receivers: ["push_receiver", "jaeger"]
+    metrics/spanmetrics:
+      exporters: ["prometheus"]
+      processors: ["spanmetrics_limits"]
+      receivers: ["noop"]
 `,
 		},
 		{
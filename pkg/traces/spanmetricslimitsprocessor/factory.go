@@ -0,0 +1,91 @@
+package spanmetricslimitsprocessor
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/consumer"
+)
+
+const (
+	// TypeStr is the unique identifier for the span metrics limits processor.
+	TypeStr = "spanmetrics_limits"
+
+	// OtherValue replaces the values of a dimension which aren't allowed.
+	OtherValue = "other"
+)
+
+// Config holds the configuration for the span metrics limits processor.
+type Config struct {
+	config.ProcessorSettings `mapstructure:",squash"`
+
+	Dimensions []DimensionLimit `mapstructure:"dimensions"`
+}
+
+// DimensionLimit limits the values of a dimension of span metrics.
+type DimensionLimit struct {
+	// Name of the dimension to limit.
+	Name string `mapstructure:"name" yaml:"name"`
+
+	// AllowedValues, if set, are the only values kept for the dimension.
+	// Other values are replaced with OtherValue.
+	AllowedValues []string `mapstructure:"allowed_values" yaml:"allowed_values,omitempty"`
+
+	// DeniedValues are values of the dimension which are replaced with
+	// OtherValue.
+	DeniedValues []string `mapstructure:"denied_values" yaml:"denied_values,omitempty"`
+
+	// MaxValues is the maximum number of distinct values of the dimension.
+	// Once it's exceeded, the dimension is dropped from all metrics. 0
+	// disables the limit.
+	MaxValues int `mapstructure:"max_values" yaml:"max_values,omitempty"`
+}
+
+// Validate implements config.Processor.
+func (c *Config) Validate() error {
+	seen := make(map[string]struct{}, len(c.Dimensions))
+	for _, d := range c.Dimensions {
+		if d.Name == "" {
+			return fmt.Errorf("dimension limit must have a name")
+		}
+		if _, ok := seen[d.Name]; ok {
+			return fmt.Errorf("dimension %q is limited more than once", d.Name)
+		}
+		seen[d.Name] = struct{}{}
+
+		if len(d.AllowedValues) > 0 && len(d.DeniedValues) > 0 {
+			return fmt.Errorf("dimension %q: at most one of allowed_values and denied_values can be set", d.Name)
+		}
+		if d.MaxValues < 0 {
+			return fmt.Errorf("dimension %q: max_values must not be negative", d.Name)
+		}
+	}
+	return nil
+}
+
+// NewFactory returns a new factory for the span metrics limits processor.
+func NewFactory() component.ProcessorFactory {
+	return component.NewProcessorFactory(
+		TypeStr,
+		createDefaultConfig,
+		component.WithMetricsProcessor(createMetricsProcessor, component.StabilityLevelUndefined),
+	)
+}
+
+func createDefaultConfig() config.Processor {
+	return &Config{
+		ProcessorSettings: config.NewProcessorSettings(config.NewComponentIDWithName(TypeStr, TypeStr)),
+	}
+}
+
+func createMetricsProcessor(
+	_ context.Context,
+	_ component.ProcessorCreateSettings,
+	cfg config.Processor,
+	nextConsumer consumer.Metrics,
+) (component.MetricsProcessor, error) {
+
+	return newProcessor(nextConsumer, cfg.(*Config)), nil
+}
@@ -0,0 +1,310 @@
+// Package spanmetricslimitsprocessor limits the values of the dimensions of
+// span metrics, so a single attribute with many distinct values doesn't
+// create a large number of series.
+package spanmetricslimitsprocessor
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	util "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+var _ component.MetricsProcessor = (*processor)(nil)
+
+type processor struct {
+	nextConsumer consumer.Metrics
+	logger       log.Logger
+
+	mut    sync.Mutex
+	limits map[string]*dimensionState
+}
+
+// dimensionState tracks the values of a limited dimension.
+type dimensionState struct {
+	limit   DimensionLimit
+	allowed map[string]struct{}
+	denied  map[string]struct{}
+
+	// Distinct values seen so far, until the dimension is dropped.
+	seen    map[string]struct{}
+	dropped bool
+}
+
+func newProcessor(nextConsumer consumer.Metrics, cfg *Config) *processor {
+	limits := make(map[string]*dimensionState, len(cfg.Dimensions))
+	for _, d := range cfg.Dimensions {
+		limits[d.Name] = &dimensionState{
+			limit:   d,
+			allowed: toSet(d.AllowedValues),
+			denied:  toSet(d.DeniedValues),
+			seen:    make(map[string]struct{}),
+		}
+	}
+
+	return &processor{
+		nextConsumer: nextConsumer,
+		logger:       log.With(util.Logger, "component", "spanmetrics limits"),
+		limits:       limits,
+	}
+}
+
+func toSet(values []string) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// filter returns the value v is replaced with, applying the allow and deny
+// lists of the dimension.
+func (ds *dimensionState) filter(v string) string {
+	if ds.allowed != nil {
+		if _, ok := ds.allowed[v]; !ok {
+			return OtherValue
+		}
+	}
+	if _, ok := ds.denied[v]; ok {
+		return OtherValue
+	}
+	return v
+}
+
+// observe records v as a value of the dimension, dropping the dimension if
+// it has more than MaxValues distinct values. It returns true if the
+// dimension was dropped by this call.
+func (ds *dimensionState) observe(v string) bool {
+	if ds.dropped || ds.limit.MaxValues == 0 {
+		return false
+	}
+	if _, ok := ds.seen[v]; ok {
+		return false
+	}
+	ds.seen[v] = struct{}{}
+	if len(ds.seen) <= ds.limit.MaxValues {
+		return false
+	}
+
+	// The seen values are no longer needed once the dimension is dropped.
+	ds.dropped = true
+	ds.seen = nil
+	return true
+}
+
+func (p *processor) Start(_ context.Context, _ component.Host) error { return nil }
+
+func (p *processor) Shutdown(_ context.Context) error { return nil }
+
+func (p *processor) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: true}
+}
+
+func (p *processor) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	p.mut.Lock()
+	p.observe(md)
+	p.apply(md)
+	p.mut.Unlock()
+
+	return p.nextConsumer.ConsumeMetrics(ctx, md)
+}
+
+// observe tracks the values of the limited dimensions in md. Values are
+// observed for all of md before limits are applied, so a dimension dropped
+// part way through md is dropped from all of its metrics.
+func (p *processor) observe(md pmetric.Metrics) {
+	forEachAttributes(md, func(attrs pcommon.Map) {
+		for name, ds := range p.limits {
+			v, ok := attrs.Get(name)
+			if !ok {
+				continue
+			}
+			if ds.observe(ds.filter(v.AsString())) {
+				level.Warn(p.logger).Log("msg", "dimension exceeded its maximum number of values and is dropped from span metrics", "dimension", name, "max_values", ds.limit.MaxValues)
+			}
+		}
+	})
+}
+
+// apply applies the limits to the attributes of md, and merges data points
+// of the same metric whose attributes became identical.
+func (p *processor) apply(md pmetric.Metrics) {
+	forEachAttributes(md, func(attrs pcommon.Map) {
+		for name, ds := range p.limits {
+			v, ok := attrs.Get(name)
+			if !ok {
+				continue
+			}
+			if ds.dropped {
+				attrs.Remove(name)
+				continue
+			}
+			if filtered := ds.filter(v.AsString()); filtered != v.AsString() {
+				attrs.PutStr(name, filtered)
+			}
+		}
+	})
+
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			mergeMetrics(sms.At(j).Metrics())
+		}
+	}
+}
+
+// forEachAttributes calls f with the attributes of every sum and histogram
+// data point in md. Other types of metrics aren't produced for span metrics
+// and are left untouched.
+func forEachAttributes(md pmetric.Metrics, f func(pcommon.Map)) {
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			ms := sms.At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				m := ms.At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					dps := m.Sum().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						f(dps.At(l).Attributes())
+					}
+				case pmetric.MetricTypeHistogram:
+					dps := m.Histogram().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						f(dps.At(l).Attributes())
+					}
+				}
+			}
+		}
+	}
+}
+
+// mergeMetrics merges the data points of sums and histograms in ms which
+// have the same name and attributes into the first of them. Metrics left
+// without data points are removed.
+func mergeMetrics(ms pmetric.MetricSlice) {
+	var (
+		sums       = make(map[string]pmetric.NumberDataPoint)
+		histograms = make(map[string]pmetric.HistogramDataPoint)
+	)
+
+	for k := 0; k < ms.Len(); k++ {
+		m := ms.At(k)
+		switch m.Type() {
+		case pmetric.MetricTypeSum:
+			m.Sum().DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+				key := seriesKey(m.Name(), dp.Attributes())
+				into, ok := sums[key]
+				if !ok {
+					sums[key] = dp
+					return false
+				}
+				mergeNumberDataPoint(into, dp)
+				return true
+			})
+		case pmetric.MetricTypeHistogram:
+			m.Histogram().DataPoints().RemoveIf(func(dp pmetric.HistogramDataPoint) bool {
+				key := seriesKey(m.Name(), dp.Attributes())
+				into, ok := histograms[key]
+				if !ok {
+					histograms[key] = dp
+					return false
+				}
+				if !equalBounds(into.ExplicitBounds(), dp.ExplicitBounds()) {
+					// Histograms with different buckets can't be merged.
+					return false
+				}
+				mergeHistogramDataPoint(into, dp)
+				return true
+			})
+		}
+	}
+
+	ms.RemoveIf(func(m pmetric.Metric) bool {
+		switch m.Type() {
+		case pmetric.MetricTypeSum:
+			return m.Sum().DataPoints().Len() == 0
+		case pmetric.MetricTypeHistogram:
+			return m.Histogram().DataPoints().Len() == 0
+		default:
+			return false
+		}
+	})
+}
+
+// seriesKey returns a key identifying the series of a data point.
+func seriesKey(name string, attrs pcommon.Map) string {
+	pairs := make([]string, 0, attrs.Len())
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		pairs = append(pairs, k+"\xff"+v.AsString())
+		return true
+	})
+	sort.Strings(pairs)
+	return name + "\xfe" + strings.Join(pairs, "\xfe")
+}
+
+func mergeNumberDataPoint(into, from pmetric.NumberDataPoint) {
+	switch into.ValueType() {
+	case pmetric.NumberDataPointValueTypeInt:
+		into.SetIntValue(into.IntValue() + from.IntValue())
+	case pmetric.NumberDataPointValueTypeDouble:
+		into.SetDoubleValue(into.DoubleValue() + from.DoubleValue())
+	}
+	mergeTimestamps(into, from)
+	from.Exemplars().MoveAndAppendTo(into.Exemplars())
+}
+
+func equalBounds(a, b pcommon.Float64Slice) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+	for i := 0; i < a.Len(); i++ {
+		if a.At(i) != b.At(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeHistogramDataPoint(into, from pmetric.HistogramDataPoint) {
+	buckets := into.BucketCounts()
+	for i := 0; i < buckets.Len() && i < from.BucketCounts().Len(); i++ {
+		buckets.SetAt(i, buckets.At(i)+from.BucketCounts().At(i))
+	}
+	into.SetCount(into.Count() + from.Count())
+	into.SetSum(into.Sum() + from.Sum())
+	mergeTimestamps(into, from)
+	from.Exemplars().MoveAndAppendTo(into.Exemplars())
+}
+
+type timestamped interface {
+	StartTimestamp() pcommon.Timestamp
+	SetStartTimestamp(pcommon.Timestamp)
+	Timestamp() pcommon.Timestamp
+	SetTimestamp(pcommon.Timestamp)
+}
+
+// mergeTimestamps sets the time range of into to cover the time ranges of
+// both data points.
+func mergeTimestamps(into, from timestamped) {
+	if from.StartTimestamp() < into.StartTimestamp() {
+		into.SetStartTimestamp(from.StartTimestamp())
+	}
+	if from.Timestamp() > into.Timestamp() {
+		into.SetTimestamp(from.Timestamp())
+	}
+}
@@ -0,0 +1,187 @@
+package spanmetricslimitsprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// spanMetrics builds metrics shaped like the output of the spanmetrics
+// processor, with one calls_total and one latency metric per value of the
+// http.url attribute.
+func spanMetrics(urls ...string) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	ms := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+
+	for _, url := range urls {
+		calls := ms.AppendEmpty()
+		calls.SetName("calls_total")
+		calls.SetEmptySum().SetIsMonotonic(true)
+		dp := calls.Sum().DataPoints().AppendEmpty()
+		dp.SetIntValue(2)
+		dp.Attributes().PutStr("service.name", "app")
+		dp.Attributes().PutStr("http.url", url)
+
+		latency := ms.AppendEmpty()
+		latency.SetName("latency")
+		latency.SetEmptyHistogram()
+		hdp := latency.Histogram().DataPoints().AppendEmpty()
+		hdp.ExplicitBounds().FromRaw([]float64{10})
+		hdp.BucketCounts().FromRaw([]uint64{1, 1})
+		hdp.SetCount(2)
+		hdp.SetSum(25)
+		hdp.Attributes().PutStr("service.name", "app")
+		hdp.Attributes().PutStr("http.url", url)
+	}
+	return md
+}
+
+// series returns the http.url attribute and value of every data point in md,
+// keyed by metric name.
+func series(t *testing.T, md pmetric.Metrics) map[string]map[string]float64 {
+	res := map[string]map[string]float64{}
+	ms := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < ms.Len(); i++ {
+		m := ms.At(i)
+		if res[m.Name()] == nil {
+			res[m.Name()] = map[string]float64{}
+		}
+		switch m.Type() {
+		case pmetric.MetricTypeSum:
+			for j := 0; j < m.Sum().DataPoints().Len(); j++ {
+				dp := m.Sum().DataPoints().At(j)
+				res[m.Name()][urlOf(dp.Attributes())] = float64(dp.IntValue())
+			}
+		case pmetric.MetricTypeHistogram:
+			for j := 0; j < m.Histogram().DataPoints().Len(); j++ {
+				dp := m.Histogram().DataPoints().At(j)
+				require.Equal(t, dp.Count(), dp.BucketCounts().At(0)+dp.BucketCounts().At(1))
+				res[m.Name()][urlOf(dp.Attributes())] = float64(dp.Count())
+			}
+		}
+	}
+	return res
+}
+
+func urlOf(attrs pcommon.Map) string {
+	if v, ok := attrs.Get("http.url"); ok {
+		return v.AsString()
+	}
+	return ""
+}
+
+func TestProcessor(t *testing.T) {
+	tt := []struct {
+		name   string
+		limit  DimensionLimit
+		input  []string
+		expect map[string]float64
+	}{
+		{
+			name:   "no limits",
+			limit:  DimensionLimit{Name: "http.url"},
+			input:  []string{"/a", "/b"},
+			expect: map[string]float64{"/a": 2, "/b": 2},
+		},
+		{
+			name:   "allowed values",
+			limit:  DimensionLimit{Name: "http.url", AllowedValues: []string{"/a"}},
+			input:  []string{"/a", "/b", "/c"},
+			expect: map[string]float64{"/a": 2, OtherValue: 4},
+		},
+		{
+			name:   "denied values",
+			limit:  DimensionLimit{Name: "http.url", DeniedValues: []string{"/a"}},
+			input:  []string{"/a", "/b"},
+			expect: map[string]float64{OtherValue: 2, "/b": 2},
+		},
+		{
+			name:   "max values not exceeded",
+			limit:  DimensionLimit{Name: "http.url", MaxValues: 2},
+			input:  []string{"/a", "/b", "/a"},
+			expect: map[string]float64{"/a": 4, "/b": 2},
+		},
+		{
+			// The dimension is dropped from all series, and the series are merged.
+			name:   "max values exceeded",
+			limit:  DimensionLimit{Name: "http.url", MaxValues: 2},
+			input:  []string{"/a", "/b", "/c"},
+			expect: map[string]float64{"": 6},
+		},
+		{
+			// Values replaced by the allow list only count once.
+			name:   "max values after allowed values",
+			limit:  DimensionLimit{Name: "http.url", AllowedValues: []string{"/a"}, MaxValues: 2},
+			input:  []string{"/a", "/b", "/c"},
+			expect: map[string]float64{"/a": 2, OtherValue: 4},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{Dimensions: []DimensionLimit{tc.limit}}
+			require.NoError(t, cfg.Validate())
+
+			sink := new(consumertest.MetricsSink)
+			p := newProcessor(sink, cfg)
+
+			require.NoError(t, p.ConsumeMetrics(context.Background(), spanMetrics(tc.input...)))
+			require.Len(t, sink.AllMetrics(), 1)
+
+			res := series(t, sink.AllMetrics()[0])
+			require.Equal(t, tc.expect, res["calls_total"])
+			require.Equal(t, tc.expect, res["latency"])
+		})
+	}
+}
+
+func TestProcessor_DroppedDimensionStaysDropped(t *testing.T) {
+	sink := new(consumertest.MetricsSink)
+	p := newProcessor(sink, &Config{Dimensions: []DimensionLimit{{Name: "http.url", MaxValues: 1}}})
+
+	require.NoError(t, p.ConsumeMetrics(context.Background(), spanMetrics("/a", "/b")))
+	require.NoError(t, p.ConsumeMetrics(context.Background(), spanMetrics("/a")))
+
+	require.Len(t, sink.AllMetrics(), 2)
+	require.Equal(t, map[string]float64{"": 2}, series(t, sink.AllMetrics()[1])["calls_total"])
+}
+
+func TestConfigValidate(t *testing.T) {
+	tt := []struct {
+		name        string
+		dimensions  []DimensionLimit
+		expectedErr string
+	}{
+		{
+			name:        "missing name",
+			dimensions:  []DimensionLimit{{MaxValues: 1}},
+			expectedErr: "dimension limit must have a name",
+		},
+		{
+			name:        "duplicate name",
+			dimensions:  []DimensionLimit{{Name: "a"}, {Name: "a"}},
+			expectedErr: `dimension "a" is limited more than once`,
+		},
+		{
+			name:        "allowed and denied values",
+			dimensions:  []DimensionLimit{{Name: "a", AllowedValues: []string{"x"}, DeniedValues: []string{"y"}}},
+			expectedErr: "at most one of allowed_values and denied_values can be set",
+		},
+		{
+			name:        "negative max values",
+			dimensions:  []DimensionLimit{{Name: "a", MaxValues: -1}},
+			expectedErr: "max_values must not be negative",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{Dimensions: tc.dimensions}
+			require.ErrorContains(t, cfg.Validate(), tc.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,237 @@
+// Package upgrade checks for new releases of Grafana Agent and can upgrade the
+// running binary in place for installations which aren't managed by a
+// package manager.
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/mod/semver"
+)
+
+// DefaultReleasesURL is the GitHub API endpoint which returns the latest
+// release of Grafana Agent.
+const DefaultReleasesURL = "https://api.github.com/repos/grafana/agent/releases/latest"
+
+// Release is a published release of Grafana Agent.
+type Release struct {
+	Version string  `json:"tag_name"`
+	URL     string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a downloadable file attached to a Release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Status reports the running version of the agent against the latest
+// release.
+type Status struct {
+	CurrentVersion string `json:"current_version"`
+	LatestVersion  string `json:"latest_version,omitempty"`
+	ReleaseURL     string `json:"release_url,omitempty"`
+
+	// UpdateAvailable is true if LatestVersion is newer than CurrentVersion.
+	// It is always false for development builds which don't have a valid
+	// version.
+	UpdateAvailable bool `json:"update_available"`
+
+	LastChecked *time.Time `json:"last_checked,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// CheckerOptions configures a Checker.
+type CheckerOptions struct {
+	// ReleasesURL returns the latest release in the GitHub releases API
+	// format.
+	ReleasesURL string
+	// Interval between checks.
+	Interval time.Duration
+	// Client to make requests with. http.DefaultClient is used if nil.
+	Client *http.Client
+}
+
+// DefaultCheckerOptions holds default options for a Checker.
+var DefaultCheckerOptions = CheckerOptions{
+	ReleasesURL: DefaultReleasesURL,
+	Interval:    6 * time.Hour,
+}
+
+// Checker periodically checks for the latest release of Grafana Agent.
+type Checker struct {
+	log     log.Logger
+	opts    CheckerOptions
+	current string
+
+	latestInfo     *prometheus.GaugeVec
+	available      prometheus.Gauge
+	checkFailures  prometheus.Counter
+	lastCheckedSec prometheus.Gauge
+
+	mut    sync.RWMutex
+	latest *Release
+	status Status
+}
+
+// NewChecker creates a new Checker. Metrics for the Checker are registered to
+// reg.
+func NewChecker(l log.Logger, reg prometheus.Registerer, opts CheckerOptions) (*Checker, error) {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	c := &Checker{
+		log:     l,
+		opts:    opts,
+		current: build.Version,
+		status:  Status{CurrentVersion: build.Version},
+
+		latestInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_upgrade_latest_release_info",
+			Help: "Set to 1 with the version of the latest available release as a label.",
+		}, []string{"version"}),
+		available: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_upgrade_available",
+			Help: "Set to 1 if the latest available release is newer than the running version.",
+		}),
+		checkFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_upgrade_check_failures_total",
+			Help: "Total number of failed checks for the latest release.",
+		}),
+		lastCheckedSec: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_upgrade_last_check_timestamp_seconds",
+			Help: "Timestamp of the last successful check for the latest release.",
+		}),
+	}
+
+	for _, m := range []prometheus.Collector{c.latestInfo, c.available, c.checkFailures, c.lastCheckedSec} {
+		if err := reg.Register(m); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Run checks for the latest release immediately and then every interval
+// until ctx is canceled.
+func (c *Checker) Run(ctx context.Context) {
+	t := time.NewTicker(c.opts.Interval)
+	defer t.Stop()
+
+	for {
+		if err := c.Check(ctx); err != nil {
+			level.Warn(c.log).Log("msg", "failed to check for the latest release", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Check retrieves the latest release and updates the status of c.
+func (c *Checker) Check(ctx context.Context) error {
+	rel, err := c.fetchLatest(ctx)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if err != nil {
+		c.checkFailures.Inc()
+		c.status.Error = err.Error()
+		return err
+	}
+
+	now := time.Now()
+	c.latest = rel
+	c.status = Status{
+		CurrentVersion:  c.current,
+		LatestVersion:   rel.Version,
+		ReleaseURL:      rel.URL,
+		UpdateAvailable: newer(rel.Version, c.current),
+		LastChecked:     &now,
+	}
+
+	c.latestInfo.Reset()
+	c.latestInfo.WithLabelValues(rel.Version).Set(1)
+	if c.status.UpdateAvailable {
+		c.available.Set(1)
+	} else {
+		c.available.Set(0)
+	}
+	c.lastCheckedSec.Set(float64(now.Unix()))
+	return nil
+}
+
+func (c *Checker) fetchLatest(ctx context.Context) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.opts.ReleasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", fmt.Sprintf("GrafanaAgent/%s", build.Version))
+
+	resp, err := c.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, c.opts.ReleasesURL)
+	}
+
+	var rel Release
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return nil, fmt.Errorf("decoding release: %w", err)
+	}
+	if rel.Version == "" {
+		return nil, fmt.Errorf("release from %s has no version", c.opts.ReleasesURL)
+	}
+	return &rel, nil
+}
+
+// Status returns the current status of c.
+func (c *Checker) Status() Status {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.status
+}
+
+// Latest returns the latest release found by c, or nil if no check succeeded
+// yet.
+func (c *Checker) Latest() *Release {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.latest
+}
+
+// Handler returns an http.Handler which writes the status of c as JSON.
+func (c *Checker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.Status())
+	})
+}
+
+// newer returns true if latest is a newer version than current. Versions
+// which aren't valid semantic versions are never considered newer.
+func newer(latest, current string) bool {
+	if !semver.IsValid(latest) || !semver.IsValid(current) {
+		return false
+	}
+	return semver.Compare(latest, current) > 0
+}
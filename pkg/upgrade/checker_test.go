@@ -0,0 +1,64 @@
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Release{Version: "v0.40.0", URL: "https://example.com/v0.40.0"})
+	}))
+	defer srv.Close()
+
+	c, err := NewChecker(log.NewNopLogger(), prometheus.NewRegistry(), CheckerOptions{ReleasesURL: srv.URL})
+	require.NoError(t, err)
+	c.current = "v0.39.1"
+
+	require.NoError(t, c.Check(context.Background()))
+
+	status := c.Status()
+	require.Equal(t, "v0.40.0", status.LatestVersion)
+	require.Equal(t, "https://example.com/v0.40.0", status.ReleaseURL)
+	require.True(t, status.UpdateAvailable)
+	require.Empty(t, status.Error)
+	require.Equal(t, "v0.40.0", c.Latest().Version)
+}
+
+func TestChecker_Failure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	c, err := NewChecker(log.NewNopLogger(), prometheus.NewRegistry(), CheckerOptions{ReleasesURL: srv.URL})
+	require.NoError(t, err)
+
+	require.Error(t, c.Check(context.Background()))
+	require.Contains(t, c.Status().Error, "unexpected status code 403")
+	require.Nil(t, c.Latest())
+}
+
+func TestNewer(t *testing.T) {
+	tt := []struct {
+		latest, current string
+		expect          bool
+	}{
+		{"v0.40.0", "v0.39.1", true},
+		{"v0.39.1", "v0.39.1", false},
+		{"v0.39.0", "v0.39.1", false},
+		{"v0.40.0", "v0.40.0-rc.0", true},
+		{"v0.40.0", "main-5d4ec0e", false},
+		{"v0.40.0", "", false},
+	}
+	for _, tc := range tt {
+		require.Equal(t, tc.expect, newer(tc.latest, tc.current), "latest=%s current=%s", tc.latest, tc.current)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+// +build !windows
+
+package upgrade
+
+import (
+	"os"
+	"syscall"
+)
+
+func execBinary(path string) error {
+	return syscall.Exec(path, os.Args, os.Environ())
+}
@@ -0,0 +1,8 @@
+//go:build windows
+// +build windows
+
+package upgrade
+
+func execBinary(path string) error {
+	return errUnsupported
+}
@@ -0,0 +1,279 @@
+package upgrade
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/util/signature"
+)
+
+const (
+	checksumsAsset = "SHA256SUMS"
+	signatureAsset = "SHA256SUMS.sig"
+
+	// maxDownloadSize limits how much is downloaded for a single asset.
+	maxDownloadSize = 512 << 20
+)
+
+// UpgraderOptions configures an Upgrader.
+type UpgraderOptions struct {
+	// PublicKey verifies the signature of the SHA256SUMS asset of a release.
+	PublicKey ed25519.PublicKey
+	// AssetName is the name of the binary to install. The release must have
+	// an asset named AssetName + ".zip" which contains a file named
+	// AssetName.
+	AssetName string
+	// Executable is the path of the binary to replace.
+	Executable string
+	// Client to make requests with. http.DefaultClient is used if nil.
+	Client *http.Client
+}
+
+// ErrInProgress is returned by Upgrader.Stage when another upgrade is being
+// staged.
+var ErrInProgress = errors.New("an upgrade is already in progress")
+
+// DefaultAssetName returns the name of the release binary for the current
+// platform.
+func DefaultAssetName() string {
+	name := fmt.Sprintf("grafana-agent-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Upgrader replaces the running binary with the binary from a release.
+type Upgrader struct {
+	log  log.Logger
+	opts UpgraderOptions
+
+	mut     sync.Mutex
+	staging bool // Set while an upgrade is being staged
+}
+
+// NewUpgrader creates a new Upgrader.
+func NewUpgrader(l log.Logger, opts UpgraderOptions) (*Upgrader, error) {
+	if runtime.GOOS == "windows" {
+		return nil, fmt.Errorf("managed upgrades are not supported on Windows")
+	}
+	if len(opts.PublicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("a valid ed25519 public key is required for managed upgrades")
+	}
+	if opts.AssetName == "" {
+		opts.AssetName = DefaultAssetName()
+	}
+	if opts.Executable == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("finding executable: %w", err)
+		}
+		opts.Executable = exe
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	return &Upgrader{log: l, opts: opts}, nil
+}
+
+// Stage downloads the binary from rel, verifies it against the signed
+// checksums of the release, and replaces the executable with it. The new
+// binary takes effect once the process calls Exec.
+//
+// Only one upgrade is staged at a time; Stage returns ErrInProgress if it's
+// called while another call to Stage is running.
+func (u *Upgrader) Stage(ctx context.Context, rel *Release) error {
+	u.mut.Lock()
+	if u.staging {
+		u.mut.Unlock()
+		return ErrInProgress
+	}
+	u.staging = true
+	u.mut.Unlock()
+
+	defer func() {
+		u.mut.Lock()
+		u.staging = false
+		u.mut.Unlock()
+	}()
+
+	return u.stage(ctx, rel)
+}
+
+func (u *Upgrader) stage(ctx context.Context, rel *Release) error {
+	var (
+		archiveName = u.opts.AssetName + ".zip"
+
+		archive, checksums, sigAsset *Asset
+	)
+	for i, a := range rel.Assets {
+		switch a.Name {
+		case archiveName:
+			archive = &rel.Assets[i]
+		case checksumsAsset:
+			checksums = &rel.Assets[i]
+		case signatureAsset:
+			sigAsset = &rel.Assets[i]
+		}
+	}
+	switch {
+	case archive == nil:
+		return fmt.Errorf("release %s has no asset %s", rel.Version, archiveName)
+	case checksums == nil || sigAsset == nil:
+		return fmt.Errorf("release %s is not signed: missing %s or %s", rel.Version, checksumsAsset, signatureAsset)
+	}
+
+	sums, err := u.download(ctx, checksums)
+	if err != nil {
+		return err
+	}
+	sig, err := u.download(ctx, sigAsset)
+	if err != nil {
+		return err
+	}
+	if err := signature.Verify(u.opts.PublicKey, sums, sig); err != nil {
+		return fmt.Errorf("verifying %s: %w", checksumsAsset, err)
+	}
+
+	expect, err := findChecksum(sums, archiveName)
+	if err != nil {
+		return err
+	}
+	bb, err := u.download(ctx, archive)
+	if err != nil {
+		return err
+	}
+	if actual := sha256.Sum256(bb); !bytes.Equal(actual[:], expect) {
+		return fmt.Errorf("checksum mismatch for %s", archiveName)
+	}
+
+	bin, err := extractFile(bb, u.opts.AssetName)
+	if err != nil {
+		return err
+	}
+	if err := replaceFile(u.opts.Executable, bin); err != nil {
+		return err
+	}
+
+	level.Info(u.log).Log("msg", "staged upgrade", "version", rel.Version, "path", u.opts.Executable)
+	return nil
+}
+
+// Executable returns the path of the binary which is replaced by Stage.
+func (u *Upgrader) Executable() string {
+	return u.opts.Executable
+}
+
+// Exec replaces the current process with the staged binary, passing through
+// the original arguments and environment.
+func (u *Upgrader) Exec() error {
+	return execBinary(u.opts.Executable)
+}
+
+func (u *Upgrader) download(ctx context.Context, a *Asset) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.opts.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", a.Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: unexpected status code %d", a.Name, resp.StatusCode)
+	}
+
+	bb, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", a.Name, err)
+	} else if len(bb) > maxDownloadSize {
+		return nil, fmt.Errorf("downloading %s: asset is too large", a.Name)
+	}
+	return bb, nil
+}
+
+// findChecksum finds the checksum of name in the output of sha256sum.
+func findChecksum(sums []byte, name string) ([]byte, error) {
+	s := bufio.NewScanner(bytes.NewReader(sums))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		return hex.DecodeString(fields[0])
+	}
+	return nil, fmt.Errorf("%s has no checksum for %s", checksumsAsset, name)
+}
+
+func extractFile(archive []byte, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("opening archive: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(io.LimitReader(rc, maxDownloadSize))
+	}
+	return nil, fmt.Errorf("archive does not contain %s", name)
+}
+
+// replaceFile atomically replaces path with contents, keeping the file mode
+// of the existing file.
+func replaceFile(path string, contents []byte) (err error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".upgrade-")
+	if err != nil {
+		return fmt.Errorf("creating staging file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	_, err = f.Write(contents)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing staging file: %w", err)
+	}
+	if err := os.Chmod(f.Name(), fi.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// errUnsupported is returned by Exec on platforms where the process can't be
+// replaced.
+var errUnsupported = errors.New("replacing the running process is not supported on this platform")
@@ -0,0 +1,156 @@
+package upgrade
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgrader_Stage(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	exe := filepath.Join(t.TempDir(), "grafana-agent")
+	require.NoError(t, os.WriteFile(exe, []byte("old"), 0755))
+
+	newRelease := func(t *testing.T, signer ed25519.PrivateKey, binary string) *Release {
+		archive := zipFile(t, "grafana-agent-test", []byte(binary))
+		sum := sha256.Sum256(archive)
+		sums := []byte(fmt.Sprintf("%x  grafana-agent-test.zip\n", sum))
+
+		files := map[string][]byte{
+			"grafana-agent-test.zip": archive,
+			"SHA256SUMS":             sums,
+			"SHA256SUMS.sig":         ed25519.Sign(signer, sums),
+		}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bb, ok := files[r.URL.Path[1:]]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(bb)
+		}))
+		t.Cleanup(srv.Close)
+
+		rel := &Release{Version: "v0.40.0"}
+		for name := range files {
+			rel.Assets = append(rel.Assets, Asset{Name: name, URL: srv.URL + "/" + name})
+		}
+		return rel
+	}
+
+	u, err := NewUpgrader(log.NewNopLogger(), UpgraderOptions{
+		PublicKey:  pub,
+		AssetName:  "grafana-agent-test",
+		Executable: exe,
+	})
+	require.NoError(t, err)
+
+	t.Run("invalid signature", func(t *testing.T) {
+		_, otherKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+
+		err = u.Stage(context.Background(), newRelease(t, otherKey, "new"))
+		require.EqualError(t, err, "verifying SHA256SUMS: invalid signature")
+
+		bb, err := os.ReadFile(exe)
+		require.NoError(t, err)
+		require.Equal(t, "old", string(bb))
+	})
+
+	t.Run("valid signature", func(t *testing.T) {
+		require.NoError(t, u.Stage(context.Background(), newRelease(t, priv, "new")))
+
+		bb, err := os.ReadFile(exe)
+		require.NoError(t, err)
+		require.Equal(t, "new", string(bb))
+
+		fi, err := os.Stat(exe)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+	})
+
+	t.Run("unsigned release", func(t *testing.T) {
+		rel := newRelease(t, priv, "new")
+
+		var assets []Asset
+		for _, a := range rel.Assets {
+			if a.Name != "SHA256SUMS.sig" {
+				assets = append(assets, a)
+			}
+		}
+		rel.Assets = assets
+
+		err := u.Stage(context.Background(), rel)
+		require.EqualError(t, err, "release v0.40.0 is not signed: missing SHA256SUMS or SHA256SUMS.sig")
+	})
+}
+
+func TestUpgrader_StageInProgress(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	// The server blocks downloads until release is closed.
+	var (
+		requested = make(chan struct{}, 1)
+		release   = make(chan struct{})
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-release
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	rel := &Release{Version: "v0.40.0"}
+	for _, name := range []string{"grafana-agent-test.zip", "SHA256SUMS", "SHA256SUMS.sig"} {
+		rel.Assets = append(rel.Assets, Asset{Name: name, URL: srv.URL + "/" + name})
+	}
+
+	u, err := NewUpgrader(log.NewNopLogger(), UpgraderOptions{
+		PublicKey:  pub,
+		AssetName:  "grafana-agent-test",
+		Executable: filepath.Join(t.TempDir(), "grafana-agent"),
+	})
+	require.NoError(t, err)
+
+	staged := make(chan error, 1)
+	go func() { staged <- u.Stage(context.Background(), rel) }()
+	<-requested
+
+	require.ErrorIs(t, u.Stage(context.Background(), rel), ErrInProgress)
+
+	close(release)
+	require.ErrorContains(t, <-staged, "404")
+
+	// Upgrades can be staged again once the previous one finished.
+	require.NotErrorIs(t, u.Stage(context.Background(), rel), ErrInProgress)
+}
+
+func zipFile(t *testing.T, name string, contents []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	require.NoError(t, err)
+	_, err = w.Write(contents)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
@@ -0,0 +1,61 @@
+// Package signature verifies detached ed25519 signatures of downloaded files,
+// such as releases and remote configuration.
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParsePublicKey parses an ed25519 public key, either PEM-encoded as a
+// PKIX "PUBLIC KEY" block or as a base64-encoded raw key.
+func ParsePublicKey(bb []byte) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode(bb); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key: %w", err)
+		}
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("public key is %T, not ed25519", key)
+		}
+		return edKey, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(bb)))
+	if err != nil {
+		return nil, fmt.Errorf("decoding public key: %w", err)
+	} else if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes, got %d", ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// ReadPublicKey reads and parses the ed25519 public key at path.
+func ReadPublicKey(path string) (ed25519.PublicKey, error) {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading public key: %w", err)
+	}
+	return ParsePublicKey(bb)
+}
+
+// Verify verifies a raw or base64-encoded signature of msg.
+func Verify(key ed25519.PublicKey, msg, sig []byte) error {
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil {
+			return fmt.Errorf("decoding signature: %w", err)
+		}
+		sig = decoded
+	}
+	if !ed25519.Verify(key, msg, sig) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
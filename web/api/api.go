@@ -7,6 +7,7 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"path"
 
@@ -14,6 +15,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/componentdocs"
 )
 
 // FlowAPI is a wrapper around the component API.
@@ -30,6 +32,9 @@ func NewFlowAPI(flow *flow.Flow, r *mux.Router) *FlowAPI {
 func (f *FlowAPI) RegisterRoutes(urlPrefix string, r *mux.Router) {
 	r.Handle(path.Join(urlPrefix, "/components"), httputil.CompressionHandler{Handler: f.listComponentsHandler()})
 	r.Handle(path.Join(urlPrefix, "/components/{id}"), httputil.CompressionHandler{Handler: f.listComponentHandler()})
+	r.Handle(path.Join(urlPrefix, "/graph"), httputil.CompressionHandler{Handler: f.graphHandler()})
+	r.Handle(path.Join(urlPrefix, "/schema"), httputil.CompressionHandler{Handler: f.listSchemasHandler()})
+	r.Handle(path.Join(urlPrefix, "/schema/{name}"), httputil.CompressionHandler{Handler: f.schemaHandler()})
 }
 
 func (f *FlowAPI) listComponentsHandler() http.HandlerFunc {
@@ -66,6 +71,63 @@ func (f *FlowAPI) listComponentHandler() http.HandlerFunc {
 	}
 }
 
+// graphHandler returns the component graph. The graph is encoded as JSON
+// unless the format query parameter is set to "dot".
+func (f *FlowAPI) graphHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gi := f.flow.GraphInfo()
+
+		switch format := r.URL.Query().Get("format"); format {
+		case "", "json":
+			bb, err := json.Marshal(gi)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(bb)
+		case "dot":
+			w.Header().Set("Content-Type", "text/vnd.graphviz")
+			_ = gi.WriteDOT(w)
+		default:
+			http.Error(w, fmt.Sprintf("unsupported format %q", format), http.StatusBadRequest)
+		}
+	}
+}
+
+// listSchemasHandler returns the arguments and exports of every registered
+// component, for use by editor tooling such as language servers.
+func (f *FlowAPI) listSchemasHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		bb, err := json.Marshal(componentdocs.All())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bb)
+	}
+}
+
+// schemaHandler returns the arguments and exports of a single registered
+// component by name.
+func (f *FlowAPI) schemaHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, ok := componentdocs.Get(mux.Vars(r)["name"])
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		bb, err := json.Marshal(c)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(bb)
+	}
+}
+
 // json returns the JSON representation of c.
 func (f *FlowAPI) json(c *flow.ComponentInfo) ([]byte, error) {
 	var buf bytes.Buffer